@@ -0,0 +1,63 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package signhelper
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/pkg/errors"
+)
+
+var ErrDoubleSign = errors.New("refused to sign a conflicting vote")
+
+// slashingProtectionPrefix + code (uint32 big endian) + number (uint64 big endian) + turn (uint32 big endian) -> vote hash
+var slashingProtectionPrefix = []byte("sp-")
+
+// SlashingProtection records the hashes of the signed consensus votes, so that
+// a restarted or duplicated validator sharing the database never signs two
+// different hashes for the same vote of a height and turn.
+type SlashingProtection struct {
+	mu sync.Mutex
+	db mandb.Database
+}
+
+// NewSlashingProtection creates a slashing protection stored in the database.
+func NewSlashingProtection(db mandb.Database) *SlashingProtection {
+	return &SlashingProtection{db: db}
+}
+
+func slashingProtectionKey(code mc.EventCode, number uint64, turn uint32) []byte {
+	key := make([]byte, len(slashingProtectionPrefix)+16)
+	n := copy(key, slashingProtectionPrefix)
+	binary.BigEndian.PutUint32(key[n:], uint32(code))
+	binary.BigEndian.PutUint64(key[n+4:], number)
+	binary.BigEndian.PutUint32(key[n+12:], turn)
+	return key
+}
+
+// CheckAndRecord records the hash as the vote of the message code for the
+// number and turn. It fails with ErrDoubleSign if another hash was recorded
+// for them before, signing the same hash again is allowed.
+func (sp *SlashingProtection) CheckAndRecord(code mc.EventCode, number uint64, turn uint32, hash common.Hash) error {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	key := slashingProtectionKey(code, number, turn)
+	if signed, err := sp.db.Get(key); err == nil && len(signed) > 0 {
+		if common.BytesToHash(signed) != hash {
+			return errors.Wrapf(ErrDoubleSign, "code %d number %d turn %d signed %s, requested %s", code, number, turn, common.BytesToHash(signed).TerminalString(), hash.TerminalString())
+		}
+		return nil
+	}
+	return sp.db.Put(key, hash.Bytes())
+}
+
+// Close closes the database of the slashing protection.
+func (sp *SlashingProtection) Close() {
+	sp.db.Close()
+}
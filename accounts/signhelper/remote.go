@@ -0,0 +1,182 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package signhelper
+
+import (
+	"math/big"
+
+	"github.com/MatrixAINetwork/go-matrix/accounts"
+	"github.com/MatrixAINetwork/go-matrix/accounts/keystore"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+	"github.com/pkg/errors"
+)
+
+// The remote signer protocol is JSON-RPC 2.0 over IPC, HTTP or websocket in
+// the "signer" namespace. Addresses are hex encoded, byte arrays 0x prefixed
+// hex strings.
+//
+//	signer_accounts() -> [address]
+//		The accounts the signer holds keys of.
+//	signer_signHashWithValidate(address, hash, validate) -> signature
+//		The 65 bytes signature [R || S || V] of the 32 bytes hash, with the
+//		validate flag folded into V as done by crypto.SignWithValidate.
+//	signer_signHash(address, hash) -> signature
+//		The 65 bytes signature [R || S || V] of the 32 bytes hash, where V is
+//		0 or 1. It signs the signing hashes of transactions.
+//	signer_signVrf(address, msg) -> {"publicKey": bytes, "value": bytes, "proof": bytes}
+//		The compressed public key of the account, the VRF value and the proof
+//		of the message.
+//
+// The signer does not interpret the hashes it signs, the node guards its
+// consensus votes with its slashing protection before requesting them.
+const remoteSignerNamespace = "signer"
+
+var ErrRemoteAccount = errors.New("account not held by the remote signer")
+
+// VrfResult is the result of signer_signVrf.
+type VrfResult struct {
+	PublicKey hexutil.Bytes `json:"publicKey"`
+	Value     hexutil.Bytes `json:"value"`
+	Proof     hexutil.Bytes `json:"proof"`
+}
+
+// RemoteSigner signs through a remote signer speaking the signer protocol.
+type RemoteSigner struct {
+	client *rpc.Client
+}
+
+// NewRemoteSigner creates a remote signer using the client.
+func NewRemoteSigner(client *rpc.Client) *RemoteSigner {
+	return &RemoteSigner{client: client}
+}
+
+// DialRemoteSigner connects to the remote signer at the endpoint, an IPC path
+// or an HTTP or websocket URL.
+func DialRemoteSigner(endpoint string) (*RemoteSigner, error) {
+	client, err := rpc.Dial(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return NewRemoteSigner(client), nil
+}
+
+// Accounts returns the accounts the remote signer holds keys of.
+func (s *RemoteSigner) Accounts() ([]common.Address, error) {
+	var result []common.Address
+	if err := s.client.Call(&result, remoteSignerNamespace+"_accounts"); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CheckAccountAndPassword implements entrust.AccountChecker, accepting the
+// accounts held by the remote signer whatever the password.
+func (s *RemoteSigner) CheckAccountAndPassword(a accounts.Account, passphrase string) error {
+	held, err := s.Accounts()
+	if err != nil {
+		return err
+	}
+	for _, account := range held {
+		if account == a.Address {
+			return nil
+		}
+	}
+	return ErrRemoteAccount
+}
+
+func (s *RemoteSigner) SignHashWithValidate(account common.Address, password string, hash []byte, validate bool) ([]byte, error) {
+	var result hexutil.Bytes
+	if err := s.client.Call(&result, remoteSignerNamespace+"_signHashWithValidate", account, hexutil.Bytes(hash), validate); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *RemoteSigner) SignTx(account common.Address, password string, tx types.SelfTransaction, chainID *big.Int) (types.SelfTransaction, error) {
+	signer := types.NewEIP155Signer(chainID)
+	hash := signer.Hash(tx)
+	var sig hexutil.Bytes
+	if err := s.client.Call(&sig, remoteSignerNamespace+"_signHash", account, hexutil.Bytes(hash[:])); err != nil {
+		return nil, err
+	}
+	return tx.WithSignature(signer, sig)
+}
+
+func (s *RemoteSigner) SignVrf(account common.Address, password string, msg []byte) ([]byte, []byte, []byte, error) {
+	var result VrfResult
+	if err := s.client.Call(&result, remoteSignerNamespace+"_signVrf", account, hexutil.Bytes(msg)); err != nil {
+		return []byte{}, []byte{}, []byte{}, err
+	}
+	return result.PublicKey, result.Value, result.Proof, nil
+}
+
+// Close closes the connection to the remote signer.
+func (s *RemoteSigner) Close() {
+	s.client.Close()
+}
+
+// KeyStoreSignerService serves the signer protocol with the keys of a keystore
+// unlocked by their passwords. Registered in the "signer" namespace of an RPC
+// server, it is a stand-in remote signer.
+type KeyStoreSignerService struct {
+	ks        *keystore.KeyStore
+	passwords map[common.Address]string
+}
+
+// NewKeyStoreSignerService creates a signer service for the accounts of the
+// keystore with the passwords.
+func NewKeyStoreSignerService(ks *keystore.KeyStore, passwords map[common.Address]string) *KeyStoreSignerService {
+	return &KeyStoreSignerService{ks: ks, passwords: passwords}
+}
+
+func (s *KeyStoreSignerService) account(address common.Address) (accounts.Account, string, error) {
+	password, ok := s.passwords[address]
+	if !ok {
+		return accounts.Account{}, "", ErrRemoteAccount
+	}
+	return accounts.Account{Address: address}, password, nil
+}
+
+// Accounts returns the accounts the service signs with.
+func (s *KeyStoreSignerService) Accounts() []common.Address {
+	addrs := make([]common.Address, 0, len(s.passwords))
+	for addr := range s.passwords {
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
+// SignHashWithValidate signs the hash with the validate flag.
+func (s *KeyStoreSignerService) SignHashWithValidate(address common.Address, hash hexutil.Bytes, validate bool) (hexutil.Bytes, error) {
+	account, password, err := s.account(address)
+	if err != nil {
+		return nil, err
+	}
+	return s.ks.SignHashValidateWithPass(account, password, hash, validate)
+}
+
+// SignHash signs the hash.
+func (s *KeyStoreSignerService) SignHash(address common.Address, hash hexutil.Bytes) (hexutil.Bytes, error) {
+	account, password, err := s.account(address)
+	if err != nil {
+		return nil, err
+	}
+	return s.ks.SignHashWithPassphrase(account, password, hash)
+}
+
+// SignVrf computes the VRF value and proof of the message.
+func (s *KeyStoreSignerService) SignVrf(address common.Address, msg hexutil.Bytes) (*VrfResult, error) {
+	account, password, err := s.account(address)
+	if err != nil {
+		return nil, err
+	}
+	pk, value, proof, err := s.ks.SignVrfWithPass(account, password, msg)
+	if err != nil {
+		return nil, err
+	}
+	return &VrfResult{PublicKey: pk, Value: value, Proof: proof}, nil
+}
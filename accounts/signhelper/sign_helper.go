@@ -18,6 +18,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/accounts/keystore"
 	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mc"
 	"github.com/MatrixAINetwork/go-matrix/params/enstrust"
 )
 
@@ -42,21 +43,35 @@ var (
 	ErrIllegalSignAccount    = errors.New("sign account is illegal")
 	ErrReader                = errors.New("auth reader is nil")
 	ErrGetAccountAndPassword = errors.New("get account and password  error")
+	ErrNilProtection         = errors.New("slashing protection is nil")
 )
 
 type SignHelper struct {
 	mu         sync.RWMutex
 	keyStore   *keystore.KeyStore
+	signer     Signer // Local keystore unless a remote signer is set
+	remote     *RemoteSigner
+	protection *SlashingProtection
 	authReader AuthReader
+	ca         ca.Provider
 }
 
 func NewSignHelper() *SignHelper {
 	return &SignHelper{
 		keyStore:   nil,
 		authReader: nil,
+		ca:         ca.DefaultService(),
 	}
 }
 
+// SetCA sets the identity service whose accounts sign, the default service of
+// the process is used until then.
+func (sh *SignHelper) SetCA(identity ca.Provider) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	sh.ca = identity
+}
+
 func (sh *SignHelper) SetAuthReader(reader AuthReader) error {
 	if reader == nil {
 		return ErrReader
@@ -82,10 +97,63 @@ func (sh *SignHelper) SetAccountManager(am *accounts.Manager) error {
 		return ErrKeyStoreReflect
 	}
 	sh.keyStore = ks
-	entrust.SetAccountChecker(ks)
+	if sh.remote == nil {
+		sh.signer = &keyStoreSigner{ks}
+		entrust.SetAccountChecker(ks)
+	}
+	return nil
+}
+
+// SetRemoteSigner signs with the remote signer at the endpoint instead of the
+// local keystore. The entrust accounts are checked to be held by the remote
+// signer, their passwords are not used.
+func (sh *SignHelper) SetRemoteSigner(endpoint string) error {
+	remote, err := DialRemoteSigner(endpoint)
+	if err != nil {
+		return err
+	}
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if sh.remote != nil {
+		sh.remote.Close()
+	}
+	sh.remote = remote
+	sh.signer = remote
+	entrust.SetAccountChecker(remote)
 	return nil
 }
 
+// SetSlashingProtection guards the consensus votes with the protection.
+func (sh *SignHelper) SetSlashingProtection(protection *SlashingProtection) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	sh.protection = protection
+}
+
+// SignVoteWithValidateByReader signs the hash of a consensus vote of the
+// message code at the number and turn. The vote identifies what is voted for,
+// the hash signed may change when the same vote is requested again, like a
+// resent request with a new timestamp. It refuses to sign if another vote was
+// signed for the code, number and turn before.
+func (sh *SignHelper) SignVoteWithValidateByReader(reader AuthReader, code mc.EventCode, number uint64, turn uint32, vote common.Hash, hash common.Hash, validate bool, blkHash common.Hash) (common.Signature, error) {
+	sh.mu.RLock()
+	protection := sh.protection
+	sh.mu.RUnlock()
+	if protection == nil {
+		return common.Signature{}, ErrNilProtection
+	}
+	if err := protection.CheckAndRecord(code, number, turn, vote); err != nil {
+		log.Error(ModeLog, "拒绝签名冲突投票", err)
+		return common.Signature{}, err
+	}
+	return sh.SignHashWithValidateByReader(reader, hash.Bytes(), validate, blkHash)
+}
+
+func (sh *SignHelper) SignVoteWithValidate(code mc.EventCode, number uint64, turn uint32, vote common.Hash, hash common.Hash, validate bool, blkHash common.Hash) (common.Signature, error) {
+	return sh.SignVoteWithValidateByReader(sh.authReader, code, number, turn, vote, hash, validate, blkHash)
+}
+
 func (sh *SignHelper) SignHashWithValidateByReader(reader AuthReader, hash []byte, validate bool, blkHash common.Hash) (common.Signature, error) {
 	signAccount, signPassword, err := sh.getSignAccountAndPassword(reader, blkHash)
 	if err != nil {
@@ -97,10 +165,10 @@ func (sh *SignHelper) SignHashWithValidateByReader(reader AuthReader, hash []byt
 
 	sh.mu.RLock()
 	defer sh.mu.RUnlock()
-	if nil == sh.keyStore {
+	if nil == sh.signer {
 		return common.Signature{}, ErrNilKeyStore
 	}
-	sign, err := sh.keyStore.SignHashValidateWithPass(signAccount, signPassword, hash, validate)
+	sign, err := sh.signer.SignHashWithValidate(signAccount.Address, signPassword, hash, validate)
 	if err != nil {
 		return common.Signature{}, err
 	}
@@ -120,12 +188,11 @@ func (sh *SignHelper) SignHashWithValidateByAccount(hash []byte, validate bool,
 
 	sh.mu.RLock()
 	defer sh.mu.RUnlock()
-	if nil == sh.keyStore {
+	if nil == sh.signer {
 		return common.Signature{}, ErrNilKeyStore
 	}
 
-	ac := accounts.Account{Address: signAccount}
-	sign, err := sh.keyStore.SignHashValidateWithPass(ac, password, hash, validate)
+	sign, err := sh.signer.SignHashWithValidate(signAccount, password, hash, validate)
 	if err != nil {
 		return common.Signature{}, err
 	}
@@ -144,10 +211,10 @@ func (sh *SignHelper) SignTx(tx types.SelfTransaction, chainID *big.Int, blkHash
 	}
 	sh.mu.RLock()
 	defer sh.mu.RUnlock()
-	if nil == sh.keyStore {
+	if nil == sh.signer {
 		return nil, ErrNilKeyStore
 	}
-	return sh.keyStore.SignTxWithPassAndTemp(signAccount, signPassword, tx, chainID)
+	return sh.signer.SignTx(signAccount.Address, signPassword, tx, chainID)
 }
 
 func (sh *SignHelper) SignVrfByAccount(msg []byte, account common.Address) ([]byte, []byte, []byte, error) {
@@ -159,11 +226,10 @@ func (sh *SignHelper) SignVrfByAccount(msg []byte, account common.Address) ([]by
 
 	sh.mu.RLock()
 	defer sh.mu.RUnlock()
-	if nil == sh.keyStore {
+	if nil == sh.signer {
 		return nil, nil, nil, ErrNilKeyStore
 	}
-	ac := accounts.Account{Address: signAccount}
-	return sh.keyStore.SignVrfWithPass(ac, password, msg)
+	return sh.signer.SignVrf(signAccount, password, msg)
 }
 
 func (sh *SignHelper) SignVrf(msg []byte, blkHash common.Hash) ([]byte, []byte, []byte, error) {
@@ -178,10 +244,10 @@ func (sh *SignHelper) SignVrf(msg []byte, blkHash common.Hash) ([]byte, []byte,
 
 	sh.mu.RLock()
 	defer sh.mu.RUnlock()
-	if nil == sh.keyStore {
+	if nil == sh.signer {
 		return []byte{}, []byte{}, []byte{}, ErrNilKeyStore
 	}
-	return sh.keyStore.SignVrfWithPass(signAccount, signPassword, msg)
+	return sh.signer.SignVrf(signAccount.Address, signPassword, msg)
 }
 
 func (sh *SignHelper) getSignAccountAndPasswordAtSignHeight(reader AuthReader, blkHash common.Hash, signHeight uint64, usingEntrust bool) (accounts.Account, string, error) {
@@ -190,12 +256,12 @@ func (sh *SignHelper) getSignAccountAndPasswordAtSignHeight(reader AuthReader, b
 	var addrs []common.Address
 	var err error
 	if usingEntrust {
-		addrs, err = reader.GetA2AccountsFromA0AccountAtSignHeight(ca.GetDepositAddress(), blkHash, signHeight)
+		addrs, err = reader.GetA2AccountsFromA0AccountAtSignHeight(sh.ca.GetDepositAddress(), blkHash, signHeight)
 		if err != nil {
 			return account, "", err
 		}
 	} else {
-		addrs = []common.Address{ca.GetSignAddress()}
+		addrs = []common.Address{sh.ca.GetSignAddress()}
 	}
 
 	addr, password, err := reader.GetSignAccountPassword(addrs)
@@ -205,7 +271,7 @@ func (sh *SignHelper) getSignAccountAndPasswordAtSignHeight(reader AuthReader, b
 
 func (sh *SignHelper) getSignAccountAndPassword(reader AuthReader, blkHash common.Hash) (accounts.Account, string, error) {
 	account := accounts.Account{}
-	addrs, err := reader.GetA2AccountsFromA0Account(ca.GetDepositAddress(), blkHash)
+	addrs, err := reader.GetA2AccountsFromA0Account(sh.ca.GetDepositAddress(), blkHash)
 	if err != nil {
 		return account, "", err
 	}
@@ -0,0 +1,39 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package signhelper
+
+import (
+	"math/big"
+
+	"github.com/MatrixAINetwork/go-matrix/accounts"
+	"github.com/MatrixAINetwork/go-matrix/accounts/keystore"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+)
+
+// Signer is the backend holding the keys of the sign accounts. The password is
+// the one of the entrust file, backends keeping their keys unlocked ignore it.
+type Signer interface {
+	SignHashWithValidate(account common.Address, password string, hash []byte, validate bool) ([]byte, error)
+	SignTx(account common.Address, password string, tx types.SelfTransaction, chainID *big.Int) (types.SelfTransaction, error)
+	// SignVrf returns the compressed public key, the VRF value and the proof.
+	SignVrf(account common.Address, password string, msg []byte) ([]byte, []byte, []byte, error)
+}
+
+// keyStoreSigner signs with the keys of the local keystore.
+type keyStoreSigner struct {
+	ks *keystore.KeyStore
+}
+
+func (s *keyStoreSigner) SignHashWithValidate(account common.Address, password string, hash []byte, validate bool) ([]byte, error) {
+	return s.ks.SignHashValidateWithPass(accounts.Account{Address: account}, password, hash, validate)
+}
+
+func (s *keyStoreSigner) SignTx(account common.Address, password string, tx types.SelfTransaction, chainID *big.Int) (types.SelfTransaction, error) {
+	return s.ks.SignTxWithPassAndTemp(accounts.Account{Address: account}, password, tx, chainID)
+}
+
+func (s *keyStoreSigner) SignVrf(account common.Address, password string, msg []byte) ([]byte, []byte, []byte, error) {
+	return s.ks.SignVrfWithPass(accounts.Account{Address: account}, password, msg)
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package signhelper
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/accounts"
+	"github.com/MatrixAINetwork/go-matrix/accounts/keystore"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	_ "github.com/MatrixAINetwork/go-matrix/crypto/vrf"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+	"github.com/pkg/errors"
+)
+
+// Tests that a remote signer served by the keystore stand-in produces the
+// signatures of the local keystore.
+func TestRemoteSigner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signhelper-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.NewAccount("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := rpc.NewServer()
+	if err := server.RegisterName("signer", NewKeyStoreSignerService(ks, map[common.Address]string{account.Address: "password"})); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+	remote := NewRemoteSigner(rpc.DialInProc(server))
+	defer remote.Close()
+	local := &keyStoreSigner{ks}
+
+	if err := remote.CheckAccountAndPassword(account, ""); err != nil {
+		t.Fatalf("held account refused: %v", err)
+	}
+	if err := remote.CheckAccountAndPassword(accounts.Account{Address: common.Address{0x01}}, ""); err == nil {
+		t.Fatalf("unknown account accepted")
+	}
+
+	hash := crypto.Keccak256([]byte("vote"))
+	sig, err := remote.SignHashWithValidate(account.Address, "", hash, true)
+	if err != nil {
+		t.Fatalf("remote sign failed: %v", err)
+	}
+	want, err := local.SignHashWithValidate(account.Address, "password", hash, true)
+	if err != nil {
+		t.Fatalf("local sign failed: %v", err)
+	}
+	if !bytes.Equal(sig, want) {
+		t.Fatalf("signature mismatch: have %x, want %x", sig, want)
+	}
+	signer, validate, err := crypto.VerifySignWithValidate(hash, sig)
+	if err != nil || signer != account.Address || !validate {
+		t.Fatalf("signature verification mismatch: have %x %v %v, want %x true", signer, validate, err, account.Address)
+	}
+	if _, err := remote.SignHashWithValidate(common.Address{0x01}, "", hash, true); err == nil {
+		t.Fatalf("unknown account signed")
+	}
+
+	chainID := big.NewInt(1)
+	tx := types.NewTransaction(0, common.Address{0x02}, big.NewInt(1), 21000, big.NewInt(1), nil, nil, nil, nil, 0, 0, "MAN", 0)
+	signed, err := remote.SignTx(account.Address, "", tx, chainID)
+	if err != nil {
+		t.Fatalf("remote tx sign failed: %v", err)
+	}
+	if from, err := types.Sender(types.NewEIP155Signer(chainID), signed); err != nil || from != account.Address {
+		t.Fatalf("tx sender mismatch: have %x %v, want %x", from, err, account.Address)
+	}
+
+	pk, value, proof, err := remote.SignVrf(account.Address, "", []byte("seed"))
+	if err != nil {
+		t.Fatalf("remote vrf failed: %v", err)
+	}
+	wantPk, _, _, err := local.SignVrf(account.Address, "password", []byte("seed"))
+	if err != nil {
+		t.Fatalf("local vrf failed: %v", err)
+	}
+	if !bytes.Equal(pk, wantPk) || len(value) == 0 || len(proof) == 0 {
+		t.Fatalf("vrf mismatch: have %x %x %x, want public key %x", pk, value, proof, wantPk)
+	}
+}
+
+// Tests that the slashing protection refuses another hash for a signed vote
+// only, also after being reopened.
+func TestSlashingProtection(t *testing.T) {
+	db := mandb.NewMemDatabase()
+	sp := NewSlashingProtection(db)
+
+	if err := sp.CheckAndRecord(mc.HD_BlkConsensusVote, 10, 2, common.Hash{0x01}); err != nil {
+		t.Fatalf("first vote refused: %v", err)
+	}
+	if err := sp.CheckAndRecord(mc.HD_BlkConsensusVote, 10, 2, common.Hash{0x01}); err != nil {
+		t.Fatalf("same vote refused: %v", err)
+	}
+	if err := sp.CheckAndRecord(mc.HD_BlkConsensusVote, 10, 2, common.Hash{0x02}); errors.Cause(err) != ErrDoubleSign {
+		t.Fatalf("conflicting vote error mismatch: have %v, want %v", err, ErrDoubleSign)
+	}
+	if err := sp.CheckAndRecord(mc.HD_BlkConsensusVote, 10, 3, common.Hash{0x02}); err != nil {
+		t.Fatalf("vote of next turn refused: %v", err)
+	}
+	if err := sp.CheckAndRecord(mc.HD_BlkConsensusVote, 11, 2, common.Hash{0x02}); err != nil {
+		t.Fatalf("vote of next number refused: %v", err)
+	}
+	if err := sp.CheckAndRecord(mc.HD_LeaderReelectVote, 10, 2, common.Hash{0x02}); err != nil {
+		t.Fatalf("reelect vote refused: %v", err)
+	}
+
+	sp = NewSlashingProtection(db)
+	if err := sp.CheckAndRecord(mc.HD_LeaderReelectVote, 10, 2, common.Hash{0x03}); errors.Cause(err) != ErrDoubleSign {
+		t.Fatalf("conflicting vote after reopen error mismatch: have %v, want %v", err, ErrDoubleSign)
+	}
+}
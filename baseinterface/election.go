@@ -4,6 +4,8 @@
 package baseinterface
 
 import (
+	"sort"
+
 	"github.com/MatrixAINetwork/go-matrix/core/state"
 	"github.com/MatrixAINetwork/go-matrix/election/support"
 	"github.com/MatrixAINetwork/go-matrix/mc"
@@ -23,6 +25,22 @@ func RegElectPlug(name string, value func() ElectionInterface) {
 	electionPlugs[name] = value
 }
 
+// HasElectPlug reports whether the election plug is registered.
+func HasElectPlug(name string) bool {
+	_, ok := electionPlugs[name]
+	return ok
+}
+
+// ElectPlugs returns the names of the registered election plugs, sorted.
+func ElectPlugs() []string {
+	names := make([]string, 0, len(electionPlugs))
+	for name := range electionPlugs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func NewElect(ElectPlugs string) ElectionInterface {
 	//从配置中获取参数
 	if _, ok := electionPlugs[ElectPlugs]; ok {
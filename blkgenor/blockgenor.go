@@ -63,39 +63,39 @@ func New(man Backend) (*BlockGenor, error) {
 	bg.pm = NewProcessManage(man)
 
 	var err error
-	if bg.roleUpdatedMsgSub, err = mc.SubscribeEvent(mc.CA_RoleUpdated, bg.roleUpdatedMsgCh); err != nil {
+	if bg.roleUpdatedMsgSub, err = man.MsgCenter().SubscribeEvent(mc.CA_RoleUpdated, bg.roleUpdatedMsgCh); err != nil {
 		log.Error("区块生成模块", "订阅错误，消息号", mc.CA_RoleUpdated, "错误：", err)
 		return nil, err
 	}
-	if bg.leaderChangeSub, err = mc.SubscribeEvent(mc.Leader_LeaderChangeNotify, bg.leaderChangeNotifyCh); err != nil {
+	if bg.leaderChangeSub, err = man.MsgCenter().SubscribeEvent(mc.Leader_LeaderChangeNotify, bg.leaderChangeNotifyCh); err != nil {
 		log.Error("区块生成模块", "订阅错误，消息号", mc.Leader_LeaderChangeNotify, "错误：", err)
 		return nil, err
 	}
-	if bg.minerResultSub, err = mc.SubscribeEvent(mc.HD_MiningRsp, bg.minerResultCh); err != nil {
+	if bg.minerResultSub, err = man.MsgCenter().SubscribeEvent(mc.HD_MiningRsp, bg.minerResultCh); err != nil {
 		log.Error("区块生成模块", "订阅错误，消息号", mc.HD_MiningRsp, "错误：", err)
 		return nil, err
 	}
-	if bg.broadcastMinerResultSub, err = mc.SubscribeEvent(mc.HD_BroadcastMiningRsp, bg.broadcastMinerResultCh); err != nil {
+	if bg.broadcastMinerResultSub, err = man.MsgCenter().SubscribeEvent(mc.HD_BroadcastMiningRsp, bg.broadcastMinerResultCh); err != nil {
 		log.Error("区块生成模块", "订阅错误，消息号", mc.HD_BroadcastMiningRsp, "错误：", err)
 		return nil, err
 	}
-	if bg.blockConsensusSub, err = mc.SubscribeEvent(mc.BlkVerify_VerifyConsensusOK, bg.blockConsensusCh); err != nil {
+	if bg.blockConsensusSub, err = man.MsgCenter().SubscribeEvent(mc.BlkVerify_VerifyConsensusOK, bg.blockConsensusCh); err != nil {
 		log.Error("区块生成模块", "订阅错误，消息号", mc.BlkVerify_VerifyConsensusOK, "错误：", err)
 		return nil, err
 	}
-	if bg.blockInsertSub, err = mc.SubscribeEvent(mc.HD_NewBlockInsert, bg.blockInsertCh); err != nil {
+	if bg.blockInsertSub, err = man.MsgCenter().SubscribeEvent(mc.HD_NewBlockInsert, bg.blockInsertCh); err != nil {
 		log.Error("区块生成模块", "订阅错误，消息号", mc.HD_NewBlockInsert, "错误：", err)
 		return nil, err
 	}
-	if bg.recoverySub, err = mc.SubscribeEvent(mc.Leader_RecoveryState, bg.recoveryCh); err != nil {
+	if bg.recoverySub, err = man.MsgCenter().SubscribeEvent(mc.Leader_RecoveryState, bg.recoveryCh); err != nil {
 		log.Error("区块生成模块", "订阅错误，消息号", mc.Leader_RecoveryState, "错误：", err)
 		return nil, err
 	}
-	if bg.fullBlockReqSub, err = mc.SubscribeEvent(mc.HD_FullBlockReq, bg.fullBlockReqCh); err != nil {
+	if bg.fullBlockReqSub, err = man.MsgCenter().SubscribeEvent(mc.HD_FullBlockReq, bg.fullBlockReqCh); err != nil {
 		log.Error("区块生成模块", "订阅错误，消息号", mc.HD_FullBlockReq, "错误：", err)
 		return nil, err
 	}
-	if bg.fullBlockRspSub, err = mc.SubscribeEvent(mc.HD_FullBlockRsp, bg.fullBlockRspCh); err != nil {
+	if bg.fullBlockRspSub, err = man.MsgCenter().SubscribeEvent(mc.HD_FullBlockRsp, bg.fullBlockRspCh); err != nil {
 		log.Error("区块生成模块", "订阅错误，消息号", mc.HD_FullBlockRsp, "错误：", err)
 		return nil, err
 	}
@@ -12,7 +12,6 @@ import (
 	"time"
 
 	"github.com/MatrixAINetwork/go-matrix/accounts/signhelper"
-	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/core"
 	"github.com/MatrixAINetwork/go-matrix/crypto"
@@ -231,7 +230,7 @@ func (p *Process) canInsertBlock(bcInterval *mc.BCIntervalInfo, header *types.He
 			return false
 		}
 
-		if role, _ := ca.GetAccountOriginalRole(signAccount, header.ParentHash); common.RoleBroadcast != role {
+		if role, _ := p.pm.ca.GetAccountOriginalRole(signAccount, header.ParentHash); common.RoleBroadcast != role {
 			log.Warn(p.logExtraInfo(), "广播区块插入消息非法，签名人不是广播身份, 角色", role.String())
 			return false
 		}
@@ -354,8 +353,8 @@ func (p *Process) canGenHeader() bool {
 			return false
 		}
 
-		if p.curLeader != ca.GetDepositAddress() {
-			log.Debug(p.logExtraInfo(), "自己不是当前leader，进入挖矿结果验证阶段, 高度", p.number, "地址", ca.GetDepositAddress().Hex(), "leader", p.curLeader.Hex())
+		if p.curLeader != p.pm.ca.GetDepositAddress() {
+			log.Debug(p.logExtraInfo(), "自己不是当前leader，进入挖矿结果验证阶段, 高度", p.number, "地址", p.pm.ca.GetDepositAddress().Hex(), "leader", p.curLeader.Hex())
 			p.state = StateMinerResultVerify
 			p.processMinerResultVerify(p.curLeader, true)
 			return false
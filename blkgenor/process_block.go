@@ -8,7 +8,6 @@ import (
 
 	"time"
 
-	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/consensus/blkmanage"
 	"github.com/MatrixAINetwork/go-matrix/core"
@@ -151,7 +150,7 @@ func (p *Process) ProcessFullBlockRsp(rsp *mc.HD_FullBlockRspMsg) {
 		Header: rsp.Header,
 		State:  stateDB.Copy(),
 	}
-	mc.PublishEvent(mc.BlockGenor_NewBlockReady, readyMsg)
+	p.pm.center.PublishEvent(mc.BlockGenor_NewBlockReady, readyMsg)
 
 	p.state = StateBlockInsert
 	p.processBlockInsert(rsp.Header.Leader)
@@ -254,7 +253,7 @@ func (p *Process) dealMinerResultVerifyCommon(leader common.Address) {
 		State:  blockData.block.State.Copy(),
 	}
 	log.Info(p.logExtraInfo(), "普通区块验证完成", "发送新区块准备完毕消息", "高度", p.number, "leader", readyMsg.Header.Leader.Hex())
-	mc.PublishEvent(mc.BlockGenor_NewBlockReady, readyMsg)
+	p.pm.center.PublishEvent(mc.BlockGenor_NewBlockReady, readyMsg)
 
 	p.state = StateBlockInsert
 	p.processBlockInsert(p.curLeader)
@@ -301,8 +300,8 @@ func (p *Process) canGenBlock() bool {
 			return false
 		}
 
-		if p.nextLeader != ca.GetDepositAddress() {
-			log.Debug(p.logExtraInfo(), "准备进行区块广播,自己不是下个区块leader,高度", p.number, "next leader", p.nextLeader.Hex(), "self", ca.GetDepositAddress().Hex())
+		if p.nextLeader != p.pm.ca.GetDepositAddress() {
+			log.Debug(p.logExtraInfo(), "准备进行区块广播,自己不是下个区块leader,高度", p.number, "next leader", p.nextLeader.Hex(), "self", p.pm.ca.GetDepositAddress().Hex())
 			return false
 		}
 	}
@@ -312,7 +311,7 @@ func (p *Process) canGenBlock() bool {
 func (p *Process) pickSatisfyMinerResults(header *types.Header, results []*mc.HD_MiningRspMsg, innerMinerPick bool) (*mc.HD_MiningRspMsg, error) {
 	for _, result := range results {
 		if innerMinerPick == false {
-			role, _ := ca.GetAccountOriginalRole(result.Coinbase, header.ParentHash)
+			role, _ := p.pm.ca.GetAccountOriginalRole(result.Coinbase, header.ParentHash)
 			if common.RoleInnerMiner == role {
 				log.Warn(p.logExtraInfo(), "基金会矿工结果", "当前未超时，暂时不选用", "from", result.Coinbase.Hex(), "难度", result.Difficulty, "高度", p.number)
 				continue
@@ -393,7 +392,7 @@ func (p *Process) insertAndBcBlock(isSelf bool, leader common.Address, header *t
 		log.Error(p.logExtraInfo(), "插入区块失败", err)
 		return common.Hash{}, err
 	}
-	mc.PublishEvent(mc.BlockInserted, &mc.BlockInsertedMsg{Block: mc.BlockInfo{Hash: block.Hash(), Number: block.NumberU64()}, InsertTime: uint64(time.Now().Unix()), CanonState: stat == core.CanonStatTy})
+	p.pm.center.PublishEvent(mc.BlockInserted, &mc.BlockInsertedMsg{Block: mc.BlockInfo{Hash: block.Hash(), Number: block.NumberU64()}, InsertTime: uint64(time.Now().Unix()), CanonState: stat == core.CanonStatTy})
 	// Broadcast the block and announce chain insertion event
 	hash := block.Hash()
 	p.eventMux().Post(core.NewMinedBlockEvent{Block: block})
@@ -406,6 +405,6 @@ func (p *Process) insertAndBcBlock(isSelf bool, leader common.Address, header *t
 		events = append(events, core.ChainHeadEvent{Block: block})
 	}
 	p.blockChain().PostChainEvents(events, logs)
-	mc.PublishEvent(mc.BlockGenor_HeaderGenerateReq, p.number+1)
+	p.pm.center.PublishEvent(mc.BlockGenor_HeaderGenerateReq, p.number+1)
 	return hash, nil
 }
@@ -76,7 +76,7 @@ func (p *Process) dealMinerResultVerifyBroadcast() {
 			State:  state.Copy(),
 		}
 		log.Info(p.logExtraInfo(), "广播区块验证完成", "发送新区块准备完毕消息", "高度", p.number, "leader", result.Header.Leader.Hex())
-		mc.PublishEvent(mc.BlockGenor_NewBlockReady, readyMsg)
+		p.pm.center.PublishEvent(mc.BlockGenor_NewBlockReady, readyMsg)
 
 		p.changeState(StateBlockInsert)
 		p.processBlockInsert(result.Header.Leader)
@@ -6,7 +6,6 @@ package blkgenor
 import (
 	"github.com/MatrixAINetwork/go-matrix/consensus/blkmanage"
 
-	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/core/state"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
@@ -109,7 +108,7 @@ func (p *Process) sendHeaderVerifyReq(header *types.Header, txsCode []*common.Re
 		TxsCode:                txsCode,
 		ConsensusTurn:          p.consensusTurn,
 		OnlineConsensusResults: onlineConsensusResults,
-		From: ca.GetSignAddress(),
+		From: p.pm.ca.GetSignAddress(),
 	}
 	//send to local block verify module
 	localBlock := &mc.LocalBlockVerifyConsensusReq{BlkVerifyConsensusReq: p2pBlock, OriginalTxs: originalTxs, FinalTxs: finalTxs, Receipts: receipts, State: stateDB}
@@ -117,20 +116,20 @@ func (p *Process) sendHeaderVerifyReq(header *types.Header, txsCode []*common.Re
 		txpoolCache.MakeStruck(types.GetTX(originalTxs), header.HashNoSignsAndNonce(), p.number)
 	}
 	log.Info(p.logExtraInfo(), "本地发送区块验证请求, root", p2pBlock.Header.Roots, "高度", p.number)
-	mc.PublishEvent(mc.BlockGenor_HeaderVerifyReq, localBlock)
+	p.pm.center.PublishEvent(mc.BlockGenor_HeaderVerifyReq, localBlock)
 	p.startConsensusReqSender(p2pBlock)
 }
 
 func (p *Process) sendBroadcastMiningReq(header *types.Header, finalTxs []types.CoinSelfTransaction) {
 	sendMsg := &mc.BlockData{Header: header, Txs: finalTxs}
 	log.Info(p.logExtraInfo(), "广播挖矿请求(本地), number", sendMsg.Header.Number, "root", header.Roots, "tx数量", len(types.GetTX(finalTxs)))
-	mc.PublishEvent(mc.HD_BroadcastMiningReq, &mc.BlockGenor_BroadcastMiningReqMsg{sendMsg})
+	p.pm.center.PublishEvent(mc.HD_BroadcastMiningReq, &mc.BlockGenor_BroadcastMiningReqMsg{sendMsg})
 }
 
 func (p *Process) setSignatures(header *types.Header) error {
 
 	signHash := header.HashNoSignsAndNonce()
-	sign, err := p.signHelper().SignHashWithValidateByAccount(signHash.Bytes(), true, ca.GetDepositAddress())
+	sign, err := p.signHelper().SignHashWithValidateByAccount(signHash.Bytes(), true, p.pm.ca.GetDepositAddress())
 	if err != nil {
 		log.Error(p.logExtraInfo(), "广播区块生成，签名错误", err)
 		return err
@@ -8,6 +8,7 @@ import (
 
 	"github.com/MatrixAINetwork/go-matrix/accounts/signhelper"
 	"github.com/MatrixAINetwork/go-matrix/baseinterface"
+	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/consensus/blkmanage"
 	"github.com/MatrixAINetwork/go-matrix/core"
 	"github.com/MatrixAINetwork/go-matrix/log"
@@ -31,6 +32,8 @@ type ProcessManage struct {
 	olConsensus   *olconsensus.TopNodeService
 	random        *baseinterface.Random
 	manblk        *blkmanage.ManBlkManage
+	center        *mc.Center
+	ca            ca.Provider
 }
 
 func NewProcessManage(matrix Backend) *ProcessManage {
@@ -46,6 +49,8 @@ func NewProcessManage(matrix Backend) *ProcessManage {
 		olConsensus:   matrix.OLConsensus(),
 		random:        matrix.Random(),
 		manblk:        matrix.ManBlkDeal(),
+		center:        matrix.MsgCenter(),
+		ca:            matrix.CA(),
 	}
 }
 
@@ -8,10 +8,12 @@ import (
 
 	"github.com/MatrixAINetwork/go-matrix/accounts/signhelper"
 	"github.com/MatrixAINetwork/go-matrix/baseinterface"
+	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/consensus/blkmanage"
 	"github.com/MatrixAINetwork/go-matrix/core"
 	"github.com/MatrixAINetwork/go-matrix/event"
+	"github.com/MatrixAINetwork/go-matrix/mc"
 	"github.com/MatrixAINetwork/go-matrix/msgsend"
 	"github.com/MatrixAINetwork/go-matrix/olconsensus"
 	"github.com/MatrixAINetwork/go-matrix/reelection"
@@ -36,6 +38,8 @@ type Backend interface {
 	OLConsensus() *olconsensus.TopNodeService
 	Random() *baseinterface.Random
 	ManBlkDeal() *blkmanage.ManBlkManage
+	MsgCenter() *mc.Center
+	CA() *ca.Service
 }
 
 type VrfMsg struct {
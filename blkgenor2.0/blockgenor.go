@@ -65,47 +65,47 @@ func New(man Backend) (*BlockGenor, error) {
 	bg.pm = NewProcessManage(man)
 
 	var err error
-	if bg.roleUpdatedMsgSub, err = mc.SubscribeEvent(mc.CA_RoleUpdated, bg.roleUpdatedMsgCh); err != nil {
+	if bg.roleUpdatedMsgSub, err = man.MsgCenter().SubscribeEvent(mc.CA_RoleUpdated, bg.roleUpdatedMsgCh); err != nil {
 		log.Error(bg.logExtraInfo(), "订阅错误，消息号", mc.CA_RoleUpdated, "错误：", err)
 		return nil, err
 	}
-	if bg.leaderChangeSub, err = mc.SubscribeEvent(mc.Leader_LeaderChangeNotify, bg.leaderChangeNotifyCh); err != nil {
+	if bg.leaderChangeSub, err = man.MsgCenter().SubscribeEvent(mc.Leader_LeaderChangeNotify, bg.leaderChangeNotifyCh); err != nil {
 		log.Error(bg.logExtraInfo(), "订阅错误，消息号", mc.Leader_LeaderChangeNotify, "错误：", err)
 		return nil, err
 	}
-	if bg.powResultSub, err = mc.SubscribeEvent(mc.HD_V2_PowMiningRsp, bg.powResultCh); err != nil {
+	if bg.powResultSub, err = man.MsgCenter().SubscribeEvent(mc.HD_V2_PowMiningRsp, bg.powResultCh); err != nil {
 		log.Error(bg.logExtraInfo(), "订阅错误，消息号", mc.HD_V2_PowMiningRsp, "错误：", err)
 		return nil, err
 	}
-	if bg.aiResultSub, err = mc.SubscribeEvent(mc.HD_V2_AIMiningRsp, bg.aiResultCh); err != nil {
+	if bg.aiResultSub, err = man.MsgCenter().SubscribeEvent(mc.HD_V2_AIMiningRsp, bg.aiResultCh); err != nil {
 		log.Error(bg.logExtraInfo(), "订阅错误，消息号", mc.HD_V2_AIMiningRsp, "错误：", err)
 		return nil, err
 	}
-	if bg.posBlockSub, err = mc.SubscribeEvent(mc.BlkVerify_POSFinishedNotifyV2, bg.posBlockCh); err != nil {
+	if bg.posBlockSub, err = man.MsgCenter().SubscribeEvent(mc.BlkVerify_POSFinishedNotifyV2, bg.posBlockCh); err != nil {
 		log.Error(bg.logExtraInfo(), "订阅错误，消息号", mc.BlkVerify_POSFinishedNotifyV2, "错误：", err)
 		return nil, err
 	}
-	if bg.blockInsertSub, err = mc.SubscribeEvent(mc.HD_NewBlockInsert, bg.blockInsertCh); err != nil {
+	if bg.blockInsertSub, err = man.MsgCenter().SubscribeEvent(mc.HD_NewBlockInsert, bg.blockInsertCh); err != nil {
 		log.Error(bg.logExtraInfo(), "订阅错误，消息号", mc.HD_NewBlockInsert, "错误：", err)
 		return nil, err
 	}
-	if bg.broadcastBlockResultSub, err = mc.SubscribeEvent(mc.HD_BroadcastMiningRsp, bg.broadcastBlockResultCh); err != nil {
+	if bg.broadcastBlockResultSub, err = man.MsgCenter().SubscribeEvent(mc.HD_BroadcastMiningRsp, bg.broadcastBlockResultCh); err != nil {
 		log.Error("区块生成模块", "订阅错误，消息号", mc.HD_BroadcastMiningRsp, "错误：", err)
 		return nil, err
 	}
-	if bg.basePowerSub, err = mc.SubscribeEvent(mc.HD_BasePowerResult, bg.basePowerCh); err != nil {
+	if bg.basePowerSub, err = man.MsgCenter().SubscribeEvent(mc.HD_BasePowerResult, bg.basePowerCh); err != nil {
 		log.Error(bg.logExtraInfo(), "订阅错误，消息号", mc.HD_BasePowerResult, "错误：", err)
 		return nil, err
 	}
-	if bg.recoverySub, err = mc.SubscribeEvent(mc.Leader_RecoveryState, bg.recoveryCh); err != nil {
+	if bg.recoverySub, err = man.MsgCenter().SubscribeEvent(mc.Leader_RecoveryState, bg.recoveryCh); err != nil {
 		log.Error(bg.logExtraInfo(), "订阅错误，消息号", mc.Leader_RecoveryState, "错误：", err)
 		return nil, err
 	}
-	if bg.fullBlockReqSub, err = mc.SubscribeEvent(mc.HD_V2_FullBlockReq, bg.fullBlockReqCh); err != nil {
+	if bg.fullBlockReqSub, err = man.MsgCenter().SubscribeEvent(mc.HD_V2_FullBlockReq, bg.fullBlockReqCh); err != nil {
 		log.Error(bg.logExtraInfo(), "订阅错误，消息号", mc.HD_V2_FullBlockReq, "错误：", err)
 		return nil, err
 	}
-	if bg.fullBlockRspSub, err = mc.SubscribeEvent(mc.HD_V2_FullBlockRsp, bg.fullBlockRspCh); err != nil {
+	if bg.fullBlockRspSub, err = man.MsgCenter().SubscribeEvent(mc.HD_V2_FullBlockRsp, bg.fullBlockRspCh); err != nil {
 		log.Error(bg.logExtraInfo(), "订阅错误，消息号", mc.HD_V2_FullBlockRsp, "错误：", err)
 		return nil, err
 	}
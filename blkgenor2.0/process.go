@@ -8,7 +8,6 @@ import (
 	"sync"
 
 	"github.com/MatrixAINetwork/go-matrix/accounts/signhelper"
-	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/core"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
@@ -94,7 +93,7 @@ func newProcess(number uint64, pm *ProcessManage) *Process {
 		basePowPool:       NewBasePowPool("算力检测池(高度)" + strconv.Itoa(int(number))),
 		blockPool:         NewBlockPool(),
 		broadcastRstCache: make(map[common.Address]*bcBlockRspInfo),
-		FullBlockReqCache: common.NewReuseMsgController(3),
+		FullBlockReqCache: common.NewReuseMsgControllerWithClock(3, pm.clock),
 		msgSender:         nil,
 	}
 
@@ -212,7 +211,7 @@ func (p *Process) startBcBlock() {
 			return
 		}
 
-		if p.curLeader == ca.GetDepositAddress() && p.bcInterval.IsBroadcastNumber(p.number) == false {
+		if p.curLeader == p.pm.ca.GetDepositAddress() && p.bcInterval.IsBroadcastNumber(p.number) == false {
 			p.processBcBlock()
 			p.startAIPick()
 		} else {
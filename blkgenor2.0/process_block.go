@@ -12,7 +12,6 @@ import (
 
 	"errors"
 	"fmt"
-	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/core"
 	"github.com/MatrixAINetwork/go-matrix/core/state"
@@ -219,7 +218,7 @@ func (p *Process) processPowCombine(combinePow bool) {
 		State:  blockData.block.State.Copy(),
 	}
 	log.Info(p.logExtraInfo(), "POW结果组合阶段", "组合完毕", "发送新区块准备完毕消息", p.number, "leader", readyMsg.Header.Leader.Hex())
-	mc.PublishEvent(mc.BlockGenor_NewBlockReady, readyMsg)
+	p.pm.center.PublishEvent(mc.BlockGenor_NewBlockReady, readyMsg)
 
 	p.state = StateBlockInsert
 	p.startBlockInsert(p.curLeader)
@@ -380,8 +379,8 @@ func (p *Process) canInsertBlock() bool {
 			return false
 		}
 
-		if p.nextLeader != ca.GetDepositAddress() {
-			log.Debug(p.logExtraInfo(), "准备进行区块广播,自己不是下个区块leader,高度", p.number, "next leader", p.nextLeader.Hex(), "self", ca.GetDepositAddress().Hex())
+		if p.nextLeader != p.pm.ca.GetDepositAddress() {
+			log.Debug(p.logExtraInfo(), "准备进行区块广播,自己不是下个区块leader,高度", p.number, "next leader", p.nextLeader.Hex(), "self", p.pm.ca.GetDepositAddress().Hex())
 			return false
 		}
 	}
@@ -424,7 +423,7 @@ func (p *Process) insertBlock(header *types.Header, finalTxs []types.CoinSelfTra
 		log.Error(p.logExtraInfo(), "processInsertBlock 失败", err)
 		return err
 	}
-	mc.PublishEvent(mc.BlockInserted, &mc.BlockInsertedMsg{Block: mc.BlockInfo{Hash: block.Hash(), Number: block.NumberU64()}, InsertTime: uint64(time.Now().Unix()), CanonState: stat == core.CanonStatTy})
+	p.pm.center.PublishEvent(mc.BlockInserted, &mc.BlockInsertedMsg{Block: mc.BlockInfo{Hash: block.Hash(), Number: block.NumberU64()}, InsertTime: uint64(p.pm.clock.Now().Unix()), CanonState: stat == core.CanonStatTy})
 	// Broadcast the block and announce chain insertion event
 	hash := block.Hash()
 	var (
@@ -492,7 +491,7 @@ func (p *Process) checkInsertedHeader(bcInterval *mc.BCIntervalInfo, header *typ
 			return false
 		}
 
-		if role, _ := ca.GetAccountOriginalRole(signAccount, header.ParentHash); common.RoleBroadcast != role {
+		if role, _ := p.pm.ca.GetAccountOriginalRole(signAccount, header.ParentHash); common.RoleBroadcast != role {
 			log.Warn(p.logExtraInfo(), "广播区块插入消息非法，签名人不是广播身份, 角色", role.String())
 			return false
 		}
@@ -87,7 +87,7 @@ func (p *Process) processBCBlockVerify() {
 			State:  state.Copy(),
 		}
 		log.Info(p.logExtraInfo(), "广播区块验证完成", "发送新区块准备完毕消息", "高度", p.number, "leader", bcBlock.Header.Leader.Hex())
-		mc.PublishEvent(mc.BlockGenor_NewBlockReady, readyMsg)
+		p.pm.center.PublishEvent(mc.BlockGenor_NewBlockReady, readyMsg)
 
 		p.state = StateBlockInsert
 		p.startBlockInsert(bcBlock.Header.Leader)
@@ -6,7 +6,6 @@ package blkgenorV2
 import (
 	"github.com/MatrixAINetwork/go-matrix/consensus/blkmanage"
 
-	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
 	"github.com/MatrixAINetwork/go-matrix/log"
@@ -61,7 +60,7 @@ func (p *Process) processHeaderGen(AIResult *mc.HD_V2_AIMiningRspMsg) error {
 		TxsCode:                txsCode,
 		ConsensusTurn:          p.consensusTurn,
 		OnlineConsensusResults: onlineConsensusResults,
-		From: ca.GetSignAddress(),
+		From: p.pm.ca.GetSignAddress(),
 	}
 	//send to local block verify module
 	if len(originalTxs) > 0 {
@@ -73,13 +72,13 @@ func (p *Process) processHeaderGen(AIResult *mc.HD_V2_AIMiningRspMsg) error {
 
 func (p *Process) sendHeaderVerifyReq(req *mc.LocalBlockVerifyConsensusReq) {
 	log.Info(p.logExtraInfo(), "本地发送区块验证请求", req.BlkVerifyConsensusReq.Header.HashNoSignsAndNonce().TerminalString(), "高度", p.number)
-	mc.PublishEvent(mc.BlockGenor_HeaderVerifyReq, req)
+	p.pm.center.PublishEvent(mc.BlockGenor_HeaderVerifyReq, req)
 	p.startConsensusReqSender(req.BlkVerifyConsensusReq)
 }
 
 func (p *Process) startConsensusReqSender(req *mc.HD_BlkConsensusReqMsg) {
 	p.closeMsgSender()
-	sender, err := common.NewResendMsgCtrl(req, p.sendConsensusReqFunc, manparams.BlkPosReqSendInterval, manparams.BlkPosReqSendTimes)
+	sender, err := common.NewResendMsgCtrlWithClock(req, p.sendConsensusReqFunc, manparams.BlkPosReqSendInterval, manparams.BlkPosReqSendTimes, p.pm.clock)
 	if err != nil {
 		log.Error(p.logExtraInfo(), "创建req发送器", "失败", "err", err)
 		return
@@ -143,7 +142,7 @@ func (p *Process) processBroadcastBlockGen() error {
 
 func (p *Process) setSignatures(header *types.Header) error {
 	signHash := header.HashNoSignsAndNonce()
-	sign, err := p.signHelper().SignHashWithValidateByAccount(signHash.Bytes(), true, ca.GetDepositAddress())
+	sign, err := p.signHelper().SignHashWithValidateByAccount(signHash.Bytes(), true, p.pm.ca.GetDepositAddress())
 	if err != nil {
 		log.Error(p.logExtraInfo(), "广播区块生成，签名错误", err)
 		return err
@@ -161,7 +160,7 @@ func (p *Process) sendBroadcastRspMsg(bcBlock *mc.BlockData) {
 
 func (p *Process) startBroadcastRspSender(bcBlock *mc.BlockData) {
 	p.closeMsgSender()
-	sender, err := common.NewResendMsgCtrl(bcBlock, p.sendBroadcastRspFunc, manparams.BlkPosReqSendInterval, manparams.BlkPosReqSendTimes)
+	sender, err := common.NewResendMsgCtrlWithClock(bcBlock, p.sendBroadcastRspFunc, manparams.BlkPosReqSendInterval, manparams.BlkPosReqSendTimes, p.pm.clock)
 	if err != nil {
 		log.Error(p.logExtraInfo(), "创建广播区块结果发送器", "失败", "err", err)
 		return
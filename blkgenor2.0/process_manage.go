@@ -8,6 +8,8 @@ import (
 
 	"github.com/MatrixAINetwork/go-matrix/accounts/signhelper"
 	"github.com/MatrixAINetwork/go-matrix/baseinterface"
+	"github.com/MatrixAINetwork/go-matrix/ca"
+	"github.com/MatrixAINetwork/go-matrix/common/mclock"
 	"github.com/MatrixAINetwork/go-matrix/consensus/blkmanage"
 	"github.com/MatrixAINetwork/go-matrix/core"
 	"github.com/MatrixAINetwork/go-matrix/log"
@@ -31,6 +33,9 @@ type ProcessManage struct {
 	olConsensus   *olconsensus.TopNodeService
 	random        *baseinterface.Random
 	manblk        *blkmanage.ManBlkManage
+	center        *mc.Center
+	ca            ca.Provider
+	clock         mclock.Clock
 }
 
 func NewProcessManage(matrix Backend) *ProcessManage {
@@ -46,6 +51,9 @@ func NewProcessManage(matrix Backend) *ProcessManage {
 		olConsensus:   matrix.OLConsensus(),
 		random:        matrix.Random(),
 		manblk:        matrix.ManBlkDeal(),
+		center:        matrix.MsgCenter(),
+		ca:            matrix.CA(),
+		clock:         matrix.Clock(),
 	}
 }
 
@@ -8,7 +8,6 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/consensus/blkmanage"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mc"
-	"time"
 )
 
 func (p *Process) ProcessRecoveryMsg(msg *mc.RecoveryStateMsg) {
@@ -41,7 +40,7 @@ func (p *Process) sendFullBlockReq(hash common.Hash, number uint64, target commo
 			HeaderHash: hash,
 			Number:     number,
 		}
-		p.FullBlockReqCache.AddMsg(hash, reqMsg, time.Now().Unix())
+		p.FullBlockReqCache.AddMsg(hash, reqMsg, p.pm.clock.Now().Unix())
 		log.Debug(p.logExtraInfo(), "状态恢复消息处理", "发送完整区块获取请求消息", "to", target.Hex(), "高度", reqMsg.Number, "hash", reqMsg.HeaderHash.TerminalString())
 		p.pm.hd.SendNodeMsg(mc.HD_V2_FullBlockReq, reqMsg, common.RoleNil, []common.Address{target})
 	}
@@ -121,7 +120,7 @@ func (p *Process) ProcessFullBlockRsp(rsp *mc.HD_V2_FullBlockRspMsg) {
 		Header: rsp.Header,
 		State:  stateDB.Copy(),
 	}
-	mc.PublishEvent(mc.BlockGenor_NewBlockReady, readyMsg)
+	p.pm.center.PublishEvent(mc.BlockGenor_NewBlockReady, readyMsg)
 
 	p.state = StateBlockInsert
 	p.startBlockInsert(rsp.Header.Leader)
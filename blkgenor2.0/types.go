@@ -8,10 +8,13 @@ import (
 
 	"github.com/MatrixAINetwork/go-matrix/accounts/signhelper"
 	"github.com/MatrixAINetwork/go-matrix/baseinterface"
+	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/mclock"
 	"github.com/MatrixAINetwork/go-matrix/consensus/blkmanage"
 	"github.com/MatrixAINetwork/go-matrix/core"
 	"github.com/MatrixAINetwork/go-matrix/event"
+	"github.com/MatrixAINetwork/go-matrix/mc"
 	"github.com/MatrixAINetwork/go-matrix/msgsend"
 	"github.com/MatrixAINetwork/go-matrix/olconsensus"
 	"github.com/MatrixAINetwork/go-matrix/reelection"
@@ -35,6 +38,9 @@ type Backend interface {
 	OLConsensus() *olconsensus.TopNodeService
 	Random() *baseinterface.Random
 	ManBlkDeal() *blkmanage.ManBlkManage
+	MsgCenter() *mc.Center
+	CA() *ca.Service
+	Clock() mclock.Clock
 }
 
 type VrfMsg struct {
@@ -6,7 +6,9 @@ package blkverify
 import (
 	"github.com/MatrixAINetwork/go-matrix/accounts/signhelper"
 	"github.com/MatrixAINetwork/go-matrix/baseinterface"
+	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/mclock"
 	"github.com/MatrixAINetwork/go-matrix/consensus/blkmanage"
 	"github.com/MatrixAINetwork/go-matrix/core"
 	"github.com/MatrixAINetwork/go-matrix/event"
@@ -27,6 +29,9 @@ type Matrix interface {
 	Random() *baseinterface.Random
 	ChainDb() mandb.Database
 	ManBlkDeal() *blkmanage.ManBlkManage
+	MsgCenter() *mc.Center
+	CA() *ca.Service
+	Clock() mclock.Clock
 }
 
 type BlockVerify struct {
@@ -69,25 +74,25 @@ func NewBlockVerify(matrix Matrix) (*BlockVerify, error) {
 	server.processManage = NewProcessManage(matrix)
 
 	var err error
-	if server.roleUpdatedMsgSub, err = mc.SubscribeEvent(mc.CA_RoleUpdated, server.roleUpdatedMsgCh); err != nil {
+	if server.roleUpdatedMsgSub, err = matrix.MsgCenter().SubscribeEvent(mc.CA_RoleUpdated, server.roleUpdatedMsgCh); err != nil {
 		return nil, err
 	}
-	if server.leaderChangeSub, err = mc.SubscribeEvent(mc.Leader_LeaderChangeNotify, server.leaderChangeNotifyCh); err != nil {
+	if server.leaderChangeSub, err = matrix.MsgCenter().SubscribeEvent(mc.Leader_LeaderChangeNotify, server.leaderChangeNotifyCh); err != nil {
 		return nil, err
 	}
-	if server.requestSub, err = mc.SubscribeEvent(mc.HD_BlkConsensusReq, server.requestCh); err != nil {
+	if server.requestSub, err = matrix.MsgCenter().SubscribeEvent(mc.HD_BlkConsensusReq, server.requestCh); err != nil {
 		return nil, err
 	}
-	if server.localVerifyReqSub, err = mc.SubscribeEvent(mc.BlockGenor_HeaderVerifyReq, server.localVerifyReqCh); err != nil {
+	if server.localVerifyReqSub, err = matrix.MsgCenter().SubscribeEvent(mc.BlockGenor_HeaderVerifyReq, server.localVerifyReqCh); err != nil {
 		return nil, err
 	}
-	if server.voteMsgSub, err = mc.SubscribeEvent(mc.HD_BlkConsensusVote, server.voteMsgCh); err != nil {
+	if server.voteMsgSub, err = matrix.MsgCenter().SubscribeEvent(mc.HD_BlkConsensusVote, server.voteMsgCh); err != nil {
 		return nil, err
 	}
-	if server.recoverySub, err = mc.SubscribeEvent(mc.Leader_RecoveryState, server.recoveryCh); err != nil {
+	if server.recoverySub, err = matrix.MsgCenter().SubscribeEvent(mc.Leader_RecoveryState, server.recoveryCh); err != nil {
 		return nil, err
 	}
-	if server.fullBlkReqSub, err = mc.SubscribeEvent(mc.HD_FullBlkReqToBroadcast, server.fullBlkReqCh); err != nil {
+	if server.fullBlkReqSub, err = matrix.MsgCenter().SubscribeEvent(mc.HD_FullBlkReqToBroadcast, server.fullBlkReqCh); err != nil {
 		return nil, err
 	}
 
@@ -116,7 +116,7 @@ func (p *Process) bcFinishedProcess(lvResult verifyResult) {
 		State:       p.curProcessReq.stateDB,
 	}
 	log.Info(p.logExtraInfo(), "广播身份", "请求验证完成, 发出区块共识结果消息", "高度", p.number, "block hash", result.BlockHash.TerminalString())
-	mc.PublishEvent(mc.BlkVerify_VerifyConsensusOK, &result)
+	p.pm.center.PublishEvent(mc.BlkVerify_VerifyConsensusOK, &result)
 
 	posMsg := mc.BlockPOSFinishedV2{
 		Header:      p.curProcessReq.req.Header,
@@ -126,7 +126,7 @@ func (p *Process) bcFinishedProcess(lvResult verifyResult) {
 		Receipts:    p.curProcessReq.receipts,
 		State:       p.curProcessReq.stateDB,
 	}
-	mc.PublishEvent(mc.BlkVerify_POSFinishedNotifyV2, &posMsg)
+	p.pm.center.PublishEvent(mc.BlkVerify_POSFinishedNotifyV2, &posMsg)
 
 	// 运行完成，再次进入start状态
 	p.saveProcessedBCBlockHash(p.curProcessReq.hash)
@@ -0,0 +1,25 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+// Contains the metrics collected by the block verification.
+
+package blkverify
+
+import (
+	"github.com/MatrixAINetwork/go-matrix/metrics"
+)
+
+var (
+	verifyTimer          = metrics.NewRegisteredTimer("blkverify/verify", nil) // Local verification of a request, until the vote
+	verifySuccessCounter = metrics.NewRegisteredCounter("blkverify/verify/success", nil)
+	verifyFailedCounter  = metrics.NewRegisteredCounter("blkverify/verify/failed", nil)
+
+	voteSentCounter     = metrics.NewRegisteredCounter("blkverify/votes/sent", nil)
+	voteReceivedCounter = metrics.NewRegisteredCounter("blkverify/votes/received", nil)
+	voteInvalidCounter  = metrics.NewRegisteredCounter("blkverify/votes/invalid", nil)
+
+	posTimer         = metrics.NewRegisteredTimer("blkverify/pos", nil)       // From the vote until POS passes
+	posVotesGauge    = metrics.NewRegisteredGauge("blkverify/pos/votes", nil) // Valid votes of the last block passing POS
+	posPassedCounter = metrics.NewRegisteredCounter("blkverify/pos/passed", nil)
+)
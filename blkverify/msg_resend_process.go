@@ -41,7 +41,7 @@ func (p *Process) startSendMineReq(posHeader *types.Header) {
 
 	log.Trace(p.logExtraInfo(), "关键时间点", "共识投票完毕，发送挖矿请求", "time", time.Now(), "块高", p.number)
 	p.closeMineReqMsgSender()
-	sender, err := common.NewResendMsgCtrl(reqMsg, p.sendMineReqFunc, manparams.MinerReqSendInterval, 0)
+	sender, err := common.NewResendMsgCtrlWithClock(reqMsg, p.sendMineReqFunc, manparams.MinerReqSendInterval, 0, p.clock())
 	if err != nil {
 		log.Error(p.logExtraInfo(), "创建挖矿请求发送器", "失败", "err", err)
 		return
@@ -96,7 +96,7 @@ func (p *Process) sendMineReqFunc(data interface{}, times uint32) {
 
 func (p *Process) startPosedReqSender(req *mc.HD_BlkConsensusReqMsg) {
 	p.closePosedReqSender()
-	sender, err := common.NewResendMsgCtrl(req, p.sendPosedReqFunc, manparams.PosedReqSendInterval, 0)
+	sender, err := common.NewResendMsgCtrlWithClock(req, p.sendPosedReqFunc, manparams.PosedReqSendInterval, 0, p.clock())
 	if err != nil {
 		log.Error(p.logExtraInfo(), "创建POS完成的req发送器", "失败", "err", err)
 		return
@@ -106,7 +106,7 @@ func (p *Process) startPosedReqSender(req *mc.HD_BlkConsensusReqMsg) {
 
 func (p *Process) startPosedReqSenderV2(req *reqData) {
 	p.closePosedReqSender()
-	sender, err := common.NewResendMsgCtrl(req, p.sendPosedReqFuncV2, manparams.PosedReqSendInterval, 0)
+	sender, err := common.NewResendMsgCtrlWithClock(req, p.sendPosedReqFuncV2, manparams.PosedReqSendInterval, 0, p.clock())
 	if err != nil {
 		log.Error(p.logExtraInfo(), "创建POS完成的req发送器", "失败", "err", err)
 		return
@@ -145,7 +145,7 @@ func (p *Process) sendPosedReqFuncV2(data interface{}, times uint32) {
 	}
 
 	headerTime := reqInfo.req.Header.Time.Int64()
-	curTime := time.Now().Unix()
+	curTime := p.clock().Now().Unix()
 	if curTime <= headerTime+120 {
 		//给广播节点发送区块验证请求(带签名列表)
 		if times == 1 {
@@ -173,7 +173,7 @@ func (p *Process) sendPosedReqFuncV2(data interface{}, times uint32) {
 
 func (p *Process) startVoteMsgSender(vote *mc.HD_ConsensusVote) {
 	p.closeVoteMsgSender()
-	sender, err := common.NewResendMsgCtrl(vote, p.sendVoteMsgFunc, manparams.BlkVoteSendInterval, manparams.BlkVoteSendTimes)
+	sender, err := common.NewResendMsgCtrlWithClock(vote, p.sendVoteMsgFunc, manparams.BlkVoteSendInterval, manparams.BlkVoteSendTimes, p.clock())
 	if err != nil {
 		log.Error(p.logExtraInfo(), "创建投票消息发送器", "失败", "err", err)
 		return
@@ -11,7 +11,9 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/baseinterface"
 
 	"github.com/MatrixAINetwork/go-matrix/accounts/signhelper"
+	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/mclock"
 	"github.com/MatrixAINetwork/go-matrix/core"
 	"github.com/MatrixAINetwork/go-matrix/event"
 	"github.com/MatrixAINetwork/go-matrix/log"
@@ -36,6 +38,9 @@ type ProcessManage struct {
 	chainDB        mandb.Database
 	verifiedBlocks map[common.Hash]*verifiedBlock
 	manblk         *blkmanage.ManBlkManage
+	center         *mc.Center
+	ca             ca.Provider
+	clock          mclock.Clock
 }
 
 func NewProcessManage(matrix Matrix) *ProcessManage {
@@ -52,6 +57,9 @@ func NewProcessManage(matrix Matrix) *ProcessManage {
 		chainDB:        matrix.ChainDb(),
 		verifiedBlocks: make(map[common.Hash]*verifiedBlock),
 		manblk:         matrix.ManBlkDeal(),
+		center:         matrix.MsgCenter(),
+		ca:             matrix.CA(),
+		clock:          matrix.Clock(),
 	}
 }
 
@@ -23,6 +23,7 @@ var (
 	paramErr    = errors.New("param error")
 	reqExistErr = errors.New("req already exist")
 	cantFindErr = errors.New("can't find req in cache")
+	tooManyErr  = errors.New("too many req")
 )
 
 type reqType uint8
@@ -199,7 +200,7 @@ func (rc *reqCache) AddReq(req *mc.HD_BlkConsensusReqMsg, isDBRecovery bool) (*r
 		}
 	}
 	if fromSize >= rc.fromLimit {
-		return nil, errors.Errorf("req from[%s] is too many(%d)", req.From.Hex(), fromSize)
+		return nil, errors.Wrapf(tooManyErr, "req from[%s] count(%d)", req.From.Hex(), fromSize)
 	}
 
 	reqType := reqTypeUnknownReq
@@ -254,7 +255,7 @@ func (rc *reqCache) AddFullBlkReq(req *mc.HD_FullBlkReqToBroadcastMsg) (*reqData
 		}
 	}
 	if fromSize >= rc.fromLimit {
-		return nil, errors.Errorf("req from[%s] is too many(%d)", req.From.Hex(), fromSize)
+		return nil, errors.Wrapf(tooManyErr, "req from[%s] count(%d)", req.From.Hex(), fromSize)
 	}
 
 	reqType := reqTypeUnknownReq
@@ -5,9 +5,9 @@ package blkverify
 
 import (
 	"container/list"
-	"time"
 
 	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/mclock"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/pkg/errors"
 
@@ -27,15 +27,17 @@ type unverifiedVotePool struct {
 	timeoutInterval       int64                                        // 超时时间
 	AccountVoteCountLimit int                                          // 每个用户的投票数量限制
 	logInfo               string
+	clock                 mclock.Clock
 }
 
-func newUnverifiedVotePool(logInfo string) *unverifiedVotePool {
+func newUnverifiedVotePool(logInfo string, clock mclock.Clock) *unverifiedVotePool {
 	return &unverifiedVotePool{
 		voteMap:               make(map[common.Address]map[common.Hash]*voteInfo),
 		timeIndex:             list.New(),
 		timeoutInterval:       manparams.VotePoolTimeout,
 		AccountVoteCountLimit: manparams.VotePoolCountLimit,
 		logInfo:               logInfo,
+		clock:                 clock,
 	}
 }
 
@@ -45,7 +47,7 @@ func (vp *unverifiedVotePool) AddVote(signHash common.Hash, sign common.Signatur
 	}
 
 	vote := &voteInfo{
-		time:     time.Now().UnixNano() / 1000000,
+		time:     vp.clock.Now().UnixNano() / 1000000,
 		sign:     sign,
 		signHash: signHash,
 		from:     from,
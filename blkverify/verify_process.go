@@ -8,8 +8,8 @@ import (
 	"time"
 
 	"github.com/MatrixAINetwork/go-matrix/accounts/signhelper"
-	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/mclock"
 	"github.com/MatrixAINetwork/go-matrix/consensus/blkmanage"
 	"github.com/MatrixAINetwork/go-matrix/core"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
@@ -17,6 +17,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mandb"
 	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/p2p"
 	"github.com/MatrixAINetwork/go-matrix/params"
 	"github.com/MatrixAINetwork/go-matrix/params/manversion"
 	"github.com/MatrixAINetwork/go-matrix/reelection"
@@ -84,6 +85,8 @@ func (vr verifyResult) String() string {
 var (
 	ErrParamIsNil = errors.New("param is nil")
 	ErrExistVote  = errors.New("vote is existed")
+
+	errVoteSignAccount = errors.New("vote sign account mismatch")
 )
 
 type Process struct {
@@ -101,6 +104,8 @@ type Process struct {
 	mineReqMsgSender *common.ResendMsgCtrl
 	posedReqSender   *common.ResendMsgCtrl
 	bcProcessedHash  []common.Hash
+	verifyStart      time.Time // Start of the local verification of the current request
+	posStart         time.Time // Start of the POS of the current request
 }
 
 func newProcess(number uint64, pm *ProcessManage) *Process {
@@ -120,7 +125,7 @@ func newProcess(number uint64, pm *ProcessManage) *Process {
 		state:            StateIdle,
 		curProcessReq:    nil,
 		reqCache:         newReqCache(pm.bc),
-		unverifiedVotes:  newUnverifiedVotePool(pm.logExtraInfo()),
+		unverifiedVotes:  newUnverifiedVotePool(pm.logExtraInfo(), pm.clock),
 		pm:               pm,
 		txsAcquireSeq:    0,
 		voteMsgSender:    nil,
@@ -273,6 +278,9 @@ func (p *Process) AddFullBlkReq(reqMsg *mc.HD_FullBlkReqToBroadcastMsg) {
 		if err != reqExistErr {
 			log.Trace(p.logExtraInfo(), "请求添加缓存失败", err, "from", reqMsg.From, "高度", p.number)
 		}
+		if errors.Cause(err) == tooManyErr {
+			p2p.ServerP2p.PenalizeByAddress(reqMsg.From, p2p.OffenceSpam)
+		}
 		return
 	}
 	log.Info(p.logExtraInfo(), "区块共识请求处理", "请求添加缓存成功", "from", reqMsg.From.Hex(), "高度", p.number, "reqHash", reqData.hash.TerminalString(), "leader", reqMsg.Header.Leader.Hex())
@@ -293,6 +301,9 @@ func (p *Process) AddReq(reqMsg *mc.HD_BlkConsensusReqMsg) {
 		if err != reqExistErr {
 			log.Trace(p.logExtraInfo(), "请求添加缓存失败", err, "from", reqMsg.From, "高度", p.number)
 		}
+		if errors.Cause(err) == tooManyErr {
+			p2p.ServerP2p.PenalizeByAddress(reqMsg.From, p2p.OffenceSpam)
+		}
 		return
 	}
 	log.Info(p.logExtraInfo(), "区块共识请求处理", "请求添加缓存成功", "from", reqMsg.From.Hex(), "高度", p.number, "reqHash", reqData.hash.TerminalString(), "leader", reqMsg.Header.Leader.Hex())
@@ -360,9 +371,14 @@ func (p *Process) HandleVote(signHash common.Hash, vote common.Signature, from c
 
 	verifiedVote, err := p.verifyVote(signHash, vote, from, p.curProcessReq.req.Header.ParentHash, true)
 	if err != nil {
+		voteInvalidCounter.Inc(1)
 		log.Info(p.logExtraInfo(), "处理投票消息", "签名验证失败", "err", err)
+		if errors.Cause(err) == errVoteSignAccount {
+			p2p.ServerP2p.PenalizeByAddress(from, p2p.OffenceInvalidConsensusMsg)
+		}
 		return
 	}
+	voteReceivedCounter.Inc(1)
 
 	p.curProcessReq.addVote(verifiedVote)
 	p.processDPOSOnce()
@@ -393,6 +409,7 @@ func (p *Process) startReqVerifyCommon() {
 	}
 
 	p.curProcessReq = req
+	p.verifyStart = time.Now()
 	log.Trace(p.logExtraInfo(), "请求验证阶段", "开始", "高度", p.number, "HeaderHash", p.curProcessReq.hash.TerminalString(), "parent hash", p.curProcessReq.req.Header.ParentHash.TerminalString(), "之前状态", p.state.String())
 	p.state = StateReqVerify
 	p.processReqOnce()
@@ -476,12 +493,12 @@ func (p *Process) processTxsAcquire(txsAcquireCh <-chan *core.RetChan, seq int)
 	log.Trace(p.logExtraInfo(), "交易获取协程", "启动", "当前身份", p.role.String(), "高度", p.number)
 	defer log.Trace(p.logExtraInfo(), "交易获取协程", "退出", "当前身份", p.role.String(), "高度", p.number)
 
-	outTime := time.NewTimer(time.Second * 5)
+	outTime := p.clock().NewTimer(time.Second * 5)
 	select {
 	case txsResult := <-txsAcquireCh:
 		go p.StartVerifyTxsAndState(txsResult)
 
-	case <-outTime.C:
+	case <-outTime.C():
 		log.Trace(p.logExtraInfo(), "交易获取协程", "获取交易超时", "高度", p.number, "seq", seq)
 		go p.ProcessTxsAcquireTimeOut(seq)
 		return
@@ -554,18 +571,20 @@ func (p *Process) verifyTxsAndState() {
 
 func (p *Process) sendVote(validate bool) {
 	signHash := p.curProcessReq.hash
-	sign, err := p.signHelper().SignHashWithValidate(signHash.Bytes(), validate, p.curProcessReq.req.Header.ParentHash)
+	turn := p.curProcessReq.req.ConsensusTurn.TotalTurns()
+	sign, err := p.signHelper().SignVoteWithValidate(mc.HD_BlkConsensusVote, p.number, turn, signHash, signHash, validate, p.curProcessReq.req.Header.ParentHash)
 	if err != nil {
 		log.Error(p.logExtraInfo(), "投票签名失败", err, "高度", p.number)
 		return
 	}
 
 	p.startVoteMsgSender(&mc.HD_ConsensusVote{SignHash: signHash, Sign: sign, Number: p.number})
+	voteSentCounter.Inc(1)
 
 	//将自己的投票加入票池
 	p.curProcessReq.addVote(&common.VerifiedSign{
 		Sign:     sign,
-		Account:  ca.GetDepositAddress(),
+		Account:  p.pm.ca.GetDepositAddress(),
 		Validate: true,
 		Stock:    0,
 	})
@@ -581,7 +600,7 @@ func (p *Process) notifyVerifiedBlock() {
 		Receipts:    p.curProcessReq.receipts,
 		State:       p.curProcessReq.stateDB,
 	}
-	mc.PublishEvent(mc.BlkVerify_VerifyConsensusOK, &result)
+	p.pm.center.PublishEvent(mc.BlkVerify_VerifyConsensusOK, &result)
 }
 
 func (p *Process) startDPOSVerify(lvResult verifyResult) {
@@ -596,6 +615,15 @@ func (p *Process) startDPOSVerify(lvResult verifyResult) {
 	}
 
 	log.Trace(p.logExtraInfo(), "开始POS阶段,验证结果", lvResult.String(), "高度", p.number)
+	if !p.verifyStart.IsZero() && p.curProcessReq.reqType != reqTypeLocalReq {
+		verifyTimer.UpdateSince(p.verifyStart)
+	}
+	if lvResult == localVerifyResultSuccess {
+		verifySuccessCounter.Inc(1)
+	} else {
+		verifyFailedCounter.Inc(1)
+	}
+	p.posStart = time.Now()
 	if lvResult == localVerifyResultSuccess {
 		p.sendVote(true)
 		p.notifyVerifiedBlock()
@@ -652,6 +680,9 @@ func (p *Process) processDPOSOnce() {
 		return
 	}
 	log.Info(p.logExtraInfo(), "POS验证处理", "POS通过", "正确签名数量", len(rightSigns), "高度", p.number)
+	posTimer.UpdateSince(p.posStart)
+	posVotesGauge.Update(int64(len(rightSigns)))
+	posPassedCounter.Inc(1)
 	p.curProcessReq.posFinished = true
 	p.curProcessReq.req.Header.Signatures = rightSigns
 
@@ -678,7 +709,7 @@ func (p *Process) finishedProcess() {
 			ConsensusTurn: p.curProcessReq.req.ConsensusTurn,
 			TxsCode:       p.curProcessReq.req.TxsCode,
 		}
-		mc.PublishEvent(mc.BlkVerify_POSFinishedNotify, &notify)
+		p.pm.center.PublishEvent(mc.BlkVerify_POSFinishedNotify, &notify)
 
 		posMsg := mc.BlockPOSFinishedV2{
 			Header:      p.curProcessReq.req.Header,
@@ -688,7 +719,7 @@ func (p *Process) finishedProcess() {
 			Receipts:    p.curProcessReq.receipts,
 			State:       p.curProcessReq.stateDB,
 		}
-		mc.PublishEvent(mc.BlkVerify_POSFinishedNotifyV2, &posMsg)
+		p.pm.center.PublishEvent(mc.BlkVerify_POSFinishedNotifyV2, &posMsg)
 	}
 
 	//给矿工发送区块验证结果
@@ -729,7 +760,7 @@ func (p *Process) verifyVote(signHash common.Hash, vote common.Signature, from c
 	}
 
 	if verifyFrom && signAccount != from {
-		return nil, errors.Errorf("vote sign account[%s] != from account[%s]", signAccount.Hex(), from.Hex())
+		return nil, errors.Wrapf(errVoteSignAccount, "sign account[%s] != from account[%s]", signAccount.Hex(), from.Hex())
 	}
 
 	return &common.VerifiedSign{
@@ -801,3 +832,5 @@ func (p *Process) logExtraInfo() string { return p.pm.logExtraInfo() }
 func (p *Process) eventMux() *event.TypeMux { return p.pm.event }
 
 func (p *Process) ChainDb() mandb.Database { return p.pm.chainDB }
+
+func (p *Process) clock() mclock.Clock { return p.pm.clock }
@@ -32,8 +32,52 @@ type TopologyGraphReader interface {
 	GetSuperSeq(blockHash common.Hash) (uint64, error)
 }
 
-// Identity stand for node's identity.
-type Identity struct {
+// Provider is the view of the node identity and the network topology the
+// consumers of the ca service need.
+type Provider interface {
+	GetRole() common.RoleType
+	GetHash() common.Hash
+	GetSignAddress() common.Address
+	GetDepositAddress() common.Address
+	GetRolesByGroup(roleType common.RoleType) []common.Address
+	GetRolesByGroupWithNextElect(roleType common.RoleType) []common.Address
+	GetTopologyByNumber(reqTypes common.RoleType, number uint64) (*mc.TopologyGraph, error)
+	GetTopologyByHash(reqTypes common.RoleType, hash common.Hash) (*mc.TopologyGraph, error)
+	GetAccountOriginalRole(account common.Address, hash common.Hash) (common.RoleType, error)
+	ConvertSignToDepositAddress(address common.Address) (common.Address, error)
+	ConvertDepositToSignAddress(address common.Address) (common.Address, error)
+	GetElectedByHash(hash common.Hash) ([]vm.DepositDetail, error)
+}
+
+// LinkProvider is the part of the service the p2p layer uses to decide which
+// nodes to keep connected to and where to send messages.
+type LinkProvider interface {
+	Provider
+	InDuration() bool
+	GetRolesByGroupOnlyNextElect(roleType common.RoleType) []common.Address
+	GetTopologyInLinker() map[common.RoleType][]common.Address
+	GetGapValidator() []common.Address
+	GetDropNode() []common.Address
+}
+
+// DepositReader provides the deposits of a block. A topology reader which
+// implements it is used for the deposits too, else they are read from the
+// chain state.
+type DepositReader interface {
+	GetDepositListByHash(hash common.Hash, roleType common.RoleType) ([]vm.DepositDetail, error)
+	GetDepositAndWithDrawListByHash(hash common.Hash) ([]vm.DepositDetail, error)
+	GetAllDepositByHash(hash common.Hash) ([]vm.DepositDetail, error)
+}
+
+// BCIntervalReader provides the broadcast interval of a block. A topology
+// reader which implements it is used for the interval too, else it is read
+// from the chain config.
+type BCIntervalReader interface {
+	GetBroadcastIntervalByHash(blockHash common.Hash) (*mc.BCIntervalInfo, error)
+}
+
+// Service stand for node's identity.
+type Service struct {
 	// self nodeId
 	self discover.NodeID
 	addr common.Address
@@ -45,6 +89,9 @@ type Identity struct {
 
 	trChan            chan TopologyGraphReader
 	topologyReader    TopologyGraphReader
+	depositReader     DepositReader
+	bcIntervalReader  BCIntervalReader
+	center            *mc.Center
 	topology          *mc.TopologyGraph
 	prevElect         []common.Elect
 	currentNodes      []common.Address
@@ -79,21 +126,54 @@ type Identity struct {
 	addrByGroup map[common.RoleType][]common.Address
 }
 
-var ide = newIde()
+// chainDeposits reads the deposits from the chain state.
+type chainDeposits struct{}
 
-func newIde() *Identity {
-	return &Identity{
+func (chainDeposits) GetDepositListByHash(hash common.Hash, roleType common.RoleType) ([]vm.DepositDetail, error) {
+	return depoistInfo.GetDepositListByHash(hash, roleType)
+}
+
+func (chainDeposits) GetDepositAndWithDrawListByHash(hash common.Hash) ([]vm.DepositDetail, error) {
+	return depoistInfo.GetDepositAndWithDrawListByHash(hash)
+}
+
+func (chainDeposits) GetAllDepositByHash(hash common.Hash) ([]vm.DepositDetail, error) {
+	return depoistInfo.GetAllDepositByHash(hash)
+}
+
+// chainBCInterval reads the broadcast interval from the chain config.
+type chainBCInterval struct{}
+
+func (chainBCInterval) GetBroadcastIntervalByHash(blockHash common.Hash) (*mc.BCIntervalInfo, error) {
+	return manparams.GetBCIntervalInfoByHash(blockHash)
+}
+
+var local = NewService(common.Address{}, mc.DefaultCenter())
+
+// NewService creates the identity service of the node with the given sign
+// address. It follows the blocks published on center and publishes the role
+// updates there.
+func NewService(addr common.Address, center *mc.Center) *Service {
+	return &Service{
+		addr:        addr,
+		center:      center,
 		quit:        make(chan struct{}),
 		currentRole: common.RoleNil,
 		duration:    false,
 		trChan:      make(chan TopologyGraphReader, 1),
 		topology:    new(mc.TopologyGraph),
 		prevElect:   make([]common.Elect, 0),
+		log:         log.New(),
 	}
 }
 
+// DefaultService returns the service used by the package level functions.
+func DefaultService() *Service {
+	return local
+}
+
 // init to do something before run.
-func (ide *Identity) init(id discover.NodeID, path string, addr common.Address) {
+func (ide *Service) init(id discover.NodeID, path string, addr common.Address) {
 	ide.once.Do(func() {
 		// check bootNode and set identity
 		ide.self = id
@@ -104,8 +184,13 @@ func (ide *Identity) init(id discover.NodeID, path string, addr common.Address)
 
 // Run this Identity.
 func Start(id discover.NodeID, path string, addr common.Address) {
-	ide.init(id, path, addr)
+	local.init(id, path, addr)
+	local.Start()
+}
 
+// Start runs the service until Stop is called. It waits for the topology
+// reader before following the blocks.
+func (ide *Service) Start() {
 	defer func() {
 		ide.sub.Unsubscribe()
 
@@ -115,116 +200,136 @@ func Start(id discover.NodeID, path string, addr common.Address) {
 
 	select {
 	case tr := <-ide.trChan:
-		ide.topologyReader = tr
+		ide.setReader(tr)
 	case <-ide.quit:
 		return
 	}
 
 	ide.blockChan = make(chan *types.Block)
-	ide.sub, _ = mc.SubscribeEvent(mc.NewBlockMessage, ide.blockChan)
+	ide.sub, _ = ide.center.SubscribeEvent(mc.NewBlockMessage, ide.blockChan)
 	log.Info("CA", "订阅区块事件", "完成")
-	mc.PublishEvent(mc.CA_ReqCurrentBlock, struct{}{})
+	ide.center.PublishEvent(mc.CA_ReqCurrentBlock, struct{}{})
 
 	for {
 		select {
 		case block := <-ide.blockChan:
-			header := block.Header()
-			hash := block.Hash()
-			ide.currentHeight = header.Number
-			ide.hash = block.Hash()
+			ide.ProcessHeader(block.Header())
+		case <-ide.quit:
+			return
+		}
+	}
+}
 
-			log.Info("CA", "leader", header.Leader, "height", header.Number.Uint64(), "block hash", hash)
+// setReader sets the topology reader and the deposit and broadcast interval
+// readers it provides.
+func (ide *Service) setReader(tr TopologyGraphReader) {
+	ide.topologyReader = tr
+	ide.depositReader = chainDeposits{}
+	if dr, ok := tr.(DepositReader); ok {
+		ide.depositReader = dr
+	}
+	ide.bcIntervalReader = chainBCInterval{}
+	if br, ok := tr.(BCIntervalReader); ok {
+		ide.bcIntervalReader = br
+	}
+}
 
-			// init current height deposit
-			ide.deposit, _ = GetElectedByHeightWithdrawByHash(header.Hash())
+// ProcessHeader updates the identity and topology to the given block and
+// publishes the role of the node.
+func (ide *Service) ProcessHeader(header *types.Header) {
+	hash := header.Hash()
+	ide.currentHeight = header.Number
+	ide.hash = hash
 
-			// get broadcast interval
-			bcInterval, err := manparams.GetBCIntervalInfoByHash(hash)
-			if err != nil {
-				ide.log.Error("get broadcast interval", "error", err)
-				continue
-			}
+	log.Info("CA", "leader", header.Leader, "height", header.Number.Uint64(), "block hash", hash)
 
-			// do topology
-			tg, err := ide.topologyReader.GetTopologyGraphByHash(hash)
-			if err != nil {
-				ide.log.Error("get topology", "error", err)
-				continue
-			}
-			newTg := &mc.TopologyGraph{}
-			for _, value := range tg.NodeList {
-				sAddr, err := ConvertDepositToSignAddress(value.Account)
-				if err != nil {
-					log.Error("convert address failed", "error", err)
-					continue
-				}
-				newTg.NodeList = append(newTg.NodeList, mc.TopologyNodeInfo{sAddr, value.Position, value.Type, value.NodeNumber})
-			}
-			newTg.CurNodeNumber = tg.CurNodeNumber
-			ide.topology = newTg
+	// init current height deposit
+	ide.deposit, _ = ide.depositReader.GetDepositAndWithDrawListByHash(hash)
 
-			// get special accounts
-			broadcastAccounts, err := ide.topologyReader.GetBroadcastAccounts(hash)
-			if err != nil {
-				log.Error("ca", "get broadcast accounts err", err)
-				continue
-			}
-			ide.broadcastAccounts = broadcastAccounts
+	// get broadcast interval
+	bcInterval, err := ide.bcIntervalReader.GetBroadcastIntervalByHash(hash)
+	if err != nil {
+		ide.log.Error("get broadcast interval", "error", err)
+		return
+	}
 
-			innerMiners, err := ide.topologyReader.GetInnerMinersAccount(hash)
-			if err != nil {
-				log.Error("ca", "get inner miner accounts err", err)
-				continue
-			}
-			ide.innerMiners = innerMiners
-			// get elect
-			elect, err := ide.topologyReader.GetNextElectByHash(hash)
-			if err != nil {
-				ide.log.Error("get next elect", "error", err)
-				continue
-			}
-			// get super seq
-			superSeq, err := ide.topologyReader.GetSuperSeq(hash)
-			if err != nil {
-				ide.log.Error("get super seq", "error", err)
-				continue
-			}
-			newElect := make([]common.Elect, 0)
-			for _, val := range elect {
-				sAddr, err := ConvertDepositToSignAddress(val.Account)
-				if err != nil {
-					log.Error("convert address failed", "error", err)
-					continue
-				}
-				newElect = append(newElect, common.Elect{Account: sAddr, Stock: val.Stock, Type: val.Type, VIP: val.VIP})
-			}
-			ide.prevElect = newElect
-
-			// init topology
-			initCurrentTopology()
-			initNowTopologyResult()
-
-			// get nodes in buckets
-			nodesInBuckets := getNodesInBuckets(header.Hash())
-
-			// send role message to elect
-			caMsg := &mc.RoleUpdatedMsg{Role: ide.currentRole, BlockNum: header.Number.Uint64(), BlockHash: hash, Leader: header.Leader, SuperSeq: superSeq, Version: string(header.Version)}
-			mc.PublishEvent(mc.CA_RoleUpdated, caMsg)
-			log.Info("ca publish identity", "data", caMsg)
-			// get nodes in buckets and send to buckets
-			mc.PublishEvent(mc.BlockToBuckets, mc.BlockToBucket{Ms: nodesInBuckets, Height: block.Header().Number, Role: ide.currentRole})
-			// send identity to linker
-			mc.PublishEvent(mc.BlockToLinkers, mc.BlockToLinker{Height: header.Number, BroadCastInterval: bcInterval, Role: ide.currentRole})
-			mc.PublishEvent(mc.SendSyncRole, mc.SyncIdEvent{Role: ide.currentRole}) //lb
-			mc.PublishEvent(mc.TxPoolManager, ide.currentRole)
-		case <-ide.quit:
-			return
+	// do topology
+	tg, err := ide.topologyReader.GetTopologyGraphByHash(hash)
+	if err != nil {
+		ide.log.Error("get topology", "error", err)
+		return
+	}
+	newTg := &mc.TopologyGraph{}
+	for _, value := range tg.NodeList {
+		sAddr, err := ide.ConvertDepositToSignAddress(value.Account)
+		if err != nil {
+			log.Error("convert address failed", "error", err)
+			continue
+		}
+		newTg.NodeList = append(newTg.NodeList, mc.TopologyNodeInfo{sAddr, value.Position, value.Type, value.NodeNumber})
+	}
+	newTg.CurNodeNumber = tg.CurNodeNumber
+	ide.topology = newTg
+
+	// get special accounts
+	broadcastAccounts, err := ide.topologyReader.GetBroadcastAccounts(hash)
+	if err != nil {
+		log.Error("ca", "get broadcast accounts err", err)
+		return
+	}
+	ide.broadcastAccounts = broadcastAccounts
+
+	innerMiners, err := ide.topologyReader.GetInnerMinersAccount(hash)
+	if err != nil {
+		log.Error("ca", "get inner miner accounts err", err)
+		return
+	}
+	ide.innerMiners = innerMiners
+	// get elect
+	elect, err := ide.topologyReader.GetNextElectByHash(hash)
+	if err != nil {
+		ide.log.Error("get next elect", "error", err)
+		return
+	}
+	// get super seq
+	superSeq, err := ide.topologyReader.GetSuperSeq(hash)
+	if err != nil {
+		ide.log.Error("get super seq", "error", err)
+		return
+	}
+	newElect := make([]common.Elect, 0)
+	for _, val := range elect {
+		sAddr, err := ide.ConvertDepositToSignAddress(val.Account)
+		if err != nil {
+			log.Error("convert address failed", "error", err)
+			continue
 		}
+		newElect = append(newElect, common.Elect{Account: sAddr, Stock: val.Stock, Type: val.Type, VIP: val.VIP})
 	}
+	ide.prevElect = newElect
+
+	// init topology
+	ide.initCurrentTopology()
+	ide.initNowTopologyResult()
+
+	// get nodes in buckets
+	nodesInBuckets := ide.getNodesInBuckets(header.Hash())
+
+	// send role message to elect
+	caMsg := &mc.RoleUpdatedMsg{Role: ide.currentRole, BlockNum: header.Number.Uint64(), BlockHash: hash, Leader: header.Leader, SuperSeq: superSeq, Version: string(header.Version)}
+	ide.center.PublishEvent(mc.CA_RoleUpdated, caMsg)
+	roleGauge.Update(int64(ide.currentRole))
+	log.Info("ca publish identity", "data", caMsg)
+	// get nodes in buckets and send to buckets
+	ide.center.PublishEvent(mc.BlockToBuckets, mc.BlockToBucket{Ms: nodesInBuckets, Height: header.Number, Role: ide.currentRole})
+	// send identity to linker
+	ide.center.PublishEvent(mc.BlockToLinkers, mc.BlockToLinker{Height: header.Number, BroadCastInterval: bcInterval, Role: ide.currentRole})
+	ide.center.PublishEvent(mc.SendSyncRole, mc.SyncIdEvent{Role: ide.currentRole}) //lb
+	ide.center.PublishEvent(mc.TxPoolManager, ide.currentRole)
 }
 
 // Stop this Identity.
-func Stop() {
+func (ide *Service) Stop() {
 	ide.log.Info("identity stop")
 
 	ide.lock.Lock()
@@ -233,7 +338,7 @@ func Stop() {
 }
 
 // InitCurrentTopology init current topology.
-func initCurrentTopology() {
+func (ide *Service) initCurrentTopology() {
 	log.Info("current topology", "info:", ide.topology)
 	ide.lock.Lock()
 	// change default role
@@ -266,7 +371,7 @@ func initCurrentTopology() {
 }
 
 // initNowTopologyResult
-func initNowTopologyResult() {
+func (ide *Service) initNowTopologyResult() {
 	ide.lock.Lock()
 	ide.addrByGroup = make(map[common.RoleType][]common.Address)
 	for _, node := range ide.topology.NodeList {
@@ -285,12 +390,12 @@ func initNowTopologyResult() {
 }
 
 // SetTopologyReader
-func SetTopologyReader(topologyReader TopologyGraphReader) {
+func (ide *Service) SetTopologyReader(topologyReader TopologyGraphReader) {
 	ide.trChan <- topologyReader
 }
 
 // GetRolesByGroup
-func GetRolesByGroup(roleType common.RoleType) (result []common.Address) {
+func (ide *Service) GetRolesByGroup(roleType common.RoleType) (result []common.Address) {
 	ide.lock.RLock()
 	defer ide.lock.RUnlock()
 
@@ -305,8 +410,8 @@ func GetRolesByGroup(roleType common.RoleType) (result []common.Address) {
 }
 
 // GetRolesByGroupWithBackup
-func GetRolesByGroupWithNextElect(roleType common.RoleType) (result []common.Address) {
-	result = GetRolesByGroup(roleType)
+func (ide *Service) GetRolesByGroupWithNextElect(roleType common.RoleType) (result []common.Address) {
+	result = ide.GetRolesByGroup(roleType)
 	for _, elect := range ide.prevElect {
 		temp := true
 		role := elect.Type.Transfer2CommonRole()
@@ -325,7 +430,7 @@ func GetRolesByGroupWithNextElect(roleType common.RoleType) (result []common.Add
 }
 
 // GetRolesByGroupOnlyBackup
-func GetRolesByGroupOnlyNextElect(roleType common.RoleType) (result []common.Address) {
+func (ide *Service) GetRolesByGroupOnlyNextElect(roleType common.RoleType) (result []common.Address) {
 	for _, elect := range ide.prevElect {
 		role := elect.Type.Transfer2CommonRole()
 		if (role & roleType) != 0 {
@@ -336,20 +441,20 @@ func GetRolesByGroupOnlyNextElect(roleType common.RoleType) (result []common.Add
 }
 
 // Get self identity.
-func GetRole() (role common.RoleType) {
+func (ide *Service) GetRole() (role common.RoleType) {
 	ide.lock.RLock()
 	defer ide.lock.RUnlock()
 
 	return ide.currentRole
 }
 
-func GetHeight() *big.Int {
+func (ide *Service) GetHeight() *big.Int {
 	ide.lock.RLock()
 	defer ide.lock.RUnlock()
 
 	return ide.currentHeight
 }
-func GetHash() common.Hash {
+func (ide *Service) GetHash() common.Hash {
 	ide.lock.RLock()
 	defer ide.lock.RUnlock()
 
@@ -357,7 +462,7 @@ func GetHash() common.Hash {
 }
 
 // InDuration
-func InDuration() bool {
+func (ide *Service) InDuration() bool {
 	ide.lock.RLock()
 	defer ide.lock.RUnlock()
 
@@ -394,8 +499,19 @@ func GetElectedByHeightWithdrawByHash(hash common.Hash) ([]vm.DepositDetail, err
 	return depoistInfo.GetDepositAndWithDrawListByHash(hash)
 }
 
+// GetElectedByHash returns all deposits of the given block.
+func (ide *Service) GetElectedByHash(hash common.Hash) ([]vm.DepositDetail, error) {
+	ide.lock.RLock()
+	reader := ide.depositReader
+	ide.lock.RUnlock()
+	if reader == nil {
+		reader = chainDeposits{}
+	}
+	return reader.GetAllDepositByHash(hash)
+}
+
 // GetNodeNumber
-func GetNodeNumber() (uint32, error) {
+func (ide *Service) GetNodeNumber() (uint32, error) {
 	ide.lock.RLock()
 	defer ide.lock.RUnlock()
 
@@ -408,7 +524,7 @@ func GetNodeNumber() (uint32, error) {
 }
 
 // GetGapValidator
-func GetGapValidator() (rlt []common.Address) {
+func (ide *Service) GetGapValidator() (rlt []common.Address) {
 	ori, err := ide.topologyReader.GetOriginalElectByHash(ide.hash)
 	if err != nil {
 		ide.log.Error("ca", "GetOriginalElect, error:", err)
@@ -417,7 +533,7 @@ func GetGapValidator() (rlt []common.Address) {
 
 	for _, or := range ori {
 		if or.Type >= common.ElectRoleValidator {
-			sAddr, err := ConvertDepositToSignAddress(or.Account)
+			sAddr, err := ide.ConvertDepositToSignAddress(or.Account)
 			if err != nil {
 				log.Error("convert address failed", "error", err)
 				continue
@@ -429,8 +545,8 @@ func GetGapValidator() (rlt []common.Address) {
 }
 
 // getNodesInBuckets get miner nodes that should be in buckets.
-func getNodesInBuckets(hash common.Hash) (result []common.Address) {
-	electedMiners, _ := GetElectedByHeightAndRoleByHash(hash, common.RoleMiner)
+func (ide *Service) getNodesInBuckets(hash common.Hash) (result []common.Address) {
+	electedMiners, _ := ide.depositReader.GetDepositListByHash(hash, common.RoleMiner)
 
 	msMap := make(map[common.Address]struct{})
 	for _, m := range electedMiners {
@@ -454,7 +570,7 @@ func getNodesInBuckets(hash common.Hash) (result []common.Address) {
 }
 
 // GetTopologyInLinker
-func GetTopologyInLinker() (result map[common.RoleType][]common.Address) {
+func (ide *Service) GetTopologyInLinker() (result map[common.RoleType][]common.Address) {
 	ide.lock.RLock()
 	defer ide.lock.RUnlock()
 
@@ -488,7 +604,7 @@ func GetTopologyInLinker() (result map[common.RoleType][]common.Address) {
 }
 
 // GetDropNode
-func GetDropNode() (result []common.Address) {
+func (ide *Service) GetDropNode() (result []common.Address) {
 	for _, fn := range ide.frontNodes {
 		temp := false
 		for _, cn := range ide.currentNodes {
@@ -505,7 +621,7 @@ func GetDropNode() (result []common.Address) {
 }
 
 // GetSelfAddress
-func GetSignAddress() common.Address {
+func (ide *Service) GetSignAddress() common.Address {
 	ide.lock.RLock()
 	defer ide.lock.RUnlock()
 
@@ -513,7 +629,7 @@ func GetSignAddress() common.Address {
 }
 
 // GetSelfDepositAddress
-func GetDepositAddress() common.Address {
+func (ide *Service) GetDepositAddress() common.Address {
 	ide.lock.RLock()
 	defer ide.lock.RUnlock()
 
@@ -532,7 +648,7 @@ func GetDepositAddress() common.Address {
 		}
 	}
 
-	depositAccount, err := ConvertSignToDepositAddress(ide.addr)
+	depositAccount, err := ide.ConvertSignToDepositAddress(ide.addr)
 	if err != nil {
 		log.Error("ca", "获取自己的抵押账户失败", err)
 		return common.Address{}
@@ -541,7 +657,7 @@ func GetDepositAddress() common.Address {
 }
 
 // GetSelfLevel
-func GetSelfLevel() int {
+func (ide *Service) GetSelfLevel() int {
 	switch {
 	case ide.currentRole > common.RoleBucket:
 		return TopNode
@@ -556,15 +672,15 @@ func GetSelfLevel() int {
 }
 
 // GetTopologyByNumber
-func GetTopologyByNumber(reqTypes common.RoleType, number uint64) (*mc.TopologyGraph, error) {
+func (ide *Service) GetTopologyByNumber(reqTypes common.RoleType, number uint64) (*mc.TopologyGraph, error) {
 	hash := ide.topologyReader.GetHashByNumber(number)
 	if (hash == common.Hash{}) {
 		return nil, errors.Errorf("get hash by number(%d) err!", number)
 	}
-	return GetTopologyByHash(reqTypes, hash)
+	return ide.GetTopologyByHash(reqTypes, hash)
 }
 
-func GetTopologyByHash(reqTypes common.RoleType, hash common.Hash) (*mc.TopologyGraph, error) {
+func (ide *Service) GetTopologyByHash(reqTypes common.RoleType, hash common.Hash) (*mc.TopologyGraph, error) {
 	tg, err := ide.topologyReader.GetTopologyGraphByHash(hash)
 	if err != nil {
 		log.Error("GetAccountTopologyInfo", "error", err, "hash", hash.TerminalString())
@@ -584,7 +700,7 @@ func GetTopologyByHash(reqTypes common.RoleType, hash common.Hash) (*mc.Topology
 }
 
 // GetAccountTopologyInfo
-func GetAccountTopologyInfo(account common.Address, number uint64) (*mc.TopologyNodeInfo, error) {
+func (ide *Service) GetAccountTopologyInfo(account common.Address, number uint64) (*mc.TopologyNodeInfo, error) {
 	hash := ide.topologyReader.GetHashByNumber(number)
 	if (hash == common.Hash{}) {
 		return nil, errors.Errorf("get hash by number(%d) err!", number)
@@ -604,7 +720,7 @@ func GetAccountTopologyInfo(account common.Address, number uint64) (*mc.Topology
 }
 
 // GetAccountOriginalRole
-func GetAccountOriginalRole(account common.Address, hash common.Hash) (common.RoleType, error) {
+func (ide *Service) GetAccountOriginalRole(account common.Address, hash common.Hash) (common.RoleType, error) {
 	broadcasts, err := ide.topologyReader.GetBroadcastAccounts(hash)
 	if err == nil {
 		for _, bc := range broadcasts {
@@ -638,7 +754,7 @@ func GetAccountOriginalRole(account common.Address, hash common.Hash) (common.Ro
 }
 
 // ConvertSignToDepositAddress
-func ConvertSignToDepositAddress(address common.Address) (addr common.Address, err error) {
+func (ide *Service) ConvertSignToDepositAddress(address common.Address) (addr common.Address, err error) {
 	for _, node := range ide.deposit {
 		if node.SignAddress == address {
 			return node.Address, nil
@@ -649,7 +765,7 @@ func ConvertSignToDepositAddress(address common.Address) (addr common.Address, e
 }
 
 // ConvertDepositToSignAddress
-func ConvertDepositToSignAddress(address common.Address) (addr common.Address, err error) {
+func (ide *Service) ConvertDepositToSignAddress(address common.Address) (addr common.Address, err error) {
 	for _, node := range ide.deposit {
 		if node.Address == address {
 			return node.SignAddress, nil
@@ -658,3 +774,93 @@ func ConvertDepositToSignAddress(address common.Address) (addr common.Address, e
 
 	return addr, errors.New("not found")
 }
+
+// The package level functions operate on the default service.
+
+func Stop() {
+	local.Stop()
+}
+
+func SetTopologyReader(topologyReader TopologyGraphReader) {
+	local.SetTopologyReader(topologyReader)
+}
+
+func GetRolesByGroup(roleType common.RoleType) []common.Address {
+	return local.GetRolesByGroup(roleType)
+}
+
+func GetRolesByGroupWithNextElect(roleType common.RoleType) []common.Address {
+	return local.GetRolesByGroupWithNextElect(roleType)
+}
+
+func GetRolesByGroupOnlyNextElect(roleType common.RoleType) []common.Address {
+	return local.GetRolesByGroupOnlyNextElect(roleType)
+}
+
+func GetRole() common.RoleType {
+	return local.GetRole()
+}
+
+func GetHeight() *big.Int {
+	return local.GetHeight()
+}
+
+func GetHash() common.Hash {
+	return local.GetHash()
+}
+
+func InDuration() bool {
+	return local.InDuration()
+}
+
+func GetNodeNumber() (uint32, error) {
+	return local.GetNodeNumber()
+}
+
+func GetGapValidator() []common.Address {
+	return local.GetGapValidator()
+}
+
+func GetTopologyInLinker() map[common.RoleType][]common.Address {
+	return local.GetTopologyInLinker()
+}
+
+func GetDropNode() []common.Address {
+	return local.GetDropNode()
+}
+
+func GetSignAddress() common.Address {
+	return local.GetSignAddress()
+}
+
+func GetDepositAddress() common.Address {
+	return local.GetDepositAddress()
+}
+
+func GetSelfLevel() int {
+	return local.GetSelfLevel()
+}
+
+func GetTopologyByNumber(reqTypes common.RoleType, number uint64) (*mc.TopologyGraph, error) {
+	return local.GetTopologyByNumber(reqTypes, number)
+}
+
+func GetTopologyByHash(reqTypes common.RoleType, hash common.Hash) (*mc.TopologyGraph, error) {
+	return local.GetTopologyByHash(reqTypes, hash)
+}
+
+func GetAccountTopologyInfo(account common.Address, number uint64) (*mc.TopologyNodeInfo, error) {
+	return local.GetAccountTopologyInfo(account, number)
+}
+
+func GetAccountOriginalRole(account common.Address, hash common.Hash) (common.RoleType, error) {
+	return local.GetAccountOriginalRole(account, hash)
+}
+
+func ConvertSignToDepositAddress(address common.Address) (common.Address, error) {
+	return local.ConvertSignToDepositAddress(address)
+}
+
+func ConvertDepositToSignAddress(address common.Address) (common.Address, error) {
+	return local.ConvertDepositToSignAddress(address)
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package ca
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/core/vm"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+)
+
+var (
+	depositA = common.HexToAddress("0x0a")
+	depositB = common.HexToAddress("0x0b")
+	signA    = common.HexToAddress("0x1a")
+	signB    = common.HexToAddress("0x1b")
+)
+
+// testBlock makes the validators of the block, in order of the given deposit
+// accounts, backup validators after the first one.
+func testBlock(number uint64, validators ...common.Address) (*StaticBlock, *types.Header) {
+	header := &types.Header{Number: new(big.Int).SetUint64(number), Time: big.NewInt(0)}
+	block := &StaticBlock{
+		Number:   number,
+		Hash:     header.Hash(),
+		Topology: &mc.TopologyGraph{},
+		Deposits: []vm.DepositDetail{
+			{Address: depositA, SignAddress: signA, Role: big.NewInt(common.RoleValidator)},
+			{Address: depositB, SignAddress: signB, Role: big.NewInt(common.RoleValidator)},
+		},
+	}
+	for i, account := range validators {
+		role := common.RoleType(common.RoleValidator)
+		if i > 0 {
+			role = common.RoleBackupValidator
+		}
+		block.Topology.NodeList = append(block.Topology.NodeList, mc.TopologyNodeInfo{Account: account, Position: uint16(i), Type: role})
+	}
+	return block, header
+}
+
+func newTestService(t *testing.T, addr common.Address, topology *StaticTopology) (*Service, chan *mc.RoleUpdatedMsg) {
+	service := NewService(addr, mc.NewCenter())
+	service.setReader(topology)
+	roleCh := make(chan *mc.RoleUpdatedMsg, 4)
+	if _, err := service.center.SubscribeEvent(mc.CA_RoleUpdated, roleCh); err != nil {
+		t.Fatalf("subscribe failed: %v", err)
+	}
+	return service, roleCh
+}
+
+func waitRole(t *testing.T, ch chan *mc.RoleUpdatedMsg, number uint64, role common.RoleType) {
+	select {
+	case msg := <-ch:
+		if msg.BlockNum != number || msg.Role != role {
+			t.Fatalf("role update mismatch: have %d %v, want %d %v", msg.BlockNum, msg.Role, number, role)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no role update for block %d", number)
+	}
+}
+
+// TestReelectionRoleTransition runs two nodes in one process over the same
+// topology, the validator and backup swap roles at the reelection block.
+func TestReelectionRoleTransition(t *testing.T) {
+	topology := NewStaticTopology()
+	nodeA, roleA := newTestService(t, signA, topology)
+	nodeB, roleB := newTestService(t, signB, topology)
+
+	block1, header1 := testBlock(1, depositA, depositB)
+	topology.AddBlock(block1)
+	nodeA.ProcessHeader(header1)
+	nodeB.ProcessHeader(header1)
+	waitRole(t, roleA, 1, common.RoleValidator)
+	waitRole(t, roleB, 1, common.RoleBackupValidator)
+
+	if have := nodeA.GetDepositAddress(); have != depositA {
+		t.Errorf("deposit address mismatch: have %x, want %x", have, depositA)
+	}
+	if have := nodeA.GetRolesByGroup(common.RoleBackupValidator); len(have) != 1 || have[0] != signB {
+		t.Errorf("backup validators mismatch: have %x", have)
+	}
+
+	block2, header2 := testBlock(2, depositB, depositA)
+	topology.AddBlock(block2)
+	nodeA.ProcessHeader(header2)
+	nodeB.ProcessHeader(header2)
+	waitRole(t, roleA, 2, common.RoleBackupValidator)
+	waitRole(t, roleB, 2, common.RoleValidator)
+
+	if nodeA.GetRole() != common.RoleBackupValidator || nodeB.GetRole() != common.RoleValidator {
+		t.Errorf("roles not swapped: %v, %v", nodeA.GetRole(), nodeB.GetRole())
+	}
+	graph, err := nodeB.GetTopologyByNumber(common.RoleValidator, 1)
+	if err != nil || len(graph.NodeList) != 1 || graph.NodeList[0].Account != depositA {
+		t.Errorf("historic topology mismatch: %v, %v", graph, err)
+	}
+	if role, err := nodeB.GetAccountOriginalRole(depositA, header2.Hash()); err == nil || role != common.RoleNil {
+		t.Errorf("original role without elect: have %v, %v", role, err)
+	}
+	if GetRole() == common.RoleValidator {
+		t.Errorf("default service affected by the test services")
+	}
+}
+
+// TestElectedByHash checks the p2p linker reads the deposits of a block from
+// the topology of its own service.
+func TestElectedByHash(t *testing.T) {
+	topology := NewStaticTopology()
+	node, _ := newTestService(t, signA, topology)
+	block, header := testBlock(1, depositA, depositB)
+	topology.AddBlock(block)
+
+	var linker LinkProvider = node
+	elected, err := linker.GetElectedByHash(header.Hash())
+	if err != nil || len(elected) != 2 || elected[0].SignAddress != signA || elected[1].SignAddress != signB {
+		t.Fatalf("elected mismatch: have %v, %v", elected, err)
+	}
+	if _, err := linker.GetElectedByHash(common.Hash{0x01}); err == nil {
+		t.Fatalf("unknown block accepted")
+	}
+}
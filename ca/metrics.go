@@ -0,0 +1,10 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package ca
+
+import "github.com/MatrixAINetwork/go-matrix/metrics"
+
+// roleGauge is the current role type of the node.
+var roleGauge = metrics.NewRegisteredGauge("ca/role", nil)
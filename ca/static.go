@@ -0,0 +1,171 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package ca
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/vm"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+)
+
+var errUnknownBlock = errors.New("unknown block")
+
+// StaticBlock is the topology of one block of a StaticTopology. Accounts are
+// the deposit accounts, as stored in the chain state.
+type StaticBlock struct {
+	Number            uint64
+	Hash              common.Hash
+	Topology          *mc.TopologyGraph
+	OriginalElect     []common.Elect
+	NextElect         []common.Elect
+	BroadcastAccounts []common.Address
+	InnerMiners       []common.Address
+	SuperSeq          uint64
+	Deposits          []vm.DepositDetail
+	BCInterval        *mc.BCIntervalInfo
+}
+
+// StaticTopology is a topology reader serving fixed blocks instead of reading
+// the chain, for tests and simulations.
+type StaticTopology struct {
+	lock     sync.RWMutex
+	blocks   map[common.Hash]*StaticBlock
+	byNumber map[uint64]common.Hash
+	current  common.Hash
+}
+
+// NewStaticTopology creates an empty static topology.
+func NewStaticTopology() *StaticTopology {
+	return &StaticTopology{
+		blocks:   make(map[common.Hash]*StaticBlock),
+		byNumber: make(map[uint64]common.Hash),
+	}
+}
+
+// AddBlock adds the topology of a block, which becomes the current one.
+func (st *StaticTopology) AddBlock(block *StaticBlock) {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	if block.Topology == nil {
+		block.Topology = new(mc.TopologyGraph)
+	}
+	st.blocks[block.Hash] = block
+	st.byNumber[block.Number] = block.Hash
+	st.current = block.Hash
+}
+
+func (st *StaticTopology) block(hash common.Hash) (*StaticBlock, error) {
+	st.lock.RLock()
+	defer st.lock.RUnlock()
+	block, ok := st.blocks[hash]
+	if !ok {
+		return nil, errUnknownBlock
+	}
+	return block, nil
+}
+
+func (st *StaticTopology) GetCurrentHash() common.Hash {
+	st.lock.RLock()
+	defer st.lock.RUnlock()
+	return st.current
+}
+
+func (st *StaticTopology) GetHashByNumber(number uint64) common.Hash {
+	st.lock.RLock()
+	defer st.lock.RUnlock()
+	return st.byNumber[number]
+}
+
+func (st *StaticTopology) GetTopologyGraphByHash(blockHash common.Hash) (*mc.TopologyGraph, error) {
+	block, err := st.block(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	return block.Topology, nil
+}
+
+func (st *StaticTopology) GetOriginalElectByHash(blockHash common.Hash) ([]common.Elect, error) {
+	block, err := st.block(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	return block.OriginalElect, nil
+}
+
+func (st *StaticTopology) GetNextElectByHash(blockHash common.Hash) ([]common.Elect, error) {
+	block, err := st.block(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	return block.NextElect, nil
+}
+
+func (st *StaticTopology) GetBroadcastAccounts(blockHash common.Hash) ([]common.Address, error) {
+	block, err := st.block(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	return block.BroadcastAccounts, nil
+}
+
+func (st *StaticTopology) GetInnerMinersAccount(blockHash common.Hash) ([]common.Address, error) {
+	block, err := st.block(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	return block.InnerMiners, nil
+}
+
+func (st *StaticTopology) GetSuperSeq(blockHash common.Hash) (uint64, error) {
+	block, err := st.block(blockHash)
+	if err != nil {
+		return 0, err
+	}
+	return block.SuperSeq, nil
+}
+
+// GetDepositListByHash returns the deposits of the given role, RoleMiner and
+// RoleValidator select the deposits with the according role.
+func (st *StaticTopology) GetDepositListByHash(hash common.Hash, roleType common.RoleType) ([]vm.DepositDetail, error) {
+	block, err := st.block(hash)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]vm.DepositDetail, 0)
+	for _, deposit := range block.Deposits {
+		if deposit.Role != nil && deposit.Role.Cmp(big.NewInt(int64(roleType))) == 0 {
+			result = append(result, deposit)
+		}
+	}
+	return result, nil
+}
+
+func (st *StaticTopology) GetDepositAndWithDrawListByHash(hash common.Hash) ([]vm.DepositDetail, error) {
+	block, err := st.block(hash)
+	if err != nil {
+		return nil, err
+	}
+	return block.Deposits, nil
+}
+
+func (st *StaticTopology) GetAllDepositByHash(hash common.Hash) ([]vm.DepositDetail, error) {
+	return st.GetDepositAndWithDrawListByHash(hash)
+}
+
+func (st *StaticTopology) GetBroadcastIntervalByHash(blockHash common.Hash) (*mc.BCIntervalInfo, error) {
+	block, err := st.block(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	if block.BCInterval == nil {
+		return &mc.BCIntervalInfo{BCInterval: 100}, nil
+	}
+	return block.BCInterval, nil
+}
@@ -5,8 +5,10 @@
 package common
 
 import (
-	"github.com/pkg/errors"
 	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common/mclock"
+	"github.com/pkg/errors"
 )
 
 var (
@@ -19,10 +21,17 @@ type ResendMsgCtrl struct {
 	interval time.Duration
 	msg      interface{}
 	sendFunc func(interface{}, uint32)
+	clock    mclock.Clock
 	closeCh  chan struct{}
 }
 
 func NewResendMsgCtrl(msg interface{}, sendFunc func(interface{}, uint32), interval int64, times uint32) (*ResendMsgCtrl, error) {
+	return NewResendMsgCtrlWithClock(msg, sendFunc, interval, times, mclock.System{})
+}
+
+// NewResendMsgCtrlWithClock is NewResendMsgCtrl timing the resends with the
+// given clock.
+func NewResendMsgCtrlWithClock(msg interface{}, sendFunc func(interface{}, uint32), interval int64, times uint32, clock mclock.Clock) (*ResendMsgCtrl, error) {
 	if sendFunc == nil || interval <= 0 {
 		return nil, ErrParam
 	}
@@ -32,6 +41,7 @@ func NewResendMsgCtrl(msg interface{}, sendFunc func(interface{}, uint32), inter
 		interval: time.Duration(interval) * time.Second,
 		msg:      msg,
 		sendFunc: sendFunc,
+		clock:    clock,
 		closeCh:  make(chan struct{}),
 	}
 	go ctrl.running()
@@ -46,10 +56,10 @@ func (self *ResendMsgCtrl) running() {
 	self.curTimes = 1
 	self.sendFunc(self.msg, self.curTimes)
 
-	timer := time.NewTimer(self.interval)
+	timer := self.clock.NewTimer(self.interval)
 	for {
 		select {
-		case <-timer.C:
+		case <-timer.C():
 			if self.maxTimes != 0 && self.curTimes == self.maxTimes {
 				return
 			}
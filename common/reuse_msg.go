@@ -6,7 +6,8 @@ package common
 
 import (
 	"errors"
-	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common/mclock"
 )
 
 var (
@@ -23,12 +24,20 @@ type msgCache struct {
 type ReuseMsgController struct {
 	msgMap      map[Hash]*msgCache
 	useInterval int64
+	clock       mclock.Clock
 }
 
 func NewReuseMsgController(useInterval int64) *ReuseMsgController {
+	return NewReuseMsgControllerWithClock(useInterval, mclock.System{})
+}
+
+// NewReuseMsgControllerWithClock is NewReuseMsgController reading the use
+// times from the given clock.
+func NewReuseMsgControllerWithClock(useInterval int64, clock mclock.Clock) *ReuseMsgController {
 	return &ReuseMsgController{
 		msgMap:      make(map[Hash]*msgCache),
 		useInterval: useInterval,
+		clock:       clock,
 	}
 }
 
@@ -69,7 +78,7 @@ func (self ReuseMsgController) ReUseMsg(msgKey Hash) (interface{}, error) {
 		return nil, ErrMsgNotExist
 	}
 
-	curTime := time.Now().Unix()
+	curTime := self.clock.Now().Unix()
 	if curTime-cache.useTime < self.useInterval {
 		return nil, ErrUseMsgTooOften
 	}
@@ -505,6 +505,20 @@ type RecorbleTx struct {
 	Typ     byte
 }
 
+// Involves returns whether the address sends or receives the transfer, any
+// address does if it is empty.
+func (rt *RecorbleTx) Involves(addr Address) bool {
+	if addr == (Address{}) || rt.From == addr {
+		return true
+	}
+	for _, aa := range rt.Adam {
+		if aa.Addr == addr {
+			return true
+		}
+	}
+	return false
+}
+
 //地址为matrix地址
 type EntrustType struct {
 	//委托地址
@@ -741,6 +755,13 @@ type CalculateDeposit struct {
 	CalcDeposit []OperationalInterestSlash
 }
 
+// SlashRecord is a slash charged to a deposit account by a block, recorded
+// while the block is processed and stored with it.
+type SlashRecord struct {
+	Account Address
+	Amount  *big.Int
+}
+
 //退选信息
 type WithDrawInfo struct {
 	WithDrawAmount *big.Int
@@ -181,3 +181,21 @@ func TestMixedcaseAccount_Address(t *testing.T) {
 	}
 
 }
+
+func TestRecorbleTxInvolves(t *testing.T) {
+	from, to, other := HexToAddress("0x01"), HexToAddress("0x02"), HexToAddress("0x03")
+	rt := &RecorbleTx{From: from, Adam: []AddrAmont{{Addr: to, Amont: big.NewInt(1)}}}
+	for _, test := range []struct {
+		addr Address
+		want bool
+	}{
+		{from, true},
+		{to, true},
+		{other, false},
+		{Address{}, true},
+	} {
+		if have := rt.Involves(test.addr); have != test.want {
+			t.Errorf("address %x: have %v, want %v", test.addr, have, test.want)
+		}
+	}
+}
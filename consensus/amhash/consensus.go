@@ -585,6 +585,24 @@ func (amhash *Amhash) VerifySeal(chain consensus.ChainReader, header *types.Head
 	return nil
 }
 
+// VerifyX11Share implements consensus.ShareVerifier.
+func (amhash *Amhash) VerifyX11Share(header *types.Header, difficulty *big.Int) bool {
+	if difficulty.Sign() <= 0 {
+		return false
+	}
+	result := x11PowHash(generateMineData(header), header.Nonce.Uint64())
+	return new(big.Int).SetBytes(Reverse(result)).Cmp(new(big.Int).Div(maxUint256, difficulty)) <= 0
+}
+
+// VerifySm3Share implements consensus.ShareVerifier.
+func (amhash *Amhash) VerifySm3Share(header *types.Header, difficulty *big.Int) bool {
+	if difficulty.Sign() <= 0 {
+		return false
+	}
+	result := sm3PowHash(generateMineData(header), header.Sm3Nonce.Uint64())
+	return new(big.Int).SetBytes(result).Cmp(new(big.Int).Div(maxUint256, difficulty)) <= 0
+}
+
 func (amhash *Amhash) VerifyAISeal(chain consensus.ChainReader, header *types.Header) error {
 	bcInterval, err := chain.GetBroadcastIntervalByHash(header.ParentHash)
 	if err != nil {
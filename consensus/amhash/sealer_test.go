@@ -3,8 +3,50 @@
 // file COPYING or http://www.opensource.org/licenses/mit-license.php
 package amhash
 
-import "testing"
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+)
 
 func TestMine(t *testing.T) {
 	t.Logf("test")
 }
+
+func TestVerifyShare(t *testing.T) {
+	amhash := &Amhash{}
+	header := &types.Header{
+		ParentHash: common.HexToHash("0x5d4b0b8e3cd9f8b1c8c1f1d1ce2c0a6b32a1b8c3f27a71f1d6c4e1f6a5b4c3d2"),
+		Coinbase:   common.HexToAddress("0x0ead6cdb8d214389909a535d4ccc21a393dddba9"),
+	}
+	shareDifficulty, blockDifficulty := big.NewInt(16), new(big.Int).Lsh(big.NewInt(1), 200)
+
+	found := false
+	for nonce := uint64(0); nonce < 10000 && !found; nonce++ {
+		header.Nonce = types.EncodeNonce(nonce)
+		found = amhash.VerifyX11Share(header, shareDifficulty)
+	}
+	if !found {
+		t.Fatal("no x11 share found")
+	}
+	if amhash.VerifyX11Share(header, blockDifficulty) {
+		t.Error("x11 share meets the block difficulty")
+	}
+
+	found = false
+	for nonce := uint64(0); nonce < 10000 && !found; nonce++ {
+		header.Sm3Nonce = types.EncodeNonce(nonce)
+		found = amhash.VerifySm3Share(header, shareDifficulty)
+	}
+	if !found {
+		t.Fatal("no sm3 share found")
+	}
+	if amhash.VerifySm3Share(header, blockDifficulty) {
+		t.Error("sm3 share meets the block difficulty")
+	}
+	if amhash.VerifySm3Share(header, new(big.Int)) {
+		t.Error("share of zero difficulty accepted")
+	}
+}
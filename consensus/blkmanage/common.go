@@ -7,6 +7,8 @@ import (
 	"errors"
 
 	"github.com/MatrixAINetwork/go-matrix/accounts/signhelper"
+	"github.com/MatrixAINetwork/go-matrix/ca"
+	"github.com/MatrixAINetwork/go-matrix/common/mclock"
 	"github.com/MatrixAINetwork/go-matrix/reelection"
 
 	"fmt"
@@ -111,6 +113,8 @@ type BlKSupport interface {
 	SignHelper() *signhelper.SignHelper
 	EventMux() *event.TypeMux
 	ReElection() *reelection.ReElection
+	CA() *ca.Service
+	Clock() mclock.Clock
 }
 type VrfMsg struct {
 	VrfValue []byte
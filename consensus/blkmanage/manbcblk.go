@@ -9,8 +9,6 @@ import (
 	"reflect"
 	"time"
 
-	"github.com/MatrixAINetwork/go-matrix/ca"
-
 	"github.com/MatrixAINetwork/go-matrix/baseinterface"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/core/state"
@@ -57,8 +55,8 @@ func (bd *ManBCBlkPlug) Prepare(version string, support BlKSupport, interval *mc
 		return nil, nil, err
 	}
 
-	bd.setBCTimeStamp(parent, originHeader, num)
-	bd.baseInterface.setLeader(originHeader)
+	bd.setBCTimeStamp(support, parent, originHeader, num)
+	bd.baseInterface.setLeader(support, originHeader)
 	bd.baseInterface.setNumber(originHeader, num)
 	bd.baseInterface.setGasLimit(originHeader, parent)
 	bd.baseInterface.setExtra(originHeader)
@@ -74,7 +72,7 @@ func (bd *ManBCBlkPlug) Prepare(version string, support BlKSupport, interval *mc
 	}
 	bd.baseInterface.initBasePowers(originHeader)
 	if manversion.VersionCmp(string(originHeader.Version), manversion.VersionAIMine) >= 0 {
-		bd.setBCMiner(originHeader)
+		bd.setBCMiner(support, originHeader)
 	}
 	if err := support.BlockChain().Engine(originHeader.Version).Prepare(support.BlockChain(), originHeader); err != nil {
 		log.Error(LogManBlk, "Failed to prepare header for mining", err)
@@ -95,7 +93,7 @@ func (p *ManBCBlkPlug) getBCVrfValue(support BlKSupport, parent *types.Block) ([
 		log.Error(LogManBlk, "生成vrfmsg出错", err, "parentMsg", parentMsg)
 		return []byte{}, []byte{}, []byte{}, errors.New("生成vrfmsg出错")
 	}
-	return support.SignHelper().SignVrfByAccount(vrfmsg, ca.GetDepositAddress())
+	return support.SignHelper().SignVrfByAccount(vrfmsg, support.CA().GetDepositAddress())
 }
 
 func (p *ManBCBlkPlug) setBCVrf(support BlKSupport, parent *types.Block, header *types.Header) error {
@@ -108,22 +106,22 @@ func (p *ManBCBlkPlug) setBCVrf(support BlKSupport, parent *types.Block, header
 	return nil
 }
 
-func (p *ManBCBlkPlug) setBCTimeStamp(parent *types.Block, header *types.Header, num uint64) {
-	nowTime := time.Now()
+func (p *ManBCBlkPlug) setBCTimeStamp(support BlKSupport, parent *types.Block, header *types.Header, num uint64) {
+	nowTime := support.Clock().Now()
 	// 广播区块时间戳默认为父区块+1s， 保证所有广播节点出块的时间戳一致
 	tsTamp := parent.Time().Int64() + 1
 	log.Info(LogManBlk, "关键时间点", "广播区块头开始生成", "cur time", nowTime, "header time", tsTamp, "块高", num)
 	// this will ensure we're not going off too far in the future
-	if now := time.Now().Unix(); tsTamp > now+1 {
+	if now := support.Clock().Now().Unix(); tsTamp > now+1 {
 		wait := time.Duration(tsTamp-now) * time.Second
 		log.Info(LogManBlk, "等待时间同步", common.PrettyDuration(wait))
-		time.Sleep(wait)
+		support.Clock().Sleep(wait)
 	}
 	p.baseInterface.setTime(header, tsTamp)
 }
 
-func (p *ManBCBlkPlug) setBCMiner(header *types.Header) {
-	address := ca.GetDepositAddress()
+func (p *ManBCBlkPlug) setBCMiner(support BlKSupport, header *types.Header) {
+	address := support.CA().GetDepositAddress()
 	header.Coinbase = address
 	header.AICoinbase = address
 }
@@ -10,7 +10,6 @@ import (
 	"time"
 
 	"github.com/MatrixAINetwork/go-matrix/baseinterface"
-	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/core"
 	"github.com/MatrixAINetwork/go-matrix/core/state"
@@ -102,21 +101,21 @@ func (bd *ManBlkBasePlug) setNumber(header *types.Header, num uint64) {
 	header.Number = new(big.Int).SetUint64(num)
 }
 
-func (bd *ManBlkBasePlug) setLeader(header *types.Header) {
-	header.Leader = ca.GetDepositAddress()
+func (bd *ManBlkBasePlug) setLeader(support BlKSupport, header *types.Header) {
+	header.Leader = support.CA().GetDepositAddress()
 }
-func (bd *ManBlkBasePlug) setTimeStamp(parent *types.Block, header *types.Header, num uint64) {
-	tstart := time.Now()
+func (bd *ManBlkBasePlug) setTimeStamp(support BlKSupport, parent *types.Block, header *types.Header, num uint64) {
+	tstart := support.Clock().Now()
 	log.Info(LogManBlk, "关键时间点", "区块头开始生成", "time", tstart, "块高", num)
 	tstamp := tstart.Unix()
 	if parent.Time().Cmp(new(big.Int).SetInt64(tstamp)) >= 0 {
 		tstamp = parent.Time().Int64() + 1
 	}
 	// this will ensure we're not going off too far in the future
-	if now := time.Now().Unix(); tstamp > now+1 {
+	if now := support.Clock().Now().Unix(); tstamp > now+1 {
 		wait := time.Duration(tstamp-now) * time.Second
 		log.Info(LogManBlk, "等待时间同步", common.PrettyDuration(wait))
-		time.Sleep(wait)
+		support.Clock().Sleep(wait)
 	}
 	bd.setTime(header, tstamp)
 }
@@ -184,8 +183,8 @@ func (bd *ManBlkBasePlug) Prepare(version string, support BlKSupport, interval *
 		return nil, nil, err
 	}
 
-	bd.setTimeStamp(parent, originHeader, num)
-	bd.setLeader(originHeader)
+	bd.setTimeStamp(support, parent, originHeader, num)
+	bd.setLeader(support, originHeader)
 	bd.setNumber(originHeader, num)
 	bd.setGasLimit(originHeader, parent)
 	bd.setExtra(originHeader)
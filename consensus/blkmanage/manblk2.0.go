@@ -10,7 +10,6 @@ import (
 	"time"
 
 	"github.com/MatrixAINetwork/go-matrix/baseinterface"
-	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/core"
 	"github.com/MatrixAINetwork/go-matrix/core/state"
@@ -113,11 +112,11 @@ func (bd *ManBlkV2Plug) setNumber(header *types.Header, num uint64) {
 	header.Number = new(big.Int).SetUint64(num)
 }
 
-func (bd *ManBlkV2Plug) setLeader(header *types.Header) {
-	header.Leader = ca.GetDepositAddress()
+func (bd *ManBlkV2Plug) setLeader(support BlKSupport, header *types.Header) {
+	header.Leader = support.CA().GetDepositAddress()
 }
-func (bd *ManBlkV2Plug) setTimeStamp(parent *types.Block, header *types.Header, num uint64) {
-	tstart := time.Now()
+func (bd *ManBlkV2Plug) setTimeStamp(support BlKSupport, parent *types.Block, header *types.Header, num uint64) {
+	tstart := support.Clock().Now()
 	log.Info(LogManBlk, "关键时间点", "区块头开始生成", "time", tstart, "块高", num)
 	tstamp := tstart.Unix()
 	targetTime := parent.Time().Int64() + params.MinBlockInterval.Int64()
@@ -126,10 +125,10 @@ func (bd *ManBlkV2Plug) setTimeStamp(parent *types.Block, header *types.Header,
 		tstamp = targetTime
 	}
 	// this will ensure we're not going off too far in the future
-	if now := time.Now().Unix(); tstamp > now+1 {
+	if now := support.Clock().Now().Unix(); tstamp > now+1 {
 		wait := time.Duration(tstamp-now) * time.Second
 		log.Info(LogManBlk, "等待时间同步", common.PrettyDuration(wait))
-		time.Sleep(wait)
+		support.Clock().Sleep(wait)
 	}
 	bd.setTime(header, tstamp)
 }
@@ -208,8 +207,8 @@ func (bd *ManBlkV2Plug) Prepare(version string, support BlKSupport, interval *mc
 		log.Error(LogManBlk, "区块生成阶段", "获取父区块失败")
 		return nil, nil, err
 	}
-	bd.setTimeStamp(parent, originHeader, num)
-	bd.setLeader(originHeader)
+	bd.setTimeStamp(support, parent, originHeader, num)
+	bd.setLeader(support, originHeader)
 	bd.setNumber(originHeader, num)
 	bd.setGasLimit(originHeader, parent)
 	bd.setExtra(originHeader)
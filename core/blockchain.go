@@ -11,10 +11,8 @@ import (
 	"io"
 	"io/ioutil"
 	"math/big"
-	"os"
-	"path"
+	"path/filepath"
 	"runtime/debug"
-	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -52,6 +50,9 @@ import (
 var (
 	blockInsertTimer = metrics.NewRegisteredTimer("chain/inserts", nil)
 
+	broadcastIntervalGauge = metrics.NewRegisteredGauge("chain/broadcast/interval", nil) // Blocks between broadcast blocks
+	broadcastTimeGauge     = metrics.NewRegisteredGauge("chain/broadcast/time", nil)     // Seconds between the last two broadcast blocks
+
 	ErrNoGenesis = errors.New("Genesis not found in chain")
 
 	SaveSnapPeriod uint64 = 300
@@ -79,6 +80,9 @@ type CacheConfig struct {
 	Disabled      bool          // Whether to disable trie write caching (archive node)
 	TrieNodeLimit int           // Memory limit (MB) at which to flush the current in-memory trie to disk
 	TrieTimeLimit time.Duration // Time limit after which to flush the current in-memory trie to disk
+
+	PruneRecent   uint64 // States of the latest blocks kept by online pruning, 0 to disable it
+	PruneInterval uint64 // Blocks between two online prunes
 }
 
 // BlockChain represents the canonical chain given a database with a genesis
@@ -98,6 +102,7 @@ type CacheConfig struct {
 type BlockChain struct {
 	chainConfig *params.ChainConfig // Chain & network configuration
 	cacheConfig *CacheConfig        // Cache configuration for pruning
+	ca          ca.Provider         // Identity of the node, used by the block rewards
 
 	db     mandb.Database // Low level persistent database to store final content in
 	triegc *prque.Prque   // Priority queue mapping block numbers to tries to gc
@@ -152,6 +157,8 @@ type BlockChain struct {
 	qBlockQueue    *prque.Prque
 	qIpfsMu        sync.RWMutex
 
+	snapDir string // directory snapshots are saved to
+
 	//matrix state
 	matrixProcessor *MatrixProcessor
 	topologyStore   *TopologyStore
@@ -179,6 +186,8 @@ func NewBlockChain(db mandb.Database, cacheConfig *CacheConfig, chainConfig *par
 	bc := &BlockChain{
 		chainConfig:     chainConfig,
 		cacheConfig:     cacheConfig,
+		ca:              ca.DefaultService(),
+		msgceter:        mc.DefaultCenter(),
 		db:              db,
 		triegc:          prque.New(),
 		stateCache:      state.NewDatabase(db),
@@ -196,6 +205,7 @@ func NewBlockChain(db mandb.Database, cacheConfig *CacheConfig, chainConfig *par
 		badBlocks:       badBlocks,
 		matrixProcessor: NewMatrixProcessor(),
 		badDumpHistory:  make([]common.Hash, 0),
+		snapDir:         snapshot.DefaultDir,
 	}
 	bc.topologyStore = NewTopologyStore(bc)
 
@@ -237,30 +247,16 @@ func NewBlockChain(db mandb.Database, cacheConfig *CacheConfig, chainConfig *par
 		}
 	}
 
-	reqCh := make(chan struct{})
-	sub, err := mc.SubscribeEvent(mc.CA_ReqCurrentBlock, reqCh)
-	if err == nil {
-		go func(chain *BlockChain, reqCh chan struct{}, sub event.Subscription) {
-			time.Sleep(3 * time.Second)
-			select {
-			case <-reqCh:
-				block := chain.CurrentBlock()
-				num := block.Number().Uint64()
-				log.Debug("MAIN", "本地区块插入消息已发送", num, "hash", block.Hash())
-				mc.PublishEvent(mc.NewBlockMessage, block)
-				sub.Unsubscribe()
-				close(reqCh)
-				return
-			}
-		}(bc, reqCh, sub)
-	} else {
-		log.Error(ModuleName, "订阅CA请求当前区块事件失败", err)
-	}
+	bc.answerCurrentBlockReq(3 * time.Second)
 
 	manparams.SetStateReader(bc)
 
 	// Take ownership of this particular state
 	go bc.update()
+	if !cacheConfig.Disabled && cacheConfig.PruneRecent != 0 && cacheConfig.PruneInterval != 0 {
+		bc.wg.Add(1)
+		go bc.pruneLoop(cacheConfig.PruneRecent, cacheConfig.PruneInterval)
+	}
 	return bc, nil
 }
 
@@ -447,6 +443,45 @@ func (bc *BlockChain) CurrentFastBlock() *types.Block {
 	return x.(*types.Block)
 }
 
+// SetCA sets the identity service of the node, the default service of the
+// process is used until then.
+func (bc *BlockChain) SetCA(identity ca.Provider) {
+	bc.ca = identity
+}
+
+// SetMsgCenter sets the event center the chain publishes its blocks on, the
+// default center of the process is used until then. The ca request for the
+// current block is answered on the new center.
+func (bc *BlockChain) SetMsgCenter(center *mc.Center) {
+	bc.msgceter = center
+	bc.answerCurrentBlockReq(0)
+}
+
+// answerCurrentBlockReq publishes the current block once the ca service asks
+// for it, after the given delay.
+func (bc *BlockChain) answerCurrentBlockReq(delay time.Duration) {
+	center := bc.msgceter
+	reqCh := make(chan struct{})
+	sub, err := center.SubscribeEvent(mc.CA_ReqCurrentBlock, reqCh)
+	if err != nil {
+		log.Error(ModuleName, "订阅CA请求当前区块事件失败", err)
+		return
+	}
+	go func() {
+		defer sub.Unsubscribe()
+		if delay > 0 {
+			time.Sleep(delay)
+		}
+		select {
+		case <-reqCh:
+			block := bc.CurrentBlock()
+			log.Debug("MAIN", "本地区块插入消息已发送", block.Number().Uint64(), "hash", block.Hash())
+			center.PublishEvent(mc.NewBlockMessage, block)
+		case <-bc.quit:
+		}
+	}()
+}
+
 // SetProcessor sets the processor required for making state modifications.
 func (bc *BlockChain) SetProcessor(version string, processor Processor) {
 	bc.procmu.Lock()
@@ -1179,6 +1214,9 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, state *state.State
 		}
 	}
 	rawdb.WriteReceipts(batch, block.Hash(), block.NumberU64(), receipts)
+	if slashes := state.SlashRecords(); len(slashes) > 0 {
+		rawdb.WriteBlockSlashes(batch, block.Hash(), block.NumberU64(), slashes)
+	}
 
 	// If the total difficulty is higher than our known, add it to the canonical chain
 	// Second clause in the if statement reduces the vulnerability to selfish mining.
@@ -1531,6 +1569,9 @@ func (bc *BlockChain) insertChain(chain types.Blocks, flg int) (int, []interface
 
 			coalescedLogs = append(coalescedLogs, logs...)
 			blockInsertTimer.UpdateSince(bstart)
+			if metrics.Enabled {
+				bc.updateBroadcastMetrics(block)
+			}
 			events = append(events, ChainEvent{block, block.Hash(), logs})
 			lastCanon = block
 
@@ -1546,7 +1587,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, flg int) (int, []interface
 		}
 
 		// 发出区块插入事件
-		mc.PublishEvent(mc.BlockInserted, &mc.BlockInsertedMsg{Block: mc.BlockInfo{Hash: block.Hash(), Number: block.NumberU64()}, InsertTime: uint64(time.Now().Unix()), CanonState: status == CanonStatTy})
+		bc.msgceter.PublishEvent(mc.BlockInserted, &mc.BlockInsertedMsg{Block: mc.BlockInfo{Hash: block.Hash(), Number: block.NumberU64()}, InsertTime: uint64(time.Now().Unix()), CanonState: status == CanonStatTy})
 
 		stats.processed++
 		stats.usedGas += usedGas
@@ -1589,6 +1630,23 @@ func (bc *BlockChain) insertChain(chain types.Blocks, flg int) (int, []interface
 	return 0, events, coalescedLogs, nil
 }
 
+// updateBroadcastMetrics updates the broadcast interval metrics if the block
+// is a broadcast block.
+func (bc *BlockChain) updateBroadcastMetrics(block *types.Block) {
+	bcInterval, err := bc.GetBroadcastIntervalByHash(block.ParentHash())
+	if err != nil || !bcInterval.IsBroadcastNumber(block.NumberU64()) {
+		return
+	}
+	interval := bcInterval.GetBroadcastInterval()
+	broadcastIntervalGauge.Update(int64(interval))
+	if block.NumberU64() < interval {
+		return
+	}
+	if last := bc.GetHeaderByNumber(block.NumberU64() - interval); last != nil {
+		broadcastTimeGauge.Update(block.Time().Int64() - last.Time.Int64())
+	}
+}
+
 // insertStats tracks and reports on block insertion.
 type insertStats struct {
 	queued, processed, ignored int
@@ -1791,7 +1849,7 @@ func (bc *BlockChain) PostChainEvents(events []interface{}, logs []types.CoinLog
 			//=========Begin===============
 			bc.sendBroadTx()
 			//=============end===============
-			mc.PublishEvent(mc.NewBlockMessage, ev.Block)
+			bc.msgceter.PublishEvent(mc.NewBlockMessage, ev.Block)
 
 		case ChainSideEvent:
 			bc.chainSideFeed.Send(ev)
@@ -1832,14 +1890,14 @@ func (bc *BlockChain) sendBroadTx() {
 			return
 		}
 		log.Info(ModuleName, "sendBroadTx获取最新的root", types.RlpHash(preBroadcastRoot.LastStateRoot).String())
-		currentAcc := ca.GetDepositAddress().Big()
+		currentAcc := bc.ca.GetDepositAddress().Big()
 		ret := new(big.Int).Rem(currentAcc, big.NewInt(int64(bcInterval.BCInterval)-1))
 		broadcastBlock := types.RlpHash(preBroadcastRoot.LastStateRoot).Big()
 		val := new(big.Int).Rem(broadcastBlock, big.NewInt(int64(bcInterval.BCInterval)-1))
 		if ret.Cmp(val) == 0 {
 			height := new(big.Int).Add(new(big.Int).SetUint64(subVal), big.NewInt(int64(bcInterval.BCInterval))) //下一广播区块的高度
 			data := new([]byte)
-			mc.PublishEvent(mc.SendBroadCastTx, mc.BroadCastEvent{mc.Heartbeat, height, *data})
+			bc.msgceter.PublishEvent(mc.SendBroadCastTx, mc.BroadCastEvent{mc.Heartbeat, height, *data})
 			log.Trace("blockchain", "blockChian:sendBroadTx()", ret, "val", val)
 		}
 		log.Trace("blockchain", "blockChian:sendBroadTx()", ret, "val", val)
@@ -2160,7 +2218,6 @@ func (bc *BlockChain) processSuperBlockState(block *types.Block, stateDB *state.
 	}
 	for _, currencie := range block.Currencies() {
 		if currencie.CurrencyName != params.MAN_COIN {
-			errors.Errorf("super block's txs CurrencyName not Matrix err", currencie.CurrencyName)
 			log.Error("super block error", "super block's txs CurrencyName not Matrix err", currencie.CurrencyName)
 			continue
 		}
@@ -2207,7 +2264,7 @@ func (bc *BlockChain) processSuperBlockState(block *types.Block, stateDB *state.
 
 		if err := mState.SetSuperBlkToState(stateDB, block.Header().Extra, block.Header().Number.Uint64()); err != nil {
 			log.Error("genesis", "设置matrix状态树错误", err)
-			return errors.Errorf("设置超级区块状态树错误", err)
+			return errors.Errorf("设置超级区块状态树错误: %v", err)
 		}
 	}
 	return nil
@@ -2482,6 +2539,10 @@ func (bc *BlockChain) SynSnapshot(blockNum uint64, hash string, filePath string)
 			return 0, false
 		}
 	}
+	if snapshot.IsChunked(filePath) {
+		return bc.synChunkedSnapshot(blockNum, filePath)
+	}
+	// Legacy snapshots are a single rlp blob of SnapshotDatas (or SnapshotDatasV1)
 	rb, rerr := ioutil.ReadFile(filePath)
 	if rerr != nil {
 		fmt.Println("BlockChain synSnapshot read snapfile error", rerr)
@@ -2685,10 +2746,6 @@ func (bc *BlockChain) SaveSnapshot(blockNum uint64, period uint64, NewBlocknum u
 	}
 	nums := getSnapshotNums(NewBlocknum, bc)
 
-	snapshotDatas := snapshot.SnapshotDatas{
-		Datas: make([]snapshot.SnapshotData, 0),
-	}
-
 	tmpstatedb, stateerr := bc.StateAtBlockHash(sblock.Hash())
 	if stateerr != nil {
 		log.Error("BlockChain savesnapshot ", "open state fialed,err ", stateerr)
@@ -2701,74 +2758,41 @@ func (bc *BlockChain) SaveSnapshot(blockNum uint64, period uint64, NewBlocknum u
 		log.Error(" BlockChain savesnapshot ", "get pre broadcast root err", err)
 		return
 	}
-	log.Info("BlockChain savesnapshot getDump before broadcast state")
-	//if !preBCRoot.BeforeLastStateRoot.Equal(common.Hash{}) {
-	cs, stateerr := getDumpDB(preBCRoot.BeforeLastStateRoot, bc)
-	if stateerr != nil {
-		log.Error("BlockChain savesnapshot ", "get a dumpdb err", err)
-		return
-	}
-	snapshotDatas.OtherTries = append(snapshotDatas.OtherTries, cs)
-	//}
-	log.Info("BlockChain savesnapshot getDump last broadcast state")
-	//if !preBCRoot.LastStateRoot.Equal(common.Hash{}) {
-	lastcs, Laststateerr := getDumpDB(preBCRoot.LastStateRoot, bc)
-	if Laststateerr != nil {
-		log.Error("BlockChain savesnapshot ", "get a dumpdb err", err)
-		return
-	}
-	snapshotDatas.OtherTries = append(snapshotDatas.OtherTries, lastcs)
-	//}
 
-	var filePath string
+	header := &snapshot.Header{
+		ChainID: bc.chainConfig.ChainId,
+		Number:  sblock.NumberU64(),
+		Hash:    sblock.Hash(),
+		Roots:   sblock.Header().Roots,
+		Sections: []snapshot.Section{
+			{Roots: preBCRoot.BeforeLastStateRoot},
+			{Roots: preBCRoot.LastStateRoot},
+		},
+	}
+	blocks := make([]*types.Block, 0, len(nums))
 	for _, correct := range nums {
 		log.Info("BlockChain savesnapshot ", "correct###############################: ", correct)
-
-		block := bc.GetBlockByNumber(uint64(correct))
-		if block != nil {
-			td := bc.GetTd(block.Hash(), block.NumberU64())
-			header := block.Header()
-			root := header.Roots
-			Seq := uint64(0)
-			//超级区块
-			if block.IsSuperBlock() {
-				Seq, _ = bc.GetSuperBlockSeq()
-			}
-
-			log.Info("BlockChain savesnapshot ", "root ###############################: ", root)
-			statedb, err := bc.getStateCache(root)
-			if err != nil {
-				log.Error("BlockChain savesnapshot ", "open state fialed,err ", err)
-				return
-			}
-			coinTries := statedb.RawDumpDB()
-			snapshotData := snapshot.SnapshotData{coinTries, td, *block, Seq}
-			snapshotDatas.Datas = append(snapshotDatas.Datas, snapshotData)
-		} else {
+		block := bc.GetBlockByNumber(correct)
+		if block == nil {
 			log.Error("BlockChain savesnapshot ", "GetBlockByNumber  error ,blkNum ", correct)
+			continue
 		}
+		blocks = append(blocks, block)
+		header.Sections = append(header.Sections, snapshot.Section{Block: true, Number: correct, Roots: block.Header().Roots})
 	}
-	wb, err := rlp.EncodeToBytes(&snapshotDatas)
-	if err != nil {
-		log.Error("BlockChain savesnapshot ", "encode  err: ", err)
-	}
-	filePath = path.Join(snapshot.SNAPDIR, "/TrieData"+strconv.Itoa(int(nums[len(nums)-1])))
-	f, ferr := os.Create(filePath)
-	if ferr != nil {
-		log.Error("BlockChain Create TrieData", "ferr", ferr, "f", f)
-	}
-	count, err := f.Write(wb)
+
+	filePath := filepath.Join(bc.snapDir, snapshot.FileName(header.Number))
+	manifest, err := bc.writeSnapshot(filePath, header, blocks)
 	if err != nil {
 		log.Error("BlockChain savesnapshot ", "Write snapshot err: ", err)
-	} else {
-		log.Info("BlockChain savesnapshot ", "Write snapshot bytes : ", count)
+		return
 	}
-	f.Close()
+	log.Info("BlockChain savesnapshot ", "file", filePath, "chunks", len(manifest.Chunks), "manifest", manifest.Hash())
 	fmt.Println("matrix  save snapshot sucess! blockNum=", NewBlocknum)
 	if bc.qBlockQueue != nil {
 		var tmpSanpInfo types.SnapSaveInfo
-		tmpSanpInfo.BlockNum = nums[len(nums)-1]
-		tmpSanpInfo.BlockHash = bc.GetHeaderByNumber(tmpSanpInfo.BlockNum).Hash().String()
+		tmpSanpInfo.BlockNum = header.Number
+		tmpSanpInfo.BlockHash = header.Hash.String()
 		tmpSanpInfo.SnapPath = filePath
 		bc.qBlockQueue.Push(tmpSanpInfo, -float32(tmpSanpInfo.BlockNum))
 	}
@@ -2793,6 +2817,12 @@ func (bc *BlockChain) PrintSnapshotAccountMsg(blockNum uint64, hash string, file
 
 	// Make sure the peer's TD is higher than our own
 	fmt.Println("BlockChain PrintSnapshotAccountMsg", filePath)
+	if snapshot.IsChunked(filePath) {
+		if err := printChunkedSnapshotAccounts(filePath); err != nil {
+			log.Error("BlockChain PrintSnapshotAccountMsg", "read snapshot err", err)
+		}
+		return
+	}
 	rb, rerr := ioutil.ReadFile(filePath)
 	if rerr != nil {
 		log.Error("BlockChain synSnapshot", "Read TrieData err: ", rerr)
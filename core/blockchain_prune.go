@@ -0,0 +1,442 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package core
+
+import (
+	"sort"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+	"github.com/MatrixAINetwork/go-matrix/trie"
+	"github.com/pkg/errors"
+)
+
+// DefaultPruneRecent is the number of latest block states pruning keeps by default.
+const DefaultPruneRecent = 1024
+
+// Reasons a state is kept by pruning
+const (
+	PruneKeepRecent     = "recent"
+	PruneKeepElection   = "election"  // Read by election and rewards back to the last re-election
+	PruneKeepBroadcast  = "broadcast" // Last broadcast states the interest and slash rewards read
+	PruneKeepSnapshot   = "snapshot"
+	PruneKeepSuperBlock = "superblock"
+	PruneKeepGenesis    = "genesis"
+)
+
+// PruneConfig selects the states pruning keeps.
+type PruneConfig struct {
+	Recent     uint64 // States of the latest blocks kept, at least the ones cached in memory
+	SnapPeriod uint64 // Period of the snapshots, the states of the latest one are kept, 0 for none
+	DryRun     bool   // Only report what pruning removes
+}
+
+// KeptState is a state kept by pruning.
+type KeptState struct {
+	Number uint64 // Block of the state, or the block whose state refers to it
+	Hash   common.Hash
+	Reason string
+	Roots  []common.CoinRoot
+}
+
+// PruneResult is the report of pruning the state.
+type PruneResult struct {
+	Head    uint64
+	Kept    []KeptState
+	Absent  []KeptState        // States pruning would keep which are not stored, like most states in gcmode full
+	Marked  int                // Entries reachable from the kept states
+	Removed int                // Entries not reachable, removed unless dry run
+	Size    common.StorageSize // Size of the removed entries
+	Elapsed time.Duration
+}
+
+// PruneState removes the state entries which are not reachable from the states
+// pruning keeps: the states of the latest blocks, of the blocks election and
+// rewards read back to two blocks before the last re-election, the last
+// broadcast states, the states of the latest snapshot and the last super block.
+// Only canonical states which are stored are kept.
+//
+// Block insertion only waits while the kept states are listed and while each
+// batch of entries is removed. Before a batch is removed the states of the
+// blocks inserted since are marked as well.
+func (bc *BlockChain) PruneState(config PruneConfig) (*PruneResult, error) {
+	start := time.Now()
+	p := &statePruner{
+		bc:     bc,
+		reader: trieNodeReader{bc.stateCache.TrieDB()},
+		marked: make(map[common.Hash]struct{}),
+		states: make(map[common.Hash]struct{}),
+		blocks: make(map[common.Hash]struct{}),
+	}
+
+	bc.chainmu.Lock()
+	head := bc.CurrentBlock().NumberU64()
+	kept, absent, err := bc.keptStates(config, p.reader)
+	bc.chainmu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	result := &PruneResult{Head: head, Absent: absent}
+	for _, ks := range absent {
+		p.blocks[ks.Hash] = struct{}{}
+	}
+	for _, ks := range kept {
+		if ks.Reason == PruneKeepRecent {
+			p.blocks[ks.Hash] = struct{}{}
+		}
+		stored, err := p.markState(ks.Roots)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s state of block %d", ks.Reason, ks.Number)
+		}
+		// States of the oldest blocks cached in memory may be dropped
+		// meanwhile.
+		if !stored {
+			result.Absent = append(result.Absent, ks)
+			continue
+		}
+		result.Kept = append(result.Kept, ks)
+	}
+	result.Marked = len(p.marked)
+
+	if result.Removed, result.Size, err = p.sweep(bc.db, config.DryRun); err != nil {
+		return nil, err
+	}
+	result.Elapsed = time.Since(start)
+	return result, nil
+}
+
+// keptStates lists the states pruning keeps which are stored, and the ones
+// which are not, sorted by block.
+func (bc *BlockChain) keptStates(config PruneConfig, reader trieNodeReader) ([]KeptState, []KeptState, error) {
+	if config.Recent < triesInMemory {
+		return nil, nil, errors.Errorf("pruning has to keep the states of at least %d blocks", triesInMemory)
+	}
+	head := bc.CurrentBlock().NumberU64()
+	kept, absent := make([]KeptState, 0), make([]KeptState, 0)
+	numbers := make(map[uint64]struct{})
+	keep := func(number uint64, reason string) error {
+		if _, ok := numbers[number]; ok {
+			return nil
+		}
+		header := bc.GetHeaderByNumber(number)
+		if header == nil {
+			return errors.Errorf("canonical header %d not found", number)
+		}
+		numbers[number] = struct{}{}
+		ks := KeptState{Number: number, Hash: header.Hash(), Reason: reason, Roots: header.Roots}
+		if !reader.hasState(header.Roots) {
+			absent = append(absent, ks)
+			return nil
+		}
+		kept = append(kept, ks)
+		return nil
+	}
+
+	oldest := uint64(0)
+	if head+1 > config.Recent {
+		oldest = head + 1 - config.Recent
+	}
+	for number := oldest; number <= head; number++ {
+		if err := keep(number, PruneKeepRecent); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Election and rewards of the kept blocks read the states two blocks
+	// before the last broadcast and re-election, and between. The interval
+	// is read from the oldest recent state stored, the head one at least.
+	var stored uint64
+	for _, ks := range kept {
+		if ks.Reason == PruneKeepRecent {
+			stored = ks.Number
+			break
+		}
+	}
+	st, err := bc.StateAtNumber(stored)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "state of block %d", stored)
+	}
+	bcInterval, err := matrixstate.GetBroadcastInterval(st)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "broadcast interval of block %d", stored)
+	}
+	from := bcInterval.GetLastReElectionNumber()
+	if last := bcInterval.GetLastBroadcastNumber(); last < from {
+		from = last
+	}
+	if from > 2 {
+		from -= 2
+	} else {
+		from = 0
+	}
+	for number := from; number < oldest; number++ {
+		if err := keep(number, PruneKeepElection); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Interest and slash rewards read the states of the last two broadcast
+	// blocks by root, they change on broadcast blocks only. They can't be
+	// read from the states which are not stored.
+	for number := from; number <= head; number++ {
+		if number != from && !bcInterval.IsBroadcastNumber(number) {
+			continue
+		}
+		header := bc.GetHeaderByNumber(number)
+		if header == nil {
+			return nil, nil, errors.Errorf("canonical header %d not found", number)
+		}
+		if !reader.hasState(header.Roots) {
+			continue
+		}
+		st, err := bc.StateAt(header.Roots)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "state of block %d", number)
+		}
+		roots, err := matrixstate.GetPreBroadcastRoot(st)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "broadcast roots of block %d", number)
+		}
+		for _, r := range [][]common.CoinRoot{roots.LastStateRoot, roots.BeforeLastStateRoot} {
+			if len(r) == 0 {
+				continue
+			}
+			ks := KeptState{Number: number, Hash: header.Hash(), Reason: PruneKeepBroadcast, Roots: r}
+			if !reader.hasState(r) {
+				absent = append(absent, ks)
+				continue
+			}
+			kept = append(kept, ks)
+		}
+	}
+
+	// A snapshot is made of the states of the period block and the blocks
+	// before it, see SaveSnapshot.
+	if config.SnapPeriod != 0 {
+		if last := head / config.SnapPeriod * config.SnapPeriod; last >= MaxTraceBackCommonBlockNum {
+			for number := last + 1 - MaxTraceBackCommonBlockNum; number <= last; number++ {
+				if err := keep(number, PruneKeepSnapshot); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+	}
+	if superNum, err := bc.GetSuperBlockNum(); err == nil && superNum <= head {
+		if err := keep(superNum, PruneKeepSuperBlock); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := keep(0, PruneKeepGenesis); err != nil {
+		return nil, nil, err
+	}
+
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].Number < kept[j].Number })
+	sort.SliceStable(absent, func(i, j int) bool { return absent[i].Number < absent[j].Number })
+	return kept, absent, nil
+}
+
+// trieNodeReader reads the entries of the trie database, the nodes cached in
+// memory before the ones on disk.
+type trieNodeReader struct {
+	db *trie.Database
+}
+
+func (r trieNodeReader) Get(key []byte) ([]byte, error) {
+	return r.db.Node(common.BytesToHash(key))
+}
+
+func (r trieNodeReader) Has(key []byte) (bool, error) {
+	blob, err := r.Get(key)
+	return err == nil && len(blob) != 0, nil
+}
+
+// hasState reports whether the shard roots of a state and the root nodes of
+// its shards are stored. The nodes below are not checked.
+func (r trieNodeReader) hasState(roots []common.CoinRoot) bool {
+	for _, cr := range roots {
+		if cr.Root == (common.Hash{}) {
+			continue
+		}
+		blob, err := r.Get(cr.Root[:])
+		if err != nil || len(blob) == 0 {
+			return false
+		}
+		var hashs []common.Hash
+		if err := rlp.DecodeBytes(blob, &hashs); err != nil {
+			return false
+		}
+		for _, hash := range hashs {
+			if hash == (common.Hash{}) || hash == types.EmptyRootHash {
+				continue
+			}
+			if ok, _ := r.Has(hash[:]); !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// statePruner marks the kept states and removes the state entries which are
+// not marked.
+type statePruner struct {
+	bc     *BlockChain
+	reader trieNodeReader
+	marked map[common.Hash]struct{} // Entries reachable from the kept states
+	states map[common.Hash]struct{} // Hashes of the shard roots of the states marked
+	blocks map[common.Hash]struct{} // Blocks whose state is marked or not stored
+}
+
+// markState marks the entries of a state, it returns false if the state is not
+// stored. States the chain drops while they are marked are not stored either.
+func (p *statePruner) markState(roots []common.CoinRoot) (bool, error) {
+	hash := types.RlpHash(roots)
+	if _, ok := p.states[hash]; ok {
+		return true, nil
+	}
+	if err := state.MarkState(p.reader, roots, p.marked); err != nil {
+		if !p.reader.hasState(roots) {
+			return false, nil
+		}
+		return false, err
+	}
+	p.states[hash] = struct{}{}
+	return true, nil
+}
+
+// markNewBlocks marks the states of the canonical blocks inserted since the
+// kept states were listed. It has to be called with the chain lock held.
+func (p *statePruner) markNewBlocks() error {
+	for block := p.bc.CurrentBlock(); block != nil; block = p.bc.GetBlock(block.ParentHash(), block.NumberU64()-1) {
+		if _, ok := p.blocks[block.Hash()]; ok {
+			return nil
+		}
+		p.blocks[block.Hash()] = struct{}{}
+		if _, err := p.markState(block.Root()); err != nil {
+			return errors.Wrapf(err, "state of new block %d", block.NumberU64())
+		}
+		if block.NumberU64() == 0 {
+			return nil
+		}
+	}
+	return nil
+}
+
+// sweep removes the state entries of the database which are not marked. State
+// entries are the only ones stored by their bare 32 byte hash, all other data
+// is stored under a prefix. The entries are removed in batches, each while
+// block insertion waits.
+func (p *statePruner) sweep(db mandb.Database, dryRun bool) (int, common.StorageSize, error) {
+	var (
+		removed int
+		size    common.StorageSize
+		pending = make(map[common.Hash]int) // Entries to remove and their size
+	)
+	remove := func() error {
+		p.bc.chainmu.Lock()
+		defer p.bc.chainmu.Unlock()
+
+		if err := p.markNewBlocks(); err != nil {
+			return err
+		}
+		batch := db.NewBatch()
+		for hash, n := range pending {
+			if _, ok := p.marked[hash]; ok {
+				continue
+			}
+			if err := batch.Delete(hash[:]); err != nil {
+				return err
+			}
+			removed++
+			size += common.StorageSize(n)
+		}
+		pending = make(map[common.Hash]int)
+		return batch.Write()
+	}
+	sweep := func(key, value []byte) error {
+		if len(key) != common.HashLength {
+			return nil
+		}
+		hash := common.BytesToHash(key)
+		if _, ok := p.marked[hash]; ok {
+			return nil
+		}
+		if dryRun {
+			removed++
+			size += common.StorageSize(len(key) + len(value))
+			return nil
+		}
+		pending[hash] = len(key) + len(value)
+		if len(pending)*common.HashLength >= mandb.IdealBatchSize {
+			return remove()
+		}
+		return nil
+	}
+
+	switch db := db.(type) {
+	case *mandb.LDBDatabase:
+		it := db.NewIterator()
+		for it.Next() {
+			if err := sweep(it.Key(), it.Value()); err != nil {
+				it.Release()
+				return removed, size, err
+			}
+		}
+		it.Release()
+		if err := it.Error(); err != nil {
+			return removed, size, err
+		}
+	case *mandb.MemDatabase:
+		for _, key := range db.Keys() {
+			value, _ := db.Get(key)
+			if err := sweep(key, value); err != nil {
+				return removed, size, err
+			}
+		}
+	default:
+		return 0, 0, errors.Errorf("pruning not supported on %T", db)
+	}
+	if dryRun || len(pending) == 0 {
+		return removed, size, nil
+	}
+	return removed, size, remove()
+}
+
+// pruneLoop prunes the state every interval blocks while the chain runs.
+func (bc *BlockChain) pruneLoop(recent, interval uint64) {
+	defer bc.wg.Done()
+
+	headCh := make(chan ChainHeadEvent, 16)
+	sub := bc.SubscribeChainHeadEvent(headCh)
+	defer sub.Unsubscribe()
+
+	last := bc.CurrentBlock().NumberU64()
+	for {
+		select {
+		case ev := <-headCh:
+			number := ev.Block.NumberU64()
+			if number < last+interval {
+				continue
+			}
+			last = number
+			result, err := bc.PruneState(PruneConfig{Recent: recent, SnapPeriod: SaveSnapPeriod})
+			if err != nil {
+				log.Error("Failed to prune state", "number", number, "err", err)
+				continue
+			}
+			log.Info("Pruned state", "number", number, "kept", len(result.Kept), "marked", result.Marked,
+				"removed", result.Removed, "size", result.Size, "elapsed", common.PrettyDuration(result.Elapsed))
+		case <-sub.Err():
+			return
+		case <-bc.quit:
+			return
+		}
+	}
+}
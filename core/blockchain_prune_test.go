@@ -0,0 +1,134 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/hashicorp/golang-lru"
+)
+
+var pruneTestContract = common.HexToAddress("0xc0de")
+
+// commitPruneTestState changes the balance of an account of the state of
+// parent. The shard roots are written to disk, the trie nodes only if flush is
+// set, like the states gcmode full keeps in memory.
+func commitPruneTestState(t *testing.T, db mandb.Database, sdb state.Database, parent []common.CoinRoot, account byte, balance int64, flush bool) []common.CoinRoot {
+	st, err := state.NewStateDBManage(parent, db, sdb)
+	if err != nil {
+		t.Fatalf("failed to open state: %v", err)
+	}
+	if parent == nil {
+		for i := byte(1); i <= 8; i++ {
+			st.AddBalance(params.MAN_COIN, common.MainAccount, common.BytesToAddress([]byte{i << 4, i}), big.NewInt(int64(i)))
+		}
+		st.SetCode(params.MAN_COIN, pruneTestContract, []byte{0x60, 0x00, 0x60, 0x00})
+		st.SetState(params.MAN_COIN, pruneTestContract, common.Hash{0x01}, common.Hash{0x02})
+	}
+	st.SetBalance(params.MAN_COIN, common.MainAccount, common.BytesToAddress([]byte{account << 4, account}), big.NewInt(balance))
+	roots, _, err := st.Commit(true)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if flush {
+		if err := sdb.TrieDB().CommitRoots(roots, false); err != nil {
+			t.Fatalf("failed to write state: %v", err)
+		}
+	}
+	return roots
+}
+
+func newTestStatePruner(bc *BlockChain) *statePruner {
+	return &statePruner{
+		bc:     bc,
+		reader: trieNodeReader{bc.stateCache.TrieDB()},
+		marked: make(map[common.Hash]struct{}),
+		states: make(map[common.Hash]struct{}),
+		blocks: make(map[common.Hash]struct{}),
+	}
+}
+
+func verifyPrunedState(db mandb.Database, roots []common.CoinRoot) bool {
+	for _, report := range state.VerifyState(db, roots, nil) {
+		if report.Failed() {
+			return false
+		}
+	}
+	return true
+}
+
+// Tests that pruning removes the entries of the states which are not kept,
+// skips the states which are not stored and keeps the states of the blocks
+// inserted while the entries are swept.
+func TestPruneState(t *testing.T) {
+	db := mandb.NewMemDatabase()
+	blockCache, _ := lru.New(4)
+	bc := &BlockChain{db: db, stateCache: state.NewDatabase(db), blockCache: blockCache}
+
+	kept := commitPruneTestState(t, db, bc.stateCache, nil, 1, 100, true)
+	stale := commitPruneTestState(t, db, bc.stateCache, kept, 1, 200, true)
+	inserted := commitPruneTestState(t, db, bc.stateCache, kept, 2, 300, false)
+	dropped := commitPruneTestState(t, db, state.NewDatabase(db), kept, 3, 400, false)
+	db.Put([]byte("pruneTestEntry"), []byte{0x01})
+
+	parent := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(10), Roots: kept})
+	head := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(11), ParentHash: parent.Hash(), Roots: inserted})
+	blockCache.Add(parent.Hash(), parent)
+	bc.currentBlock.Store(parent)
+
+	p := newTestStatePruner(bc)
+	p.blocks[parent.Hash()] = struct{}{}
+	if stored, err := p.markState(kept); !stored || err != nil {
+		t.Fatalf("kept state not marked: %v %v", stored, err)
+	}
+	if stored, err := p.markState(dropped); stored || err != nil {
+		t.Fatalf("state not stored marked: %v %v", stored, err)
+	}
+	entries := len(db.Keys())
+	want, _, err := p.sweep(db, true)
+	if err != nil || want == 0 || len(db.Keys()) != entries {
+		t.Fatalf("dry run mismatch: %d removed, %d of %d entries left, %v", want, len(db.Keys()), entries, err)
+	}
+
+	// The head block is inserted after marking, its shard roots stored on
+	// disk are kept as well.
+	bc.currentBlock.Store(head)
+	removed, size, err := p.sweep(db, false)
+	if err != nil || removed != want-1 || size == 0 {
+		t.Fatalf("sweep mismatch: have %d removed (%v), want %d, %v", removed, size, want-1, err)
+	}
+	if len(db.Keys()) != entries-removed {
+		t.Errorf("entry count mismatch: have %d, want %d", len(db.Keys()), entries-removed)
+	}
+	if _, err := db.Get([]byte("pruneTestEntry")); err != nil {
+		t.Errorf("entry of other data removed")
+	}
+	if !verifyPrunedState(db, kept) {
+		t.Errorf("kept state removed")
+	}
+	if verifyPrunedState(db, stale) {
+		t.Errorf("stale state not removed")
+	}
+	if err := bc.stateCache.TrieDB().CommitRoots(inserted, false); err != nil {
+		t.Fatalf("failed to write state: %v", err)
+	}
+	if !verifyPrunedState(db, inserted) {
+		t.Errorf("state of the inserted block removed")
+	}
+
+	// States which are stored but corrupt fail the marking
+	st, _ := state.NewStateDBManage(kept, db, bc.stateCache)
+	code := st.GetCodeHash(params.MAN_COIN, pruneTestContract)
+	db.Delete(code[:])
+	if _, err := newTestStatePruner(bc).markState(kept); err == nil {
+		t.Errorf("state without code marked")
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package core
+
+import (
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
+	"github.com/pkg/errors"
+)
+
+// RewindPlan is the report of rewinding the chain to a block, made before
+// anything is changed.
+type RewindPlan struct {
+	Head       uint64
+	HeadHash   common.Hash
+	Target     uint64
+	TargetHash common.Hash
+	Roots      []common.CoinRoot // State roots per currency of the target
+
+	// StateErr is why the state of the target can not be used, it has to be
+	// regenerated from a snapshot then. The matrix state, which the topology
+	// store is read from, has to be readable as well.
+	StateErr error
+
+	SuperBlock uint64 // Last super block, rewound if above the target
+}
+
+// StateAvailable returns whether the chain can be rewound to the target
+// without regenerating its state.
+func (p *RewindPlan) StateAvailable() bool {
+	return p.StateErr == nil
+}
+
+// Removed returns the number of canonical blocks the rewind removes.
+func (p *RewindPlan) Removed() uint64 {
+	return p.Head - p.Target
+}
+
+// PlanRewind reports what rewinding the chain to the given block removes and
+// whether the state of the block is available.
+func (bc *BlockChain) PlanRewind(number uint64) (*RewindPlan, error) {
+	head := bc.CurrentBlock()
+	if number > head.NumberU64() {
+		return nil, errors.Errorf("rewind target %d above the head %d", number, head.NumberU64())
+	}
+	target := bc.GetBlockByNumber(number)
+	if target == nil {
+		return nil, errors.Errorf("canonical block %d not found", number)
+	}
+	plan := &RewindPlan{
+		Head:       head.NumberU64(),
+		HeadHash:   head.Hash(),
+		Target:     number,
+		TargetHash: target.Hash(),
+		Roots:      target.Root(),
+		StateErr:   bc.checkRewindState(target.Root()),
+	}
+	if superBlock, err := bc.GetSuperBlockNum(); err == nil {
+		plan.SuperBlock = superBlock
+	}
+	return plan, nil
+}
+
+// checkRewindState checks that the state of the roots and the matrix state in
+// it can be read.
+func (bc *BlockChain) checkRewindState(roots []common.CoinRoot) error {
+	st, err := bc.StateAt(roots)
+	if err != nil {
+		return err
+	}
+	if _, err := matrixstate.GetTopologyGraph(st); err != nil {
+		return errors.Wrap(err, "topology graph")
+	}
+	return nil
+}
+
+// VerifyRewind checks that the chain was rewound to the block of the plan,
+// with the state of the block and without canonical blocks above it.
+func (bc *BlockChain) VerifyRewind(number uint64, hash common.Hash) error {
+	if head := bc.CurrentBlock(); head.NumberU64() != number || head.Hash() != hash {
+		return errors.Errorf("head block %d %s, want %d %s", head.NumberU64(), head.Hash().Hex(), number, hash.Hex())
+	}
+	if header := bc.CurrentHeader(); header.Number.Uint64() != number || header.Hash() != hash {
+		return errors.Errorf("head header %d %s, want %d %s", header.Number.Uint64(), header.Hash().Hex(), number, hash.Hex())
+	}
+	if fast := bc.CurrentFastBlock(); fast.NumberU64() > number {
+		return errors.Errorf("head fast block %d above %d", fast.NumberU64(), number)
+	}
+	if next := rawdb.ReadCanonicalHash(bc.db, number+1); next != (common.Hash{}) {
+		return errors.Errorf("canonical block %d %s left", number+1, next.Hex())
+	}
+	if err := bc.checkRewindState(bc.CurrentBlock().Root()); err != nil {
+		return errors.Wrap(err, "state of the head")
+	}
+	return nil
+}
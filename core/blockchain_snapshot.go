@@ -0,0 +1,484 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+	"github.com/MatrixAINetwork/go-matrix/snapshot"
+	"github.com/MatrixAINetwork/go-matrix/trie"
+	"github.com/pkg/errors"
+)
+
+// SetSnapshotDir sets the directory snapshots are saved to and creates it.
+func (bc *BlockChain) SetSnapshotDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	bc.snapDir = dir
+	return nil
+}
+
+// SnapshotDir returns the directory snapshots are saved to.
+func (bc *BlockChain) SnapshotDir() string {
+	return bc.snapDir
+}
+
+// writeSnapshot streams the sections of header into a chunked snapshot file.
+// blocks holds the block of every block section, in section order. The file
+// is written under a temporary name and only renamed once it is complete.
+func (bc *BlockChain) writeSnapshot(filePath string, header *snapshot.Header, blocks []*types.Block) (*snapshot.Manifest, error) {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return nil, err
+	}
+	tmpPath := filePath + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmpPath)
+	defer f.Close()
+
+	w, err := snapshot.NewWriter(f, header)
+	if err != nil {
+		return nil, err
+	}
+	nextBlock := 0
+	for i, section := range header.Sections {
+		var statedb *state.StateDBManage
+		if section.Block {
+			statedb, err = bc.getStateCache(section.Roots)
+		} else {
+			statedb, err = bc.StateAt(section.Roots)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "open state of section %d", i)
+		}
+		err = statedb.RawDumpDBChunks(snapshot.DefaultChunkItems, func(coin string, index int, dump state.DumpDB) error {
+			return w.WriteState(i, &snapshot.StateChunk{Coin: coin, Shard: uint32(index), Dump: dump})
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "dump state of section %d", i)
+		}
+		if !section.Block {
+			continue
+		}
+		block := blocks[nextBlock]
+		nextBlock++
+		seq := uint64(0)
+		if block.IsSuperBlock() {
+			seq, _ = bc.GetSuperBlockSeq()
+		}
+		chunk := &snapshot.BlockChunk{Block: block, Td: bc.GetTd(block.Hash(), block.NumberU64()), Seq: seq}
+		if err := w.WriteBlock(i, chunk); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		return nil, err
+	}
+	return w.Manifest(), nil
+}
+
+// synChunkedSnapshot loads a chunked snapshot. The whole file is verified
+// against its manifest and state roots before anything is written to the
+// chain database.
+func (bc *BlockChain) synChunkedSnapshot(blockNum uint64, filePath string) (uint64, bool) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		log.Error("BlockChain synSnapshot", "open snapshot err", err)
+		return 0, false
+	}
+	defer f.Close()
+
+	sr, err := snapshot.NewReader(f)
+	if err != nil {
+		log.Error("BlockChain synSnapshot", "read snapshot err", err)
+		return 0, false
+	}
+	snapNum := sr.Header.Number
+	if blockNum != 0 && blockNum != snapNum {
+		log.Debug("BlockChain synSnapshot", "the blockNum is not eq the real snapnumber ,sblockNum", blockNum, "snapNum", snapNum)
+		return 0, false
+	}
+	if sr.Header.ChainID == nil || bc.chainConfig.ChainId == nil || sr.Header.ChainID.Cmp(bc.chainConfig.ChainId) != 0 {
+		log.Error("BlockChain synSnapshot snapshot of another chain", "chainID", sr.Header.ChainID, "local", bc.chainConfig.ChainId)
+		return snapNum, false
+	}
+
+	snapBlock, err := bc.applySnapshot(sr, false)
+	if err != nil {
+		log.Error("BlockChain synSnapshot verify snapshot failed", "err", err)
+		return snapNum, false
+	}
+	log.Info("BlockChain synSnapshot snapshot verified", "number", snapNum, "hash", sr.Header.Hash, "manifest", sr.Manifest.Hash(), "chunks", len(sr.Manifest.Chunks))
+
+	currentBlock := bc.CurrentBlock()
+	if !common.IsGreaterLink(common.LinkInfo{Sbs: 0, Bn: snapBlock.NumberU64(), Bt: snapBlock.Time().Uint64()}, common.LinkInfo{Sbs: 0, Bn: currentBlock.NumberU64(), Bt: currentBlock.Time().Uint64()}) {
+		log.Warn("BlockChain synSnapshot the snap blocknum is too low")
+		return snapNum, false
+	}
+	if _, err := bc.applySnapshot(sr, true); err != nil {
+		log.Error("BlockChain synSnapshot load snapshot failed", "err", err)
+		return snapNum, false
+	}
+	return snapNum, true
+}
+
+// applySnapshot walks all chunks of a snapshot and rebuilds its tries. Unless
+// write is set the tries are built in memory only, which verifies the state
+// roots and blocks without touching the chain database. It returns the block
+// the snapshot was taken at.
+func (bc *BlockChain) applySnapshot(sr *snapshot.Reader, write bool) (*types.Block, error) {
+	var loader *snapStateLoader
+	if write {
+		loader = newSnapStateLoader(sr.Header, trie.NewDatabase(bc.db), bc.db)
+	} else {
+		loader = newSnapStateLoader(sr.Header, trie.NewDatabase(mandb.NewMemDatabase()), nil)
+	}
+
+	var snapBlock *types.Block
+	err := sr.Chunks(func(ref snapshot.ChunkRef, payload []byte) error {
+		switch ref.Kind {
+		case snapshot.ChunkState:
+			chunk, err := snapshot.DecodeStateChunk(payload)
+			if err != nil {
+				return err
+			}
+			return loader.apply(int(ref.Section), chunk)
+
+		case snapshot.ChunkBlock:
+			chunk, err := snapshot.DecodeBlockChunk(payload)
+			if err != nil {
+				return err
+			}
+			if err := loader.closeSection(int(ref.Section)); err != nil {
+				return err
+			}
+			if err := checkSnapshotBlock(sr.Header, int(ref.Section), chunk); err != nil {
+				return err
+			}
+			if chunk.Block.NumberU64() == sr.Header.Number {
+				snapBlock = chunk.Block
+			}
+			if write {
+				bc.writeSnapshotBlock(chunk)
+			}
+			return nil
+
+		default:
+			return fmt.Errorf("unknown chunk kind %v", ref.Kind)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := loader.finish(); err != nil {
+		return nil, err
+	}
+	if snapBlock == nil {
+		return nil, errors.New("snapshot block missing")
+	}
+	return snapBlock, nil
+}
+
+func (bc *BlockChain) writeSnapshotBlock(chunk *snapshot.BlockChunk) {
+	block := chunk.Block
+	bc.CurrentBlock().SetHeadNum(block.Number().Int64())
+	if err := bc.WriteBlockWithoutState(block, chunk.Td); err != nil {
+		log.Error("BlockChain synSnapshot", " Failed writing block to chain", err)
+	}
+	rawdb.WriteHeadHeaderHash(bc.db, block.Hash())
+	rawdb.WriteHeadBlockHash(bc.db, block.Hash())
+	rawdb.WriteHeadFastBlockHash(bc.db, block.Hash())
+	rawdb.WriteCanonicalHash(bc.db, block.Hash(), block.NumberU64())
+	bc.CurrentBlockStore(block)
+	log.Info("BlockChain synSnapshot", "block insert ok, number", block.NumberU64())
+}
+
+func checkSnapshotBlock(header *snapshot.Header, index int, chunk *snapshot.BlockChunk) error {
+	section := header.Sections[index]
+	block := chunk.Block
+	if block == nil || chunk.Td == nil {
+		return fmt.Errorf("section %d: incomplete block chunk", index)
+	}
+	if !section.Block || block.NumberU64() != section.Number {
+		return fmt.Errorf("section %d: unexpected block %d", index, block.NumberU64())
+	}
+	if !equalCoinRoots(block.Header().Roots, section.Roots) {
+		return fmt.Errorf("section %d: block roots do not match section roots", index)
+	}
+	if block.NumberU64() == header.Number {
+		if block.Hash() != header.Hash {
+			return fmt.Errorf("snapshot block hash mismatch, have %s want %s", block.Hash().TerminalString(), header.Hash.TerminalString())
+		}
+		if !equalCoinRoots(block.Header().Roots, header.Roots) {
+			return errors.New("snapshot block roots do not match header roots")
+		}
+	}
+	return nil
+}
+
+func equalCoinRoots(a, b []common.CoinRoot) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Cointyp != b[i].Cointyp || a[i].Root != b[i].Root {
+			return false
+		}
+	}
+	return true
+}
+
+// snapStateLoader rebuilds the shard tries of a chunked snapshot. The chunks
+// of a section arrive ordered by coin and shard, so only the shard currently
+// being loaded is kept open. Without a chain database the tries are only
+// hashed, otherwise they are committed like LoadDumps does.
+type snapStateLoader struct {
+	header *snapshot.Header
+	triedb *trie.Database
+	db     mandb.Database
+
+	section int
+	coin    string
+	shard   int
+	open    bool
+
+	root         common.Hash
+	trie         *trie.SecureTrie
+	storage      map[common.Address]*trie.SecureTrie
+	storageRoots map[common.Address]common.Hash
+
+	shardRoots []common.Hash
+	coinRoots  []common.CoinRoot
+	done       []bool
+}
+
+func newSnapStateLoader(header *snapshot.Header, triedb *trie.Database, db mandb.Database) *snapStateLoader {
+	return &snapStateLoader{
+		header:  header,
+		triedb:  triedb,
+		db:      db,
+		section: -1,
+		done:    make([]bool, len(header.Sections)),
+	}
+}
+
+func (l *snapStateLoader) apply(section int, chunk *snapshot.StateChunk) error {
+	if section != l.section || chunk.Coin != l.coin || int(chunk.Shard) != l.shard || !l.open {
+		if err := l.openShard(section, chunk); err != nil {
+			return err
+		}
+	}
+	if chunk.Dump.Root != l.root {
+		return fmt.Errorf("section %d coin %s shard %d: inconsistent shard root", section, chunk.Coin, chunk.Shard)
+	}
+	dump := &chunk.Dump
+	for _, itc := range dump.CodeDatas {
+		if crypto.Keccak256Hash(itc.Code) != common.BytesToHash(itc.CodeHash) {
+			return fmt.Errorf("section %d coin %s: code hash mismatch", section, chunk.Coin)
+		}
+		if l.db != nil {
+			l.triedb.Insert(common.BytesToHash(itc.CodeHash), itc.Code)
+			l.triedb.Commit(common.BytesToHash(itc.CodeHash), false)
+		}
+	}
+	for _, itm := range dump.Matrix {
+		l.trie.Update(itm.GetKey, itm.Value)
+	}
+	for _, ita := range dump.Account {
+		var account state.Account
+		if err := rlp.DecodeBytes(ita.Value, &account); err != nil {
+			return err
+		}
+		l.storageRoots[common.BytesToAddress(ita.GetKey)] = account.Root
+		l.trie.Update(ita.GetKey, ita.Value)
+	}
+	for _, itas := range dump.MapAccount {
+		storageTrie := l.storage[itas.Addr]
+		if storageTrie == nil {
+			storageTrie, _ = trie.NewSecure(common.Hash{}, l.triedb, 0)
+			l.storage[itas.Addr] = storageTrie
+		}
+		for _, it := range itas.DumpData {
+			storageTrie.Update(it.GetKey, it.Value)
+		}
+	}
+	return nil
+}
+
+func (l *snapStateLoader) openShard(section int, chunk *snapshot.StateChunk) error {
+	if section < l.section || (section == l.section && l.done[section]) {
+		return fmt.Errorf("section %d: state chunk out of order", section)
+	}
+	if err := l.closeShard(); err != nil {
+		return err
+	}
+	if section != l.section || chunk.Coin != l.coin {
+		if err := l.closeCoin(); err != nil {
+			return err
+		}
+	}
+	if section != l.section {
+		if err := l.closeSection(l.section); err != nil {
+			return err
+		}
+		l.section = section
+	}
+	if int(chunk.Shard) != len(l.shardRoots) {
+		return fmt.Errorf("section %d coin %s: shard %d out of order", section, chunk.Coin, chunk.Shard)
+	}
+	l.coin, l.shard, l.root = chunk.Coin, int(chunk.Shard), chunk.Dump.Root
+	l.trie, _ = trie.NewSecure(common.Hash{}, l.triedb, 0)
+	l.storage = make(map[common.Address]*trie.SecureTrie)
+	l.storageRoots = make(map[common.Address]common.Hash)
+	l.open = true
+	return nil
+}
+
+func (l *snapStateLoader) commit(t *trie.SecureTrie) (common.Hash, error) {
+	if l.db == nil {
+		return t.Hash(), nil
+	}
+	root, err := t.Commit(nil)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return root, l.triedb.Commit(root, true)
+}
+
+func (l *snapStateLoader) closeShard() error {
+	if !l.open {
+		return nil
+	}
+	l.open = false
+	for addr, storageTrie := range l.storage {
+		root, err := l.commit(storageTrie)
+		if err != nil {
+			return err
+		}
+		if want, ok := l.storageRoots[addr]; !ok || root != want {
+			return fmt.Errorf("section %d coin %s shard %d: storage root mismatch for %s", l.section, l.coin, l.shard, addr.Hex())
+		}
+	}
+	for addr, want := range l.storageRoots {
+		if _, ok := l.storage[addr]; !ok && want != types.EmptyRootHash && want != (common.Hash{}) {
+			return fmt.Errorf("section %d coin %s shard %d: storage of %s missing", l.section, l.coin, l.shard, addr.Hex())
+		}
+	}
+	root, err := l.commit(l.trie)
+	if err != nil {
+		return err
+	}
+	if root != l.root {
+		return fmt.Errorf("section %d coin %s shard %d: root mismatch, have %s want %s", l.section, l.coin, l.shard, root.TerminalString(), l.root.TerminalString())
+	}
+	log.Info("BlockChain synSnapshot shard loaded", "section", l.section, "coin", l.coin, "shard", l.shard, "root", root)
+	l.shardRoots = append(l.shardRoots, root)
+	l.trie, l.storage, l.storageRoots = nil, nil, nil
+	return nil
+}
+
+func (l *snapStateLoader) closeCoin() error {
+	if len(l.shardRoots) == 0 {
+		return nil
+	}
+	bs, bshash := types.RlpEncodeAndHash(l.shardRoots)
+	if l.db != nil {
+		if err := l.db.Put(bshash[:], bs); err != nil {
+			return err
+		}
+	}
+	l.coinRoots = append(l.coinRoots, common.CoinRoot{Cointyp: l.coin, Root: bshash})
+	l.shardRoots = nil
+	return nil
+}
+
+// closeSection finishes the given section if it is the one being loaded and
+// checks its coin roots against the header.
+func (l *snapStateLoader) closeSection(section int) error {
+	if section < 0 || section != l.section || l.done[section] {
+		return nil
+	}
+	if err := l.closeShard(); err != nil {
+		return err
+	}
+	if err := l.closeCoin(); err != nil {
+		return err
+	}
+	want := l.header.Sections[section].Roots
+	if len(want) != len(l.coinRoots) {
+		return fmt.Errorf("section %d: have %d coin roots, want %d", section, len(l.coinRoots), len(want))
+	}
+	for _, cr := range want {
+		found := false
+		for _, have := range l.coinRoots {
+			if have.Cointyp == cr.Cointyp {
+				found = have.Root == cr.Root
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("section %d: coin %s root mismatch", section, cr.Cointyp)
+		}
+	}
+	l.coinRoots = nil
+	l.done[section] = true
+	return nil
+}
+
+func (l *snapStateLoader) finish() error {
+	if err := l.closeSection(l.section); err != nil {
+		return err
+	}
+	for i, done := range l.done {
+		if !done {
+			return fmt.Errorf("section %d: state missing", i)
+		}
+	}
+	return nil
+}
+
+// printChunkedSnapshotAccounts prints the accounts of the state the snapshot
+// was taken at.
+func printChunkedSnapshotAccounts(filePath string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sr, err := snapshot.NewReader(f)
+	if err != nil {
+		return err
+	}
+	last := len(sr.Header.Sections) - 1
+	return sr.Chunks(func(ref snapshot.ChunkRef, payload []byte) error {
+		if ref.Kind != snapshot.ChunkState || int(ref.Section) != last {
+			return nil
+		}
+		chunk, err := snapshot.DecodeStateChunk(payload)
+		if err != nil {
+			return err
+		}
+		chunk.Dump.PrintAccountMsg()
+		return nil
+	})
+}
@@ -68,7 +68,7 @@ func (bc *BlockChain) ProcessStateVersion(version []byte, st *state.StateDBManag
 	return bc.matrixProcessor.ProcessStateVersion(version, st)
 }
 
-func (bd *BlockChain) processStateSwitchGamma(stateDB *state.StateDBManage) error {
+func processStateSwitchGamma(stateDB *state.StateDBManage) error {
 	electCfg, err := matrixstate.GetElectConfigInfo(stateDB)
 	if nil != err {
 		log.Crit("blockChain", "选举配置错误", err)
@@ -157,7 +157,7 @@ func (bd *BlockChain) processStateSwitchGamma(stateDB *state.StateDBManage) erro
 	return nil
 }
 
-func (bd *BlockChain) processStateSwitchDelta(stateDB *state.StateDBManage, t uint64) error {
+func processStateSwitchDelta(stateDB *state.StateDBManage, t uint64) error {
 	err := matrixstate.SetInterestCalc(stateDB, util.CalcDelta)
 	if nil != err {
 		log.Crit("blockChain", "利息奖励引擎设置错误", err)
@@ -176,7 +176,7 @@ func (bd *BlockChain) processStateSwitchDelta(stateDB *state.StateDBManage, t ui
 	return nil
 }
 
-func (bd *BlockChain) processStateSwitchAIMine(stateDB *state.StateDBManage) error {
+func processStateSwitchAIMine(stateDB *state.StateDBManage) error {
 	err := matrixstate.SetMinDifficulty(stateDB, params.AIManMinimumDifficulty)
 	if nil != err {
 		log.Crit("blockChain", "设置最小挖矿难度失败", err)
@@ -302,7 +302,7 @@ func (bd *BlockChain) processStateSwitchAIMine(stateDB *state.StateDBManage) err
 	}
 	return nil
 }
-func (bd *BlockChain) processStateSwitchZeta(stateDB *state.StateDBManage) error {
+func processStateSwitchZeta(stateDB *state.StateDBManage) error {
 	err := matrixstate.SetMinDifficulty(stateDB, params.ZetaMinimumDifficulty)
 	if nil != err {
 		log.Crit("blockChain", "设置最小挖矿难度失败", err)
@@ -344,6 +344,34 @@ func (bd *BlockChain) processStateSwitchZeta(stateDB *state.StateDBManage) error
 	}
 	return nil
 }
+// processGenesisVersionSwitch sets the state which the switch heights of the
+// versions up to the genesis version set on a chain started before them. A
+// genesis of a version before Gamma, or of an unknown one, is left as it is.
+func processGenesisVersionSwitch(t uint64, version string, stateDB *state.StateDBManage) error {
+	if !manversion.IsCorrectVersion([]byte(version)) {
+		return nil
+	}
+	switches := []struct {
+		version string
+		process func() error
+	}{
+		{manversion.VersionGamma, func() error { return processStateSwitchGamma(stateDB) }},
+		{manversion.VersionDelta, func() error { return processStateSwitchDelta(stateDB, t) }},
+		{manversion.VersionAIMine, func() error { return processStateSwitchAIMine(stateDB) }},
+		{manversion.VersionZeta, func() error { return processStateSwitchZeta(stateDB) }},
+	}
+	for _, sw := range switches {
+		if manversion.VersionCmp(version, sw.version) < 0 {
+			break
+		}
+		log.Info("genesis", "设置版本切换状态", sw.version)
+		if err := sw.process(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (bc *BlockChain) ProcessStateVersionSwitch(num uint64, t uint64, version []byte, stateDB *state.StateDBManage) error {
 	//提前一个块设置各自算法引擎和配置，切换高度生效
 	switch num {
@@ -353,7 +381,7 @@ func (bc *BlockChain) ProcessStateVersionSwitch(num uint64, t uint64, version []
 			return nil
 		}
 		log.Info("blockchain", "切换版本Gamma高度", num)
-		return bc.processStateSwitchGamma(stateDB)
+		return processStateSwitchGamma(stateDB)
 
 	case manversion.VersionNumDelta - 1:
 		if manversion.VersionCmp(string(version), manversion.VersionDelta) >= 0 {
@@ -361,7 +389,7 @@ func (bc *BlockChain) ProcessStateVersionSwitch(num uint64, t uint64, version []
 			return nil
 		}
 		log.Info("blockchain", "切换版本Delta 高度", num)
-		return bc.processStateSwitchDelta(stateDB, t)
+		return processStateSwitchDelta(stateDB, t)
 
 	case manversion.VersionNumAIMine - 1:
 		if manversion.VersionCmp(string(version), manversion.VersionAIMine) >= 0 {
@@ -369,7 +397,7 @@ func (bc *BlockChain) ProcessStateVersionSwitch(num uint64, t uint64, version []
 			return nil
 		}
 		log.Info("blockchain", "切换版本AI Mine高度", num)
-		return bc.processStateSwitchAIMine(stateDB)
+		return processStateSwitchAIMine(stateDB)
 
 	case manversion.VersionNumZeta - 1:
 		if manversion.VersionCmp(string(version), manversion.VersionZeta) >= 0 {
@@ -377,7 +405,7 @@ func (bc *BlockChain) ProcessStateVersionSwitch(num uint64, t uint64, version []
 			return nil
 		}
 		log.Info("blockchain", "切换版本Zeta高度", num)
-		return bc.processStateSwitchZeta(stateDB)
+		return processStateSwitchZeta(stateDB)
 
 	default:
 		return nil
@@ -597,15 +625,16 @@ func (bc *BlockChain) SetBlockDurationStatus(header *types.Header, state *state.
 		return nil
 	}
 
-	mineHeader, _, err := bc.getMineHeader(header.Number.Uint64()-1, header.ParentHash, bcInterval)
-	if err != nil {
-		return err
-	}
 	blockDurationStatus := &mc.BlockDurationStatus{[]uint8{0}}
+	// The block after a reelection has no mine header, the first one of a
+	// chain starting at AIMine would precede the genesis
 	if bcInterval.IsReElectionNumber(header.Number.Uint64() - 1) {
-		blockDurationStatus.Status = []uint8{0}
 		return matrixstate.SetBlockDuration(state, blockDurationStatus)
 	}
+	mineHeader, _, err := bc.getMineHeader(header.Number.Uint64()-1, header.ParentHash, bcInterval)
+	if err != nil {
+		return err
+	}
 	innerMiners, err := bc.GetInnerMinerAccounts(header.ParentHash)
 	isTimeout := amhash.IsPowTimeout(mineHeader.Coinbase, innerMiners)
 	if isTimeout {
@@ -435,3 +435,22 @@ func (c *ChainIndexer) removeSectionHead(section uint64) {
 
 	c.indexDb.Delete(append([]byte("shead"), data[:]...))
 }
+
+// RewindChainIndex drops the processed sections of a chain index that are not
+// complete at the given block, so they are processed again once the chain
+// reaches them. The indexer of indexDb must not be running. It returns the
+// number of sections before and after the rewind, nothing is changed if
+// dryRun is set.
+func RewindChainIndex(indexDb mandb.Database, sectionSize, number uint64, dryRun bool) (uint64, uint64) {
+	c := &ChainIndexer{indexDb: indexDb, sectionSize: sectionSize}
+	c.loadValidSections()
+
+	sections, keep := c.storedSections, (number+1)/sectionSize
+	if keep >= sections {
+		return sections, sections
+	}
+	if !dryRun {
+		c.setValidSections(keep)
+	}
+	return sections, keep
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
 	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/params"
 )
 
 // Runs multiple tests with randomized parameters.
@@ -79,11 +80,20 @@ func testChainIndexer(t *testing.T, count int) {
 	}
 	// inject inserts a new random canonical header into the database directly
 	inject := func(number uint64) {
-		header := &types.Header{Number: big.NewInt(int64(number)), Extra: big.NewInt(rand.Int63()).Bytes()}
+		header := &types.Header{
+			Number:   big.NewInt(int64(number)),
+			Extra:    big.NewInt(rand.Int63()).Bytes(),
+			Roots:    []common.CoinRoot{{Cointyp: params.MAN_COIN}},
+			Sharding: []common.Coinbyte{{}},
+		}
 		if number > 0 {
 			header.ParentHash = rawdb.ReadCanonicalHash(db, number-1)
 		}
 		rawdb.WriteHeader(db, header)
+		if number == 0 {
+			// Later headers are rebuilt with the currencies of the genesis block
+			rawdb.WriteBody(db, header.Hash(), number, &types.Body{})
+		}
 		rawdb.WriteCanonicalHash(db, header.Hash(), number)
 	}
 	// Start indexer with an already existing chain
@@ -223,3 +233,55 @@ func (b *testChainIndexBackend) Commit() error {
 	}
 	return nil
 }
+
+// Tests that rewinding a chain index drops the sections not complete at the
+// target block, and that a dry run only reports them.
+func TestRewindChainIndex(t *testing.T) {
+	const sectionSize, stored = 10, 5
+
+	tests := []struct {
+		number uint64
+		kept   uint64
+	}{
+		{100, stored}, // Above the processed sections
+		{49, stored},  // Last block of the last section
+		{48, 4},
+		{25, 2},
+		{5, 0},
+	}
+	for _, test := range tests {
+		db := mandb.NewMemDatabase()
+		indexer := &ChainIndexer{indexDb: db, sectionSize: sectionSize}
+		for i := uint64(0); i < stored; i++ {
+			indexer.setSectionHead(i, common.Hash{byte(i + 1)})
+		}
+		indexer.setValidSections(stored)
+
+		// check reads the index back, as the indexer does on its next start
+		check := func(dryRun bool, want uint64) {
+			reader := &ChainIndexer{indexDb: db, sectionSize: sectionSize}
+			reader.loadValidSections()
+			if reader.storedSections != want {
+				t.Fatalf("block %d, dry run %v: section count mismatch: have %d, want %d", test.number, dryRun, reader.storedSections, want)
+			}
+			for i := uint64(0); i < stored; i++ {
+				if have := reader.SectionHead(i); (have != common.Hash{}) != (i < want) {
+					t.Errorf("block %d, dry run %v: section %d head mismatch: have %x", test.number, dryRun, i, have)
+				}
+			}
+			if !dryRun && want > 0 && want*sectionSize-1 > test.number {
+				t.Errorf("block %d, dry run %v: section %d above the target kept", test.number, dryRun, want-1)
+			}
+		}
+		for _, dryRun := range []bool{true, false} {
+			if sections, kept := RewindChainIndex(db, sectionSize, test.number, dryRun); sections != stored || kept != test.kept {
+				t.Fatalf("block %d, dry run %v: rewind mismatch: have %d/%d, want %d/%d", test.number, dryRun, sections, kept, stored, test.kept)
+			}
+			if dryRun {
+				check(dryRun, stored)
+			} else {
+				check(dryRun, test.kept)
+			}
+		}
+	}
+}
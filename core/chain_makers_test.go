@@ -2,6 +2,12 @@
 // Distributed under the MIT software license, see the accompanying
 // file COPYING or http://www.opensource.org/licenses/mit-license.php
 
+// Uses types.HomesteadSigner, the old NewTransaction, NewBlockChain and
+// InsertChain signatures and GetBalance without a currency.
+
+//go:build legacytests
+// +build legacytests
+
 package core
 
 import (
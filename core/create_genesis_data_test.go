@@ -27,15 +27,17 @@ var (
 	saveFileName = "./saveGenesis.txt"
 )
 
-func init() {
+// loadGens reads the genesis nodes from readFileName, skipping the test when
+// the file is not provided.
+func loadGens(t *testing.T) {
 	file, err := os.Open(readFileName)
 	if err != nil {
-		os.Exit(1)
+		t.Skipf("no genesis node list: %v", err)
 	}
 	defer file.Close()
 	data, err := ioutil.ReadAll(file)
 	if err != nil {
-		os.Exit(1)
+		t.Fatalf("failed to read genesis node list: %v", err)
 	}
 
 	dadaSclice := strings.Split(string(data), "\n")
@@ -60,6 +62,7 @@ func init() {
 }
 
 func TestCreateGenesisData(t *testing.T) {
+	loadGens(t)
 	var str string
 	for k, v := range gens {
 		switch v.Role {
@@ -317,6 +317,12 @@ func (g *Genesis) ToBlock(db mandb.Database) (*types.Block, error) {
 		log.Error("genesis", "MState.SetSuperBlkToState err", err)
 		return nil, err
 	}
+	if g.Number == 0 {
+		if err := processGenesisVersionSwitch(g.Timestamp, g.Version, statedb); err != nil {
+			log.Error("genesis", "processGenesisVersionSwitch err", err)
+			return nil, err
+		}
+	}
 	roots, sharding := statedb.IntermediateRoot(false)
 	head := &types.Header{
 		Number:            new(big.Int).SetUint64(g.Number),
@@ -2,6 +2,12 @@
 // Distributed under the MIT software license, see the accompanying
 // file COPYING or http://www.opensource.org/licenses/mit-license.php
 
+// Uses the old NewBlockChain signature and the single return value of
+// DefaultGenesisBlock and DefaultTestnetGenesisBlock.
+
+//go:build legacytests
+// +build legacytests
+
 package core
 
 import (
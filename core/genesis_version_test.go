@@ -0,0 +1,86 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package core
+
+import (
+	"encoding/binary"
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/params/manparams"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+)
+
+var depositContract = common.BytesToAddress([]byte{10})
+
+// testGenesisDeposit makes the storage of the first version of the deposit
+// contract holding a validator deposit of the account.
+func testGenesisDeposit(addr common.Address) map[common.Hash]common.Hash {
+	index := make([]byte, 8)
+	binary.BigEndian.PutUint64(index, 0)
+	amount := new(big.Int).Mul(big.NewInt(100000), big.NewInt(1e18))
+	return map[common.Hash]common.Hash{
+		common.BytesToHash(append(depositContract[:], 'D', 'N', 'U', 'M')):         common.BigToHash(big.NewInt(1)),
+		common.BytesToHash(append(append(depositContract[:], 'D', 'I'), index...)): addr.Hash(),
+		common.BytesToHash(append(addr[:], 'D')):                                   common.BigToHash(amount),
+		common.BytesToHash(append(addr[:], 'R')):                                   common.BigToHash(big.NewInt(common.RoleValidator)),
+		common.BytesToHash(append(addr[:], 'N', 'X')):                              addr.Hash(),
+		common.BytesToHash(append(addr[:], 'N', 'Y')):                              addr.Hash(),
+	}
+}
+
+// Tests that a genesis of a later version starts with the state the switch
+// heights of the versions up to it set, and one before Gamma without it.
+func TestGenesisVersionSwitch(t *testing.T) {
+	tests := []struct {
+		version  string
+		plug     string
+		deposits bool
+	}{
+		{manversion.VersionAlpha, manparams.ElectPlug_layerd, false},
+		{manversion.VersionBeta, manparams.ElectPlug_layerd, false},
+		{manversion.VersionDelta, manparams.ElectPlug_layerdBSS, true},
+		{manversion.VersionAIMine, manparams.ElectPlug_layerdDP, true},
+		{manversion.VersionZeta, manparams.ElectPlug_layerdDPV2, true},
+	}
+	for _, test := range tests {
+		genesis, err := DefaultGenesis("")
+		if err != nil {
+			t.Fatalf("failed to load the default genesis: %v", err)
+		}
+		genesis.Version = test.version
+		validator := common.HexToAddress("0x01")
+		genesis.NetTopology = common.NetTopology{
+			Type:            common.NetTopoTypeAll,
+			NetTopologyData: []common.NetTopologyData{{Account: validator, Position: common.GeneratePosition(0, common.ElectRoleValidator)}},
+		}
+		genesis.MState.CurElect = &[]GenesisElect{{Account: GenesisAddress(validator), Stock: 1, Type: common.ElectRoleValidator}}
+		genesis.Alloc = GenesisAlloc{depositContract: {Balance: new(big.Int), Storage: testGenesisDeposit(validator)}}
+		accounts := []GenesisAddress{GenesisAddress(common.HexToAddress("0x02"))}
+		genesis.MState.Broadcasts = &accounts
+		genesis.MState.VersionSuperAccounts = &accounts
+		genesis.MState.BlockSuperAccounts = &accounts
+		genesis.MState.MultiCoinSuperAccounts = &accounts
+		genesis.MState.SubChainSuperAccounts = &accounts
+		db := mandb.NewMemDatabase()
+		block := genesis.MustCommit(db)
+		st, err := state.NewStateDBManage(block.Root(), db, state.NewDatabase(db))
+		if err != nil {
+			t.Fatalf("version %s: failed to open state: %v", test.version, err)
+		}
+		if cfg, err := matrixstate.GetElectConfigInfo(st); err != nil || cfg.ElectPlug != test.plug {
+			t.Errorf("version %s: election plug mismatch: have %v (%v), want %s", test.version, cfg, err, test.plug)
+		}
+		version := st.GetState(params.MAN_COIN, common.Address{}, common.BytesToHash([]byte(params.DepositVersionKey_1)))
+		if deposits := version == common.BytesToHash([]byte(params.DepositVersion_1)); deposits != test.deposits {
+			t.Errorf("version %s: deposit version mismatch: have %v, want %v", test.version, deposits, test.deposits)
+		}
+	}
+}
@@ -6,10 +6,14 @@ package core
 
 import (
 	"container/list"
+	"crypto/ecdsa"
+	"math/big"
 
+	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
 	"github.com/MatrixAINetwork/go-matrix/event"
 	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/params"
 )
 
 // Implement our ManTest Manager
@@ -72,3 +76,13 @@ func NewTestManager() *TestManager {
 	// testManager.stateManager = NewStateManager(testManager)
 	return testManager
 }
+
+func transaction(nonce uint64, gaslimit uint64, key *ecdsa.PrivateKey) *types.Transaction {
+	return pricedTransaction(nonce, gaslimit, big.NewInt(1), key)
+}
+
+func pricedTransaction(nonce uint64, gaslimit uint64, gasprice *big.Int, key *ecdsa.PrivateKey) *types.Transaction {
+	tx := types.NewTransaction(nonce, common.Address{}, big.NewInt(100), gaslimit, gasprice, nil, nil, nil, nil, 0, 0, params.MAN_COIN, 0)
+	signed, _ := types.SignTx(tx, types.NewEIP155Signer(params.TestChainConfig.ChainId), key)
+	return signed.(*types.Transaction)
+}
@@ -0,0 +1,178 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package matrixstate
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+)
+
+// KeyInfo describes a matrix state key available at a block.
+type KeyInfo struct {
+	Key   string      `json:"key"`
+	Hash  common.Hash `json:"hash"`  // Hash the value is stored under
+	Type  string      `json:"type"`  // Go type of the decoded value
+	Empty bool        `json:"empty"` // No value stored yet
+}
+
+// KeyDiff is the change of a matrix state key between two states.
+type KeyDiff struct {
+	Key     string      `json:"key"`
+	Changed bool        `json:"changed"`
+	From    interface{} `json:"from"`
+	To      interface{} `json:"to"`
+}
+
+// Keys returns the keys the manager has operators for, sorted.
+func (self *Manager) Keys() []string {
+	keys := make([]string, 0, len(self.operators))
+	for key := range self.operators {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// findStateOperator returns the operator of key for the version of the state.
+// The version info is readable in every version.
+func findStateOperator(st StateDB, key string) (MatrixOperator, error) {
+	if err := checkStateDB(st); err != nil {
+		return nil, err
+	}
+	if key == mc.MSKeyVersionInfo {
+		return versionOpt, nil
+	}
+	mgr := GetManager(GetVersionInfo(st))
+	if mgr == nil {
+		return nil, ErrFindManager
+	}
+	return mgr.FindOperator(key)
+}
+
+// GetKeys lists the keys readable in the state, including the version info.
+func GetKeys(st StateDB) ([]KeyInfo, error) {
+	if err := checkStateDB(st); err != nil {
+		return nil, err
+	}
+	mgr := GetManager(GetVersionInfo(st))
+	if mgr == nil {
+		return nil, ErrFindManager
+	}
+	keys := append([]string{mc.MSKeyVersionInfo}, mgr.Keys()...)
+	infos := make([]KeyInfo, 0, len(keys))
+	for _, key := range keys {
+		opt, err := findStateOperator(st, key)
+		if err != nil {
+			return nil, err
+		}
+		info := KeyInfo{Key: key, Hash: opt.KeyHash(), Empty: len(st.GetMatrixData(opt.KeyHash())) == 0}
+		if value, err := opt.GetValue(st); err == nil && value != nil {
+			info.Type = reflect.TypeOf(value).String()
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// GetValueByKey decodes the value of key with the operator of the state version.
+func GetValueByKey(st StateDB, key string) (interface{}, error) {
+	opt, err := findStateOperator(st, key)
+	if err != nil {
+		return nil, err
+	}
+	return opt.GetValue(st)
+}
+
+// DiffKey compares the value of key in two states. Values are compared in
+// their encoded form, a key unknown to the version of one state is nil there.
+func DiffKey(from, to StateDB, key string) (*KeyDiff, error) {
+	fromOpt, fromErr := findStateOperator(from, key)
+	toOpt, toErr := findStateOperator(to, key)
+	if fromErr != nil && toErr != nil {
+		return nil, fromErr
+	}
+	diff := &KeyDiff{Key: key}
+	fromData, err := storedValue(from, fromOpt, &diff.From)
+	if err != nil {
+		return nil, err
+	}
+	toData, err := storedValue(to, toOpt, &diff.To)
+	if err != nil {
+		return nil, err
+	}
+	diff.Changed = (fromOpt == nil) != (toOpt == nil) || !bytes.Equal(fromData, toData)
+	return diff, nil
+}
+
+// storedValue returns the encoded value of the operator and decodes it into
+// value. Keys without operator or without stored data have no value.
+func storedValue(st StateDB, opt MatrixOperator, value *interface{}) ([]byte, error) {
+	if opt == nil {
+		return nil, nil
+	}
+	data := st.GetMatrixData(opt.KeyHash())
+	if len(data) == 0 {
+		return nil, nil
+	}
+	decoded, err := opt.GetValue(st)
+	if err != nil {
+		return nil, err
+	}
+	*value = decoded
+	return data, nil
+}
+
+// DiffAll compares all keys readable in either state and returns the changed ones.
+func DiffAll(from, to StateDB) ([]*KeyDiff, error) {
+	keys := make(map[string]struct{})
+	for _, st := range []StateDB{from, to} {
+		infos, err := GetKeys(st)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range infos {
+			keys[info.Key] = struct{}{}
+		}
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	diffs := make([]*KeyDiff, 0)
+	for _, key := range sorted {
+		diff, err := DiffKey(from, to, key)
+		if err != nil {
+			return nil, err
+		}
+		if diff.Changed {
+			diffs = append(diffs, diff)
+		}
+	}
+	return diffs, nil
+}
+
+// CheckKeys decodes the stored value of every key readable in the state and
+// returns the keys whose value fails to decode. Keys without value are skipped.
+func CheckKeys(st StateDB) (map[string]error, error) {
+	infos, err := GetKeys(st)
+	if err != nil {
+		return nil, err
+	}
+	failed := make(map[string]error)
+	for _, info := range infos {
+		if info.Empty {
+			continue
+		}
+		if _, err := GetValueByKey(st, info.Key); err != nil {
+			failed[info.Key] = err
+		}
+	}
+	return failed, nil
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package matrixstate
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+)
+
+func newVersionedTestState(t *testing.T) *TestState {
+	st := newTestState()
+	if err := SetVersionInfo(st, manversion.VersionAlpha); err != nil {
+		t.Fatalf("set version failed: %v", err)
+	}
+	if err := SetElectConfigInfo(st, &mc.ElectConfigInfo{ValidatorNum: 11, BackValidator: 5, ElectPlug: "layerd"}); err != nil {
+		t.Fatalf("set elect config failed: %v", err)
+	}
+	return st
+}
+
+func copyTestState(st *TestState) *TestState {
+	cpy := newTestState()
+	for key, value := range st.cache {
+		cpy.cache[key] = value
+	}
+	return cpy
+}
+
+func TestGetKeys(t *testing.T) {
+	st := newVersionedTestState(t)
+	infos, err := GetKeys(st)
+	if err != nil {
+		t.Fatalf("get keys failed: %v", err)
+	}
+	if want := len(mangerAlpha.operators) + 1; len(infos) != want {
+		t.Fatalf("key count mismatch: have %d, want %d", len(infos), want)
+	}
+	found := make(map[string]KeyInfo)
+	for _, info := range infos {
+		found[info.Key] = info
+	}
+	if info := found[mc.MSKeyElectConfigInfo]; info.Empty || info.Type != "*mc.ElectConfigInfo" {
+		t.Errorf("elect config info mismatch: %+v", info)
+	}
+	if info := found[mc.MSKeyVersionInfo]; info.Empty || info.Type != "string" {
+		t.Errorf("version info mismatch: %+v", info)
+	}
+	if info := found[mc.MSKeyAccountBroadcasts]; !info.Empty {
+		t.Errorf("unset key reported as stored: %+v", info)
+	}
+
+	value, err := GetValueByKey(st, mc.MSKeyElectConfigInfo)
+	if err != nil {
+		t.Fatalf("get value failed: %v", err)
+	}
+	if cfg := value.(*mc.ElectConfigInfo); cfg.ValidatorNum != 11 || cfg.ElectPlug != "layerd" {
+		t.Errorf("decoded value mismatch: %+v", cfg)
+	}
+	if _, err := GetValueByKey(st, "no such key"); err != ErrOptNotExist {
+		t.Errorf("unknown key error mismatch: have %v, want %v", err, ErrOptNotExist)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	from := newVersionedTestState(t)
+	to := copyTestState(from)
+	SetElectConfigInfo(to, &mc.ElectConfigInfo{ValidatorNum: 19, BackValidator: 5, ElectPlug: "layerd"})
+	SetBroadcastAccounts(to, []common.Address{common.HexToAddress("0x01")})
+
+	diff, err := DiffKey(from, to, mc.MSKeyElectConfigInfo)
+	if err != nil {
+		t.Fatalf("diff failed: %v", err)
+	}
+	if !diff.Changed || diff.From.(*mc.ElectConfigInfo).ValidatorNum != 11 || diff.To.(*mc.ElectConfigInfo).ValidatorNum != 19 {
+		t.Errorf("diff mismatch: %+v", diff)
+	}
+	if diff, _ := DiffKey(from, to, mc.MSKeyVersionInfo); diff.Changed {
+		t.Errorf("unchanged key reported as changed: %+v", diff)
+	}
+
+	diffs, err := DiffAll(from, to)
+	if err != nil {
+		t.Fatalf("diff all failed: %v", err)
+	}
+	keys := make([]string, 0, len(diffs))
+	for _, diff := range diffs {
+		keys = append(keys, diff.Key)
+	}
+	if want := []string{mc.MSKeyAccountBroadcasts, mc.MSKeyElectConfigInfo}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("changed keys mismatch: have %v, want %v", keys, want)
+	}
+	if diffs[0].From != nil {
+		t.Errorf("unset value not nil: %v", diffs[0].From)
+	}
+}
+
+func TestCheckKeys(t *testing.T) {
+	st := newVersionedTestState(t)
+	failed, err := CheckKeys(st)
+	if err != nil {
+		t.Fatalf("check keys failed: %v", err)
+	}
+	if len(failed) != 0 {
+		t.Fatalf("valid state reported failing keys: %v", failed)
+	}
+
+	opt, err := mangerAlpha.FindOperator(mc.MSKeyElectConfigInfo)
+	if err != nil {
+		t.Fatalf("find operator failed: %v", err)
+	}
+	st.SetMatrixData(opt.KeyHash(), []byte{0xff, 0x01})
+	if failed, err = CheckKeys(st); err != nil {
+		t.Fatalf("check keys failed: %v", err)
+	}
+	if _, ok := failed[mc.MSKeyElectConfigInfo]; !ok || len(failed) != 1 {
+		t.Errorf("failing keys mismatch: have %v, want %s", failed, mc.MSKeyElectConfigInfo)
+	}
+}
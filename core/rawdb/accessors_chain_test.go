@@ -2,6 +2,12 @@
 // Distributed under the MIT software license, see the accompanying
 // file COPYING or http://www.opensource.org/licenses/mit-license.php
 
+// Uses the old NewTransaction signature, Body.Transactions and the Header
+// TxHash and ReceiptHash fields.
+
+//go:build legacytests
+// +build legacytests
+
 package rawdb
 
 import (
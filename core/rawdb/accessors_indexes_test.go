@@ -2,6 +2,11 @@
 // Distributed under the MIT software license, see the accompanying
 // file COPYING or http://www.opensource.org/licenses/mit-license.php
 
+// Uses the old NewTransaction and NewBlock signatures.
+
+//go:build legacytests
+// +build legacytests
+
 package rawdb
 
 import (
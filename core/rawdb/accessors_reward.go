@@ -0,0 +1,135 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package rawdb
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+)
+
+// RewardEntry is a reward credited to or a slash charged to an account. The
+// entries of an account are numbered in block order.
+type RewardEntry struct {
+	Number   uint64
+	TxHash   common.Hash // Reward transaction, empty for slashes
+	Category byte
+	Currency string
+	Amount   *big.Int
+}
+
+func rewardEntryKey(account common.Address, index uint64) []byte {
+	key := append(append(append([]byte{}, rewardEntryPrefix...), account.Bytes()...), make([]byte, 8)...)
+	binary.BigEndian.PutUint64(key[len(key)-8:], index)
+	return key
+}
+
+// ReadRewardEntryCount retrieves the number of reward entries of the account.
+func ReadRewardEntryCount(db DatabaseReader, account common.Address) uint64 {
+	data, _ := db.Get(append(append([]byte{}, rewardCountPrefix...), account.Bytes()...))
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// WriteRewardEntryCount stores the number of reward entries of the account,
+// entries past it are considered removed.
+func WriteRewardEntryCount(db DatabaseWriter, account common.Address, count uint64) {
+	if err := db.Put(append(append([]byte{}, rewardCountPrefix...), account.Bytes()...), encodeBlockNumber(count)); err != nil {
+		log.Crit("Failed to store reward entry count", "err", err)
+	}
+}
+
+// ReadRewardEntry retrieves the reward entry of the account at the index.
+func ReadRewardEntry(db DatabaseReader, account common.Address, index uint64) *RewardEntry {
+	data, _ := db.Get(rewardEntryKey(account, index))
+	if len(data) == 0 {
+		return nil
+	}
+	entry := new(RewardEntry)
+	if err := rlp.DecodeBytes(data, entry); err != nil {
+		log.Error("Invalid reward entry RLP", "account", account, "index", index, "err", err)
+		return nil
+	}
+	return entry
+}
+
+// WriteRewardEntry stores the reward entry of the account at the index.
+func WriteRewardEntry(db DatabaseWriter, account common.Address, index uint64, entry *RewardEntry) {
+	data, err := rlp.EncodeToBytes(entry)
+	if err != nil {
+		log.Crit("Failed to encode reward entry", "err", err)
+	}
+	if err := db.Put(rewardEntryKey(account, index), data); err != nil {
+		log.Crit("Failed to store reward entry", "err", err)
+	}
+}
+
+// ReadRewardSectionAccounts retrieves the accounts with reward entries in the
+// indexed section.
+func ReadRewardSectionAccounts(db DatabaseReader, section uint64) []common.Address {
+	data, _ := db.Get(append(append([]byte{}, rewardSectionPrefix...), encodeBlockNumber(section)...))
+	if len(data) == 0 {
+		return nil
+	}
+	var accounts []common.Address
+	if err := rlp.DecodeBytes(data, &accounts); err != nil {
+		log.Error("Invalid reward section accounts RLP", "section", section, "err", err)
+		return nil
+	}
+	return accounts
+}
+
+// WriteRewardSectionAccounts stores the accounts with reward entries in the
+// indexed section.
+func WriteRewardSectionAccounts(db DatabaseWriter, section uint64, accounts []common.Address) {
+	data, err := rlp.EncodeToBytes(accounts)
+	if err != nil {
+		log.Crit("Failed to encode reward section accounts", "err", err)
+	}
+	if err := db.Put(append(append([]byte{}, rewardSectionPrefix...), encodeBlockNumber(section)...), data); err != nil {
+		log.Crit("Failed to store reward section accounts", "err", err)
+	}
+}
+
+func blockSlashesKey(hash common.Hash, number uint64) []byte {
+	return append(append(append([]byte{}, blockSlashesPrefix...), encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// ReadBlockSlashes retrieves the slashes charged by a block.
+func ReadBlockSlashes(db DatabaseReader, hash common.Hash, number uint64) []common.SlashRecord {
+	data, _ := db.Get(blockSlashesKey(hash, number))
+	if len(data) == 0 {
+		return nil
+	}
+	var slashes []common.SlashRecord
+	if err := rlp.DecodeBytes(data, &slashes); err != nil {
+		log.Error("Invalid block slashes RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return slashes
+}
+
+// WriteBlockSlashes stores the slashes charged by a block.
+func WriteBlockSlashes(db DatabaseWriter, hash common.Hash, number uint64, slashes []common.SlashRecord) {
+	data, err := rlp.EncodeToBytes(slashes)
+	if err != nil {
+		log.Crit("Failed to encode block slashes", "err", err)
+	}
+	if err := db.Put(blockSlashesKey(hash, number), data); err != nil {
+		log.Crit("Failed to store block slashes", "err", err)
+	}
+}
+
+// DeleteBlockSlashes removes the slashes charged by a block.
+func DeleteBlockSlashes(db DatabaseDeleter, hash common.Hash, number uint64) {
+	if err := db.Delete(blockSlashesKey(hash, number)); err != nil {
+		log.Crit("Failed to delete block slashes", "err", err)
+	}
+}
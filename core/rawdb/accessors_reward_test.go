@@ -0,0 +1,82 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package rawdb
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+)
+
+// Tests that reward entries, their counts and the section accounts can be
+// stored and retrieved.
+func TestRewardEntryStorage(t *testing.T) {
+	db := mandb.NewMemDatabase()
+	account := common.BytesToAddress([]byte{0x11})
+
+	if count := ReadRewardEntryCount(db, account); count != 0 {
+		t.Fatalf("pristine entry count mismatch: have %d, want 0", count)
+	}
+	if entry := ReadRewardEntry(db, account, 0); entry != nil {
+		t.Fatalf("non existent entry returned: %v", entry)
+	}
+	entries := []*RewardEntry{
+		{Number: 1, TxHash: common.Hash{0x01}, Category: 0, Currency: "MAN", Amount: big.NewInt(100)},
+		{Number: 7, Category: 0xff, Currency: "MAN", Amount: big.NewInt(3)},
+	}
+	for i, entry := range entries {
+		WriteRewardEntry(db, account, uint64(i), entry)
+	}
+	WriteRewardEntryCount(db, account, uint64(len(entries)))
+
+	if count := ReadRewardEntryCount(db, account); count != uint64(len(entries)) {
+		t.Fatalf("entry count mismatch: have %d, want %d", count, len(entries))
+	}
+	for i, want := range entries {
+		if have := ReadRewardEntry(db, account, uint64(i)); !reflect.DeepEqual(have, want) {
+			t.Fatalf("entry %d mismatch: have %v, want %v", i, have, want)
+		}
+	}
+	if other := ReadRewardEntryCount(db, common.BytesToAddress([]byte{0x22})); other != 0 {
+		t.Fatalf("entry count of other account mismatch: have %d, want 0", other)
+	}
+
+	WriteRewardSectionAccounts(db, 2, []common.Address{account})
+	if accounts := ReadRewardSectionAccounts(db, 2); !reflect.DeepEqual(accounts, []common.Address{account}) {
+		t.Fatalf("section accounts mismatch: have %v", accounts)
+	}
+	if accounts := ReadRewardSectionAccounts(db, 3); accounts != nil {
+		t.Fatalf("non existent section accounts returned: %v", accounts)
+	}
+}
+
+// Tests that the slashes of a block can be stored, retrieved and are deleted
+// with the block.
+func TestBlockSlashesStorage(t *testing.T) {
+	db := mandb.NewMemDatabase()
+	hash := common.Hash{0x01}
+
+	if slashes := ReadBlockSlashes(db, hash, 7); slashes != nil {
+		t.Fatalf("non existent slashes returned: %v", slashes)
+	}
+	slashes := []common.SlashRecord{
+		{Account: common.BytesToAddress([]byte{0x11}), Amount: big.NewInt(5)},
+		{Account: common.BytesToAddress([]byte{0x22}), Amount: big.NewInt(9)},
+	}
+	WriteBlockSlashes(db, hash, 7, slashes)
+	if have := ReadBlockSlashes(db, hash, 7); !reflect.DeepEqual(have, slashes) {
+		t.Fatalf("slashes mismatch: have %v, want %v", have, slashes)
+	}
+	if have := ReadBlockSlashes(db, common.Hash{0x02}, 7); have != nil {
+		t.Fatalf("slashes of other block returned: %v", have)
+	}
+	DeleteBlock(db, hash, 7)
+	if have := ReadBlockSlashes(db, hash, 7); have != nil {
+		t.Fatalf("deleted slashes returned: %v", have)
+	}
+}
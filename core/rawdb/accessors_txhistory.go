@@ -0,0 +1,107 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package rawdb
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+)
+
+// TxHistoryAccount is an account of a currency with a transaction history.
+type TxHistoryAccount struct {
+	Address  common.Address
+	Currency string
+}
+
+// TxHistoryEntry is a transaction or transfer touching an account. The entries
+// of an account are numbered in block order.
+type TxHistoryEntry struct {
+	Number uint64
+	TxHash common.Hash // Transaction, or the scheduled one executed
+	Kind   byte
+	Failed bool     // Transaction failed according to its receipt
+	Amount *big.Int // Amount sent or received by the account
+}
+
+func txHistoryAccountKey(prefix []byte, account TxHistoryAccount) []byte {
+	return append(append(append([]byte{}, prefix...), account.Address.Bytes()...), account.Currency...)
+}
+
+func txHistoryEntryKey(account TxHistoryAccount, index uint64) []byte {
+	return append(txHistoryAccountKey(txHistoryEntryPrefix, account), encodeBlockNumber(index)...)
+}
+
+// ReadTxHistoryCount retrieves the number of history entries of the account.
+func ReadTxHistoryCount(db DatabaseReader, account TxHistoryAccount) uint64 {
+	data, _ := db.Get(txHistoryAccountKey(txHistoryCountPrefix, account))
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// WriteTxHistoryCount stores the number of history entries of the account,
+// entries past it are considered removed.
+func WriteTxHistoryCount(db DatabaseWriter, account TxHistoryAccount, count uint64) {
+	if err := db.Put(txHistoryAccountKey(txHistoryCountPrefix, account), encodeBlockNumber(count)); err != nil {
+		log.Crit("Failed to store tx history count", "err", err)
+	}
+}
+
+// ReadTxHistoryEntry retrieves the history entry of the account at the index.
+func ReadTxHistoryEntry(db DatabaseReader, account TxHistoryAccount, index uint64) *TxHistoryEntry {
+	data, _ := db.Get(txHistoryEntryKey(account, index))
+	if len(data) == 0 {
+		return nil
+	}
+	entry := new(TxHistoryEntry)
+	if err := rlp.DecodeBytes(data, entry); err != nil {
+		log.Error("Invalid tx history entry RLP", "account", account.Address, "currency", account.Currency, "index", index, "err", err)
+		return nil
+	}
+	return entry
+}
+
+// WriteTxHistoryEntry stores the history entry of the account at the index.
+func WriteTxHistoryEntry(db DatabaseWriter, account TxHistoryAccount, index uint64, entry *TxHistoryEntry) {
+	data, err := rlp.EncodeToBytes(entry)
+	if err != nil {
+		log.Crit("Failed to encode tx history entry", "err", err)
+	}
+	if err := db.Put(txHistoryEntryKey(account, index), data); err != nil {
+		log.Crit("Failed to store tx history entry", "err", err)
+	}
+}
+
+// ReadTxHistorySectionAccounts retrieves the accounts with history entries in
+// the indexed section.
+func ReadTxHistorySectionAccounts(db DatabaseReader, section uint64) []TxHistoryAccount {
+	data, _ := db.Get(append(append([]byte{}, txHistorySectionPrefix...), encodeBlockNumber(section)...))
+	if len(data) == 0 {
+		return nil
+	}
+	var accounts []TxHistoryAccount
+	if err := rlp.DecodeBytes(data, &accounts); err != nil {
+		log.Error("Invalid tx history section accounts RLP", "section", section, "err", err)
+		return nil
+	}
+	return accounts
+}
+
+// WriteTxHistorySectionAccounts stores the accounts with history entries in
+// the indexed section.
+func WriteTxHistorySectionAccounts(db DatabaseWriter, section uint64, accounts []TxHistoryAccount) {
+	data, err := rlp.EncodeToBytes(accounts)
+	if err != nil {
+		log.Crit("Failed to encode tx history section accounts", "err", err)
+	}
+	if err := db.Put(append(append([]byte{}, txHistorySectionPrefix...), encodeBlockNumber(section)...), data); err != nil {
+		log.Crit("Failed to store tx history section accounts", "err", err)
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package rawdb
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+)
+
+// Tests that history entries, their counts and the section accounts can be
+// stored and retrieved, separately per currency.
+func TestTxHistoryStorage(t *testing.T) {
+	db := mandb.NewMemDatabase()
+	account := TxHistoryAccount{Address: common.BytesToAddress([]byte{0x11}), Currency: "MAN"}
+	other := TxHistoryAccount{Address: account.Address, Currency: "MANX"}
+
+	if count := ReadTxHistoryCount(db, account); count != 0 {
+		t.Fatalf("pristine entry count mismatch: have %d, want 0", count)
+	}
+	if entry := ReadTxHistoryEntry(db, account, 0); entry != nil {
+		t.Fatalf("non existent entry returned: %v", entry)
+	}
+	entries := []*TxHistoryEntry{
+		{Number: 1, TxHash: common.Hash{0x01}, Kind: 1, Amount: big.NewInt(100)},
+		{Number: 7, TxHash: common.Hash{0x02}, Kind: 2, Failed: true, Amount: big.NewInt(0)},
+	}
+	for i, entry := range entries {
+		WriteTxHistoryEntry(db, account, uint64(i), entry)
+	}
+	WriteTxHistoryCount(db, account, uint64(len(entries)))
+
+	if count := ReadTxHistoryCount(db, account); count != uint64(len(entries)) {
+		t.Fatalf("entry count mismatch: have %d, want %d", count, len(entries))
+	}
+	for i, want := range entries {
+		if have := ReadTxHistoryEntry(db, account, uint64(i)); !reflect.DeepEqual(have, want) {
+			t.Fatalf("entry %d mismatch: have %v, want %v", i, have, want)
+		}
+	}
+	if count := ReadTxHistoryCount(db, other); count != 0 {
+		t.Fatalf("entry count of other currency mismatch: have %d, want 0", count)
+	}
+	if entry := ReadTxHistoryEntry(db, other, 0); entry != nil {
+		t.Fatalf("entry of other currency returned: %v", entry)
+	}
+
+	WriteTxHistorySectionAccounts(db, 2, []TxHistoryAccount{account, other})
+	if accounts := ReadTxHistorySectionAccounts(db, 2); !reflect.DeepEqual(accounts, []TxHistoryAccount{account, other}) {
+		t.Fatalf("section accounts mismatch: have %v", accounts)
+	}
+	if accounts := ReadTxHistorySectionAccounts(db, 3); accounts != nil {
+		t.Fatalf("non existent section accounts returned: %v", accounts)
+	}
+}
@@ -47,6 +47,21 @@ var (
 	// Chain index prefixes (use `i` + single byte to avoid mixing data types).
 	BloomBitsIndexPrefix = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
 
+	// RewardLedgerIndexPrefix is the data table of the reward ledger indexer to track its progress
+	RewardLedgerIndexPrefix = []byte("iR")
+
+	rewardEntryPrefix   = []byte("reward-entry-")   // rewardEntryPrefix + address + index (uint64 big endian) -> reward entry
+	rewardCountPrefix   = []byte("reward-count-")   // rewardCountPrefix + address -> number of reward entries (uint64 big endian)
+	rewardSectionPrefix = []byte("reward-section-") // rewardSectionPrefix + section (uint64 big endian) -> accounts credited in the section
+	blockSlashesPrefix  = []byte("reward-slashes-") // blockSlashesPrefix + num (uint64 big endian) + hash -> slashes charged by the block
+
+	// TxHistoryIndexPrefix is the data table of the transaction history indexer to track its progress
+	TxHistoryIndexPrefix = []byte("iA")
+
+	txHistoryEntryPrefix   = []byte("txhistory-entry-")   // txHistoryEntryPrefix + address + currency + index (uint64 big endian) -> history entry
+	txHistoryCountPrefix   = []byte("txhistory-count-")   // txHistoryCountPrefix + address + currency -> number of history entries (uint64 big endian)
+	txHistorySectionPrefix = []byte("txhistory-section-") // txHistorySectionPrefix + section (uint64 big endian) -> accounts with entries in the section
+
 	preimageCounter    = metrics.NewRegisteredCounter("db/preimage/total", nil)
 	preimageHitCounter = metrics.NewRegisteredCounter("db/preimage/hits", nil)
 
@@ -110,6 +110,78 @@ func (self *StateDB) RawDumpDB() DumpDB {
 	return dump
 }
 
+// RawDumpDBChunks walks the trie like RawDumpDB, but hands the entries to fn
+// in parts of at most limit values instead of collecting the whole trie. At
+// least one part is produced, so empty tries are reported with their root.
+func (self *StateDB) RawDumpDBChunks(limit int, fn func(DumpDB) error) error {
+	root := self.trie.Hash()
+	dump := DumpDB{Root: root}
+	items, emitted := 0, false
+	flush := func() error {
+		err := fn(dump)
+		dump = DumpDB{Root: root}
+		items, emitted = 0, true
+		return err
+	}
+
+	it := trie.NewIterator(self.trie.NodeIterator(nil))
+	for it.Next() {
+		addr := self.trie.GetKey(it.Key)
+		if len(it.Value) >= 4 && bytes.Equal(it.Value[:4], []byte("MAN-")) {
+			dump.Matrix = append(dump.Matrix, DumpValue{it.Key, addr, it.Value})
+			if items++; items >= limit {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		dump.Account = append(dump.Account, DumpValue{it.Key, addr, it.Value})
+		items++
+
+		var data Account
+		if err := rlp.DecodeBytes(it.Value, &data); err != nil {
+			return err
+		}
+		obj := newObject(nil, common.BytesToAddress(addr), data)
+		if code := obj.Code(self.db); len(code) != 0 {
+			dump.CodeDatas = append(dump.CodeDatas, CodeData{CodeHash: data.CodeHash, Code: code})
+			items++
+		}
+
+		storage := MapAccountArr{Addr: common.BytesToAddress(addr)}
+		storageIt := trie.NewIterator(obj.getTrie(self.db).NodeIterator(nil))
+		for storageIt.Next() {
+			storage.DumpData = append(storage.DumpData, DumpValue{storageIt.Key, self.trie.GetKey(storageIt.Key), storageIt.Value})
+			if items++; items >= limit {
+				dump.MapAccount = append(dump.MapAccount, storage)
+				storage = MapAccountArr{Addr: storage.Addr}
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		if storageIt.Err != nil {
+			return storageIt.Err
+		}
+		if len(storage.DumpData) != 0 {
+			dump.MapAccount = append(dump.MapAccount, storage)
+		}
+		if items >= limit {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if it.Err != nil {
+		return it.Err
+	}
+	if items != 0 || !emitted {
+		return flush()
+	}
+	return nil
+}
+
 func (self *StateDB) RawDump1(dbDump *DumpDB) Dump {
 	dump := Dump{
 		Root:       fmt.Sprintf("%x", dbDump.Root),
@@ -0,0 +1,138 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package state
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"sort"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/btrie"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
+
+// Statuses of a scheduled transaction that left the state in a block.
+const (
+	ScheduledTxExecuted = "executed" // Execution time reached, the amounts are paid if the withdraw balance covers them
+	ScheduledTxReverted = "reverted" // Reverted by the sender before the execution time
+)
+
+// ScheduledTx is a revocable or timed transfer parked in the state. The first
+// block whose time reaches the execution time pays the recipients from the
+// withdraw account of the sender.
+type ScheduledTx struct {
+	Hash        common.Hash          `json:"hash"`
+	Type        byte                 `json:"type"` // common.ExtraRevocable or common.ExtraTimeTxType
+	From        string               `json:"from"`
+	Currency    string               `json:"currency"`
+	Amount      *hexutil.Big         `json:"amount"` // Sum of the amounts of the recipients
+	Recipients  []ScheduledRecipient `json:"recipients"`
+	ExecuteTime uint64               `json:"executeTime"`
+	// Revocable transfers can be reverted by the sender with the hash in
+	// blocks before this time, it is the execution time.
+	RevertDeadline uint64 `json:"revertDeadline,omitempty"`
+}
+
+// ScheduledRecipient is a recipient of a scheduled transfer.
+type ScheduledRecipient struct {
+	To     string       `json:"to"`
+	Amount *hexutil.Big `json:"amount"`
+}
+
+// ScheduledTxChange is a scheduled transfer that left the state in a block.
+type ScheduledTxChange struct {
+	*ScheduledTx
+	Status string `json:"status"`
+	Number uint64 `json:"number"`
+}
+
+// GetScheduledTxs returns the revocable and timed transfers of the currency
+// parked in the state which are sent by the address or pay it, sorted by
+// execution time. The empty currency and address match all transfers.
+func (shard *StateDBManage) GetScheduledTxs(cointyp string, addr common.Address) ([]*ScheduledTx, error) {
+	statedb, err := shard.GetStateDb(params.MAN_COIN, common.Address{})
+	if err != nil {
+		return nil, err
+	}
+	txs := make([]*ScheduledTx, 0)
+	for _, typ := range []byte{common.ExtraRevocable, common.ExtraTimeTxType} {
+		for _, it := range statedb.GetBtreeItem(math.MaxUint32, typ) {
+			item, ok := it.(btrie.SpcialTxData)
+			if !ok {
+				continue
+			}
+			for hash, data := range item.Value_Tx {
+				var rt common.RecorbleTx
+				if err := json.Unmarshal(data, &rt); err != nil {
+					log.Warn("Undecodable scheduled transaction", "hash", hash, "err", err)
+					continue
+				}
+				if (cointyp != "" && rt.Cointyp != cointyp) || !rt.Involves(addr) {
+					continue
+				}
+				txs = append(txs, newScheduledTx(hash, typ, item.Key_Time, &rt))
+			}
+		}
+	}
+	sort.Slice(txs, func(i, j int) bool {
+		if txs[i].ExecuteTime != txs[j].ExecuteTime {
+			return txs[i].ExecuteTime < txs[j].ExecuteTime
+		}
+		return txs[i].Hash.Big().Cmp(txs[j].Hash.Big()) < 0
+	})
+	return txs, nil
+}
+
+func newScheduledTx(hash common.Hash, typ byte, key uint32, rt *common.RecorbleTx) *ScheduledTx {
+	tx := &ScheduledTx{
+		Hash:        hash,
+		Type:        typ,
+		From:        base58.Base58EncodeToString(rt.Cointyp, rt.From),
+		Currency:    rt.Cointyp,
+		Recipients:  make([]ScheduledRecipient, 0, len(rt.Adam)),
+		ExecuteTime: uint64(key),
+	}
+	total := new(big.Int)
+	for _, aa := range rt.Adam {
+		amount := new(big.Int)
+		if aa.Amont != nil {
+			amount.Set(aa.Amont)
+		}
+		total.Add(total, amount)
+		tx.Recipients = append(tx.Recipients, ScheduledRecipient{To: base58.Base58EncodeToString(rt.Cointyp, aa.Addr), Amount: (*hexutil.Big)(amount)})
+	}
+	tx.Amount = (*hexutil.Big)(total)
+	if typ == common.ExtraRevocable {
+		tx.RevertDeadline = tx.ExecuteTime
+	}
+	return tx
+}
+
+// ScheduledTxChanges returns the transfers of pre which are gone from cur, the
+// list of the block of the number and time. Transfers whose execution time
+// the block reached were executed, the others reverted.
+func ScheduledTxChanges(pre, cur []*ScheduledTx, number, time uint64) []ScheduledTxChange {
+	left := make(map[common.Hash]struct{}, len(cur))
+	for _, tx := range cur {
+		left[tx.Hash] = struct{}{}
+	}
+	changes := make([]ScheduledTxChange, 0)
+	for _, tx := range pre {
+		if _, ok := left[tx.Hash]; ok {
+			continue
+		}
+		status := ScheduledTxExecuted
+		if tx.ExecuteTime > time {
+			status = ScheduledTxReverted
+		}
+		changes = append(changes, ScheduledTxChange{ScheduledTx: tx, Status: status, Number: number})
+	}
+	return changes
+}
@@ -35,6 +35,7 @@ type StateDBManage struct {
 	shardings    []*CoinManage
 	coinRoot     []common.CoinRoot
 	retcoinRoot  []common.CoinRoot
+	slashes      []common.SlashRecord // Slashes charged while processing the block
 }
 type CoinTrie struct {
 	Coin     string
@@ -135,6 +136,7 @@ func (shard *StateDBManage) Reset(roots []common.CoinRoot) error {
 	shard.thash = common.Hash{}
 	shard.bhash = common.Hash{}
 	shard.txIndex = 0
+	shard.slashes = nil
 	for _, cr := range roots {
 		for _, cm := range shard.shardings {
 			if cm.Cointyp == cr.Cointyp {
@@ -250,6 +252,20 @@ func (shard *StateDBManage) Preimages() map[string]map[common.Hash][]byte {
 	return mm
 }
 
+// AddSlashRecord records a slash charged to a deposit account while processing
+// the block, the records are stored with the block.
+func (shard *StateDBManage) AddSlashRecord(account common.Address, amount *big.Int) {
+	if amount == nil || amount.Sign() <= 0 {
+		return
+	}
+	shard.slashes = append(shard.slashes, common.SlashRecord{Account: account, Amount: new(big.Int).Set(amount)})
+}
+
+// SlashRecords returns the slashes charged while processing the block.
+func (shard *StateDBManage) SlashRecords() []common.SlashRecord {
+	return shard.slashes
+}
+
 func (shard *StateDBManage) AddRefund(cointyp string, address common.Address, gas uint64) {
 
 	sd, err := shard.GetStateDb(cointyp, address)
@@ -626,6 +642,7 @@ func (shard *StateDBManage) Copy() *StateDBManage {
 			Cointyp: root.Cointyp,
 		})
 	}
+	state.slashes = append([]common.SlashRecord(nil), shard.slashes...)
 	return state
 
 }
@@ -1250,4 +1267,20 @@ func (shard *StateDBManage) RawDumpDB() []CoinTrie {
 		snapCoinTrie = append(snapCoinTrie, CoinTrie{shard.Cointyp, dumplist})
 	}
 	return snapCoinTrie
-}
\ No newline at end of file
+}
+
+// RawDumpDBChunks dumps every shard trie of every coin through
+// StateDB.RawDumpDBChunks, in coin and shard order.
+func (shard *StateDBManage) RawDumpDBChunks(limit int, fn func(coin string, index int, dump DumpDB) error) error {
+	for _, cm := range shard.shardings {
+		for i, rm := range cm.Rmanage {
+			err := rm.State.RawDumpDBChunks(limit, func(dump DumpDB) error {
+				return fn(cm.Cointyp, i, dump)
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
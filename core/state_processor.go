@@ -183,7 +183,7 @@ func (p *StateProcessor) ProcessReward(st *state.StateDBManage, header *types.He
 		lottery.LotterySaveAccount(account[params.MAN_COIN], header.VrfValue)
 	}
 
-	interestReward := interest.ManageNew(st, preState)
+	interestReward := interest.ManageNew(st, preState, p.bc.ca)
 
 	if nil == interestReward {
 		return util.AccumulatorCheck(st, rewardList)
@@ -583,11 +583,11 @@ func (p *StateProcessor) ProcessTxs(block *types.Block, statedb *state.StateDBMa
 func (p *StateProcessor) isValidater(hash common.Hash) bool {
 	roles, _ := ca.GetElectedByHeightAndRoleByHash(hash, common.RoleValidator)
 	for _, role := range roles {
-		if role.SignAddress == ca.GetSignAddress() {
+		if role.SignAddress == p.bc.ca.GetSignAddress() {
 			return true
 		}
 	}
-	if ca.GetRole() == common.RoleBroadcast {
+	if p.bc.ca.GetRole() == common.RoleBroadcast {
 		return true
 	}
 	return false
@@ -0,0 +1,187 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package core
+
+import (
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/pkg/errors"
+)
+
+var (
+	errProposalNoGenesis     = errors.New("super block proposal without genesis")
+	errProposalHashMismatch  = errors.New("super block proposal hash mismatch")
+	errProposalSignerMissing = errors.New("signer is not a super block account of the proposal")
+)
+
+// SuperBlockProposal is a super block collecting the signatures of the super
+// block accounts (MSKeyAccountBlockSupers) until it reaches the threshold to
+// be imported. The genesis carries the roots of the super block and no
+// signatures, the signatures are kept beside it.
+type SuperBlockProposal struct {
+	Genesis     *Genesis           `json:"genesis"`
+	Hash        common.Hash        `json:"hash"`        // Hash of the super block without signatures, the signed content
+	StateNumber uint64             `json:"stateNumber"` // Block the signers and the threshold are read at
+	StateHash   common.Hash        `json:"stateHash"`
+	Signers     []common.Address   `json:"signers"`
+	Threshold   int                `json:"threshold"` // Signatures of different signers required
+	Signatures  []common.Signature `json:"signatures"`
+}
+
+// NewSuperBlockProposal creates a proposal without signatures of a super block
+// genesis with its roots. The signatures of the genesis are dropped.
+func NewSuperBlockProposal(genesis *Genesis, stateNumber uint64, stateHash common.Hash, signers []common.Address, threshold int) (*SuperBlockProposal, error) {
+	if genesis == nil {
+		return nil, errProposalNoGenesis
+	}
+	genesis.Signatures = make([]common.Signature, 0)
+	p := &SuperBlockProposal{
+		Genesis:     genesis,
+		StateNumber: stateNumber,
+		StateHash:   stateHash,
+		Signers:     signers,
+		Threshold:   threshold,
+		Signatures:  make([]common.Signature, 0),
+	}
+	hash, err := p.ContentHash()
+	if err != nil {
+		return nil, err
+	}
+	p.Hash = hash
+	return p, nil
+}
+
+// ContentHash computes the hash of the super block of the genesis without
+// signatures, the hash the signers sign.
+func (p *SuperBlockProposal) ContentHash() (common.Hash, error) {
+	if p.Genesis == nil {
+		return common.Hash{}, errProposalNoGenesis
+	}
+	// ToSuperBlock resets the alloc of the genesis, keep the proposal as it is.
+	genesis := *p.Genesis
+	genesis.Signatures = nil
+	block := genesis.ToSuperBlock()
+	if block == nil {
+		return common.Hash{}, errors.New("failed to create super block of the proposal")
+	}
+	return block.HashNoSigns(), nil
+}
+
+// Validate checks that the hash of the proposal is the hash of its content.
+func (p *SuperBlockProposal) Validate() error {
+	hash, err := p.ContentHash()
+	if err != nil {
+		return err
+	}
+	if hash != p.Hash {
+		return errors.Wrapf(errProposalHashMismatch, "content %s, proposal %s", hash.Hex(), p.Hash.Hex())
+	}
+	return nil
+}
+
+// IsSigner returns whether the account is a signer of the proposal.
+func (p *SuperBlockProposal) IsSigner(account common.Address) bool {
+	for _, signer := range p.Signers {
+		if signer == account {
+			return true
+		}
+	}
+	return false
+}
+
+// SignedBy returns the valid signature of every signer that signed the proposal.
+func (p *SuperBlockProposal) SignedBy() map[common.Address]common.Signature {
+	signed := make(map[common.Address]common.Signature)
+	for _, sign := range p.Signatures {
+		account, _, err := crypto.VerifySignWithValidate(p.Hash.Bytes(), sign.Bytes())
+		if err != nil || !p.IsSigner(account) {
+			continue
+		}
+		if _, ok := signed[account]; !ok {
+			signed[account] = sign
+		}
+	}
+	return signed
+}
+
+// AddSignature adds a signature of the proposal hash, replacing an earlier one
+// of the same signer. It returns the signer.
+func (p *SuperBlockProposal) AddSignature(sign common.Signature) (common.Address, error) {
+	account, _, err := crypto.VerifySignWithValidate(p.Hash.Bytes(), sign.Bytes())
+	if err != nil {
+		return common.Address{}, errors.Wrap(err, "invalid signature")
+	}
+	if !p.IsSigner(account) {
+		return account, errors.Wrapf(errProposalSignerMissing, "%s", account.Hex())
+	}
+	signatures := make([]common.Signature, 0, len(p.Signatures)+1)
+	for _, old := range p.Signatures {
+		if signer, _, err := crypto.VerifySignWithValidate(p.Hash.Bytes(), old.Bytes()); err == nil && signer == account {
+			continue
+		}
+		signatures = append(signatures, old)
+	}
+	p.Signatures = append(signatures, sign)
+	return account, nil
+}
+
+// Merge adds the signatures of another copy of the proposal. Both have to
+// propose the same super block to the same signers.
+func (p *SuperBlockProposal) Merge(other *SuperBlockProposal) error {
+	if other.Hash != p.Hash {
+		return errors.Wrapf(errProposalHashMismatch, "merging %s into %s", other.Hash.Hex(), p.Hash.Hex())
+	}
+	if other.Threshold != p.Threshold || !sameSigners(other.Signers, p.Signers) {
+		return errors.New("super block proposals have different signers")
+	}
+	signed := p.SignedBy()
+	for account, sign := range other.SignedBy() {
+		if _, ok := signed[account]; ok {
+			continue
+		}
+		p.Signatures = append(p.Signatures, sign)
+		signed[account] = sign
+	}
+	return nil
+}
+
+// CheckThreshold returns an error if fewer signers than the threshold signed
+// the proposal.
+func (p *SuperBlockProposal) CheckThreshold() error {
+	if signed := len(p.SignedBy()); signed < p.Threshold {
+		return errors.Errorf("super block proposal has %d of %d required signatures", signed, p.Threshold)
+	}
+	return nil
+}
+
+// SignedGenesis returns the genesis of the proposal with the valid signatures,
+// ready to be imported.
+func (p *SuperBlockProposal) SignedGenesis() *Genesis {
+	genesis := *p.Genesis
+	genesis.Signatures = make([]common.Signature, 0, len(p.Signatures))
+	signed := p.SignedBy()
+	for _, signer := range p.Signers {
+		if sign, ok := signed[signer]; ok {
+			genesis.Signatures = append(genesis.Signatures, sign)
+		}
+	}
+	return &genesis
+}
+
+func sameSigners(a, b []common.Address) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[common.Address]struct{}, len(a))
+	for _, account := range a {
+		set[account] = struct{}{}
+	}
+	for _, account := range b {
+		if _, ok := set[account]; !ok {
+			return false
+		}
+	}
+	return true
+}
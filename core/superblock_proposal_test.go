@@ -0,0 +1,181 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/consensus"
+	"github.com/MatrixAINetwork/go-matrix/consensus/mtxdpos"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/pkg/errors"
+)
+
+// superBlockStateReader serves the super block accounts, the other reader
+// methods are not used.
+type superBlockStateReader struct {
+	consensus.StateReader
+	accounts []common.Address
+}
+
+func (r *superBlockStateReader) GetCurrentHash() common.Hash { return common.Hash{} }
+
+func (r *superBlockStateReader) GetBlockSuperAccounts(blockHash common.Hash) ([]common.Address, error) {
+	return r.accounts, nil
+}
+
+func testSuperBlockGenesis() *Genesis {
+	return &Genesis{
+		Number:     100,
+		Timestamp:  1000,
+		ParentHash: common.Hash{0x01},
+		Version:    "1.0.0.0",
+		GasLimit:   1,
+		Difficulty: big.NewInt(1),
+		Alloc:      GenesisAlloc{common.HexToAddress("0x01"): {Balance: big.NewInt(1)}},
+	}
+}
+
+// newTestSuperBlockSigners makes the keys of four super block accounts, three
+// of them have to sign.
+func newTestSuperBlockSigners(t *testing.T) ([]*ecdsa.PrivateKey, []common.Address, int) {
+	keys := make([]*ecdsa.PrivateKey, 4)
+	signers := make([]common.Address, len(keys))
+	for i := range keys {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatalf("failed to generate key: %v", err)
+		}
+		keys[i], signers[i] = key, crypto.PubkeyToAddress(key.PublicKey)
+	}
+	return keys, signers, mtxdpos.NewMtxDPOS(false).SuperBlockSignTarget(len(keys))
+}
+
+func newTestSuperBlockProposal(t *testing.T, signers []common.Address, threshold int) *SuperBlockProposal {
+	p, err := NewSuperBlockProposal(testSuperBlockGenesis(), 99, common.Hash{0x02}, signers, threshold)
+	if err != nil {
+		t.Fatalf("failed to create proposal: %v", err)
+	}
+	return p
+}
+
+func signProposal(t *testing.T, p *SuperBlockProposal, key *ecdsa.PrivateKey) common.Signature {
+	sign, err := crypto.Sign(p.Hash.Bytes(), key)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	return common.BytesToSignature(sign)
+}
+
+func TestSuperBlockProposalContentHash(t *testing.T) {
+	_, signers, threshold := newTestSuperBlockSigners(t)
+	p := newTestSuperBlockProposal(t, signers, threshold)
+
+	if want := testSuperBlockGenesis().ToSuperBlock().HashNoSigns(); p.Hash != want {
+		t.Fatalf("hash mismatch: have %x, want %x", p.Hash, want)
+	}
+	if len(p.Genesis.Alloc) != 1 {
+		t.Errorf("hashing reset the alloc of the proposal")
+	}
+	// The signatures of the genesis aren't part of the signed content
+	p.Genesis.Signatures = []common.Signature{{0x01}}
+	if err := p.Validate(); err != nil {
+		t.Errorf("signatures of the genesis changed the content: %v", err)
+	}
+	p.Genesis.Timestamp++
+	if err := p.Validate(); errors.Cause(err) != errProposalHashMismatch {
+		t.Errorf("changed content: have %v, want %v", err, errProposalHashMismatch)
+	}
+	if _, err := NewSuperBlockProposal(nil, 99, common.Hash{}, signers, threshold); err != errProposalNoGenesis {
+		t.Errorf("proposal without genesis: have %v, want %v", err, errProposalNoGenesis)
+	}
+}
+
+func TestSuperBlockProposalAddSignature(t *testing.T) {
+	keys, signers, threshold := newTestSuperBlockSigners(t)
+	p := newTestSuperBlockProposal(t, signers, threshold)
+
+	if account, err := p.AddSignature(signProposal(t, p, keys[0])); err != nil || account != signers[0] {
+		t.Fatalf("signature rejected: %x, %v", account, err)
+	}
+	// A new signature of the same signer replaces the old one
+	if _, err := p.AddSignature(signProposal(t, p, keys[0])); err != nil || len(p.Signatures) != 1 {
+		t.Fatalf("signature not replaced: %d signatures, %v", len(p.Signatures), err)
+	}
+	outsider, _ := crypto.GenerateKey()
+	if _, err := p.AddSignature(signProposal(t, p, outsider)); errors.Cause(err) != errProposalSignerMissing {
+		t.Errorf("outsider signature: have %v, want %v", err, errProposalSignerMissing)
+	}
+	if _, err := p.AddSignature(common.Signature{0x01}); err == nil {
+		t.Errorf("invalid signature accepted")
+	}
+	if len(p.Signatures) != 1 {
+		t.Errorf("signature count mismatch: have %d, want 1", len(p.Signatures))
+	}
+}
+
+// Tests that two partly signed copies of a proposal merge into one reaching the
+// threshold, whose signed genesis passes the super block check of the
+// consensus engine.
+func TestSuperBlockProposalMerge(t *testing.T) {
+	keys, signers, threshold := newTestSuperBlockSigners(t)
+	if threshold != 3 {
+		t.Fatalf("threshold mismatch: have %d, want 3", threshold)
+	}
+	a := newTestSuperBlockProposal(t, signers, threshold)
+	b := newTestSuperBlockProposal(t, signers, threshold)
+	for _, key := range keys[:2] {
+		a.AddSignature(signProposal(t, a, key))
+	}
+	for _, key := range keys[1:3] {
+		b.AddSignature(signProposal(t, b, key))
+	}
+	engine, reader := mtxdpos.NewMtxDPOS(false), &superBlockStateReader{accounts: signers}
+
+	if err := a.CheckThreshold(); err == nil {
+		t.Fatalf("threshold reached with %d signatures", len(a.Signatures))
+	}
+	if err := engine.CheckSuperBlock(reader, a.SignedGenesis().ToSuperBlock().Header()); err == nil {
+		t.Fatalf("super block below the threshold accepted")
+	}
+	if err := a.Merge(b); err != nil {
+		t.Fatalf("merge failed: %v", err)
+	}
+	if len(a.Signatures) != 3 {
+		t.Fatalf("signature count mismatch: have %d, want 3", len(a.Signatures))
+	}
+	if err := a.CheckThreshold(); err != nil {
+		t.Fatalf("threshold not reached: %v", err)
+	}
+	genesis := a.SignedGenesis()
+	for i, sign := range genesis.Signatures {
+		if account, _, err := crypto.VerifySignWithValidate(a.Hash.Bytes(), sign.Bytes()); err != nil || account != signers[i] {
+			t.Errorf("signature %d mismatch: have %x, %v, want %x", i, account, err, signers[i])
+		}
+	}
+	if len(a.Genesis.Signatures) != 0 {
+		t.Errorf("signed genesis shares the signatures of the proposal")
+	}
+	if err := engine.CheckSuperBlock(reader, genesis.ToSuperBlock().Header()); err != nil {
+		t.Errorf("signed super block rejected: %v", err)
+	}
+
+	// Copies of other content or other signers don't merge
+	other := testSuperBlockGenesis()
+	other.Timestamp++
+	c, err := NewSuperBlockProposal(other, 99, common.Hash{0x02}, signers, threshold)
+	if err != nil {
+		t.Fatalf("failed to create proposal: %v", err)
+	}
+	if err := a.Merge(c); errors.Cause(err) != errProposalHashMismatch {
+		t.Errorf("merge of other content: have %v, want %v", err, errProposalHashMismatch)
+	}
+	if err := a.Merge(newTestSuperBlockProposal(t, signers[:3], threshold)); err == nil {
+		t.Errorf("merge of other signers succeeded")
+	}
+}
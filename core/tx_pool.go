@@ -9,6 +9,7 @@ import (
 	"errors"
 	//"github.com/MatrixAINetwork/go-matrix/p2p/discover"
 	"math/big"
+	"sort"
 	"sync"
 	"time"
 
@@ -52,6 +53,10 @@ var (
 	// with a different one without the required price bump.
 	ErrReplaceUnderpriced = errors.New("replacement transaction underpriced")
 
+	// ErrAccountQueueFull is returned if a transaction behind a nonce gap is added
+	// while the account already holds AccountQueue of them.
+	ErrAccountQueueFull = errors.New("account queue is full")
+
 	// ErrInsufficientFunds is returned if the total cost of executing a transaction
 	// is higher than the balance of the user's account.
 	ErrInsufficientFunds        = errors.New("insufficient funds for gas * price + value")
@@ -93,6 +98,9 @@ var (
 	statsReportInterval = 8 * time.Second // Time interval to report transaction pool stats
 )
 
+// maxDroppedTxs is the number of dropped transactions remembered by the pool.
+const maxDroppedTxs = 1024
+
 var (
 	// Metrics for the pending pool
 	pendingDiscardCounter   = metrics.NewRegisteredCounter("txpool/pending/discard", nil)
@@ -121,6 +129,17 @@ const (
 	TxStatusIncluded
 )
 
+// TxDrop records a transaction that was rejected or evicted by the pool for
+// one of its limits, and the reason why.
+type TxDrop struct {
+	Hash     common.Hash    `json:"hash"`
+	From     common.Address `json:"from"`
+	Nonce    uint64         `json:"nonce"`
+	Currency string         `json:"currency"`
+	Reason   string         `json:"reason"`
+	Time     time.Time      `json:"time"`
+}
+
 type mapst struct {
 	slist []*big.Int
 	mlock sync.RWMutex
@@ -159,7 +178,12 @@ type ConsensusNTx struct {
 
 // TxPoolConfig are the configuration parameters of the transaction pool.
 type TxPoolConfig struct {
-	PriceLimit   uint64 // Minimum gas price to enforce for acceptance into the pool
+	Journal   string        // Journal of local transactions to survive node restarts
+	Rejournal time.Duration // Time interval to regenerate the local transaction journal
+
+	PriceLimit uint64 // Minimum gas price to enforce for acceptance into the pool
+	PriceBump  uint64 // Minimum price bump percentage to replace an already existing transaction (nonce)
+
 	AccountSlots uint64 // Minimum number of executable transaction slots guaranteed per account
 	GlobalSlots  uint64 // Maximum number of executable transaction slots for all accounts
 	AccountQueue uint64 // Maximum number of non-executable transaction slots permitted per account
@@ -170,7 +194,12 @@ type TxPoolConfig struct {
 // DefaultTxPoolConfig contains the default configurations for the transaction
 // pool.
 var DefaultTxPoolConfig = TxPoolConfig{
-	PriceLimit:   params.TxGasPrice, // 2018-08-29 由1改为此值
+	Journal:   "transactions.rlp",
+	Rejournal: time.Hour,
+
+	PriceLimit: params.TxGasPrice, // 2018-08-29 由1改为此值
+	PriceBump:  10,
+
 	AccountSlots: 16,
 	GlobalSlots:  4096 * 5 * 5 * 10, // 2018-08-30 改为乘以5
 	AccountQueue: 64 * 1000,
@@ -195,6 +224,9 @@ type NormalTxPool struct {
 
 	pending map[common.Address]*txList // All currently processable transactions
 	all     *txLookup                  // All transactions to allow lookups
+	priced  *txPricedList              // All transactions sorted by price
+	locals  map[common.Address]bool    // Accounts of locally submitted transactions
+	dropped []TxDrop                   // Most recently dropped transactions
 
 	SContainer map[common.Hash]*types.Transaction
 	NContainer map[uint32]*types.Transaction
@@ -210,6 +242,8 @@ type NormalTxPool struct {
 	mapErrorTxs   map[*big.Int]*types.Transaction  //  存放所有的错误交易（20个区块自动删除）
 	mapTxsTiming  map[common.Hash]time.Time        //  需要做定时删除的交易
 	mapHighttx    map[uint64][]uint32
+
+	metricCurrencies map[string]struct{} // Currencies with pending and queued gauges
 }
 
 // sanitize checks the provided user configurations and changes anything that's
@@ -220,6 +254,14 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool price limit", "provided", conf.PriceLimit, "updated", DefaultTxPoolConfig.PriceLimit)
 		conf.PriceLimit = DefaultTxPoolConfig.PriceLimit
 	}
+	if conf.PriceBump < 1 {
+		log.Warn("Sanitizing invalid txpool price bump", "provided", conf.PriceBump, "updated", DefaultTxPoolConfig.PriceBump)
+		conf.PriceBump = DefaultTxPoolConfig.PriceBump
+	}
+	if conf.Rejournal < time.Second {
+		log.Warn("Sanitizing invalid txpool journal time", "provided", conf.Rejournal, "updated", time.Second)
+		conf.Rejournal = time.Second
+	}
 	return conf
 }
 
@@ -233,6 +275,7 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		chain:         chain,
 		signer:        types.NewEIP155Signer(chainconfig.ChainId),
 		pending:       make(map[common.Address]*txList),
+		locals:        make(map[common.Address]bool),
 		SContainer:    make(map[common.Hash]*types.Transaction), //by
 		NContainer:    make(map[uint32]*types.Transaction),      //by
 		udptxsCh:      make(chan []*types.Transaction_Mx, 0),    //
@@ -247,6 +290,7 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		mapTxsTiming:  make(map[common.Hash]time.Time),        //  需要做定时删除的交易
 		mapHighttx:    make(map[uint64][]uint32, 0),
 	}
+	nPool.priced = newTxPricedList(nPool.all)
 	nPool.reset(nil, chain.CurrentBlock().Header())
 	// Subscribe events from blockchain
 	nPool.chainHeadSub = nPool.chain.SubscribeChainHeadEvent(nPool.chainHeadCh)
@@ -286,6 +330,7 @@ func (nPool *NormalTxPool) loop() {
 			if ev.Block != nil {
 				nPool.mu.Lock()
 				nPool.reset(head.Header(), ev.Block.Header())
+				nPool.enforceLimits()
 				head = ev.Block
 				h := head.Number().Uint64() - 1
 				if txlist, ok := nPool.mapHighttx[h]; ok {
@@ -295,6 +340,9 @@ func (nPool *NormalTxPool) loop() {
 				}
 				delete(nPool.mapHighttx, h)
 				txpoolCache.DeleteTxCache(head.Header().HashNoSignsAndNonce(), head.Number().Uint64())
+				if metrics.Enabled {
+					nPool.updateCurrencyMetrics()
+				}
 				nPool.mu.Unlock()
 				nPool.getPendingTx() //
 			}
@@ -304,6 +352,7 @@ func (nPool *NormalTxPool) loop() {
 		case <-delteTime.C:
 			nPool.mu.Lock()
 			nPool.blockTiming() //
+			nPool.enforceLimits()
 			nPool.mu.Unlock()
 			nPool.getPendingTx()
 
@@ -610,16 +659,46 @@ func (nPool *NormalTxPool) GetTxNmap() map[uint32]*types.Transaction {
 // stats retrieves the current pool stats, namely the number of pending and the
 // number of queued (non-executable) transactions.
 func (nPool *NormalTxPool) stats() (int, int) {
-	pending := 0
-	for _, list := range nPool.pending {
-		for _, txs := range list.txs {
-			pending += txs.Len()
+	pending, queued := 0, 0
+	for addr, list := range nPool.pending {
+		for typ := range list.txs {
+			ready, gapped := nPool.executable(addr, list, typ)
+			pending += len(ready)
+			queued += len(gapped)
 		}
 	}
-	queued := 0
 	return pending, queued
 }
 
+// updateCurrencyMetrics updates the pending and queued gauges of every currency
+// in the pool, and zeroes those of the currencies that left it.
+func (nPool *NormalTxPool) updateCurrencyMetrics() {
+	pending, queued := make(map[string]int), make(map[string]int)
+	for addr, list := range nPool.pending {
+		for typ := range list.txs {
+			ready, gapped := nPool.executable(addr, list, typ)
+			pending[typ] += len(ready)
+			queued[typ] += len(gapped)
+		}
+	}
+	if nPool.metricCurrencies == nil {
+		nPool.metricCurrencies = make(map[string]struct{})
+	}
+	for typ := range pending {
+		nPool.metricCurrencies[typ] = struct{}{}
+	}
+	for typ := range nPool.metricCurrencies {
+		metrics.GetOrRegisterGauge("txpool/"+typ+"/pending", nil).Update(int64(pending[typ]))
+		metrics.GetOrRegisterGauge("txpool/"+typ+"/queued", nil).Update(int64(queued[typ]))
+	}
+}
+
+// executable splits the transactions of an account in the given currency into
+// the executable ones and the ones waiting behind a nonce gap.
+func (nPool *NormalTxPool) executable(addr common.Address, list *txList, typ string) ([]*types.Transaction, []*types.Transaction) {
+	return list.Executable(typ, nPool.currentState.GetNonce(typ, addr))
+}
+
 // Content retrieves the data content of the transaction pool, returning all the
 // pending as well as queued transactions, grouped by account and sorted by nonce.
 func (nPool *NormalTxPool) Content() map[common.Address][]*types.Transaction {
@@ -636,6 +715,59 @@ func (nPool *NormalTxPool) Content() map[common.Address][]*types.Transaction {
 	return pending
 }
 
+// ContentByStatus retrieves the transactions of the pool grouped by account and
+// sorted by nonce, split into the executable ones and the ones waiting behind a
+// nonce gap.
+func (nPool *NormalTxPool) ContentByStatus() (map[common.Address][]*types.Transaction, map[common.Address][]*types.Transaction) {
+	nPool.mu.RLock()
+	defer nPool.mu.RUnlock()
+	pending := make(map[common.Address][]*types.Transaction)
+	queued := make(map[common.Address][]*types.Transaction)
+	for addr, list := range nPool.pending {
+		for typ := range list.txs {
+			ready, gapped := nPool.executable(addr, list, typ)
+			if len(ready) > 0 {
+				pending[addr] = append(pending[addr], ready...)
+			}
+			if len(gapped) > 0 {
+				queued[addr] = append(queued[addr], gapped...)
+			}
+		}
+	}
+	return pending, queued
+}
+
+// Dropped returns the most recently dropped transactions, oldest first.
+func (nPool *NormalTxPool) Dropped() []TxDrop {
+	nPool.mu.RLock()
+	defer nPool.mu.RUnlock()
+	dropped := make([]TxDrop, len(nPool.dropped))
+	copy(dropped, nPool.dropped)
+	return dropped
+}
+
+// recordDrop remembers why a transaction was rejected or evicted by the pool.
+func (nPool *NormalTxPool) recordDrop(tx *types.Transaction, from common.Address, reason string) {
+	log.Debug("Dropped transaction", "hash", tx.Hash(), "from", from, "nonce", tx.Nonce(), "reason", reason)
+	nPool.dropped = append(nPool.dropped, TxDrop{
+		Hash:     tx.Hash(),
+		From:     from,
+		Nonce:    tx.Nonce(),
+		Currency: tx.GetTxCurrency(),
+		Reason:   reason,
+		Time:     time.Now(),
+	})
+	if len(nPool.dropped) > maxDroppedTxs {
+		nPool.dropped = nPool.dropped[len(nPool.dropped)-maxDroppedTxs:]
+	}
+}
+
+// isLocal reports whether the sender of a transaction is a local account.
+func (nPool *NormalTxPool) isLocal(tx *types.Transaction) bool {
+	from, err := nPool.checkTxFrom(tx)
+	return err == nil && nPool.locals[from]
+}
+
 // Pending retrieves all currently processable transactions, groupped by origin
 // account and sorted by nonce. The returned transaction set is a copy and can be
 // freely modified by calling code.
@@ -1054,6 +1186,10 @@ func (nPool *NormalTxPool) RecvErrTx(addr common.Address, listS []*big.Int) {
 		}
 		nPool.mapCaclErrtxs[hash] = append(nPool.mapCaclErrtxs[hash], addr)
 		if uint64(len(nPool.mapCaclErrtxs[hash])) >= params.ErrTxConsensus {
+			if tx := nPool.all.Get(hash); tx != nil {
+				from, _ := nPool.checkTxFrom(tx)
+				nPool.recordDrop(tx, from, "rejected by validators")
+			}
 			nPool.removeTx(hash, true)
 		} else {
 			nPool.addBlockTiming(hash)
@@ -1094,6 +1230,10 @@ func (nPool *NormalTxPool) blockTiming() {
 	}
 	if len(listHash) > 0 {
 		for _, hash := range listHash {
+			if tx := nPool.all.Get(hash); tx != nil {
+				from, _ := nPool.checkTxFrom(tx)
+				nPool.recordDrop(tx, from, "not packed before timeout")
+			}
 			nPool.removeTx(hash, true)
 			delete(nPool.mapCaclErrtxs, hash)
 			delete(nPool.mapTxsTiming, hash)
@@ -1305,27 +1445,43 @@ func (nPool *NormalTxPool) add(tx *types.Transaction, local bool) (bool, error)
 		invalidTxCounter.Inc(1)
 		return false, err
 	}
-	// 池子满了之后就不再加入
-	// If the transaction pool is full, discard transactions
-	if uint64(nPool.all.Count()) >= nPool.config.GlobalSlots+nPool.config.GlobalQueue {
-		return false, ErrTXPoolFull
-	}
-
 	// 如果交易中已经有了from就不需要在做解签
 	from, addrerr := nPool.checkTxFrom(tx)
 	if addrerr != nil {
 		return false, addrerr
 	}
 	if list := nPool.pending[from]; list != nil && list.Overlaps(tx) {
-		return false, ErrTXNonceSame
-	}
-	//将交易加入pending
-	if nPool.pending[from] == nil {
-		nPool.pending[from] = newTxList(false, tx.GetTxCurrency())
+		// A transaction with the same nonce is only replaced if the price is bumped enough
+		inserted, old := list.Add(tx, nPool.config.PriceBump)
+		if !inserted {
+			pendingDiscardCounter.Inc(1)
+			nPool.recordDrop(tx, from, ErrReplaceUnderpriced.Error())
+			return false, ErrReplaceUnderpriced
+		}
+		nPool.all.Remove(old.Hash())
+		nPool.priced.Removed()
+		nPool.deleteMap(old)
+		pendingReplaceCounter.Inc(1)
+		nPool.recordDrop(old, from, "replaced by "+hash.Hex())
+	} else {
+		if err := nPool.checkLimits(tx, from, local); err != nil {
+			return false, err
+		}
+		//将交易加入pending
+		if nPool.pending[from] == nil {
+			nPool.pending[from] = newTxList(false, tx.GetTxCurrency())
+		}
+		nPool.pending[from].Add(tx, nPool.config.PriceBump)
 	}
-	nPool.pending[from].Add(tx, 0)
 	nPool.all.Add(tx)
-	nPool.pendingState.SetNonce(tx.Currency, from, tx.Nonce()+1)
+	nPool.priced.Put(tx)
+	if local {
+		nPool.locals[from] = true
+	}
+	// A replacement or a late gap filler must not move the pending nonce back
+	if nonce := tx.Nonce() + 1; nPool.pendingState.GetNonce(tx.Currency, from) < nonce {
+		nPool.pendingState.SetNonce(tx.Currency, from, nonce)
+	}
 	//selfRole := ca.GetRole()
 	switch ca.GetRole() {
 	case common.RoleMiner, common.RoleValidator:
@@ -1349,6 +1505,124 @@ func (nPool *NormalTxPool) add(tx *types.Transaction, local bool) (bool, error)
 	return true, nil
 }
 
+// checkLimits makes room for a new (not replacing) transaction. Gapped
+// transactions are rejected once the account queue is full; if the whole pool
+// is full the cheapest remote transactions are evicted, unless the new one is
+// not better priced than them.
+func (nPool *NormalTxPool) checkLimits(tx *types.Transaction, from common.Address, local bool) error {
+	if list := nPool.pending[from]; list != nil {
+		ready, gapped := nPool.executable(from, list, tx.GetTxCurrency())
+		next := nPool.currentState.GetNonce(tx.GetTxCurrency(), from)
+		if n := len(ready); n > 0 && ready[n-1].Nonce() >= next {
+			next = ready[n-1].Nonce() + 1
+		}
+		if tx.Nonce() > next && uint64(len(gapped)) >= nPool.config.AccountQueue {
+			queuedRateLimitCounter.Inc(1)
+			nPool.recordDrop(tx, from, ErrAccountQueueFull.Error())
+			return ErrAccountQueueFull
+		}
+	}
+	// 池子满了之后就不再加入
+	// If the transaction pool is full, discard underpriced transactions
+	capacity := nPool.config.GlobalSlots + nPool.config.GlobalQueue
+	if uint64(nPool.all.Count()) < capacity {
+		return nil
+	}
+	if !local && nPool.priced.Underpriced(tx, nPool.isLocal) {
+		underpricedTxCounter.Inc(1)
+		nPool.recordDrop(tx, from, "underpriced, txpool is full")
+		return ErrUnderpriced
+	}
+	drop := nPool.priced.Discard(nPool.all.Count()-int(capacity)+1, nPool.isLocal)
+	if len(drop) == 0 {
+		nPool.recordDrop(tx, from, ErrTXPoolFull.Error())
+		return ErrTXPoolFull
+	}
+	for _, old := range drop {
+		oldFrom, _ := nPool.checkTxFrom(old)
+		nPool.recordDrop(old, oldFrom, "evicted by better priced "+tx.Hash().Hex())
+		underpricedTxCounter.Inc(1)
+		nPool.removeTx(old.Hash(), false)
+	}
+	return nil
+}
+
+// enforceLimits drops transactions beyond the global slot limits. Accounts
+// holding more executable transactions than their guaranteed slots are trimmed
+// first, after that the cheapest gapped transactions are dropped.
+func (nPool *NormalTxPool) enforceLimits() {
+	nPool.truncatePending()
+	nPool.truncateQueue()
+}
+
+// truncatePending drops the highest nonce executable transactions of remote
+// accounts exceeding AccountSlots while there are more than GlobalSlots.
+func (nPool *NormalTxPool) truncatePending() {
+	total := 0
+	ready := make(map[common.Address][]*types.Transaction)
+	offenders := make([]common.Address, 0)
+	for addr, list := range nPool.pending {
+		for typ := range list.txs {
+			txs, _ := nPool.executable(addr, list, typ)
+			ready[addr] = append(ready[addr], txs...)
+		}
+		total += len(ready[addr])
+		if !nPool.locals[addr] && uint64(len(ready[addr])) > nPool.config.AccountSlots {
+			offenders = append(offenders, addr)
+		}
+	}
+	if uint64(total) <= nPool.config.GlobalSlots {
+		return
+	}
+	// Trim the biggest spenders first
+	sort.Slice(offenders, func(i, j int) bool { return len(ready[offenders[i]]) > len(ready[offenders[j]]) })
+	for _, addr := range offenders {
+		txs := ready[addr]
+		sort.Slice(txs, func(i, j int) bool { return txs[i].Nonce() < txs[j].Nonce() })
+		for uint64(len(txs)) > nPool.config.AccountSlots && uint64(total) > nPool.config.GlobalSlots {
+			tx := txs[len(txs)-1]
+			txs = txs[:len(txs)-1]
+			total--
+			pendingRateLimitCounter.Inc(1)
+			nPool.recordDrop(tx, addr, "account exceeds pending slots, txpool is full")
+			nPool.removeTx(tx.Hash(), true)
+		}
+		if uint64(total) <= nPool.config.GlobalSlots {
+			return
+		}
+	}
+}
+
+// truncateQueue drops the cheapest gapped transactions of remote accounts while
+// there are more than GlobalQueue.
+func (nPool *NormalTxPool) truncateQueue() {
+	total := 0
+	queued := make([]*types.Transaction, 0)
+	for addr, list := range nPool.pending {
+		for typ := range list.txs {
+			_, gapped := nPool.executable(addr, list, typ)
+			total += len(gapped)
+			if !nPool.locals[addr] {
+				queued = append(queued, gapped...)
+			}
+		}
+	}
+	if uint64(total) <= nPool.config.GlobalQueue {
+		return
+	}
+	sort.Sort(priceHeap(queued))
+	for _, tx := range queued {
+		if uint64(total) <= nPool.config.GlobalQueue {
+			return
+		}
+		from, _ := nPool.checkTxFrom(tx)
+		total--
+		queuedDiscardCounter.Inc(1)
+		nPool.recordDrop(tx, from, "queue exceeds slots, txpool is full")
+		nPool.removeTx(tx.Hash(), true)
+	}
+}
+
 // AddLocal enqueues a single transaction into the pool if it is valid, marking
 // the sender as a local one in the mean time, ensuring it goes around the local
 // pricing constraints.
@@ -1360,6 +1634,36 @@ func (nPool *NormalTxPool) AddTxPool(txer types.SelfTransaction) error {
 	return err
 }
 
+// AddLocal enqueues a locally submitted transaction into the pool and marks
+// its sender as local, so its transactions are kept in the journal.
+func (nPool *NormalTxPool) AddLocal(txer types.SelfTransaction) error {
+	tx, ok := txer.(*types.Transaction)
+	if !ok {
+		return ErrTXWrongful
+	}
+	return nPool.addTxs([]*types.Transaction{tx}, true)
+}
+
+// Locals retrieves the pending transactions of all local accounts, of every
+// currency, sorted by nonce.
+func (nPool *NormalTxPool) Locals() []types.SelfTransaction {
+	nPool.mu.RLock()
+	defer nPool.mu.RUnlock()
+	txs := make([]types.SelfTransaction, 0)
+	for addr := range nPool.locals {
+		list := nPool.pending[addr]
+		if list == nil {
+			continue
+		}
+		for _, coinTxs := range list.txs {
+			for _, tx := range coinTxs.Flatten() {
+				txs = append(txs, tx)
+			}
+		}
+	}
+	return txs
+}
+
 // addTxs attempts to queue a batch of transactions if they are valid.
 func (nPool *NormalTxPool) addTxs(txs []*types.Transaction, local bool) error {
 	nPool.getFromByTx(txs) //
@@ -1418,13 +1722,17 @@ func (nPool *NormalTxPool) removeTx(hash common.Hash, outofbound bool) {
 	addr, _ := nPool.checkTxFrom(tx)
 	// Remove it from the list of known transactions
 	nPool.all.Remove(hash)
+	nPool.priced.Removed()
 	nPool.deleteMap(tx)
 	// Remove the transaction from the pending lists and reset the account nonce
 	if pending := nPool.pending[addr]; pending != nil {
 		if removed, _ := pending.Remove(tx); removed {
 			// If no more pending transactions are left, remove the list
 			if pending.Empty(tx.GetTxCurrency()) {
-				delete(nPool.pending, addr)
+				delete(pending.txs, tx.GetTxCurrency())
+				if len(pending.txs) == 0 {
+					delete(nPool.pending, addr)
+				}
 			}
 			// Update the account nonce if needed
 			if nonce := tx.Nonce(); nPool.pendingState.GetNonce(tx.Currency, addr) > nonce {
@@ -1449,6 +1757,7 @@ func (nPool *NormalTxPool) DemoteUnexecutables() {
 				hash := tx.Hash()
 				//log.Trace("Removed old pending transaction", "hash", hash)
 				nPool.all.Remove(hash)
+				nPool.priced.Removed()
 			}
 			// Drop all transactions that are too costly (low balance or out of gas), and queue any invalids back for later
 			tBalance := new(big.Int)
@@ -1464,6 +1773,8 @@ func (nPool *NormalTxPool) DemoteUnexecutables() {
 				hash := tx.Hash()
 				log.Trace("Removed unpayable pending transaction", "hash", hash)
 				nPool.all.Remove(hash)
+				nPool.priced.Removed()
+				nPool.recordDrop(tx, addr, ErrInsufficientFunds.Error())
 				pendingNofundsCounter.Inc(1)
 			}
 			// Delete the entire queue entry if it became empty.
@@ -32,6 +32,13 @@ type TxPool interface {
 	ReturnAllTxsByN(listN []uint32, resqe byte, addr common.Address, retch chan *RetChan_txpool)
 }
 
+// LocalTxPool is implemented by the txpools whose locally submitted
+// transactions are kept in the journal of the manager.
+type LocalTxPool interface {
+	AddLocal(tx types.SelfTransaction) error
+	Locals() []types.SelfTransaction
+}
+
 type TxpoolEx interface {
 	DemoteUnexecutables()
 	ListenUdp()
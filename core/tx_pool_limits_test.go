@@ -0,0 +1,227 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
+
+// newTestNormalTxPool creates a pool on top of an in memory state, without the
+// chain subscriptions and the network goroutines of NewTxPool.
+func newTestNormalTxPool(t *testing.T, config TxPoolConfig) *NormalTxPool {
+	db := mandb.NewMemDatabase()
+	statedb, err := state.NewStateDBManage(nil, db, state.NewDatabase(db))
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	pool := &NormalTxPool{
+		config:        config,
+		chainconfig:   params.TestChainConfig,
+		signer:        types.NewEIP155Signer(params.TestChainConfig.ChainId),
+		gasPrice:      new(big.Int).SetUint64(config.PriceLimit),
+		sendTxCh:      make(chan NewTxsEvent, 1024),
+		currentState:  statedb,
+		pendingState:  state.ManageState(statedb),
+		currentMaxGas: 1000000,
+		pending:       make(map[common.Address]*txList),
+		all:           newTxLookup(),
+		locals:        make(map[common.Address]bool),
+		SContainer:    make(map[common.Hash]*types.Transaction),
+		NContainer:    make(map[uint32]*types.Transaction),
+		mapTxsTiming:  make(map[common.Hash]time.Time),
+		mapHighttx:    make(map[uint64][]uint32),
+	}
+	pool.priced = newTxPricedList(pool.all)
+	return pool
+}
+
+// fundedKey creates a new account holding enough funds for the tests.
+func fundedKey(t *testing.T, pool *NormalTxPool) *ecdsa.PrivateKey {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.SetBalance(params.MAN_COIN, common.MainAccount, addr, new(big.Int).Exp(big.NewInt(10), big.NewInt(24), nil))
+	return key
+}
+
+// accountNonce returns the n-th nonce of an account, account nonces start with
+// the NonceAddOne flag set.
+func accountNonce(n uint64) uint64 {
+	return params.NonceAddOne | n
+}
+
+func testTxPoolConfig() TxPoolConfig {
+	config := DefaultTxPoolConfig
+	config.PriceLimit = 1
+	config.AccountSlots = 2
+	config.GlobalSlots = 4
+	config.AccountQueue = 2
+	config.GlobalQueue = 2
+	return config
+}
+
+// Tests that a pending transaction is only replaced by one bumping its price by
+// at least PriceBump percent, and that the replacement doesn't move the pending
+// nonce back.
+func TestTxPoolReplacement(t *testing.T) {
+	pool := newTestNormalTxPool(t, testTxPoolConfig())
+	key := fundedKey(t, pool)
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		if _, err := pool.add(pricedTransaction(accountNonce(nonce), 100000, big.NewInt(100), key), false); err != nil {
+			t.Fatalf("failed to add transaction %d: %v", nonce, err)
+		}
+	}
+	if _, err := pool.add(pricedTransaction(accountNonce(0), 100000, big.NewInt(109), key), false); err != ErrReplaceUnderpriced {
+		t.Fatalf("underpriced replacement error mismatch: have %v, want %v", err, ErrReplaceUnderpriced)
+	}
+	replacement := pricedTransaction(accountNonce(0), 100000, big.NewInt(110), key)
+	if _, err := pool.add(replacement, false); err != nil {
+		t.Fatalf("failed to replace transaction: %v", err)
+	}
+	if pool.all.Count() != 3 {
+		t.Fatalf("transaction count mismatch: have %d, want %d", pool.all.Count(), 3)
+	}
+	if tx := pool.pending[addr].txs[params.MAN_COIN].Get(accountNonce(0)); tx != replacement {
+		t.Fatalf("replacement not pending: have %v, want %v", tx, replacement)
+	}
+	if nonce := pool.pendingState.GetNonce(params.MAN_COIN, addr); nonce != accountNonce(3) {
+		t.Fatalf("pending nonce mismatch: have %d, want %d", nonce, 3)
+	}
+	if dropped := pool.Dropped(); len(dropped) != 2 {
+		t.Fatalf("dropped transaction count mismatch: have %d, want %d", len(dropped), 2)
+	}
+}
+
+// Tests that gapped transactions are rejected once the account queue is full.
+func TestTxPoolAccountQueueLimit(t *testing.T) {
+	pool := newTestNormalTxPool(t, testTxPoolConfig())
+	key := fundedKey(t, pool)
+
+	for _, nonce := range []uint64{0, 2, 3} {
+		if _, err := pool.add(transaction(accountNonce(nonce), 100000, key), false); err != nil {
+			t.Fatalf("failed to add transaction %d: %v", nonce, err)
+		}
+	}
+	if _, err := pool.add(transaction(accountNonce(4), 100000, key), false); err != ErrAccountQueueFull {
+		t.Fatalf("queue limit error mismatch: have %v, want %v", err, ErrAccountQueueFull)
+	}
+	// Filling the gap is still accepted
+	if _, err := pool.add(transaction(accountNonce(1), 100000, key), false); err != nil {
+		t.Fatalf("failed to fill the nonce gap: %v", err)
+	}
+}
+
+// Tests that once the pool is full, new transactions evict the cheapest remote
+// ones, or are rejected if they are not better priced.
+func TestTxPoolGlobalLimitEviction(t *testing.T) {
+	config := testTxPoolConfig()
+	pool := newTestNormalTxPool(t, config)
+
+	keys := make([]*ecdsa.PrivateKey, 7)
+	for i := range keys {
+		keys[i] = fundedKey(t, pool)
+	}
+	capacity := int(config.GlobalSlots + config.GlobalQueue)
+	for i := 0; i < capacity; i++ {
+		if _, err := pool.add(pricedTransaction(accountNonce(0), 100000, big.NewInt(int64(10+i)), keys[i]), false); err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	if _, err := pool.add(pricedTransaction(accountNonce(0), 100000, big.NewInt(10), keys[6]), false); err != ErrUnderpriced {
+		t.Fatalf("underpriced error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+	if _, err := pool.add(pricedTransaction(accountNonce(0), 100000, big.NewInt(100), keys[6]), false); err != nil {
+		t.Fatalf("failed to add better priced transaction: %v", err)
+	}
+	if pool.all.Count() != capacity {
+		t.Fatalf("transaction count mismatch: have %d, want %d", pool.all.Count(), capacity)
+	}
+	if list := pool.pending[crypto.PubkeyToAddress(keys[0].PublicKey)]; list != nil {
+		t.Fatalf("cheapest transaction not evicted")
+	}
+}
+
+// Tests that accounts over their pending slots are trimmed from the highest
+// nonce down while the pool holds more than GlobalSlots executables, sparing
+// local accounts.
+func TestTxPoolTruncatePending(t *testing.T) {
+	config := testTxPoolConfig()
+	config.GlobalQueue = 100
+	pool := newTestNormalTxPool(t, config)
+
+	spender, local, other := fundedKey(t, pool), fundedKey(t, pool), fundedKey(t, pool)
+	for nonce := uint64(0); nonce < 4; nonce++ {
+		if _, err := pool.add(transaction(accountNonce(nonce), 100000, spender), false); err != nil {
+			t.Fatalf("failed to add spender transaction %d: %v", nonce, err)
+		}
+		if _, err := pool.add(transaction(accountNonce(nonce), 100000, local), true); err != nil {
+			t.Fatalf("failed to add local transaction %d: %v", nonce, err)
+		}
+	}
+	if _, err := pool.add(transaction(accountNonce(0), 100000, other), false); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	pool.truncatePending()
+
+	spent := pool.pending[crypto.PubkeyToAddress(spender.PublicKey)].txs[params.MAN_COIN]
+	if spent.Len() != int(config.AccountSlots) {
+		t.Fatalf("spender transaction count mismatch: have %d, want %d", spent.Len(), config.AccountSlots)
+	}
+	if spent.Get(accountNonce(config.AccountSlots-1)) == nil {
+		t.Fatalf("lowest nonce transactions not kept")
+	}
+	if n := pool.pending[crypto.PubkeyToAddress(local.PublicKey)].txs[params.MAN_COIN].Len(); n != 4 {
+		t.Fatalf("local transaction count mismatch: have %d, want %d", n, 4)
+	}
+	if nonce := pool.pendingState.GetNonce(params.MAN_COIN, crypto.PubkeyToAddress(spender.PublicKey)); nonce != accountNonce(config.AccountSlots) {
+		t.Fatalf("pending nonce mismatch: have %d, want %d", nonce, accountNonce(config.AccountSlots))
+	}
+}
+
+// Tests that the cheapest gapped remote transactions are dropped while the
+// pool holds more than GlobalQueue of them.
+func TestTxPoolTruncateQueue(t *testing.T) {
+	config := testTxPoolConfig()
+	config.AccountQueue = 10
+	config.GlobalQueue = 4
+	pool := newTestNormalTxPool(t, config)
+
+	cheap, dear, local := fundedKey(t, pool), fundedKey(t, pool), fundedKey(t, pool)
+	for nonce := uint64(1); nonce < 3; nonce++ {
+		if _, err := pool.add(pricedTransaction(accountNonce(nonce), 100000, big.NewInt(1), cheap), false); err != nil {
+			t.Fatalf("failed to add cheap transaction %d: %v", nonce, err)
+		}
+		if _, err := pool.add(pricedTransaction(accountNonce(nonce), 100000, big.NewInt(5), dear), false); err != nil {
+			t.Fatalf("failed to add dear transaction %d: %v", nonce, err)
+		}
+		if _, err := pool.add(pricedTransaction(accountNonce(nonce), 100000, big.NewInt(1), local), true); err != nil {
+			t.Fatalf("failed to add local transaction %d: %v", nonce, err)
+		}
+	}
+	pool.truncateQueue()
+
+	if list := pool.pending[crypto.PubkeyToAddress(cheap.PublicKey)]; list != nil {
+		t.Fatalf("cheapest queued transactions not dropped")
+	}
+	if n := pool.pending[crypto.PubkeyToAddress(dear.PublicKey)].txs[params.MAN_COIN].Len(); n != 2 {
+		t.Fatalf("dear transaction count mismatch: have %d, want %d", n, 2)
+	}
+	if n := pool.pending[crypto.PubkeyToAddress(local.PublicKey)].txs[params.MAN_COIN].Len(); n != 2 {
+		t.Fatalf("local transaction count mismatch: have %d, want %d", n, 2)
+	}
+}
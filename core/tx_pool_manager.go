@@ -112,9 +112,15 @@ type TxPoolManager struct {
 	txFeed       event.Feed
 	scope        event.SubscriptionScope
 	chain        blockChain
+
+	journal     *txJournal // Journal of local transactions to back up to disk
+	journalMu   sync.Mutex
+	journalQuit chan struct{}
+	journalWg   sync.WaitGroup
 }
 
 func NewTxPoolManager(config TxPoolConfig, chainconfig *params.ChainConfig, chain blockChain, path string) *TxPoolManager {
+	config = (&config).sanitize()
 	txPoolManager := &TxPoolManager{
 		txPoolsMutex: sync.RWMutex{},
 		txPools:      make(map[byte]TxPool),
@@ -125,6 +131,10 @@ func NewTxPoolManager(config TxPoolConfig, chainconfig *params.ChainConfig, chai
 		sendTxCh:     make(chan NewTxsEvent),
 		chain:        chain,
 	}
+	if config.Journal != "" {
+		txPoolManager.journal = newTxJournal(config.Journal)
+		txPoolManager.journalQuit = make(chan struct{})
+	}
 	SelfBlackList = NewInitblacklist()
 	go txPoolManager.loop(config, chainconfig, chain, path)
 	return txPoolManager
@@ -186,6 +196,10 @@ func (pm *TxPoolManager) loop(config TxPoolConfig, chainconfig *params.ChainConf
 
 	normalTxPool := NewTxPool(config, chainconfig, chain, pm.sendTxCh)
 	pm.Subscribe(normalTxPool)
+	if pm.journal != nil {
+		pm.journalWg.Add(1)
+		go pm.journalLoop(config.Rejournal)
+	}
 
 	for {
 		select {
@@ -215,8 +229,75 @@ func (pm *TxPoolManager) loop(config TxPoolConfig, chainconfig *params.ChainConf
 	}
 }
 
+// journalLoop replays the journal into the subscribed pools and regenerates it
+// periodically from their local transactions.
+func (pm *TxPoolManager) journalLoop(rejournal time.Duration) {
+	defer pm.journalWg.Done()
+
+	pm.journalMu.Lock()
+	if err := pm.journal.load(pm.addLocals); err != nil {
+		log.Warn("Failed to load transaction journal", "err", err)
+	}
+	if err := pm.journal.rotate(pm.locals()); err != nil {
+		log.Warn("Failed to rotate transaction journal", "err", err)
+	}
+	pm.journalMu.Unlock()
+
+	journal := time.NewTicker(rejournal)
+	defer journal.Stop()
+	for {
+		select {
+		case <-journal.C:
+			pm.journalMu.Lock()
+			if err := pm.journal.rotate(pm.locals()); err != nil {
+				log.Warn("Failed to rotate local tx journal", "err", err)
+			}
+			pm.journalMu.Unlock()
+		case <-pm.journalQuit:
+			return
+		}
+	}
+}
+
+// addLocals adds journaled transactions to the pools they belong to. The
+// transactions are validated by the pools like newly submitted ones.
+func (pm *TxPoolManager) addLocals(txs []types.SelfTransaction) []error {
+	pm.txPoolsMutex.Lock()
+	defer pm.txPoolsMutex.Unlock()
+	errs := make([]error, len(txs))
+	for i, tx := range txs {
+		pool, ok := pm.txPools[tx.TxType()].(LocalTxPool)
+		if !ok {
+			errs[i] = ErrTxPoolNonexistent
+			continue
+		}
+		errs[i] = pool.AddLocal(tx)
+	}
+	return errs
+}
+
+// locals collects the local transactions of all subscribed pools.
+func (pm *TxPoolManager) locals() []types.SelfTransaction {
+	pm.txPoolsMutex.RLock()
+	defer pm.txPoolsMutex.RUnlock()
+	txs := make([]types.SelfTransaction, 0)
+	for _, pool := range pm.txPools {
+		if lpool, ok := pool.(LocalTxPool); ok {
+			txs = append(txs, lpool.Locals()...)
+		}
+	}
+	return txs
+}
+
 // Stop txpool manager.
 func (pm *TxPoolManager) Stop() {
+	if pm.journal != nil {
+		close(pm.journalQuit)
+		pm.journalWg.Wait()
+		pm.journalMu.Lock()
+		pm.journal.close()
+		pm.journalMu.Unlock()
+	}
 	pm.txPoolsMutex.Lock()
 	defer pm.txPoolsMutex.Unlock()
 	pm.scope.Close()
@@ -409,13 +490,52 @@ func (pm *TxPoolManager) AddRemote(tx types.SelfTransaction) (err error) {
 	err = pm.txPools[tx.TxType()].AddTxPool(tx)
 	return err
 }
-func (pm *TxPoolManager) AddRemotes(txs []types.SelfTransaction) []error {
-	for _, tx := range txs {
-		pm.txPools[tx.TxType()].AddTxPool(tx)
+// AddLocal adds a locally submitted transaction to its pool and records it in
+// the journal if the pool keeps local transactions.
+func (pm *TxPoolManager) AddLocal(tx types.SelfTransaction) error {
+	pm.txPoolsMutex.Lock()
+	pool, ok := pm.txPools[tx.TxType()]
+	if !ok {
+		pm.txPoolsMutex.Unlock()
+		return ErrTxPoolNonexistent
+	}
+	lpool, ok := pool.(LocalTxPool)
+	if !ok {
+		err := pool.AddTxPool(tx)
+		pm.txPoolsMutex.Unlock()
+		return err
+	}
+	err := lpool.AddLocal(tx)
+	pm.txPoolsMutex.Unlock()
+	if err != nil || pm.journal == nil {
+		return err
+	}
+	pm.journalMu.Lock()
+	defer pm.journalMu.Unlock()
+	if err := pm.journal.insert(tx); err != nil {
+		log.Warn("Failed to journal local transaction", "err", err)
 	}
 	return nil
 }
 
+// AddRemotes adds the transactions received from a peer to their pools,
+// returning the error of each transaction.
+func (pm *TxPoolManager) AddRemotes(txs []types.SelfTransaction) []error {
+	pm.txPoolsMutex.Lock()
+	defer pm.txPoolsMutex.Unlock()
+
+	errs := make([]error, len(txs))
+	for i, tx := range txs {
+		pool, ok := pm.txPools[tx.TxType()]
+		if !ok {
+			errs[i] = ErrTxPoolNonexistent
+			continue
+		}
+		errs[i] = pool.AddTxPool(tx)
+	}
+	return errs
+}
+
 func (pm *TxPoolManager) SubscribeNewTxsEvent(ch chan NewTxsEvent) (ev event.Subscription) {
 	return pm.scope.Track(pm.txFeed.Subscribe(ch))
 }
@@ -2,6 +2,12 @@
 // Distributed under the MIT software license, see the accompanying
 // file COPYING or http://www.opensource.org/licenses/mit-license.php
 
+// Uses the single currency TxPool internals (pending, queue, all, AddRemote,
+// lockedReset) and redeclares helpers of tx_pool_limits_test.go.
+
+//go:build legacytests
+// +build legacytests
+
 package core
 
 import (
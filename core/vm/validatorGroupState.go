@@ -256,15 +256,20 @@ func (vc *ValidatorGroupState) GetState(contractAddress common.Address, time uin
 	}
 	return nil
 }
+// DepositLevel returns the highest level rate the amount reaches, -1 if none.
+func (vc *ValidatorGroupState) DepositLevel(amount *big.Int) int {
+	for i := len(vc.Reward.LevelRate) - 1; i >= 0; i-- {
+		if amount.Cmp(vc.Reward.LevelRate[i].Threshold) >= 0 {
+			return i
+		}
+	}
+	return -1
+}
 func (vc *ValidatorGroupState) CalDepositWeight(address common.Address, amount *big.Int) *big.Int {
 	if address == vc.OwnerInfo.Owner {
 		return vc.Reward.OwnerRate.Mul(amount)
-	} else {
-		for i := len(vc.Reward.LevelRate) - 1; i >= 0; i-- {
-			if amount.Cmp(vc.Reward.LevelRate[i].Threshold) >= 0 {
-				return vc.Reward.LevelRate[i].Rate.Mul(amount)
-			}
-		}
+	} else if level := vc.DepositLevel(amount); level >= 0 {
+		return vc.Reward.LevelRate[level].Rate.Mul(amount)
 	}
 	return big.NewInt(0)
 }
@@ -275,6 +275,11 @@ func (v1 *DepositMnger_v1) PayInterest(stateDB vm.StateDBManager, addrA0 common.
 	return nil
 }
 func (v1 *DepositMnger_v1) ConversionDeposit(statedb vm.StateDBManager, t uint64) map[common.Address]common.CheckDepositInfo {
+	if depositInfo == nil {
+		// A genesis from Delta on converts the deposits before the backend is set
+		contract := vm.NewContract(vm.AccountRef(common.HexToAddress("1337")), vm.AccountRef(common.BytesToAddress([]byte{10})), big.NewInt(0), 0, params.MAN_COIN)
+		return new(vm.MatrixDeposit001).ConversionDeposit(contract, statedb, t)
+	}
 	if depositInfo.Contract == nil {
 		depositInfo.Contract = vm.NewContract(vm.AccountRef(common.HexToAddress("1337")), vm.AccountRef(common.BytesToAddress([]byte{10})), big.NewInt(0), 0, params.MAN_COIN)
 	}
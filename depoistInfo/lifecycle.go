@@ -0,0 +1,270 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package depoistInfo
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core/vm"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/reward/depositcfg"
+)
+
+// Statuses of a deposit position and of a withdrawal of the current deposit.
+const (
+	DepositStatusActive      = "active"      // Current deposit earning interest
+	DepositStatusEmpty       = "empty"       // Current deposit without amount or withdrawals
+	DepositStatusLocked      = "locked"      // Fixed deposit renewed every term until withdrawn
+	DepositStatusWithdrawing = "withdrawing" // Withdrawn, waiting until it can be refunded
+	DepositStatusRefundable  = "refundable"  // Can be refunded now
+	DepositStatusRefunded    = "refunded"    // Refunded, the position or withdrawal is gone
+)
+
+// ErrNoDeposit is returned for an account without a deposit.
+var ErrNoDeposit = errors.New("account has no deposit")
+
+// Events of the timeline of a deposit position.
+const (
+	DepositEventTermEnd        = "term_end"        // Running term of a locked deposit ends, it renews unless withdrawn before
+	DepositEventEarliestRefund = "earliest_refund" // Refund time if the locked deposit is withdrawn now
+	DepositEventInterestEnd    = "interest_end"    // Withdrawn fixed deposit stops earning interest
+	DepositEventRefundable     = "refundable"      // Withdrawal can be refunded
+)
+
+// DepositLifecycle is the state of all deposit positions of an account at a
+// block.
+type DepositLifecycle struct {
+	Account     string            `json:"account"`     // Deposit account (A0)
+	SignAccount string            `json:"signAccount"` // Authorized account (A1)
+	Role        *hexutil.Big      `json:"role"`
+	Number      uint64            `json:"number"`
+	Time        uint64            `json:"time"`
+	Positions   []DepositPosition `json:"positions"`
+}
+
+// DepositPosition is a deposit position with its status and timeline.
+type DepositPosition struct {
+	Position    uint64              `json:"position"`
+	DepositType uint64              `json:"depositType"` // 0 for the current deposit, months of a fixed deposit
+	Status      string              `json:"status"`
+	Amount      *hexutil.Big        `json:"amount"`
+	Interest    *hexutil.Big        `json:"interest"` // Accrued interest not paid yet
+	Slash       *hexutil.Big        `json:"slash"`    // Accrued slash, deducted from the interest
+	BeginTime   uint64              `json:"beginTime"`
+	TermEnd     uint64              `json:"termEnd,omitempty"` // End of the running term of a locked deposit
+	EndTime     uint64              `json:"endTime,omitempty"` // Interest end of a withdrawn fixed deposit
+	Withdrawals []DepositWithdrawal `json:"withdrawals"`
+	Timeline    []DepositEvent      `json:"timeline"`
+}
+
+// DepositWithdrawal is a pending withdrawal of a position.
+type DepositWithdrawal struct {
+	Amount     *hexutil.Big `json:"amount"` // Zero for fixed deposits, all of the position is refunded
+	RefundTime uint64       `json:"refundTime"`
+	Status     string       `json:"status"`
+}
+
+// DepositEvent is a future event of a position. The height is estimated from
+// the block time, it is zero if no block time is given.
+type DepositEvent struct {
+	Event  string `json:"event"`
+	Time   uint64 `json:"time"`
+	Height uint64 `json:"height,omitempty"`
+}
+
+// DepositChange is a status change of a position or a withdrawal of it
+// between two blocks.
+type DepositChange struct {
+	Account    string       `json:"account"`
+	Number     uint64       `json:"number"`
+	Position   uint64       `json:"position"`
+	Withdrawal bool         `json:"withdrawal"` // Change of a withdrawal of the current deposit
+	Amount     *hexutil.Big `json:"amount"`
+	Old        string       `json:"old"` // Empty for new positions
+	New        string       `json:"new"`
+}
+
+// GetDepositLifecycle returns the deposit positions of the account at the
+// block of the state, the account may be the deposit or the authorized one.
+// Heights of the timeline are estimated with blockTime seconds per block.
+func GetDepositLifecycle(st vm.StateDBManager, account common.Address, number, time, blockTime uint64) (*DepositLifecycle, error) {
+	dpb := GetDepositBase(st, account)
+	if dpb == nil {
+		if a0 := GetDepositAccount(st, account); a0 != (common.Address{}) {
+			dpb = GetDepositBase(st, a0)
+		}
+	}
+	if dpb == nil {
+		return nil, ErrNoDeposit
+	}
+	lifecycle := &DepositLifecycle{
+		Account:     base58.Base58EncodeToString(params.MAN_COIN, dpb.AddressA0),
+		SignAccount: base58.Base58EncodeToString(params.MAN_COIN, dpb.AddressA1),
+		Role:        (*hexutil.Big)(dpb.Role),
+		Number:      number,
+		Time:        time,
+		Positions:   make([]DepositPosition, 0, len(dpb.Dpstmsg)),
+	}
+	estimate := func(event string, at uint64) DepositEvent {
+		ev := DepositEvent{Event: event, Time: at}
+		if blockTime > 0 && at > time {
+			ev.Height = number + (at-time+blockTime-1)/blockTime
+		}
+		return ev
+	}
+	for _, msg := range dpb.Dpstmsg {
+		pos := DepositPosition{
+			Position:    msg.Position,
+			DepositType: msg.DepositType,
+			Amount:      bigOrZero(msg.DepositAmount),
+			Interest:    bigOrZero(msg.Interest),
+			Slash:       bigOrZero(msg.Slash),
+			BeginTime:   msg.BeginTime,
+			EndTime:     msg.EndTime,
+			Withdrawals: make([]DepositWithdrawal, 0, len(msg.WithDrawInfolist)),
+			Timeline:    make([]DepositEvent, 0),
+		}
+		refundable := false
+		for _, withdraw := range msg.WithDrawInfolist {
+			status := DepositStatusWithdrawing
+			if withdraw.WithDrawTime <= time {
+				status, refundable = DepositStatusRefundable, true
+			} else {
+				pos.Timeline = append(pos.Timeline, estimate(DepositEventRefundable, withdraw.WithDrawTime))
+			}
+			pos.Withdrawals = append(pos.Withdrawals, DepositWithdrawal{
+				Amount:     bigOrZero(withdraw.WithDrawAmount),
+				RefundTime: withdraw.WithDrawTime,
+				Status:     status,
+			})
+		}
+
+		switch {
+		case msg.DepositType == depositcfg.CurrentDeposit:
+			switch {
+			case msg.DepositAmount != nil && msg.DepositAmount.Sign() > 0:
+				pos.Status = DepositStatusActive
+			case refundable:
+				pos.Status = DepositStatusRefundable
+			case len(msg.WithDrawInfolist) > 0:
+				pos.Status = DepositStatusWithdrawing
+			default:
+				pos.Status = DepositStatusEmpty
+			}
+		case len(msg.WithDrawInfolist) > 0:
+			pos.Status = DepositStatusWithdrawing
+			if refundable {
+				pos.Status = DepositStatusRefundable
+			}
+			if msg.EndTime > time {
+				pos.Timeline = append([]DepositEvent{estimate(DepositEventInterestEnd, msg.EndTime)}, pos.Timeline...)
+			}
+		default:
+			pos.Status = DepositStatusLocked
+			if term := fixedDepositTerm(msg.DepositType); term > 0 && time >= msg.BeginTime {
+				// The withdrawal waits for the end of the running term, as CalcDepositTime computes it.
+				pos.TermEnd = (time-msg.BeginTime)/term*term + term + msg.BeginTime
+				pos.Timeline = append(pos.Timeline,
+					estimate(DepositEventTermEnd, pos.TermEnd),
+					estimate(DepositEventEarliestRefund, pos.TermEnd+depositcfg.Delay))
+			}
+		}
+		lifecycle.Positions = append(lifecycle.Positions, pos)
+	}
+	return lifecycle, nil
+}
+
+// fixedDepositTerm returns the term of a fixed deposit type in seconds.
+func fixedDepositTerm(depositType uint64) uint64 {
+	cfg, ok := depositcfg.GetDepositCfg(depositcfg.VersionA).GetDepositPositionCfg(depositType).(*depositcfg.Depositregular)
+	if !ok {
+		return 0
+	}
+	return cfg.Depositreg.Tmduration
+}
+
+func bigOrZero(x *big.Int) *hexutil.Big {
+	if x == nil {
+		return (*hexutil.Big)(new(big.Int))
+	}
+	return (*hexutil.Big)(new(big.Int).Set(x))
+}
+
+// DepositChanges compares the deposit positions of an account at a block with
+// those at its parent, pre is nil if the account had no deposit there.
+func DepositChanges(pre, cur *DepositLifecycle) []DepositChange {
+	changes := make([]DepositChange, 0)
+	if cur == nil && pre == nil {
+		return changes
+	}
+	account, number := "", uint64(0)
+	if cur != nil {
+		account, number = cur.Account, cur.Number
+	} else {
+		account, number = pre.Account, pre.Number+1
+	}
+	prePositions := make(map[uint64]*DepositPosition)
+	if pre != nil {
+		for i := range pre.Positions {
+			prePositions[pre.Positions[i].Position] = &pre.Positions[i]
+		}
+	}
+	change := func(position uint64, withdrawal bool, amount *hexutil.Big, old, new string) {
+		changes = append(changes, DepositChange{Account: account, Number: number, Position: position, Withdrawal: withdrawal, Amount: amount, Old: old, New: new})
+	}
+
+	if cur != nil {
+		for i := range cur.Positions {
+			pos := &cur.Positions[i]
+			prePos, ok := prePositions[pos.Position]
+			if !ok {
+				change(pos.Position, false, pos.Amount, "", pos.Status)
+				continue
+			}
+			delete(prePositions, pos.Position)
+			if prePos.Status != pos.Status {
+				change(pos.Position, false, pos.Amount, prePos.Status, pos.Status)
+			}
+			if pos.DepositType != depositcfg.CurrentDeposit {
+				continue
+			}
+			// Withdrawals of the current deposit change on their own, matched by refund time and amount.
+			preWithdrawals := make(map[string]string)
+			for _, w := range prePos.Withdrawals {
+				preWithdrawals[withdrawalKey(w)] = w.Status
+			}
+			for _, w := range pos.Withdrawals {
+				key := withdrawalKey(w)
+				if status, ok := preWithdrawals[key]; !ok {
+					change(pos.Position, true, w.Amount, "", w.Status)
+				} else if status != w.Status {
+					change(pos.Position, true, w.Amount, status, w.Status)
+				}
+				delete(preWithdrawals, key)
+			}
+			for _, w := range prePos.Withdrawals {
+				if _, ok := preWithdrawals[withdrawalKey(w)]; ok {
+					change(pos.Position, true, w.Amount, w.Status, DepositStatusRefunded)
+				}
+			}
+		}
+	}
+	if pre != nil {
+		for i := range pre.Positions {
+			if pos, ok := prePositions[pre.Positions[i].Position]; ok {
+				change(pos.Position, false, pos.Amount, pos.Status, DepositStatusRefunded)
+			}
+		}
+	}
+	return changes
+}
+
+func withdrawalKey(w DepositWithdrawal) string {
+	return fmt.Sprintf("%d/%s", w.RefundTime, w.Amount.ToInt())
+}
@@ -0,0 +1,285 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package depoistInfo
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/vm"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/reward/depositcfg"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+)
+
+const (
+	testBegin     = uint64(1000000) // Begin time of the test positions
+	testBlockTime = uint64(5)
+	testNumber    = uint64(100)
+)
+
+var (
+	testAccountA0 = common.HexToAddress("0x0a")
+	testAccountA1 = common.HexToAddress("0x1a")
+)
+
+// testDeposit makes a deposit with a current position of the amount and a
+// three month fixed position of twice the amount.
+func testDeposit(amount int64) *common.DepositBase {
+	return &common.DepositBase{
+		AddressA0: testAccountA0,
+		AddressA1: testAccountA1,
+		Role:      big.NewInt(common.RoleValidator),
+		Dpstmsg: []common.DepositMsg{
+			{DepositType: depositcfg.CurrentDeposit, DepositAmount: big.NewInt(amount), Interest: big.NewInt(1), Slash: big.NewInt(0), BeginTime: testBegin, Position: 0},
+			{DepositType: depositcfg.MONTH_3, DepositAmount: big.NewInt(2 * amount), Interest: big.NewInt(2), Slash: big.NewInt(0), BeginTime: testBegin, Position: 1},
+		},
+	}
+}
+
+func copyDeposit(t *testing.T, dpb *common.DepositBase) *common.DepositBase {
+	enc, err := rlp.EncodeToBytes(dpb)
+	if err != nil {
+		t.Fatalf("failed to encode deposit: %v", err)
+	}
+	cpy := new(common.DepositBase)
+	if err := rlp.DecodeBytes(enc, cpy); err != nil {
+		t.Fatalf("failed to decode deposit: %v", err)
+	}
+	return cpy
+}
+
+// withdraw withdraws the amount of the position at time t the way the deposit
+// contract does.
+func withdraw(t *testing.T, dpb *common.DepositBase, index uint64, amount int64, at uint64) *common.DepositBase {
+	dpb = copyDeposit(t, dpb)
+	cfg := depositcfg.GetDepositCfg(depositcfg.VersionA).GetDepositPositionCfg(dpb.Dpstmsg[index].DepositType)
+	if err := cfg.CalcDepositTime(index, dpb, big.NewInt(amount), at); err != nil {
+		t.Fatalf("failed to withdraw: %v", err)
+	}
+	return dpb
+}
+
+// refund refunds the withdrawals of the current position due at time t, and
+// the fixed positions which are refundable.
+func refund(t *testing.T, dpb *common.DepositBase, at uint64) *common.DepositBase {
+	dpb = copyDeposit(t, dpb)
+	msgs := dpb.Dpstmsg[:0]
+	for i, msg := range dpb.Dpstmsg {
+		if msg.DepositType == depositcfg.CurrentDeposit {
+			cfg := depositcfg.GetDepositCfg(depositcfg.VersionA).GetDepositPositionCfg(msg.DepositType)
+			if _, _, err := cfg.CheckAndcalcrefundDeposit(uint64(i), dpb, at); err != nil {
+				t.Fatalf("failed to refund: %v", err)
+			}
+			msg = dpb.Dpstmsg[i]
+		} else if len(msg.WithDrawInfolist) > 0 && msg.WithDrawInfolist[0].WithDrawTime <= at {
+			continue
+		}
+		msgs = append(msgs, msg)
+	}
+	dpb.Dpstmsg = msgs
+	return dpb
+}
+
+// newDepositState stores the deposit in a fresh state, along with the lookup
+// of its authorized account.
+func newDepositState(t *testing.T, dpb *common.DepositBase) vm.StateDBManager {
+	db := mandb.NewMemDatabase()
+	st, err := state.NewStateDBManage(nil, db, state.NewDatabase(db))
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	if dpb == nil {
+		return st
+	}
+	st.SetState(params.MAN_COIN, common.Address{}, common.BytesToHash([]byte(params.DepositVersionKey_1)), common.BytesToHash([]byte(params.DepositVersion_1)))
+	contract := vm.NewContract(vm.AccountRef(common.HexToAddress("1337")), vm.AccountRef(common.BytesToAddress([]byte{10})), big.NewInt(0), 0, params.MAN_COIN)
+	if err := depositmanagerversoin2.MatrixDeposit.SetDepositBase(contract, st, dpb.AddressA0, dpb); err != nil {
+		t.Fatalf("failed to store deposit: %v", err)
+	}
+	st.SetStateByteArray(params.MAN_COIN, contract.Address(), common.BytesToHash(append(dpb.AddressA1[:], 'A', '1')), dpb.AddressA0[:])
+	return st
+}
+
+func testLifecycle(t *testing.T, dpb *common.DepositBase, number, time uint64) *DepositLifecycle {
+	if dpb == nil {
+		return nil
+	}
+	lifecycle, err := GetDepositLifecycle(newDepositState(t, dpb), testAccountA0, number, time, testBlockTime)
+	if err != nil {
+		t.Fatalf("failed to get lifecycle: %v", err)
+	}
+	return lifecycle
+}
+
+// Tests the statuses and timelines of the positions against the withdrawals
+// CalcDepositTime makes, and that the term end of a locked position is the
+// interest end a withdrawal at that time would get.
+func TestDepositLifecycle(t *testing.T) {
+	term := uint64(depositcfg.SecondsPerMonth * depositcfg.MONTH_3)
+	withdrawn := withdraw(t, withdraw(t, testDeposit(300), 0, 100, testBegin+10), 1, 0, testBegin+term+10)
+	emptied := withdraw(t, testDeposit(300), 0, 300, testBegin+10)
+
+	tests := []struct {
+		name     string
+		deposit  *common.DepositBase
+		time     uint64
+		status   []string
+		timeline [][]string
+	}{
+		{"fresh", testDeposit(300), testBegin + 10, []string{DepositStatusActive, DepositStatusLocked},
+			[][]string{{}, {DepositEventTermEnd, DepositEventEarliestRefund}}},
+		{"second term", testDeposit(300), testBegin + term + 10, []string{DepositStatusActive, DepositStatusLocked},
+			[][]string{{}, {DepositEventTermEnd, DepositEventEarliestRefund}}},
+		{"withdrawing", withdrawn, testBegin + 20, []string{DepositStatusActive, DepositStatusWithdrawing},
+			[][]string{{DepositEventRefundable}, {DepositEventInterestEnd, DepositEventRefundable}}},
+		{"current refundable", withdrawn, testBegin + depositcfg.Days7Seconds + 10, []string{DepositStatusActive, DepositStatusWithdrawing},
+			[][]string{{}, {DepositEventInterestEnd, DepositEventRefundable}}},
+		{"all refundable", withdrawn, testBegin + 2*term + depositcfg.Delay, []string{DepositStatusActive, DepositStatusRefundable},
+			[][]string{{}, {}}},
+		{"emptied", emptied, testBegin + 20, []string{DepositStatusWithdrawing, DepositStatusLocked},
+			[][]string{{DepositEventRefundable}, {DepositEventTermEnd, DepositEventEarliestRefund}}},
+		{"emptied refundable", emptied, testBegin + depositcfg.Days7Seconds + 10, []string{DepositStatusRefundable, DepositStatusLocked},
+			[][]string{{}, {DepositEventTermEnd, DepositEventEarliestRefund}}},
+		{"empty", refund(t, emptied, testBegin+depositcfg.Days7Seconds+10), testBegin + depositcfg.Days7Seconds + 10, []string{DepositStatusEmpty, DepositStatusLocked},
+			[][]string{{}, {DepositEventTermEnd, DepositEventEarliestRefund}}},
+	}
+	for _, test := range tests {
+		lifecycle := testLifecycle(t, test.deposit, testNumber, test.time)
+		if lifecycle.Account != base58.Base58EncodeToString(params.MAN_COIN, testAccountA0) || lifecycle.Role.ToInt().Int64() != common.RoleValidator {
+			t.Errorf("%s: deposit mismatch: have %s %v", test.name, lifecycle.Account, lifecycle.Role)
+		}
+		if len(lifecycle.Positions) != len(test.status) {
+			t.Fatalf("%s: position count mismatch: have %d, want %d", test.name, len(lifecycle.Positions), len(test.status))
+		}
+		for i, pos := range lifecycle.Positions {
+			msg := test.deposit.Dpstmsg[i]
+			if pos.Status != test.status[i] {
+				t.Errorf("%s: position %d status mismatch: have %s, want %s", test.name, i, pos.Status, test.status[i])
+			}
+			if pos.Amount.ToInt().Cmp(msg.DepositAmount) != 0 || pos.EndTime != msg.EndTime {
+				t.Errorf("%s: position %d mismatch: have %v %d, want %v %d", test.name, i, pos.Amount, pos.EndTime, msg.DepositAmount, msg.EndTime)
+			}
+			if len(pos.Withdrawals) != len(msg.WithDrawInfolist) {
+				t.Fatalf("%s: position %d withdrawal count mismatch: have %d, want %d", test.name, i, len(pos.Withdrawals), len(msg.WithDrawInfolist))
+			}
+			for j, w := range pos.Withdrawals {
+				info := msg.WithDrawInfolist[j]
+				if w.RefundTime != info.WithDrawTime || w.Amount.ToInt().Cmp(info.WithDrawAmount) != 0 || (w.Status == DepositStatusRefundable) != (info.WithDrawTime <= test.time) {
+					t.Errorf("%s: position %d withdrawal %d mismatch: have %d %v %s, want %d %v", test.name, i, j, w.RefundTime, w.Amount, w.Status, info.WithDrawTime, info.WithDrawAmount)
+				}
+			}
+			if pos.Status == DepositStatusLocked {
+				// A withdrawal now ends the interest at the term end and refunds after the delay
+				want := withdraw(t, test.deposit, uint64(i), 0, test.time).Dpstmsg[i]
+				if pos.TermEnd != want.EndTime || pos.Timeline[1].Time != want.WithDrawInfolist[0].WithDrawTime {
+					t.Errorf("%s: position %d term mismatch: have %d %d, want %d %d", test.name, i, pos.TermEnd, pos.Timeline[1].Time, want.EndTime, want.WithDrawInfolist[0].WithDrawTime)
+				}
+			}
+			if len(pos.Timeline) != len(test.timeline[i]) {
+				t.Fatalf("%s: position %d timeline mismatch: have %v, want %v", test.name, i, pos.Timeline, test.timeline[i])
+			}
+			for j, ev := range pos.Timeline {
+				if ev.Event != test.timeline[i][j] {
+					t.Errorf("%s: position %d event %d mismatch: have %s, want %s", test.name, i, j, ev.Event, test.timeline[i][j])
+				}
+				if height := testNumber + (ev.Time-test.time+testBlockTime-1)/testBlockTime; ev.Time <= test.time || ev.Height != height {
+					t.Errorf("%s: position %d event %d estimate mismatch: have %d at %d, want %d after %d", test.name, i, j, ev.Height, ev.Time, height, test.time)
+				}
+			}
+		}
+	}
+}
+
+func TestDepositLifecycleAccounts(t *testing.T) {
+	st := newDepositState(t, testDeposit(300))
+	lifecycle, err := GetDepositLifecycle(st, testAccountA1, testNumber, testBegin, 0)
+	if err != nil {
+		t.Fatalf("failed to get lifecycle by the authorized account: %v", err)
+	}
+	if lifecycle.Account != base58.Base58EncodeToString(params.MAN_COIN, testAccountA0) || len(lifecycle.Positions) != 2 {
+		t.Errorf("lifecycle mismatch: have %s with %d positions", lifecycle.Account, len(lifecycle.Positions))
+	}
+	for _, pos := range lifecycle.Positions {
+		for _, ev := range pos.Timeline {
+			if ev.Height != 0 {
+				t.Errorf("position %d: height estimated without block time: %v", pos.Position, ev)
+			}
+		}
+	}
+	if _, err := GetDepositLifecycle(st, common.HexToAddress("0x2a"), testNumber, testBegin, 0); err != ErrNoDeposit {
+		t.Errorf("account without deposit: have %v, want %v", err, ErrNoDeposit)
+	}
+}
+
+func TestDepositChanges(t *testing.T) {
+	term := uint64(depositcfg.SecondsPerMonth * depositcfg.MONTH_3)
+	base := testDeposit(300)
+	partial := withdraw(t, base, 0, 100, testBegin+10)
+	fixed := withdraw(t, base, 1, 0, testBegin+10)
+	refundAt := testBegin + term + depositcfg.Delay
+
+	type change struct {
+		position   uint64
+		withdrawal bool
+		amount     int64
+		old, new   string
+	}
+	tests := []struct {
+		name     string
+		pre, cur *common.DepositBase
+		preTime  uint64
+		curTime  uint64
+		want     []change
+	}{
+		{"unchanged", base, base, testBegin + 10, testBegin + 20, nil},
+		{"new deposit", nil, base, 0, testBegin, []change{
+			{0, false, 300, "", DepositStatusActive},
+			{1, false, 600, "", DepositStatusLocked},
+		}},
+		{"current withdrawal", base, partial, testBegin + 5, testBegin + 10, []change{
+			{0, true, 100, "", DepositStatusWithdrawing},
+		}},
+		{"withdrawal due", partial, partial, testBegin + depositcfg.Days7Seconds, testBegin + depositcfg.Days7Seconds + 10, []change{
+			{0, true, 100, DepositStatusWithdrawing, DepositStatusRefundable},
+		}},
+		{"withdrawal refunded", partial, refund(t, partial, testBegin+depositcfg.Days7Seconds+10), testBegin + depositcfg.Days7Seconds + 10, testBegin + depositcfg.Days7Seconds + 10, []change{
+			{0, true, 100, DepositStatusRefundable, DepositStatusRefunded},
+		}},
+		{"fixed withdrawal", base, fixed, testBegin + 5, testBegin + 10, []change{
+			{1, false, 600, DepositStatusLocked, DepositStatusWithdrawing},
+		}},
+		{"fixed refunded", fixed, refund(t, fixed, refundAt), refundAt, refundAt, []change{
+			{1, false, 600, DepositStatusRefundable, DepositStatusRefunded},
+		}},
+		{"deposit gone", base, nil, testBegin + 10, 0, []change{
+			{0, false, 300, DepositStatusActive, DepositStatusRefunded},
+			{1, false, 600, DepositStatusLocked, DepositStatusRefunded},
+		}},
+	}
+	for _, test := range tests {
+		pre := testLifecycle(t, test.pre, testNumber-1, test.preTime)
+		cur := testLifecycle(t, test.cur, testNumber, test.curTime)
+		changes := DepositChanges(pre, cur)
+		if len(changes) != len(test.want) {
+			t.Fatalf("%s: change count mismatch: have %v, want %v", test.name, changes, test.want)
+		}
+		for i, have := range changes {
+			want := test.want[i]
+			if have.Account != base58.Base58EncodeToString(params.MAN_COIN, testAccountA0) || have.Number != testNumber {
+				t.Errorf("%s: change %d block mismatch: have %s %d", test.name, i, have.Account, have.Number)
+			}
+			if have.Position != want.position || have.Withdrawal != want.withdrawal || have.Amount.ToInt().Int64() != want.amount || have.Old != want.old || have.New != want.new {
+				t.Errorf("%s: change %d mismatch: have %d %v %v %q→%q, want %v", test.name, i, have.Position, have.Withdrawal, have.Amount, have.Old, have.New, want)
+			}
+		}
+	}
+	if changes := DepositChanges(nil, nil); len(changes) != 0 {
+		t.Errorf("changes without deposits: %v", changes)
+	}
+}
@@ -39,6 +39,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/core"
 	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
 	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
 	"github.com/MatrixAINetwork/go-matrix/core/vm"
 	"github.com/MatrixAINetwork/go-matrix/core/vm/validatorGroup"
@@ -155,8 +156,15 @@ func (s *PublicTxPoolAPI) Status() map[string]hexutil.Uint {
 	return map[string]hexutil.Uint{
 		"pending": hexutil.Uint(pending),
 		"queued":  hexutil.Uint(queue),
+		"dropped": hexutil.Uint(len(s.b.TxPoolDropped())),
 	}
 }
+// Dropped returns the transactions most recently rejected or evicted by the
+// pool limits, oldest first, along with the reason they were dropped.
+func (s *PublicTxPoolAPI) Dropped() []core.TxDrop {
+	return s.b.TxPoolDropped()
+}
+
 func (s *PublicTxPoolAPI) GetTxNmap() map[uint32]common.Hash {
 	nmap := s.b.GetTxNmap()
 	retval := make(map[uint32]common.Hash)
@@ -198,6 +206,16 @@ func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
 		}
 		content["queued"][account.Hex()] = dump
 	}
+	// Flatten the dropped transactions along with the reason they were dropped
+	content["dropped"] = make(map[string]map[string]string)
+	for _, drop := range s.b.TxPoolDropped() {
+		dump, ok := content["dropped"][drop.From.Hex()]
+		if !ok {
+			dump = make(map[string]string)
+			content["dropped"][drop.From.Hex()] = dump
+		}
+		dump[drop.Hash.Hex()] = fmt.Sprintf("nonce %d: %s", drop.Nonce, drop.Reason)
+	}
 	return content
 }
 
@@ -1129,6 +1147,26 @@ func (s *PublicBlockChainAPI) GetAuthGasAddress(ctx context.Context, strAddress
 	return "", errors.New("without entrust gas")
 }
 
+// GetScheduledTxs returns the revocable and timed transfers of the currency of
+// the address which it sends or receives and which are not executed yet at the
+// block, sorted by execution time. The hash of a revocable one is the one to
+// revert it with.
+func (s *PublicBlockChainAPI) GetScheduledTxs(ctx context.Context, strAddress string, blockNr rpc.BlockNumber) ([]*state.ScheduledTx, error) {
+	st, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if st == nil || err != nil {
+		return nil, err
+	}
+	coin, err := getCoinFromManAddress(strAddress)
+	if err != nil {
+		return nil, err
+	}
+	addr, err := base58.Base58DecodeToAddress(strAddress)
+	if err != nil {
+		return nil, err
+	}
+	return st.GetScheduledTxs(coin, addr)
+}
+
 func (s *PublicBlockChainAPI) GetMatrixStateByNum(ctx context.Context, key string, blockNr rpc.BlockNumber) (interface{}, error) {
 	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
 	if state == nil || err != nil {
@@ -57,6 +57,7 @@ type Backend interface {
 	Stats() (pending int, queued int)
 	GetTxNmap() map[uint32]*types.Transaction
 	TxPoolContent() (map[common.Address]types.SelfTransactions, map[common.Address]types.SelfTransactions)
+	TxPoolDropped() []core.TxDrop
 	SubscribeNewTxsEvent(chan core.NewTxsEvent) event.Subscription //Y
 
 	SignTx(signedTx types.SelfTransaction, chainID *big.Int, blkHash common.Hash, signHeight uint64, usingEntrust bool) (types.SelfTransaction, error) //
@@ -113,6 +114,26 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 			Version:   "1.0",
 			Service:   NewPublicTxPoolAPI(apiBackend),
 			Public:    true,
+		}, {
+			Namespace: "matrixstate",
+			Version:   "1.0",
+			Service:   NewPublicMatrixStateAPI(apiBackend),
+			Public:    true,
+		}, {
+			Namespace: "election",
+			Version:   "1.0",
+			Service:   NewPublicElectionAPI(apiBackend),
+			Public:    true,
+		}, {
+			Namespace: "validatorgroup",
+			Version:   "1.0",
+			Service:   NewPublicValidatorGroupAPI(apiBackend),
+			Public:    true,
+		}, {
+			Namespace: "deposit",
+			Version:   "1.0",
+			Service:   NewPublicDepositAPI(apiBackend),
+			Public:    true,
 		}, {
 			Namespace: "debug",
 			Version:   "1.0",
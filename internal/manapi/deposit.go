@@ -0,0 +1,85 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package manapi
+
+import (
+	"context"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/depoistInfo"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+)
+
+// depositBlockTimeSpan is the number of blocks the block time of the height
+// estimates is averaged over.
+const depositBlockTimeSpan = 100
+
+// PublicDepositAPI tracks the lifecycle of the deposit positions of accounts.
+type PublicDepositAPI struct {
+	b Backend
+}
+
+// NewPublicDepositAPI creates a new deposit lifecycle API.
+func NewPublicDepositAPI(b Backend) *PublicDepositAPI {
+	return &PublicDepositAPI{b}
+}
+
+// DepositPositions are the deposit positions of an account at a block. The
+// heights of the timelines and the interest payment are estimates.
+type DepositPositions struct {
+	*depoistInfo.DepositLifecycle
+	BlockTime           uint64 `json:"blockTime"`           // Average seconds per block of the estimates
+	NextInterestPayment uint64 `json:"nextInterestPayment"` // Height the accrued interest is paid at, 0 if unknown
+}
+
+// Positions returns every deposit position of the account at the block with
+// its status, amounts, accrued interest and slash and projected timeline. The
+// account may be the deposit or the authorized one.
+func (s *PublicDepositAPI) Positions(ctx context.Context, account string, blockNr rpc.BlockNumber) (*DepositPositions, error) {
+	addr, err := base58.Base58DecodeToAddress(account)
+	if err != nil {
+		return nil, err
+	}
+	st, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	if st == nil || header == nil {
+		return nil, errStateNotFound
+	}
+	number := header.Number.Uint64()
+	blockTime := s.blockTime(ctx, number, header.Time.Uint64())
+
+	lifecycle, err := depoistInfo.GetDepositLifecycle(st, addr, number, header.Time.Uint64(), blockTime)
+	if err != nil {
+		return nil, err
+	}
+	result := &DepositPositions{DepositLifecycle: lifecycle, BlockTime: blockTime}
+	if cfg, err := matrixstate.GetInterestCfg(st); err == nil && cfg.PayInterval > 0 && number > 0 {
+		// Interest is paid by the first block after every multiple of the interval.
+		result.NextInterestPayment = ((number-1)/cfg.PayInterval+1)*cfg.PayInterval + 1
+	}
+	return result, nil
+}
+
+// blockTime returns the average seconds per block of the blocks before the
+// number, at least one.
+func (s *PublicDepositAPI) blockTime(ctx context.Context, number, time uint64) uint64 {
+	span := uint64(depositBlockTimeSpan)
+	if number < span {
+		span = number
+	}
+	if span == 0 {
+		return 1
+	}
+	header, err := s.b.HeaderByNumber(ctx, rpc.BlockNumber(number-span))
+	if err != nil || header == nil || header.Time.Uint64() >= time {
+		return 1
+	}
+	if blockTime := (time - header.Time.Uint64()) / span; blockTime > 0 {
+		return blockTime
+	}
+	return 1
+}
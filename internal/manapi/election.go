@@ -0,0 +1,288 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package manapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/core/vm"
+	"github.com/MatrixAINetwork/go-matrix/depoistInfo"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+)
+
+// maxElectionHistoryRange limits the blocks scanned by one history request,
+// every block of the range is read from its state. Longer ranges are queried
+// in several requests.
+const maxElectionHistoryRange = 1000
+
+// Reasons of an election change point.
+const (
+	ElectionReasonTopology = "topology" // The topology graph changed
+	ElectionReasonElect    = "elect"    // The elect graph changed
+	ElectionReasonOnline   = "online"   // The elect online state changed
+)
+
+// Actions of a topology change applied by a block header.
+const (
+	TopologyActionAll     = "all"     // Full topology, replaces the graph
+	TopologyActionOnline  = "online"  // Elected node came back online
+	TopologyActionOffline = "offline" // Node went offline, removed from the graph
+	TopologyActionReplace = "replace" // Node took the position
+)
+
+// PublicElectionAPI explores the historical election results and topology.
+type PublicElectionAPI struct {
+	b Backend
+}
+
+// NewPublicElectionAPI creates a new election explorer API.
+func NewPublicElectionAPI(b Backend) *PublicElectionAPI {
+	return &PublicElectionAPI{b}
+}
+
+// ElectionNode is a node of the topology, elect or online lists, with the
+// deposit of the account at the block.
+type ElectionNode struct {
+	Account     string       `json:"account"`
+	SignAccount string       `json:"sign_account,omitempty"`
+	Position    uint16       `json:"position"`
+	Role        string       `json:"role"`
+	Online      bool         `json:"online"`
+	Stock       uint16       `json:"stock,omitempty"`
+	VIPLevel    int          `json:"vip_level,omitempty"`
+	Deposit     *hexutil.Big `json:"deposit,omitempty"`
+}
+
+// TopologyChange is an entry of the NetTopology of a block header.
+type TopologyChange struct {
+	Action   string `json:"action"`
+	Account  string `json:"account"`
+	Position uint16 `json:"position"`
+	Replaced string `json:"replaced,omitempty"` // Account previously at the position
+}
+
+// ElectionChangePoint is the election state of a block changing it.
+type ElectionChangePoint struct {
+	Number             uint64           `json:"number"`
+	Hash               common.Hash      `json:"hash"`
+	Reasons            []string         `json:"reasons"`
+	TopologyChanges    []TopologyChange `json:"topology_changes"`
+	Topology           []ElectionNode   `json:"topology"`
+	Elect              []ElectionNode   `json:"elect"`
+	NextMinerElect     []ElectionNode   `json:"next_miner_elect"`
+	NextValidatorElect []ElectionNode   `json:"next_validator_elect"`
+	ElectOnline        []ElectionNode   `json:"elect_online"`
+}
+
+// electionState is the election data read from the state of a block.
+type electionState struct {
+	header   *types.Header
+	topology *mc.TopologyGraph
+	elect    *mc.ElectGraph
+	online   *mc.ElectOnlineStatus
+}
+
+func (s *PublicElectionAPI) electionStateAt(ctx context.Context, blockNr rpc.BlockNumber) (*electionState, error) {
+	st, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	if st == nil || header == nil {
+		return nil, errStateNotFound
+	}
+	return readElectionState(st, header)
+}
+
+func readElectionState(st *state.StateDBManage, header *types.Header) (*electionState, error) {
+	topology, err := matrixstate.GetTopologyGraph(st)
+	if err != nil {
+		return nil, err
+	}
+	elect, err := matrixstate.GetElectGraph(st)
+	if err != nil {
+		return nil, err
+	}
+	online, err := matrixstate.GetElectOnlineState(st)
+	if err != nil {
+		return nil, err
+	}
+	return &electionState{header: header, topology: topology, elect: elect, online: online}, nil
+}
+
+// At returns the election state of the block, whether or not it changed there.
+func (s *PublicElectionAPI) At(ctx context.Context, blockNr rpc.BlockNumber) (*ElectionChangePoint, error) {
+	cur, err := s.electionStateAt(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	var pre *electionState
+	if number := cur.header.Number.Uint64(); number > 0 {
+		if pre, err = s.electionStateAt(ctx, rpc.BlockNumber(number-1)); err != nil {
+			return nil, err
+		}
+	}
+	return makeChangePoint(pre, cur, electionReasons(pre, cur))
+}
+
+// History returns the election state at every block of the range changing the
+// topology graph, the elect graph or the elect online state.
+func (s *PublicElectionAPI) History(ctx context.Context, from rpc.BlockNumber, to rpc.BlockNumber) ([]*ElectionChangePoint, error) {
+	last, err := s.b.HeaderByNumber(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+	if last == nil {
+		return nil, errStateNotFound
+	}
+	first, err := s.b.HeaderByNumber(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	if first == nil {
+		return nil, errStateNotFound
+	}
+	start, end := first.Number.Uint64(), last.Number.Uint64()
+	if start > end {
+		return nil, fmt.Errorf("invalid range: from %d > to %d", start, end)
+	}
+	if end-start >= maxElectionHistoryRange {
+		return nil, fmt.Errorf("range of %d blocks exceeds limit %d", end-start+1, maxElectionHistoryRange)
+	}
+
+	var pre *electionState
+	if start > 0 {
+		if pre, err = s.electionStateAt(ctx, rpc.BlockNumber(start-1)); err != nil {
+			return nil, err
+		}
+	}
+	points := make([]*ElectionChangePoint, 0)
+	for number := start; number <= end; number++ {
+		cur, err := s.electionStateAt(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return nil, err
+		}
+		if reasons := electionReasons(pre, cur); len(reasons) > 0 {
+			point, err := makeChangePoint(pre, cur, reasons)
+			if err != nil {
+				return nil, err
+			}
+			points = append(points, point)
+		}
+		pre = cur
+	}
+	return points, nil
+}
+
+// electionReasons compares the election state of a block with its parent, the
+// genesis block has no parent and changes everything.
+func electionReasons(pre, cur *electionState) []string {
+	reasons := make([]string, 0)
+	if pre == nil || types.RlpHash(pre.topology) != types.RlpHash(cur.topology) {
+		reasons = append(reasons, ElectionReasonTopology)
+	}
+	if pre == nil || types.RlpHash(pre.elect) != types.RlpHash(cur.elect) {
+		reasons = append(reasons, ElectionReasonElect)
+	}
+	if pre == nil || types.RlpHash(pre.online) != types.RlpHash(cur.online) {
+		reasons = append(reasons, ElectionReasonOnline)
+	}
+	return reasons
+}
+
+func makeChangePoint(pre, cur *electionState, reasons []string) (*ElectionChangePoint, error) {
+	hash := cur.header.Hash()
+	deposits, err := depoistInfo.GetAllDepositByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	depositMap := make(map[common.Address]*vm.DepositDetail, len(deposits))
+	for i := range deposits {
+		depositMap[deposits[i].Address] = &deposits[i]
+	}
+
+	point := &ElectionChangePoint{
+		Number:             cur.header.Number.Uint64(),
+		Hash:               hash,
+		Reasons:            reasons,
+		TopologyChanges:    topologyChanges(pre, cur.header),
+		Topology:           make([]ElectionNode, 0, len(cur.topology.NodeList)),
+		Elect:              electionNodes(cur.elect.ElectList, depositMap),
+		NextMinerElect:     electionNodes(cur.elect.NextMinerElect, depositMap),
+		NextValidatorElect: electionNodes(cur.elect.NextValidatorElect, depositMap),
+		ElectOnline:        electionNodes(cur.online.ElectOnline, depositMap),
+	}
+	for _, node := range cur.topology.NodeList {
+		point.Topology = append(point.Topology, withDeposit(ElectionNode{
+			Account:  base58.Base58EncodeToString(params.MAN_COIN, node.Account),
+			Position: node.Position,
+			Role:     node.Type.String(),
+			Online:   true,
+		}, node.Account, depositMap))
+	}
+	return point, nil
+}
+
+func electionNodes(nodes []mc.ElectNodeInfo, deposits map[common.Address]*vm.DepositDetail) []ElectionNode {
+	result := make([]ElectionNode, 0, len(nodes))
+	for _, node := range nodes {
+		result = append(result, withDeposit(ElectionNode{
+			Account:  base58.Base58EncodeToString(params.MAN_COIN, node.Account),
+			Position: node.Position,
+			Role:     node.Type.String(),
+			Online:   node.Position != common.PosOffline,
+			Stock:    node.Stock,
+			VIPLevel: int(node.VIPLevel),
+		}, node.Account, deposits))
+	}
+	return result
+}
+
+func withDeposit(node ElectionNode, account common.Address, deposits map[common.Address]*vm.DepositDetail) ElectionNode {
+	if deposit, ok := deposits[account]; ok {
+		node.SignAccount = base58.Base58EncodeToString(params.MAN_COIN, deposit.SignAddress)
+		node.Deposit = (*hexutil.Big)(deposit.Deposit)
+	}
+	return node
+}
+
+// topologyChanges decodes the NetTopology of the header, replaced accounts are
+// looked up in the topology graph of the parent.
+func topologyChanges(pre *electionState, header *types.Header) []TopologyChange {
+	changes := make([]TopologyChange, 0, len(header.NetTopology.NetTopologyData))
+	for _, data := range header.NetTopology.NetTopologyData {
+		change := TopologyChange{
+			Account:  base58.Base58EncodeToString(params.MAN_COIN, data.Account),
+			Position: data.Position,
+		}
+		switch {
+		case header.NetTopology.Type == common.NetTopoTypeAll:
+			change.Action = TopologyActionAll
+		case data.Position == common.PosOnline:
+			change.Action = TopologyActionOnline
+		case data.Position == common.PosOffline:
+			change.Action = TopologyActionOffline
+		default:
+			change.Action = TopologyActionReplace
+			if pre != nil {
+				for _, node := range pre.topology.NodeList {
+					if node.Position == data.Position {
+						change.Replaced = base58.Base58EncodeToString(params.MAN_COIN, node.Account)
+						break
+					}
+				}
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
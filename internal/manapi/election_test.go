@@ -0,0 +1,134 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package manapi
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
+
+var (
+	electionAccountA = common.HexToAddress("0x0a")
+	electionAccountB = common.HexToAddress("0x0b")
+	electionAccountC = common.HexToAddress("0x0c")
+)
+
+func testElectionState(topology []common.Address, elect []common.Address, offline []common.Address) *electionState {
+	st := &electionState{
+		header:   &types.Header{Number: big.NewInt(1)},
+		topology: &mc.TopologyGraph{},
+		elect:    &mc.ElectGraph{},
+		online:   &mc.ElectOnlineStatus{},
+	}
+	for i, account := range topology {
+		st.topology.NodeList = append(st.topology.NodeList, mc.TopologyNodeInfo{Account: account, Position: uint16(i), Type: common.RoleValidator})
+	}
+	for i, account := range elect {
+		st.elect.ElectList = append(st.elect.ElectList, mc.ElectNodeInfo{Account: account, Position: uint16(i), Type: common.RoleValidator})
+		position := common.PosOnline
+		for _, off := range offline {
+			if off == account {
+				position = common.PosOffline
+			}
+		}
+		st.online.ElectOnline = append(st.online.ElectOnline, mc.ElectNodeInfo{Account: account, Position: position, Type: common.RoleValidator})
+	}
+	return st
+}
+
+func TestElectionReasons(t *testing.T) {
+	base := testElectionState([]common.Address{electionAccountA, electionAccountB}, []common.Address{electionAccountA, electionAccountB, electionAccountC}, nil)
+	tests := []struct {
+		name string
+		pre  *electionState
+		cur  *electionState
+		want []string
+	}{
+		{"genesis", nil, base, []string{ElectionReasonTopology, ElectionReasonElect, ElectionReasonOnline}},
+		{"unchanged", base, testElectionState([]common.Address{electionAccountA, electionAccountB}, []common.Address{electionAccountA, electionAccountB, electionAccountC}, nil), []string{}},
+		{"topology", base, testElectionState([]common.Address{electionAccountA, electionAccountC}, []common.Address{electionAccountA, electionAccountB, electionAccountC}, nil), []string{ElectionReasonTopology}},
+		{"online", base, testElectionState([]common.Address{electionAccountA, electionAccountB}, []common.Address{electionAccountA, electionAccountB, electionAccountC}, []common.Address{electionAccountC}), []string{ElectionReasonOnline}},
+		{"reelection", base, testElectionState([]common.Address{electionAccountB, electionAccountC}, []common.Address{electionAccountB, electionAccountC}, nil), []string{ElectionReasonTopology, ElectionReasonElect, ElectionReasonOnline}},
+	}
+	for _, tt := range tests {
+		if have := electionReasons(tt.pre, tt.cur); !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("%s: reasons mismatch: have %v, want %v", tt.name, have, tt.want)
+		}
+	}
+}
+
+func TestTopologyChanges(t *testing.T) {
+	encode := func(account common.Address) string { return base58.Base58EncodeToString(params.MAN_COIN, account) }
+	pre := testElectionState([]common.Address{electionAccountA, electionAccountB}, nil, nil)
+	tests := []struct {
+		name     string
+		pre      *electionState
+		topology common.NetTopology
+		want     []TopologyChange
+	}{
+		{
+			name:     "empty",
+			pre:      pre,
+			topology: common.NetTopology{Type: common.NetTopoTypeChange},
+			want:     []TopologyChange{},
+		},
+		{
+			name: "all",
+			pre:  nil,
+			topology: common.NetTopology{Type: common.NetTopoTypeAll, NetTopologyData: []common.NetTopologyData{
+				{Account: electionAccountA, Position: 0},
+				{Account: electionAccountB, Position: 1},
+			}},
+			want: []TopologyChange{
+				{Action: TopologyActionAll, Account: encode(electionAccountA), Position: 0},
+				{Action: TopologyActionAll, Account: encode(electionAccountB), Position: 1},
+			},
+		},
+		{
+			name: "online and offline",
+			pre:  pre,
+			topology: common.NetTopology{Type: common.NetTopoTypeChange, NetTopologyData: []common.NetTopologyData{
+				{Account: electionAccountC, Position: common.PosOnline},
+				{Account: electionAccountB, Position: common.PosOffline},
+			}},
+			want: []TopologyChange{
+				{Action: TopologyActionOnline, Account: encode(electionAccountC), Position: common.PosOnline},
+				{Action: TopologyActionOffline, Account: encode(electionAccountB), Position: common.PosOffline},
+			},
+		},
+		{
+			name: "replace",
+			pre:  pre,
+			topology: common.NetTopology{Type: common.NetTopoTypeChange, NetTopologyData: []common.NetTopologyData{
+				{Account: electionAccountC, Position: 1},
+			}},
+			want: []TopologyChange{
+				{Action: TopologyActionReplace, Account: encode(electionAccountC), Position: 1, Replaced: encode(electionAccountB)},
+			},
+		},
+		{
+			name: "replace without parent",
+			pre:  nil,
+			topology: common.NetTopology{Type: common.NetTopoTypeChange, NetTopologyData: []common.NetTopologyData{
+				{Account: electionAccountC, Position: 1},
+			}},
+			want: []TopologyChange{
+				{Action: TopologyActionReplace, Account: encode(electionAccountC), Position: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		header := &types.Header{Number: big.NewInt(2), NetTopology: tt.topology}
+		if have := topologyChanges(tt.pre, header); !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("%s: changes mismatch: have %+v, want %+v", tt.name, have, tt.want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package manapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+)
+
+var errStateNotFound = errors.New("state not found")
+
+// PublicMatrixStateAPI provides the decoded matrix state, the configuration
+// and statistics the chain keeps in the state tree.
+type PublicMatrixStateAPI struct {
+	b Backend
+}
+
+// NewPublicMatrixStateAPI creates a new matrix state API.
+func NewPublicMatrixStateAPI(b Backend) *PublicMatrixStateAPI {
+	return &PublicMatrixStateAPI{b}
+}
+
+// MatrixStateValue is a decoded matrix state value at a block.
+type MatrixStateValue struct {
+	Key     string      `json:"key"`
+	Number  uint64      `json:"number"`
+	Version string      `json:"version"`
+	Value   interface{} `json:"value"`
+}
+
+// MatrixStateDiff is the change of matrix state values between two blocks.
+type MatrixStateDiff struct {
+	From    uint64                 `json:"from"`
+	To      uint64                 `json:"to"`
+	Changes []*matrixstate.KeyDiff `json:"changes"`
+}
+
+func (s *PublicMatrixStateAPI) stateAt(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDBManage, *types.Header, error) {
+	st, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if st == nil || header == nil {
+		return nil, nil, errStateNotFound
+	}
+	return st, header, nil
+}
+
+// Keys lists the matrix state keys readable at the block.
+func (s *PublicMatrixStateAPI) Keys(ctx context.Context, blockNr rpc.BlockNumber) ([]matrixstate.KeyInfo, error) {
+	st, _, err := s.stateAt(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	return matrixstate.GetKeys(st)
+}
+
+// Get returns the value of the key at the block.
+func (s *PublicMatrixStateAPI) Get(ctx context.Context, key string, blockNr rpc.BlockNumber) (*MatrixStateValue, error) {
+	st, header, err := s.stateAt(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	value, err := matrixstate.GetValueByKey(st, key)
+	if err != nil {
+		return nil, err
+	}
+	return &MatrixStateValue{
+		Key:     key,
+		Number:  header.Number.Uint64(),
+		Version: matrixstate.GetVersionInfo(st),
+		Value:   value,
+	}, nil
+}
+
+// GetAll returns all values stored at the block.
+func (s *PublicMatrixStateAPI) GetAll(ctx context.Context, blockNr rpc.BlockNumber) (map[string]interface{}, error) {
+	st, _, err := s.stateAt(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	infos, err := matrixstate.GetKeys(st)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{})
+	for _, info := range infos {
+		if info.Empty {
+			continue
+		}
+		if values[info.Key], err = matrixstate.GetValueByKey(st, info.Key); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// Diff compares the key between two blocks. An empty key compares all keys
+// and returns only the changed ones.
+func (s *PublicMatrixStateAPI) Diff(ctx context.Context, key string, from rpc.BlockNumber, to rpc.BlockNumber) (*MatrixStateDiff, error) {
+	fromSt, fromHeader, err := s.stateAt(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	toSt, toHeader, err := s.stateAt(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+	result := &MatrixStateDiff{From: fromHeader.Number.Uint64(), To: toHeader.Number.Uint64()}
+	if key == "" {
+		result.Changes, err = matrixstate.DiffAll(fromSt, toSt)
+		return result, err
+	}
+	diff, err := matrixstate.DiffKey(fromSt, toSt, key)
+	if err != nil {
+		return nil, err
+	}
+	result.Changes = []*matrixstate.KeyDiff{diff}
+	return result, nil
+}
@@ -0,0 +1,311 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package manapi
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/vm"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+)
+
+// Kinds of amounts a validator group simulation distributes.
+const (
+	ValidatorGroupReward   = "reward"   // Block reward paid to the group, shared by all deposits
+	ValidatorGroupInterest = "interest" // Interest of the current deposit, shared by the current deposits
+)
+
+// PublicValidatorGroupAPI queries the validator group contracts and simulates
+// their reward distribution.
+type PublicValidatorGroupAPI struct {
+	b Backend
+}
+
+// NewPublicValidatorGroupAPI creates a new validator group API.
+func NewPublicValidatorGroupAPI(b Backend) *PublicValidatorGroupAPI {
+	return &PublicValidatorGroupAPI{b}
+}
+
+// ValidatorGroupRate is the rate Rate / Decimal.
+type ValidatorGroupRate struct {
+	Rate    *hexutil.Big `json:"rate"`
+	Decimal *hexutil.Big `json:"decimal"`
+}
+
+// ValidatorGroupLevel is the weight rate of the member deposits reaching the
+// threshold.
+type ValidatorGroupLevel struct {
+	Threshold *hexutil.Big       `json:"threshold"`
+	Rate      ValidatorGroupRate `json:"rate"`
+}
+
+// ValidatorGroupPosition is a fixed deposit of a member.
+type ValidatorGroupPosition struct {
+	Position uint64       `json:"position"`
+	DType    uint64       `json:"dtype"` // Months of the deposit
+	Amount   *hexutil.Big `json:"amount"`
+	EndTime  uint64       `json:"endTime"` // Time the withdrawal ends, 0 if not withdrawn
+}
+
+// ValidatorGroupWithdraw is a pending withdrawal of the current deposit.
+type ValidatorGroupWithdraw struct {
+	Amount *hexutil.Big `json:"amount"`
+	Time   uint64       `json:"time"`
+}
+
+// ValidatorGroupMember is a member of a group with its deposits and the
+// rewards and interest it has not claimed yet.
+type ValidatorGroupMember struct {
+	Account        string                   `json:"account"`
+	Owner          bool                     `json:"owner"`
+	Level          int                      `json:"level"`          // Deposit level reached, -1 for none or the owner
+	Deposit        *hexutil.Big             `json:"deposit"`        // All deposits weighting the rewards
+	CurrentDeposit *hexutil.Big             `json:"currentDeposit"` // Current deposit weighting the interest
+	Weight         *hexutil.Big             `json:"weight"`         // Reward weight of the deposit
+	Reward         *hexutil.Big             `json:"reward"`
+	Interest       *hexutil.Big             `json:"interest"`
+	Positions      []ValidatorGroupPosition `json:"positions"`
+	Withdrawals    []ValidatorGroupWithdraw `json:"withdrawals"`
+}
+
+// ValidatorGroup is a validator group contract.
+type ValidatorGroup struct {
+	Address         string                 `json:"address"`
+	Owner           string                 `json:"owner"`
+	SignAccount     string                 `json:"signAccount"`
+	WithdrawAllTime uint64                 `json:"withdrawAllTime"`
+	Balance         *hexutil.Big           `json:"balance"`
+	OwnerRate       ValidatorGroupRate     `json:"ownerRate"`
+	NodeRate        ValidatorGroupRate     `json:"nodeRate"`
+	LevelRates      []ValidatorGroupLevel  `json:"levelRates"`
+	TotalDeposit    *hexutil.Big           `json:"totalDeposit"`
+	TotalReward     *hexutil.Big           `json:"totalReward"`
+	TotalInterest   *hexutil.Big           `json:"totalInterest"`
+	Members         []ValidatorGroupMember `json:"members"`
+}
+
+// ValidatorGroupShare is what a member receives of a simulated distribution.
+type ValidatorGroupShare struct {
+	Account string       `json:"account"`
+	Weight  *hexutil.Big `json:"weight"`
+	Before  *hexutil.Big `json:"before"`
+	After   *hexutil.Big `json:"after"`
+	Share   *hexutil.Big `json:"share"`
+}
+
+// ValidatorGroupSimulation is the distribution of an amount paid to a group.
+type ValidatorGroupSimulation struct {
+	Address    string                `json:"address"`
+	Kind       string                `json:"kind"`
+	Amount     *hexutil.Big          `json:"amount"`
+	Time       uint64                `json:"time"`
+	NodeAmount *hexutil.Big          `json:"nodeAmount"` // Share of the node rate, credited to the owner
+	Shares     []ValidatorGroupShare `json:"shares"`
+}
+
+func (s *PublicValidatorGroupAPI) stateAt(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDBManage, uint64, error) {
+	st, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if err != nil {
+		return nil, 0, err
+	}
+	if st == nil || header == nil {
+		return nil, 0, errStateNotFound
+	}
+	return st, header.Time.Uint64(), nil
+}
+
+// List returns all validator group contracts at the block, ordered by address.
+func (s *PublicValidatorGroupAPI) List(ctx context.Context, blockNr rpc.BlockNumber) ([]*ValidatorGroup, error) {
+	st, time, err := s.stateAt(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := (&vm.ValidatorContractState{}).GetValidatorGroupInfo(time, st)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]common.Address, 0, len(groups))
+	for addr := range groups {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i].Hex() < addrs[j].Hex() })
+
+	result := make([]*ValidatorGroup, 0, len(addrs))
+	for _, addr := range addrs {
+		result = append(result, newValidatorGroup(addr, groups[addr], st))
+	}
+	return result, nil
+}
+
+// Get returns the validator group contract at the block.
+func (s *PublicValidatorGroupAPI) Get(ctx context.Context, group string, blockNr rpc.BlockNumber) (*ValidatorGroup, error) {
+	st, time, err := s.stateAt(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	addr, groupState, err := loadValidatorGroup(group, time, st)
+	if err != nil {
+		return nil, err
+	}
+	return newValidatorGroup(addr, groupState, st), nil
+}
+
+// Simulate distributes the amount of the kind to the group at the block as
+// the reward or interest payment would, without changing the chain. The
+// deposits are weighted at the time, the time of the block if zero.
+func (s *PublicValidatorGroupAPI) Simulate(ctx context.Context, group string, kind string, amount hexutil.Big, time hexutil.Uint64, blockNr rpc.BlockNumber) (*ValidatorGroupSimulation, error) {
+	st, blockTime, err := s.stateAt(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	if time == 0 {
+		time = hexutil.Uint64(blockTime)
+	}
+	if amount.ToInt().Sign() < 0 {
+		return nil, fmt.Errorf("negative amount %v", amount.ToInt())
+	}
+	addr, groupState, err := loadValidatorGroup(group, uint64(time), st)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		deposit    func(i int) *big.Int
+		credited   func(i int) *big.Int
+		distribute func(*big.Int) error
+	)
+	switch kind {
+	case ValidatorGroupReward:
+		deposit = func(i int) *big.Int { return groupState.ValidatorMap[i].AllAmount }
+		credited = func(i int) *big.Int { return groupState.ValidatorMap[i].Reward }
+		distribute = groupState.DistributeRewards
+	case ValidatorGroupInterest:
+		deposit = func(i int) *big.Int { return groupState.ValidatorMap[i].Current.Amount }
+		credited = func(i int) *big.Int { return groupState.ValidatorMap[i].Current.Interest }
+		distribute = groupState.DistributeCurrentInterests
+	default:
+		return nil, fmt.Errorf("unknown distribution kind %q, want %q or %q", kind, ValidatorGroupReward, ValidatorGroupInterest)
+	}
+
+	result := &ValidatorGroupSimulation{
+		Address:    base58.Base58EncodeToString(params.MAN_COIN, addr),
+		Kind:       kind,
+		Amount:     (*hexutil.Big)(new(big.Int).Set(amount.ToInt())),
+		Time:       uint64(time),
+		NodeAmount: (*hexutil.Big)(groupState.Reward.NodeRate.Mul(amount.ToInt())),
+		Shares:     make([]ValidatorGroupShare, 0, len(groupState.ValidatorMap)),
+	}
+	before := make([]*big.Int, len(groupState.ValidatorMap))
+	for i := range groupState.ValidatorMap {
+		before[i] = new(big.Int).Set(credited(i))
+	}
+	// The distribution only changes the loaded group, which is not written back.
+	if err := distribute(new(big.Int).Set(amount.ToInt())); err != nil {
+		return nil, err
+	}
+	for i, member := range groupState.ValidatorMap {
+		after := new(big.Int).Set(credited(i))
+		result.Shares = append(result.Shares, ValidatorGroupShare{
+			Account: base58.Base58EncodeToString(params.MAN_COIN, member.Address),
+			Weight:  (*hexutil.Big)(groupState.CalDepositWeight(member.Address, deposit(i))),
+			Before:  (*hexutil.Big)(before[i]),
+			After:   (*hexutil.Big)(after),
+			Share:   (*hexutil.Big)(new(big.Int).Sub(after, before[i])),
+		})
+	}
+	return result, nil
+}
+
+func loadValidatorGroup(group string, time uint64, st *state.StateDBManage) (common.Address, *vm.ValidatorGroupState, error) {
+	addr, err := base58.Base58DecodeToAddress(group)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	groups := &vm.ValidatorContractState{}
+	if err := groups.GetState(vm.ValidatorGroupContractAddress, st); err != nil {
+		return common.Address{}, nil, err
+	}
+	if !groups.Find(addr) {
+		return common.Address{}, nil, fmt.Errorf("%s is not a validator group", group)
+	}
+	groupState := vm.NewValidatorGroupState()
+	if err := groupState.GetState(addr, time, st); err != nil {
+		return common.Address{}, nil, err
+	}
+	return addr, groupState, nil
+}
+
+func newValidatorGroupRate(rate vm.RateOption) ValidatorGroupRate {
+	return ValidatorGroupRate{Rate: (*hexutil.Big)(rate.Rate), Decimal: (*hexutil.Big)(rate.Decimal)}
+}
+
+func newValidatorGroup(addr common.Address, groupState *vm.ValidatorGroupState, st *state.StateDBManage) *ValidatorGroup {
+	group := &ValidatorGroup{
+		Address:         base58.Base58EncodeToString(params.MAN_COIN, addr),
+		Owner:           base58.Base58EncodeToString(params.MAN_COIN, groupState.OwnerInfo.Owner),
+		SignAccount:     base58.Base58EncodeToString(params.MAN_COIN, groupState.OwnerInfo.SignAddress),
+		WithdrawAllTime: groupState.OwnerInfo.WithdrawAllTime,
+		Balance:         (*hexutil.Big)(new(big.Int)),
+		OwnerRate:       newValidatorGroupRate(groupState.Reward.OwnerRate),
+		NodeRate:        newValidatorGroupRate(groupState.Reward.NodeRate),
+		LevelRates:      make([]ValidatorGroupLevel, 0, len(groupState.Reward.LevelRate)),
+		TotalDeposit:    (*hexutil.Big)(new(big.Int)),
+		TotalReward:     (*hexutil.Big)(new(big.Int)),
+		TotalInterest:   (*hexutil.Big)(new(big.Int)),
+		Members:         make([]ValidatorGroupMember, 0, len(groupState.ValidatorMap)),
+	}
+	if balance := st.GetBalance(params.MAN_COIN, addr); len(balance) > common.MainAccount {
+		group.Balance = (*hexutil.Big)(balance[common.MainAccount].Balance)
+	}
+	for _, level := range groupState.Reward.LevelRate {
+		group.LevelRates = append(group.LevelRates, ValidatorGroupLevel{
+			Threshold: (*hexutil.Big)(level.Threshold),
+			Rate:      newValidatorGroupRate(level.Rate),
+		})
+	}
+	for _, info := range groupState.ValidatorMap {
+		member := ValidatorGroupMember{
+			Account:        base58.Base58EncodeToString(params.MAN_COIN, info.Address),
+			Owner:          info.Address == groupState.OwnerInfo.Owner,
+			Level:          -1,
+			Deposit:        (*hexutil.Big)(info.AllAmount),
+			CurrentDeposit: (*hexutil.Big)(info.Current.Amount),
+			Weight:         (*hexutil.Big)(groupState.CalDepositWeight(info.Address, info.AllAmount)),
+			Reward:         (*hexutil.Big)(info.Reward),
+			Interest:       (*hexutil.Big)(info.Current.Interest),
+			Positions:      make([]ValidatorGroupPosition, 0, len(info.Positions)),
+			Withdrawals:    make([]ValidatorGroupWithdraw, 0, len(info.Current.WithdrawList)),
+		}
+		if !member.Owner {
+			member.Level = groupState.DepositLevel(info.AllAmount)
+		}
+		for _, pos := range info.Positions {
+			member.Positions = append(member.Positions, ValidatorGroupPosition{
+				Position: pos.Position,
+				DType:    pos.DType,
+				Amount:   (*hexutil.Big)(pos.Amount),
+				EndTime:  pos.EndTime,
+			})
+		}
+		for _, withdraw := range info.Current.WithdrawList {
+			member.Withdrawals = append(member.Withdrawals, ValidatorGroupWithdraw{
+				Amount: (*hexutil.Big)(withdraw.WithDrawAmount),
+				Time:   withdraw.WithDrawTime,
+			})
+		}
+		group.TotalDeposit.ToInt().Add(group.TotalDeposit.ToInt(), info.AllAmount)
+		group.TotalReward.ToInt().Add(group.TotalReward.ToInt(), info.Reward)
+		group.TotalInterest.ToInt().Add(group.TotalInterest.ToInt(), info.Current.Interest)
+		group.Members = append(group.Members, member)
+	}
+	return group
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package manapi
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/core/vm"
+	"github.com/MatrixAINetwork/go-matrix/core/vm/validatorGroup"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+)
+
+var (
+	groupAddress = common.HexToAddress("0x1000")
+	groupOwner   = common.HexToAddress("0x01")
+	groupMemberA = common.HexToAddress("0x02")
+	groupMemberB = common.HexToAddress("0x03")
+)
+
+// stateBackend serves a single state, the other backend methods are not used.
+type stateBackend struct {
+	Backend
+	st     *state.StateDBManage
+	header *types.Header
+}
+
+func (b *stateBackend) StateAndHeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDBManage, *types.Header, error) {
+	return b.st, b.header, nil
+}
+
+func manAmount(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e18))
+}
+
+// newValidatorGroupBackend stores a group with an owner rate of 1, a node rate
+// of 10% and level rates of 1, 2 and 3. The owner and member B deposit 10 MAN,
+// member A deposits 10000 MAN, reaching the second level.
+func newValidatorGroupBackend(t *testing.T) *stateBackend {
+	db := mandb.NewMemDatabase()
+	st, err := state.NewStateDBManage(nil, db, state.NewDatabase(db))
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	groups := &vm.ValidatorContractState{}
+	groups.Insert(groupAddress)
+	if err := groups.SetState(vm.ValidatorGroupContractAddress, st); err != nil {
+		t.Fatalf("failed to store group list: %v", err)
+	}
+	group := vm.NewValidatorGroupState()
+	group.OwnerInfo.Owner = groupOwner
+	if err := group.SetRewardRate(big.NewInt(1e9), big.NewInt(1e8), []*big.Int{big.NewInt(1e9), big.NewInt(2e9), big.NewInt(3e9)}); err != nil {
+		t.Fatalf("failed to set rates: %v", err)
+	}
+	for _, member := range []struct {
+		account common.Address
+		amount  int64
+	}{{groupOwner, 10}, {groupMemberA, 10000}, {groupMemberB, 10}} {
+		info := validatorGroup.NewValidatorInfo(member.account)
+		info.Current.Amount = manAmount(member.amount)
+		group.ValidatorMap.Insert(*info)
+	}
+	if err := group.SetOwner(groupAddress, st); err != nil {
+		t.Fatalf("failed to store owner: %v", err)
+	}
+	if err := group.SetReward(groupAddress, st); err != nil {
+		t.Fatalf("failed to store rates: %v", err)
+	}
+	if err := group.SetValidatorMap(groupAddress, st); err != nil {
+		t.Fatalf("failed to store members: %v", err)
+	}
+	return &stateBackend{st: st, header: &types.Header{Number: big.NewInt(1), Time: big.NewInt(1000)}}
+}
+
+func TestValidatorGroupGet(t *testing.T) {
+	api := NewPublicValidatorGroupAPI(newValidatorGroupBackend(t))
+	group, err := api.Get(context.Background(), base58.Base58EncodeToString(params.MAN_COIN, groupAddress), rpc.LatestBlockNumber)
+	if err != nil {
+		t.Fatalf("failed to get group: %v", err)
+	}
+	want := map[common.Address]struct {
+		level  int
+		weight *big.Int
+	}{
+		groupOwner:   {-1, manAmount(10)},
+		groupMemberA: {1, manAmount(20000)},
+		groupMemberB: {0, manAmount(10)},
+	}
+	if len(group.Members) != len(want) {
+		t.Fatalf("member count mismatch: have %d, want %d", len(group.Members), len(want))
+	}
+	for _, member := range group.Members {
+		addr, _ := base58.Base58DecodeToAddress(member.Account)
+		if member.Level != want[addr].level || member.Weight.ToInt().Cmp(want[addr].weight) != 0 {
+			t.Errorf("member %s mismatch: have level %d weight %v, want %d %v", member.Account, member.Level, member.Weight.ToInt(), want[addr].level, want[addr].weight)
+		}
+	}
+	if group.TotalDeposit.ToInt().Cmp(manAmount(10020)) != 0 {
+		t.Errorf("total deposit mismatch: have %v, want %v", group.TotalDeposit.ToInt(), manAmount(10020))
+	}
+}
+
+// Tests that a simulated reward is shared by the deposit weights after the
+// node rate, the owner receiving the node share and the rounding remainder,
+// and that the group in the state is left unchanged.
+func TestValidatorGroupSimulate(t *testing.T) {
+	api := NewPublicValidatorGroupAPI(newValidatorGroupBackend(t))
+	group := base58.Base58EncodeToString(params.MAN_COIN, groupAddress)
+	amount := big.NewInt(1000003)
+
+	sim, err := api.Simulate(context.Background(), group, ValidatorGroupReward, hexutil.Big(*amount), 0, rpc.LatestBlockNumber)
+	if err != nil {
+		t.Fatalf("simulation failed: %v", err)
+	}
+	if sim.Time != 1000 || sim.NodeAmount.ToInt().Int64() != 100000 {
+		t.Fatalf("simulation mismatch: time %d, node amount %v", sim.Time, sim.NodeAmount.ToInt())
+	}
+	shared := big.NewInt(900003)
+	total := new(big.Int)
+	for _, share := range sim.Shares {
+		addr, _ := base58.Base58DecodeToAddress(share.Account)
+		want := new(big.Int).Mul(shared, share.Weight.ToInt())
+		want.Div(want, manAmount(20020))
+		if addr == groupOwner {
+			// The owner also receives the rounding remainder
+			if want.Add(want, sim.NodeAmount.ToInt()); share.Share.ToInt().Cmp(want) < 0 {
+				t.Errorf("owner share mismatch: have %v, want at least %v", share.Share.ToInt(), want)
+			}
+		} else if share.Share.ToInt().Cmp(want) != 0 {
+			t.Errorf("share of %s mismatch: have %v, want %v", share.Account, share.Share.ToInt(), want)
+		}
+		if share.Before.ToInt().Sign() != 0 || new(big.Int).Sub(share.After.ToInt(), share.Before.ToInt()).Cmp(share.Share.ToInt()) != 0 {
+			t.Errorf("share of %s inconsistent: before %v, after %v", share.Account, share.Before.ToInt(), share.After.ToInt())
+		}
+		total.Add(total, share.Share.ToInt())
+	}
+	if total.Cmp(amount) != 0 {
+		t.Errorf("distributed total mismatch: have %v, want %v", total, amount)
+	}
+
+	stored, err := api.Get(context.Background(), group, rpc.LatestBlockNumber)
+	if err != nil {
+		t.Fatalf("failed to get group: %v", err)
+	}
+	if stored.TotalReward.ToInt().Sign() != 0 {
+		t.Errorf("simulation changed the state: total reward %v", stored.TotalReward.ToInt())
+	}
+	if _, err := api.Simulate(context.Background(), group, "bonus", hexutil.Big(*amount), 0, rpc.LatestBlockNumber); err == nil {
+		t.Errorf("unknown kind accepted")
+	}
+	if _, err := api.Simulate(context.Background(), base58.Base58EncodeToString(params.MAN_COIN, groupMemberA), ValidatorGroupReward, hexutil.Big(*amount), 0, rpc.LatestBlockNumber); err == nil {
+		t.Errorf("non group accepted")
+	}
+}
@@ -6,19 +6,26 @@
 package web3ext
 
 var Modules = map[string]string{
-	"admin":      Admin_JS,
-	"chequebook": Chequebook_JS,
-	"clique":     Clique_JS,
-	"debug":      Debug_JS,
-	"man":        Man_JS,
-	"eth":        Man_JS,
-	"miner":      Miner_JS,
-	"net":        Net_JS,
-	"personal":   Personal_JS,
-	"rpc":        RPC_JS,
-	"shh":        Shh_JS,
-	"swarmfs":    SWARMFS_JS,
-	"txpool":     TxPool_JS,
+	"admin":          Admin_JS,
+	"chequebook":     Chequebook_JS,
+	"clique":         Clique_JS,
+	"debug":          Debug_JS,
+	"deposit":        Deposit_JS,
+	"election":       Election_JS,
+	"man":            Man_JS,
+	"matrixstate":    MatrixState_JS,
+	"eth":            Man_JS,
+	"miner":          Miner_JS,
+	"net":            Net_JS,
+	"personal":       Personal_JS,
+	"reward":         Reward_JS,
+	"rpc":            RPC_JS,
+	"shh":            Shh_JS,
+	"stratum":        Stratum_JS,
+	"swarmfs":        SWARMFS_JS,
+	"txpool":         TxPool_JS,
+	"txhistory":      TxHistory_JS,
+	"validatorgroup": ValidatorGroup_JS,
 }
 
 const Chequebook_JS = `
@@ -112,6 +119,21 @@ web3._extend({
 			call: 'admin_removePeer',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'banPeer',
+			call: 'admin_banPeer',
+			params: 3,
+			inputFormatter: [null, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'unban',
+			call: 'admin_unban',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'listBans',
+			call: 'admin_listBans'
+		}),
 		new web3._extend.Method({
 			name: 'exportChain',
 			call: 'admin_exportChain',
@@ -366,6 +388,23 @@ web3._extend({
 			call: 'debug_getBadBlocks',
 			params: 0,
 		}),
+		new web3._extend.Method({
+			name: 'electSimulateInput',
+			call: 'debug_electSimulateInput',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'electSimulate',
+			call: 'debug_electSimulate',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'electSimulateWithInput',
+			call: 'debug_electSimulateWithInput',
+			params: 2
+		}),
 		new web3._extend.Method({
 			name:'getCommit',
 			call:'debug_getCommit',
@@ -495,6 +534,12 @@ web3._extend({
 			call: 'man_getAuthGasAddress',
 			params: 2,
 		}),
+		new web3._extend.Method({
+			name: 'getScheduledTxs',
+			call: 'man_getScheduledTxs',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
 		new web3._extend.Method({
 			name: 'getEntrustFromByTime',
 			call: 'man_getEntrustFromByTime',
@@ -704,6 +749,58 @@ web3._extend({
 });
 `
 
+const Election_JS = `
+web3._extend({
+	property: 'election',
+	methods: [
+		new web3._extend.Method({
+			name: 'at',
+			call: 'election_at',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'history',
+			call: 'election_history',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+	]
+});
+`
+
+const MatrixState_JS = `
+web3._extend({
+	property: 'matrixstate',
+	methods: [
+		new web3._extend.Method({
+			name: 'keys',
+			call: 'matrixstate_keys',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'get',
+			call: 'matrixstate_get',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getAll',
+			call: 'matrixstate_getAll',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'diff',
+			call: 'matrixstate_diff',
+			params: 3,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+	]
+});
+`
+
 const TxPool_JS = `
 web3._extend({
 	property: 'txpool',
@@ -722,15 +819,107 @@ web3._extend({
 			name: 'getTxNmap',
 			getter: 'txpool_getTxNmap'
 		}),
+		new web3._extend.Property({
+			name: 'dropped',
+			getter: 'txpool_dropped'
+		}),
 		new web3._extend.Property({
 			name: 'status',
 			getter: 'txpool_status',
 			outputFormatter: function(status) {
 				status.pending = web3._extend.utils.toDecimal(status.pending);
 				status.queued = web3._extend.utils.toDecimal(status.queued);
+				status.dropped = web3._extend.utils.toDecimal(status.dropped);
 				return status;
 			}
 		}),
 	]
 });
 `
+
+const Reward_JS = `
+web3._extend({
+	property: 'reward',
+	methods: [
+		new web3._extend.Method({
+			name: 'history',
+			call: 'reward_history',
+			params: 5,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'totals',
+			call: 'reward_totals',
+			params: 3,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+	]
+});
+`
+
+const ValidatorGroup_JS = `
+web3._extend({
+	property: 'validatorgroup',
+	methods: [
+		new web3._extend.Method({
+			name: 'list',
+			call: 'validatorgroup_list',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'get',
+			call: 'validatorgroup_get',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'simulate',
+			call: 'validatorgroup_simulate',
+			params: 5,
+			inputFormatter: [null, null, web3._extend.utils.fromDecimal, web3._extend.utils.fromDecimal, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+	]
+});
+`
+
+const Deposit_JS = `
+web3._extend({
+	property: 'deposit',
+	methods: [
+		new web3._extend.Method({
+			name: 'positions',
+			call: 'deposit_positions',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+	]
+});
+`
+
+const Stratum_JS = `
+web3._extend({
+	property: 'stratum',
+	methods: [
+		new web3._extend.Method({
+			name: 'workers',
+			call: 'stratum_workers',
+			params: 0
+		}),
+	]
+});
+`
+
+const TxHistory_JS = `
+web3._extend({
+	property: 'txhistory',
+	methods: [
+		new web3._extend.Method({
+			name: 'transactions',
+			call: 'txhistory_transactions',
+			params: 5,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter, null, null]
+		}),
+	]
+});
+`
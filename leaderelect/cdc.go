@@ -135,7 +135,7 @@ func (dc *cdc) SetReelectTurn(reelectTurn uint32) error {
 	}
 	master, err := dc.GetLeader(dc.curConsensusTurn.TotalTurns()+reelectTurn, dc.bcInterval)
 	if err != nil {
-		return errors.Errorf("获取master错误(%v), 重选轮次(%d), 共识轮次(%d)", err, reelectTurn, dc.curConsensusTurn.String())
+		return errors.Errorf("获取master错误(%v), 重选轮次(%d), 共识轮次(%s)", err, reelectTurn, dc.curConsensusTurn.String())
 	}
 	dc.reelectMaster.Set(master)
 	dc.curReelectTurn = reelectTurn
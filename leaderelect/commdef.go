@@ -5,6 +5,7 @@ package leaderelect
 
 import (
 	"github.com/MatrixAINetwork/go-matrix/accounts/signhelper"
+	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/consensus"
 	"github.com/MatrixAINetwork/go-matrix/core"
@@ -36,6 +37,8 @@ type Matrix interface {
 	Engine(version string) consensus.Engine
 	HD() *msgsend.HD
 	FetcherNotify(hash common.Hash, number uint64, addr common.Address)
+	MsgCenter() *mc.Center
+	CA() *ca.Service
 }
 
 type StateReader interface {
@@ -97,7 +97,7 @@ func (self *controller) publishLeaderMsg() {
 	log.Debug(self.logInfo, "公布leader身份消息, leader", msg.Leader.Hex(), "高度", msg.Number,
 		"共识状态", msg.ConsensusState, "共识轮次", msg.ConsensusTurn.String(), "重选轮次", msg.ReelectTurn,
 		"pre Leader", msg.PreLeader.Hex(), "Next Leader", msg.NextLeader.Hex())
-	mc.PublishEvent(mc.Leader_LeaderChangeNotify, msg)
+	self.matrix.MsgCenter().PublishEvent(mc.Leader_LeaderChangeNotify, msg)
 }
 
 func (self *controller) setTimer(outTime int64, timer *time.Timer) {
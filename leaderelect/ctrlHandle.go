@@ -6,7 +6,6 @@ package leaderelect
 import (
 	"time"
 
-	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mc"
@@ -75,8 +74,8 @@ func (self *controller) handleStartMsg(msg *startControllerMsg) {
 		return
 	}
 
-	a0Address := ca.GetDepositAddress()
-	nodeAddress := ca.GetSignAddress()
+	a0Address := self.matrix.CA().GetDepositAddress()
+	nodeAddress := self.matrix.CA().GetSignAddress()
 	self.SetSelfAddress(a0Address, nodeAddress)
 
 	log.Debug(self.logInfo, "开始消息处理", "start", "高度", self.dc.number, "preLeader", msg.parentHeader.Leader.Hex(), "header time", msg.parentHeader.Time.Int64())
@@ -49,7 +49,7 @@ func (self *controller) startReelect(reelectTurn uint32) {
 
 func (self *controller) finishReelectWithPOS(posResult *mc.HD_BlkConsensusReqMsg, from common.Address) {
 	log.Info(self.logInfo, "完成leader重选", "POS结果重置，恢复并开始挖矿等待", "共识轮次", self.ConsensusTurn().String(), "高度", self.Number())
-	mc.PublishEvent(mc.Leader_RecoveryState, &mc.RecoveryStateMsg{Type: mc.RecoveryTypePOS, Header: posResult.Header, From: from})
+	self.matrix.MsgCenter().PublishEvent(mc.Leader_RecoveryState, &mc.RecoveryStateMsg{Type: mc.RecoveryTypePOS, Header: posResult.Header, From: from})
 	self.setTimer(0, self.timer)
 	self.setTimer(0, self.reelectTimer)
 	self.dc.state = stMining
@@ -275,7 +275,8 @@ func (self *controller) handleRLReq(req *mc.HD_ReelectLeaderReqMsg) {
 	}
 
 	hash := types.RlpHash(req)
-	sign, err := self.matrix.SignHelper().SignHashWithValidateByReader(self.dc, hash.Bytes(), true, self.ParentHash())
+	turn := req.InquiryReq.ConsensusTurn.TotalTurns() + req.InquiryReq.ReelectTurn
+	sign, err := self.matrix.SignHelper().SignVoteWithValidateByReader(self.dc, mc.HD_LeaderReelectVote, self.dc.number, turn, reelectVoteHash(req), hash, true, self.ParentHash())
 	if err != nil {
 		log.Error(self.logInfo, "leader重选请求处理", "签名失败", "err", err)
 		return
@@ -289,6 +290,15 @@ func (self *controller) handleRLReq(req *mc.HD_ReelectLeaderReqMsg) {
 	self.matrix.HD().SendNodeMsg(mc.HD_LeaderReelectVote, rsp, common.RoleNil, []common.Address{req.InquiryReq.From})
 }
 
+// reelectVoteHash identifies the reelection vote of a request. The master
+// resends the request with new timestamps and agree signs, and the inquiry
+// with a new timestamp, without changing what is voted for.
+func reelectVoteHash(req *mc.HD_ReelectLeaderReqMsg) common.Hash {
+	inquiry := *req.InquiryReq
+	inquiry.TimeStamp = 0
+	return types.RlpHash(&inquiry)
+}
+
 func (self *controller) handleRLVote(msg *mc.HD_ConsensusVote) {
 	if nil == msg {
 		log.Info(self.logInfo, "处理leader重选响应", "消息为nil")
@@ -602,7 +612,7 @@ func (self *controller) checkRLResult(result *mc.HD_ReelectLeaderConsensus) erro
 	}
 	turn := calcNextConsensusTurn(result.Req.InquiryReq.ConsensusTurn, result.Req.InquiryReq.ReelectTurn)
 	if turn.Cmp(self.dc.curConsensusTurn) < 0 {
-		return errors.Errorf("消息目标共识轮次(%d) < 本地共识轮次(%d)", turn.String(), self.dc.curConsensusTurn.String())
+		return errors.Errorf("消息目标共识轮次(%s) < 本地共识轮次(%s)", turn.String(), self.dc.curConsensusTurn.String())
 	}
 	if self.mp.parentHeader == nil {
 		return errors.Errorf("缺少父区块")
@@ -671,5 +681,5 @@ func (self *controller) processNewBlockReadyRsp(header *types.Header, from commo
 
 	//发送恢复状态消息
 	log.Debug(self.logInfo, "处理新区块响应", "发送恢复状态消息", "高度", number, "block hash", header.Hash().TerminalString())
-	mc.PublishEvent(mc.Leader_RecoveryState, &mc.RecoveryStateMsg{Type: mc.RecoveryTypeFullHeader, Header: header, From: from, IsBroadcast: isBroadcast})
+	self.matrix.MsgCenter().PublishEvent(mc.Leader_RecoveryState, &mc.RecoveryStateMsg{Type: mc.RecoveryTypeFullHeader, Header: header, From: from, IsBroadcast: isBroadcast})
 }
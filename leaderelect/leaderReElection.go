@@ -67,31 +67,31 @@ func NewLeaderIdentityService(matrix Matrix, extraInfo string) (*LeaderIdentity,
 func (self *LeaderIdentity) subEvents() error {
 	//订阅身份变更消息
 	var err error
-	if self.newBlockReadySub, err = mc.SubscribeEvent(mc.BlockGenor_NewBlockReady, self.newBlockReadyCh); err != nil {
+	if self.newBlockReadySub, err = self.matrix.MsgCenter().SubscribeEvent(mc.BlockGenor_NewBlockReady, self.newBlockReadyCh); err != nil {
 		return errors.Errorf("订阅<new block ready>事件错误(%v)", err)
 	}
-	if self.roleUpdateSub, err = mc.SubscribeEvent(mc.CA_RoleUpdated, self.roleUpdateCh); err != nil {
+	if self.roleUpdateSub, err = self.matrix.MsgCenter().SubscribeEvent(mc.CA_RoleUpdated, self.roleUpdateCh); err != nil {
 		return errors.Errorf("订阅<CA身份通知>事件错误(%v)", err)
 	}
-	if self.blkPOSNotifySub, err = mc.SubscribeEvent(mc.BlkVerify_POSFinishedNotify, self.blkPOSNotifyCh); err != nil {
+	if self.blkPOSNotifySub, err = self.matrix.MsgCenter().SubscribeEvent(mc.BlkVerify_POSFinishedNotify, self.blkPOSNotifyCh); err != nil {
 		return errors.Errorf("订阅<POS验证完成>事件错误(%v)", err)
 	}
-	if self.rlInquiryReqSub, err = mc.SubscribeEvent(mc.HD_LeaderReelectInquiryReq, self.rlInquiryReqCh); err != nil {
+	if self.rlInquiryReqSub, err = self.matrix.MsgCenter().SubscribeEvent(mc.HD_LeaderReelectInquiryReq, self.rlInquiryReqCh); err != nil {
 		return errors.Errorf("订阅<重选询问请求>事件错误(%v)", err)
 	}
-	if self.rlInquiryRspSub, err = mc.SubscribeEvent(mc.HD_LeaderReelectInquiryRsp, self.rlInquiryRspCh); err != nil {
+	if self.rlInquiryRspSub, err = self.matrix.MsgCenter().SubscribeEvent(mc.HD_LeaderReelectInquiryRsp, self.rlInquiryRspCh); err != nil {
 		return errors.Errorf("订阅<重选询问响应>事件错误(%v)", err)
 	}
-	if self.rlReqSub, err = mc.SubscribeEvent(mc.HD_LeaderReelectReq, self.rlReqCh); err != nil {
+	if self.rlReqSub, err = self.matrix.MsgCenter().SubscribeEvent(mc.HD_LeaderReelectReq, self.rlReqCh); err != nil {
 		return errors.Errorf("订阅<leader重选请求>事件错误(%v)", err)
 	}
-	if self.rlVoteSub, err = mc.SubscribeEvent(mc.HD_LeaderReelectVote, self.rlVoteCh); err != nil {
+	if self.rlVoteSub, err = self.matrix.MsgCenter().SubscribeEvent(mc.HD_LeaderReelectVote, self.rlVoteCh); err != nil {
 		return errors.Errorf("订阅<leader重选投票>事件错误(%v)", err)
 	}
-	if self.rlBroadcastSub, err = mc.SubscribeEvent(mc.HD_LeaderReelectBroadcast, self.rlBroadcastCh); err != nil {
+	if self.rlBroadcastSub, err = self.matrix.MsgCenter().SubscribeEvent(mc.HD_LeaderReelectBroadcast, self.rlBroadcastCh); err != nil {
 		return errors.Errorf("订阅<重选广播>事件错误(%v)", err)
 	}
-	if self.rlBroadcastRspSub, err = mc.SubscribeEvent(mc.HD_LeaderReelectBroadcastRsp, self.rlBroadcastRspCh); err != nil {
+	if self.rlBroadcastRspSub, err = self.matrix.MsgCenter().SubscribeEvent(mc.HD_LeaderReelectBroadcastRsp, self.rlBroadcastRspCh); err != nil {
 		return errors.Errorf("订阅<重选广播响应>事件错误(%v)", err)
 	}
 	return nil
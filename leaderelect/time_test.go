@@ -23,6 +23,7 @@ func TestSliceSort(t *testing.T) {
 }
 
 func TestTimer(t *testing.T) {
+	t.Skip("logs the timer for hours, for running by hand")
 	log.InitLog(3)
 	recvCh := make(chan struct{})
 	go TimerRunning(t, recvCh)
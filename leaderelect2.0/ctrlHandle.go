@@ -4,9 +4,6 @@
 package leaderelect2
 
 import (
-	"time"
-
-	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mc"
@@ -80,8 +77,8 @@ func (self *controller) handleStartMsg(msg *startControllerMsg) {
 		return
 	}
 
-	a0Address := ca.GetDepositAddress()
-	nodeAddress := ca.GetSignAddress()
+	a0Address := self.matrix.CA().GetDepositAddress()
+	nodeAddress := self.matrix.CA().GetSignAddress()
 	self.SetSelfAddress(a0Address, nodeAddress)
 
 	log.Debug(self.logInfo, "开始消息处理", "start", "高度", self.dc.number, "preLeader", msg.parentHeader.Leader.Hex(), "header time", msg.parentHeader.Time.Int64())
@@ -108,7 +105,7 @@ func (self *controller) handleStartMsg(msg *startControllerMsg) {
 	if self.dc.turnTime.SetBeginTime(msg.parentHeader.Time.Int64()) {
 		self.mp.SaveParentHeader(msg.parentHeader)
 		if isFirstConsensusTurn(self.ConsensusTurn()) {
-			curTime := time.Now().Unix()
+			curTime := self.matrix.Clock().Now().Unix()
 			st, remainTime, reelectTurn := self.dc.turnTime.CalState(0, curTime)
 			log.Debug(self.logInfo, "开始消息处理", "完成", "状态计算结果", st.String(), "剩余时间", remainTime, "重选轮次", reelectTurn)
 			self.dc.state = st
@@ -138,7 +135,7 @@ func (self *controller) handleBlockPOSFinishedNotify(msg *mc.BlockPOSFinishedNot
 }
 
 func (self *controller) timeOutHandle() {
-	curTime := time.Now().Unix()
+	curTime := self.matrix.Clock().Now().Unix()
 	st, remainTime, reelectTurn := self.dc.turnTime.CalState(self.dc.curConsensusTurn.TotalTurns(), curTime)
 	switch self.State() {
 	case stPos:
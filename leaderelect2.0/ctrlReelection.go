@@ -29,6 +29,7 @@ func (self *controller) startReelect(reelectTurn uint32) {
 		log.Error(self.logInfo, "开启重选流程", "设置重选轮次失败", "err", err, "高度", self.dc.number)
 		return
 	}
+	reelectStartCounter.Inc(1)
 	beginTime, endTime := self.dc.turnTime.CalTurnTime(self.dc.curConsensusTurn.TotalTurns(), self.dc.curReelectTurn)
 	master := self.dc.GetReelectMaster()
 	if master == self.dc.selfAddr {
@@ -49,7 +50,8 @@ func (self *controller) startReelect(reelectTurn uint32) {
 
 func (self *controller) finishReelectWithPOS(posResult *mc.HD_BlkConsensusReqMsg, from common.Address) {
 	log.Info(self.logInfo, "完成leader重选", "POS结果重置，恢复并开始挖矿等待", "共识轮次", self.ConsensusTurn().String(), "高度", self.Number())
-	mc.PublishEvent(mc.Leader_RecoveryState, &mc.RecoveryStateMsg{Type: mc.RecoveryTypePOS, Header: posResult.Header, From: from})
+	self.matrix.MsgCenter().PublishEvent(mc.Leader_RecoveryState, &mc.RecoveryStateMsg{Type: mc.RecoveryTypePOS, Header: posResult.Header, From: from})
+	reelectPOSCounter.Inc(1)
 	self.setTimer(0, self.timer)
 	self.setTimer(0, self.reelectTimer)
 	self.dc.state = stMining
@@ -65,6 +67,7 @@ func (self *controller) finishReelectWithRLConsensus(rlResult *mc.HD_V2_ReelectL
 		log.Error(self.logInfo, "完成leader重选", "leader重置, 设置共识轮次失败", "err", err)
 		return
 	}
+	reelectRLCounter.Inc(1)
 
 	//缓存共识结果消息
 	self.mp.SaveRLConsensusMsg(rlResult)
@@ -72,7 +75,7 @@ func (self *controller) finishReelectWithRLConsensus(rlResult *mc.HD_V2_ReelectL
 	self.setTimer(0, self.reelectTimer)
 	self.selfCache.ClearSelfInquiryMsg()
 	self.dc.isMaster = false
-	curTime := time.Now().Unix()
+	curTime := self.matrix.Clock().Now().Unix()
 	st, remainTime, reelectTurn := self.dc.turnTime.CalState(consensusTurn.TotalTurns(), curTime)
 	log.Info(self.logInfo, "完成leader重选", "leader重置", "重选轮次", reelectTurn, "旧共识轮次", self.ConsensusTurn().String(), "新共识轮次", consensusTurn.String(), "高度", self.Number(),
 		"状态计算结果", st.String(), "下次超时时间", remainTime, "计算的重选轮次", reelectTurn, "轮次开始时间", self.dc.turnTime.GetBeginTime(self.ConsensusTurn().TotalTurns()))
@@ -296,7 +299,8 @@ func (self *controller) handleRLReq(req *mc.HD_V2_ReelectLeaderReqMsg) {
 	}
 
 	hash := types.RlpHash(req)
-	sign, err := self.matrix.SignHelper().SignHashWithValidateByReader(self.dc, hash.Bytes(), true, self.ParentHash())
+	turn := req.InquiryReq.ConsensusTurn.TotalTurns() + req.InquiryReq.ReelectTurn
+	sign, err := self.matrix.SignHelper().SignVoteWithValidateByReader(self.dc, mc.HD_V2_LeaderReelectVote, self.dc.number, turn, reelectVoteHash(req), hash, true, self.ParentHash())
 	if err != nil {
 		log.Error(self.logInfo, "leader重选请求处理", "签名失败", "err", err)
 		return
@@ -310,6 +314,15 @@ func (self *controller) handleRLReq(req *mc.HD_V2_ReelectLeaderReqMsg) {
 	self.matrix.HD().SendNodeMsg(mc.HD_V2_LeaderReelectVote, rsp, common.RoleNil, []common.Address{req.InquiryReq.From})
 }
 
+// reelectVoteHash identifies the reelection vote of a request. The master
+// resends the request with new timestamps and agree signs, and the inquiry
+// with a new timestamp, without changing what is voted for.
+func reelectVoteHash(req *mc.HD_V2_ReelectLeaderReqMsg) common.Hash {
+	inquiry := *req.InquiryReq
+	inquiry.TimeStamp = 0
+	return types.RlpHash(&inquiry)
+}
+
 func (self *controller) handleRLVote(msg *mc.HD_V2_ConsensusVote) {
 	if nil == msg {
 		log.Info(self.logInfo, "处理leader重选响应", "消息为nil")
@@ -409,7 +422,7 @@ func (self *controller) sendInquiryReq() {
 		HeaderTime:    self.mp.parentHeader.Time.Uint64(),
 		ConsensusTurn: self.dc.curConsensusTurn,
 		ReelectTurn:   self.dc.curReelectTurn,
-		TimeStamp:     uint64(time.Now().Unix()),
+		TimeStamp:     uint64(self.matrix.Clock().Now().Unix()),
 		Master:        self.dc.selfAddr,
 		From:          self.dc.selfNodeAddr,
 	}
@@ -430,7 +443,7 @@ func (self *controller) sendInquiryReq() {
 }
 
 func (self *controller) sendInquiryReqToSingle(target common.Address) {
-	curTime := time.Now().Unix()
+	curTime := self.matrix.Clock().Now().Unix()
 	if false == self.selfCache.CanSendSingleInquiryReq(curTime, self.dc.turnTime.reelectHandleInterval) {
 		log.Trace(self.logInfo, "send<重选询问请求>single", "尚未达到发送间隔，不发送请求")
 		return
@@ -533,7 +546,7 @@ func (self *controller) sendInquiryRspWithNewBlockReady(reqHash common.Hash, tar
 }
 
 func (self *controller) sendRLReq() {
-	req, reqHash, err := self.selfCache.GetRLReqMsg()
+	req, reqHash, err := self.selfCache.GetRLReqMsg(self.matrix.Clock().Now().Unix())
 	if err != nil {
 		log.Warn(self.logInfo, "send<leader重选请求>", "获取请求消息失败", "err", err)
 		return
@@ -622,7 +635,7 @@ func (self *controller) checkRLResult(result *mc.HD_V2_ReelectLeaderConsensus) e
 	}
 	turn := calcNextConsensusTurn(result.Req.InquiryReq.ConsensusTurn, result.Req.InquiryReq.ReelectTurn)
 	if turn.Cmp(self.dc.curConsensusTurn) < 0 {
-		return errors.Errorf("消息目标共识轮次(%d) < 本地共识轮次(%d)", turn.String(), self.dc.curConsensusTurn.String())
+		return errors.Errorf("消息目标共识轮次(%s) < 本地共识轮次(%s)", turn.String(), self.dc.curConsensusTurn.String())
 	}
 	if self.mp.parentHeader == nil {
 		return errors.Errorf("缺少父区块")
@@ -691,5 +704,5 @@ func (self *controller) processNewBlockReadyRsp(header *types.Header, from commo
 
 	//发送恢复状态消息
 	log.Debug(self.logInfo, "处理新区块响应", "发送恢复状态消息", "高度", number, "block hash", header.Hash().TerminalString())
-	mc.PublishEvent(mc.Leader_RecoveryState, &mc.RecoveryStateMsg{Type: mc.RecoveryTypeFullHeader, Header: header, From: from, IsBroadcast: isBroadcast})
+	self.matrix.MsgCenter().PublishEvent(mc.Leader_RecoveryState, &mc.RecoveryStateMsg{Type: mc.RecoveryTypeFullHeader, Header: header, From: from, IsBroadcast: isBroadcast})
 }
@@ -7,8 +7,6 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
 
-	"time"
-
 	"github.com/MatrixAINetwork/go-matrix/accounts/signhelper"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mc"
@@ -236,7 +234,7 @@ func (self *masterCache) GenBroadcastMsgWithRLSuccess(rlAgreeVotes []common.Sign
 	return nil
 }
 
-func (self *masterCache) GetRLReqMsg() (*mc.HD_V2_ReelectLeaderReqMsg, common.Hash, error) {
+func (self *masterCache) GetRLReqMsg(curTime int64) (*mc.HD_V2_ReelectLeaderReqMsg, common.Hash, error) {
 	if self.inquiryResult != mc.ReelectRSPTypeAgree {
 		return nil, common.Hash{}, errors.Errorf("当前询问结果(%v) != ReelectRSPTypeAgree", self.inquiryResult)
 	}
@@ -245,7 +243,7 @@ func (self *masterCache) GetRLReqMsg() (*mc.HD_V2_ReelectLeaderReqMsg, common.Ha
 	if OK == false || reqMsg == nil {
 		return nil, common.Hash{}, errors.New("缓存中不存在请求消息")
 	}
-	reqMsg.TimeStamp = uint64(time.Now().Unix())
+	reqMsg.TimeStamp = uint64(curTime)
 	return reqMsg, self.rlReqPool.saveReqMsgAndHash(reqMsg), nil
 }
 
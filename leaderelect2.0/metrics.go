@@ -0,0 +1,22 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+// Contains the metrics collected by the leader election.
+
+package leaderelect2
+
+import (
+	"github.com/MatrixAINetwork/go-matrix/metrics"
+)
+
+var (
+	numberGauge         = metrics.NewRegisteredGauge("leaderelect/number", nil)
+	consensusTurnGauge  = metrics.NewRegisteredGauge("leaderelect/turn/consensus", nil) // Total turns of the current consensus turn
+	reelectTurnGauge    = metrics.NewRegisteredGauge("leaderelect/turn/reelect", nil)
+	consensusStateGauge = metrics.NewRegisteredGauge("leaderelect/consensus", nil) // 1 if the block of the number reached consensus
+
+	reelectStartCounter = metrics.NewRegisteredCounter("leaderelect/reelect/start", nil)
+	reelectPOSCounter   = metrics.NewRegisteredCounter("leaderelect/reelect/pos", nil)    // Reelections ended by a POS result
+	reelectRLCounter    = metrics.NewRegisteredCounter("leaderelect/reelect/leader", nil) // Reelections ended by a new leader consensus
+)
@@ -0,0 +1,54 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package leaderelect2
+
+import (
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/accounts/signhelper"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/pkg/errors"
+)
+
+func testRLReqMsg(master common.Address, timeStamp uint64) *mc.HD_V2_ReelectLeaderReqMsg {
+	return &mc.HD_V2_ReelectLeaderReqMsg{
+		InquiryReq: &mc.HD_V2_ReelectInquiryReqMsg{
+			Number:        10,
+			HeaderTime:    100,
+			ConsensusTurn: mc.ConsensusTurnInfo{PreConsensusTurn: 1, UsedReelectTurn: 2},
+			ReelectTurn:   1,
+			TimeStamp:     timeStamp,
+			Master:        master,
+			From:          common.HexToAddress("0x02"),
+		},
+		AgreeSigns: []common.Signature{{byte(timeStamp)}},
+		TimeStamp:  timeStamp + 1,
+	}
+}
+
+// Tests that the slashing protection accepts a reelection request resent with
+// new timestamps and agree signs, and refuses a request of another master.
+func TestReelectVoteResend(t *testing.T) {
+	sp := signhelper.NewSlashingProtection(mandb.NewMemDatabase())
+	master := common.HexToAddress("0x01")
+	req, resent := testRLReqMsg(master, 1000), testRLReqMsg(master, 1005)
+	turn := req.InquiryReq.ConsensusTurn.TotalTurns() + req.InquiryReq.ReelectTurn
+
+	if types.RlpHash(req) == types.RlpHash(resent) {
+		t.Fatalf("resent request signs the same hash")
+	}
+	if err := sp.CheckAndRecord(mc.HD_V2_LeaderReelectVote, 10, turn, reelectVoteHash(req)); err != nil {
+		t.Fatalf("request refused: %v", err)
+	}
+	if err := sp.CheckAndRecord(mc.HD_V2_LeaderReelectVote, 10, turn, reelectVoteHash(resent)); err != nil {
+		t.Fatalf("resent request refused: %v", err)
+	}
+	other := testRLReqMsg(common.HexToAddress("0x03"), 1000)
+	if err := sp.CheckAndRecord(mc.HD_V2_LeaderReelectVote, 10, turn, reelectVoteHash(other)); errors.Cause(err) != signhelper.ErrDoubleSign {
+		t.Fatalf("request of another master: have %v, want %v", err, signhelper.ErrDoubleSign)
+	}
+}
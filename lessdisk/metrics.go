@@ -0,0 +1,12 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package lessdisk
+
+import "github.com/MatrixAINetwork/go-matrix/metrics"
+
+var (
+	delBlkCounter     = metrics.NewRegisteredCounter("lessdisk/delete/blocks", nil) // Local blocks deleted
+	delBlkFailCounter = metrics.NewRegisteredCounter("lessdisk/delete/fails", nil)  // Local blocks failed to delete
+	minNumberGauge    = metrics.NewRegisteredGauge("lessdisk/minnumber", nil)       // Lowest block number kept
+)
@@ -0,0 +1,34 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package lessdisk
+
+import (
+	"github.com/pkg/errors"
+)
+
+// RewindIndex removes the block indexes above the given block up to the old
+// head, nothing is changed if dryRun is set. It fails if the blocks of the
+// given block were already deleted. It returns the lowest block kept, zero
+// if none is indexed, and the number of heights whose index is removed.
+func RewindIndex(db DatabaseOperator, head, number uint64, dryRun bool) (uint64, int, error) {
+	im := newIndexOperator("lessdisk rewind", db)
+	minNumber := im.readMinNumberIndex()
+	if minNumber != 0 && minNumber > number {
+		return minNumber, 0, errors.Errorf("blocks below %d are deleted by lessdisk", minNumber)
+	}
+	removed := 0
+	for i := number + 1; i <= head; i++ {
+		if len(im.readBlkIndex(i)) == 0 {
+			continue
+		}
+		removed++
+		if dryRun {
+			continue
+		}
+		if err := im.deleteBlkIndex(i); err != nil {
+			return minNumber, removed, err
+		}
+	}
+	return minNumber, removed, nil
+}
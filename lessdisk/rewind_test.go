@@ -0,0 +1,65 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package lessdisk
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+)
+
+// Tests that a rewind removes the block indexes above the target up to the
+// head, refuses targets below the blocks lessdisk deleted, and that a dry run
+// only counts the indexes.
+func TestRewindIndex(t *testing.T) {
+	indexed := []uint64{10, 11, 12, 14, 15}
+	tests := []struct {
+		min     uint64
+		head    uint64
+		number  uint64
+		removed int
+		fail    bool
+	}{
+		{10, 15, 12, 2, false},
+		{10, 15, 15, 0, false},
+		{10, 14, 12, 1, false}, // Indexes above the head are left alone
+		{0, 15, 9, 5, false},   // Nothing deleted by lessdisk yet
+		{11, 15, 10, 0, true},
+	}
+	for i, test := range tests {
+		db := newSimDB()
+		index := make(map[uint64][]dbBlkIndex)
+		for _, number := range indexed {
+			index[number] = []dbBlkIndex{{Hash: common.Hash{byte(number)}, InsertTime: number}}
+		}
+		if err := db.initDB(test.min, index); err != nil {
+			t.Fatalf("test %d: failed to init database: %v", i, err)
+		}
+		before := make(map[string][]byte)
+		for key, value := range db.cache {
+			before[key] = value
+		}
+
+		minNumber, removed, err := RewindIndex(db, test.head, test.number, true)
+		if (err != nil) != test.fail || minNumber != test.min || removed != test.removed {
+			t.Fatalf("test %d: dry run mismatch: have %d %d %v, want %d %d, fail %v", i, minNumber, removed, err, test.min, test.removed, test.fail)
+		}
+		if !reflect.DeepEqual(db.cache, before) {
+			t.Fatalf("test %d: dry run changed the database", i)
+		}
+
+		minNumber, removed, err = RewindIndex(db, test.head, test.number, false)
+		if (err != nil) != test.fail || minNumber != test.min || removed != test.removed {
+			t.Fatalf("test %d: rewind mismatch: have %d %d %v, want %d %d, fail %v", i, minNumber, removed, err, test.min, test.removed, test.fail)
+		}
+		im := newIndexOperator("test", db)
+		for _, number := range indexed {
+			kept := test.fail || number <= test.number || number > test.head
+			if have := im.readBlkIndex(number); (len(have) != 0) != kept {
+				t.Errorf("test %d: index of block %d mismatch: have %v, kept %v", i, number, have, kept)
+			}
+		}
+	}
+}
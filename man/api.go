@@ -32,6 +32,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/man/wizard"
 	"github.com/MatrixAINetwork/go-matrix/miner"
 	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/reelection"
 	"github.com/MatrixAINetwork/go-matrix/rlp"
 	"github.com/MatrixAINetwork/go-matrix/rpc"
 	"github.com/MatrixAINetwork/go-matrix/trie"
@@ -530,6 +531,44 @@ func (api *PrivateDebugAPI) GetCommit(ctx context.Context) ([]common.CommitConte
 	return common.PutCommit, nil
 }
 
+func (api *PrivateDebugAPI) electSimulateHash(blockNr rpc.BlockNumber) (common.Hash, error) {
+	var header *types.Header
+	if blockNr == rpc.LatestBlockNumber || blockNr == rpc.PendingBlockNumber {
+		header = api.man.blockchain.CurrentHeader()
+	} else {
+		header = api.man.blockchain.GetHeaderByNumber(uint64(blockNr))
+	}
+	if header == nil {
+		return common.Hash{}, fmt.Errorf("block #%d not found", blockNr)
+	}
+	return header.Hash(), nil
+}
+
+// ElectSimulateInput returns the election input the next reelection would see
+// at the block, which can be edited and passed to ElectSimulateWithInput.
+func (api *PrivateDebugAPI) ElectSimulateInput(blockNr rpc.BlockNumber) (*reelection.ElectSimulateInput, error) {
+	hash, err := api.electSimulateHash(blockNr)
+	if err != nil {
+		return nil, err
+	}
+	return api.man.reelection.ElectSimulateInput(hash)
+}
+
+// ElectSimulate runs the election plugs on the election input of the block,
+// all registered plugs if none are given. The chain state is not modified.
+func (api *PrivateDebugAPI) ElectSimulate(blockNr rpc.BlockNumber, plugs []string) ([]*reelection.ElectSimulateResult, error) {
+	input, err := api.ElectSimulateInput(blockNr)
+	if err != nil {
+		return nil, err
+	}
+	return reelection.SimulateElect(input, plugs)
+}
+
+// ElectSimulateWithInput runs the election plugs on the given input.
+func (api *PrivateDebugAPI) ElectSimulateWithInput(input reelection.ElectSimulateInput, plugs []string) ([]*reelection.ElectSimulateResult, error) {
+	return reelection.SimulateElect(&input, plugs)
+}
+
 // StorageRangeResult is the result of a debug_storageRangeAt API call.
 type StorageRangeResult struct {
 	Storage storageMap   `json:"storage"`
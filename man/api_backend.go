@@ -233,7 +233,7 @@ func (b *ManAPIBackend) ImportSuperBlock(ctx context.Context, filePath string) (
 
 //TODO 调用该方法的时候应该返回错误的切片
 func (b *ManAPIBackend) SendTx(ctx context.Context, signedTx types.SelfTransaction) error {
-	return b.man.txPool.AddRemote(signedTx)
+	return b.man.txPool.AddLocal(signedTx)
 }
 
 func (b *ManAPIBackend) GetPoolTransactions() (types.SelfTransactions, error) {
@@ -281,22 +281,11 @@ func (b *ManAPIBackend) GetPoolNonce(cointyp string, ctx context.Context, addr c
 }
 
 func (b *ManAPIBackend) Stats() (pending int, queued int) {
-	bpooler, err := b.man.TxPool().GetTxPoolByType(types.BroadCastTxIndex)
-	if err == nil {
-		_, ok := bpooler.(*core.BroadCastTxPool)
-		if ok {
-			//_,btxs = bpool.Content()
-		} else {
-			queued = 0
-		}
-	}
 	npooler, nerr := b.man.TxPool().GetTxPoolByType(types.NormalTxIndex)
 	if nerr == nil {
 		npool, ok := npooler.(*core.NormalTxPool)
 		if ok {
-			pending, _ = npool.Stats()
-		} else {
-			pending = 0
+			pending, queued = npool.Stats()
 		}
 	}
 	return pending, queued
@@ -315,39 +304,37 @@ func (b *ManAPIBackend) GetTxNmap() map[uint32]*types.Transaction {
 	return retval
 }
 
-//TODO 应该将返回值加入切片中否则以后多一种交易就要添加一个返回值
-func (b *ManAPIBackend) TxPoolContent() (ntxs map[common.Address]types.SelfTransactions, btxs map[common.Address]types.SelfTransactions) {
-	ntxs = make(map[common.Address]types.SelfTransactions)
-	btxs = make(map[common.Address]types.SelfTransactions)
-	bpooler, err := b.man.TxPool().GetTxPoolByType(types.BroadCastTxIndex)
-	if err == nil {
-		_, ok := bpooler.(*core.BroadCastTxPool)
-		if ok {
-			//_,btxs = bpool.Content()
-		} else {
-			btxs = nil
-		}
-	}
+func (b *ManAPIBackend) TxPoolContent() (pending map[common.Address]types.SelfTransactions, queued map[common.Address]types.SelfTransactions) {
+	pending = make(map[common.Address]types.SelfTransactions)
+	queued = make(map[common.Address]types.SelfTransactions)
 	npooler, nerr := b.man.TxPool().GetTxPoolByType(types.NormalTxIndex)
 	if nerr == nil {
 		npool, ok := npooler.(*core.NormalTxPool)
 		if ok {
-			txlist := npool.Content()
-			for k, vlist := range txlist {
-				txser := make([]types.SelfTransaction, 0)
-				for _, v := range vlist {
-					txser = append(txser, v)
+			ready, gapped := npool.ContentByStatus()
+			for addr, txs := range ready {
+				for _, tx := range txs {
+					pending[addr] = append(pending[addr], tx)
 				}
-				if vs, ok := ntxs[k]; !ok {
-					txser = append(txser, vs...)
+			}
+			for addr, txs := range gapped {
+				for _, tx := range txs {
+					queued[addr] = append(queued[addr], tx)
 				}
-				ntxs[k] = txser
 			}
-		} else {
-			ntxs = nil
 		}
 	}
-	return ntxs, btxs
+	return pending, queued
+}
+
+func (b *ManAPIBackend) TxPoolDropped() []core.TxDrop {
+	npooler, nerr := b.man.TxPool().GetTxPoolByType(types.NormalTxIndex)
+	if nerr == nil {
+		if npool, ok := npooler.(*core.NormalTxPool); ok {
+			return npool.Dropped()
+		}
+	}
+	return nil
 }
 
 func (b *ManAPIBackend) SubscribeNewTxsEvent(ch chan core.NewTxsEvent) event.Subscription {
@@ -533,7 +520,7 @@ func (b *ManAPIBackend) GetFutureRewards(state *state.StateDBManage, number rpc.
 }
 
 func (b *ManAPIBackend) calcFutureInterest(state *state.StateDBManage, latestElectNum uint64, bcInterval *mc.BCIntervalInfo) (map[common.Address]*big.Int, error) {
-	interestReward := interest.New(state, state)
+	interestReward := interest.New(state, state, b.man.CA())
 	if nil == interestReward {
 		return nil, errors.New("interest创建失败")
 	}
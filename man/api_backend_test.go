@@ -2,6 +2,11 @@
 // Distributed under the MIT software license, see the accompanying
 // file COPYING or http://www.opensource.org/licenses/mit-license.php
 
+// Imports bou.ke/monkey, which isn't vendored.
+
+//go:build legacytests
+// +build legacytests
+
 package man
 
 import (
@@ -0,0 +1,188 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package man
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+)
+
+// maxRewardHistoryLimit is the maximum number of entries of a history page.
+const maxRewardHistoryLimit = 1000
+
+var errRewardLedgerDisabled = errors.New("reward ledger is not enabled, start gman with --rewardledger")
+
+// PublicRewardAPI provides the reward and slash history of accounts from the
+// reward ledger index.
+type PublicRewardAPI struct {
+	man *Matrix
+}
+
+// NewPublicRewardAPI creates a new reward ledger API.
+func NewPublicRewardAPI(man *Matrix) *PublicRewardAPI {
+	return &PublicRewardAPI{man}
+}
+
+// RewardEntry is a reward credited to or a slash charged to an account.
+type RewardEntry struct {
+	Number   uint64       `json:"number"`
+	TxHash   *common.Hash `json:"txHash,omitempty"`
+	Category string       `json:"category"`
+	Currency string       `json:"currency"`
+	Amount   *hexutil.Big `json:"amount"`
+}
+
+// RewardHistory is a page of the entries of an account in a block range.
+type RewardHistory struct {
+	Account string        `json:"account"`
+	From    uint64        `json:"from"`
+	To      uint64        `json:"to"`
+	Indexed uint64        `json:"indexed"` // Number of blocks covered by the index
+	Total   uint64        `json:"total"`   // Number of entries in the range
+	Entries []RewardEntry `json:"entries"`
+}
+
+// RewardTotals are the sums of the entries of an account in a block range by
+// currency and category.
+type RewardTotals struct {
+	Account string                             `json:"account"`
+	From    uint64                             `json:"from"`
+	To      uint64                             `json:"to"`
+	Indexed uint64                             `json:"indexed"`
+	Totals  map[string]map[string]*hexutil.Big `json:"totals"`
+}
+
+// rewardRange resolves the block range of a query to the entry indexes of the
+// account, the range is capped at the last indexed block.
+func (api *PublicRewardAPI) rewardRange(account string, from, to rpc.BlockNumber) (addr common.Address, first, last, indexed, lo, hi uint64, err error) {
+	if api.man.rewardIndexer == nil {
+		err = errRewardLedgerDisabled
+		return
+	}
+	if addr, err = base58.Base58DecodeToAddress(account); err != nil {
+		return
+	}
+	sections, _, _ := api.man.rewardIndexer.Sections()
+	indexed = sections * rewardLedgerSectionSize
+
+	resolve := func(number rpc.BlockNumber) uint64 {
+		if number < 0 {
+			if indexed == 0 {
+				return 0
+			}
+			return indexed - 1
+		}
+		return uint64(number)
+	}
+	first, last = resolve(from), resolve(to)
+	if first > last {
+		err = fmt.Errorf("invalid range: from %d > to %d", first, last)
+		return
+	}
+	db := api.man.chainDb
+	count := rawdb.ReadRewardEntryCount(db, addr)
+	if indexed == 0 || first >= indexed {
+		lo, hi = count, count
+		return
+	}
+	if last >= indexed {
+		last = indexed - 1
+	}
+	lo = rewardEntrySearch(db, addr, count, first)
+	hi = rewardEntrySearch(db, addr, count, last+1)
+	return
+}
+
+// History returns the reward and slash entries of the account in the block
+// range, limit entries from the offset at most.
+func (api *PublicRewardAPI) History(account string, from, to rpc.BlockNumber, offset, limit uint64) (*RewardHistory, error) {
+	addr, first, last, indexed, lo, hi, err := api.rewardRange(account, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if limit == 0 || limit > maxRewardHistoryLimit {
+		limit = maxRewardHistoryLimit
+	}
+	history := &RewardHistory{
+		Account: account,
+		From:    first,
+		To:      last,
+		Indexed: indexed,
+		Total:   hi - lo,
+		Entries: make([]RewardEntry, 0),
+	}
+	if offset > hi-lo {
+		offset = hi - lo
+	}
+	for i := lo + offset; i < hi && uint64(len(history.Entries)) < limit; i++ {
+		entry := rawdb.ReadRewardEntry(api.man.chainDb, addr, i)
+		if entry == nil {
+			return nil, fmt.Errorf("reward entry %d of %s missing", i, account)
+		}
+		history.Entries = append(history.Entries, newRewardEntry(entry))
+	}
+	return history, nil
+}
+
+// Totals returns the sums of the reward and slash entries of the account in
+// the block range.
+func (api *PublicRewardAPI) Totals(account string, from, to rpc.BlockNumber) (*RewardTotals, error) {
+	addr, first, last, indexed, lo, hi, err := api.rewardRange(account, from, to)
+	if err != nil {
+		return nil, err
+	}
+	totals := &RewardTotals{
+		Account: account,
+		From:    first,
+		To:      last,
+		Indexed: indexed,
+		Totals:  make(map[string]map[string]*hexutil.Big),
+	}
+	for i := lo; i < hi; i++ {
+		entry := rawdb.ReadRewardEntry(api.man.chainDb, addr, i)
+		if entry == nil {
+			return nil, fmt.Errorf("reward entry %d of %s missing", i, account)
+		}
+		categories, ok := totals.Totals[entry.Currency]
+		if !ok {
+			categories = make(map[string]*hexutil.Big)
+			totals.Totals[entry.Currency] = categories
+		}
+		category := rewardCategoryName(entry.Category)
+		if categories[category] == nil {
+			categories[category] = (*hexutil.Big)(new(big.Int))
+		}
+		categories[category].ToInt().Add(categories[category].ToInt(), entry.Amount)
+	}
+	return totals, nil
+}
+
+func newRewardEntry(entry *rawdb.RewardEntry) RewardEntry {
+	result := RewardEntry{
+		Number:   entry.Number,
+		Category: rewardCategoryName(entry.Category),
+		Currency: entry.Currency,
+		Amount:   (*hexutil.Big)(entry.Amount),
+	}
+	if entry.TxHash != (common.Hash{}) {
+		hash := entry.TxHash
+		result.TxHash = &hash
+	}
+	return result
+}
+
+func rewardCategoryName(category byte) string {
+	if name, ok := rewardCategoryNames[category]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", category)
+}
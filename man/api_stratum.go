@@ -0,0 +1,33 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package man
+
+import (
+	"errors"
+
+	"github.com/MatrixAINetwork/go-matrix/miner"
+)
+
+var errStratumDisabled = errors.New("stratum server is not enabled, start gman with --stratum")
+
+// PublicStratumAPI provides the statistics of the workers of the stratum
+// mining server.
+type PublicStratumAPI struct {
+	man *Matrix
+}
+
+// NewPublicStratumAPI creates a new stratum API.
+func NewPublicStratumAPI(man *Matrix) *PublicStratumAPI {
+	return &PublicStratumAPI{man}
+}
+
+// Workers returns the share accounting and hashrate of every worker seen since
+// the node started.
+func (api *PublicStratumAPI) Workers() ([]miner.StratumWorker, error) {
+	if api.man.stratum == nil {
+		return nil, errStratumDisabled
+	}
+	return api.man.stratum.Workers(), nil
+}
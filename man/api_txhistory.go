@@ -0,0 +1,127 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package man
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+)
+
+// maxTxHistoryLimit is the maximum number of entries of a history page.
+const maxTxHistoryLimit = 1000
+
+var errTxHistoryDisabled = errors.New("transaction history is not enabled, start gman with --txhistory")
+
+// PublicTxHistoryAPI provides the transactions and transfers touching an
+// account from the transaction history index.
+type PublicTxHistoryAPI struct {
+	man *Matrix
+}
+
+// NewPublicTxHistoryAPI creates a new transaction history API.
+func NewPublicTxHistoryAPI(man *Matrix) *PublicTxHistoryAPI {
+	return &PublicTxHistoryAPI{man}
+}
+
+// TxHistoryEntry is a transaction or transfer touching an account.
+type TxHistoryEntry struct {
+	Number uint64       `json:"number"`
+	TxHash common.Hash  `json:"txHash"`
+	Kind   string       `json:"kind"`
+	Failed bool         `json:"failed,omitempty"`
+	Amount *hexutil.Big `json:"amount"`
+}
+
+// TxHistory is a page of the entries of an account in a block range.
+type TxHistory struct {
+	Account string           `json:"account"`
+	From    uint64           `json:"from"`
+	To      uint64           `json:"to"`
+	Indexed uint64           `json:"indexed"` // Number of blocks covered by the index
+	Total   uint64           `json:"total"`   // Number of entries in the range
+	Entries []TxHistoryEntry `json:"entries"`
+}
+
+// Transactions returns the transactions and transfers touching the account in
+// the currency of its address and the block range, limit entries from the
+// offset at most. The range is capped at the last indexed block.
+func (api *PublicTxHistoryAPI) Transactions(account string, from, to rpc.BlockNumber, offset, limit uint64) (*TxHistory, error) {
+	if api.man.txHistoryIndexer == nil {
+		return nil, errTxHistoryDisabled
+	}
+	addr, err := base58.Base58DecodeToAddress(account)
+	if err != nil {
+		return nil, err
+	}
+	key := rawdb.TxHistoryAccount{Address: addr, Currency: strings.Split(account, ".")[0]}
+
+	sections, _, _ := api.man.txHistoryIndexer.Sections()
+	indexed := sections * txHistorySectionSize
+	resolve := func(number rpc.BlockNumber) uint64 {
+		if number < 0 {
+			if indexed == 0 {
+				return 0
+			}
+			return indexed - 1
+		}
+		return uint64(number)
+	}
+	first, last := resolve(from), resolve(to)
+	if first > last {
+		return nil, fmt.Errorf("invalid range: from %d > to %d", first, last)
+	}
+	if limit == 0 || limit > maxTxHistoryLimit {
+		limit = maxTxHistoryLimit
+	}
+	history := &TxHistory{
+		Account: account,
+		From:    first,
+		To:      last,
+		Indexed: indexed,
+		Entries: make([]TxHistoryEntry, 0),
+	}
+	if indexed == 0 || first >= indexed {
+		return history, nil
+	}
+	if last >= indexed {
+		last = indexed - 1
+	}
+	db := api.man.chainDb
+	count := rawdb.ReadTxHistoryCount(db, key)
+	lo := txHistorySearch(db, key, count, first)
+	hi := txHistorySearch(db, key, count, last+1)
+	history.Total = hi - lo
+	if offset > hi-lo {
+		offset = hi - lo
+	}
+	for i := lo + offset; i < hi && uint64(len(history.Entries)) < limit; i++ {
+		entry := rawdb.ReadTxHistoryEntry(db, key, i)
+		if entry == nil {
+			return nil, fmt.Errorf("tx history entry %d of %s missing", i, account)
+		}
+		history.Entries = append(history.Entries, TxHistoryEntry{
+			Number: entry.Number,
+			TxHash: entry.TxHash,
+			Kind:   txHistoryKindName(entry.Kind),
+			Failed: entry.Failed,
+			Amount: (*hexutil.Big)(entry.Amount),
+		})
+	}
+	return history, nil
+}
+
+func txHistoryKindName(kind byte) string {
+	if name, ok := txHistoryKindNames[kind]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", kind)
+}
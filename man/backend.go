@@ -24,6 +24,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/common/mclock"
 	"github.com/MatrixAINetwork/go-matrix/consensus"
 	"github.com/MatrixAINetwork/go-matrix/consensus/ai"
 	"github.com/MatrixAINetwork/go-matrix/consensus/amhash"
@@ -94,12 +95,15 @@ type Matrix struct {
 	dposEngine     map[string]consensus.DPOSEngine
 	accountManager *accounts.Manager
 
-	bloomRequests chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
-	bloomIndexer  *core.ChainIndexer             // Bloom indexer operating during block imports
+	bloomRequests    chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
+	bloomIndexer     *core.ChainIndexer             // Bloom indexer operating during block imports
+	rewardIndexer    *core.ChainIndexer             // Reward ledger indexer, nil if disabled
+	txHistoryIndexer *core.ChainIndexer             // Transaction history indexer, nil if disabled
 
 	APIBackend *ManAPIBackend
 
 	miner    *miner.Miner
+	stratum  *miner.StratumServer // Stratum mining server, nil if disabled
 	gasPrice *big.Int
 	manbase  common.Address
 
@@ -109,10 +113,12 @@ type Matrix struct {
 	broadTx *broadcastTx.BroadCast //
 
 	//algorithm
-	ca         *ca.Identity //node传进来的
-	msgcenter  *mc.Center   //node传进来的
-	hd         *msgsend.HD  //node传进来的
+	ca         *ca.Service //node传进来的
+	msgcenter  *mc.Center  //node传进来的
+	recorder   *mc.Recorder
+	hd         *msgsend.HD //node传进来的
 	signHelper *signhelper.SignHelper
+	protection *signhelper.SlashingProtection
 
 	reelection     *reelection.ReElection //换届服务
 	random         *baseinterface.Random
@@ -171,6 +177,19 @@ func New(ctx *pod.ServiceContext, config *Config) (*Matrix, error) {
 		bloomRequests: make(chan chan *bloombits.Retrieval),
 		bloomIndexer:  NewBloomIndexer(chainDb, params.BloomBitsBlocks),
 	}
+	if man.msgcenter == nil {
+		man.msgcenter = mc.DefaultCenter()
+	}
+	if man.ca == nil {
+		man.ca = ca.DefaultService()
+	}
+	if config.EventRecord != "" {
+		if man.recorder, err = mc.NewRecorder(ctx.ResolvePath(config.EventRecord)); err != nil {
+			return nil, err
+		}
+		man.msgcenter.SetRecorder(man.recorder)
+		log.Info("Recording message center events", "file", ctx.ResolvePath(config.EventRecord))
+	}
 	man.engine, man.dposEngine = CreateConsensusEngineMap(ctx, &config.Manash, chainConfig, chainDb)
 	log.Info("Initialising Matrix protocol", "versions", ProtocolVersions, "network", config.NetworkId)
 
@@ -183,12 +202,14 @@ func New(ctx *pod.ServiceContext, config *Config) (*Matrix, error) {
 	}
 	var (
 		vmConfig    = vm.Config{EnablePreimageRecording: config.EnablePreimageRecording}
-		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieNodeLimit: config.TrieCache, TrieTimeLimit: config.TrieTimeout}
+		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieNodeLimit: config.TrieCache, TrieTimeLimit: config.TrieTimeout,
+			PruneRecent: config.PruneStateRecent, PruneInterval: config.PruneStateInterval}
 	)
 	man.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, man.chainConfig, vmConfig, man.engine, man.dposEngine)
 	if err != nil {
 		return nil, err
 	}
+	man.blockchain.SetCA(man.ca)
 
 	// Rewind the chain in case of an incompatible config upgrade.
 	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
@@ -196,15 +217,34 @@ func New(ctx *pod.ServiceContext, config *Config) (*Matrix, error) {
 		man.blockchain.SetHead(compat.RewindTo)
 		rawdb.WriteChainConfig(chainDb, genesisHash, chainConfig)
 	}
+	if snapDir := ctx.ResolvePath(config.SnapshotDir); snapDir != "" {
+		if err := man.blockchain.SetSnapshotDir(snapDir); err != nil {
+			return nil, err
+		}
+	}
 	man.bloomIndexer.Start(man.blockchain)
+	if config.RewardLedger {
+		man.rewardIndexer = NewRewardLedgerIndexer(chainDb, man.blockchain)
+		man.rewardIndexer.Start(man.blockchain)
+	}
+	if config.TxHistory {
+		man.txHistoryIndexer = NewTxHistoryIndexer(chainDb, man.blockchain)
+		man.txHistoryIndexer.Start(man.blockchain)
+	}
 
 	man.signHelper.SetAuthReader(man.blockchain)
+	protectionDb, err := ctx.OpenDatabase("signprotection", 0, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	man.protection = signhelper.NewSlashingProtection(protectionDb)
+	man.signHelper.SetSlashingProtection(man.protection)
 
-	ca.SetTopologyReader(man.blockchain.GetTopologyStore())
+	man.ca.SetTopologyReader(man.blockchain.GetTopologyStore())
 
-	//if config.TxPool.Journal != "" {
-	//	config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
-	//}
+	if config.TxPool.Journal != "" {
+		config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
+	}
 	man.txPool = core.NewTxPoolManager(config.TxPool, man.chainConfig, man.blockchain, ctx.GetConfig().DataDir)
 
 	if man.protocolManager, err = NewProtocolManager(man.chainConfig, config.SyncMode, config.NetworkId, man.eventMux, man.txPool, man.engine, man.blockchain, chainDb, ctx.MsgCenter); err != nil {
@@ -217,6 +257,11 @@ func New(ctx *pod.ServiceContext, config *Config) (*Matrix, error) {
 		return nil, err
 	}
 	man.miner.SetExtra(makeExtraData(config.ExtraData))
+	if config.StratumAddr != "" {
+		agent := miner.NewRemoteAgent(man.blockchain, man.engine)
+		man.miner.Register(agent)
+		man.stratum = miner.NewStratumServer(agent, miner.StratumConfig{Addr: config.StratumAddr, Difficulty: config.StratumDifficulty})
+	}
 
 	//algorithm
 	man.random, err = baseinterface.NewRandom(man.blockchain)
@@ -229,7 +274,7 @@ func New(ctx *pod.ServiceContext, config *Config) (*Matrix, error) {
 	man.blockchain.Processor([]byte(manversion.VersionAIMine)).SetRandom(man.random)
 	man.blockchain.Processor([]byte(manversion.VersionZeta)).SetRandom(man.random)
 	man.olConsensus = olconsensus.NewTopNodeService(man.blockchain)
-	topNodeInstance := olconsensus.NewTopNodeInstance(man.signHelper, man.hd)
+	topNodeInstance := olconsensus.NewTopNodeInstance(man.signHelper, man.hd, man.msgcenter, man.ca)
 	man.olConsensus.SetValidatorReader(man.blockchain)
 	man.olConsensus.SetStateReaderInterface(man.blockchain.GetTopologyStore())
 	man.olConsensus.SetTopNodeStateInterface(topNodeInstance)
@@ -451,6 +496,21 @@ func (s *Matrix) APIs() []rpc.API {
 			Namespace: "debug",
 			Version:   "1.0",
 			Service:   NewPrivateDebugAPI(s.chainConfig, s),
+		}, {
+			Namespace: "reward",
+			Version:   "1.0",
+			Service:   NewPublicRewardAPI(s),
+			Public:    true,
+		}, {
+			Namespace: "txhistory",
+			Version:   "1.0",
+			Service:   NewPublicTxHistoryAPI(s),
+			Public:    true,
+		}, {
+			Namespace: "stratum",
+			Version:   "1.0",
+			Service:   NewPublicStratumAPI(s),
+			Public:    true,
 		}, {
 			Namespace: "net",
 			Version:   "1.0",
@@ -519,8 +579,9 @@ func (s *Matrix) IsListening() bool                        { return true } // Al
 func (s *Matrix) ManVersion() int                          { return int(s.protocolManager.SubProtocols[0].Version) }
 func (s *Matrix) NetVersion() uint64                       { return s.networkId }
 func (s *Matrix) Downloader() *downloader.Downloader       { return s.protocolManager.downloader }
-func (s *Matrix) CA() *ca.Identity                         { return s.ca }
+func (s *Matrix) CA() *ca.Service                          { return s.ca }
 func (s *Matrix) MsgCenter() *mc.Center                    { return s.msgcenter }
+func (s *Matrix) Clock() mclock.Clock                      { return mclock.System{} }
 func (s *Matrix) SignHelper() *signhelper.SignHelper       { return s.signHelper }
 func (s *Matrix) ReElection() *reelection.ReElection       { return s.reelection }
 func (s *Matrix) HD() *msgsend.HD                          { return s.hd }
@@ -560,6 +621,11 @@ func (s *Matrix) Start(srvr *p2p.Server) error {
 	if s.lesServer != nil {
 		s.lesServer.Start(srvr)
 	}
+	if s.stratum != nil {
+		if err := s.stratum.Start(); err != nil {
+			return fmt.Errorf("stratum server: %v", err)
+		}
+	}
 	//s.broadTx.Start()//
 	return nil
 }
@@ -622,6 +688,15 @@ func (s *Matrix) Stop() error {
 	s.blockVerify.Close()
 	s.olConsensus.Close()
 	s.bloomIndexer.Close()
+	if s.rewardIndexer != nil {
+		s.rewardIndexer.Close()
+	}
+	if s.txHistoryIndexer != nil {
+		s.txHistoryIndexer.Close()
+	}
+	if s.stratum != nil {
+		s.stratum.Stop()
+	}
 	s.blockchain.Stop()
 	s.protocolManager.Stop()
 	if s.lesServer != nil {
@@ -632,7 +707,12 @@ func (s *Matrix) Stop() error {
 	s.eventMux.Stop()
 
 	s.chainDb.Close()
+	s.protection.Close()
 	s.broadTx.Stop() //
+	if s.recorder != nil {
+		s.msgcenter.SetRecorder(nil)
+		s.recorder.Close()
+	}
 	close(s.shutdownChan)
 
 	return nil
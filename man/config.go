@@ -19,6 +19,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/man/downloader"
 	"github.com/MatrixAINetwork/go-matrix/man/gasprice"
 	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/snapshot"
 )
 
 // DefaultConfig contains default settings for use on the Matrix main net.
@@ -31,13 +32,15 @@ var DefaultConfig = Config{
 		DatasetsInMem:  1,
 		DatasetsOnDisk: 2,
 	},
-	NetworkId:         1,
-	LightPeers:        100,
-	DatabaseCache:     768,
-	DatabaseTableSize: 2,
-	TrieCache:         256,
-	TrieTimeout:       5 * time.Minute,
-	GasPrice:          big.NewInt(18 * params.Shannon),
+	NetworkId:          1,
+	LightPeers:         100,
+	DatabaseCache:      768,
+	DatabaseTableSize:  2,
+	TrieCache:          256,
+	TrieTimeout:        5 * time.Minute,
+	PruneStateInterval: 3000,
+	GasPrice:           big.NewInt(18 * params.Shannon),
+	SnapshotDir:        snapshot.DefaultDir,
 
 	TxPool: core.DefaultTxPoolConfig,
 	GPO: gasprice.Config{
@@ -84,6 +87,10 @@ type Config struct {
 	DatabaseTableSize  int
 	TrieTimeout        time.Duration
 
+	// State pruning options, only with NoPruning off
+	PruneStateRecent   uint64 `toml:",omitempty"` // States of the latest blocks kept by online pruning, 0 disables it
+	PruneStateInterval uint64 // Blocks between two online prunes
+
 	// Mining-related options
 	Manerbase    common.Address `toml:",omitempty"`
 	MinerThreads int            `toml:",omitempty"`
@@ -99,6 +106,24 @@ type Config struct {
 	// Gas Price Oracle options
 	GPO gasprice.Config
 
+	// Snapshot options
+	SnapshotDir string // Directory snapshots are saved to and loaded from, relative to the instance directory
+
+	// File the events published on the message center are recorded to, empty disables recording
+	EventRecord string `toml:",omitempty"`
+
+	// Index the rewards and slashes of all accounts for the reward API
+	RewardLedger bool `toml:",omitempty"`
+
+	// Index the transactions and transfers of all accounts for the txhistory API
+	TxHistory bool `toml:",omitempty"`
+
+	// Stratum mining server listening address, empty disables the server
+	StratumAddr string `toml:",omitempty"`
+
+	// Initial and minimum x11 share difficulty of the stratum sessions
+	StratumDifficulty uint64 `toml:",omitempty"`
+
 	// Enables tracking of SHA3 preimages in the VM
 	EnablePreimageRecording bool
 
@@ -0,0 +1,74 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+// Package archive provides the content-addressed stores used by the ipfs
+// download path of the downloader to share block batches, snapshots and the
+// published cache index between nodes.
+package archive
+
+import (
+	"crypto/sha256"
+	"errors"
+	"hash"
+	"io"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+)
+
+// HashLen is the length of an object address returned by the archives. It
+// matches the length of an ipfs CIDv0 hash.
+const HashLen = 46
+
+var (
+	ErrInvalidHash = errors.New("archive: invalid object hash")
+	ErrNotFound    = errors.New("archive: object not found")
+	ErrCorrupt     = errors.New("archive: object content does not match its hash")
+	ErrNoPublisher = errors.New("archive: no publisher id")
+)
+
+// BlockArchive is a content-addressed store for block batches and snapshots.
+// Objects are addressed by the hash returned when storing them. A node may in
+// addition publish a directory of index files under its publisher id, which
+// other nodes resolve by that id.
+type BlockArchive interface {
+	// PutBlockBatch stores a (batch of) block or cache page and returns its hash.
+	PutBlockBatch(r io.Reader) (string, error)
+	// GetBlockBatch writes the object stored under hash into w.
+	GetBlockBatch(hash string, w io.Writer) error
+	// PutSnapshot stores a state snapshot and returns its hash.
+	PutSnapshot(r io.Reader) (string, error)
+	// GetSnapshot writes the snapshot stored under hash into w.
+	GetSnapshot(hash string, w io.Writer) error
+
+	// PublishIndex stores every file of dir and publishes them under the
+	// publisher id of this node. It returns the hash of the published index.
+	PublishIndex(dir string) (string, error)
+	// GetIndex returns the content of the named index file published by publisher.
+	GetIndex(publisher, name string) ([]byte, error)
+	// ListIndex returns the names of the index files published by publisher.
+	ListIndex(publisher string) ([]string, error)
+}
+
+// newHasher returns the digest used for object addresses.
+func newHasher() hash.Hash {
+	return sha256.New()
+}
+
+// encodeHash turns a sha256 digest into a base58 multihash, the same form as
+// an ipfs CIDv0 hash ("Qm...").
+func encodeHash(digest []byte) string {
+	return base58.Encode(append([]byte{0x12, 0x20}, digest...))
+}
+
+// decodeHash returns the sha256 digest of an object address.
+func decodeHash(hash string) ([]byte, error) {
+	if len(hash) != HashLen {
+		return nil, ErrInvalidHash
+	}
+	raw := base58.Decode(hash)
+	if len(raw) != 34 || raw[0] != 0x12 || raw[1] != 0x20 {
+		return nil, ErrInvalidHash
+	}
+	return raw[2:], nil
+}
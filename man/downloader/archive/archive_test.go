@@ -0,0 +1,248 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package archive
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func newTestLocal(t *testing.T, id string) (*LocalArchive, string) {
+	root, err := ioutil.TempDir("", "archive-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	a, err := NewLocalArchive(root, id)
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	return a, root
+}
+
+func writeTestIndex(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "archive-index")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+// testArchive runs the put/get/publish cycle shared by all implementations.
+func testArchive(t *testing.T, a BlockArchive, publisher string) {
+	batch := []byte("block batch payload")
+	hash, err := a.PutBlockBatch(bytes.NewReader(batch))
+	if err != nil {
+		t.Fatalf("failed to put block batch: %v", err)
+	}
+	if len(hash) != HashLen || !strings.HasPrefix(hash, "Qm") {
+		t.Fatalf("unexpected hash format: %s", hash)
+	}
+	buf := new(bytes.Buffer)
+	if err := a.GetBlockBatch(hash, buf); err != nil {
+		t.Fatalf("failed to get block batch: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), batch) {
+		t.Fatalf("block batch mismatch: have %q, want %q", buf.Bytes(), batch)
+	}
+
+	snap := bytes.Repeat([]byte("snapshot"), 1024)
+	hash, err = a.PutSnapshot(bytes.NewReader(snap))
+	if err != nil {
+		t.Fatalf("failed to put snapshot: %v", err)
+	}
+	buf.Reset()
+	if err := a.GetSnapshot(hash, buf); err != nil {
+		t.Fatalf("failed to get snapshot: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), snap) {
+		t.Fatalf("snapshot mismatch")
+	}
+
+	dir := writeTestIndex(t, map[string]string{"firstCacheInfo.jn": "{}", "lastestblockInfo.gb": "latest"})
+	defer os.RemoveAll(dir)
+	if _, err := a.PublishIndex(dir); err != nil {
+		t.Fatalf("failed to publish index: %v", err)
+	}
+	names, err := a.ListIndex(publisher)
+	if err != nil {
+		t.Fatalf("failed to list index: %v", err)
+	}
+	if want := []string{"firstCacheInfo.jn", "lastestblockInfo.gb"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("index mismatch: have %v, want %v", names, want)
+	}
+	content, err := a.GetIndex(publisher, "lastestblockInfo.gb")
+	if err != nil {
+		t.Fatalf("failed to get index file: %v", err)
+	}
+	if string(content) != "latest" {
+		t.Fatalf("index file mismatch: have %q", content)
+	}
+}
+
+func TestLocalArchive(t *testing.T) {
+	a, root := newTestLocal(t, "node")
+	defer os.RemoveAll(root)
+
+	testArchive(t, a, "node")
+}
+
+func TestLocalArchiveShared(t *testing.T) {
+	publisher, root := newTestLocal(t, "broadcast")
+	defer os.RemoveAll(root)
+	reader, err := NewLocalArchive(root, "follower")
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	dir := writeTestIndex(t, map[string]string{"firstCacheInfo.jn": "v1"})
+	defer os.RemoveAll(dir)
+	if _, err := publisher.PublishIndex(dir); err != nil {
+		t.Fatalf("failed to publish index: %v", err)
+	}
+	if _, err := reader.GetIndex("follower", "firstCacheInfo.jn"); err != ErrNotFound {
+		t.Fatalf("unpublished index: have %v, want %v", err, ErrNotFound)
+	}
+	// Republishing replaces the index seen by other nodes
+	ioutil.WriteFile(filepath.Join(dir, "firstCacheInfo.jn"), []byte("v2"), 0644)
+	if _, err := publisher.PublishIndex(dir); err != nil {
+		t.Fatalf("failed to republish index: %v", err)
+	}
+	content, err := reader.GetIndex("broadcast", "firstCacheInfo.jn")
+	if err != nil || string(content) != "v2" {
+		t.Fatalf("shared index mismatch: have %q, %v", content, err)
+	}
+}
+
+func TestLocalArchiveErrors(t *testing.T) {
+	a, root := newTestLocal(t, "")
+	defer os.RemoveAll(root)
+
+	if err := a.GetBlockBatch("../../etc/passwd", ioutil.Discard); err != ErrInvalidHash {
+		t.Fatalf("invalid hash: have %v, want %v", err, ErrInvalidHash)
+	}
+	missing := encodeHash(make([]byte, 32))
+	if err := a.GetBlockBatch(missing, ioutil.Discard); err != ErrNotFound {
+		t.Fatalf("missing object: have %v, want %v", err, ErrNotFound)
+	}
+	if _, err := a.PublishIndex(root); err != ErrNoPublisher {
+		t.Fatalf("publish without id: have %v, want %v", err, ErrNoPublisher)
+	}
+	hash, err := a.PutBlockBatch(strings.NewReader("original"))
+	if err != nil {
+		t.Fatalf("failed to put block batch: %v", err)
+	}
+	if err := ioutil.WriteFile(a.objectPath(hash), []byte("tampered"), 0644); err != nil {
+		t.Fatalf("failed to tamper object: %v", err)
+	}
+	if err := a.GetBlockBatch(hash, ioutil.Discard); err != ErrCorrupt {
+		t.Fatalf("corrupt object: have %v, want %v", err, ErrCorrupt)
+	}
+}
+
+// fakeAPI serves the subset of the ipfs http api used by HTTPArchive, backed
+// by a local archive.
+type fakeAPI struct {
+	store *LocalArchive
+	peer  string
+	lock  sync.Mutex
+	dirs  map[string]map[string]string
+	names map[string]string
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	arg := r.URL.Query().Get("arg")
+	switch strings.TrimPrefix(r.URL.Path, "/api/v0/") {
+	case "add":
+		mr, err := r.MultipartReader()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		dir := make(map[string]string)
+		enc := json.NewEncoder(w)
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				break
+			}
+			hash, err := f.store.PutBlockBatch(part)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			dir[part.FileName()] = hash
+			enc.Encode(addResponse{Name: part.FileName(), Hash: hash})
+		}
+		if r.URL.Query().Get("wrap-with-directory") == "true" {
+			blob, _ := json.Marshal(dir)
+			hash, _ := f.store.PutBlockBatch(bytes.NewReader(blob))
+			f.dirs[hash] = dir
+			enc.Encode(addResponse{Hash: hash})
+		}
+	case "name/publish":
+		f.names[f.peer] = strings.TrimPrefix(arg, "/ipfs/")
+	case "cat":
+		parts := strings.Split(strings.TrimPrefix(arg, "/"), "/")
+		hash := parts[1]
+		if parts[0] == "ipns" {
+			hash = f.dirs[f.names[parts[1]]][parts[2]]
+		}
+		if err := f.store.GetBlockBatch(hash, w); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(errorResponse{Message: err.Error()})
+		}
+	case "ls":
+		var ls lsResponse
+		ls.Objects = make([]struct {
+			Hash  string
+			Links []struct{ Name, Hash string }
+		}, 1)
+		dir := f.dirs[f.names[strings.TrimPrefix(arg, "/ipns/")]]
+		for _, name := range []string{"firstCacheInfo.jn", "lastestblockInfo.gb"} {
+			if hash, ok := dir[name]; ok {
+				ls.Objects[0].Links = append(ls.Objects[0].Links, struct{ Name, Hash string }{name, hash})
+			}
+		}
+		json.NewEncoder(w).Encode(ls)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestHTTPArchive(t *testing.T) {
+	store, root := newTestLocal(t, "")
+	defer os.RemoveAll(root)
+	srv := httptest.NewServer(&fakeAPI{
+		store: store,
+		peer:  "QmPeer",
+		dirs:  make(map[string]map[string]string),
+		names: make(map[string]string),
+	})
+	defer srv.Close()
+
+	a := NewHTTPArchive(srv.URL, 0)
+	testArchive(t, a, "QmPeer")
+
+	missing := encodeHash(make([]byte, 32))
+	if err := a.GetBlockBatch(missing, ioutil.Discard); err == nil || !strings.Contains(err.Error(), ErrNotFound.Error()) {
+		t.Fatalf("missing object: have %v", err)
+	}
+}
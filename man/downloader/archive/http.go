@@ -0,0 +1,264 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package archive
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultHTTPTimeout bounds a single request against the archive api.
+const DefaultHTTPTimeout = 16 * time.Minute
+
+// HTTPArchive is a client for the http api of an ipfs compatible
+// content-addressed store (the /api/v0 endpoints of an ipfs daemon).
+type HTTPArchive struct {
+	api    string
+	client *http.Client
+}
+
+// NewHTTPArchive creates a client for the api served at endpoint, e.g.
+// "http://127.0.0.1:5001".
+func NewHTTPArchive(endpoint string, timeout time.Duration) *HTTPArchive {
+	if timeout <= 0 {
+		timeout = DefaultHTTPTimeout
+	}
+	return &HTTPArchive{
+		api:    strings.TrimRight(endpoint, "/") + "/api/v0/",
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+type addResponse struct {
+	Name string
+	Hash string
+}
+
+type lsResponse struct {
+	Objects []struct {
+		Hash  string
+		Links []struct {
+			Name string
+			Hash string
+		}
+	}
+}
+
+type errorResponse struct {
+	Message string
+}
+
+// PutBlockBatch stores a block batch and returns its hash.
+func (a *HTTPArchive) PutBlockBatch(r io.Reader) (string, error) {
+	return a.add(r)
+}
+
+// GetBlockBatch writes the block batch stored under hash into w.
+func (a *HTTPArchive) GetBlockBatch(hash string, w io.Writer) error {
+	return a.cat("/ipfs/"+hash, w)
+}
+
+// PutSnapshot stores a snapshot and returns its hash.
+func (a *HTTPArchive) PutSnapshot(r io.Reader) (string, error) {
+	return a.add(r)
+}
+
+// GetSnapshot writes the snapshot stored under hash into w.
+func (a *HTTPArchive) GetSnapshot(hash string, w io.Writer) error {
+	return a.cat("/ipfs/"+hash, w)
+}
+
+// PublishIndex adds the regular files of dir wrapped into one directory and
+// publishes that directory under the peer id of the daemon.
+func (a *HTTPArchive) PublishIndex(dir string) (string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+	for _, fi := range files {
+		if !fi.Mode().IsRegular() {
+			continue
+		}
+		part, err := mw.CreateFormFile("file", fi.Name())
+		if err != nil {
+			return "", err
+		}
+		f, err := os.Open(filepath.Join(dir, fi.Name()))
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(part, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return "", err
+	}
+	query := url.Values{"wrap-with-directory": {"true"}, "cid-version": {"0"}}
+	resp, err := a.post("add", query, mw.FormDataContentType(), body)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// The response holds one object per added file, the wrapping directory last
+	var dirHash string
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var added addResponse
+		if err := dec.Decode(&added); err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+		dirHash = added.Hash
+	}
+	if dirHash == "" {
+		return "", fmt.Errorf("archive: publish %s: no directory hash", dir)
+	}
+	resp, err = a.post("name/publish", url.Values{"arg": {"/ipfs/" + dirHash}}, "", nil)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return dirHash, nil
+}
+
+// GetIndex returns the named index file published by publisher.
+func (a *HTTPArchive) GetIndex(publisher, name string) ([]byte, error) {
+	if publisher == "" {
+		return nil, ErrNoPublisher
+	}
+	buf := new(bytes.Buffer)
+	if err := a.cat("/ipns/"+publisher+"/"+name, buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ListIndex returns the names of the index files published by publisher.
+func (a *HTTPArchive) ListIndex(publisher string) ([]string, error) {
+	if publisher == "" {
+		return nil, ErrNoPublisher
+	}
+	resp, err := a.post("ls", url.Values{"arg": {"/ipns/" + publisher}}, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var ls lsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&ls); err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, obj := range ls.Objects {
+		for _, link := range obj.Links {
+			names = append(names, link.Name)
+		}
+	}
+	return names, nil
+}
+
+// Bootstrap replaces the bootstrap peers of the daemon.
+func (a *HTTPArchive) Bootstrap(peers []string) error {
+	resp, err := a.post("bootstrap/rm/all", nil, "", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	for _, peer := range peers {
+		resp, err := a.post("bootstrap/add", url.Values{"arg": {peer}}, "", nil)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+	}
+	return nil
+}
+
+func (a *HTTPArchive) add(r io.Reader) (string, error) {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		part, err := mw.CreateFormFile("file", "file")
+		if err == nil {
+			_, err = io.Copy(part, r)
+		}
+		if err == nil {
+			err = mw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	query := url.Values{"quiet": {"true"}, "chunker": {"size-1048576"}, "cid-version": {"0"}}
+	resp, err := a.post("add", query, mw.FormDataContentType(), pr)
+	pr.Close()
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var added addResponse
+	if err := json.NewDecoder(resp.Body).Decode(&added); err != nil {
+		return "", err
+	}
+	if len(added.Hash) != HashLen {
+		return "", ErrInvalidHash
+	}
+	return added.Hash, nil
+}
+
+func (a *HTTPArchive) cat(path string, w io.Writer) error {
+	resp, err := a.post("cat", url.Values{"arg": {path}}, "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// post issues an api call and turns non-200 responses into errors.
+func (a *HTTPArchive) post(cmd string, query url.Values, contentType string, body io.Reader) (*http.Response, error) {
+	endpoint := a.api + cmd
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequest("POST", endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var apiErr errorResponse
+		blob, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(blob, &apiErr) == nil && apiErr.Message != "" {
+			return nil, fmt.Errorf("archive: %s: %s", cmd, apiErr.Message)
+		}
+		return nil, fmt.Errorf("archive: %s: %s", cmd, resp.Status)
+	}
+	return resp, nil
+}
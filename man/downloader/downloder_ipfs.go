@@ -8,15 +8,14 @@ import (
 	"bytes"
 	"compress/gzip"
 	"container/list"
-	"context"
 	"encoding/binary"
 	"encoding/gob"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
-	"os/exec"
 	"path"
 	"path/filepath"
 	"strconv"
@@ -27,12 +26,13 @@ import (
 
 	"github.com/MatrixAINetwork/go-matrix/core/types"
 	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/man/downloader/archive"
 	"github.com/MatrixAINetwork/go-matrix/rlp"
 	"gopkg.in/karalabe/cookiejar.v2/collections/prque"
 )
 
 const (
-	IpfsHashLen                 = 46
+	IpfsHashLen                 = archive.HashLen
 	LastestBlockStroeNum        = 100    //100
 	Cache2StoreHashMaxNum       = 216000 //每月产生的区块//测试 2000  //要改成月216000 //2628000 //24 hour* (3600 second /12 second）*365
 	Cache1StoreCache2Num        = 6000   //500年的 //测试10000  //改成12000 1000年
@@ -92,9 +92,7 @@ type IPfsDownloader struct {
 	IpfsDealBlocking    int32
 	StrIpfspeerID       string
 	StrIpfsSecondpeerID string
-	StrIPFSLocationPath string
 	StrIPFSServerInfo   string
-	StrIPFSExecName     string
 	HeaderIpfsCh        chan []BlockIpfsReq //[]*types.Header
 	//	BlockRcvCh          chan *types.Block
 	//runQuit      chan struct{}
@@ -126,6 +124,11 @@ var (
 	strBatchReceiptFile   = "batchreceipt.rp"
 	strTmpBatchCache2File = "secondBatchCacheInfo.gb"
 )
+var errArchiveNotReady = errors.New("ipfs block archive not initialized")
+
+// defaultArchiveAPI is the api address of a local ipfs daemon.
+const defaultArchiveAPI = "http://127.0.0.1:5001"
+
 var HeadBatchFlag uint64 = 0x12345678
 var BodyBatchFlag uint64 = 0x23456781
 var ReceiptBatchFlag uint64 = 0x34567812
@@ -145,7 +148,9 @@ type StoreIpfsCache struct {
 }
 type DownloadFileInfo struct {
 	Downloadflg          bool
-	IpfsPath             string
+	ArchiveAPI           string // http api of the content-addressed store, e.g. http://127.0.0.1:5001
+	ArchiveDir           string // local content-addressed store, used instead of ArchiveAPI if set
+	ArchiveID            string // publisher id in ArchiveDir, defaults to PrimaryDescription
 	StrIPFSServerInfo    string
 	StrIPFSServer2Info   string
 	StrIPFSServer3Info   string
@@ -171,9 +176,6 @@ type BatchBlockSt struct {
 	bodyStoreFile      *os.File
 	receiptStoreFile   *os.File
 }
-type StopIpfs struct {
-	Stop func()
-}
 type IPFSBlockStat struct {
 	gIPFSerrorNum          int
 	curBlockNum            uint64
@@ -185,18 +187,13 @@ type IPFSBlockStat struct {
 	totalZipSnapDataSize   int64
 }
 
-var StopIpfsHandler = StopIpfs{}
-
 var gIpfsCache GetIpfsCache
 var gIpfsStoreCache StoreIpfsCache
 var IpfsInfo DownloadFileInfo
 var logMap bool
 var listPeerId [2]string
 var testShowlog int
-var gIpfsPath string
-var runQuit chan int         //struct{}
-var timeOutFlg int           //chan int
-var gtimeOutSign chan string //struct{}
+var gArchive archive.BlockArchive
 var gIpfsStat IPFSBlockStat
 var gIpfsProcessBlockNumber uint64
 
@@ -213,12 +210,11 @@ func init() {
 	//err :=
 	ReadJsFile("ipfsinfo.json", &IpfsInfo)
 	//fmt.Println("read ipfs ", err, IpfsInfo.Downloadflg, IpfsInfo.StrIPFSServerInfo)
-	if /*IpfsInfo.IpfsPath == "" ||*/ IpfsInfo.StrIPFSServerInfo == "" || IpfsInfo.PrimaryDescription == "" {
+	if IpfsInfo.ArchiveAPI == "" && IpfsInfo.ArchiveDir == "" && IpfsInfo.StrIPFSServerInfo != "" {
+		IpfsInfo.ArchiveAPI = defaultArchiveAPI
+	}
+	if (IpfsInfo.ArchiveAPI == "" && IpfsInfo.ArchiveDir == "") || IpfsInfo.PrimaryDescription == "" {
 		IpfsInfo.Downloadflg = false
-	} else {
-		runQuit = make(chan int)         //struct{})
-		gtimeOutSign = make(chan string) //struct{})
-		//timeOutCh = make(chan int)
 	}
 }
 func GetIpfsMode() bool {
@@ -261,269 +257,62 @@ func GetFileSize(filename string) int64 {
 	})
 	return result
 }
-func (d *Downloader) IpfsDownloadTestInit() error {
-
-	CheckDirAndCreate(strCacheDirectory)
-	//
-	d.dpIpfs.StrIPFSLocationPath = "D:\\lb\\go-ipfs"
-	d.dpIpfs.StrIPFSExecName = d.dpIpfs.StrIPFSLocationPath + "\\ipfs.exe"
-	//gStrIpfsPath = d.dpIpfs.StrIPFSLocationPath
-	gIpfsPath = d.dpIpfs.StrIPFSExecName
-	d.dpIpfs.StrIPFSServerInfo = "/ip4/192.168.3.30/tcp/4001/ipfs/QmQSazdGapokSejxeTTQc4tCRcHgqRPtoMeW3trRk4zA1S"
-	return nil // foe test
+// newBlockArchive opens the archive configured in ipfsinfo.json. A local
+// directory takes precedence over the http api.
+func newBlockArchive() (archive.BlockArchive, error) {
+	if IpfsInfo.ArchiveDir != "" {
+		id := IpfsInfo.ArchiveID
+		if id == "" {
+			id = IpfsInfo.PrimaryDescription
+		}
+		return archive.NewLocalArchive(IpfsInfo.ArchiveDir, id)
+	}
+	return archive.NewHTTPArchive(IpfsInfo.ArchiveAPI, archive.DefaultHTTPTimeout), nil
 }
 
-func StopIpfsProcess() {
-	log.Warn("ipfs Downloader StopIpfsProcess  manual exit")
-	StopIpfsHandler.Stop()
-	fmt.Println("ipfs manual exit")
+// SetBlockArchive replaces the archive used by the ipfs download path.
+func (d *Downloader) SetBlockArchive(a archive.BlockArchive) {
+	gArchive = a
 }
 
-var strAnother = "The process cannot access the file because it is being used by another process"
-var strIPFSstdErr = "api not running"
-var strIPFSstd2Err = "routing: not found"
-var strIPFSpatherr = "file does not exist"
-
 func (d *Downloader) IpfsDownloadInit() error {
-
-	var out bytes.Buffer
-	var outerr bytes.Buffer
 	// Directory
 	CheckDirAndCreate(strCacheDirectory)
-	//fmt.Println("IpfsDownloadInit enter")
-	//
-	d.dpIpfs.StrIPFSLocationPath = IpfsInfo.IpfsPath      //"D:\\lb\\go-ipfs"
-	d.dpIpfs.StrIPFSExecName = IpfsInfo.IpfsPath + "ipfs" //d.dpIpfs.StrIPFSLocationPath + "\\ipfs.exe"
-	gIpfsPath = d.dpIpfs.StrIPFSExecName                  //gStrIpfsPath = d.dpIpfs.StrIPFSLocationPath
 	d.dpIpfs.StrIpfspeerID = IpfsInfo.PrimaryDescription
 	d.dpIpfs.StrIpfsSecondpeerID = IpfsInfo.SecondaryDescription
-	d.dpIpfs.StrIPFSServerInfo = IpfsInfo.StrIPFSServerInfo //"/ip4/192.168.3.30/tcp/4001/ipfs/QmQSazdGapokSejxeTTQc4tCRcHgqRPtoMeW3trRk4zA1S"
-	//	return nil // foe test
+	d.dpIpfs.StrIPFSServerInfo = IpfsInfo.StrIPFSServerInfo
 	listPeerId[0] = d.dpIpfs.StrIpfspeerID
 	listPeerId[1] = d.dpIpfs.StrIpfsSecondpeerID
-	if d.dpIpfs.StrIpfsSecondpeerID != "" {
-		listPeerId[1] = d.dpIpfs.StrIpfsSecondpeerID
-	}
-	fmt.Println("peer ID ", listPeerId[0], listPeerId[1])
 	log.Warn("ipfs Downloader init", "peerid0", listPeerId[0], "peerid1", listPeerId[1])
-	//d.dpIpfs.BatchStBlock = new(BatchBlockSt)
-	out.Reset()
-	outerr.Reset()
-	c := exec.Command(d.dpIpfs.StrIPFSExecName, "init")
-	c.Stdout = &out
-	c.Stderr = &outerr
-	err := c.Run()
-
-	strErrInfo := outerr.String()
-	strttt := err.Error()
-	if err != nil {
-		log.Warn("ipfs IpfsDownloadInit init error", "error", err, "ipfs err", strErrInfo) //, "fff", strttt)
-		//return err
-		if strings.Index(strttt, strIPFSpatherr) > 0 {
+
+	if gArchive == nil {
+		a, err := newBlockArchive()
+		if err != nil {
+			log.Error("ipfs IpfsDownloadInit open archive error", "error", err)
 			d.IpfsMode = false //启动失败时 置为false
 			IpfsInfo.Downloadflg = false
 			d.bIpfsDownload = 0
-			log.Warn("ipfs IpfsDownloadInit init error", strttt)
-			return nil
-		}
-	}
-	out.Reset()
-	outerr.Reset()
-	c = exec.Command(d.dpIpfs.StrIPFSExecName, "bootstrap", "rm", "all")
-	err = c.Run()
-	strErrInfo = outerr.String()
-
-	if err != nil {
-		log.Error("ipfs IpfsDownloadInit bootstrap rm error", "error", err, "ipfs err", strErrInfo)
-		//return err
-	}
-	out.Reset()
-	outerr.Reset()
-	c = exec.Command(d.dpIpfs.StrIPFSExecName, "bootstrap", "add", d.dpIpfs.StrIPFSServerInfo) //"/ip4/192.168.3.30/tcp/4001/ipfs/QmQSazdGapokSejxeTTQc4tCRcHgqRPtoMeW3trRk4zA1S")
-	err = c.Run()
-	strErrInfo = outerr.String()
-
-	if err != nil {
-		log.Error("ipfs IpfsDownloadInit bootstrap add error", "error", err, "ipfs err", strErrInfo)
-		//return err
-	}
-	if IpfsInfo.StrIPFSServer2Info != "" {
-		c = exec.Command(d.dpIpfs.StrIPFSExecName, "bootstrap", "add", IpfsInfo.StrIPFSServer2Info)
-		err = c.Run()
-	}
-	if IpfsInfo.StrIPFSServer3Info != "" {
-		c = exec.Command(d.dpIpfs.StrIPFSExecName, "bootstrap", "add", IpfsInfo.StrIPFSServer3Info)
-		err = c.Run()
-	}
-	if IpfsInfo.StrIPFSServer4Info != "" {
-		c = exec.Command(d.dpIpfs.StrIPFSExecName, "bootstrap", "add", IpfsInfo.StrIPFSServer4Info)
-		err = c.Run()
-	}
-	if IpfsInfo.StrIPFSServer5Info != "" {
-		c = exec.Command(d.dpIpfs.StrIPFSExecName, "bootstrap", "add", IpfsInfo.StrIPFSServer5Info)
-		err = c.Run()
-	}
-	if IpfsInfo.StrIPFSServer6Info != "" {
-		c = exec.Command(d.dpIpfs.StrIPFSExecName, "bootstrap", "add", IpfsInfo.StrIPFSServer6Info)
-		err = c.Run()
-	}
-	if IpfsInfo.StrIPFSServer7Info != "" {
-		c = exec.Command(d.dpIpfs.StrIPFSExecName, "bootstrap", "add", IpfsInfo.StrIPFSServer7Info)
-		err = c.Run()
-	}
-	if IpfsInfo.StrIPFSServer8Info != "" {
-		c = exec.Command(d.dpIpfs.StrIPFSExecName, "bootstrap", "add", IpfsInfo.StrIPFSServer8Info)
-		err = c.Run()
-	}
-	if IpfsInfo.StrIPFSServer9Info != "" {
-		c = exec.Command(d.dpIpfs.StrIPFSExecName, "bootstrap", "add", IpfsInfo.StrIPFSServer9Info)
-		err = c.Run()
-	}
-	if IpfsInfo.StrIPFSServer10Info != "" {
-		c = exec.Command(d.dpIpfs.StrIPFSExecName, "bootstrap", "add", IpfsInfo.StrIPFSServer10Info)
-		err = c.Run()
-	}
-
-	out.Reset()
-	outerr.Reset()
-
-	fmt.Println("ipfs daemon run", d.dpIpfs.StrIPFSExecName)
-	/*
-		c = exec.Command(d.dpIpfs.StrIPFSExecName, "daemon") //"add", "D:\\melog3332.txt")
-		d.dpIpfs.BIpfsIsRunning = true
-		err = c.Run()
-	*/
-
-	ctx, cancel := context.WithCancel(context.Background())
-	StopIpfsHandler.Stop = cancel
-	//err = exec.CommandContext(ctx, d.dpIpfs.StrIPFSExecName, "daemon").Run()
-	cm := exec.CommandContext(ctx, d.dpIpfs.StrIPFSExecName, "daemon")
-	cm.Stdout = &out
-	cm.Stderr = &outerr
-	err = cm.Run()
-	strErrInfo = outerr.String()
-
-	d.dpIpfs.BIpfsIsRunning = false
-	if err != nil {
-		log.Error("ipfs IpfsDownloadInit daemon error,exit init", "error", err, "out", out.String(), "ipfs err", outerr.String())
-		//return err
-	} //"The process cannot access the file because it is being used by another process"
-	if strings.Index(strErrInfo, strAnother) >= 0 {
-
-	} else {
-		/*d.IpfsMode = false //启动失败时 置为false
-		IpfsInfo.Downloadflg = false
-		d.bIpfsDownload = 0*/
-	}
-	fmt.Println("ipfsDownloadInit error", err)
-
-	return nil
-}
-func RestartIpfsDaemon() {
-	var outerr bytes.Buffer
-	var out bytes.Buffer
-
-	StopIpfsProcess()
-	//time.Sleep(1 * time.Second)
-	log.Warn("ipfs RestartIpfsDaemon daemon")
-	ctx, cancel := context.WithCancel(context.Background())
-	StopIpfsHandler.Stop = cancel
-	flgCh := make(chan int)
-	go func() {
-		fmt.Println("ipfs restart again", gIpfsPath)
-		cd := exec.CommandContext(ctx, gIpfsPath, "daemon")
-		cd.Stdout = &out
-		cd.Stderr = &outerr
-		log.Warn("ipfs RestartIpfsDaemon daemon again")
-		flgCh <- 0
-		err := cd.Run()
-		//strErrInfo := outerr.String()
-		//d.dpIpfs.BIpfsIsRunning = true
-		if err != nil {
-			log.Error("ipfs RestartIpfsDaemon daemon error, will exit", "error", err, "out", out.String(), "outerr", outerr.String())
-			//return err
-		}
-		//d.IpfsMode = false //启动失败时 置为false
-		fmt.Println("****RestartIpfs Daemon will exit", err, out.String(), outerr.String())
-	}()
-
-	<-flgCh
-	log.Warn("ipfs---- RestartIpfsDaemon daemon over")
-	fmt.Println("ipfs ---restart over")
-	time.Sleep(20 * time.Second)
-	log.Warn("ipfs---- RestartIpfsDaemon daemon sleep over")
-}
-func CheckIpfsStatus(err error) {
-	//log.Warn("ipfs---- CheckIpfsStatus--------")
-	if err.Error() == "exit status 1" {
-		RestartIpfsDaemon()
-	}
-
-}
-
-func ipfsGetTimeout() {
-	//return
-	var flg int
-	recvSync := time.NewTicker(8 * time.Minute)
-	defer func() {
-		log.Warn("ipfs---- ipfsGetTimeout out--------", "flg", flg)
-		recvSync.Stop()
-	}()
-	//log.Warn("ipfs---- ipfsGetTimeout in")
-	//quit    chan struct{}
-	for {
-		select {
-		case <-recvSync.C:
-			log.Warn("ipfs---- ipfsGetTimeout")
-			flg = 1
-			RestartIpfsDaemon()
-			timeOutFlg = 1
-		case <-runQuit:
-			flg = 0
-			return
-		}
-	}
-}
-func TimeoutExec(str string) {
-	var flg int
-	timeOut := time.NewTicker(16 * time.Minute)
-	defer func() {
-		log.Warn("ipfs---- ipfsTimeoutExec out--------", "flg", flg, "hash", str)
-		timeOut.Stop()
-	}()
-	for {
-		select {
-		case <-timeOut.C:
-			log.Warn("ipfs---- TimeoutExec time out")
-			flg = 1
-			RestartIpfsDaemon()
-			timeOutFlg = 1
-		case <-runQuit:
-			flg = 0
-			return
+			return err
 		}
+		gArchive = a
 	}
-}
-func IpfsStartTimer(hash string) {
-	timeOutFlg = 0
-	gtimeOutSign <- hash //- struct{}{}
-}
-func IpfsStopTimer() {
-	//超时的不需要再停止
-	if timeOutFlg == 0 {
-		runQuit <- 1
-	}
-}
-func (d *Downloader) IpfsTimeoutTask() {
-	log.Warn("IpfsTimeoutTask enter in")
-	for {
-		select {
-		case str := <-gtimeOutSign:
-			timeOutFlg = 0
-			TimeoutExec(str)
+	// Bootstrap peers only apply to an ipfs daemon behind the http api
+	if b, ok := gArchive.(interface{ Bootstrap([]string) error }); ok && d.dpIpfs.StrIPFSServerInfo != "" {
+		peers := []string{d.dpIpfs.StrIPFSServerInfo}
+		for _, peer := range []string{IpfsInfo.StrIPFSServer2Info, IpfsInfo.StrIPFSServer3Info, IpfsInfo.StrIPFSServer4Info,
+			IpfsInfo.StrIPFSServer5Info, IpfsInfo.StrIPFSServer6Info, IpfsInfo.StrIPFSServer7Info,
+			IpfsInfo.StrIPFSServer8Info, IpfsInfo.StrIPFSServer9Info, IpfsInfo.StrIPFSServer10Info} {
+			if peer != "" {
+				peers = append(peers, peer)
+			}
 		}
+		go func() {
+			if err := b.Bootstrap(peers); err != nil {
+				log.Error("ipfs IpfsDownloadInit bootstrap error", "error", err)
+			}
+		}()
 	}
-
+	return nil
 }
 func (d *Downloader) dealIPFSerrorProc() {
 	if gIpfsStat.gIPFSerrorNum > 10 {
@@ -588,7 +377,7 @@ func storeCache(data interface{}, file *os.File) error {
 	err := encoder.Encode(data)
 	if err != nil {
 		log.Error("error store gob Encode error", "error", err)
-		return fmt.Errorf("ipfs error store gob Encode error", err)
+		return fmt.Errorf("ipfs error store gob Encode error: %v", err)
 	}
 	_, err = file.Write(buffer.Bytes())
 	return err
@@ -602,7 +391,7 @@ func loadCache(data interface{}, len int32, file *os.File) error {
 	rawBuf, err := ioutil.ReadAll(file)
 	if err != nil {
 		log.Error("ipfs loadCache load file read error", "error", err)
-		return fmt.Errorf("ipfs load file read error", err)
+		return fmt.Errorf("ipfs load file read error: %v", err)
 	}
 	buffer := bytes.NewBuffer(rawBuf)
 	dec := gob.NewDecoder(buffer)
@@ -610,7 +399,7 @@ func loadCache(data interface{}, len int32, file *os.File) error {
 	if err != nil {
 		len := GetFileSize(file.Name())
 		log.Error("ipfs error store gob decode error", "error", err, "fileSize", len)
-		return fmt.Errorf("ipfs error store gob decode error", err)
+		return fmt.Errorf("ipfs error store gob decode error: %v", err)
 	}
 	return nil
 }
@@ -696,60 +485,72 @@ func DeCompressFile(Dst string, Src string) error {
 	return nil
 }
 
-// IpfsGetBlockByHash get block
-func IpfsGetBlockByHash(strHash string, compress bool) (*os.File, error) {
-	var out bytes.Buffer
-	var outerr bytes.Buffer
-	var fileName string
-	//log.Debug("ipfs IpfsGetBlockByHash info before", "strHash", strHash)
+// ipfsGetFile fetches an object from the archive into the file fileName.
+func ipfsGetFile(get func(string, io.Writer) error, strHash string, fileName string) error {
+	if gArchive == nil {
+		return errArchiveNotReady
+	}
+	file, err := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	err = get(strHash, file)
+	file.Close()
+	if err != nil {
+		os.Remove(fileName)
+	}
+	return err
+}
+
+// ipfsGetByHash fetches an object into a file named after its hash and
+// decompresses it into hash+".unzip" if needed
+func ipfsGetByHash(get func(string, io.Writer) error, strHash string, compress bool) (*os.File, error) {
 	if strHash == "" {
 		log.Error("ipfs IpfsGetBlockByHash strHash error", "strHash", strHash)
 		gIpfsStat.gIPFSerrorNum++
 		return nil, fmt.Errorf("IpfsGetBlockByHash strHash error")
 	}
-	c := exec.Command(gIpfsPath, "get", strHash)
-	c.Stdout = &out
-	c.Stderr = &outerr
-	timeOutFlg = 0
-	//go ipfsGetTimeout()
-	IpfsStartTimer(strHash)
-	//	log.Trace("ipfs IpfsGetBlockByHash run in")
-	err := c.Run()
-	log.Trace("ipfs IpfsGetBlockByHash run out", "strHash", strHash)
-	//runQuit <- 1 //struct{}{}
-	IpfsStopTimer()
-	//c.StdinPipe()
-	//strErrInfo := outerr.String()
-
+	err := ipfsGetFile(get, strHash, strHash)
 	log.Debug("ipfs IpfsGetBlockByHash info", "error", err, "strHash", strHash)
-
 	if err != nil {
-		log.Error("ipfs IpfsGetBlockByHash error", "error", err, "ipfs err", outerr.String())
+		log.Error("ipfs IpfsGetBlockByHash error", "error", err)
 		gIpfsStat.gIPFSerrorNum++
-		if timeOutFlg == 0 {
-			CheckIpfsStatus(err)
-		}
 		return nil, err
 	}
-
 	gIpfsStat.gIPFSerrorNum = 0
 
-	fileName = strHash
+	fileName := strHash
 	if compress == true {
 		fileName = strHash + ".unzip"
 		DeCompressFile(fileName, strHash)
 		os.Remove(strHash)
 	}
 	return os.OpenFile(fileName, os.O_RDONLY /*|os.O_APPEND*/, 0644)
+}
 
+// IpfsGetBlockByHash get block
+func IpfsGetBlockByHash(strHash string, compress bool) (*os.File, error) {
+	if gArchive == nil {
+		return nil, errArchiveNotReady
+	}
+	return ipfsGetByHash(gArchive.GetBlockBatch, strHash, compress)
 }
 
-//IpfsAddNewFile
-func IpfsAddNewFile(filePath string, compress bool) (Hash, int64, error) {
-	var out bytes.Buffer
-	var outerr bytes.Buffer
+// IpfsGetSnapshotByHash get snapshot
+func IpfsGetSnapshotByHash(strHash string, compress bool) (*os.File, error) {
+	if gArchive == nil {
+		return nil, errArchiveNotReady
+	}
+	return ipfsGetByHash(gArchive.GetSnapshot, strHash, compress)
+}
+
+// ipfsAddFile stores the (optionally compressed) file with put
+func ipfsAddFile(put func(io.Reader) (string, error), filePath string, compress bool) (Hash, int64, error) {
 	var addfilePath string = filePath
 	var zipfilesize int64
+	if gArchive == nil {
+		return nil, 0, errArchiveNotReady
+	}
 	if compress == true {
 		addfilePath = filePath + ".zip"
 		err := CompressFile(addfilePath, filePath)
@@ -763,35 +564,40 @@ func IpfsAddNewFile(filePath string, compress bool) (Hash, int64, error) {
 		zipfilesize = fhandler.Size()
 
 	}
-	c := exec.Command(gIpfsPath, "add", "-q", "-s", "size-1048576", addfilePath) //1M
-	c.Stdout = &out
-	c.Stderr = &outerr
-	err := c.Run()
-	//strErrInfo := outerr.String()
-
+	file, err := os.Open(addfilePath)
+	if err != nil {
+		return nil, zipfilesize, err
+	}
+	defer file.Close()
 	log.Trace("ipfs IpfsAddNewFile to ipfs network", "filePath", addfilePath)
 
+	hash, err := put(file)
 	if err != nil {
-		log.Error("ipfs IpfsAddNewFile to  ipfs network", "error", err, "ipfs err", outerr.String())
+		log.Error("ipfs IpfsAddNewFile to  ipfs network", "error", err)
+		return nil, zipfilesize, err
+	}
+	return Hash(hash), zipfilesize, nil
+}
 
-		RestartIpfsDaemon()
-		c = exec.Command(gIpfsPath, "add", "-q", "-s", "size-1048576", addfilePath)
-		err = c.Run()
-		log.Error("ipfs IpfsAddNewFile to  ipfs network error again")
-		if err != nil {
-			log.Error("ipfs IpfsAddNewFile to  ipfs network error again", "error", err, "ipfs err", outerr.String())
-			return nil, zipfilesize, err
-		}
-		//return nil, err
+//IpfsAddNewFile
+func IpfsAddNewFile(filePath string, compress bool) (Hash, int64, error) {
+	if gArchive == nil {
+		return nil, 0, errArchiveNotReady
 	}
-	return out.Bytes(), zipfilesize, nil
+	return ipfsAddFile(gArchive.PutBlockBatch, filePath, compress)
+}
+
+//IpfsAddNewSnapshot
+func IpfsAddNewSnapshot(filePath string, compress bool) (Hash, int64, error) {
+	if gArchive == nil {
+		return nil, 0, errArchiveNotReady
+	}
+	return ipfsAddFile(gArchive.PutSnapshot, filePath, compress)
 }
 
 //IpfsGetFileCache2ByHash
 
 func IpfsGetFileCache2ByHash(strhash, objfileName string) (*os.File, bool, error) {
-	var out bytes.Buffer
-	var outerr bytes.Buffer
 	if strhash == "" {
 		//var errf error = nil
 		tmpBlockFile, errf := os.OpenFile(objfileName, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0644) //"secondCacheInfo.gb"创建新文件
@@ -801,28 +607,13 @@ func IpfsGetFileCache2ByHash(strhash, objfileName string) (*os.File, bool, error
 			return tmpBlockFile, true, nil
 		}
 	}
-
-	//tmp := []byte(strhash)
-	//strhash2 := string(tmp[0:IpfsHashLen])
-	strFile := "-o=" + objfileName
-	c := exec.Command(gIpfsPath, "get", strFile, strhash)
-	//c := exec.Command(gStrIpfsName, "get", "-o=secondCacheInfo.gb", strhash) //strhash)
-	//go ipfsGetTimeout()
-	IpfsStartTimer(strhash)
-	c.Stdout = &out
-	c.Stderr = &outerr
-	err := c.Run()
-	//runQuit <- 1
-	IpfsStopTimer()
-	//strErrInfo := outerr.String()
-	stdErr := outerr.String()
+	if gArchive == nil {
+		return nil, false, errArchiveNotReady
+	}
+	err := ipfsGetFile(gArchive.GetBlockBatch, strhash, objfileName)
 	if err != nil {
-		log.Error("ipfs IpfsGetFileCache2ByHash get error", "error", err, "ipfs err", outerr.String())
-		if strings.Index(stdErr, strIPFSstdErr) > 0 {
-			CheckIpfsStatus(err)
-		}
+		log.Error("ipfs IpfsGetFileCache2ByHash get error", "error", err)
 		gIpfsStat.gIPFSerrorNum++
-		//CheckIpfsStatus(err)
 		return nil, false, err
 	}
 	gIpfsStat.gIPFSerrorNum = 0
@@ -1085,81 +876,31 @@ func (d *Downloader) IpfsSyncSaveLatestBlock() {
 
 //IPfsDirectoryUpdate
 func (d *Downloader) IPfsDirectoryUpdate() error {
-	var out bytes.Buffer
-	var outerr bytes.Buffer
-
-	c := exec.Command(d.dpIpfs.StrIPFSExecName, "add", "-Q", "-r", strCacheDirectory)
-	c.Stdout = &out
-	c.Stderr = &outerr
-	//outbuf, err := c.Output()
-	err := c.Run()
-	//strErrInfo := outerr.String()
-	if err != nil {
-		log.Error("ipfs IPfsDirectoryUpdate add dictory error", "error", err, "ipfs err", outerr.String())
-		RestartIpfsDaemon()
-		c := exec.Command(d.dpIpfs.StrIPFSExecName, "add", "-Q", "-r", strCacheDirectory)
-		err := c.Run()
-		if err != nil {
-			log.Error("ipfs IPfsDirectoryUpdate add dictory error again", "error", err)
-			return err
-		}
+	if gArchive == nil {
+		return errArchiveNotReady
 	}
-
-	if out.Len() < IpfsHashLen {
-		log.Error("ipfs IPfsDirectoryUpdate add dictory error again", "error", err, "out.Len() ", out.Len(), "ipfs err", outerr.String())
-		return err
-	}
-	publishHash := string(out.Bytes()[0 : out.Len()-1]) //0：IpfsHashLen
-
-	out.Reset()
-	outerr.Reset()
-	//run
-	c = exec.Command(d.dpIpfs.StrIPFSExecName, "name", "publish", publishHash) //string(outbuf[:]))
-	IpfsStartTimer(publishHash)
-	err = c.Run()
-	IpfsStopTimer()
-	strErrInfo := outerr.String()
-
+	publishHash, err := gArchive.PublishIndex(strCacheDirectory)
 	if err != nil {
-		log.Error("ipfs IPfsDirectoryUpdate name publish error", "error", err, "publish", publishHash, "ipfs err", strErrInfo)
+		log.Error("ipfs IPfsDirectoryUpdate publish error", "error", err, "directory", strCacheDirectory)
 		return err
 	}
+	log.Trace("ipfs IPfsDirectoryUpdate publish", "publish", publishHash)
 	return nil
-	//IPFS  name  publish 	// ipfs name resolve  + peerID
 }
 
 //IpfsSyncGetFirstCache
 func (d *Downloader) IpfsSyncGetFirstCache(index int) (*Cache1StoreCfg, error) {
-
-	//var out bytes.Buffer
-	var outerr bytes.Buffer
-	//ipnsPath := "/ipns/" + d.dpIpfs.StrIpfspeerID + "/" + strCache1BlockFile //"firstCacheSync.ha"
-	ipnsPath := "/ipns/" + listPeerId[index] + "/" + strCache1BlockFile
-	c := exec.Command(d.dpIpfs.StrIPFSExecName, "cat", ipnsPath) //或cat
-	//c.Stdout = &out
-	//go ipfsGetTimeout()
-	IpfsStartTimer("first")
-	c.Stderr = &outerr
-	//err := c.Run()
-	outbuf, err := c.Output()
-	//runQuit <- 1
-	IpfsStopTimer()
-	//strErrInfo := outerr.String()
-
-	//new
 	curCache1Info := new(Cache1StoreCfg) // Cache1StoreCfg{}
-	stdErr := outerr.String()
+	if gArchive == nil {
+		return curCache1Info, errArchiveNotReady
+	}
+	outbuf, err := gArchive.GetIndex(listPeerId[index], strCache1BlockFile)
 	if err != nil {
-		log.Error("ipfs error IpfsSyncGetFirstCache error", "error", err, "ipfs err", outerr.String())
-		if strings.Index(stdErr, strIPFSstdErr) > 0 || strings.Index(stdErr, strIPFSstd2Err) > 0 {
-			CheckIpfsStatus(err)
-		}
+		log.Error("ipfs error IpfsSyncGetFirstCache error", "error", err)
 		gIpfsStat.gIPFSerrorNum++
-		//CheckIpfsStatus(err)
 		d.dealIPFSerrorProc()
 		return curCache1Info, err
 	}
-	//ReadJsFile()
 	gIpfsStat.gIPFSerrorNum = 0
 
 	err = json.Unmarshal(outbuf, curCache1Info)
@@ -1177,43 +918,21 @@ func (d *Downloader) IpfsSyncGetSecondCache() {
 
 //IpfsSyncGetLatestBlock
 func (d *Downloader) IpfsSyncGetLatestBlock(index int) (*LastestBlcokCfg, uint64, error) {
-	//var out bytes.Buffer
-	var outerr bytes.Buffer
-	//ipnsPath := "/ipns/" + d.dpIpfs.StrIpfspeerID + "/" +  //"lastestblock.ha"
-	ipnsPath := "/ipns/" + listPeerId[index] + "/" + strLastestBlockFile
-	//out.Reset()
-
-	//log.Error("ipfs IpfsSyncGetLatestBlock run cmd before", "listPeerId", listPeerId[index])
-
-	c := exec.Command(gIpfsPath, "get", "-o=ipfsCachecommon/", ipnsPath) //
-	//c.Stdout = &out
-	c.Stderr = &outerr
-	err := c.Run()
-	//outbuf, err := c.Output()
-	//log.Error("ipfs IpfsSyncGetLatestBlock run cmd after")
-
-	//strErrInfo := outerr.String()
-	stdErr := outerr.String()
 	curLastestInfo := new(LastestBlcokCfg) //LastestBlcokCfg{}
+	if gArchive == nil {
+		return curLastestInfo, 0, errArchiveNotReady
+	}
+	outbuf, err := gArchive.GetIndex(listPeerId[index], strLastestBlockFile)
 	if err != nil {
-
-		log.Error("ipfs IpfsSyncGetLatestBlock run cmd error", "error", err, "ipfs err", outerr.String())
-		if strings.Index(stdErr, strIPFSstdErr) > 0 {
-			CheckIpfsStatus(err)
-		}
-		//CheckIpfsStatus(err)
+		log.Error("ipfs IpfsSyncGetLatestBlock get index error", "error", err)
 		return curLastestInfo, 0, err
 	}
-
-	tmpFile, errf := os.Open(path.Join(strCacheDirectory, strLastestBlockFile)) //os.Open(strLastestBlockFile)
-	defer tmpFile.Close()
-	if errf != nil {
-		log.Error("ipfs IpfsSyncGetLatestBlock OpenFile error", "error", errf)
+	// Keep a local copy in the cache directory like the published one
+	if err := ioutil.WriteFile(path.Join(strCacheDirectory, strLastestBlockFile), outbuf, 0644); err != nil {
+		log.Error("ipfs IpfsSyncGetLatestBlock WriteFile error", "error", err)
 		return curLastestInfo, 0, err
 	}
-
-	//curLastestInfo := LastestBlcokCfg{}
-	err = loadCache(curLastestInfo, 0, tmpFile)
+	err = gob.NewDecoder(bytes.NewReader(outbuf)).Decode(curLastestInfo)
 	if err != nil {
 		log.Error("ipfs IpfsSyncGetLatestBlock loadCache json.Unmarshal error", "error", err)
 		return curLastestInfo, 0, err
@@ -2186,7 +1905,7 @@ func (d *Downloader) AddStateRootInfoToIpfs(blockNum uint64, strheadHash string,
 	fhandler, _ := os.Stat(filePath)
 	snapSize := fhandler.Size()
 	gIpfsStat.totalSnapDataSize += snapSize
-	bHash, zipSize, err1 := IpfsAddNewFile(filePath, true)
+	bHash, zipSize, err1 := IpfsAddNewSnapshot(filePath, true)
 	if err1 != nil {
 		log.Error(" ipfs AddStateRootInfoToIpfs error IpfsAddNewFile", "filePath", filePath)
 		return
@@ -2854,7 +2573,11 @@ func (d *Downloader) ParseBatchReceipt(batchblockhash string, beginReqNumber uin
 	return true
 }
 func (d *Downloader) ParseMPTstatus(batchblockhash string, beginReqNumber uint64, realstatusNumber uint64) bool {
-	blockFile, err := IpfsGetBlockByHash(batchblockhash, true)
+	blockFile, err := IpfsGetSnapshotByHash(batchblockhash, true)
+	if err != nil {
+		log.Debug(" ParseMPTstatus error in IpfsGetSnapshotByHash", "error", err)
+		return false
+	}
 	//解压区块
 	filepath := blockFile.Name()
 	defer func() {
@@ -2862,10 +2585,6 @@ func (d *Downloader) ParseMPTstatus(batchblockhash string, beginReqNumber uint64
 	}()
 	blockFile.Close()
 
-	if err != nil {
-		log.Debug(" ParseMPTstatus error in IpfsGetBlockByHash", "error", err)
-		return false
-	}
 	log.Debug("ipfs  ParseMPTstatus begin", "beginReqNumber", beginReqNumber, "realstatusNumber", realstatusNumber)
 	d.blockchain.SynSnapshot(realstatusNumber, batchblockhash, filepath)
 	return true
@@ -3161,21 +2880,21 @@ func (d *Downloader) ClearIpfsQueue() {
 }
 func (d *Downloader) GetfirstcacheByIPFS() {
 	fmt.Println("ipfs broadcast id ", d.dpIpfs.StrIpfspeerID)
-	//var out bytes.Buffer
-	var outerr bytes.Buffer
-	//ipnsPath := "/ipns/" + d.dpIpfs.StrIpfspeerID + "/" + strCache1BlockFile //"firstCacheSync.ha"
-	ipnsPath := "/ipns/" + d.dpIpfs.StrIpfspeerID + "/" + strCache1BlockFile
-	c := exec.Command(d.dpIpfs.StrIPFSExecName, "cat", ipnsPath) //或cat
-
-	//IpfsStartTimer("first")
-	c.Stderr = &outerr
-	outbuf, err := c.Output()
-	//IpfsStopTimer()
+	if gArchive == nil {
+		fmt.Println("ipfs error", errArchiveNotReady)
+		return
+	}
+	names, err := gArchive.ListIndex(d.dpIpfs.StrIpfspeerID)
+	if err != nil {
+		fmt.Println("ipfs error ListIndex error", err)
+		return
+	}
+	fmt.Println("ipfs published index", names)
 
+	outbuf, err := gArchive.GetIndex(d.dpIpfs.StrIpfspeerID, strCache1BlockFile)
 	curCache1Info := new(Cache1StoreCfg) // Cache1StoreCfg{}
-	//stdErr := outerr.String()
 	if err != nil {
-		fmt.Println("ipfs error IpfsSyncGetFirstCache error", err, outerr.String())
+		fmt.Println("ipfs error IpfsSyncGetFirstCache error", err)
 		return
 	}
 	err = json.Unmarshal(outbuf, curCache1Info)
@@ -3213,7 +2932,7 @@ func (d *Downloader) GetsecondcacheByIPFS(strHash string) {
 	for key, value := range cache2st.MapList.Numberstore {
 
 		for key2, value2 := range value.Blockhash {
-			fmt.Println("ipfs second cache blockNum=%d  blockHash=%s, ipfshash=%s", key, key2, value2) //cache2st.MapList.Numberstore[key].Blockhash[key2],
+			fmt.Printf("ipfs second cache blockNum=%d  blockHash=%s, ipfshash=%s\n", key, key2, value2) //cache2st.MapList.Numberstore[key].Blockhash[key2],
 		}
 	}
 	file.Close()
@@ -3250,7 +2969,7 @@ func (d *Downloader) GetBlockByIPFS(strHash string) {
 }
 func (d *Downloader) GetsanpByIPFS(strHash string) {
 	fmt.Println("ipfs sanpshoot", strHash)
-	file, err := IpfsGetBlockByHash(strHash, true)
+	file, err := IpfsGetSnapshotByHash(strHash, true)
 	//解压
 	file.Close()
 
@@ -3263,3 +2982,43 @@ func (d *Downloader) GetsanpByIPFS(strHash string) {
 	//}
 	os.Remove(strHash + ".unzip")
 }
+
+// RewindIpfsCache removes the blocks above the given block from the cache of
+// the latest published blocks, nothing is changed if dryRun is set. It returns
+// the latest block of the cache before the rewind and the number of heights
+// removed, both zero if the node keeps no cache.
+func RewindIpfsCache(number uint64, dryRun bool) (uint64, int, error) {
+	filename := path.Join(strCacheDirectory, strLastestBlockFile)
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return 0, 0, nil
+	}
+	file, err := os.OpenFile(filename, os.O_RDWR, 0644)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	lastest := new(LastestBlcokCfg)
+	lastest.MapList.Numberstore = make(map[uint64]NumberMapingCoupledHash)
+	if err := loadCache(lastest, 0, file); err != nil {
+		return 0, 0, err
+	}
+	current, removed := lastest.CurrentNum, 0
+	for blockNum := range lastest.MapList.Numberstore {
+		if blockNum > number {
+			delete(lastest.MapList.Numberstore, blockNum)
+			removed++
+		}
+	}
+	if dryRun || (removed == 0 && current <= number) {
+		return current, removed, nil
+	}
+	lastest.HashNum = len(lastest.MapList.Numberstore)
+	if lastest.CurrentNum > number {
+		lastest.CurrentNum = number
+	}
+	if _, err := file.Seek(0, 0); err != nil {
+		return current, removed, err
+	}
+	return current, removed, storeCache(lastest, file)
+}
@@ -1,6 +1,12 @@
 // Copyright (c) 2018 The MATRIX Authors
 // Distributed under the MIT software license, see the accompanying
 // file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+// Imports the ethdb package, replaced by mandb.
+
+//go:build legacytests
+// +build legacytests
+
 package downloader
 
 import (
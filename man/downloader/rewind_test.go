@@ -0,0 +1,111 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package downloader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+// writeTestIpfsCache stores a cache of the latest published blocks from first
+// to current in a temporary cache directory.
+func writeTestIpfsCache(t *testing.T, first, current uint64) string {
+	dir, err := ioutil.TempDir("", "ipfs-cache")
+	if err != nil {
+		t.Fatalf("failed to create cache directory: %v", err)
+	}
+	strCacheDirectory = dir
+
+	lastest := &LastestBlcokCfg{CurrentNum: current, MapList: BlockStore{Numberstore: make(map[uint64]NumberMapingCoupledHash)}}
+	for number := first; number <= current; number++ {
+		lastest.MapList.Numberstore[number] = NumberMapingCoupledHash{Blockhash: map[string]string{fmt.Sprintf("0x%x", number): "ipfs"}}
+	}
+	lastest.HashNum = len(lastest.MapList.Numberstore)
+	file, err := os.Create(path.Join(dir, strLastestBlockFile))
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer file.Close()
+	if err := storeCache(lastest, file); err != nil {
+		t.Fatalf("failed to store cache: %v", err)
+	}
+	return dir
+}
+
+func readTestIpfsCache(t *testing.T) *LastestBlcokCfg {
+	file, err := os.Open(path.Join(strCacheDirectory, strLastestBlockFile))
+	if err != nil {
+		t.Fatalf("failed to open cache: %v", err)
+	}
+	defer file.Close()
+	lastest := new(LastestBlcokCfg)
+	if err := loadCache(lastest, 0, file); err != nil {
+		t.Fatalf("failed to load cache: %v", err)
+	}
+	return lastest
+}
+
+// Tests that a rewind removes the cached blocks above the target and lowers
+// the current block to it, and that a dry run only counts them.
+func TestRewindIpfsCache(t *testing.T) {
+	defer func(dir string) { strCacheDirectory = dir }(strCacheDirectory)
+
+	tests := []struct {
+		number  uint64
+		removed int
+		current uint64 // Current block after the rewind
+	}{
+		{17, 3, 17},
+		{20, 0, 20},
+		{25, 0, 20},
+		{10, 6, 10}, // Below all cached blocks
+	}
+	for _, test := range tests {
+		dir := writeTestIpfsCache(t, 15, 20)
+		before, err := ioutil.ReadFile(path.Join(dir, strLastestBlockFile))
+		if err != nil {
+			t.Fatalf("failed to read cache: %v", err)
+		}
+
+		current, removed, err := RewindIpfsCache(test.number, true)
+		if err != nil || current != 20 || removed != test.removed {
+			t.Fatalf("block %d: dry run mismatch: have %d %d %v, want 20 %d", test.number, current, removed, err, test.removed)
+		}
+		if after, _ := ioutil.ReadFile(path.Join(dir, strLastestBlockFile)); string(after) != string(before) {
+			t.Fatalf("block %d: dry run changed the cache", test.number)
+		}
+
+		current, removed, err = RewindIpfsCache(test.number, false)
+		if err != nil || current != 20 || removed != test.removed {
+			t.Fatalf("block %d: rewind mismatch: have %d %d %v, want 20 %d", test.number, current, removed, err, test.removed)
+		}
+		lastest := readTestIpfsCache(t)
+		if lastest.CurrentNum != test.current || lastest.HashNum != len(lastest.MapList.Numberstore) {
+			t.Errorf("block %d: cache head mismatch: have %d with %d blocks, want %d", test.number, lastest.CurrentNum, lastest.HashNum, test.current)
+		}
+		if len(lastest.MapList.Numberstore) != 6-test.removed {
+			t.Errorf("block %d: cached block count mismatch: have %d, want %d", test.number, len(lastest.MapList.Numberstore), 6-test.removed)
+		}
+		for number := range lastest.MapList.Numberstore {
+			if number > test.number {
+				t.Errorf("block %d: block %d left in the cache", test.number, number)
+			}
+		}
+		os.RemoveAll(dir)
+	}
+
+	// Nodes not publishing blocks keep no cache
+	dir, err := ioutil.TempDir("", "ipfs-cache")
+	if err != nil {
+		t.Fatalf("failed to create cache directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	strCacheDirectory = dir
+	if current, removed, err := RewindIpfsCache(10, false); err != nil || current != 0 || removed != 0 {
+		t.Errorf("rewind without cache: have %d %d %v", current, removed, err)
+	}
+}
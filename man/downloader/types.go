@@ -13,6 +13,9 @@ import (
 // peerDropFn is a callback type for dropping a peer detected as malicious.
 type peerDropFn func(id string,flg int)
 
+// peerPenaltyFn is a callback type for penalizing a peer delivering invalid data.
+type peerPenaltyFn func(id string)
+
 // dataPack is a data message returned by a peer for some query.
 type dataPack interface {
 	PeerId() string
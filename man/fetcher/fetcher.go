@@ -55,6 +55,9 @@ type chainInsertFn func(types.Blocks) (int, error)
 // peerDropFn is a callback type for dropping a peer detected as malicious.
 type peerDropFn func(id string,flg int)
 
+// peerPenaltyFn is a callback type for penalizing a peer delivering invalid data.
+type peerPenaltyFn func(id string)
+
 // announce is the hash notification of the availability of a new block in the
 // network.
 type announce struct {
@@ -127,6 +130,7 @@ type Fetcher struct {
 	chainHeight    chainHeightFn      // Retrieves the current chain's height
 	insertChain    chainInsertFn      // Injects a batch of blocks into the chain
 	dropPeer       peerDropFn         // Drops a peer for misbehaving
+	penalizePeer   peerPenaltyFn      // Penalizes a peer delivering invalid blocks
 
 	// Testing hooks
 	announceChangeHook func(common.Hash, bool) // Method to call upon adding or deleting a hash from the announce list
@@ -167,6 +171,12 @@ func New(getBlock blockRetrievalFn, verifyHeader headerVerifierFn, broadcastBloc
 	}
 }
 
+// SetPeerPenalty sets the callback penalizing the peers delivering invalid
+// blocks, on top of dropping them. It must be set before Start.
+func (f *Fetcher) SetPeerPenalty(penalizePeer peerPenaltyFn) {
+	f.penalizePeer = penalizePeer
+}
+
 // Start boots up the announcement based synchroniser, accepting and processing
 // hash notifications and block fetches until termination requested.
 func (f *Fetcher) Start() {
@@ -577,6 +587,7 @@ func (f *Fetcher) loop() {
 					log.Trace("fetch header recv sucess","hash",hash)
 					if header.Number.Uint64() != announce.number {
 						log.Trace("Invalid block number header fetched", "peer", announce.origin, "hash", header.Hash().String(), "announced", announce.number, "provided", header.Number)
+						f.penalize(announce.origin)
 						f.dropPeer(announce.origin,0)
 						f.forgetHash(hash)
 						continue
@@ -864,6 +875,7 @@ func (f *Fetcher) insert(peer string, block *types.Block) {
 		default:
 			// Something went very wrong, drop the peer
 			log.Debug("Propagated block verification failed", "peer", peer, "number", block.Number(), "hash", hash.String(), "err", err)
+			f.penalize(peer)
 			f.dropPeer(peer,0)
 			return
 		}
@@ -945,3 +957,10 @@ func (f *Fetcher) forgetBlock(hash common.Hash) {
 		delete(f.queued, hash)
 	}
 }
+
+// penalize reports a peer delivering invalid blocks, if a penalty is set.
+func (f *Fetcher) penalize(peer string) {
+	if f.penalizePeer != nil {
+		f.penalizePeer(peer)
+	}
+}
@@ -11,13 +11,16 @@ import (
 	"fmt"
 	"github.com/MatrixAINetwork/go-matrix/base58"
 	"math/big"
+	"strings"
 	"sync"
 	"time"
 
 	matrix "github.com/MatrixAINetwork/go-matrix"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/depoistInfo"
 	"github.com/MatrixAINetwork/go-matrix/event"
 	"github.com/MatrixAINetwork/go-matrix/mandb"
 	"github.com/MatrixAINetwork/go-matrix/rpc"
@@ -222,6 +225,156 @@ func (api *PublicFilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, er
 	return rpcSub, nil
 }
 
+// DepositChanges creates a subscription that fires when a deposit position of
+// the account, the deposit or the authorized one, or a withdrawal of it
+// changes its status in a new block.
+func (api *PublicFilterAPI) DepositChanges(ctx context.Context, account string) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	backend, ok := api.backend.(StateBackend)
+	if !ok {
+		return &rpc.Subscription{}, errors.New("deposit changes require the chain state")
+	}
+	addr, err := base58.Base58DecodeToAddress(account)
+	if err != nil {
+		return &rpc.Subscription{}, err
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		headers := make(chan *types.Header)
+		headersSub := api.events.SubscribeNewHeads(headers)
+
+		var (
+			last     *depoistInfo.DepositLifecycle
+			lastHash common.Hash
+		)
+		for {
+			select {
+			case h := <-headers:
+				cur, ok := depositLifecycleAt(backend, addr, h.Hash())
+				if !ok {
+					continue
+				}
+				pre := last
+				if lastHash != h.ParentHash {
+					if pre, ok = depositLifecycleAt(backend, addr, h.ParentHash); !ok {
+						last, lastHash = cur, h.Hash()
+						continue
+					}
+				}
+				last, lastHash = cur, h.Hash()
+				if changes := depoistInfo.DepositChanges(pre, cur); len(changes) > 0 {
+					notifier.Notify(rpcSub.ID, changes)
+				}
+			case <-rpcSub.Err():
+				headersSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				headersSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// depositLifecycleAt returns the deposit positions of the account at the block,
+// nil if it has no deposit. It fails if the state of the block is missing or
+// the deposits can't be read from it.
+func depositLifecycleAt(backend StateBackend, addr common.Address, hash common.Hash) (*depoistInfo.DepositLifecycle, bool) {
+	st, header, err := backend.StateAndHeaderByHash(context.Background(), hash)
+	if err != nil || st == nil || header == nil {
+		return nil, false
+	}
+	lifecycle, err := depoistInfo.GetDepositLifecycle(st, addr, header.Number.Uint64(), header.Time.Uint64(), 0)
+	switch {
+	case err == depoistInfo.ErrNoDeposit:
+		return nil, true
+	case err != nil:
+		return nil, false
+	}
+	return lifecycle, true
+}
+
+// ScheduledTxChanges creates a subscription that fires when a revocable or
+// timed transfer of the currency of the account, which the account sends or
+// receives, is executed or reverted in a new block. Transfers parked and
+// executed in the same block are not reported.
+func (api *PublicFilterAPI) ScheduledTxChanges(ctx context.Context, account string) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	backend, ok := api.backend.(StateBackend)
+	if !ok {
+		return &rpc.Subscription{}, errors.New("scheduled transaction changes require the chain state")
+	}
+	addr, err := base58.Base58DecodeToAddress(account)
+	if err != nil {
+		return &rpc.Subscription{}, err
+	}
+	coin := strings.Split(account, ".")[0]
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		headers := make(chan *types.Header)
+		headersSub := api.events.SubscribeNewHeads(headers)
+
+		var (
+			last     []*state.ScheduledTx
+			lastHash common.Hash
+		)
+		for {
+			select {
+			case h := <-headers:
+				cur, ok := scheduledTxsAt(backend, coin, addr, h.Hash())
+				if !ok {
+					continue
+				}
+				pre := last
+				if lastHash != h.ParentHash {
+					if pre, ok = scheduledTxsAt(backend, coin, addr, h.ParentHash); !ok {
+						last, lastHash = cur, h.Hash()
+						continue
+					}
+				}
+				last, lastHash = cur, h.Hash()
+				if changes := state.ScheduledTxChanges(pre, cur, h.Number.Uint64(), h.Time.Uint64()); len(changes) > 0 {
+					notifier.Notify(rpcSub.ID, changes)
+				}
+			case <-rpcSub.Err():
+				headersSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				headersSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// scheduledTxsAt returns the scheduled transfers of the account at the block.
+// It fails if the state of the block is missing.
+func scheduledTxsAt(backend StateBackend, coin string, addr common.Address, hash common.Hash) ([]*state.ScheduledTx, bool) {
+	st, _, err := backend.StateAndHeaderByHash(context.Background(), hash)
+	if err != nil || st == nil {
+		return nil, false
+	}
+	txs, err := st.GetScheduledTxs(coin, addr)
+	if err != nil {
+		return nil, false
+	}
+	return txs, true
+}
+
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
 func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
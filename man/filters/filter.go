@@ -11,6 +11,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/core"
 	"github.com/MatrixAINetwork/go-matrix/core/bloombits"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
 	"github.com/MatrixAINetwork/go-matrix/event"
 	"github.com/MatrixAINetwork/go-matrix/mandb"
@@ -33,6 +34,12 @@ type Backend interface {
 	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession)
 }
 
+// StateBackend is implemented by the backends with access to the chain state,
+// the deposit and scheduled transaction subscriptions require it.
+type StateBackend interface {
+	StateAndHeaderByHash(ctx context.Context, hash common.Hash) (*state.StateDBManage, *types.Header, error)
+}
+
 // Filter can be used to retrieve and filter logs.
 type Filter struct {
 	backend Backend
@@ -53,8 +53,32 @@ var (
 // not compatible (low protocol version restrictions and high requirements).
 var errIncompatibleConfig = errors.New("incompatible configuration")
 
+// protoError is a violation of the protocol by a peer.
+type protoError struct {
+	code errCode
+	msg  string
+}
+
+func (e *protoError) Error() string {
+	return fmt.Sprintf("%v - %v", e.code, e.msg)
+}
+
 func errResp(code errCode, format string, v ...interface{}) error {
-	return fmt.Errorf("%v - %v", code, fmt.Sprintf(format, v...))
+	return &protoError{code: code, msg: fmt.Sprintf(format, v...)}
+}
+
+// invalidTxErrors are the pool errors of transactions no honest peer relays,
+// unlike the ones depending on the state of the pool or of the chain.
+var invalidTxErrors = map[error]bool{
+	core.ErrInvalidSender:   true,
+	core.ErrIntrinsicGas:    true,
+	core.ErrNegativeValue:   true,
+	core.ErrOversizedData:   true,
+	core.ErrTXWrongful:      true,
+	core.ErrTXUnknownType:   true,
+	core.ErrTxToRepeat:      true,
+	core.ErrTXToNil:         true,
+	core.ErrTXCountOverflow: true,
 }
 
 //var MyPm *ProtocolManager
@@ -188,9 +212,21 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 	}
 	manager.fetcher = fetcher.New(blockchain.GetBlockByHash, validator, manager.BroadcastBlock, heighter, inserter, manager.removePeer)
 
+	penalizeInvalidBlock := func(id string) { manager.penalizePeer(id, p2p.OffenceInvalidBlock) }
+	manager.downloader.SetPeerPenalty(penalizeInvalidBlock)
+	manager.fetcher.SetPeerPenalty(penalizeInvalidBlock)
+
 	return manager, nil
 }
 
+// penalizePeer reports the misbehaviour of a registered peer to the reputation
+// of the p2p server.
+func (pm *ProtocolManager) penalizePeer(id string, offence p2p.Offence) {
+	if peer := pm.Peers.Peer(id); peer != nil {
+		p2p.ServerP2p.Penalize(peer.ID(), offence)
+	}
+}
+
 func (pm *ProtocolManager) removePeer(id string, flg int) {
 	// Short circuit if the peer was already removed
 	//	peer := pm.peers.Peer(id)
@@ -375,6 +411,9 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	for {
 		if err := pm.handleMsg(p); err != nil {
 			p.Log().Debug("Matrix message handling failed", "err", err)
+			if _, ok := err.(*protoError); ok {
+				p2p.ServerP2p.Penalize(p.ID(), p2p.OffenceBadMessage)
+			}
 			return err
 		}
 	}
@@ -921,7 +960,12 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			log.Info("==tcp tx hash", "from", tx.From().String(), "tx.Nonce", tx.Nonce(), "hash", hash.String(), "sender addr", p2p.ServerP2p.ConvertIdToAddress(p.ID()).String(),
 				"node id", p.ID().String())
 		}
-		pm.txpool.AddRemotes(txs)
+		for _, err := range pm.txpool.AddRemotes(txs) {
+			if invalidTxErrors[err] {
+				p.Log().Trace("Invalid transaction received", "err", err)
+				p2p.ServerP2p.Penalize(p.ID(), p2p.OffenceInvalidTx)
+			}
+		}
 	case msg.Code == common.NetworkMsg:
 		var m []*core.MsgStruct
 		if err := msg.Decode(&m); err != nil {
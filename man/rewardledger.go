@@ -0,0 +1,197 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package man
+
+import (
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core"
+	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
+
+const (
+	// rewardLedgerSectionSize is the number of blocks of a reward ledger section.
+	rewardLedgerSectionSize = 4096
+
+	// rewardLedgerConfirms is the number of confirmation blocks before a reward
+	// ledger section is considered final and indexed.
+	rewardLedgerConfirms = 256
+
+	// rewardLedgerThrottling is the time to wait between processing two
+	// consecutive sections.
+	rewardLedgerThrottling = 100 * time.Millisecond
+)
+
+// rewardCategorySlash is the category of slash entries, the reward categories
+// are the common.Reward*Type values.
+const rewardCategorySlash byte = 0xff
+
+var rewardCategoryNames = map[byte]string{
+	common.RewardMinerType:     "miner",
+	common.RewardValidatorType: "validator",
+	common.RewardInterestType:  "interest",
+	common.RewardTxsType:       "txs",
+	common.RewardLotteryType:   "lottery",
+	rewardCategorySlash:        "slash",
+}
+
+// rewardTxCategories maps the reward transaction types to their category.
+var rewardTxCategories = map[byte]byte{
+	common.ExtraUnGasMinerTxType:     common.RewardMinerType,
+	common.ExtraUnGasValidatorTxType: common.RewardValidatorType,
+	common.ExtraUnGasInterestTxType:  common.RewardInterestType,
+	common.ExtraUnGasTxsType:         common.RewardTxsType,
+	common.ExtraUnGasLotteryTxType:   common.RewardLotteryType,
+}
+
+// RewardLedgerIndexer implements a core.ChainIndexer, recording every reward
+// credited by the reward transactions of a block and every slash charged to
+// the deposit accounts by account.
+//
+// Slashes are read from the slash records stored with the blocks by the slash
+// calculation, blocks imported without being processed, as by fast sync, have
+// none.
+type RewardLedgerIndexer struct {
+	db    mandb.Database
+	chain rewardLedgerChain
+	size  uint64
+
+	section  uint64                                  // Section being processed currently
+	entries  map[common.Address][]*rawdb.RewardEntry // Entries of the section by account
+	accounts []common.Address                        // Accounts of the section in order of their first entry
+}
+
+// rewardLedgerChain is the part of the chain the reward ledger reads.
+type rewardLedgerChain interface {
+	GetBlock(hash common.Hash, number uint64) *types.Block
+}
+
+// NewRewardLedgerIndexer returns a chain indexer that builds the reward ledger
+// of the canonical chain.
+func NewRewardLedgerIndexer(db mandb.Database, chain *core.BlockChain) *core.ChainIndexer {
+	backend := &RewardLedgerIndexer{
+		db:    db,
+		chain: chain,
+		size:  rewardLedgerSectionSize,
+	}
+	table := mandb.NewTable(db, string(rawdb.RewardLedgerIndexPrefix))
+
+	return core.NewChainIndexer(db, table, backend, rewardLedgerSectionSize, rewardLedgerConfirms, rewardLedgerThrottling, "rewardledger")
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new reward ledger
+// section. Entries a previous run stored for the section and the ones after
+// it, rolled back by a reorg, are dropped.
+func (b *RewardLedgerIndexer) Reset(section uint64, prevHead common.Hash) error {
+	b.section = section
+	b.entries = make(map[common.Address][]*rawdb.RewardEntry)
+	b.accounts = b.accounts[:0]
+
+	start := section * b.size
+	for stored := section; ; stored++ {
+		accounts := rawdb.ReadRewardSectionAccounts(b.db, stored)
+		if accounts == nil {
+			break
+		}
+		for _, account := range accounts {
+			count := rawdb.ReadRewardEntryCount(b.db, account)
+			if keep := rewardEntrySearch(b.db, account, count, start); keep < count {
+				rawdb.WriteRewardEntryCount(b.db, account, keep)
+			}
+		}
+	}
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, adding the rewards and slashes
+// of a new header to the section.
+func (b *RewardLedgerIndexer) Process(header *types.Header) {
+	number := header.Number.Uint64()
+	block := b.chain.GetBlock(header.Hash(), number)
+	if block == nil {
+		log.Error("Reward ledger block missing", "number", number, "hash", header.Hash())
+		return
+	}
+	for _, currency := range block.Currencies() {
+		for _, tx := range currency.Transactions.GetTransactions() {
+			category, ok := rewardTxCategories[tx.GetMatrixType()]
+			if !ok {
+				continue
+			}
+			entry := func(amount *big.Int) *rawdb.RewardEntry {
+				return &rawdb.RewardEntry{Number: number, TxHash: tx.Hash(), Category: category, Currency: tx.GetTxCurrency(), Amount: new(big.Int).Set(amount)}
+			}
+			if to := tx.To(); to != nil {
+				b.credit(rewardRecipient(category, *to, tx.Data()), entry(tx.Value()))
+			}
+			for _, extra := range tx.GetMatrix_EX() {
+				for _, to := range extra.ExtraTo {
+					if to.Recipient != nil && to.Amount != nil {
+						b.credit(rewardRecipient(category, *to.Recipient, to.Payload), entry(to.Amount))
+					}
+				}
+			}
+		}
+	}
+	b.processSlashes(header)
+}
+
+// rewardRecipient returns the account credited by a reward transfer. Interests
+// are paid to the deposit contract, the input names the deposit account.
+func rewardRecipient(category byte, to common.Address, input []byte) common.Address {
+	if category == common.RewardInterestType && to == common.ContractAddress && len(input) >= 36 {
+		return common.BytesToAddress(input[4:36])
+	}
+	return to
+}
+
+func (b *RewardLedgerIndexer) credit(account common.Address, entry *rawdb.RewardEntry) {
+	if entry.Amount.Sign() <= 0 {
+		return
+	}
+	if _, ok := b.entries[account]; !ok {
+		b.accounts = append(b.accounts, account)
+	}
+	b.entries[account] = append(b.entries[account], entry)
+}
+
+// processSlashes records the slashes the block charged.
+func (b *RewardLedgerIndexer) processSlashes(header *types.Header) {
+	for _, slash := range rawdb.ReadBlockSlashes(b.db, header.Hash(), header.Number.Uint64()) {
+		b.credit(slash.Account, &rawdb.RewardEntry{Number: header.Number.Uint64(), Category: rewardCategorySlash, Currency: params.MAN_COIN, Amount: slash.Amount})
+	}
+}
+
+// Commit implements core.ChainIndexerBackend, appending the entries of the
+// section to the ledgers of the accounts.
+func (b *RewardLedgerIndexer) Commit() error {
+	batch := b.db.NewBatch()
+	for _, account := range b.accounts {
+		count := rawdb.ReadRewardEntryCount(b.db, account)
+		for _, entry := range b.entries[account] {
+			rawdb.WriteRewardEntry(batch, account, count, entry)
+			count++
+		}
+		rawdb.WriteRewardEntryCount(batch, account, count)
+	}
+	rawdb.WriteRewardSectionAccounts(batch, b.section, b.accounts)
+	return batch.Write()
+}
+
+// rewardEntrySearch returns the index of the first of count entries of the
+// account at or above the block number.
+func rewardEntrySearch(db rawdb.DatabaseReader, account common.Address, count uint64, number uint64) uint64 {
+	return uint64(sort.Search(int(count), func(i int) bool {
+		entry := rawdb.ReadRewardEntry(db, account, uint64(i))
+		return entry == nil || entry.Number >= number
+	}))
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package man
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
+
+// testBlockChain serves the blocks of the indexer tests.
+type testBlockChain map[common.Hash]*types.Block
+
+func (c testBlockChain) GetBlock(hash common.Hash, number uint64) *types.Block {
+	return c[hash]
+}
+
+func (c testBlockChain) add(number uint64, txs ...types.SelfTransaction) *types.Header {
+	header := &types.Header{Number: new(big.Int).SetUint64(number), Time: big.NewInt(int64(number)), Roots: []common.CoinRoot{{Cointyp: params.MAN_COIN}}}
+	block := types.NewBlockWithTxs(header, types.MakeCurencyBlock([]types.CoinSelfTransaction{{CoinType: params.MAN_COIN, Txser: txs}}, nil, nil))
+	c[block.Hash()] = block
+	return block.Header()
+}
+
+// rewardTx makes a reward transaction of the type paying the amounts to the
+// recipients, the first one is the recipient of the transaction itself.
+func rewardTx(txType byte, data []byte, to common.Address, amount int64, extra ...*types.ExtraTo_tr) types.SelfTransaction {
+	return types.NewTransactions(0, to, big.NewInt(amount), 0, new(big.Int), data, nil, nil, nil, extra, 0, txType, 0, params.MAN_COIN, 0)
+}
+
+func extraTo(to common.Address, amount int64) *types.ExtraTo_tr {
+	return &types.ExtraTo_tr{To_tr: &to, Value_tr: (*hexutil.Big)(big.NewInt(amount))}
+}
+
+// interestInput makes the deposit contract input of an interest payment.
+func interestInput(account common.Address) []byte {
+	return append([]byte{0x01, 0x02, 0x03, 0x04}, common.LeftPadBytes(account.Bytes(), 32)...)
+}
+
+func newTestRewardLedger(size uint64) (*RewardLedgerIndexer, testBlockChain) {
+	chain := make(testBlockChain)
+	return &RewardLedgerIndexer{db: mandb.NewMemDatabase(), chain: chain, size: size}, chain
+}
+
+func rewardEntries(db rawdb.DatabaseReader, account common.Address) []*rawdb.RewardEntry {
+	var entries []*rawdb.RewardEntry
+	for i := uint64(0); i < rawdb.ReadRewardEntryCount(db, account); i++ {
+		entries = append(entries, rawdb.ReadRewardEntry(db, account, i))
+	}
+	return entries
+}
+
+func TestRewardRecipient(t *testing.T) {
+	account := common.HexToAddress("0x1234")
+	tests := []struct {
+		category byte
+		to       common.Address
+		input    []byte
+		want     common.Address
+	}{
+		{common.RewardInterestType, common.ContractAddress, interestInput(account), account},
+		{common.RewardInterestType, common.ContractAddress, interestInput(account)[:35], common.ContractAddress},
+		{common.RewardInterestType, account, nil, account},
+		{common.RewardMinerType, common.ContractAddress, interestInput(account), common.ContractAddress},
+	}
+	for i, test := range tests {
+		if have := rewardRecipient(test.category, test.to, test.input); have != test.want {
+			t.Errorf("test %d: recipient mismatch: have %x, want %x", i, have, test.want)
+		}
+	}
+}
+
+// Tests that the rewards of the reward transactions and the slashes recorded
+// with the block are credited to their accounts, and other transactions are
+// ignored.
+func TestRewardLedgerProcess(t *testing.T) {
+	indexer, chain := newTestRewardLedger(4)
+	miner, validator, depositor := common.HexToAddress("0x01"), common.HexToAddress("0x02"), common.HexToAddress("0x03")
+
+	header := chain.add(1,
+		rewardTx(common.ExtraUnGasMinerTxType, nil, miner, 5, extraTo(validator, 3), extraTo(depositor, 0)),
+		rewardTx(common.ExtraUnGasInterestTxType, interestInput(depositor), common.ContractAddress, 7),
+		rewardTx(common.ExtraNormalTxType, nil, miner, 100),
+	)
+	rawdb.WriteBlockSlashes(indexer.db, header.Hash(), 1, []common.SlashRecord{{Account: miner, Amount: big.NewInt(2)}})
+
+	if err := indexer.Reset(0, common.Hash{}); err != nil {
+		t.Fatalf("reset failed: %v", err)
+	}
+	indexer.Process(header)
+	if err := indexer.Commit(); err != nil {
+		t.Fatalf("commit failed: %v", err)
+	}
+
+	tests := []struct {
+		account  common.Address
+		category []byte
+		amount   []int64
+	}{
+		{miner, []byte{common.RewardMinerType, rewardCategorySlash}, []int64{5, 2}},
+		{validator, []byte{common.RewardMinerType}, []int64{3}},
+		{depositor, []byte{common.RewardInterestType}, []int64{7}},
+		{common.ContractAddress, nil, nil},
+	}
+	for _, test := range tests {
+		entries := rewardEntries(indexer.db, test.account)
+		if len(entries) != len(test.amount) {
+			t.Fatalf("account %x: entry count mismatch: have %d, want %d", test.account, len(entries), len(test.amount))
+		}
+		for i, entry := range entries {
+			if entry.Number != 1 || entry.Category != test.category[i] || entry.Amount.Int64() != test.amount[i] {
+				t.Errorf("account %x entry %d mismatch: have %d %d %v, want 1 %d %d", test.account, i, entry.Number, entry.Category, entry.Amount, test.category[i], test.amount[i])
+			}
+			if (entry.Category == rewardCategorySlash) != (entry.TxHash == common.Hash{}) {
+				t.Errorf("account %x entry %d: transaction hash mismatch: %x", test.account, i, entry.TxHash)
+			}
+		}
+	}
+	if accounts := rawdb.ReadRewardSectionAccounts(indexer.db, 0); len(accounts) != 3 || accounts[0] != miner {
+		t.Errorf("section accounts mismatch: have %x", accounts)
+	}
+}
+
+// Tests that resetting a section drops the entries a previous run stored for
+// it and the sections after it, keeping the ones of the earlier sections.
+func TestRewardLedgerResetTruncates(t *testing.T) {
+	indexer, chain := newTestRewardLedger(4)
+	miner, validator := common.HexToAddress("0x01"), common.HexToAddress("0x02")
+
+	index := func(section uint64, headers ...*types.Header) {
+		if err := indexer.Reset(section, common.Hash{}); err != nil {
+			t.Fatalf("section %d: reset failed: %v", section, err)
+		}
+		for _, header := range headers {
+			indexer.Process(header)
+		}
+		if err := indexer.Commit(); err != nil {
+			t.Fatalf("section %d: commit failed: %v", section, err)
+		}
+	}
+	index(0, chain.add(2, rewardTx(common.ExtraUnGasMinerTxType, nil, miner, 1)))
+	index(1, chain.add(5, rewardTx(common.ExtraUnGasMinerTxType, nil, miner, 2)), chain.add(6, rewardTx(common.ExtraUnGasMinerTxType, nil, miner, 3)))
+	index(2, chain.add(9, rewardTx(common.ExtraUnGasMinerTxType, nil, miner, 5, extraTo(validator, 6))))
+	if count := rawdb.ReadRewardEntryCount(indexer.db, miner); count != 4 {
+		t.Fatalf("entry count mismatch: have %d, want 4", count)
+	}
+	// Reindex the second section after a reorg
+	index(1, chain.add(5, rewardTx(common.ExtraUnGasMinerTxType, nil, miner, 4)))
+
+	entries := rewardEntries(indexer.db, miner)
+	if len(entries) != 2 {
+		t.Fatalf("entry count mismatch: have %d, want 2", len(entries))
+	}
+	if entries[0].Number != 2 || entries[0].Amount.Int64() != 1 || entries[1].Number != 5 || entries[1].Amount.Int64() != 4 {
+		t.Errorf("entries mismatch: have %d %v, %d %v", entries[0].Number, entries[0].Amount, entries[1].Number, entries[1].Amount)
+	}
+	if entries := rewardEntries(indexer.db, validator); len(entries) != 0 {
+		t.Errorf("entries of a later section left: %v", entries)
+	}
+}
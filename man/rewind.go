@@ -0,0 +1,41 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package man
+
+import (
+	"github.com/MatrixAINetwork/go-matrix/core"
+	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
+
+// ChainIndexRewind is the rewind of the processed sections of a chain index.
+type ChainIndexRewind struct {
+	Name        string
+	SectionSize uint64
+	Sections    uint64 // Processed sections before the rewind
+	Kept        uint64 // Processed sections after the rewind
+}
+
+// RewindChainIndexes drops the sections of the chain indexes of the node that
+// are not complete at the given block, nothing is changed if dryRun is set.
+// The node must not be running.
+func RewindChainIndexes(db mandb.Database, number uint64, dryRun bool) []ChainIndexRewind {
+	indexes := []struct {
+		name   string
+		prefix []byte
+		size   uint64
+	}{
+		{"bloombits", rawdb.BloomBitsIndexPrefix, params.BloomBitsBlocks},
+		{"rewardledger", rawdb.RewardLedgerIndexPrefix, rewardLedgerSectionSize},
+	}
+	rewinds := make([]ChainIndexRewind, 0, len(indexes))
+	for _, index := range indexes {
+		table := mandb.NewTable(db, string(index.prefix))
+		sections, kept := core.RewindChainIndex(table, index.size, number, dryRun)
+		rewinds = append(rewinds, ChainIndexRewind{Name: index.name, SectionSize: index.size, Sections: sections, Kept: kept})
+	}
+	return rewinds
+}
@@ -8,8 +8,8 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
-	"path"
-	"strconv"
+	"path/filepath"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -189,7 +189,7 @@ func (pm *ProtocolManager) syncer() {
 	curBlkNum := pm.blockchain.CurrentBlock().NumberU64()
 	//自动加载手动保存的快照
 	if SnapLoadFile != "" {
-		filePath := path.Join(snapshot.SNAPDIR, SnapLoadFile)
+		filePath := filepath.Join(pm.blockchain.SnapshotDir(), SnapLoadFile)
 		if common.FileExist(filePath) == false {
 			fmt.Println("matrix  load local snapshoot not find file", SnapLoadFile)
 			log.Error("matrix  load local snapshoot not find file", "file", SnapLoadFile)
@@ -211,8 +211,11 @@ func (pm *ProtocolManager) syncer() {
 			maxTime  int64
 			fileName string
 		)
-		files, _ := ioutil.ReadDir(snapshot.SNAPDIR)
+		files, _ := ioutil.ReadDir(pm.blockchain.SnapshotDir())
 		for _, onefile := range files {
+			if strings.HasSuffix(onefile.Name(), ".tmp") {
+				continue
+			}
 			if onefile.ModTime().Unix() > maxTime {
 				maxTime = onefile.ModTime().Unix()
 				fileName = onefile.Name()
@@ -223,7 +226,7 @@ func (pm *ProtocolManager) syncer() {
 			log.Error("matrix auto load local snapshoot can't find snap file")
 		} else {
 			fmt.Println("matrix auto load local snapshoot start", fileName)
-			filePath := path.Join(snapshot.SNAPDIR, fileName)
+			filePath := filepath.Join(pm.blockchain.SnapshotDir(), fileName)
 			blockNum, flg := pm.blockchain.SynSnapshot(0, "", filePath)
 			if flg == false {
 				fmt.Println("Info!  matrix  can't auto load and use snapshoot because local chaindata is heigher than snap or other,SnapNum=", blockNum, curBlkNum)
@@ -254,7 +257,7 @@ func (pm *ProtocolManager) syncer() {
 			}
 		} else {
 			pm.downloader.SetSnapshootNum(SnapshootNumber)
-			filePath := path.Join(snapshot.SNAPDIR, "/TrieData"+strconv.Itoa(int(SnapshootNumber)))
+			filePath := filepath.Join(pm.blockchain.SnapshotDir(), snapshot.FileName(SnapshootNumber))
 			if blkNum, flg := pm.blockchain.SynSnapshot(SnapshootNumber, "", filePath); flg == false {
 				log.Error(" ipfs local snapshoot deal error and exit,please check", "blkNum", blkNum)
 				os.Exit(1)
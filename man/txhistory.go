@@ -0,0 +1,317 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package man
+
+import (
+	"encoding/json"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core"
+	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+)
+
+const (
+	// txHistorySectionSize is the number of blocks of a transaction history
+	// section.
+	txHistorySectionSize = 256
+
+	// txHistoryConfirms is the number of confirmation blocks before a
+	// transaction history section is considered final and indexed.
+	txHistoryConfirms = 64
+
+	// txHistoryThrottling is the time to wait between processing two
+	// consecutive sections.
+	txHistoryThrottling = 100 * time.Millisecond
+)
+
+// Kinds of the transaction history entries.
+const (
+	txHistorySent              byte = iota + 1 // Sender of a transaction, the amount is the total of its recipients
+	txHistoryReceived                          // Recipient or extra recipient of a transaction
+	txHistoryGasPayer                          // Authorizer paying the gas of an entrusted transaction
+	txHistoryReward                            // Recipient of a reward transaction
+	txHistoryScheduledSent                     // Sender of a revocable or timed transfer executed in the block
+	txHistoryScheduledReceived                 // Recipient of a revocable or timed transfer executed in the block
+	txHistoryCoinIssued                        // Initial balance of a currency made by the transaction
+)
+
+var txHistoryKindNames = map[byte]string{
+	txHistorySent:              "sent",
+	txHistoryReceived:          "received",
+	txHistoryGasPayer:          "gas",
+	txHistoryReward:            "reward",
+	txHistoryScheduledSent:     "scheduled_sent",
+	txHistoryScheduledReceived: "scheduled_received",
+	txHistoryCoinIssued:        "coin_issued",
+}
+
+// TxHistoryIndexer implements a core.ChainIndexer, recording every transaction
+// and transfer of a block by the accounts and currencies it touches: senders,
+// recipients and extra recipients, entrusted gas payers, reward recipients,
+// executed revocable and timed transfers and the accounts of made currencies.
+//
+// Gas payers and scheduled executions are read from the states of the block
+// and its parent, they are skipped if these states are pruned.
+type TxHistoryIndexer struct {
+	db    mandb.Database
+	chain txHistoryChain
+	size  uint64
+
+	section  uint64                                             // Section being processed currently
+	entries  map[rawdb.TxHistoryAccount][]*rawdb.TxHistoryEntry // Entries of the section by account
+	accounts []rawdb.TxHistoryAccount                           // Accounts of the section in order of their first entry
+}
+
+// txHistoryChain is the part of the chain the transaction history reads.
+type txHistoryChain interface {
+	rewardLedgerChain
+	StateAtBlockHash(hash common.Hash) (*state.StateDBManage, error)
+}
+
+// NewTxHistoryIndexer returns a chain indexer that builds the transaction
+// history of the canonical chain.
+func NewTxHistoryIndexer(db mandb.Database, chain *core.BlockChain) *core.ChainIndexer {
+	backend := &TxHistoryIndexer{
+		db:    db,
+		chain: chain,
+		size:  txHistorySectionSize,
+	}
+	table := mandb.NewTable(db, string(rawdb.TxHistoryIndexPrefix))
+
+	return core.NewChainIndexer(db, table, backend, txHistorySectionSize, txHistoryConfirms, txHistoryThrottling, "txhistory")
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new transaction
+// history section. Entries a previous run stored for the section and the ones
+// after it, rolled back by a reorg, are dropped.
+func (b *TxHistoryIndexer) Reset(section uint64, prevHead common.Hash) error {
+	b.section = section
+	b.entries = make(map[rawdb.TxHistoryAccount][]*rawdb.TxHistoryEntry)
+	b.accounts = b.accounts[:0]
+
+	start := section * b.size
+	for stored := section; ; stored++ {
+		accounts := rawdb.ReadTxHistorySectionAccounts(b.db, stored)
+		if accounts == nil {
+			break
+		}
+		for _, account := range accounts {
+			count := rawdb.ReadTxHistoryCount(b.db, account)
+			if keep := txHistorySearch(b.db, account, count, start); keep < count {
+				rawdb.WriteTxHistoryCount(b.db, account, keep)
+			}
+		}
+	}
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, adding the transactions and
+// transfers of a new header to the section.
+func (b *TxHistoryIndexer) Process(header *types.Header) {
+	number := header.Number.Uint64()
+	block := b.chain.GetBlock(header.Hash(), number)
+	if block == nil {
+		log.Error("Tx history block missing", "number", number, "hash", header.Hash())
+		return
+	}
+	receipts := make(map[string]types.Receipts)
+	for _, coinReceipts := range rawdb.ReadReceipts(b.db, header.Hash(), number) {
+		receipts[coinReceipts.CoinType] = coinReceipts.Receiptlist
+	}
+	var parent *state.StateDBManage
+	if number > 0 {
+		parent, _ = b.chain.StateAtBlockHash(header.ParentHash)
+	}
+	for _, currency := range block.Currencies() {
+		txs := currency.Transactions.GetTransactions()
+		for i, tx := range txs {
+			var receipt *types.Receipt
+			if rs := receipts[currency.CurrencyName]; len(rs) == len(txs) {
+				receipt = rs[i]
+			}
+			b.processTx(header, tx, receipt, parent)
+		}
+	}
+	if parent != nil {
+		b.processScheduled(header, parent)
+	}
+}
+
+// processTx adds the entries of the accounts a transaction touches.
+func (b *TxHistoryIndexer) processTx(header *types.Header, tx types.SelfTransaction, receipt *types.Receipt, parent *state.StateDBManage) {
+	var (
+		number   = header.Number.Uint64()
+		currency = tx.GetTxCurrency()
+		failed   = receipt != nil && receipt.Status == types.ReceiptStatusFailed
+	)
+	entry := func(kind byte, amount *big.Int) *rawdb.TxHistoryEntry {
+		if amount == nil {
+			amount = new(big.Int)
+		}
+		return &rawdb.TxHistoryEntry{Number: number, TxHash: tx.Hash(), Kind: kind, Failed: failed, Amount: new(big.Int).Set(amount)}
+	}
+
+	// Reward transactions are indexed for their recipients only, the reward
+	// pools are paying every block.
+	category, reward := rewardTxCategories[tx.GetMatrixType()]
+	recipient := func(to common.Address, input []byte, amount *big.Int) {
+		if reward {
+			b.add(rawdb.TxHistoryAccount{Address: rewardRecipient(category, to, input), Currency: currency}, entry(txHistoryReward, amount))
+		} else {
+			b.add(rawdb.TxHistoryAccount{Address: to, Currency: currency}, entry(txHistoryReceived, amount))
+		}
+	}
+	if !reward {
+		from, err := types.Sender(types.NewEIP155Signer(tx.ChainId()), tx)
+		if err != nil {
+			log.Warn("Tx history sender unknown", "number", number, "hash", tx.Hash(), "err", err)
+		} else {
+			b.add(rawdb.TxHistoryAccount{Address: from, Currency: currency}, entry(txHistorySent, txTotalAmount(tx)))
+			if tx.IsEntrustTx() && parent != nil {
+				if payer := entrustGasPayer(parent, currency, from, number-1, header.Time.Uint64()); payer != (common.Address{}) {
+					fee := new(big.Int)
+					if receipt != nil {
+						fee.Mul(new(big.Int).SetUint64(receipt.GasUsed), tx.GasPrice())
+					}
+					b.add(rawdb.TxHistoryAccount{Address: payer, Currency: currency}, entry(txHistoryGasPayer, fee))
+				}
+			}
+		}
+	}
+	if to := tx.To(); to != nil {
+		recipient(*to, tx.Data(), tx.Value())
+	}
+	for _, extra := range tx.GetMatrix_EX() {
+		for _, to := range extra.ExtraTo {
+			if to.Recipient != nil {
+				recipient(*to.Recipient, to.Payload, to.Amount)
+			}
+		}
+	}
+
+	if tx.GetMatrixType() == common.ExtraMakeCoinType && !failed {
+		var makecoin common.SMakeCoin
+		if err := json.Unmarshal(tx.Data(), &makecoin); err != nil {
+			return
+		}
+		for str, amount := range makecoin.AddrAmount {
+			addr, err := base58.Base58DecodeToAddress(str)
+			if err != nil {
+				continue
+			}
+			b.add(rawdb.TxHistoryAccount{Address: addr, Currency: makecoin.CoinName}, entry(txHistoryCoinIssued, (*big.Int)(amount)))
+		}
+	}
+}
+
+// entrustGasPayer returns the account authorizing the entrusted sender to
+// spend its gas, in the order the state processor looks it up.
+func entrustGasPayer(st *state.StateDBManage, currency string, from common.Address, height, time uint64) common.Address {
+	if payer := st.GetGasAuthFrom(currency, from, height); payer != (common.Address{}) {
+		return payer
+	}
+	if payer := st.GetGasAuthFromByTime(currency, from, time); payer != (common.Address{}) {
+		return payer
+	}
+	return st.GetGasAuthFromByCount(currency, from)
+}
+
+// txTotalAmount returns the amount of the transaction and its extra recipients.
+func txTotalAmount(tx types.SelfTransaction) *big.Int {
+	total := tx.Value()
+	for _, extra := range tx.GetMatrix_EX() {
+		for _, to := range extra.ExtraTo {
+			if to.Amount != nil {
+				total.Add(total, to.Amount)
+			}
+		}
+	}
+	return total
+}
+
+// processScheduled adds the entries of the revocable and timed transfers the
+// block executed.
+func (b *TxHistoryIndexer) processScheduled(header *types.Header, parent *state.StateDBManage) {
+	number := header.Number.Uint64()
+	st, err := b.chain.StateAtBlockHash(header.Hash())
+	if err != nil {
+		log.Warn("Tx history skipped scheduled transfers, state missing", "number", number, "err", err)
+		return
+	}
+	pre, err := parent.GetScheduledTxs("", common.Address{})
+	if err != nil || len(pre) == 0 {
+		return
+	}
+	cur, err := st.GetScheduledTxs("", common.Address{})
+	if err != nil {
+		return
+	}
+	for _, change := range state.ScheduledTxChanges(pre, cur, number, header.Time.Uint64()) {
+		if change.Status != state.ScheduledTxExecuted {
+			continue
+		}
+		entry := func(kind byte, amount *hexutil.Big) *rawdb.TxHistoryEntry {
+			return &rawdb.TxHistoryEntry{Number: number, TxHash: change.Hash, Kind: kind, Amount: new(big.Int).Set((*big.Int)(amount))}
+		}
+		if from, err := base58.Base58DecodeToAddress(change.From); err == nil {
+			b.add(rawdb.TxHistoryAccount{Address: from, Currency: change.Currency}, entry(txHistoryScheduledSent, change.Amount))
+		}
+		for _, recipient := range change.Recipients {
+			if to, err := base58.Base58DecodeToAddress(recipient.To); err == nil {
+				b.add(rawdb.TxHistoryAccount{Address: to, Currency: change.Currency}, entry(txHistoryScheduledReceived, recipient.Amount))
+			}
+		}
+	}
+}
+
+// add appends an entry to the account, the amounts of the entries of the same
+// transaction and kind are summed up.
+func (b *TxHistoryIndexer) add(account rawdb.TxHistoryAccount, entry *rawdb.TxHistoryEntry) {
+	entries, ok := b.entries[account]
+	if !ok {
+		b.accounts = append(b.accounts, account)
+	}
+	if n := len(entries); n > 0 {
+		if last := entries[n-1]; last.Number == entry.Number && last.TxHash == entry.TxHash && last.Kind == entry.Kind {
+			last.Amount.Add(last.Amount, entry.Amount)
+			return
+		}
+	}
+	b.entries[account] = append(entries, entry)
+}
+
+// Commit implements core.ChainIndexerBackend, appending the entries of the
+// section to the histories of the accounts.
+func (b *TxHistoryIndexer) Commit() error {
+	batch := b.db.NewBatch()
+	for _, account := range b.accounts {
+		count := rawdb.ReadTxHistoryCount(b.db, account)
+		for _, entry := range b.entries[account] {
+			rawdb.WriteTxHistoryEntry(batch, account, count, entry)
+			count++
+		}
+		rawdb.WriteTxHistoryCount(batch, account, count)
+	}
+	rawdb.WriteTxHistorySectionAccounts(batch, b.section, b.accounts)
+	return batch.Write()
+}
+
+// txHistorySearch returns the index of the first of count entries of the
+// account at or above the block number.
+func txHistorySearch(db rawdb.DatabaseReader, account rawdb.TxHistoryAccount, count uint64, number uint64) uint64 {
+	return uint64(sort.Search(int(count), func(i int) bool {
+		entry := rawdb.ReadTxHistoryEntry(db, account, uint64(i))
+		return entry == nil || entry.Number >= number
+	}))
+}
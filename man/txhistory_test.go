@@ -0,0 +1,244 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package man
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/rawdb"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
+
+// testTxHistoryChain serves the blocks and the states of the transaction
+// history tests, blocks are added with the zero parent hash.
+type testTxHistoryChain struct {
+	testBlockChain
+	states map[common.Hash]*state.StateDBManage
+}
+
+func (c *testTxHistoryChain) StateAtBlockHash(hash common.Hash) (*state.StateDBManage, error) {
+	if st, ok := c.states[hash]; ok {
+		return st, nil
+	}
+	return nil, errors.New("state missing")
+}
+
+func newTestTxHistory(size uint64) (*TxHistoryIndexer, *testTxHistoryChain) {
+	chain := &testTxHistoryChain{testBlockChain: make(testBlockChain), states: make(map[common.Hash]*state.StateDBManage)}
+	return &TxHistoryIndexer{db: mandb.NewMemDatabase(), chain: chain, size: size}, chain
+}
+
+var txHistorySigner = types.NewEIP155Signer(big.NewInt(1))
+
+// signedTx makes a normal transaction of the key paying the amounts to the
+// recipients, the first one is the recipient of the transaction itself.
+func signedTx(t *testing.T, key []byte, nonce uint64, entrust bool, to common.Address, amount int64, extra ...*types.ExtraTo_tr) types.SelfTransaction {
+	prv, err := crypto.ToECDSA(key)
+	if err != nil {
+		t.Fatalf("invalid key: %v", err)
+	}
+	var isEntrust byte
+	if entrust {
+		isEntrust = 1
+	}
+	tx := types.NewTransactions(nonce, to, big.NewInt(amount), params.TxGas, big.NewInt(2), nil, nil, nil, nil, extra, 0, common.ExtraNormalTxType, isEntrust, params.MAN_COIN, 0)
+	signed, err := types.SignTx(tx, txHistorySigner, prv)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	return signed
+}
+
+func txHistoryKey(t *testing.T, seed byte) ([]byte, common.Address) {
+	key := common.LeftPadBytes([]byte{seed}, 32)
+	prv, err := crypto.ToECDSA(key)
+	if err != nil {
+		t.Fatalf("invalid key: %v", err)
+	}
+	return key, crypto.PubkeyToAddress(prv.PublicKey)
+}
+
+// entrustState makes a state in which the payer pays the gas of the entrusted
+// sender up to the block number.
+func entrustState(t *testing.T, from, payer common.Address, end uint64) *state.StateDBManage {
+	db := mandb.NewMemDatabase()
+	st, err := state.NewStateDBManage(nil, db, state.NewDatabase(db))
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	auth, _ := json.Marshal([]common.AuthType{{AuthAddres: payer, EnstrustSetType: params.EntrustByHeight, IsEntrustGas: true, EndHeight: end}})
+	st.SetAuthStateByteArray(params.MAN_COIN, from, auth)
+	return st
+}
+
+func txHistoryEntries(db rawdb.DatabaseReader, address common.Address) []*rawdb.TxHistoryEntry {
+	account := rawdb.TxHistoryAccount{Address: address, Currency: params.MAN_COIN}
+	var entries []*rawdb.TxHistoryEntry
+	for i := uint64(0); i < rawdb.ReadTxHistoryCount(db, account); i++ {
+		entries = append(entries, rawdb.ReadTxHistoryEntry(db, account, i))
+	}
+	return entries
+}
+
+// Tests that senders, recipients and extra recipients, entrusted gas payers and
+// reward recipients get the entries of a block's transactions.
+func TestTxHistoryProcess(t *testing.T) {
+	indexer, chain := newTestTxHistory(4)
+	key, sender := txHistoryKey(t, 1)
+	recipient, extra, payer, miner := common.HexToAddress("0x02"), common.HexToAddress("0x03"), common.HexToAddress("0x04"), common.HexToAddress("0x05")
+	chain.states[common.Hash{}] = entrustState(t, sender, payer, 10)
+
+	transfer := signedTx(t, key, 0, false, recipient, 5, extraTo(extra, 3), extraTo(recipient, 4))
+	entrusted := signedTx(t, key, 1, true, recipient, 1)
+	reward := rewardTx(common.ExtraUnGasMinerTxType, nil, miner, 7)
+	header := chain.add(1, transfer, entrusted, reward)
+	rawdb.WriteReceipts(indexer.db, header.Hash(), 1, []types.CoinReceipts{{CoinType: params.MAN_COIN, Receiptlist: types.Receipts{
+		{Status: types.ReceiptStatusFailed, GasUsed: 30000},
+		{Status: types.ReceiptStatusSuccessful, GasUsed: 21000},
+		{Status: types.ReceiptStatusSuccessful},
+	}}})
+
+	if err := indexer.Reset(0, common.Hash{}); err != nil {
+		t.Fatalf("reset failed: %v", err)
+	}
+	indexer.Process(header)
+	if err := indexer.Commit(); err != nil {
+		t.Fatalf("commit failed: %v", err)
+	}
+
+	type want struct {
+		tx     types.SelfTransaction
+		kind   byte
+		failed bool
+		amount int64
+	}
+	tests := []struct {
+		account common.Address
+		entries []want
+	}{
+		{sender, []want{{transfer, txHistorySent, true, 12}, {entrusted, txHistorySent, false, 1}}},
+		{recipient, []want{{transfer, txHistoryReceived, true, 9}, {entrusted, txHistoryReceived, false, 1}}},
+		{extra, []want{{transfer, txHistoryReceived, true, 3}}},
+		{payer, []want{{entrusted, txHistoryGasPayer, false, 42000}}},
+		{miner, []want{{reward, txHistoryReward, false, 7}}},
+		{common.HexToAddress("0x06"), nil},
+	}
+	for _, test := range tests {
+		entries := txHistoryEntries(indexer.db, test.account)
+		if len(entries) != len(test.entries) {
+			t.Fatalf("account %x: entry count mismatch: have %d, want %d", test.account, len(entries), len(test.entries))
+		}
+		for i, entry := range entries {
+			want := test.entries[i]
+			if entry.Number != 1 || entry.TxHash != want.tx.Hash() || entry.Kind != want.kind || entry.Failed != want.failed || entry.Amount.Int64() != want.amount {
+				t.Errorf("account %x entry %d mismatch: have %d %x %d %v %v, want 1 %x %d %v %d", test.account, i,
+					entry.Number, entry.TxHash, entry.Kind, entry.Failed, entry.Amount, want.tx.Hash(), want.kind, want.failed, want.amount)
+			}
+		}
+	}
+	if accounts := rawdb.ReadTxHistorySectionAccounts(indexer.db, 0); len(accounts) != 5 || accounts[0].Address != sender {
+		t.Errorf("section accounts mismatch: have %v", accounts)
+	}
+}
+
+// Tests that the gas payer of an entrusted transaction is skipped if the state
+// of the parent block is pruned or the entrustment ended.
+func TestTxHistoryGasPayerSkipped(t *testing.T) {
+	key, sender := txHistoryKey(t, 1)
+	payer := common.HexToAddress("0x04")
+
+	for i, states := range []map[common.Hash]*state.StateDBManage{
+		{},
+		{common.Hash{}: entrustState(t, sender, payer, 0)},
+	} {
+		indexer, chain := newTestTxHistory(4)
+		chain.states = states
+		header := chain.add(2, signedTx(t, key, 0, true, common.HexToAddress("0x02"), 1))
+		indexer.Reset(0, common.Hash{})
+		indexer.Process(header)
+		if err := indexer.Commit(); err != nil {
+			t.Fatalf("test %d: commit failed: %v", i, err)
+		}
+		if entries := txHistoryEntries(indexer.db, sender); len(entries) != 1 {
+			t.Errorf("test %d: sender entry count mismatch: have %d, want 1", i, len(entries))
+		}
+		if entries := txHistoryEntries(indexer.db, payer); len(entries) != 0 {
+			t.Errorf("test %d: gas payer indexed: %v", i, entries)
+		}
+	}
+}
+
+// Tests that resetting a section drops the entries a previous run stored for
+// it and the sections after it, keeping the ones of the earlier sections.
+func TestTxHistoryResetTruncates(t *testing.T) {
+	indexer, chain := newTestTxHistory(4)
+	key, sender := txHistoryKey(t, 1)
+	recipient := common.HexToAddress("0x02")
+
+	index := func(section uint64, headers ...*types.Header) {
+		if err := indexer.Reset(section, common.Hash{}); err != nil {
+			t.Fatalf("section %d: reset failed: %v", section, err)
+		}
+		for _, header := range headers {
+			indexer.Process(header)
+		}
+		if err := indexer.Commit(); err != nil {
+			t.Fatalf("section %d: commit failed: %v", section, err)
+		}
+	}
+	index(0, chain.add(2, signedTx(t, key, 0, false, recipient, 1)))
+	index(1, chain.add(5, signedTx(t, key, 1, false, recipient, 2)), chain.add(6, signedTx(t, key, 2, false, recipient, 3)))
+	index(2, chain.add(9, signedTx(t, key, 3, false, recipient, 4)))
+	if entries := txHistoryEntries(indexer.db, recipient); len(entries) != 4 {
+		t.Fatalf("entry count mismatch: have %d, want 4", len(entries))
+	}
+	// Reindex the second section after a reorg
+	index(1, chain.add(5, signedTx(t, key, 1, false, recipient, 5)))
+
+	for _, account := range []common.Address{sender, recipient} {
+		entries := txHistoryEntries(indexer.db, account)
+		if len(entries) != 2 {
+			t.Fatalf("account %x: entry count mismatch: have %d, want 2", account, len(entries))
+		}
+		if entries[0].Number != 2 || entries[0].Amount.Int64() != 1 || entries[1].Number != 5 || entries[1].Amount.Int64() != 5 {
+			t.Errorf("account %x: entries mismatch: have %d %v, %d %v", account, entries[0].Number, entries[0].Amount, entries[1].Number, entries[1].Amount)
+		}
+	}
+}
+
+func TestTxHistorySearch(t *testing.T) {
+	db := mandb.NewMemDatabase()
+	account := rawdb.TxHistoryAccount{Address: common.HexToAddress("0x01"), Currency: params.MAN_COIN}
+	for i, number := range []uint64{2, 5, 5, 9} {
+		rawdb.WriteTxHistoryEntry(db, account, uint64(i), &rawdb.TxHistoryEntry{Number: number, Amount: new(big.Int)})
+	}
+	tests := []struct {
+		count  uint64
+		number uint64
+		want   uint64
+	}{
+		{4, 0, 0},
+		{4, 2, 0},
+		{4, 3, 1},
+		{4, 5, 1},
+		{4, 6, 3},
+		{4, 10, 4},
+		{2, 6, 2}, // Entries above the count are not searched
+		{0, 0, 0},
+	}
+	for _, test := range tests {
+		if have := txHistorySearch(db, account, test.count, test.number); have != test.want {
+			t.Errorf("search %d of %d entries: have %d, want %d", test.number, test.count, have, test.want)
+		}
+	}
+}
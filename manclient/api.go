@@ -57,4 +57,20 @@ type ManClient interface {
 	SuggestGasPrice(ctx context.Context) (*big.Int, error)
 	EstimateGas(ctx context.Context, msg matrix.CallMsg) (uint64, error)
 	SendTransaction(ctx context.Context, tx *types.Transaction) error
+
+	// matrix
+	MatrixCoins(ctx context.Context, blockNumber *big.Int) ([]string, error)
+	MatrixCoinConfig(ctx context.Context, coin string, blockNumber *big.Int) ([]CoinConfig, error)
+	Deposits(ctx context.Context, blockNumber *big.Int) ([]DepositDetail, error)
+	DepositByAddr(ctx context.Context, account string, blockNumber *big.Int) (*DepositBase, error)
+	EntrustList(ctx context.Context, authFrom string) ([]common.EntrustType, error)
+	AuthFrom(ctx context.Context, entrustFrom string, height uint64) (string, error)
+	ValidatorGroupInfo(ctx context.Context, blockNumber *big.Int) (map[string]*ValidatorGroup, error)
+	SignAccountsByNumber(ctx context.Context, blockNumber *big.Int) ([]common.VerifiedSign1, error)
+	TopologyStatusByNumber(ctx context.Context, blockNumber *big.Int) (*TopologyStatus, error)
+	UpTime(ctx context.Context, account string, blockNumber *big.Int) (*big.Int, error)
+	Interest(ctx context.Context, account string, blockNumber *big.Int) (*big.Int, error)
+	Slash(ctx context.Context, account string, blockNumber *big.Int) (*big.Int, error)
+	FutureRewards(ctx context.Context, blockNumber *big.Int) (*FutureRewards, error)
+	SendMatrixTransaction(ctx context.Context, tx *types.Transaction) (common.Hash, error)
 }
@@ -0,0 +1,261 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package manclient
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+)
+
+// Accounts in the results of the matrix methods are base58 encoded with the
+// currency prefix, e.g. MAN.2nRsUetjWAaYUizRkgBxGETimfUTz.
+
+// Decimal is a big integer of the generic results of the node, encoded as a
+// decimal string, or as the number 0 if it is not set.
+type Decimal big.Int
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Decimal) UnmarshalJSON(input []byte) error {
+	text := strings.Trim(string(input), `"`)
+	if _, ok := (*big.Int)(d).SetString(text, 10); !ok {
+		return fmt.Errorf("invalid decimal %s", input)
+	}
+	return nil
+}
+
+// ToInt converts d to a big.Int.
+func (d *Decimal) ToInt() *big.Int {
+	return (*big.Int)(d)
+}
+
+// CoinConfig is the configuration of a currency.
+type CoinConfig struct {
+	CoinRange   string       `json:"CoinRange"`
+	CoinType    string       `json:"CoinType"`
+	PackNum     uint64       `json:"PackNum"` // Max transactions of the currency per block, 0 for none
+	CoinUnit    *hexutil.Big `json:"CoinUnit"`
+	CoinTotal   *hexutil.Big `json:"CoinTotal"`
+	CoinAddress string       `json:"CoinAddress"` // Account receiving the fees
+}
+
+// DepositDetail is a deposit of an elected node.
+type DepositDetail struct {
+	Address     string
+	SignAddress string
+	Deposit     *big.Int
+	WithdrawH   *big.Int
+	OnlineTime  *big.Int
+	Role        *big.Int
+}
+
+// DepositBase holds all deposits of an account.
+type DepositBase struct {
+	AddressA0     string
+	AddressA1     string
+	OnlineTime    *hexutil.Big
+	Role          *hexutil.Big
+	PositionNonce uint64
+	Dpstmsg       []DepositMsg
+}
+
+// DepositMsg is a current or fixed deposit of an account.
+type DepositMsg struct {
+	DepositType      uint64 // 0 current, 1, 3 or 6 fixed for months
+	DepositAmount    *hexutil.Big
+	Interest         *hexutil.Big
+	Slash            *hexutil.Big
+	BeginTime        uint64
+	EndTime          uint64
+	Position         uint64
+	WithDrawInfolist []WithDrawInfo
+}
+
+// WithDrawInfo is a withdrawal of a deposit.
+type WithDrawInfo struct {
+	WithDrawAmount *hexutil.Big
+	WithDrawTime   uint64
+}
+
+// ValidatorGroup is the state of a validator group contract.
+type ValidatorGroup struct {
+	OwnerInfo struct {
+		Owner           string
+		WithdrawAllTime uint64
+		SignAddress     string
+	}
+	Reward struct {
+		OwnerRate RateOption
+		NodeRate  RateOption
+		LevelRate []struct {
+			Threshold *Decimal
+			Rate      RateOption
+		}
+	}
+	ValidatorMap []ValidatorGroupMember
+}
+
+// RateOption is the rate Rate / Decimal.
+type RateOption struct {
+	Rate    *Decimal
+	Decimal *Decimal
+}
+
+// ValidatorGroupMember is an account deposited in a validator group.
+type ValidatorGroupMember struct {
+	Address   string
+	Reward    *Decimal
+	AllAmount *Decimal
+	Current   struct {
+		Amount       *Decimal
+		PreAmount    *Decimal
+		Interest     *Decimal
+		WithdrawList []struct {
+			WithDrawAmount *Decimal
+			WithDrawTime   uint64
+		}
+	}
+	Positions []struct {
+		DType    uint64
+		Position uint64
+		Amount   *Decimal
+		EndTime  uint64
+	}
+}
+
+// TopologyNode is a node of the topology status.
+type TopologyNode struct {
+	Account  string `json:"account"`
+	Online   bool   `json:"online"`
+	Position uint16 `json:"position"`
+}
+
+// TopologyStatus is the topology of a block and the elected nodes outside it.
+type TopologyStatus struct {
+	LeaderReelect         bool           `json:"leader_reelect"`
+	Validators            []TopologyNode `json:"validators"`
+	BackupValidators      []TopologyNode `json:"backup_validators"`
+	Miners                []TopologyNode `json:"miners"`
+	ElectValidators       []TopologyNode `json:"elect_validators"`
+	ElectBackupValidators []TopologyNode `json:"elect_backup_validators"`
+}
+
+// FutureRewards are the rewards expected until the next election.
+type FutureRewards struct {
+	Time struct {
+		Start uint64
+		Stop  uint64
+	}
+	Miner     []RewardMount
+	Validator []RewardMount
+	Interest  []RewardMount
+}
+
+// RewardMount is the reward of an account, the deposit is set for interests.
+type RewardMount struct {
+	Account  string
+	Reward   *big.Int
+	VipLevel common.VIPRoleType
+	Stock    uint16
+	Deposit  *big.Int
+}
+
+// MatrixCoins returns the currencies issued on the chain besides MAN.
+func (ec *Client) MatrixCoins(ctx context.Context, blockNumber *big.Int) ([]string, error) {
+	var result []string
+	err := ec.c.CallContext(ctx, &result, "man_getMatrixCoin", toBlockNumArg(blockNumber))
+	return result, err
+}
+
+// MatrixCoinConfig returns the configuration of the currency, of all
+// currencies if coin is empty.
+func (ec *Client) MatrixCoinConfig(ctx context.Context, coin string, blockNumber *big.Int) ([]CoinConfig, error) {
+	var result []CoinConfig
+	err := ec.c.CallContext(ctx, &result, "man_getMatrixCoinConfig", coin, toBlockNumArg(blockNumber))
+	return result, err
+}
+
+// Deposits returns the deposits of the nodes elected at the block.
+func (ec *Client) Deposits(ctx context.Context, blockNumber *big.Int) ([]DepositDetail, error) {
+	var result []DepositDetail
+	err := ec.c.CallContext(ctx, &result, "man_getDeposit", toBlockNumArg(blockNumber))
+	return result, err
+}
+
+// DepositByAddr returns all deposits of the account.
+func (ec *Client) DepositByAddr(ctx context.Context, account string, blockNumber *big.Int) (*DepositBase, error) {
+	var result *DepositBase
+	err := ec.c.CallContext(ctx, &result, "man_getDepositByAddr", account, toBlockNumArg(blockNumber))
+	return result, err
+}
+
+// EntrustList returns the valid entrustments the account authorized.
+func (ec *Client) EntrustList(ctx context.Context, authFrom string) ([]common.EntrustType, error) {
+	var result []common.EntrustType
+	err := ec.c.CallContext(ctx, &result, "man_getEntrustList", authFrom)
+	return result, err
+}
+
+// AuthFrom returns the account that entrusted entrustFrom at the height, an
+// empty string if there is none.
+func (ec *Client) AuthFrom(ctx context.Context, entrustFrom string, height uint64) (string, error) {
+	var result string
+	err := ec.c.CallContext(ctx, &result, "man_getAuthFrom", entrustFrom, height)
+	return result, err
+}
+
+// ValidatorGroupInfo returns the validator group contracts by contract account.
+func (ec *Client) ValidatorGroupInfo(ctx context.Context, blockNumber *big.Int) (map[string]*ValidatorGroup, error) {
+	var result map[string]*ValidatorGroup
+	err := ec.c.CallContext(ctx, &result, "man_getValidatorGroupInfo", toBlockNumArg(blockNumber))
+	return result, err
+}
+
+// SignAccountsByNumber returns the signatures of the block with the deposit
+// accounts of the signers.
+func (ec *Client) SignAccountsByNumber(ctx context.Context, blockNumber *big.Int) ([]common.VerifiedSign1, error) {
+	var result []common.VerifiedSign1
+	err := ec.c.CallContext(ctx, &result, "man_getSignAccountsByNumber", toBlockNumArg(blockNumber))
+	return result, err
+}
+
+// TopologyStatusByNumber returns the topology status of the block.
+func (ec *Client) TopologyStatusByNumber(ctx context.Context, blockNumber *big.Int) (*TopologyStatus, error) {
+	var result *TopologyStatus
+	err := ec.c.CallContext(ctx, &result, "man_getTopologyStatusByNumber", toBlockNumArg(blockNumber))
+	return result, err
+}
+
+// UpTime returns the online time of the deposit account.
+func (ec *Client) UpTime(ctx context.Context, account string, blockNumber *big.Int) (*big.Int, error) {
+	var result *big.Int
+	err := ec.c.CallContext(ctx, &result, "man_getUpTime", account, toBlockNumArg(blockNumber))
+	return result, err
+}
+
+// Interest returns the interest of the deposit account not paid yet.
+func (ec *Client) Interest(ctx context.Context, account string, blockNumber *big.Int) (*big.Int, error) {
+	var result *hexutil.Big
+	err := ec.c.CallContext(ctx, &result, "man_getInterest", account, toBlockNumArg(blockNumber))
+	return (*big.Int)(result), err
+}
+
+// Slash returns the slash of the deposit account not applied yet.
+func (ec *Client) Slash(ctx context.Context, account string, blockNumber *big.Int) (*big.Int, error) {
+	var result *hexutil.Big
+	err := ec.c.CallContext(ctx, &result, "man_getSlash", account, toBlockNumArg(blockNumber))
+	return (*big.Int)(result), err
+}
+
+// FutureRewards returns the rewards expected until the next election.
+func (ec *Client) FutureRewards(ctx context.Context, blockNumber *big.Int) (*FutureRewards, error) {
+	var result *FutureRewards
+	err := ec.c.CallContext(ctx, &result, "man_getFutureRewards", toBlockNumArg(blockNumber))
+	return result, err
+}
@@ -0,0 +1,209 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package manclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
+
+var errInvalidCurrency = errors.New("invalid currency")
+
+// TxOpts are the fields shared by the transactions of the New*Tx functions.
+type TxOpts struct {
+	Currency   string // Currency of the transaction, MAN if empty
+	Nonce      uint64
+	GasLimit   uint64
+	GasPrice   *big.Int
+	CommitTime uint64 // Creation time in seconds, the chain orders revocable and timed transactions by it
+	Entrust    bool   // Sent by an entrusted account on behalf of the authorizing one
+}
+
+func (opts *TxOpts) currency() string {
+	if opts.Currency == "" {
+		return params.MAN_COIN
+	}
+	return opts.Currency
+}
+
+// Recipient is an additional recipient of a multi-recipient transaction.
+type Recipient struct {
+	To    common.Address
+	Value *big.Int
+	Data  []byte
+}
+
+func newMatrixTx(opts *TxOpts, txType byte, to common.Address, value *big.Int, data []byte, extra []Recipient) *types.Transaction {
+	extraTo := make([]*types.ExtraTo_tr, 0, len(extra))
+	for i := range extra {
+		recipient := extra[i].To
+		value := new(big.Int)
+		if extra[i].Value != nil {
+			value.Set(extra[i].Value)
+		}
+		input := hexutil.Bytes(common.CopyBytes(extra[i].Data))
+		extraTo = append(extraTo, &types.ExtraTo_tr{To_tr: &recipient, Value_tr: (*hexutil.Big)(value), Input_tr: &input})
+	}
+	var entrust byte
+	if opts.Entrust {
+		entrust = 1
+	}
+	return types.NewTransactions(opts.Nonce, to, value, opts.GasLimit, opts.GasPrice, data, nil, nil, nil, extraTo, 0, txType, entrust, opts.currency(), opts.CommitTime)
+}
+
+// NewTransferTx creates a transfer, paying all extra recipients too.
+func NewTransferTx(opts *TxOpts, to common.Address, value *big.Int, data []byte, extra ...Recipient) *types.Transaction {
+	return newMatrixTx(opts, common.ExtraNormalTxType, to, value, data, extra)
+}
+
+// NewRevocableTx creates a transfer that can be reverted by NewRevertTx until
+// it is executed.
+func NewRevocableTx(opts *TxOpts, to common.Address, value *big.Int, data []byte, extra ...Recipient) *types.Transaction {
+	return newMatrixTx(opts, common.ExtraRevocable, to, value, data, extra)
+}
+
+// NewTimeTx creates a transfer executed at the commit time of the options.
+func NewTimeTx(opts *TxOpts, to common.Address, value *big.Int, data []byte, extra ...Recipient) *types.Transaction {
+	return newMatrixTx(opts, common.ExtraTimeTxType, to, value, data, extra)
+}
+
+// NewRevertTx creates a transaction reverting revocable transactions of the
+// sender, it is sent to the sender itself.
+func NewRevertTx(opts *TxOpts, from common.Address, hashes ...common.Hash) (*types.Transaction, error) {
+	if len(hashes) == 0 {
+		return nil, errors.New("no transaction to revert")
+	}
+	extra := make([]Recipient, 0, len(hashes)-1)
+	for _, hash := range hashes[1:] {
+		extra = append(extra, Recipient{To: from, Data: hash.Bytes()})
+	}
+	return newMatrixTx(opts, common.ExtraRevertTxType, from, nil, hashes[0].Bytes(), extra), nil
+}
+
+// NewAuthTx creates a transaction entrusting the accounts of the list, which
+// must be of the currency of the transaction. It is sent to the authorizing
+// account itself.
+func NewAuthTx(opts *TxOpts, from common.Address, entrusts []common.EntrustType) (*types.Transaction, error) {
+	for _, entrust := range entrusts {
+		if strings.Split(entrust.EntrustAddres, ".")[0] != opts.currency() {
+			return nil, fmt.Errorf("entrusted account %s is not of currency %s", entrust.EntrustAddres, opts.currency())
+		}
+	}
+	data, err := json.Marshal(entrusts)
+	if err != nil {
+		return nil, err
+	}
+	return newMatrixTx(opts, common.ExtraAuthTx, from, nil, data, nil), nil
+}
+
+// NewCancelEntrustTx creates a transaction cancelling the entrustments at the
+// indexes of the entrust list of the authorizing account.
+func NewCancelEntrustTx(opts *TxOpts, from common.Address, indexes []uint32) (*types.Transaction, error) {
+	data, err := json.Marshal(indexes)
+	if err != nil {
+		return nil, err
+	}
+	return newMatrixTx(opts, common.ExtraCancelEntrust, from, nil, data, nil), nil
+}
+
+// SignTx signs the transaction for the chain. The signed hash covers the
+// accounts encoded with the currency, so the currency can not be changed
+// after signing.
+func SignTx(tx *types.Transaction, chainID *big.Int, prv *ecdsa.PrivateKey) (*types.Transaction, error) {
+	if !common.IsValidityManCurrency(tx.GetTxCurrency()) {
+		return nil, errInvalidCurrency
+	}
+	signed, err := types.SignTx(tx, types.NewEIP155Signer(chainID), prv)
+	if err != nil {
+		return nil, err
+	}
+	return signed.(*types.Transaction), nil
+}
+
+// sendTxArgs is the encoding of signed transactions man_sendRawTransaction
+// accepts, with base58 encoded accounts.
+type sendTxArgs struct {
+	To          *string        `json:"to"`
+	Gas         hexutil.Uint64 `json:"gas"`
+	GasPrice    *hexutil.Big   `json:"gasPrice"`
+	Value       *hexutil.Big   `json:"value"`
+	Nonce       hexutil.Uint64 `json:"nonce"`
+	Data        hexutil.Bytes  `json:"data"`
+	V           *hexutil.Big   `json:"v"`
+	R           *hexutil.Big   `json:"r"`
+	S           *hexutil.Big   `json:"s"`
+	Currency    string         `json:"currency"`
+	TxType      byte           `json:"txType"`
+	LockHeight  uint64         `json:"lockHeight"`
+	IsEntrustTx byte           `json:"isEntrustTx"`
+	CommitTime  uint64         `json:"commitTime"`
+	ExtraTo     []*sendTxExtra `json:"extra_to"`
+}
+
+type sendTxExtra struct {
+	To    string        `json:"to"`
+	Value *hexutil.Big  `json:"value"`
+	Input hexutil.Bytes `json:"input"`
+}
+
+func toSendTxArgs(tx *types.Transaction) *sendTxArgs {
+	currency := tx.GetTxCurrency()
+	v, r, s := tx.RawSignatureValues()
+	args := &sendTxArgs{
+		Gas:        hexutil.Uint64(tx.Gas()),
+		GasPrice:   (*hexutil.Big)(tx.GasPrice()),
+		Value:      (*hexutil.Big)(tx.Value()),
+		Nonce:      hexutil.Uint64(tx.Nonce()),
+		Data:       tx.Data(),
+		V:          (*hexutil.Big)(v),
+		R:          (*hexutil.Big)(r),
+		S:          (*hexutil.Big)(s),
+		Currency:   currency,
+		CommitTime: uint64(tx.GetCreateTime()),
+		ExtraTo:    make([]*sendTxExtra, 0),
+	}
+	if tx.IsEntrustTx() {
+		args.IsEntrustTx = 1
+	}
+	if to := tx.To(); to != nil {
+		encoded := base58.Base58EncodeToString(currency, *to)
+		args.To = &encoded
+	}
+	if extras := tx.GetMatrix_EX(); len(extras) > 0 {
+		args.TxType = extras[0].TxType
+		args.LockHeight = extras[0].LockHeight
+		for _, extra := range extras[0].ExtraTo {
+			if extra.Recipient == nil {
+				continue
+			}
+			args.ExtraTo = append(args.ExtraTo, &sendTxExtra{
+				To:    base58.Base58EncodeToString(currency, *extra.Recipient),
+				Value: (*hexutil.Big)(extra.Amount),
+				Input: extra.Payload,
+			})
+		}
+	}
+	return args
+}
+
+// SendMatrixTransaction injects a transaction signed by SignTx into the
+// pending pool and returns its hash. Unlike SendTransaction it uses the
+// encoding the node accepts for all matrix transaction types.
+func (ec *Client) SendMatrixTransaction(ctx context.Context, tx *types.Transaction) (common.Hash, error) {
+	var hash common.Hash
+	err := ec.c.CallContext(ctx, &hash, "man_sendRawTransaction", toSendTxArgs(tx))
+	return hash, err
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package manclient
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+)
+
+func TestSignMatrixTx(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to, extraTo := common.HexToAddress("0x01"), common.HexToAddress("0x02")
+	chainID := big.NewInt(1)
+
+	opts := &TxOpts{Currency: "BTC", Nonce: 3, GasLimit: 21000, GasPrice: big.NewInt(18e9), CommitTime: 1550000000}
+	tx := NewRevocableTx(opts, to, big.NewInt(10), nil, Recipient{To: extraTo, Value: big.NewInt(20)})
+	signed, err := SignTx(tx, chainID, key)
+	if err != nil {
+		t.Fatalf("sign failed: %v", err)
+	}
+	sender, err := types.Sender(types.NewEIP155Signer(chainID), signed)
+	if err != nil || sender != from {
+		t.Fatalf("sender mismatch: have %x, %v, want %x", sender, err, from)
+	}
+
+	args := toSendTxArgs(signed)
+	if args.Currency != "BTC" || args.TxType != common.ExtraRevocable || args.CommitTime != opts.CommitTime {
+		t.Errorf("args mismatch: %+v", args)
+	}
+	if *args.To != base58.Base58EncodeToString("BTC", to) {
+		t.Errorf("recipient mismatch: %s", *args.To)
+	}
+	if len(args.ExtraTo) != 1 || args.ExtraTo[0].To != base58.Base58EncodeToString("BTC", extraTo) || args.ExtraTo[0].Value.ToInt().Int64() != 20 {
+		t.Errorf("extra recipients mismatch: %+v", args.ExtraTo)
+	}
+
+	if _, err := SignTx(NewTransferTx(&TxOpts{Currency: "bad"}, to, nil, nil), chainID, key); err != errInvalidCurrency {
+		t.Errorf("invalid currency error mismatch: %v", err)
+	}
+	if _, err := NewAuthTx(opts, from, []common.EntrustType{{EntrustAddres: base58.Base58EncodeToString("MAN", to)}}); err == nil {
+		t.Errorf("entrustment of another currency accepted")
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	var values []*Decimal
+	if err := json.Unmarshal([]byte(`["1000000000000000000000", 0]`), &values); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if values[0].ToInt().String() != "1000000000000000000000" || values[1].ToInt().Sign() != 0 {
+		t.Errorf("decoded values mismatch: %v, %v", values[0].ToInt(), values[1].ToInt())
+	}
+}
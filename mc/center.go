@@ -5,46 +5,166 @@ package mc
 
 import (
 	"errors"
+	"reflect"
+	"sync"
 
 	"github.com/MatrixAINetwork/go-matrix/event"
+	"github.com/MatrixAINetwork/go-matrix/log"
 )
 
+// Center dispatches the events of one node between its services. The package
+// level SubscribeEvent/PublishEvent use the default center of the process.
 type Center struct {
 	FeedMap map[EventCode]*event.Feed
+
+	lock      sync.RWMutex
+	recorder  *Recorder
+	chanTypes map[EventCode]reflect.Type // element type of the subscribed channels, used by replay
+
+	ordered bool
+	queue   []publishedEvent
+	cond    *sync.Cond
+	quit    bool
+}
+
+type publishedEvent struct {
+	code EventCode
+	data interface{}
 }
 
 var (
-	local = newCenter()
+	local = NewCenter()
 
 	SubErrorNoThisEvent  = errors.New("SubscribeEvent Failed No This Event")
 	PostErrorNoThisEvent = errors.New("PostEvent Failed No This Event")
 )
 
-func newCenter() *Center {
-	msgCenter := &Center{FeedMap: make(map[EventCode]*event.Feed)}
+// NewCenter creates a center which delivers every published event in its own
+// goroutine, like the default center.
+func NewCenter() *Center {
+	msgCenter := &Center{
+		FeedMap:   make(map[EventCode]*event.Feed),
+		chanTypes: make(map[EventCode]reflect.Type),
+	}
 	msgCenter.init()
 	return msgCenter
 }
 
+// NewOrderedCenter creates a center which delivers the published events one at
+// a time in the order they were published. Stop must be called to release it.
+func NewOrderedCenter() *Center {
+	msgCenter := NewCenter()
+	msgCenter.ordered = true
+	msgCenter.cond = sync.NewCond(&msgCenter.lock)
+	go msgCenter.dispatch()
+	return msgCenter
+}
+
+// DefaultCenter returns the center used by the package level functions.
+func DefaultCenter() *Center {
+	return local
+}
+
 func (c *Center) init() {
 	for i := 0; i < int(LastEventCode); i++ {
 		c.FeedMap[EventCode(i)] = new(event.Feed)
 	}
 }
 
-func SubscribeEvent(aim EventCode, ch interface{}) (event.Subscription, error) {
-	feed, ok := local.FeedMap[aim]
+// SetRecorder attaches a recorder which is handed every event published from
+// now on, nil detaches the current one.
+func (c *Center) SetRecorder(recorder *Recorder) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.recorder = recorder
+}
+
+// SubscribeEvent subscribes ch to the events of the given code.
+func (c *Center) SubscribeEvent(aim EventCode, ch interface{}) (event.Subscription, error) {
+	feed, ok := c.FeedMap[aim]
 	if !ok {
 		return nil, SubErrorNoThisEvent
 	}
-	return feed.Subscribe(ch), nil
+	sub := feed.Subscribe(ch)
+	c.lock.Lock()
+	c.chanTypes[aim] = reflect.TypeOf(ch).Elem()
+	c.lock.Unlock()
+	return sub, nil
 }
 
-func PublishEvent(aim EventCode, data interface{}) error {
-	feed, ok := local.FeedMap[aim]
+// PublishEvent hands data to the subscribers of the given code without waiting
+// for them to receive it.
+func (c *Center) PublishEvent(aim EventCode, data interface{}) error {
+	feed, ok := c.FeedMap[aim]
 	if !ok {
 		return PostErrorNoThisEvent
 	}
+	// Record outside the center lock, the file write must not stall publishers
+	c.lock.RLock()
+	recorder := c.recorder
+	c.lock.RUnlock()
+	if recorder != nil {
+		if err := recorder.Record(aim, data); err != nil {
+			log.Warn("event center", "record event failed", err, "code", aim)
+		}
+	}
+	c.lock.Lock()
+	if c.ordered {
+		if !c.quit {
+			c.queue = append(c.queue, publishedEvent{aim, data})
+			c.cond.Signal()
+		}
+		c.lock.Unlock()
+		return nil
+	}
+	c.lock.Unlock()
 	go feed.Send(data)
 	return nil
 }
+
+// Stop terminates the delivery of an ordered center, pending events are dropped.
+func (c *Center) Stop() {
+	if !c.ordered {
+		return
+	}
+	c.lock.Lock()
+	c.quit = true
+	c.queue = nil
+	c.cond.Broadcast()
+	c.lock.Unlock()
+}
+
+// dispatch delivers the queued events of an ordered center.
+func (c *Center) dispatch() {
+	for {
+		c.lock.Lock()
+		for len(c.queue) == 0 && !c.quit {
+			c.cond.Wait()
+		}
+		if c.quit {
+			c.lock.Unlock()
+			return
+		}
+		ev := c.queue[0]
+		c.queue = c.queue[1:]
+		c.lock.Unlock()
+
+		c.FeedMap[ev.code].Send(ev.data)
+	}
+}
+
+// chanType returns the element type of the channels subscribed to the given code.
+func (c *Center) chanType(aim EventCode) (reflect.Type, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	typ, ok := c.chanTypes[aim]
+	return typ, ok
+}
+
+func SubscribeEvent(aim EventCode, ch interface{}) (event.Subscription, error) {
+	return local.SubscribeEvent(aim, ch)
+}
+
+func PublishEvent(aim EventCode, data interface{}) error {
+	return local.PublishEvent(aim, data)
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package mc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+)
+
+// testTurn is not rlp encodable (signed int), it is recorded as json.
+type testTurn struct {
+	Turn int
+}
+
+func TestCentersAreIsolated(t *testing.T) {
+	a, b := NewCenter(), NewCenter()
+	ch := make(chan uint64, 1)
+	sub, err := b.SubscribeEvent(BlockGenor_HeaderGenerateReq, ch)
+	if err != nil {
+		t.Fatalf("subscribe failed: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	a.PublishEvent(BlockGenor_HeaderGenerateReq, uint64(1))
+	b.PublishEvent(BlockGenor_HeaderGenerateReq, uint64(2))
+	select {
+	case n := <-ch:
+		if n != 2 {
+			t.Fatalf("received event of other center: %d", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("event not delivered")
+	}
+	select {
+	case n := <-ch:
+		t.Fatalf("unexpected event %d", n)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestOrderedCenter(t *testing.T) {
+	c := NewOrderedCenter()
+	defer c.Stop()
+	ch := make(chan uint64)
+	sub, _ := c.SubscribeEvent(BlockGenor_HeaderGenerateReq, ch)
+	defer sub.Unsubscribe()
+
+	for i := uint64(0); i < 100; i++ {
+		c.PublishEvent(BlockGenor_HeaderGenerateReq, i)
+	}
+	for i := uint64(0); i < 100; i++ {
+		if n := <-ch; n != i {
+			t.Fatalf("event %d delivered out of order: %d", i, n)
+		}
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mc-record")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "events.rlp")
+
+	recorder, err := NewRecorder(path)
+	if err != nil {
+		t.Fatalf("failed to create recorder: %v", err)
+	}
+	src := NewOrderedCenter()
+	src.SetRecorder(recorder)
+	inserted := &BlockInsertedMsg{Block: BlockInfo{Hash: common.HexToHash("0x01"), Number: 7}, InsertTime: 100, CanonState: true}
+	src.PublishEvent(BlockInserted, inserted)
+	src.PublishEvent(Leader_RecoveryState, testTurn{Turn: -1})
+	src.PublishEvent(BlockGenor_HeaderGenerateReq, uint64(8))
+	src.Stop()
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("failed to close recorder: %v", err)
+	}
+
+	file, _ := os.Open(path)
+	records, err := ReadRecords(file)
+	file.Close()
+	if err != nil || len(records) != 3 {
+		t.Fatalf("read records: have %d, %v, want 3", len(records), err)
+	}
+	if records[0].Codec != CodecRLP || records[1].Codec != CodecJSON {
+		t.Fatalf("unexpected codecs: %d, %d", records[0].Codec, records[1].Codec)
+	}
+
+	// Replay into a fresh center, the header request has no subscriber
+	dst := NewCenter()
+	insertCh := make(chan *BlockInsertedMsg, 1)
+	turnCh := make(chan testTurn, 1)
+	sub1, _ := dst.SubscribeEvent(BlockInserted, insertCh)
+	sub2, _ := dst.SubscribeEvent(Leader_RecoveryState, turnCh)
+	defer sub1.Unsubscribe()
+	defer sub2.Unsubscribe()
+
+	stats, err := dst.ReplayFile(path, ReplayConfig{})
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if want := (ReplayStats{Replayed: 2, Unreplayable: 1}); stats != want {
+		t.Fatalf("replay stats mismatch: have %+v, want %+v", stats, want)
+	}
+	if have := <-insertCh; !reflect.DeepEqual(have, inserted) {
+		t.Fatalf("replayed payload mismatch: have %+v, want %+v", have, inserted)
+	}
+	if have := <-turnCh; have.Turn != -1 {
+		t.Fatalf("replayed json payload mismatch: have %+v", have)
+	}
+
+	// Filtered replay
+	stats, err = dst.ReplayFile(path, ReplayConfig{Codes: map[EventCode]bool{BlockInserted: true}})
+	if err != nil || stats.Replayed != 1 || stats.Filtered != 2 {
+		t.Fatalf("filtered replay: have %+v, %v", stats, err)
+	}
+}
+
+func TestRecordNilPayload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mc-record")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "events.rlp")
+
+	recorder, err := NewRecorder(path)
+	if err != nil {
+		t.Fatalf("failed to create recorder: %v", err)
+	}
+	if err := recorder.Record(BlockInserted, nil); err != nil {
+		t.Fatalf("failed to record nil payload: %v", err)
+	}
+	recorder.Close()
+
+	file, _ := os.Open(path)
+	records, err := ReadRecords(file)
+	file.Close()
+	if err != nil || len(records) != 1 {
+		t.Fatalf("read records: have %d, %v, want 1", len(records), err)
+	}
+	if records[0].Type != "<nil>" || records[0].Codec != CodecNone {
+		t.Fatalf("nil payload record mismatch: have %+v", records[0])
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package mc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+)
+
+// Payload encodings of a recorded event.
+const (
+	CodecNone uint8 = iota // payload could not be encoded, the event is not replayable
+	CodecRLP
+	CodecJSON
+)
+
+// EventRecord is one published event as stored by a Recorder.
+type EventRecord struct {
+	Time    uint64 // Unix time in nanoseconds the event was published at
+	Code    uint64 // EventCode of the event
+	Type    string // Go type of the payload
+	Codec   uint8
+	Payload []byte
+}
+
+// Recorder writes the events published on a center to a file, in publish order.
+type Recorder struct {
+	mu     sync.Mutex
+	writer io.WriteCloser
+	count  int
+}
+
+// NewRecorder creates a recorder appending to the file at path.
+func NewRecorder(path string) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{writer: file}, nil
+}
+
+// Record encodes the payload and appends the event to the recording. Payloads
+// which can neither be rlp nor json encoded, and nil ones, are recorded without
+// content.
+func (r *Recorder) Record(code EventCode, data interface{}) error {
+	record := EventRecord{
+		Time: uint64(time.Now().UnixNano()),
+		Code: uint64(code),
+	}
+	var encErr error
+	if data == nil {
+		record.Type = "<nil>"
+	} else {
+		record.Type = reflect.TypeOf(data).String()
+		if payload, err := rlp.EncodeToBytes(data); err == nil {
+			record.Codec, record.Payload = CodecRLP, payload
+		} else if payload, err := json.Marshal(data); err == nil {
+			record.Codec, record.Payload = CodecJSON, payload
+		} else {
+			encErr = fmt.Errorf("payload %s not encodable: %v", record.Type, err)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.writer == nil {
+		return os.ErrClosed
+	}
+	if err := rlp.Encode(r.writer, &record); err != nil {
+		return err
+	}
+	r.count++
+	return encErr
+}
+
+// Count returns the number of events recorded so far.
+func (r *Recorder) Count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.count
+}
+
+// Close flushes the recording to disk and closes the file.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.writer == nil {
+		return nil
+	}
+	err := r.writer.Close()
+	r.writer = nil
+	return err
+}
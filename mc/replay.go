@@ -0,0 +1,118 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package mc
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+)
+
+// ReplayConfig selects the events of a recording to feed back into a center.
+type ReplayConfig struct {
+	Codes map[EventCode]bool // Events to replay, nil replays all of them
+	Paced bool               // Keep the recorded intervals between the events
+}
+
+// ReplayStats reports what happened to the events of a recording.
+type ReplayStats struct {
+	Replayed     int // Events delivered to subscribers
+	Filtered     int // Events not selected by the config
+	Unreplayable int // Events without subscriber or with a payload that can't be restored
+}
+
+// ReadRecords reads all events of a recording.
+func ReadRecords(r io.Reader) ([]EventRecord, error) {
+	stream := rlp.NewStream(r, 0)
+	records := make([]EventRecord, 0)
+	for {
+		var record EventRecord
+		if err := stream.Decode(&record); err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return records, err
+		}
+		records = append(records, record)
+	}
+}
+
+// Replay feeds the events of a recording into the center in their recorded
+// order. Every event is handed to the subscribers and received by all of them
+// before the next one is sent, which makes the replay deterministic. The
+// services under test must have subscribed before, the payloads are restored
+// into the element type of their channels.
+func (c *Center) Replay(r io.Reader, config ReplayConfig) (ReplayStats, error) {
+	var (
+		stats  ReplayStats
+		last   uint64
+		stream = rlp.NewStream(r, 0)
+	)
+	for {
+		var record EventRecord
+		if err := stream.Decode(&record); err == io.EOF {
+			return stats, nil
+		} else if err != nil {
+			return stats, err
+		}
+		code := EventCode(record.Code)
+		if config.Codes != nil && !config.Codes[code] {
+			stats.Filtered++
+			continue
+		}
+		data, ok := c.restorePayload(code, &record)
+		if !ok {
+			stats.Unreplayable++
+			continue
+		}
+		if config.Paced && last != 0 && record.Time > last {
+			time.Sleep(time.Duration(record.Time - last))
+		}
+		last = record.Time
+		c.FeedMap[code].Send(data)
+		stats.Replayed++
+	}
+}
+
+// ReplayFile replays the recording stored at path.
+func (c *Center) ReplayFile(path string, config ReplayConfig) (ReplayStats, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return ReplayStats{}, err
+	}
+	defer file.Close()
+	return c.Replay(file, config)
+}
+
+// restorePayload decodes the payload of a record into the channel type of the
+// subscribers of its code.
+func (c *Center) restorePayload(code EventCode, record *EventRecord) (interface{}, bool) {
+	if _, ok := c.FeedMap[code]; !ok {
+		return nil, false
+	}
+	typ, ok := c.chanType(code)
+	if !ok || typ.Kind() == reflect.Interface || typ.String() != record.Type {
+		log.Debug("event replay", "skip event without matching subscriber", code, "type", record.Type)
+		return nil, false
+	}
+	value := reflect.New(typ)
+	var err error
+	switch record.Codec {
+	case CodecRLP:
+		err = rlp.DecodeBytes(record.Payload, value.Interface())
+	case CodecJSON:
+		err = json.Unmarshal(record.Payload, value.Interface())
+	default:
+		return nil, false
+	}
+	if err != nil {
+		log.Warn("event replay", "restore payload failed", err, "code", code, "type", record.Type)
+		return nil, false
+	}
+	return value.Elem().Interface(), true
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package prometheus
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/MatrixAINetwork/go-matrix/metrics"
+)
+
+var (
+	typeGaugeTpl   = "# TYPE %s gauge\n"
+	typeCounterTpl = "# TYPE %s counter\n"
+	typeSummaryTpl = "# TYPE %s summary\n"
+	keyValueTpl    = "%s %v\n\n"
+	keyQuantileTpl = "%s{quantile=\"%s\"} %v\n"
+)
+
+// quantiles are the quantiles of the summaries of histograms and timers.
+var quantiles = []float64{0.5, 0.75, 0.95, 0.99, 0.999, 0.9999}
+
+// collector is a collection of byte buffers that aggregate Prometheus reports
+// for different metric types.
+type collector struct {
+	buff *bytes.Buffer
+}
+
+// newCollector creates a new Prometheus metric aggregator.
+func newCollector() *collector {
+	return &collector{
+		buff: &bytes.Buffer{},
+	}
+}
+
+func (c *collector) addCounter(name string, m metrics.Counter) {
+	c.writeCounter(name, m.Count())
+}
+
+func (c *collector) addGauge(name string, m metrics.Gauge) {
+	c.writeGauge(name, m.Value())
+}
+
+func (c *collector) addGaugeFloat64(name string, m metrics.GaugeFloat64) {
+	c.writeGauge(name, m.Value())
+}
+
+func (c *collector) addMeter(name string, m metrics.Meter) {
+	c.writeCounter(name, m.Count())
+}
+
+func (c *collector) addTimer(name string, m metrics.Timer) {
+	c.writeSummary(name, quantiles, m.Percentiles(quantiles), m.Sum(), m.Count())
+}
+
+func (c *collector) addHistogram(name string, m metrics.Histogram) {
+	c.writeSummary(name, quantiles, m.Percentiles(quantiles), m.Sum(), m.Count())
+}
+
+func (c *collector) addResettingTimer(name string, m metrics.ResettingTimer) {
+	val := m.Values()
+	if len(val) == 0 {
+		return
+	}
+	var sum int64
+	for _, v := range val {
+		sum += v
+	}
+	ps := m.Percentiles([]float64{50, 95, 99})
+	c.writeSummary(name, []float64{0.5, 0.95, 0.99}, []float64{float64(ps[0]), float64(ps[1]), float64(ps[2])}, sum, int64(len(val)))
+}
+
+func (c *collector) writeGauge(name string, value interface{}) {
+	name = mutateKey(name)
+	c.buff.WriteString(fmt.Sprintf(typeGaugeTpl, name))
+	c.buff.WriteString(fmt.Sprintf(keyValueTpl, name, value))
+}
+
+func (c *collector) writeCounter(name string, value interface{}) {
+	name = mutateKey(name)
+	c.buff.WriteString(fmt.Sprintf(typeCounterTpl, name))
+	c.buff.WriteString(fmt.Sprintf(keyValueTpl, name, value))
+}
+
+func (c *collector) writeSummary(name string, qs []float64, values []float64, sum, count int64) {
+	name = mutateKey(name)
+	c.buff.WriteString(fmt.Sprintf(typeSummaryTpl, name))
+	for i := range values {
+		c.buff.WriteString(fmt.Sprintf(keyQuantileTpl, name, strconv.FormatFloat(qs[i], 'f', -1, 64), values[i]))
+	}
+	c.buff.WriteString(fmt.Sprintf("%s_sum %d\n", name, sum))
+	c.buff.WriteString(fmt.Sprintf(keyValueTpl, name+"_count", count))
+}
+
+// mutateKey converts a registry name into a valid Prometheus metric name.
+func mutateKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == ':':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package prometheus
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/metrics"
+)
+
+func TestHandler(t *testing.T) {
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = false }()
+
+	reg := metrics.NewRegistry()
+	metrics.NewRegisteredCounter("test/counter", reg).Inc(3)
+	metrics.NewRegisteredGauge("test/gauge", reg).Update(-7)
+	metrics.NewRegisteredGaugeFloat64("test/gauge-float", reg).Update(1.5)
+	metrics.NewRegisteredTimer("test/timer", reg).Update(2 * time.Millisecond)
+	metrics.NewRegisteredResettingTimer("test/resetting", reg).Update(time.Second)
+
+	rec := httptest.NewRecorder()
+	Handler(reg).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE test_counter counter\ntest_counter 3\n",
+		"# TYPE test_gauge gauge\ntest_gauge -7\n",
+		"# TYPE test_gauge_float gauge\ntest_gauge_float 1.5\n",
+		"# TYPE test_timer summary\ntest_timer{quantile=\"0.5\"} 2e+06\n",
+		"test_timer_sum 2000000\ntest_timer_count 1\n",
+		"test_resetting{quantile=\"0.99\"} 1e+09\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in\n%s", want, body)
+		}
+	}
+}
@@ -1,6 +1,13 @@
 // Copyright (c) 2018 The MATRIX Authors
 // Distributed under the MIT software license, see the accompanying
 // file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+// Uses the old newMineReqData and newMinReqCtrl signatures, from before
+// CoinSelfTransaction and ChainReader.
+
+//go:build legacytests
+// +build legacytests
+
 package miner
 
 import (
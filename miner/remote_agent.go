@@ -17,6 +17,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/consensus"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/event"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/params"
 	"math"
@@ -49,6 +50,9 @@ type RemoteAgent struct {
 	hashrateMu sync.RWMutex
 	hashrate   map[common.Hash]hashrate
 
+	workSubMu sync.Mutex
+	workSubs  map[chan<- struct{}]struct{} // Notified when the current work changes
+
 	running int32 // running indicates whether the agent is active. Call atomically
 	workid  int64
 }
@@ -59,6 +63,7 @@ func NewRemoteAgent(chain consensus.ChainReader, engine map[string]consensus.Eng
 		engine:   engine,
 		work:     make(map[common.Hash]*Work),
 		hashrate: make(map[common.Hash]hashrate),
+		workSubs: make(map[chan<- struct{}]struct{}),
 		workid:   0,
 	}
 }
@@ -95,6 +100,50 @@ func (a *RemoteAgent) Stop() {
 	close(a.workCh)
 }
 
+// SubscribeNewWork registers a subscription notified whenever the current
+// work changes, GetWork returns the new work package then. Notifications are
+// dropped while the channel is full, a buffer of one doesn't miss any change.
+func (a *RemoteAgent) SubscribeNewWork(ch chan<- struct{}) event.Subscription {
+	a.workSubMu.Lock()
+	a.workSubs[ch] = struct{}{}
+	a.workSubMu.Unlock()
+
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		a.workSubMu.Lock()
+		delete(a.workSubs, ch)
+		a.workSubMu.Unlock()
+		return nil
+	})
+}
+
+// notifyNewWork notifies the subscribers of new work without waiting for
+// them, so a slow subscriber doesn't hold up the work of the miner.
+func (a *RemoteAgent) notifyNewWork() {
+	a.workSubMu.Lock()
+	defer a.workSubMu.Unlock()
+
+	for ch := range a.workSubs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// pendingHeader returns a copy of the mine header of the work handed out by
+// GetWork for the mine hash, nil if it is unknown or expired.
+func (a *RemoteAgent) pendingHeader(hash common.Hash) *types.Header {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	work := a.work[hash]
+	if work == nil {
+		return nil
+	}
+	return types.CopyHeader(work.header)
+}
+
 // GetHashRate returns the accumulated hashrate of all identifier combined
 func (a *RemoteAgent) GetHashRate() (tot int64) {
 	a.hashrateMu.RLock()
@@ -332,6 +381,7 @@ func (a *RemoteAgent) loop(workCh chan *Work, quitCh chan struct{}) {
 			a.mu.Lock()
 			a.currentWork = work
 			a.mu.Unlock()
+			a.notifyNewWork()
 		case <-ticker.C:
 			// cleanup
 			a.mu.Lock()
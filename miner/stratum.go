@@ -0,0 +1,777 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package miner
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/consensus"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/event"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
+
+// DefaultStratumDifficulty is the default initial and minimum x11 share
+// difficulty of the stratum sessions.
+const DefaultStratumDifficulty = 1 << 16
+
+const (
+	stratumTargetShareTime  = 10 * time.Second // Time between the x11 shares of a session the difficulty is retargeted to
+	stratumRetargetInterval = time.Minute      // Interval the share difficulty of a session is retargeted at
+	stratumReportInterval   = 5 * time.Second  // Interval the hashrates are reported to the agent, below its expiry
+	stratumHashrateWindow   = 10 * time.Minute // Window of the shares the hashrate of a worker is estimated from
+	stratumIdleTimeout      = 10 * time.Minute // Sessions sending nothing for this long are closed
+	stratumWriteTimeout     = 10 * time.Second
+	stratumMaxLineSize      = 16 * 1024
+	stratumJobHistory       = 16 // Number of recent jobs shares are accepted for
+)
+
+// Stratum error codes.
+const (
+	stratumErrOther         = 20
+	stratumErrStaleJob      = 21
+	stratumErrDuplicate     = 22
+	stratumErrLowDifficulty = 23
+	stratumErrUnauthorized  = 24
+	stratumErrNotSubscribed = 25
+)
+
+// StratumConfig are the settings of the stratum server.
+type StratumConfig struct {
+	Addr       string // Listening address
+	Difficulty uint64 // Initial and minimum x11 share difficulty, DefaultStratumDifficulty if zero
+}
+
+// StratumWorker are the statistics of a worker, a rig of an account.
+type StratumWorker struct {
+	Name             string `json:"name"` // Worker name the rig authorized with, "account.rig"
+	Account          string `json:"account"`
+	Sessions         int    `json:"sessions"`   // Connected sessions
+	Difficulty       uint64 `json:"difficulty"` // x11 share difficulty of the latest active session
+	Accepted         uint64 `json:"accepted"`
+	Rejected         uint64 `json:"rejected"` // Shares below the share difficulty or invalid
+	Stale            uint64 `json:"stale"`    // Shares of expired jobs
+	Blocks           uint64 `json:"blocks"`   // Shares meeting the block difficulty accepted by the agent
+	Hashrate         uint64 `json:"hashrate"` // Estimated from the accepted x11 shares
+	ReportedHashrate uint64 `json:"reportedHashrate"`
+	LastShare        uint64 `json:"lastShare"` // Time of the last accepted share
+}
+
+type stratumShare struct {
+	time       time.Time
+	difficulty uint64
+}
+
+type stratumWorker struct {
+	stats  StratumWorker
+	id     common.Hash // Identifier of the hashrate reported to the agent
+	since  time.Time
+	shares []stratumShare // Accepted x11 shares of the hashrate window
+}
+
+// hashrate estimates the hashrate from the x11 shares of the window.
+func (w *stratumWorker) hashrate(now time.Time) uint64 {
+	for len(w.shares) > 0 && now.Sub(w.shares[0].time) > stratumHashrateWindow {
+		w.shares = w.shares[1:]
+	}
+	elapsed := now.Sub(w.since)
+	if elapsed > stratumHashrateWindow {
+		elapsed = stratumHashrateWindow
+	}
+	if elapsed < time.Second {
+		elapsed = time.Second
+	}
+	total := new(big.Int)
+	for _, share := range w.shares {
+		total.Add(total, new(big.Int).SetUint64(share.difficulty))
+	}
+	return total.Div(total, big.NewInt(int64(elapsed/time.Second))).Uint64()
+}
+
+type stratumJob struct {
+	id         string
+	hash       common.Hash // Mine hash of the work
+	work       [6]string   // Work package of RemoteAgent.GetWork
+	difficulty uint64      // x11 block difficulty
+	submitted  map[string]struct{}
+}
+
+type stratumSession struct {
+	id   uint64
+	conn net.Conn
+
+	writeMu sync.Mutex
+	enc     *json.Encoder
+
+	// Guarded by the mutex of the server
+	subscribed bool
+	worker     *stratumWorker
+	account    common.Address
+	difficulty uint64 // Share difficulty of new jobs
+	previous   uint64 // Share difficulty before the last retarget, accepted until the next job
+	shares     uint64 // x11 shares since the last retarget
+	retargeted time.Time
+}
+
+type stratumRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+type stratumResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result interface{}     `json:"result"`
+	Error  interface{}     `json:"error"`
+}
+
+type stratumNotification struct {
+	ID     interface{}   `json:"id"`
+	Method string        `json:"method"`
+	Params []interface{} `json:"params"`
+}
+
+type stratumError struct {
+	code    int
+	message string
+}
+
+// MarshalJSON encodes the error as the stratum [code, message, traceback].
+func (e *stratumError) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{e.code, e.message, nil})
+}
+
+// StratumServer is a TCP stratum endpoint for the remote miners of a
+// RemoteAgent. Every connection is a session of a worker, which is notified of
+// new work as soon as the agent gets it and submits shares at a difficulty
+// below the block difficulty, retargeted to a share every ten seconds. Shares
+// meeting the block difficulty are submitted to the agent. Nonce shares are
+// checked by the consensus.ShareVerifier of the engine of the block version,
+// they are rejected for engines not implementing it.
+//
+// Messages are JSON objects, one per line:
+//
+//	mining.subscribe       []                                       -> [[subscriptions], "", 0]
+//	mining.authorize       [worker, password]                       -> true
+//	mining.submit          [worker, job, dataType, nonce, extra]    -> true
+//	mining.submit_hashrate [hashrate]                               -> true
+//	mining.set_difficulty  [difficulty]                             (notification)
+//	mining.notify          [job, mineHash, seed, difficulty, mineType, coinbase, sm3Difficulty, clean]  (notification)
+//
+// Workers are named "account.rig", rewards are paid to the account. The data
+// types are those of RemoteAgent.SubmitWork, the extra parameter is the seed of
+// x11 nonces and the hash of AI solutions.
+type StratumServer struct {
+	agent  *RemoteAgent
+	config StratumConfig
+
+	listener net.Listener
+	sub      event.Subscription
+	quit     chan struct{}
+	wg       sync.WaitGroup
+
+	mu       sync.Mutex
+	sessions map[uint64]*stratumSession
+	workers  map[string]*stratumWorker
+	jobs     map[string]*stratumJob
+	jobOrder []string
+	job      *stratumJob // Current job
+	lastID   uint64      // Last session and job identifier
+}
+
+// NewStratumServer creates a stratum server for the miners of the agent.
+func NewStratumServer(agent *RemoteAgent, config StratumConfig) *StratumServer {
+	if config.Difficulty == 0 {
+		config.Difficulty = DefaultStratumDifficulty
+	}
+	return &StratumServer{
+		agent:    agent,
+		config:   config,
+		sessions: make(map[uint64]*stratumSession),
+		workers:  make(map[string]*stratumWorker),
+		jobs:     make(map[string]*stratumJob),
+	}
+}
+
+// Start starts listening for stratum connections.
+func (s *StratumServer) Start() error {
+	listener, err := net.Listen("tcp", s.config.Addr)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+	s.quit = make(chan struct{})
+
+	workCh := make(chan struct{}, 1)
+	s.sub = s.agent.SubscribeNewWork(workCh)
+
+	s.wg.Add(2)
+	go s.acceptLoop()
+	go s.loop(workCh)
+	log.Info("Stratum server started", "addr", listener.Addr(), "difficulty", s.config.Difficulty)
+	return nil
+}
+
+// Stop closes the listener and all sessions.
+func (s *StratumServer) Stop() {
+	if s.listener == nil {
+		return
+	}
+	close(s.quit)
+	s.sub.Unsubscribe()
+	s.listener.Close()
+
+	s.mu.Lock()
+	for _, sess := range s.sessions {
+		sess.conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	log.Info("Stratum server stopped")
+}
+
+// Workers returns the statistics of all workers seen since the start, ordered
+// by name.
+func (s *StratumServer) Workers() []StratumWorker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	result := make([]StratumWorker, 0, len(s.workers))
+	for _, worker := range s.workers {
+		stats := worker.stats
+		stats.Hashrate = worker.hashrate(now)
+		result = append(result, stats)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+func (s *StratumServer) acceptLoop() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.quit:
+				return
+			default:
+			}
+			if netErr, ok := err.(net.Error); ok && netErr.Temporary() {
+				time.Sleep(time.Second)
+				continue
+			}
+			log.Error("Stratum accept failed", "err", err)
+			return
+		}
+		s.mu.Lock()
+		s.lastID++
+		sess := &stratumSession{
+			id:         s.lastID,
+			conn:       conn,
+			enc:        json.NewEncoder(conn),
+			difficulty: s.config.Difficulty,
+			previous:   s.config.Difficulty,
+			retargeted: time.Now(),
+		}
+		s.sessions[sess.id] = sess
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.handle(sess)
+	}
+}
+
+// loop hands out new work and reports the hashrates until the server stops.
+func (s *StratumServer) loop(workCh chan struct{}) {
+	defer s.wg.Done()
+
+	report := time.NewTicker(stratumReportInterval)
+	defer report.Stop()
+
+	for {
+		select {
+		case <-workCh:
+			s.newJob()
+		case <-report.C:
+			s.report()
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// newJob notifies all sessions of the current work of the agent.
+func (s *StratumServer) newJob() {
+	work, err := s.agent.GetWork()
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	if s.job != nil && s.job.work == work {
+		s.mu.Unlock()
+		return
+	}
+	s.lastID++
+	job := &stratumJob{
+		id:         fmt.Sprintf("%x", s.lastID),
+		hash:       common.HexToHash(work[0]),
+		work:       work,
+		difficulty: new(big.Int).SetBytes(common.FromHex(work[2])).Uint64(),
+		submitted:  make(map[string]struct{}),
+	}
+	s.jobs[job.id] = job
+	s.jobOrder = append(s.jobOrder, job.id)
+	if len(s.jobOrder) > stratumJobHistory {
+		delete(s.jobs, s.jobOrder[0])
+		s.jobOrder = s.jobOrder[1:]
+	}
+	s.job = job
+
+	sessions := make([]*stratumSession, 0, len(s.sessions))
+	difficulties := make([]uint64, 0, len(s.sessions))
+	for _, sess := range s.sessions {
+		if sess.worker == nil {
+			continue
+		}
+		sess.previous = sess.difficulty
+		sessions = append(sessions, sess)
+		difficulties = append(difficulties, sess.shareDifficulty(job))
+	}
+	s.mu.Unlock()
+
+	log.Info("Stratum new job", "job", job.id, "type", work[3], "mine hash", job.hash.TerminalString(), "sessions", len(sessions))
+	for i, sess := range sessions {
+		sess.notifyJob(job, difficulties[i])
+	}
+}
+
+// report submits the hashrates of the connected workers to the agent and
+// retargets the share difficulty of the sessions.
+func (s *StratumServer) report() {
+	now := time.Now()
+
+	s.mu.Lock()
+	rates := make(map[common.Hash]uint64)
+	for _, worker := range s.workers {
+		if worker.stats.Sessions == 0 {
+			continue
+		}
+		rate := worker.stats.ReportedHashrate
+		if rate == 0 {
+			rate = worker.hashrate(now)
+		}
+		rates[worker.id] = rate
+	}
+	retargeted := make(map[*stratumSession]uint64)
+	for _, sess := range s.sessions {
+		if sess.worker == nil || now.Sub(sess.retargeted) < stratumRetargetInterval {
+			continue
+		}
+		if difficulty := s.retarget(sess, now); difficulty != sess.previous {
+			retargeted[sess] = sess.shareDifficulty(s.job)
+		}
+	}
+	s.mu.Unlock()
+
+	for id, rate := range rates {
+		s.agent.SubmitHashrate(id, rate)
+	}
+	for sess, difficulty := range retargeted {
+		sess.notify("mining.set_difficulty", difficulty)
+	}
+}
+
+// retarget adjusts the share difficulty of the session to the target share
+// time, by a factor of four at most. It returns the new difficulty.
+func (s *StratumServer) retarget(sess *stratumSession, now time.Time) uint64 {
+	elapsed := now.Sub(sess.retargeted)
+	old := sess.difficulty
+
+	difficulty := new(big.Int).SetUint64(old)
+	difficulty.Mul(difficulty, new(big.Int).SetUint64(sess.shares))
+	difficulty.Mul(difficulty, big.NewInt(int64(stratumTargetShareTime)))
+	difficulty.Div(difficulty, big.NewInt(int64(elapsed)))
+
+	next := old / 4
+	if difficulty.IsUint64() && difficulty.Uint64() > next {
+		next = difficulty.Uint64()
+	}
+	if max := old * 4; max > old && next > max {
+		next = max
+	}
+	if next < s.config.Difficulty {
+		next = s.config.Difficulty
+	}
+	if s.job != nil && s.job.difficulty > 0 && next > s.job.difficulty {
+		next = s.job.difficulty
+	}
+	sess.previous, sess.difficulty = old, next
+	sess.shares, sess.retargeted = 0, now
+	return next
+}
+
+// shareDifficulty returns the x11 share difficulty of the session for the job,
+// at most the block difficulty.
+func (sess *stratumSession) shareDifficulty(job *stratumJob) uint64 {
+	difficulty := sess.difficulty
+	if sess.previous < difficulty {
+		difficulty = sess.previous
+	}
+	if job != nil && job.difficulty > 0 && job.difficulty < difficulty {
+		difficulty = job.difficulty
+	}
+	return difficulty
+}
+
+func (sess *stratumSession) send(msg interface{}) error {
+	sess.writeMu.Lock()
+	defer sess.writeMu.Unlock()
+
+	sess.conn.SetWriteDeadline(time.Now().Add(stratumWriteTimeout))
+	if err := sess.enc.Encode(msg); err != nil {
+		sess.conn.Close()
+		return err
+	}
+	return nil
+}
+
+func (sess *stratumSession) notify(method string, params ...interface{}) {
+	sess.send(&stratumNotification{Method: method, Params: params})
+}
+
+func (sess *stratumSession) notifyJob(job *stratumJob, difficulty uint64) {
+	sess.notify("mining.set_difficulty", difficulty)
+	sess.notify("mining.notify", job.id, job.work[0], job.work[1], job.work[2], job.work[3], job.work[4], job.work[5], true)
+}
+
+// handle serves the requests of a session until it disconnects.
+func (s *StratumServer) handle(sess *stratumSession) {
+	defer s.wg.Done()
+	defer s.closeSession(sess)
+
+	scanner := bufio.NewScanner(sess.conn)
+	scanner.Buffer(make([]byte, 0, 4096), stratumMaxLineSize)
+	for {
+		sess.conn.SetReadDeadline(time.Now().Add(stratumIdleTimeout))
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				log.Debug("Stratum session closed", "session", sess.id, "addr", sess.conn.RemoteAddr(), "err", err)
+			}
+			return
+		}
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		var req stratumRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			sess.send(&stratumResponse{ID: json.RawMessage("null"), Error: &stratumError{stratumErrOther, "invalid request"}})
+			return
+		}
+		if len(req.ID) == 0 {
+			req.ID = json.RawMessage("null")
+		}
+		result, serr := s.dispatch(sess, &req)
+		resp := &stratumResponse{ID: req.ID, Result: result}
+		if serr != nil {
+			resp.Result, resp.Error = nil, serr
+		}
+		if err := sess.send(resp); err != nil {
+			return
+		}
+		if req.Method == "mining.authorize" && serr == nil {
+			s.mu.Lock()
+			job := s.job
+			difficulty := sess.shareDifficulty(job)
+			s.mu.Unlock()
+			if job != nil {
+				sess.notifyJob(job, difficulty)
+			} else {
+				sess.notify("mining.set_difficulty", difficulty)
+			}
+		}
+	}
+}
+
+func (s *StratumServer) closeSession(sess *stratumSession) {
+	sess.conn.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, sess.id)
+	if sess.worker != nil {
+		sess.worker.stats.Sessions--
+	}
+}
+
+func (s *StratumServer) dispatch(sess *stratumSession, req *stratumRequest) (interface{}, *stratumError) {
+	var params []string
+	if len(req.Params) > 0 && string(req.Params) != "null" {
+		var raw []interface{}
+		if err := json.Unmarshal(req.Params, &raw); err != nil {
+			return nil, &stratumError{stratumErrOther, "invalid params"}
+		}
+		for _, param := range raw {
+			switch param := param.(type) {
+			case string:
+				params = append(params, param)
+			case nil:
+				params = append(params, "")
+			default:
+				params = append(params, fmt.Sprint(param))
+			}
+		}
+	}
+
+	switch req.Method {
+	case "mining.subscribe":
+		s.mu.Lock()
+		sess.subscribed = true
+		s.mu.Unlock()
+		id := fmt.Sprintf("%x", sess.id)
+		return []interface{}{[][]string{{"mining.set_difficulty", id}, {"mining.notify", id}}, "", 0}, nil
+
+	case "mining.authorize":
+		if len(params) < 1 {
+			return nil, &stratumError{stratumErrOther, "missing worker name"}
+		}
+		return s.authorize(sess, params[0])
+
+	case "mining.submit":
+		if len(params) < 4 {
+			return nil, &stratumError{stratumErrOther, "missing share params"}
+		}
+		extra := ""
+		if len(params) > 4 {
+			extra = params[4]
+		}
+		return s.submit(sess, params[0], params[1], params[2], params[3], extra)
+
+	case "mining.submit_hashrate":
+		if len(params) < 1 {
+			return nil, &stratumError{stratumErrOther, "missing hashrate"}
+		}
+		rate, err := hexutil.DecodeUint64(params[0])
+		if err != nil {
+			return nil, &stratumError{stratumErrOther, "invalid hashrate"}
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if sess.worker == nil {
+			return nil, &stratumError{stratumErrUnauthorized, "unauthorized worker"}
+		}
+		sess.worker.stats.ReportedHashrate = rate
+		return true, nil
+
+	default:
+		return nil, &stratumError{stratumErrOther, fmt.Sprintf("unknown method %s", req.Method)}
+	}
+}
+
+// parseStratumWorker splits a worker name into the account and the rig, which
+// is "default" if the name is the account only.
+func parseStratumWorker(name string) (common.Address, string, error) {
+	if addr, err := base58.Base58DecodeToAddress(name); err == nil {
+		return addr, "default", nil
+	}
+	if common.IsHexAddress(name) {
+		return common.HexToAddress(name), "default", nil
+	}
+	i := strings.LastIndex(name, ".")
+	if i <= 0 || i == len(name)-1 {
+		return common.Address{}, "", errors.New("invalid worker name, expected account.rig")
+	}
+	account, rig := name[:i], name[i+1:]
+	if addr, err := base58.Base58DecodeToAddress(account); err == nil {
+		return addr, rig, nil
+	}
+	if common.IsHexAddress(account) {
+		return common.HexToAddress(account), rig, nil
+	}
+	return common.Address{}, "", fmt.Errorf("invalid account %s", account)
+}
+
+func (s *StratumServer) authorize(sess *stratumSession, name string) (interface{}, *stratumError) {
+	addr, rig, err := parseStratumWorker(name)
+	if err != nil {
+		return nil, &stratumError{stratumErrUnauthorized, err.Error()}
+	}
+	account := base58.Base58EncodeToString(params.MAN_COIN, addr)
+	name = account + "." + rig
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !sess.subscribed {
+		return nil, &stratumError{stratumErrNotSubscribed, "not subscribed"}
+	}
+	if sess.worker != nil {
+		if sess.worker.stats.Name == name {
+			return true, nil
+		}
+		sess.worker.stats.Sessions--
+	}
+	worker, ok := s.workers[name]
+	if !ok {
+		worker = &stratumWorker{
+			stats: StratumWorker{Name: name, Account: account},
+			id:    crypto.Keccak256Hash([]byte("stratum"), []byte(name)),
+			since: time.Now(),
+		}
+		s.workers[name] = worker
+	}
+	worker.stats.Sessions++
+	worker.stats.Difficulty = sess.shareDifficulty(s.job)
+	sess.worker, sess.account = worker, addr
+	log.Info("Stratum worker authorized", "worker", name, "session", sess.id, "addr", sess.conn.RemoteAddr())
+	return true, nil
+}
+
+// submit checks a share of the session and submits it to the agent if it
+// meets the block difficulty.
+func (s *StratumServer) submit(sess *stratumSession, name, jobID, dataType, nonce, extra string) (interface{}, *stratumError) {
+	s.mu.Lock()
+	worker, account := sess.worker, sess.account
+	job := s.jobs[jobID]
+	difficulty := sess.shareDifficulty(job)
+	s.mu.Unlock()
+
+	if worker == nil {
+		return nil, &stratumError{stratumErrUnauthorized, "unauthorized worker"}
+	}
+	var header *types.Header
+	if job != nil {
+		header = s.agent.pendingHeader(job.hash)
+	}
+	if header == nil {
+		s.record(worker, sess, func(stats *StratumWorker) { stats.Stale++ })
+		return nil, &stratumError{stratumErrStaleJob, "stale job"}
+	}
+	header.Coinbase = account
+
+	key := strings.ToLower(dataType + "/" + nonce + "/" + extra + "/" + worker.stats.Account)
+	s.mu.Lock()
+	_, duplicate := job.submitted[key]
+	job.submitted[key] = struct{}{}
+	s.mu.Unlock()
+	if duplicate {
+		s.record(worker, sess, func(stats *StratumWorker) { stats.Rejected++ })
+		return nil, &stratumError{stratumErrDuplicate, "duplicate share"}
+	}
+
+	verifier, _ := s.agent.engine[string(header.Version)].(consensus.ShareVerifier)
+	var share, block bool
+	switch dataType {
+	case Submitx11Nonce:
+		n, err := reverseToNonce(common.FromHex(nonce))
+		if err != nil {
+			s.record(worker, sess, func(stats *StratumWorker) { stats.Rejected++ })
+			return nil, &stratumError{stratumErrOther, "invalid nonce"}
+		}
+		header.Nonce = n
+		mixDigest := sha256.Sum256([]byte(extra))
+		header.MixDigest.SetBytes(mixDigest[:])
+		if verifier == nil {
+			return s.unverifiable(worker, sess, header)
+		}
+		share = verifier.VerifyX11Share(header, new(big.Int).SetUint64(difficulty))
+		block = share && verifier.VerifyX11Share(header, header.Difficulty)
+
+	case SubmitSM3Nonce:
+		n, ok := new(big.Int).SetString(strings.TrimPrefix(nonce, "0x"), 16)
+		if !ok || n.BitLen() > 32 {
+			s.record(worker, sess, func(stats *StratumWorker) { stats.Rejected++ })
+			return nil, &stratumError{stratumErrOther, "invalid nonce"}
+		}
+		header.Sm3Nonce = types.EncodeNonce(n.Uint64())
+		if verifier == nil {
+			return s.unverifiable(worker, sess, header)
+		}
+		blockDifficulty := calcSm3Difficulty(header.Difficulty)
+		shareDifficulty := calcSm3Difficulty(new(big.Int).SetUint64(difficulty))
+		if shareDifficulty.Cmp(blockDifficulty) > 0 {
+			shareDifficulty = blockDifficulty
+		}
+		share = verifier.VerifySm3Share(header, shareDifficulty)
+		block = share && verifier.VerifySm3Share(header, blockDifficulty)
+
+	case SubmitAiHash:
+		// AI solutions have no difficulty, every one is submitted to the agent.
+		share, block = true, true
+
+	default:
+		s.record(worker, sess, func(stats *StratumWorker) { stats.Rejected++ })
+		return nil, &stratumError{stratumErrOther, fmt.Sprintf("unknown data type %s", dataType)}
+	}
+	if !share {
+		s.record(worker, sess, func(stats *StratumWorker) { stats.Rejected++ })
+		return nil, &stratumError{stratumErrLowDifficulty, "low difficulty share"}
+	}
+
+	accepted := true
+	if block {
+		miner := base58.Base58EncodeToString(params.MAN_COIN, account)
+		switch dataType {
+		case Submitx11Nonce:
+			accepted = s.agent.SubmitWork(nonce, "", job.hash.Hex(), miner, extra, dataType, jobID)
+		case SubmitSM3Nonce:
+			accepted = s.agent.SubmitWork(nonce, "", job.hash.Hex(), miner, "", dataType, jobID)
+		case SubmitAiHash:
+			accepted = s.agent.SubmitWork("", extra, job.hash.Hex(), miner, "", dataType, jobID)
+		}
+		if accepted {
+			log.Info("Stratum block share submitted", "worker", name, "type", dataType, "job", jobID, "mine hash", job.hash.TerminalString())
+		}
+	}
+	if !accepted && dataType == SubmitAiHash {
+		s.record(worker, sess, func(stats *StratumWorker) { stats.Rejected++ })
+		return nil, &stratumError{stratumErrOther, "solution rejected"}
+	}
+	s.record(worker, sess, func(stats *StratumWorker) {
+		stats.Accepted++
+		stats.LastShare = uint64(time.Now().Unix())
+		if block && accepted {
+			stats.Blocks++
+		}
+		if dataType == Submitx11Nonce {
+			worker.shares = append(worker.shares, stratumShare{time.Now(), difficulty})
+			sess.shares++
+		}
+	})
+	return true, nil
+}
+
+// unverifiable rejects a share of a block version whose engine can't check
+// shares. Taking it for a block would submit every nonce to the agent.
+func (s *StratumServer) unverifiable(worker *stratumWorker, sess *stratumSession, header *types.Header) (interface{}, *stratumError) {
+	log.Warn("Stratum share rejected, engine can't verify shares", "version", string(header.Version), "number", header.Number)
+	s.record(worker, sess, func(stats *StratumWorker) { stats.Rejected++ })
+	return nil, &stratumError{stratumErrOther, "shares of this block version can't be verified"}
+}
+
+// record updates the statistics of the worker of the session.
+func (s *StratumServer) record(worker *stratumWorker, sess *stratumSession, update func(stats *StratumWorker)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	update(&worker.stats)
+	worker.stats.Difficulty = sess.shareDifficulty(s.job)
+}
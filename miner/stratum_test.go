@@ -0,0 +1,329 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package miner
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/consensus"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	_ "github.com/MatrixAINetwork/go-matrix/crypto/vrf"
+)
+
+const (
+	testShareDifficulty = 0x100
+	testBlockDifficulty = 0x10000
+)
+
+var testStratumAccount = common.HexToAddress("0x01")
+
+// testShareEngine accepts the nonces at least as large as the difficulty.
+type testShareEngine struct {
+	consensus.Engine
+}
+
+func (testShareEngine) VerifyX11Share(header *types.Header, difficulty *big.Int) bool {
+	return header.Nonce.Uint64() >= difficulty.Uint64()
+}
+
+func (testShareEngine) VerifySm3Share(header *types.Header, difficulty *big.Int) bool {
+	return header.Sm3Nonce.Uint64() >= difficulty.Uint64()
+}
+
+// newTestStratum makes a stratum server with a job for a work of the engine.
+func newTestStratum(engine consensus.Engine) (*StratumServer, chan *consensus.SealResult) {
+	agent := NewRemoteAgent(nil, map[string]consensus.Engine{"test": engine})
+	results := make(chan *consensus.SealResult, 4)
+	agent.SetReturnCh(results)
+	agent.currentWork = &Work{
+		header:    &types.Header{ParentHash: common.Hash{0x01}, Number: big.NewInt(1), Difficulty: big.NewInt(testBlockDifficulty), Version: []byte("test")},
+		createdAt: time.Now(),
+		mineType:  mineTaskTypePow,
+	}
+	server := NewStratumServer(agent, StratumConfig{Addr: "127.0.0.1:0", Difficulty: testShareDifficulty})
+	server.newJob()
+	return server, results
+}
+
+// newTestSession authorizes a session of the worker without a connection.
+func newTestSession(t *testing.T, s *StratumServer, worker string) *stratumSession {
+	conn, _ := net.Pipe()
+	s.lastID++
+	sess := &stratumSession{id: s.lastID, conn: conn, subscribed: true, difficulty: s.config.Difficulty, previous: s.config.Difficulty, retargeted: time.Now()}
+	s.sessions[sess.id] = sess
+	if _, err := s.authorize(sess, worker); err != nil {
+		t.Fatalf("authorize failed: %v", err.message)
+	}
+	return sess
+}
+
+// x11Nonce encodes the nonce the way the miners submit it.
+func x11Nonce(n uint32) string {
+	return hexutil.Encode([]byte{byte(n), byte(n >> 8), byte(n >> 16), byte(n >> 24)})
+}
+
+func workerStats(s *StratumServer, name string) StratumWorker {
+	for _, worker := range s.Workers() {
+		if worker.Name == name {
+			return worker
+		}
+	}
+	return StratumWorker{}
+}
+
+// Tests that shares are checked against the share and block difficulty, and
+// that duplicate and stale shares are rejected and counted.
+func TestStratumShares(t *testing.T) {
+	s, _ := newTestStratum(testShareEngine{})
+	sess := newTestSession(t, s, testStratumAccount.Hex()+".rig")
+	job := s.job.id
+	sm3Share := calcSm3Difficulty(big.NewInt(testShareDifficulty)).Uint64()
+
+	tests := []struct {
+		name     string
+		job      string
+		dataType string
+		nonce    string
+		code     int // Error code, zero if accepted
+		stats    StratumWorker
+	}{
+		{"share", job, Submitx11Nonce, x11Nonce(0x200), 0, StratumWorker{Accepted: 1}},
+		{"duplicate", job, Submitx11Nonce, x11Nonce(0x200), stratumErrDuplicate, StratumWorker{Accepted: 1, Rejected: 1}},
+		{"low difficulty", job, Submitx11Nonce, x11Nonce(0x10), stratumErrLowDifficulty, StratumWorker{Accepted: 1, Rejected: 2}},
+		{"unknown job", "ff", Submitx11Nonce, x11Nonce(0x300), stratumErrStaleJob, StratumWorker{Accepted: 1, Rejected: 2, Stale: 1}},
+		{"block", job, Submitx11Nonce, x11Nonce(testBlockDifficulty), 0, StratumWorker{Accepted: 2, Rejected: 2, Stale: 1, Blocks: 1}},
+		{"sm3 share", job, SubmitSM3Nonce, fmt.Sprintf("0x%x", sm3Share), 0, StratumWorker{Accepted: 3, Rejected: 2, Stale: 1, Blocks: 1}},
+		{"sm3 low difficulty", job, SubmitSM3Nonce, fmt.Sprintf("0x%x", sm3Share-1), stratumErrLowDifficulty, StratumWorker{Accepted: 3, Rejected: 3, Stale: 1, Blocks: 1}},
+		{"invalid nonce", job, Submitx11Nonce, "0x01", stratumErrOther, StratumWorker{Accepted: 3, Rejected: 4, Stale: 1, Blocks: 1}},
+		{"unknown type", job, "x12Nonce", x11Nonce(0x400), stratumErrOther, StratumWorker{Accepted: 3, Rejected: 5, Stale: 1, Blocks: 1}},
+	}
+	for _, test := range tests {
+		_, err := s.submit(sess, "rig", test.job, test.dataType, test.nonce, "seed")
+		if (err == nil) != (test.code == 0) || (err != nil && err.code != test.code) {
+			t.Fatalf("%s: result mismatch: have %v, want code %d", test.name, err, test.code)
+		}
+		stats := workerStats(s, sess.worker.stats.Name)
+		if stats.Accepted != test.stats.Accepted || stats.Rejected != test.stats.Rejected || stats.Stale != test.stats.Stale || stats.Blocks != test.stats.Blocks {
+			t.Fatalf("%s: stats mismatch: have %d/%d/%d/%d, want %d/%d/%d/%d", test.name, stats.Accepted, stats.Rejected, stats.Stale, stats.Blocks,
+				test.stats.Accepted, test.stats.Rejected, test.stats.Stale, test.stats.Blocks)
+		}
+	}
+	if sess.shares != 2 {
+		t.Errorf("x11 share count mismatch: have %d, want 2", sess.shares)
+	}
+
+	// Shares of the same nonce from another account aren't duplicates
+	other := newTestSession(t, s, common.HexToAddress("0x02").Hex()+".rig")
+	if _, err := s.submit(other, "other", job, Submitx11Nonce, x11Nonce(0x200), "seed"); err != nil {
+		t.Errorf("share of another account rejected: %v", err)
+	}
+
+	// Shares for work the agent expired are stale
+	delete(s.agent.work, s.job.hash)
+	if _, err := s.submit(sess, "rig", job, Submitx11Nonce, x11Nonce(0x500), "seed"); err == nil || err.code != stratumErrStaleJob {
+		t.Errorf("share of expired work: have %v, want stale", err)
+	}
+}
+
+// Tests that nonce shares are rejected if the engine can't verify them,
+// instead of being taken for blocks.
+func TestStratumUnverifiableShares(t *testing.T) {
+	s, results := newTestStratum(struct{ consensus.Engine }{})
+	sess := newTestSession(t, s, testStratumAccount.Hex()+".rig")
+
+	if _, err := s.submit(sess, "rig", s.job.id, Submitx11Nonce, x11Nonce(0x200), "seed"); err == nil {
+		t.Fatalf("unverifiable x11 share accepted")
+	}
+	if _, err := s.submit(sess, "rig", s.job.id, SubmitSM3Nonce, "0x200", ""); err == nil {
+		t.Fatalf("unverifiable sm3 share accepted")
+	}
+	if stats := workerStats(s, sess.worker.stats.Name); stats.Rejected != 2 || stats.Blocks != 0 {
+		t.Errorf("stats mismatch: have %d rejected, %d blocks", stats.Rejected, stats.Blocks)
+	}
+	// AI solutions have no difficulty and still reach the agent
+	if _, err := s.submit(sess, "rig", s.job.id, SubmitAiHash, "", common.Hash{0x02}.Hex()); err != nil {
+		t.Fatalf("ai solution rejected: %v", err)
+	}
+	select {
+	case result := <-results:
+		if result.Type != consensus.SealTypeAI || result.Header.AIHash != (common.Hash{0x02}) {
+			t.Errorf("result mismatch: have %v %x", result.Type, result.Header.AIHash)
+		}
+	default:
+		t.Errorf("ai solution not submitted")
+	}
+}
+
+func TestStratumRetarget(t *testing.T) {
+	tests := []struct {
+		old    uint64
+		shares uint64
+		want   uint64
+	}{
+		{1024, 6, 1024},                               // One share every ten seconds
+		{1024, 12, 2048},                              // Twice as fast
+		{1024, 60, 4096},                              // At most four times the difficulty
+		{1024, 0, 256},                                // At least a quarter of it
+		{512, 0, testShareDifficulty},                 // Not below the configured difficulty
+		{0x8000, 600, testBlockDifficulty},            // Not above the block difficulty
+		{testShareDifficulty, 1, testShareDifficulty}, // Slow rigs stay at the configured one
+	}
+	for i, test := range tests {
+		s, _ := newTestStratum(testShareEngine{})
+		now := time.Now()
+		sess := &stratumSession{difficulty: test.old, previous: test.old, shares: test.shares, retargeted: now.Add(-stratumRetargetInterval)}
+
+		if have := s.retarget(sess, now); have != test.want || sess.difficulty != test.want {
+			t.Errorf("test %d: difficulty mismatch: have %d, want %d", i, have, test.want)
+		}
+		if sess.shares != 0 || sess.retargeted != now || sess.previous != test.old {
+			t.Errorf("test %d: session not reset: shares %d, previous %d", i, sess.shares, sess.previous)
+		}
+		// Shares at the lower of both difficulties are accepted until the next job
+		want := test.old
+		if test.want < want {
+			want = test.want
+		}
+		if have := sess.shareDifficulty(s.job); have != want {
+			t.Errorf("test %d: share difficulty mismatch: have %d, want %d", i, have, want)
+		}
+	}
+}
+
+// testStratumClient is a miner connected to the server.
+type testStratumClient struct {
+	conn    net.Conn
+	scanner *bufio.Scanner
+	id      int
+}
+
+type testStratumMessage struct {
+	ID     *int            `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  []interface{}   `json:"error"`
+	Method string          `json:"method"`
+	Params []interface{}   `json:"params"`
+}
+
+func (c *testStratumClient) next(t *testing.T) *testStratumMessage {
+	c.conn.SetReadDeadline(time.Now().Add(time.Second))
+	if !c.scanner.Scan() {
+		t.Fatalf("read failed: %v", c.scanner.Err())
+	}
+	msg := new(testStratumMessage)
+	if err := json.Unmarshal(c.scanner.Bytes(), msg); err != nil {
+		t.Fatalf("invalid message %s: %v", c.scanner.Bytes(), err)
+	}
+	return msg
+}
+
+func (c *testStratumClient) call(t *testing.T, method string, params ...interface{}) *testStratumMessage {
+	c.id++
+	if err := json.NewEncoder(c.conn).Encode(map[string]interface{}{"id": c.id, "method": method, "params": params}); err != nil {
+		t.Fatalf("%s: write failed: %v", method, err)
+	}
+	msg := c.next(t)
+	if msg.ID == nil || *msg.ID != c.id {
+		t.Fatalf("%s: response id mismatch: have %v, want %d", method, msg.ID, c.id)
+	}
+	return msg
+}
+
+// Tests a session over the network: subscribing, authorizing, receiving the
+// current job and submitting a share to it.
+func TestStratumSession(t *testing.T) {
+	s, _ := newTestStratum(testShareEngine{})
+	if err := s.Start(); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	defer s.Stop()
+
+	conn, err := net.Dial("tcp", s.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	client := &testStratumClient{conn: conn, scanner: bufio.NewScanner(conn)}
+	worker := testStratumAccount.Hex() + ".rig"
+
+	if msg := client.call(t, "mining.authorize", worker, ""); len(msg.Error) == 0 || msg.Error[0] != float64(stratumErrNotSubscribed) {
+		t.Fatalf("authorize before subscribe: have error %v", msg.Error)
+	}
+	if msg := client.call(t, "mining.subscribe"); msg.Error != nil {
+		t.Fatalf("subscribe failed: %v", msg.Error)
+	}
+	if msg := client.call(t, "mining.authorize", worker, ""); msg.Error != nil || string(msg.Result) != "true" {
+		t.Fatalf("authorize failed: %s %v", msg.Result, msg.Error)
+	}
+	if msg := client.next(t); msg.Method != "mining.set_difficulty" || msg.Params[0] != float64(testShareDifficulty) {
+		t.Fatalf("difficulty notification mismatch: %s %v", msg.Method, msg.Params)
+	}
+	msg := client.next(t)
+	if msg.Method != "mining.notify" || msg.Params[0] != s.job.id || msg.Params[1] != s.job.hash.Hex() || msg.Params[7] != true {
+		t.Fatalf("job notification mismatch: %s %v", msg.Method, msg.Params)
+	}
+	if msg := client.call(t, "mining.submit", worker, s.job.id, Submitx11Nonce, x11Nonce(0x200), "seed"); msg.Error != nil {
+		t.Fatalf("submit failed: %v", msg.Error)
+	}
+	if msg := client.call(t, "mining.submit_hashrate", "0x500"); msg.Error != nil {
+		t.Fatalf("submit hashrate failed: %v", msg.Error)
+	}
+	workers := s.Workers()
+	if len(workers) != 1 || workers[0].Sessions != 1 || workers[0].Accepted != 1 || workers[0].ReportedHashrate != 0x500 {
+		t.Fatalf("workers mismatch: have %+v", workers)
+	}
+
+	conn.Close()
+	for deadline := time.Now().Add(time.Second); workerStats(s, workers[0].Name).Sessions != 0; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("session not closed")
+		}
+	}
+}
+
+// Tests that the agent keeps taking work while a subscriber doesn't read its
+// notifications.
+func TestRemoteAgentNewWorkNotify(t *testing.T) {
+	agent := NewRemoteAgent(nil, nil)
+	agent.Start()
+	defer agent.Stop()
+
+	ch := make(chan struct{}, 1)
+	sub := agent.SubscribeNewWork(ch)
+	for i := 0; i < 3; i++ {
+		select {
+		case agent.Work() <- &Work{header: &types.Header{Number: big.NewInt(int64(i))}}:
+		case <-time.After(time.Second):
+			t.Fatalf("work %d blocked", i)
+		}
+	}
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+		agent.mu.Lock()
+		current := agent.currentWork
+		agent.mu.Unlock()
+		if current != nil && current.header.Number.Int64() == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("work not taken")
+		}
+	}
+	if len(ch) != 1 {
+		t.Fatalf("notification count mismatch: have %d, want 1", len(ch))
+	}
+	<-ch
+	sub.Unsubscribe()
+	agent.notifyNewWork()
+	if len(ch) != 0 {
+		t.Errorf("notified after unsubscribe")
+	}
+}
@@ -12,20 +12,56 @@ import (
 	"github.com/pkg/errors"
 )
 
+// Transport carries the encoded algorithm messages to the other nodes. The
+// sends must not block the caller.
+type Transport interface {
+	SendToGroup(roles common.RoleType, data NetData)
+	SendToSingle(addr common.Address, data NetData) error
+}
+
+// p2pTransport sends the messages over the p2p network of the node.
+type p2pTransport struct{}
+
+func (p2pTransport) SendToGroup(roles common.RoleType, data NetData) {
+	go p2p.SendToGroup(roles, common.AlgorithmMsg, data)
+}
+
+func (p2pTransport) SendToSingle(addr common.Address, data NetData) error {
+	go func() {
+		err := p2p.SendToSingle(addr, common.AlgorithmMsg, data)
+		if err != nil {
+			log.Error("SendToSignal", "address", addr.Hex(), "err", err)
+		}
+	}()
+	return nil
+}
+
 type HD struct {
-	dataChan chan *AlgorithmMsg
-	dataSub  event.Subscription
-	codecMap map[mc.EventCode]MsgCodec
+	dataChan  chan *AlgorithmMsg
+	dataSub   event.Subscription
+	codecMap  map[mc.EventCode]MsgCodec
+	center    *mc.Center
+	transport Transport
+	quit      chan struct{}
 }
 
 func NewHD() (*HD, error) {
+	return NewHDWithTransport(mc.DefaultCenter(), p2pTransport{})
+}
+
+// NewHDWithTransport creates a dispatcher receiving from and publishing to the
+// given center and sending through the given transport.
+func NewHDWithTransport(center *mc.Center, transport Transport) (*HD, error) {
 	hd := &HD{
-		dataChan: make(chan *AlgorithmMsg, 10),
-		codecMap: make(map[mc.EventCode]MsgCodec),
+		dataChan:  make(chan *AlgorithmMsg, 10),
+		codecMap:  make(map[mc.EventCode]MsgCodec),
+		center:    center,
+		transport: transport,
+		quit:      make(chan struct{}),
 	}
 	//订阅网络消息
 	var err error
-	hd.dataSub, err = mc.SubscribeEvent(mc.P2P_HDMSG, hd.dataChan)
+	hd.dataSub, err = center.SubscribeEvent(mc.P2P_HDMSG, hd.dataChan)
 	if err != nil {
 		return nil, err
 	}
@@ -37,6 +73,12 @@ func NewHD() (*HD, error) {
 	return hd, nil
 }
 
+// Close stops receiving the network messages.
+func (self *HD) Close() {
+	self.dataSub.Unsubscribe()
+	close(self.quit)
+}
+
 func (self *HD) SendNodeMsg(subCode mc.EventCode, msg interface{}, Roles common.RoleType, nodes []common.Address) {
 	codec, err := self.findCodec(subCode)
 	if err != nil {
@@ -57,7 +99,7 @@ func (self *HD) SendNodeMsg(subCode mc.EventCode, msg interface{}, Roles common.
 
 	if nodes == nil {
 		log.Info("SendToGroup", "roles", Roles.String(), "SubCode", subCode)
-		go p2p.SendToGroup(Roles, common.AlgorithmMsg, sendData)
+		self.transport.SendToGroup(Roles, sendData)
 	} else {
 		log.Info("SendToSignal", "total address count", len(nodes), "SubCode", subCode)
 		for _, addr := range nodes {
@@ -66,12 +108,9 @@ func (self *HD) SendNodeMsg(subCode mc.EventCode, msg interface{}, Roles common.
 				continue
 			}
 			log.Info("SendToSignal", "address", addr.Hex())
-			go func() {
-				err := p2p.SendToSingle(addr, common.AlgorithmMsg, sendData)
-				if err != nil {
-					log.Error("SendToSignal", "address", addr.Hex(), "err", err)
-				}
-			}()
+			if err := self.transport.SendToSingle(addr, sendData); err != nil {
+				log.Error("SendToSignal", "address", addr.Hex(), "err", err)
+			}
 		}
 	}
 }
@@ -80,23 +119,40 @@ func (self *HD) receive() {
 	for {
 		select {
 		case data := <-self.dataChan:
-			subCode := mc.EventCode(data.Data.SubCode)
-			log.Trace("HD", "SubCode", subCode, "from", data.Account.Hex())
-			codec, err := self.findCodec(subCode)
-			if err != nil {
-				log.Error("HD", "receive findCodec err", err)
-				break
-			}
-			msg, err := codec.DecodeFn(data.Data.Msg, data.Account)
-			if err != nil {
-				log.Error("HD", "DecodeFn err", err, "subCode", subCode, "from", data.Account.Hex())
-				break
-			}
-			mc.PublishEvent(subCode, msg)
+			self.Deliver(data)
+		case <-self.quit:
+			return
 		}
 	}
 }
 
+// Deliver decodes a message received from the network and publishes it to the
+// services of the node.
+func (self *HD) Deliver(data *AlgorithmMsg) error {
+	subCode, msg, err := self.Decode(data)
+	if err != nil {
+		return err
+	}
+	return self.center.PublishEvent(subCode, msg)
+}
+
+// Decode restores the message carried by a network message.
+func (self *HD) Decode(data *AlgorithmMsg) (mc.EventCode, interface{}, error) {
+	subCode := mc.EventCode(data.Data.SubCode)
+	log.Trace("HD", "SubCode", subCode, "from", data.Account.Hex())
+	codec, err := self.findCodec(subCode)
+	if err != nil {
+		log.Error("HD", "receive findCodec err", err)
+		return subCode, nil, err
+	}
+	msg, err := codec.DecodeFn(data.Data.Msg, data.Account)
+	if err != nil {
+		log.Error("HD", "DecodeFn err", err, "subCode", subCode, "from", data.Account.Hex())
+		return subCode, nil, err
+	}
+	return subCode, msg, nil
+}
+
 func (self *HD) registerCodec(subCode mc.EventCode, codec MsgCodec) {
 	_, exist := self.codecMap[subCode]
 	if exist {
@@ -0,0 +1,44 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+// Contains the metrics of the elect online state.
+
+package olconsensus
+
+import (
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/metrics"
+)
+
+var (
+	electOnlineGauge  = metrics.NewRegisteredGauge("olconsensus/elect/online", nil)
+	electOfflineGauge = metrics.NewRegisteredGauge("olconsensus/elect/offline", nil)
+	selfOnlineGauge   = metrics.NewRegisteredGauge("olconsensus/self/online", nil) // 1 online, 0 offline, -1 not elected
+)
+
+// updateOnlineMetrics updates the online metrics from the elect online state
+// of a block.
+func (serv *TopNodeService) updateOnlineMetrics(electOnline *mc.ElectOnlineStatus) {
+	var online, offline int64
+	self := int64(-1)
+	for _, node := range electOnline.ElectOnline {
+		state := int64(0)
+		switch node.Position {
+		case common.PosOnline:
+			online++
+			state = 1
+		case common.PosOffline:
+			offline++
+		default:
+			continue
+		}
+		if serv.validatorSign.IsSelfAddress(node.Account) {
+			self = state
+		}
+	}
+	electOnlineGauge.Update(online)
+	electOfflineGauge.Update(offline)
+	selfOnlineGauge.Update(self)
+}
@@ -27,6 +27,8 @@ type TopNodeStateInterface interface {
 type ValidatorAccountInterface interface {
 	SignWithValidate(hash []byte, validate bool, blkhash common.Hash) (sig common.Signature, err error)
 	IsSelfAddress(addr common.Address) bool
+	SignAddress() common.Address
+	DepositAddress() common.Address
 }
 
 type MessageSendInterface interface {
@@ -47,12 +49,16 @@ type StateReaderInterface interface {
 type TopNodeInstance struct {
 	signHelper *signhelper.SignHelper
 	hd         *msgsend.HD
+	center     *mc.Center
+	ca         ca.Provider
 }
 
-func NewTopNodeInstance(sh *signhelper.SignHelper, hd *msgsend.HD) *TopNodeInstance {
+func NewTopNodeInstance(sh *signhelper.SignHelper, hd *msgsend.HD, center *mc.Center, identity ca.Provider) *TopNodeInstance {
 	return &TopNodeInstance{
 		signHelper: sh,
 		hd:         hd,
+		center:     center,
+		ca:         identity,
 	}
 }
 
@@ -61,7 +67,7 @@ func (self *TopNodeInstance) GetTopNodeOnlineState() []NodeOnLineInfo {
 	//调用p2p的接口获取节点在线状态
 	result := p2p.GetTopNodeAliveInfo(common.RoleValidator | common.RoleBackupValidator)
 	for _, value := range result {
-		account, err := ca.ConvertSignToDepositAddress(value.Account)
+		account, err := self.ca.ConvertSignToDepositAddress(value.Account)
 		if err != nil {
 			log.Debug("共识节点状态", "node转换A0账户失败", value.Account.Hex(), "err", err)
 			continue
@@ -83,7 +89,15 @@ func (self *TopNodeInstance) SignWithValidate(hash []byte, validate bool, blkhas
 }
 
 func (self *TopNodeInstance) IsSelfAddress(addr common.Address) bool {
-	return ca.GetDepositAddress() == addr
+	return self.ca.GetDepositAddress() == addr
+}
+
+func (self *TopNodeInstance) SignAddress() common.Address {
+	return self.ca.GetSignAddress()
+}
+
+func (self *TopNodeInstance) DepositAddress() common.Address {
+	return self.ca.GetDepositAddress()
 }
 
 func (self *TopNodeInstance) SendNodeMsg(subCode mc.EventCode, msg interface{}, Roles common.RoleType, address []common.Address) {
@@ -92,9 +106,9 @@ func (self *TopNodeInstance) SendNodeMsg(subCode mc.EventCode, msg interface{},
 }
 
 func (self *TopNodeInstance) SubscribeEvent(aim mc.EventCode, ch interface{}) (event.Subscription, error) {
-	return mc.SubscribeEvent(aim, ch)
+	return self.center.SubscribeEvent(aim, ch)
 }
 
 func (self *TopNodeInstance) PublishEvent(aim mc.EventCode, data interface{}) error {
-	return mc.PublishEvent(aim, data)
+	return self.center.PublishEvent(aim, data)
 }
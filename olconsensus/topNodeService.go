@@ -6,7 +6,6 @@ package olconsensus
 import (
 	"errors"
 
-	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/consensus"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
@@ -109,7 +108,7 @@ func (serv *TopNodeService) Start() error {
 func (serv *TopNodeService) subMsg() error {
 	var err error
 
-	serv.roleUpdateSub, err = mc.SubscribeEvent(mc.CA_RoleUpdated, serv.roleUpdateCh) //身份到达
+	serv.roleUpdateSub, err = serv.msgCenter.SubscribeEvent(mc.CA_RoleUpdated, serv.roleUpdateCh) //身份到达
 	if err != nil {
 		log.Error(serv.extraInfo, "身份更新订阅失败", err)
 		return err
@@ -170,6 +169,7 @@ func (serv *TopNodeService) update() {
 
 				//log.Debug(serv.extraInfo, "处理CA通知消息", "", "块高", data.BlockNum)
 				serv.stateMap.SetCurStates(data.BlockNum+1, topology, electOnline)
+				serv.updateOnlineMetrics(electOnline)
 				go serv.LeaderChangeNotifyHandler(serv.msgCheck.GetCurLeader())
 			}
 		case data := <-serv.leaderChangeCh:
@@ -227,7 +227,7 @@ func (serv *TopNodeService) LeaderChangeNotifyHandler(leader common.Address) {
 					vote := mc.HD_ConsensusVote{}
 					vote.SignHash.Set(reqHash)
 					vote.Sign.Set(sign)
-					vote.From.Set(ca.GetSignAddress())
+					vote.From.Set(serv.validatorSign.SignAddress())
 					//将该共识投票结果加入共识投票列表
 					var msg mc.HD_OnlineConsensusVotes
 					msg.Votes = append(msg.Votes, vote)
@@ -252,9 +252,9 @@ func (serv *TopNodeService) getTopNodeState(leader common.Address) (online, offl
 }
 
 func (serv *TopNodeService) sendRequest(online, offline []common.Address) {
-	leader := ca.GetDepositAddress()
+	leader := serv.validatorSign.DepositAddress()
 	reqMsg := mc.HD_OnlineConsensusReqs{
-		From: ca.GetSignAddress(),
+		From: serv.validatorSign.SignAddress(),
 	}
 	number, turn := serv.msgCheck.GetRound()
 	for _, item := range online {
@@ -313,7 +313,7 @@ func (serv *TopNodeService) consensusReqMsgHandler(msg *mc.HD_OnlineConsensusReq
 					vote := mc.HD_ConsensusVote{}
 					vote.SignHash.Set(reqHash)
 					vote.Sign.Set(sign)
-					vote.From.Set(ca.GetSignAddress())
+					vote.From.Set(serv.validatorSign.SignAddress())
 					votes.Votes = append(votes.Votes, vote)
 					log.Info(serv.extraInfo, "处理共识请求", "处理成功", "req Number", item.Number, "req turn", item.LeaderTurn, "请求hash", reqHash.TerminalString())
 					ds, have := serv.dposRing.findProposal(reqHash)
@@ -402,7 +402,7 @@ func (serv *TopNodeService) consensusVotes(proposal interface{}, votes []voteInf
 	result := mc.HD_OnlineConsensusVoteResultMsg{
 		Req:      prop,
 		SignList: rightSigns,
-		From:     ca.GetSignAddress(),
+		From:     serv.validatorSign.SignAddress(),
 	}
 
 	serv.msgSender.SendNodeMsg(mc.HD_TopNodeConsensusVoteResult, &result, common.RoleValidator, nil)
@@ -182,6 +182,14 @@ func (ts *testNodeState) IsSelfAddress(addr common.Address) bool {
 	return ts.self.Address == addr
 }
 
+func (ts *testNodeState) SignAddress() common.Address {
+	return ts.self.Address
+}
+
+func (ts *testNodeState) DepositAddress() common.Address {
+	return ts.self.Address
+}
+
 type testNodeService struct {
 	TN       *TopNodeService
 	msgChan  chan interface{}
@@ -19,6 +19,7 @@ import (
 
 type Linker struct {
 	role common.RoleType
+	ca   ca.LinkProvider
 
 	active          bool
 	broadcastActive bool
@@ -93,7 +94,7 @@ func (l *Linker) Start() {
 				if l.role != r.Role {
 					l.role = r.Role
 				}
-				dropNodes := ca.GetDropNode()
+				dropNodes := l.ca.GetDropNode()
 				l.dropNode(dropNodes)
 
 				l.maintainPeer()
@@ -203,7 +204,7 @@ func (l *Linker) dropNodeDefer(drops []common.Address) {
 // Link peers that should to link.
 // link peers by group
 func (l *Linker) link(roleType common.RoleType) {
-	all := ca.GetTopologyInLinker()
+	all := l.ca.GetTopologyInLinker()
 	for key, peers := range all {
 		if key >= roleType {
 			for _, peer := range peers {
@@ -212,7 +213,7 @@ func (l *Linker) link(roleType common.RoleType) {
 		}
 	}
 	if roleType&(common.RoleValidator|common.RoleBackupValidator) != 0 {
-		gap := ca.GetGapValidator()
+		gap := l.ca.GetGapValidator()
 		for _, val := range gap {
 			ServerP2p.AddPeerTask(val)
 		}
@@ -239,7 +240,7 @@ func (l *Linker) recordTopNodeActiveInfo() {
 	defer l.topMu.Unlock()
 
 	for i := int(common.RoleBackupMiner); i <= int(common.RoleValidator); i = i << 1 {
-		topNodes := ca.GetRolesByGroup(common.RoleType(i))
+		topNodes := l.ca.GetRolesByGroup(common.RoleType(i))
 
 		for _, tn := range topNodes {
 			if tn == ServerP2p.ManAddress {
@@ -303,8 +304,8 @@ func (l *Linker) ToLink() {
 	defer Link.mu.Unlock()
 
 	l.linkMap = make(map[common.Address]uint32)
-	h := ca.GetHash()
-	elects, _ := ca.GetElectedByHeightByHash(h)
+	h := l.ca.GetHash()
+	elects, _ := l.ca.GetElectedByHash(h)
 
 	if len(elects) <= MaxLinkers {
 		for _, elect := range elects {
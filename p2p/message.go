@@ -12,7 +12,6 @@ import (
 	"sync/atomic"
 	"time"
 
-	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/event"
 	"github.com/MatrixAINetwork/go-matrix/log"
@@ -119,7 +118,7 @@ func SendToSingle(addr common.Address, msgCode uint64, data interface{}) error {
 
 // SendToGroup send message to a group.
 func SendToGroupWithBackup(to common.RoleType, msgCode uint64, data interface{}) error {
-	address := ca.GetRolesByGroupWithNextElect(to)
+	address := ServerP2p.identity().GetRolesByGroupWithNextElect(to)
 	peers := ServerP2p.Peers()
 	for _, addr := range address {
 		if addr == ServerP2p.ManAddress {
@@ -147,7 +146,7 @@ func SendToGroupWithBackup(to common.RoleType, msgCode uint64, data interface{})
 
 // SendToGroup send message to a group.
 func SendToGroup(to common.RoleType, msgCode uint64, data interface{}) error {
-	address := ca.GetRolesByGroup(to)
+	address := ServerP2p.identity().GetRolesByGroup(to)
 	peers := ServerP2p.Peers()
 	log.Trace("message.go", "查看所有的 ServerP2P peers Count", len(peers), "目标IDS数量", len(address), "role", to.String())
 	for _, addr := range address {
@@ -0,0 +1,487 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package p2p
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/p2p/discover"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+)
+
+// Offence is a kind of peer misbehaviour, adding its penalty to the score of
+// the peer.
+type Offence string
+
+const (
+	OffenceInvalidBlock        Offence = "invalid_block"         // Block or header failing verification
+	OffenceInvalidConsensusMsg Offence = "invalid_consensus_msg" // Consensus message with a mismatching signature
+	OffenceInvalidTx           Offence = "invalid_tx"            // Transaction refused as invalid by the pool
+	OffenceBadMessage          Offence = "bad_message"           // Undecodable or malformed protocol message
+	OffenceSpam                Offence = "spam"                  // Messages beyond the rate or count limits
+)
+
+// ReputationConfig configures the scoring and banning of misbehaving peers.
+type ReputationConfig struct {
+	// Penalties overrides the scores added per offence.
+	Penalties map[Offence]int `toml:",omitempty"`
+
+	// BanThreshold is the score at which a node is banned.
+	BanThreshold int
+
+	// BanDuration is how long nodes reaching the threshold are banned.
+	BanDuration time.Duration
+
+	// DecayPerHour is the score forgiven per hour, negative to never forgive.
+	DecayPerHour int
+}
+
+// DefaultReputationConfig bans for a day the nodes committing about two
+// invalid blocks, four invalid consensus messages or fifty invalid
+// transactions within a few hours.
+var DefaultReputationConfig = ReputationConfig{
+	BanThreshold: 100,
+	BanDuration:  24 * time.Hour,
+	DecayPerHour: 10,
+}
+
+var defaultPenalties = map[Offence]int{
+	OffenceInvalidBlock:        50,
+	OffenceInvalidConsensusMsg: 25,
+	OffenceInvalidTx:           2,
+	OffenceBadMessage:          20,
+	OffenceSpam:                10,
+}
+
+// reputationFlushInterval is the interval at which changed scores are written
+// and the scores decayed to zero are dropped.
+const reputationFlushInterval = 30 * time.Second
+
+var (
+	errBannedPeer  = errors.New("banned peer")
+	errBanTarget   = errors.New("ban target is neither a node ID, an enode URL nor an IP address")
+	reputationKey  = []byte("reputation-scores")
+	reputationBans = []byte("reputation-bans")
+)
+
+// Ban is a banned node ID or IP address.
+type Ban struct {
+	Target  string `json:"target"` // Hex node ID or IP address
+	Reason  string `json:"reason"`
+	Created uint64 `json:"created"`
+	Expires uint64 `json:"expires"` // Unix time, 0 for permanent bans
+}
+
+type peerScore struct {
+	ID      discover.NodeID
+	Score   uint64
+	Updated uint64 // Unix time up to which the decay is applied
+}
+
+// Reputation scores the misbehaviour of peers and bans the nodes reaching the
+// threshold. Scores and bans are persisted in the node database.
+type Reputation struct {
+	cfg ReputationConfig
+	db  mandb.Database
+
+	mu     sync.Mutex
+	scores map[discover.NodeID]*peerScore
+	bans   map[string]*Ban
+	dirty  bool // Scores changed since they were written
+
+	quit chan struct{}
+	done chan struct{}
+}
+
+// NewReputation loads the scores and bans stored in the database. Changed
+// scores are written periodically and on Close, bans as they change.
+func NewReputation(cfg ReputationConfig, db mandb.Database) *Reputation {
+	if cfg.BanThreshold == 0 {
+		cfg.BanThreshold = DefaultReputationConfig.BanThreshold
+	}
+	if cfg.BanDuration == 0 {
+		cfg.BanDuration = DefaultReputationConfig.BanDuration
+	}
+	if cfg.DecayPerHour == 0 {
+		cfg.DecayPerHour = DefaultReputationConfig.DecayPerHour
+	}
+	r := &Reputation{
+		cfg:    cfg,
+		db:     db,
+		scores: make(map[discover.NodeID]*peerScore),
+		bans:   make(map[string]*Ban),
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	if data, err := db.Get(reputationKey); err == nil {
+		var scores []*peerScore
+		if err := rlp.DecodeBytes(data, &scores); err != nil {
+			log.Error("Invalid peer scores RLP", "err", err)
+		}
+		for _, score := range scores {
+			r.scores[score.ID] = score
+		}
+	}
+	if data, err := db.Get(reputationBans); err == nil {
+		var bans []*Ban
+		if err := rlp.DecodeBytes(data, &bans); err != nil {
+			log.Error("Invalid peer bans RLP", "err", err)
+		}
+		for _, ban := range bans {
+			r.bans[ban.Target] = ban
+		}
+	}
+	go r.loop()
+	return r
+}
+
+func (r *Reputation) loop() {
+	defer close(r.done)
+
+	flush := time.NewTicker(reputationFlushInterval)
+	defer flush.Stop()
+	for {
+		select {
+		case now := <-flush.C:
+			r.mu.Lock()
+			r.prune(uint64(now.Unix()))
+			r.mu.Unlock()
+			r.storeScores()
+		case <-r.quit:
+			r.storeScores()
+			return
+		}
+	}
+}
+
+func (r *Reputation) penalty(offence Offence) int {
+	if penalty, ok := r.cfg.Penalties[offence]; ok {
+		return penalty
+	}
+	return defaultPenalties[offence]
+}
+
+// decay forgives the score of the time passed since its last update.
+func (r *Reputation) decay(score *peerScore, now uint64) {
+	if r.cfg.DecayPerHour <= 0 || now <= score.Updated {
+		return
+	}
+	forgiven := uint64(r.cfg.DecayPerHour) * (now - score.Updated) / 3600
+	if forgiven >= score.Score {
+		score.Score, score.Updated = 0, now
+		return
+	}
+	score.Score -= forgiven
+	score.Updated += forgiven * 3600 / uint64(r.cfg.DecayPerHour)
+}
+
+// prune decays all scores and drops the ones forgiven, the lock must be held.
+func (r *Reputation) prune(now uint64) {
+	for id, score := range r.scores {
+		if r.decay(score, now); score.Score == 0 {
+			delete(r.scores, id)
+			r.dirty = true
+		}
+	}
+}
+
+// Penalize adds the penalty of the offence to the score of the node. It bans
+// the node and returns true once the score reaches the threshold.
+func (r *Reputation) Penalize(id discover.NodeID, offence Offence, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	unix := uint64(now.Unix())
+	score := r.scores[id]
+	if score == nil {
+		score = &peerScore{ID: id, Updated: unix}
+		r.scores[id] = score
+	}
+	r.decay(score, unix)
+	score.Score += uint64(r.penalty(offence))
+	log.Debug("Peer penalized", "id", id.TerminalString(), "offence", offence, "score", score.Score)
+	r.dirty = true
+
+	if score.Score == 0 {
+		delete(r.scores, id)
+	}
+	banned := score.Score >= uint64(r.cfg.BanThreshold)
+	if banned {
+		delete(r.scores, id)
+		r.ban(id.String(), fmt.Sprintf("score %d reached with %s", score.Score, offence), now, r.cfg.BanDuration)
+		r.storeBans()
+	}
+	return banned
+}
+
+// Score returns the current score of the node.
+func (r *Reputation) Score(id discover.NodeID, now time.Time) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	score := r.scores[id]
+	if score == nil {
+		return 0
+	}
+	if r.decay(score, uint64(now.Unix())); score.Score == 0 {
+		delete(r.scores, id)
+		r.dirty = true
+	}
+	return score.Score
+}
+
+// parseBanTarget normalizes a node ID, enode URL or IP address.
+func parseBanTarget(target string) (string, error) {
+	if ip := net.ParseIP(target); ip != nil {
+		return ip.String(), nil
+	}
+	if strings.HasPrefix(target, "enode://") {
+		node, err := discover.ParseNode(target)
+		if err != nil {
+			return "", err
+		}
+		return node.ID.String(), nil
+	}
+	id, err := discover.HexID(target)
+	if err != nil {
+		return "", errBanTarget
+	}
+	return id.String(), nil
+}
+
+// Ban bans the node ID, enode URL or IP address for the duration, 0 bans it
+// permanently. It returns the normalized target.
+func (r *Reputation) Ban(target string, duration time.Duration, reason string, now time.Time) (string, error) {
+	target, err := parseBanTarget(target)
+	if err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ban(target, reason, now, duration)
+	r.storeBans()
+	return target, nil
+}
+
+func (r *Reputation) ban(target string, reason string, now time.Time, duration time.Duration) {
+	ban := &Ban{Target: target, Reason: reason, Created: uint64(now.Unix())}
+	if duration > 0 {
+		ban.Expires = uint64(now.Add(duration).Unix())
+	}
+	r.bans[target] = ban
+	log.Info("Peer banned", "target", target, "reason", reason, "expires", ban.Expires)
+}
+
+// Unban lifts the ban of the node ID, enode URL or IP address and resets the
+// score of the node. It returns whether the target was banned.
+func (r *Reputation) Unban(target string) (bool, error) {
+	target, err := parseBanTarget(target)
+	if err != nil {
+		return false, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, banned := r.bans[target]
+	delete(r.bans, target)
+	if id, err := discover.HexID(target); err == nil {
+		if _, ok := r.scores[id]; ok {
+			delete(r.scores, id)
+			r.dirty = true
+		}
+	}
+	if banned {
+		r.storeBans()
+	}
+	return banned, nil
+}
+
+// Bans returns the bans in force, sorted by creation time.
+func (r *Reputation) Bans(now time.Time) []Ban {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.expire(now)
+	bans := make([]Ban, 0, len(r.bans))
+	for _, ban := range r.bans {
+		bans = append(bans, *ban)
+	}
+	sort.Slice(bans, func(i, j int) bool {
+		if bans[i].Created != bans[j].Created {
+			return bans[i].Created < bans[j].Created
+		}
+		return bans[i].Target < bans[j].Target
+	})
+	return bans
+}
+
+// IsBanned reports whether the node or the IP address is banned.
+func (r *Reputation) IsBanned(id discover.NodeID, ip net.IP, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.bans) == 0 {
+		return false
+	}
+	r.expire(now)
+	if _, ok := r.bans[id.String()]; ok {
+		return true
+	}
+	if ip != nil {
+		if _, ok := r.bans[ip.String()]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reputation) expire(now time.Time) {
+	expired := false
+	for target, ban := range r.bans {
+		if ban.Expires != 0 && ban.Expires <= uint64(now.Unix()) {
+			delete(r.bans, target)
+			expired = true
+		}
+	}
+	if expired {
+		r.storeBans()
+	}
+}
+
+// storeScores writes the scores if they changed since the last write.
+func (r *Reputation) storeScores() {
+	r.mu.Lock()
+	if !r.dirty {
+		r.mu.Unlock()
+		return
+	}
+	scores := make([]peerScore, 0, len(r.scores))
+	for _, score := range r.scores {
+		scores = append(scores, *score)
+	}
+	r.dirty = false
+	r.mu.Unlock()
+
+	data, err := rlp.EncodeToBytes(scores)
+	if err != nil {
+		log.Error("Failed to encode peer scores", "err", err)
+		return
+	}
+	if err := r.db.Put(reputationKey, data); err != nil {
+		log.Error("Failed to store peer scores", "err", err)
+	}
+}
+
+// storeBans writes the bans, the lock must be held.
+func (r *Reputation) storeBans() {
+	bans := make([]*Ban, 0, len(r.bans))
+	for _, ban := range r.bans {
+		bans = append(bans, ban)
+	}
+	data, err := rlp.EncodeToBytes(bans)
+	if err != nil {
+		log.Error("Failed to encode peer bans", "err", err)
+		return
+	}
+	if err := r.db.Put(reputationBans, data); err != nil {
+		log.Error("Failed to store peer bans", "err", err)
+	}
+}
+
+// Close writes the changed scores and closes the database of the reputation.
+func (r *Reputation) Close() {
+	close(r.quit)
+	<-r.done
+	r.db.Close()
+}
+
+func (srv *Server) openReputation() {
+	db := mandb.Database(mandb.NewMemDatabase())
+	if srv.NodeDatabase != "" {
+		ldb, err := mandb.NewLDBDatabase(filepath.Join(filepath.Dir(srv.NodeDatabase), "reputation"), 0, 0, 2)
+		if err != nil {
+			srv.log.Error("Failed to open reputation database, scores won't persist", "err", err)
+		} else {
+			db = ldb
+		}
+	}
+	srv.reputation = NewReputation(srv.Reputation, db)
+}
+
+// Penalize adds the penalty of the offence to the score of the peer,
+// disconnecting it once banned.
+func (srv *Server) Penalize(id discover.NodeID, offence Offence) {
+	if srv.reputation == nil || id == EmptyNodeId {
+		return
+	}
+	if srv.reputation.Penalize(id, offence, time.Now()) {
+		srv.disconnectBanned()
+	}
+}
+
+// PenalizeByAddress penalizes the node of the account address.
+func (srv *Server) PenalizeByAddress(addr common.Address, offence Offence) {
+	if srv.reputation == nil || srv.ntab == nil {
+		return
+	}
+	srv.Penalize(srv.ConvertAddressToId(addr), offence)
+}
+
+// BanPeer bans the node ID, enode URL or IP address for the duration, 0 bans
+// it permanently, and disconnects the matching peers.
+func (srv *Server) BanPeer(target string, duration time.Duration, reason string) error {
+	if srv.reputation == nil {
+		return errServerStopped
+	}
+	if _, err := srv.reputation.Ban(target, duration, reason, time.Now()); err != nil {
+		return err
+	}
+	srv.disconnectBanned()
+	return nil
+}
+
+// Unban lifts the ban of the node ID, enode URL or IP address. It returns
+// whether the target was banned.
+func (srv *Server) Unban(target string) (bool, error) {
+	if srv.reputation == nil {
+		return false, errServerStopped
+	}
+	return srv.reputation.Unban(target)
+}
+
+// Bans returns the bans in force.
+func (srv *Server) Bans() []Ban {
+	if srv.reputation == nil {
+		return nil
+	}
+	return srv.reputation.Bans(time.Now())
+}
+
+// isBanned reports whether the node or the IP address is banned.
+func (srv *Server) isBanned(id discover.NodeID, ip net.IP) bool {
+	return srv.reputation != nil && srv.reputation.IsBanned(id, ip, time.Now())
+}
+
+func (srv *Server) disconnectBanned() {
+	for _, p := range srv.Peers() {
+		var ip net.IP
+		if tcp, ok := p.RemoteAddr().(*net.TCPAddr); ok {
+			ip = tcp.IP
+		}
+		if srv.isBanned(p.ID(), ip) {
+			p.Disconnect(DiscUselessPeer)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package p2p
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/p2p/discover"
+)
+
+var testReputationConfig = ReputationConfig{
+	Penalties:    map[Offence]int{OffenceInvalidTx: 5},
+	BanThreshold: 100,
+	BanDuration:  time.Hour,
+	DecayPerHour: 10,
+}
+
+// Tests that penalties add up to a ban at the threshold, lasting the ban
+// duration.
+func TestReputationBanThreshold(t *testing.T) {
+	r := NewReputation(testReputationConfig, mandb.NewMemDatabase())
+	id := discover.NodeID{0x01}
+	now := time.Unix(1000000, 0)
+
+	if r.Penalize(id, OffenceInvalidBlock, now) {
+		t.Fatalf("banned below the threshold")
+	}
+	if score := r.Score(id, now); score != 50 {
+		t.Fatalf("score mismatch: have %d, want %d", score, 50)
+	}
+	if r.Penalize(id, OffenceInvalidTx, now); r.Score(id, now) != 55 {
+		t.Fatalf("configured penalty not applied: have %d, want %d", r.Score(id, now), 55)
+	}
+	if r.IsBanned(id, nil, now) {
+		t.Fatalf("banned below the threshold")
+	}
+	if !r.Penalize(id, OffenceInvalidBlock, now) {
+		t.Fatalf("not banned at the threshold")
+	}
+	if !r.IsBanned(id, nil, now.Add(59*time.Minute)) {
+		t.Fatalf("ban lifted before its expiry")
+	}
+	if bans := r.Bans(now); len(bans) != 1 || bans[0].Target != id.String() {
+		t.Fatalf("bans mismatch: have %v", bans)
+	}
+	if r.IsBanned(id, nil, now.Add(time.Hour)) {
+		t.Fatalf("ban not lifted at its expiry")
+	}
+	if bans := r.Bans(now.Add(time.Hour)); len(bans) != 0 {
+		t.Fatalf("expired bans listed: %v", bans)
+	}
+}
+
+// Tests that scores decay with time.
+func TestReputationDecay(t *testing.T) {
+	r := NewReputation(testReputationConfig, mandb.NewMemDatabase())
+	id := discover.NodeID{0x01}
+	now := time.Unix(1000000, 0)
+
+	r.Penalize(id, OffenceInvalidBlock, now)
+	if score := r.Score(id, now.Add(90*time.Minute)); score != 35 {
+		t.Fatalf("score after 1.5 hours mismatch: have %d, want %d", score, 35)
+	}
+	// The half hour left over must not be lost
+	if score := r.Score(id, now.Add(2*time.Hour)); score != 30 {
+		t.Fatalf("score after 2 hours mismatch: have %d, want %d", score, 30)
+	}
+	if r.Penalize(id, OffenceInvalidBlock, now.Add(2*time.Hour)) {
+		t.Fatalf("decayed score banned")
+	}
+	if score := r.Score(id, now.Add(20*time.Hour)); score != 0 {
+		t.Fatalf("score after 20 hours mismatch: have %d, want %d", score, 0)
+	}
+	if len(r.scores) != 0 {
+		t.Fatalf("forgiven score kept: %v", r.scores)
+	}
+	// Scores forgiven without being read are dropped by the flush
+	other := discover.NodeID{0x02}
+	r.Penalize(id, OffenceSpam, now)
+	r.Penalize(other, OffenceInvalidBlock, now)
+	r.prune(uint64(now.Add(2 * time.Hour).Unix()))
+	if _, ok := r.scores[other]; len(r.scores) != 1 || !ok {
+		t.Fatalf("scores mismatch after pruning: %v", r.scores)
+	}
+}
+
+// Tests that scores and bans persist across restarts.
+func TestReputationPersistence(t *testing.T) {
+	db := mandb.NewMemDatabase()
+	r := NewReputation(testReputationConfig, db)
+	scored, banned := discover.NodeID{0x01}, discover.NodeID{0x02}
+	now := time.Unix(1000000, 0)
+
+	r.Penalize(scored, OffenceBadMessage, now)
+	if _, err := r.Ban(banned.String(), 0, "test", now); err != nil {
+		t.Fatalf("ban failed: %v", err)
+	}
+	r.Close()
+
+	r = NewReputation(testReputationConfig, db)
+	if score := r.Score(scored, now); score != 20 {
+		t.Fatalf("score mismatch after reopen: have %d, want %d", score, 20)
+	}
+	if !r.IsBanned(banned, nil, now.Add(1000*time.Hour)) {
+		t.Fatalf("permanent ban lost after reopen")
+	}
+}
+
+// Tests banning and unbanning node IDs, enode URLs and IP addresses.
+func TestReputationManualBan(t *testing.T) {
+	r := NewReputation(testReputationConfig, mandb.NewMemDatabase())
+	id := discover.MustHexID("0x1dd9d65c4552b5eb43d5ad55a2ee3f56c6cbc1c64a5c8d659f51fcd51bace24351232b8d7821617d2b29b54b81cdefb9b3e9c37d7fd5f63270bcc9e1a6f6a439")
+	now := time.Unix(1000000, 0)
+
+	if _, err := r.Ban("not a target", 0, "test", now); err != errBanTarget {
+		t.Fatalf("invalid target error mismatch: have %v, want %v", err, errBanTarget)
+	}
+	target, err := r.Ban("enode://"+id.String()+"@10.0.0.1:50505", 0, "test", now)
+	if err != nil || target != id.String() {
+		t.Fatalf("enode ban mismatch: have %s %v, want %s", target, err, id)
+	}
+	if _, err := r.Ban("10.0.0.2", time.Minute, "test", now); err != nil {
+		t.Fatalf("ip ban failed: %v", err)
+	}
+	if !r.IsBanned(id, nil, now) {
+		t.Fatalf("banned node accepted")
+	}
+	if !r.IsBanned(discover.NodeID{0x01}, net.ParseIP("10.0.0.2"), now) {
+		t.Fatalf("banned ip accepted")
+	}
+	if r.IsBanned(discover.NodeID{0x01}, net.ParseIP("10.0.0.1"), now) {
+		t.Fatalf("ip of banned enode refused")
+	}
+
+	if ok, err := r.Unban(id.String()); !ok || err != nil {
+		t.Fatalf("unban mismatch: have %v %v, want true", ok, err)
+	}
+	if ok, _ := r.Unban(id.String()); ok {
+		t.Fatalf("unbanned twice")
+	}
+	if r.IsBanned(id, nil, now) {
+		t.Fatalf("unbanned node refused")
+	}
+	if bans := r.Bans(now); len(bans) != 1 || bans[0].Target != "10.0.0.2" {
+		t.Fatalf("bans mismatch: have %v", bans)
+	}
+}
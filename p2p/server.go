@@ -13,6 +13,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/common/mclock"
 	"github.com/MatrixAINetwork/go-matrix/event"
@@ -131,6 +132,25 @@ type Config struct {
 	// Logger is a custom logger to use with the p2p.Server.
 	Logger log.Logger `toml:",omitempty"`
 
+	// TxRelayPort is the UDP port on which the transactions relayed to the
+	// validators are received and sent to, the same on all nodes of the network.
+	// Zero defaults to DefaultTxRelayPort. The relay of older versions isn't
+	// compatible, see DefaultTxRelayPort.
+	TxRelayPort int `toml:",omitempty"`
+
+	// TxRelayRateLimit is the number of relay packets per second accepted from
+	// a source address or node. Zero defaults to DefaultTxRelayRateLimit.
+	TxRelayRateLimit int `toml:",omitempty"`
+
+	// Reputation configures the scoring of misbehaving peers and the bans of
+	// the nodes reaching the threshold.
+	Reputation ReputationConfig
+
+	// Ca is the identity and topology service of the node, used to pick the
+	// peers to link to and the receivers of group messages. Nil defaults to
+	// the default service of the process.
+	Ca ca.LinkProvider `toml:"-"`
+
 	// NetWorkId
 	NetWorkId uint64
 
@@ -155,6 +175,8 @@ type Server struct {
 
 	ntab         discoverTable
 	listener     net.Listener
+	txRelay      *txRelay
+	reputation   *Reputation
 	ourHandshake *protoHandshake
 	lastLookup   time.Time
 	//DiscV5       *discv5.Network
@@ -366,6 +388,15 @@ func (srv *Server) SubscribeEvents(ch chan *PeerEvent) event.Subscription {
 	return srv.peerFeed.Subscribe(ch)
 }
 
+// identity returns the identity service of the node, the default service of
+// the process if none was configured.
+func (srv *Server) identity() ca.LinkProvider {
+	if srv.Ca != nil {
+		return srv.Ca
+	}
+	return ca.DefaultService()
+}
+
 // Self returns the local node's endpoint information.
 func (srv *Server) Self() *discover.Node {
 	srv.lock.Lock()
@@ -410,8 +441,14 @@ func (srv *Server) Stop() {
 		// this unblocks listener Accept
 		srv.listener.Close()
 	}
+	if srv.txRelay != nil {
+		srv.txRelay.stop()
+	}
 	close(srv.quit)
 	srv.loopWG.Wait()
+	if srv.reputation != nil {
+		srv.reputation.Close()
+	}
 }
 
 // sharedUDPConn implements a shared connection. Write sends messages to the underlying connection while read returns
@@ -475,6 +512,7 @@ func (srv *Server) Start() (err error) {
 	srv.peerOp = make(chan peerOpFunc)
 	srv.peerOpDone = make(chan struct{})
 	srv.tasks = make(map[common.Address]*taskManager)
+	srv.openReputation()
 
 	var (
 		conn *net.UDPConn
@@ -580,9 +618,18 @@ func (srv *Server) Start() (err error) {
 	Custsrv = srv
 	srv.running = true
 
+	Buckets.ca = srv.identity()
+	Link.ca = srv.identity()
 	go Buckets.Start()
 	go Link.Start()
-	go UdpStart()
+	srv.txRelay = newTxRelay(srv.PrivateKey, srv.TxRelayPort, srv.TxRelayRateLimit)
+	srv.txRelay.penalize = func(id NodeID, offence Offence) { srv.Penalize(discover.NodeID(id), offence) }
+	srv.txRelay.banned = func(id NodeID) bool { return srv.isBanned(discover.NodeID(id), nil) }
+	srv.txRelay.known = func(id NodeID) bool { return srv.isTxRelaySender(discover.NodeID(id)) }
+	if err := srv.txRelay.start(); err != nil {
+		srv.log.Error("Failed to start tx relay", "err", err)
+		srv.txRelay = nil
+	}
 
 	return nil
 }
@@ -944,6 +991,14 @@ func (srv *Server) setupConn(c *conn, flags connFlag, dialDest *discover.Node) e
 		clog.Trace("Dialed identity mismatch", "want", c, dialDest.ID)
 		return DiscUnexpectedIdentity
 	}
+	var ip net.IP
+	if tcp, ok := c.fd.RemoteAddr().(*net.TCPAddr); ok {
+		ip = tcp.IP
+	}
+	if srv.isBanned(c.id, ip) {
+		clog.Trace("Rejected banned peer")
+		return errBannedPeer
+	}
 	err = srv.checkpoint(c, srv.posthandshake)
 	if err != nil {
 		clog.Trace("Rejected peer before protocol handshake", "err", err)
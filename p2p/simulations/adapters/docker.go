@@ -72,7 +72,7 @@ func (d *DockerAdapter) NewNode(config *NodeConfig) (Node, error) {
 
 	// generate the config
 	conf := &execNodeConfig{
-		Stack: node.DefaultConfig,
+		Stack: pod.DefaultConfig,
 		Node:  config,
 	}
 	conf.Stack.DataDir = "/data"
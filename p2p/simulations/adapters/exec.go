@@ -78,7 +78,7 @@ func (e *ExecAdapter) NewNode(config *NodeConfig) (Node, error) {
 
 	// generate the config
 	conf := &execNodeConfig{
-		Stack: node.DefaultConfig,
+		Stack: pod.DefaultConfig,
 		Node:  config,
 	}
 	conf.Stack.DataDir = filepath.Join(dir, "data")
@@ -300,7 +300,7 @@ func init() {
 // execNodeConfig is used to serialize the node configuration so it can be
 // passed to the child process as a JSON encoded environment variable
 type execNodeConfig struct {
-	Stack     node.Config       `json:"stack"`
+	Stack     pod.Config        `json:"stack"`
 	Node      *NodeConfig       `json:"node"`
 	Snapshots map[string][]byte `json:"snapshots,omitempty"`
 	PeerAddrs map[string]string `json:"peer_addrs,omitempty"`
@@ -351,20 +351,20 @@ func execP2PNode() {
 	}
 
 	// initialize the devp2p stack
-	stack, err := node.New(&conf.Stack)
+	stack, err := pod.New(&conf.Stack)
 	if err != nil {
 		log.Crit("error creating node stack", "err", err)
 	}
 
 	// register the services, collecting them into a map so we can wrap
 	// them in a snapshot service
-	services := make(map[string]node.Service, len(serviceNames))
+	services := make(map[string]pod.Service, len(serviceNames))
 	for _, name := range serviceNames {
 		serviceFunc, exists := serviceFuncs[name]
 		if !exists {
 			log.Crit("unknown node service", "name", name)
 		}
-		constructor := func(nodeCtx *node.ServiceContext) (node.Service, error) {
+		constructor := func(nodeCtx *pod.ServiceContext) (pod.Service, error) {
 			ctx := &ServiceContext{
 				RPCDialer:   &wsRPCDialer{addrs: conf.PeerAddrs},
 				NodeContext: nodeCtx,
@@ -386,7 +386,7 @@ func execP2PNode() {
 	}
 
 	// register the snapshot service
-	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+	if err := stack.Register(func(ctx *pod.ServiceContext) (pod.Service, error) {
 		return &snapshotService{services}, nil
 	}); err != nil {
 		log.Crit("error starting snapshot service", "err", err)
@@ -414,7 +414,7 @@ func execP2PNode() {
 // snapshotService is a node.Service which wraps a list of services and
 // exposes an API to generate a snapshot of those services
 type snapshotService struct {
-	services map[string]node.Service
+	services map[string]pod.Service
 }
 
 func (s *snapshotService) APIs() []rpc.API {
@@ -439,7 +439,7 @@ func (s *snapshotService) Stop() error {
 
 // SnapshotAPI provides an RPC method to create snapshots of services
 type SnapshotAPI struct {
-	services map[string]node.Service
+	services map[string]pod.Service
 }
 
 func (api SnapshotAPI) Snapshot() (map[string][]byte, error) {
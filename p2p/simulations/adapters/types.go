@@ -165,7 +165,7 @@ func RandomNodeConfig() *NodeConfig {
 type ServiceContext struct {
 	RPCDialer
 
-	NodeContext *node.ServiceContext
+	NodeContext *pod.ServiceContext
 	Config      *NodeConfig
 	Snapshot    []byte
 }
@@ -181,7 +181,7 @@ type RPCDialer interface {
 type Services map[string]ServiceFunc
 
 // ServiceFunc returns a node.Service which can be used to boot a devp2p node
-type ServiceFunc func(ctx *ServiceContext) (node.Service, error)
+type ServiceFunc func(ctx *ServiceContext) (pod.Service, error)
 
 // serviceFuncs is a map of registered services which are used to boot devp2p
 // nodes
@@ -34,7 +34,7 @@ func main() {
 
 	// register a single ping-pong service
 	services := map[string]adapters.ServiceFunc{
-		"ping-pong": func(ctx *adapters.ServiceContext) (node.Service, error) {
+		"ping-pong": func(ctx *adapters.ServiceContext) (pod.Service, error) {
 			return newPingPongService(ctx.Config.ID), nil
 		},
 	}
@@ -2,6 +2,12 @@
 // Distributed under the MIT software license, see the accompanying
 // file COPYING or http://www.opensource.org/licenses/mit-license.php
 
+// Starts several nodes of the in-process adapter, which share p2p.ServerP2p
+// now, and uses the test service of http_test.go.
+
+//go:build legacytests
+// +build legacytests
+
 package simulations
 
 import (
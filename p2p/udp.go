@@ -4,66 +4,465 @@
 package p2p
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
 	"net"
+	"time"
 
-	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
 	"github.com/MatrixAINetwork/go-matrix/log"
 	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/metrics"
+	"github.com/MatrixAINetwork/go-matrix/p2p/discover"
 	"github.com/MatrixAINetwork/go-matrix/params"
 	"github.com/MatrixAINetwork/go-matrix/rlp"
 )
 
-func UdpStart() {
-	addr, err := net.ResolveUDPAddr("udp", ":30000")
+const (
+	// DefaultTxRelayPort differs from the port 30000 of the unsigned relay of
+	// older versions, neither version can decode the packets of the other.
+	// Transactions aren't relayed between old and new nodes, the validators
+	// have to upgrade at once.
+	DefaultTxRelayPort      = 30001
+	DefaultTxRelayRateLimit = 100 // Packets per second accepted from a sender
+
+	txRelayPacket            = 6 // Packet type of the relay, after the ones of customize.go
+	txRelayFragmentSize      = 32 * 1024
+	txRelayMaxFragments      = 256 // Batches are limited to 8MB
+	txRelayMaxPending        = 16  // Batches being reassembled per sender
+	txRelayExpiration        = 20 * time.Second
+	txRelayReassembleTimeout = 10 * time.Second
+	txRelayCleanupInterval   = time.Minute
+	txRelaySpamInterval      = 10 * time.Second // Minimum interval between spam penalties of a node
+	txRelaySenderInterval    = 10 * time.Second // Interval between two checks whether a node may relay
+)
+
+var (
+	errTxRelayExpired   = errors.New("expired")
+	errTxRelayFragment  = errors.New("invalid fragment")
+	errTxRelayNotServed = errors.New("tx relay not running")
+
+	txRelayInPacketMeter   = metrics.NewRegisteredMeter("p2p/txrelay/in/packets", nil)
+	txRelayInTrafficMeter  = metrics.NewRegisteredMeter("p2p/txrelay/in/traffic", nil)
+	txRelayInBatchMeter    = metrics.NewRegisteredMeter("p2p/txrelay/in/batches", nil)
+	txRelayInTxMeter       = metrics.NewRegisteredMeter("p2p/txrelay/in/txs", nil)
+	txRelayBadPacketMeter  = metrics.NewRegisteredMeter("p2p/txrelay/in/dropped/bad", nil)
+	txRelayRateDropMeter   = metrics.NewRegisteredMeter("p2p/txrelay/in/dropped/rate", nil)
+	txRelayTimeoutMeter    = metrics.NewRegisteredMeter("p2p/txrelay/in/dropped/timeout", nil)
+	txRelayBannedMeter     = metrics.NewRegisteredMeter("p2p/txrelay/in/dropped/banned", nil)
+	txRelayUnknownMeter    = metrics.NewRegisteredMeter("p2p/txrelay/in/dropped/unknown", nil)
+	txRelayOutPacketMeter  = metrics.NewRegisteredMeter("p2p/txrelay/out/packets", nil)
+	txRelayOutTrafficMeter = metrics.NewRegisteredMeter("p2p/txrelay/out/traffic", nil)
+	txRelayOutBatchMeter   = metrics.NewRegisteredMeter("p2p/txrelay/out/batches", nil)
+)
+
+// txRelayFragment is a part of an RLP encoded batch of transactions. Every
+// fragment is a packet signed with the node key of the sender.
+type txRelayFragment struct {
+	Batch      uint64 // Random identifier of the batch of the sender
+	Index      uint64
+	Total      uint64
+	Expiration uint64 // Unix time after which the fragment is dropped
+	Data       []byte
+}
+
+type txRelayBatchKey struct {
+	from  NodeID
+	batch uint64
+}
+
+type txRelaySender struct {
+	known   bool
+	checked time.Time
+}
+
+type txRelayBatch struct {
+	fragments [][]byte
+	received  uint64
+	created   time.Time
+}
+
+// txRelay forwards transactions to the validators over UDP. Packets are
+// authenticated with the node key of the sender and rate limited per source
+// address and node.
+type txRelay struct {
+	priv    *ecdsa.PrivateKey
+	port    int
+	conn    *net.UDPConn
+	limiter *txRelayLimiter
+	deliver func(from NodeID, txs []*types.Transaction_Mx)
+
+	penalize func(id NodeID, offence Offence) // Reports the senders beyond the rate, if set
+	banned   func(id NodeID) bool             // Filters the packets of banned senders, if set
+	known    func(id NodeID) bool             // Filters the packets of senders which may not relay, if set
+
+	batches     map[txRelayBatchKey]*txRelayBatch
+	pending     map[NodeID]int
+	penalized   map[NodeID]time.Time
+	senders     map[NodeID]*txRelaySender // Results of known, checked again after txRelaySenderInterval
+	lastCleanup time.Time
+
+	quit chan struct{}
+}
+
+func newTxRelay(priv *ecdsa.PrivateKey, port int, rateLimit int) *txRelay {
+	if port == 0 {
+		port = DefaultTxRelayPort
+	}
+	if rateLimit == 0 {
+		rateLimit = DefaultTxRelayRateLimit
+	}
+	return &txRelay{
+		priv:    priv,
+		port:    port,
+		limiter: newTxRelayLimiter(float64(rateLimit)),
+		deliver: func(from NodeID, txs []*types.Transaction_Mx) {
+			mc.PublishEvent(mc.SendUdpTx, txs)
+		},
+		batches:   make(map[txRelayBatchKey]*txRelayBatch),
+		pending:   make(map[NodeID]int),
+		penalized: make(map[NodeID]time.Time),
+		senders:   make(map[NodeID]*txRelaySender),
+		quit:      make(chan struct{}),
+	}
+}
+
+// start opens the relay port and starts receiving. A negative port lets the
+// system pick one, for tests.
+func (r *txRelay) start() error {
+	port := r.port
+	if port < 0 {
+		port = 0
+	}
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{Port: port})
 	if err != nil {
-		log.Error("Can't resolve address: ", "p2p udp", err)
+		return err
+	}
+	r.conn = conn
+	r.port = conn.LocalAddr().(*net.UDPAddr).Port
+	log.Info("Tx relay started", "port", r.port)
+	go r.loop()
+	return nil
+}
+
+func (r *txRelay) stop() {
+	close(r.quit)
+	if r.conn != nil {
+		r.conn.Close()
+	}
+}
+
+func (r *txRelay) loop() {
+	buf := make([]byte, params.MaxUdpBuf)
+	for {
+		n, addr, err := r.conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-r.quit:
+			default:
+				log.Error("Tx relay read error", "err", err)
+			}
+			return
+		}
+		txRelayInPacketMeter.Mark(1)
+		txRelayInTrafficMeter.Mark(int64(n))
+		r.handle(buf[:n], addr, time.Now())
+	}
+}
+
+func (r *txRelay) handle(packet []byte, addr *net.UDPAddr, now time.Time) {
+	if now.Sub(r.lastCleanup) > txRelayCleanupInterval {
+		r.cleanup(now)
+	}
+	// Limit the source address before paying for the signature recovery
+	if !r.limiter.allow(addr.IP.String(), now) {
+		txRelayRateDropMeter.Mark(1)
+		return
+	}
+	from, fragment, err := decodeTxRelayPacket(packet, now)
+	if err != nil {
+		txRelayBadPacketMeter.Mark(1)
+		log.Trace("Tx relay dropped packet", "addr", addr, "err", err)
+		return
+	}
+	if r.banned != nil && r.banned(from) {
+		txRelayBannedMeter.Mark(1)
+		return
+	}
+	if !r.isKnown(from, now) {
+		txRelayUnknownMeter.Mark(1)
+		log.Trace("Tx relay dropped packet of unknown node", "addr", addr, "from", fmt.Sprintf("%x", from[:8]))
+		return
+	}
+	if !r.limiter.allow(string(from[:]), now) {
+		txRelayRateDropMeter.Mark(1)
+		if r.penalize != nil && now.Sub(r.penalized[from]) > txRelaySpamInterval {
+			r.penalized[from] = now
+			r.penalize(from, OffenceSpam)
+		}
 		return
 	}
-	conn, err := net.ListenUDP("udp", addr)
+	txs, err := r.reassemble(from, fragment, now)
 	if err != nil {
-		log.Error("Error listening:", "p2p udp", err)
+		txRelayBadPacketMeter.Mark(1)
+		log.Trace("Tx relay dropped fragment", "addr", addr, "from", fmt.Sprintf("%x", from[:8]), "err", err)
 		return
 	}
-	defer conn.Close()
+	if txs != nil {
+		txRelayInBatchMeter.Mark(1)
+		txRelayInTxMeter.Mark(int64(len(txs)))
+		r.deliver(from, txs)
+	}
+}
 
-	buf := make([]byte, params.MaxUdpBuf)
+// isKnown reports whether the node may relay transactions, checking it at most
+// once per txRelaySenderInterval.
+func (r *txRelay) isKnown(id NodeID, now time.Time) bool {
+	if r.known == nil {
+		return true
+	}
+	sender := r.senders[id]
+	if sender == nil || now.Sub(sender.checked) > txRelaySenderInterval {
+		sender = &txRelaySender{known: r.known(id), checked: now}
+		r.senders[id] = sender
+	}
+	return sender.known
+}
 
-	for {
-		var mxtxs []*types.Transaction_Mx
-		n, _, err := conn.ReadFromUDP(buf)
+// decodeTxRelayPacket checks the hash and the node key signature of a packet
+// produced by Custencodedata and returns the signer and the fragment.
+func decodeTxRelayPacket(packet []byte, now time.Time) (NodeID, *txRelayFragment, error) {
+	if len(packet) < headSize+1 {
+		return NodeID{}, nil, errPacketTooSmall
+	}
+	hash, sig, sigdata := packet[:macSize], packet[macSize:headSize], packet[headSize:]
+	if !bytes.Equal(hash, crypto.Keccak256(packet[macSize:])) {
+		return NodeID{}, nil, errBadHash
+	}
+	if sigdata[0] != txRelayPacket {
+		return NodeID{}, nil, fmt.Errorf("unknown type: %d", sigdata[0])
+	}
+	from, err := recoverNodeID(crypto.Keccak256(sigdata), sig)
+	if err != nil {
+		return NodeID{}, nil, err
+	}
+	fragment := new(txRelayFragment)
+	if err := rlp.DecodeBytes(sigdata[1:], fragment); err != nil {
+		return NodeID{}, nil, err
+	}
+	expiration := time.Unix(int64(fragment.Expiration), 0)
+	if expiration.Before(now) || expiration.After(now.Add(2*txRelayExpiration)) {
+		return NodeID{}, nil, errTxRelayExpired
+	}
+	return from, fragment, nil
+}
+
+// reassemble adds the fragment to its batch and returns the transactions of
+// the batch once complete.
+func (r *txRelay) reassemble(from NodeID, fragment *txRelayFragment, now time.Time) ([]*types.Transaction_Mx, error) {
+	if fragment.Total == 0 || fragment.Total > txRelayMaxFragments || fragment.Index >= fragment.Total || len(fragment.Data) > txRelayFragmentSize {
+		return nil, errTxRelayFragment
+	}
+	key := txRelayBatchKey{from: from, batch: fragment.Batch}
+	batch := r.batches[key]
+	if batch == nil {
+		if r.pending[from] >= txRelayMaxPending {
+			return nil, errors.New("too many pending batches")
+		}
+		batch = &txRelayBatch{fragments: make([][]byte, fragment.Total), created: now}
+		r.batches[key] = batch
+		r.pending[from]++
+	}
+	if uint64(len(batch.fragments)) != fragment.Total {
+		return nil, errTxRelayFragment
+	}
+	if batch.fragments[fragment.Index] != nil {
+		return nil, nil
+	}
+	batch.fragments[fragment.Index] = fragment.Data
+	batch.received++
+	if batch.received < fragment.Total {
+		return nil, nil
+	}
+	r.drop(key)
+
+	var txs []*types.Transaction_Mx
+	if err := rlp.DecodeBytes(bytes.Join(batch.fragments, nil), &txs); err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
+
+func (r *txRelay) drop(key txRelayBatchKey) {
+	delete(r.batches, key)
+	if r.pending[key.from]--; r.pending[key.from] <= 0 {
+		delete(r.pending, key.from)
+	}
+}
+
+// cleanup drops the batches not completed in time and the idle rate limits.
+func (r *txRelay) cleanup(now time.Time) {
+	for key, batch := range r.batches {
+		if now.Sub(batch.created) > txRelayReassembleTimeout {
+			r.drop(key)
+			txRelayTimeoutMeter.Mark(1)
+		}
+	}
+	for id, last := range r.penalized {
+		if now.Sub(last) > txRelaySpamInterval {
+			delete(r.penalized, id)
+		}
+	}
+	for id, sender := range r.senders {
+		if now.Sub(sender.checked) > txRelaySenderInterval {
+			delete(r.senders, id)
+		}
+	}
+	r.limiter.expire(now)
+	r.lastCleanup = now
+}
+
+// encode splits the transactions into signed packets.
+func (r *txRelay) encode(txs []*types.Transaction_Mx) ([][]byte, error) {
+	data, err := rlp.EncodeToBytes(txs)
+	if err != nil {
+		return nil, err
+	}
+	total := (len(data) + txRelayFragmentSize - 1) / txRelayFragmentSize
+	if total == 0 {
+		total = 1
+	}
+	if total > txRelayMaxFragments {
+		return nil, fmt.Errorf("batch of %d bytes too large", len(data))
+	}
+	fragment := &txRelayFragment{
+		Batch:      rand.Uint64(),
+		Total:      uint64(total),
+		Expiration: uint64(time.Now().Add(txRelayExpiration).Unix()),
+	}
+	packets := make([][]byte, 0, total)
+	for i := 0; i < total; i++ {
+		end := (i + 1) * txRelayFragmentSize
+		if end > len(data) {
+			end = len(data)
+		}
+		fragment.Index, fragment.Data = uint64(i), data[i*txRelayFragmentSize:end]
+		packet, _, err := Custencodedata(r.priv, string([]byte{txRelayPacket}), fragment)
 		if err != nil {
-			log.Error("UDP read error", "err", err)
+			return nil, err
+		}
+		packets = append(packets, packet)
+	}
+	return packets, nil
+}
+
+func (r *txRelay) write(packets [][]byte, addr *net.UDPAddr) {
+	for _, packet := range packets {
+		if _, err := r.conn.WriteToUDP(packet, addr); err != nil {
+			log.Error("Tx relay write failed", "addr", addr, "err", err)
 			return
 		}
+		txRelayOutPacketMeter.Mark(1)
+		txRelayOutTrafficMeter.Mark(int64(len(packet)))
+	}
+	txRelayOutBatchMeter.Mark(1)
+}
 
-		err = rlp.DecodeBytes(buf[:n], &mxtxs)
-		if err != nil {
-			log.Error("rlp decode error", "err", err)
-			continue
+// txRelayLimiter is a token bucket per key refilled at rate tokens per second,
+// holding one second of tokens at most.
+type txRelayLimiter struct {
+	rate    float64
+	buckets map[string]*txRelayBucket
+}
+
+type txRelayBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newTxRelayLimiter(rate float64) *txRelayLimiter {
+	return &txRelayLimiter{rate: rate, buckets: make(map[string]*txRelayBucket)}
+}
+
+func (l *txRelayLimiter) allow(key string, now time.Time) bool {
+	b := l.buckets[key]
+	if b == nil {
+		b = &txRelayBucket{tokens: l.rate, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.rate, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// expire drops the buckets refilled completely, they equal new ones.
+func (l *txRelayLimiter) expire(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.rate {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// isTxRelaySender reports whether the node may relay transactions to this one:
+// a connected peer, or a node of the topology or the next election whose
+// address the node table knows.
+func (srv *Server) isTxRelaySender(id discover.NodeID) bool {
+	connected := false
+	select {
+	case srv.peerOp <- func(ps map[discover.NodeID]*Peer) { _, connected = ps[id] }:
+		<-srv.peerOpDone
+	case <-srv.quit:
+		return false
+	}
+	if connected {
+		return true
+	}
+	address := srv.ConvertIdToAddress(id)
+	if address == EmptyAddress {
+		return false
+	}
+	for _, node := range srv.identity().GetRolesByGroupWithNextElect(common.RoleAll) {
+		if node == address {
+			return true
 		}
-		mc.PublishEvent(mc.SendUdpTx, mxtxs)
 	}
+	return false
 }
 
-func UdpSend(data interface{}) {
-	bytes, err := rlp.EncodeToBytes(data)
+// UdpSend relays the transactions to two of the validators.
+func UdpSend(txs []*types.Transaction_Mx) {
+	relay := ServerP2p.txRelay
+	if relay == nil {
+		log.Error("p2p udp", "err", errTxRelayNotServed)
+		return
+	}
+	packets, err := relay.encode(txs)
 	if err != nil {
-		log.Error("error", "p2p udp", err)
+		log.Error("p2p udp", "err", err)
 		return
 	}
 
 	signAddr := make([]common.Address, 0)
-	if ca.InDuration() {
-		signAddr = ca.GetRolesByGroupOnlyNextElect(common.RoleValidator | common.RoleBackupValidator)
+	if identity := ServerP2p.identity(); identity.InDuration() {
+		signAddr = identity.GetRolesByGroupOnlyNextElect(common.RoleValidator | common.RoleBackupValidator)
 	} else {
-		signAddr = ca.GetRolesByGroup(common.RoleValidator | common.RoleBackupValidator)
+		signAddr = identity.GetRolesByGroup(common.RoleValidator | common.RoleBackupValidator)
 	}
 	if len(signAddr) <= 2 {
 		for _, id := range signAddr {
 			log.Info("upd", "send tx addr", id.String(), "node id", ServerP2p.ConvertAddressToId(id).String()) //YY add log
-			send(id, bytes)
+			send(relay, id, packets)
 		}
 		return
 	}
@@ -71,32 +470,15 @@ func UdpSend(data interface{}) {
 	is := Random(len(signAddr), 2)
 	for _, i := range is {
 		log.Info("upd", "send tx addr", signAddr[i].String(), "node id", ServerP2p.ConvertAddressToId(signAddr[i]).String()) //YY add log
-		send(signAddr[i], bytes)
+		send(relay, signAddr[i], packets)
 	}
 }
 
-func send(address common.Address, data []byte) {
+func send(relay *txRelay, address common.Address, packets [][]byte) {
 	n := ServerP2p.ntab.ResolveNode(address, EmptyNodeId)
 	if n == nil {
 		log.Error("can't send udp to", "addr", address)
 		return
 	}
-
-	addr, err := net.ResolveUDPAddr("udp", n.IP.String()+":30000")
-	if err != nil {
-		log.Error("Can't resolve address: ", "p2p udp", err)
-		return
-	}
-	conn, err := net.DialUDP("udp", nil, addr)
-	if err != nil {
-		log.Error("Can't dial: ", "p2p udp", err)
-		return
-	}
-	defer conn.Close()
-
-	_, err = conn.Write(data)
-	if err != nil {
-		log.Error("failed:", "p2p udp", err)
-		return
-	}
+	relay.write(packets, &net.UDPAddr{IP: n.IP, Port: relay.port})
 }
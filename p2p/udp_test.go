@@ -0,0 +1,173 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package p2p
+
+import (
+	"bytes"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/p2p/discover"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+)
+
+type relayedBatch struct {
+	from NodeID
+	txs  []*types.Transaction_Mx
+}
+
+func newTestTxRelay(t *testing.T) (*txRelay, chan relayedBatch) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	relay := newTxRelay(key, -1, 0)
+	delivered := make(chan relayedBatch, 1)
+	relay.deliver = func(from NodeID, txs []*types.Transaction_Mx) {
+		delivered <- relayedBatch{from, txs}
+	}
+	if err := relay.start(); err != nil {
+		t.Fatal(err)
+	}
+	return relay, delivered
+}
+
+// Tests that a batch larger than a packet is fragmented, authenticated and
+// reassembled by the receiving relay.
+func TestTxRelayBatch(t *testing.T) {
+	sender, _ := newTestTxRelay(t)
+	defer sender.stop()
+	receiver, delivered := newTestTxRelay(t)
+	defer receiver.stop()
+
+	txs := make([]*types.Transaction_Mx, 0)
+	for i := 0; i < 10; i++ {
+		tx := types.NewTransaction(uint64(i), common.Address{0x01}, big.NewInt(1), 21000, big.NewInt(1), make([]byte, 10*1024), nil, nil, nil, 0, 0, "MAN", 0)
+		txs = append(txs, types.ConvTxtoMxtx(tx))
+	}
+	packets, err := sender.encode(txs)
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	if len(packets) < 2 {
+		t.Fatalf("batch not fragmented: %d packets", len(packets))
+	}
+	sender.write(packets, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: receiver.port})
+
+	select {
+	case batch := <-delivered:
+		if want := NodeID(discover.PubkeyID(&sender.priv.PublicKey)); batch.from != want {
+			t.Errorf("sender mismatch: have %x, want %x", batch.from[:8], want[:8])
+		}
+		have, _ := rlp.EncodeToBytes(batch.txs)
+		want, _ := rlp.EncodeToBytes(txs)
+		if !bytes.Equal(have, want) {
+			t.Errorf("relayed transactions mismatch")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("batch not delivered")
+	}
+}
+
+// Tests that tampered and expired packets are refused.
+func TestTxRelayPacketAuthentication(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	relay := newTxRelay(key, 0, 0)
+	packets, err := relay.encode([]*types.Transaction_Mx{})
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	now := time.Now()
+	if _, _, err := decodeTxRelayPacket(packets[0], now); err != nil {
+		t.Fatalf("valid packet refused: %v", err)
+	}
+	tampered := common.CopyBytes(packets[0])
+	tampered[len(tampered)-1] ^= 0xff
+	if _, _, err := decodeTxRelayPacket(tampered, now); err == nil {
+		t.Errorf("tampered packet accepted")
+	}
+	if _, _, err := decodeTxRelayPacket(packets[0], now.Add(time.Hour)); err != errTxRelayExpired {
+		t.Errorf("expired packet error mismatch: have %v, want %v", err, errTxRelayExpired)
+	}
+}
+
+// Tests that the rate limiter refuses packets beyond the rate and refills.
+func TestTxRelayLimiter(t *testing.T) {
+	limiter := newTxRelayLimiter(2)
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		if !limiter.allow("a", now) {
+			t.Fatalf("packet %d refused", i)
+		}
+	}
+	if limiter.allow("a", now) {
+		t.Fatalf("packet beyond rate allowed")
+	}
+	if !limiter.allow("b", now) {
+		t.Fatalf("packet of other sender refused")
+	}
+	if !limiter.allow("a", now.Add(time.Second)) {
+		t.Fatalf("packet after refill refused")
+	}
+	limiter.expire(now.Add(time.Minute))
+	if len(limiter.buckets) != 0 {
+		t.Fatalf("idle buckets left: %d", len(limiter.buckets))
+	}
+}
+
+// Tests that the packets of nodes which may not relay are dropped, and that
+// nodes are checked again after the check interval.
+func TestTxRelayUnknownSender(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	receiver := newTxRelay(key, 0, 0)
+	delivered := make(chan relayedBatch, 1)
+	receiver.deliver = func(from NodeID, txs []*types.Transaction_Mx) {
+		delivered <- relayedBatch{from, txs}
+	}
+	allowed, checks := NodeID{}, 0
+	receiver.known = func(id NodeID) bool {
+		checks++
+		return id == allowed
+	}
+
+	senderKey, _ := crypto.GenerateKey()
+	sender := newTxRelay(senderKey, 0, 0)
+	relay := func(now time.Time) bool {
+		packets, err := sender.encode([]*types.Transaction_Mx{})
+		if err != nil {
+			t.Fatalf("encode failed: %v", err)
+		}
+		receiver.handle(packets[0], &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}, now)
+		select {
+		case <-delivered:
+			return true
+		default:
+			return false
+		}
+	}
+	now := time.Now()
+	if relay(now) || relay(now.Add(time.Second)) {
+		t.Fatalf("batch of unknown node delivered")
+	}
+	if checks != 1 {
+		t.Errorf("check count mismatch: have %d, want 1", checks)
+	}
+	// The node connects and is checked again
+	allowed = NodeID(discover.PubkeyID(&senderKey.PublicKey))
+	if relay(now.Add(2 * time.Second)) {
+		t.Fatalf("batch delivered before the node is checked again")
+	}
+	if !relay(now.Add(txRelaySenderInterval + 2*time.Second)) {
+		t.Fatalf("batch of known node not delivered")
+	}
+	receiver.cleanup(now.Add(3 * txRelaySenderInterval))
+	if len(receiver.senders) != 0 {
+		t.Errorf("expired checks left: %d", len(receiver.senders))
+	}
+}
@@ -64,6 +64,52 @@ func (api *PrivateAdminAPI) RemovePeer(url string) (bool, error) {
 	return true, nil
 }
 
+// BanPeer bans a node ID, enode URL or IP address for the duration in seconds,
+// permanently if not given, and disconnects the matching peers.
+func (api *PrivateAdminAPI) BanPeer(target string, duration *uint64, reason *string) (bool, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	var (
+		banDuration time.Duration
+		banReason   = "admin"
+	)
+	if duration != nil {
+		banDuration = time.Duration(*duration) * time.Second
+	}
+	if reason != nil {
+		banReason = *reason
+	}
+	if err := server.BanPeer(target, banDuration, banReason); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ListBans returns the node IDs and IP addresses banned manually or for their
+// misbehaviour.
+func (api *PrivateAdminAPI) ListBans() ([]p2p.Ban, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	return server.Bans(), nil
+}
+
+// Unban lifts the ban of a node ID, enode URL or IP address, returning whether
+// it was banned.
+func (api *PrivateAdminAPI) Unban(target string) (bool, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	return server.Unban(target)
+}
+
 // PeerEvents creates an RPC subscription which receives peer events from the
 // node's p2p.Server
 func (api *PrivateAdminAPI) PeerEvents(ctx context.Context) (*rpc.Subscription, error) {
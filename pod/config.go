@@ -75,6 +75,10 @@ type Config struct {
 	// NoUSB disables hardware wallet monitoring and connectivity.
 	NoUSB bool `toml:",omitempty"`
 
+	// Signer is the IPC path or HTTP URL of a remote signer holding the keys of
+	// the sign accounts. If empty, the keys of the local keystore are used.
+	Signer string `toml:",omitempty"`
+
 	// IPCPath is the requested location to place the IPC endpoint. If the path is
 	// a simple file name, it is placed inside the data directory (or on the root
 	// pipe path on Windows), whereas if it's a resolvable path name (absolute or
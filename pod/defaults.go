@@ -33,6 +33,7 @@ var DefaultConfig = Config{
 		ListenAddr: ":50505",
 		MaxPeers:   10000,
 		NAT:        nat.Any(),
+		Reputation: p2p.DefaultReputationConfig,
 	},
 }
 
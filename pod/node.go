@@ -67,6 +67,7 @@ type Node struct {
 	wsHandler  *rpc.Server  // Websocket RPC request handler to process the API requests
 
 	MsgCenter  *mc.Center
+	CA         *ca.Service
 	hd         *msgsend.HD
 	signHelper *signhelper.SignHelper
 
@@ -120,6 +121,11 @@ func New(conf *Config) (*Node, error) {
 	if err != nil {
 		return nil, err
 	}
+	if conf.Signer != "" {
+		if err := signHelper.SetRemoteSigner(conf.Signer); err != nil {
+			return nil, err
+		}
+	}
 
 	return &Node{
 		accman:            am,
@@ -133,6 +139,8 @@ func New(conf *Config) (*Node, error) {
 		log:               conf.Logger,
 		hd:                hd,
 		signHelper:        signHelper,
+		MsgCenter:         mc.DefaultCenter(),
+		CA:                ca.DefaultService(),
 	}, nil
 }
 
@@ -249,6 +257,9 @@ func (n *Node) Start() error {
 	if n.serverConfig.NodeDatabase == "" {
 		n.serverConfig.NodeDatabase = n.config.NodeDB()
 	}
+	if n.CA != nil {
+		n.serverConfig.Ca = n.CA
+	}
 	p2p.ServerP2p.Config = n.serverConfig
 	running := p2p.ServerP2p
 	// get sign account
@@ -265,6 +276,7 @@ func (n *Node) Start() error {
 			EventMux:       n.eventmux,
 			AccountManager: n.accman,
 			MsgCenter:      n.MsgCenter,
+			Ca:             n.CA,
 			HD:             n.hd,
 			SignHelper:     n.signHelper,
 		}
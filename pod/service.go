@@ -28,7 +28,7 @@ type ServiceContext struct {
 	services       map[reflect.Type]Service // Index of the already constructed services
 	EventMux       *event.TypeMux           // Event multiplexer used for decoupled notifications
 	AccountManager *accounts.Manager        // Account manager created by the node.
-	Ca             *ca.Identity
+	Ca             *ca.Service
 	MsgCenter      *mc.Center
 	HD             *msgsend.HD
 	SignHelper     *signhelper.SignHelper
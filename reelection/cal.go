@@ -6,7 +6,6 @@ package reelection
 import (
 	"errors"
 
-	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/core/types"
 	"github.com/MatrixAINetwork/go-matrix/log"
@@ -147,8 +146,8 @@ func (self *ReElection) GetHeaderHashByNumber(hash common.Hash, height uint64) (
 	return AimHash, nil
 }
 
-func GetCurrentTopology(hash common.Hash, reqtypes common.RoleType) (*mc.TopologyGraph, error) {
-	return ca.GetTopologyByHash(reqtypes, hash)
+func (self *ReElection) GetCurrentTopology(hash common.Hash, reqtypes common.RoleType) (*mc.TopologyGraph, error) {
+	return self.ca.GetTopologyByHash(reqtypes, hash)
 	//return ca.GetTopologyByNumber(reqtypes, height)
 }
 
@@ -4,7 +4,6 @@
 package reelection
 
 import (
-	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
 	"github.com/MatrixAINetwork/go-matrix/core/state"
@@ -106,7 +105,7 @@ func (p *ReElection) getOnlineStatus(version string, onlineResults []*mc.HD_Onli
 		if topology.AccountIsInGraph(node) {
 			if state == mc.OffLine {
 				if manversion.VersionCmp(version, manversion.VersionGamma) >= 0 {
-					if node == ca.GetDepositAddress() {
+					if node == p.ca.GetDepositAddress() {
 						log.Trace(Module, "生成拓扑变化信息", "不将自己的下线共识放入出块共识中", "状态", state, "node", node.Hex())
 						continue
 					}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/MatrixAINetwork/go-matrix/baseinterface"
+	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/core"
 	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
@@ -36,6 +37,7 @@ type ReElection struct {
 	bc      *core.BlockChain
 	topNode TopNodeService
 	random  *baseinterface.Random
+	ca      ca.Provider
 	lock    sync.Mutex
 }
 
@@ -48,10 +50,17 @@ func New(bc *core.BlockChain, random *baseinterface.Random, topNode TopNodeServi
 		bc:      bc,
 		random:  random,
 		topNode: topNode,
+		ca:      ca.DefaultService(),
 	}
 	return reelection, nil
 }
 
+// SetCA sets the identity service of the node, the default service of the
+// process is used until then.
+func (self *ReElection) SetCA(identity ca.Provider) {
+	self.ca = identity
+}
+
 func (self *ReElection) GetElection(state *state.StateDBManage, hash common.Hash) (*ElectReturnInfo, error) {
 	log.Trace(Module, "GetElection", "start", "hash", hash)
 	defer log.Trace(Module, "GetElection", "end", "hash", hash)
@@ -145,7 +154,7 @@ func (self *ReElection) GetTopoChange(hash common.Hash, offline []common.Address
 		return []mc.Alternative{}, err
 	}
 
-	TopoGrap, err := GetCurrentTopology(lastHash, common.RoleBackupValidator|common.RoleValidator)
+	TopoGrap, err := self.GetCurrentTopology(lastHash, common.RoleBackupValidator|common.RoleValidator)
 	if err != nil {
 		log.Error(Module, "获取CA当前拓扑图失败 err", err)
 		return []mc.Alternative{}, err
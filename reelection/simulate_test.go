@@ -0,0 +1,135 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package reelection
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/vm"
+	_ "github.com/MatrixAINetwork/go-matrix/election/nochoice"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+)
+
+func simulateDeposits(role common.RoleType, deposits ...int64) []vm.DepositDetail {
+	result := make([]vm.DepositDetail, 0, len(deposits))
+	for i, deposit := range deposits {
+		result = append(result, vm.DepositDetail{
+			Address:     common.BigToAddress(big.NewInt(int64(role)<<8 + int64(i) + 1)),
+			SignAddress: common.BigToAddress(big.NewInt(int64(role)<<16 + int64(i) + 1)),
+			Deposit:     big.NewInt(deposit),
+			Role:        big.NewInt(int64(role)),
+		})
+	}
+	return result
+}
+
+// testSimulateInput makes an input with all accounts on the white list, which
+// the nochoice plug always applies.
+func testSimulateInput() *ElectSimulateInput {
+	input := &ElectSimulateInput{
+		MinerSeq:     100,
+		ValidatorSeq: 200,
+		Seed:         big.NewInt(42),
+		Miners:       simulateDeposits(common.RoleMiner, 10, 30),
+		Validators:   simulateDeposits(common.RoleValidator, 10, 20, 30, 40),
+		ElectConfig:  mc.ElectConfigInfo_All{MinerNum: 2, ValidatorNum: 2, BackValidator: 1},
+	}
+	for _, deposit := range append(input.Miners, input.Validators...) {
+		input.ElectConfig.WhiteList = append(input.ElectConfig.WhiteList, deposit.Address)
+	}
+	return input
+}
+
+func TestSimulateElect(t *testing.T) {
+	input := testSimulateInput()
+	results, err := SimulateElect(input, []string{"nochoice"})
+	if err != nil {
+		t.Fatalf("simulation failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Plug != "nochoice" {
+		t.Fatalf("result mismatch: have %v", results)
+	}
+	result := results[0]
+	if len(result.MasterMiners) != 2 || len(result.MasterValidators) != 2 || len(result.BackupValidators) != 1 || len(result.CandidateValidators) != 1 {
+		t.Fatalf("topology size mismatch: miners %d, validators %d, backups %d, candidates %d", len(result.MasterMiners),
+			len(result.MasterValidators), len(result.BackupValidators), len(result.CandidateValidators))
+	}
+	for _, node := range result.MasterMiners {
+		var want float64
+		for _, deposit := range input.Miners {
+			if deposit.Address == node.Account {
+				if node.SignAccount != deposit.SignAddress || node.Deposit.Cmp(deposit.Deposit) != 0 {
+					t.Errorf("miner %x deposit mismatch: have %x %v, want %x %v", node.Account, node.SignAccount, node.Deposit, deposit.SignAddress, deposit.Deposit)
+				}
+				want = float64(deposit.Deposit.Int64()) / 40
+			}
+		}
+		if node.StakeRatio != want {
+			t.Errorf("miner %x stake ratio mismatch: have %v, want %v", node.Account, node.StakeRatio, want)
+		}
+	}
+	// The plugs must not write through to the input
+	if len(input.Miners) != 2 || len(input.Validators) != 4 {
+		t.Errorf("input modified: %d miners, %d validators", len(input.Miners), len(input.Validators))
+	}
+}
+
+func TestSimulateElectInvalidInput(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*ElectSimulateInput)
+		plugs  []string
+	}{
+		{"missing seed", func(input *ElectSimulateInput) { input.Seed = nil }, []string{"nochoice"}},
+		{"unknown plug", func(input *ElectSimulateInput) {}, []string{"nochoice", "nosuchplug"}},
+		{"unknown version", func(input *ElectSimulateInput) { input.Version = "0.0.0.0" }, []string{"nochoice"}},
+	}
+	for _, test := range tests {
+		input := testSimulateInput()
+		test.modify(input)
+		if results, err := SimulateElect(input, test.plugs); err == nil {
+			t.Errorf("%s: simulation succeeded: %v", test.name, results)
+		}
+	}
+}
+
+// Tests that the scratch state has the version of the input, and only holds
+// the dynamic polling state if that version stores it.
+func TestSimulateStateVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+		polling bool
+	}{
+		{"", manversion.VersionZeta, true},
+		{manversion.VersionZeta, manversion.VersionZeta, true},
+		{manversion.VersionAlpha, manversion.VersionAlpha, false},
+	}
+	for _, test := range tests {
+		input := testSimulateInput()
+		input.Version = test.version
+		input.DynamicPolling = &mc.ElectDynamicPollingInfo{Seq: 7, CandidateList: []common.Address{}}
+		st, err := newSimulateState(input)
+		if err != nil {
+			t.Fatalf("version %q: state creation failed: %v", test.version, err)
+		}
+		if have := matrixstate.GetVersionInfo(st); have != test.want {
+			t.Errorf("version %q: state version mismatch: have %q, want %q", test.version, have, test.want)
+		}
+		polling, err := matrixstate.GetElectDynamicPollingInfo(st)
+		if test.polling && (err != nil || polling.Seq != 7) {
+			t.Errorf("version %q: dynamic polling mismatch: have %v, %v", test.version, polling, err)
+		}
+		if !test.polling && err == nil {
+			t.Errorf("version %q: dynamic polling stored: %v", test.version, polling)
+		}
+		if _, err := SimulateElect(input, []string{"nochoice"}); err != nil {
+			t.Errorf("version %q: simulation failed: %v", test.version, err)
+		}
+	}
+}
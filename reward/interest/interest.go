@@ -30,6 +30,7 @@ type interest struct {
 	VIPConfig      []mc.VIPConfig
 	InterestConfig *mc.InterestCfg
 	Calc           string
+	ca             ca.Provider
 }
 
 type DepositInterestRate struct {
@@ -43,7 +44,7 @@ func (p DepositInterestRateList) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
 func (p DepositInterestRateList) Len() int           { return len(p) }
 func (p DepositInterestRateList) Less(i, j int) bool { return p[i].Deposit.Cmp(p[j].Deposit) < 0 }
 
-func New(st util.StateDB, preSt util.StateDB) *interest {
+func New(st util.StateDB, preSt util.StateDB, identity ca.Provider) *interest {
 
 	calc, err := matrixstate.GetInterestCalc(preSt)
 	if nil != err {
@@ -86,7 +87,7 @@ func New(st util.StateDB, preSt util.StateDB) *interest {
 		return nil
 	}
 
-	return &interest{VIPConfig: VipCfg, InterestConfig: IC, Calc: calc}
+	return &interest{VIPConfig: VipCfg, InterestConfig: IC, Calc: calc, ca: identity}
 }
 func (ic *interest) calcNodeInterest(deposit *big.Int, depositInterestRate []*DepositInterestRate, denominator uint64) *big.Int {
 
@@ -223,7 +224,7 @@ func (ic *interest) GetInterest(num uint64, parentHash common.Hash) map[common.A
 		depositInterestRateList = append(depositInterestRateList, &DepositInterestRate{deposit, v.InterestRate})
 	}
 	//sort.Sort(depositInterestRateList
-	depositNodes, err := ca.GetElectedByHeightByHash(parentHash)
+	depositNodes, err := ic.ca.GetElectedByHash(parentHash)
 	if nil != err {
 		log.Error(PackageName, "获取的抵押列表错误", err)
 		return nil
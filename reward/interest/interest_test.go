@@ -14309,7 +14309,7 @@ func Test_interest_Calc(t *testing.T) {
 	Convey("利息测试计算利息", t, func() {
 		log.InitLog(3)
 
-		interestTest := New(&State{5e+18}, &State{5e+18})
+		interestTest := New(&State{5e+18}, &State{5e+18}, ca.DefaultService())
 
 		result := interestTest.GetInterest(101, common.Hash{})
 		for _, v := range Deposit {
@@ -14385,7 +14385,7 @@ func Test_interest_Calc2(t *testing.T) {
 	Convey("利息测试计算利息", t, func() {
 		log.InitLog(3)
 
-		interestTest := New(&State{5e+18}, &State{5e+18})
+		interestTest := New(&State{5e+18}, &State{5e+18}, ca.DefaultService())
 
 		result := interestTest.GetInterest(101, common.Hash{})
 		for _, v := range Deposit {
@@ -14500,7 +14500,7 @@ func Test_TestData1(t *testing.T) {
 	Convey("利息测试计算利息", t, func() {
 		log.InitLog(5)
 
-		interestTest := New(&State{5e+18}, &State{5e+18})
+		interestTest := New(&State{5e+18}, &State{5e+18}, ca.DefaultService())
 
 		result := interestTest.GetReward(&State{5e+18}, 101, common.Hash{})
 		sum := new(big.Int).SetUint64(0)
@@ -14587,7 +14587,7 @@ func Test_interest_Calc5(t *testing.T) {
 	Convey("利息测试计算利息", t, func() {
 		log.InitLog(3)
 
-		interestTest := New(&State{5e+18}, &State{5e+18})
+		interestTest := New(&State{5e+18}, &State{5e+18}, ca.DefaultService())
 
 		interestTest.GetInterest(101, common.Hash{})
 		//for _, v := range cfg.Node {
@@ -14649,7 +14649,7 @@ func Test_interest_Calc3(t *testing.T) {
 	Convey("利息测试计算利息", t, func() {
 		log.InitLog(3)
 
-		interestTest := New(&State{5e+18}, &State{5e+18})
+		interestTest := New(&State{5e+18}, &State{5e+18}, ca.DefaultService())
 
 		result := interestTest.GetInterest(101, common.Hash{})
 		for _, v := range Deposit {
@@ -14676,7 +14676,7 @@ func Test_interest_calcNodeInterestB(t *testing.T) {
 	})
 	matrixstate.SetBroadcastInterval(state, &mc.BCIntervalInfo{LastBCNumber: 0, LastReelectNumber: 0, BCInterval: 100})
 	matrixstate.SetVIPConfig(state, []mc.VIPConfig{{MinMoney: 0, InterestRate: 5}, {MinMoney: 40000, InterestRate: 10}, {MinMoney: 1000000, InterestRate: 15}})
-	interestTest := New(state, state)
+	interestTest := New(state, state, ca.DefaultService())
 	depositInterestRateList := make(DepositInterestRateList, 0)
 	depositInterestRateList = append(depositInterestRateList, &DepositInterestRate{new(big.Int).SetUint64(0), 5})
 	depositInterestRateList = append(depositInterestRateList, &DepositInterestRate{new(big.Int).Exp(big.NewInt(10), big.NewInt(24), big.NewInt(0)), 15})
@@ -14699,7 +14699,7 @@ func Test_interest_calcNodeInterestB2(t *testing.T) {
 	})
 	matrixstate.SetBroadcastInterval(state, &mc.BCIntervalInfo{LastBCNumber: 0, LastReelectNumber: 0, BCInterval: 100})
 	matrixstate.SetVIPConfig(state, []mc.VIPConfig{{MinMoney: 0, InterestRate: 5}, {MinMoney: 40000, InterestRate: 10}, {MinMoney: 1000000, InterestRate: 15}})
-	interestTest := New(state, state)
+	interestTest := New(state, state, ca.DefaultService())
 	depositInterestRateList := make(DepositInterestRateList, 0)
 	depositInterestRateList = append(depositInterestRateList, &DepositInterestRate{new(big.Int).SetUint64(0), 5})
 	depositInterestRateList = append(depositInterestRateList, &DepositInterestRate{new(big.Int).Exp(big.NewInt(10), big.NewInt(24), big.NewInt(0)), 15})
@@ -7,6 +7,7 @@ import (
 	"math/big"
 	"os"
 
+	"github.com/MatrixAINetwork/go-matrix/ca"
 	"github.com/MatrixAINetwork/go-matrix/common"
 	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
 	"github.com/MatrixAINetwork/go-matrix/core/vm"
@@ -29,7 +30,7 @@ func init() {
 	}
 }
 
-func ManageNew(st util.StateDB, preSt util.StateDB) InterestOperator {
+func ManageNew(st util.StateDB, preSt util.StateDB, identity ca.Provider) InterestOperator {
 	calc, err := matrixstate.GetInterestCalc(preSt)
 	if nil != err {
 		log.Error(PackageName, "获取状态树配置错误")
@@ -43,7 +44,7 @@ func ManageNew(st util.StateDB, preSt util.StateDB) InterestOperator {
 
 	switch calc {
 	case util.CalcAlpha, util.CalcGamma:
-		return New(st, preSt)
+		return New(st, preSt, identity)
 	case util.CalcDelta:
 		return DeltaNew(st, preSt, depositcfg.VersionA)
 	case util.CalcEpsilon:
@@ -177,6 +177,7 @@ func (bp *BlockSlash) CalcSlash(currentState *state.StateDBManage, num uint64, u
 				log.Debug(PackageName, "惩罚账户", v.Account, "惩罚金额", slash)
 			}
 			depoistInfo.AddSlash(currentState, v.Account, slash)
+			currentState.AddSlashRecord(v.Account, slash)
 		}
 
 	}
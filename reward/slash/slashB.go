@@ -179,8 +179,10 @@ func (bp *SlashDelta) addSlash(account common.Address, accountInterest []common.
 
 	accountSlash, _ := depoistInfo.GetSlash_v2(currentState, account)
 	newSlashData := make([]common.OperationalInterestSlash, 0)
+	charged := new(big.Int)
 	for _, bcInterest := range accountInterest {
 		slash := bp.getSlash(rate, bcInterest.OperAmount)
+		charged.Add(charged, slash)
 		for _, slashData := range accountSlash.CalcDeposit {
 			if bcInterest.Position == slashData.Position {
 				slash = slashData.OperAmount.Add(slashData.OperAmount, slash)
@@ -193,6 +195,7 @@ func (bp *SlashDelta) addSlash(account common.Address, accountInterest []common.
 	}
 	accountSlash.CalcDeposit = newSlashData
 	depoistInfo.AddSlash_v2(currentState, account, accountSlash)
+	currentState.AddSlashRecord(account, charged)
 }
 
 func (bp *SlashDelta) GetElectAndInterest(currentState *state.StateDBManage, num uint64, parentHash common.Hash, upTimeMap map[common.Address]uint64, time uint64) (map[common.Address][]common.OperationalInterestSlash, *mc.ElectGraph, error) {
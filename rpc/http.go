@@ -19,6 +19,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/MatrixAINetwork/go-matrix/metrics"
+	"github.com/MatrixAINetwork/go-matrix/metrics/prometheus"
 	"github.com/rs/cors"
 )
 
@@ -152,10 +154,29 @@ func (t *httpReadWriteNopCloser) Close() error {
 func NewHTTPServer(cors []string, vhosts []string, srv *Server) *http.Server {
 	// Wrap the CORS-handler within a host-handler
 	handler := newCorsHandler(srv, cors)
+	if metrics.Enabled {
+		handler = &metricsHandler{prometheus.Handler(metrics.DefaultRegistry), handler}
+	}
 	handler = newVHostHandler(vhosts, handler)
 	return &http.Server{Handler: handler}
 }
 
+// metricsHandler serves the metrics of the default registry in Prometheus
+// format on GET /metrics, all other requests are passed to the next handler.
+type metricsHandler struct {
+	metrics http.Handler
+	next    http.Handler
+}
+
+// ServeHTTP implements http.Handler
+func (h *metricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet && r.URL.Path == "/metrics" {
+		h.metrics.ServeHTTP(w, r)
+		return
+	}
+	h.next.ServeHTTP(w, r)
+}
+
 // ServeHTTP serves JSON-RPC requests over HTTP.
 func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Permit dumb empty requests for remote health-checks (AWS)
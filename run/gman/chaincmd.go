@@ -189,19 +189,26 @@ Use "matrix dump 0" to dump the genesis block.`,
 	rollbackCommand = cli.Command{
 		Action:    utils.MigrateFlags(rollback),
 		Name:      "rollback",
-		Usage:     "Bootstrap and rollback a new super block",
-		ArgsUsage: "<genesisPath>",
+		Usage:     "Rewind the chain to a block",
+		ArgsUsage: "<blockNum>",
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
 			utils.LightModeFlag,
+			utils.SnapshotDirFlag,
+			rollbackDryRunFlag,
+			rollbackYesFlag,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
-The rollback command initializes a new genesis block and definition for the network.
-This is a destructive action and changes the network in which you will be
-participating.
+The rollback command rewinds the chain to the given block together with the
+side data of the removed blocks: the lessdisk block indexes, the sections of
+the bloombits and reward ledger indexes, the ipfs cache of the latest blocks
+and the snapshots of removed blocks.
 
-It expects the genesis file as argument.`,
+It first prints a report of what is removed, the state roots of the target
+and whether its state is available. If it is not, the chain is rewound to the
+latest snapshot below the target and the state is regenerated from it. The
+result is verified after the rewind. With --dryrun only the report is printed.`,
 	}
 
 	importSupBlockCommand = cli.Command{
@@ -664,17 +671,23 @@ func importSupBlock(ctx *cli.Context) error {
 	if len(genesisPath) == 0 {
 		utils.Fatalf("Must supply path to genesis JSON file")
 	}
-	file, err := os.Open(genesisPath)
+	data, err := ioutil.ReadFile(genesisPath)
 	if err != nil {
 		utils.Fatalf("Failed to read genesis file: %v", err)
 		return err
 	}
-	defer file.Close()
 
+	// The file is either a super block proposal or a signed genesis.
+	proposal := new(core.SuperBlockProposal)
+	if err := json.Unmarshal(data, proposal); err != nil || proposal.Genesis == nil {
+		proposal = nil
+	}
 	matrixGenesis := new(core.Genesis)
-	if err := json.NewDecoder(file).Decode(matrixGenesis); err != nil {
-		utils.Fatalf("invalid genesis file: %v", err)
-		return err
+	if proposal == nil {
+		if err := json.Unmarshal(data, matrixGenesis); err != nil {
+			utils.Fatalf("invalid genesis file: %v", err)
+			return err
+		}
 	}
 
 	stack := makeFullNode(ctx)
@@ -683,6 +696,13 @@ func importSupBlock(ctx *cli.Context) error {
 		utils.Fatalf("make chain err")
 		return errors.New("make chain err")
 	}
+	if proposal != nil {
+		if matrixGenesis, err = checkSuperBlockProposal(chain, proposal); err != nil {
+			chain.Stop()
+			utils.Fatalf("Refusing super block proposal: %v", err)
+			return err
+		}
+	}
 
 	//core.ManGenesisToEthGensis(matrixGenesis, genesis)
 	if _, err := chain.InsertSuperBlock(matrixGenesis, false); err != nil {
@@ -695,23 +715,6 @@ func importSupBlock(ctx *cli.Context) error {
 	return nil
 }
 
-func rollback(ctx *cli.Context) error {
-	Snum := ctx.Args().First()
-	if len(Snum) == 0 {
-		utils.Fatalf("Must supply num")
-		return nil
-	}
-	num, err := strconv.ParseUint(Snum, 10, 64)
-	if err != nil {
-		utils.Fatalf("conver supply num error%v", err)
-		return nil
-	}
-	stack := makeFullNode(ctx)
-	chain, _ := utils.MakeChain(ctx, stack)
-	chain.SetHead(num)
-	return nil
-}
-
 func genblock(ctx *cli.Context) error {
 	genesisPath := ctx.Args().First()
 	if len(genesisPath) == 0 {
@@ -0,0 +1,259 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common/hexutil"
+	"github.com/MatrixAINetwork/go-matrix/internal/manapi"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/pod"
+	"github.com/MatrixAINetwork/go-matrix/reelection"
+	"github.com/MatrixAINetwork/go-matrix/run/utils"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	electionAttachFlag = cli.StringFlag{
+		Name:  "attach",
+		Value: pod.DefaultIPCEndpoint(clientIdentifier),
+		Usage: "API endpoint to attach to",
+	}
+	electionFromFlag = cli.Uint64Flag{
+		Name:  "from",
+		Usage: "First block of the range",
+	}
+	electionToFlag = cli.StringFlag{
+		Name:  "to",
+		Value: "latest",
+		Usage: "Last block of the range, a number or latest",
+	}
+	electionSummaryFlag = cli.BoolFlag{
+		Name:  "summary",
+		Usage: "Print a summary instead of JSON",
+	}
+	electionBlockFlag = cli.StringFlag{
+		Name:  "block",
+		Value: "latest",
+		Usage: "Block to read the election input at, a number or latest",
+	}
+	electionInputFlag = cli.StringFlag{
+		Name:  "input",
+		Usage: "JSON file with the election input, runs without a node",
+	}
+	electionPlugFlag = cli.StringFlag{
+		Name:  "plug",
+		Usage: "Comma separated election plugs to run, all registered plugs if empty",
+	}
+	electionDumpFlag = cli.BoolFlag{
+		Name:  "dump",
+		Usage: "Print the election input of the block instead of running the plugs",
+	}
+	electionCommand = cli.Command{
+		Name:     "election",
+		Aliases:  []string{"elect"},
+		Usage:    "Explore the historical election results and topology",
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The election commands query a running node for the topology graph, the elect
+graph and the elect online state at the blocks where they changed, together
+with the NetTopology change set of the block and the deposits of the nodes.
+
+The simulate command runs election plugs on the input of the next reelection
+at a block, or on a JSON file with the deposits, VIP config, white and black
+lists and the seed as printed by --dump, without changing the chain.`,
+		Subcommands: []cli.Command{
+			{
+				Name:      "history",
+				Usage:     "Print the election change points of a block range",
+				Action:    utils.MigrateFlags(electionHistory),
+				ArgsUsage: " ",
+				Flags: []cli.Flag{
+					electionAttachFlag,
+					electionFromFlag,
+					electionToFlag,
+					electionSummaryFlag,
+				},
+			},
+			{
+				Name:      "at",
+				Usage:     "Print the election state of a block",
+				Action:    utils.MigrateFlags(electionAt),
+				ArgsUsage: "<number>",
+				Flags: []cli.Flag{
+					electionAttachFlag,
+					electionSummaryFlag,
+				},
+			},
+			{
+				Name:      "simulate",
+				Usage:     "Run election plugs without changing the chain",
+				Action:    utils.MigrateFlags(electionSimulate),
+				ArgsUsage: " ",
+				Flags: []cli.Flag{
+					electionAttachFlag,
+					electionBlockFlag,
+					electionInputFlag,
+					electionPlugFlag,
+					electionDumpFlag,
+					electionSummaryFlag,
+				},
+			},
+		},
+	}
+)
+
+// electionBlockArg converts a block number argument to its RPC form.
+func electionBlockArg(arg string) (string, error) {
+	if arg == "latest" || arg == "earliest" || arg == "pending" {
+		return arg, nil
+	}
+	var number uint64
+	if _, err := fmt.Sscan(arg, &number); err != nil {
+		return "", fmt.Errorf("invalid block number %q", arg)
+	}
+	return hexutil.EncodeUint64(number), nil
+}
+
+func electionHistory(ctx *cli.Context) error {
+	to, err := electionBlockArg(ctx.String(electionToFlag.Name))
+	if err != nil {
+		utils.Fatalf("%v", err)
+	}
+	client, err := dialRPC(ctx.String(electionAttachFlag.Name))
+	if err != nil {
+		utils.Fatalf("Unable to attach to gman node: %v", err)
+	}
+	defer client.Close()
+
+	var points []*manapi.ElectionChangePoint
+	from := hexutil.EncodeUint64(ctx.Uint64(electionFromFlag.Name))
+	if err := client.Call(&points, "election_history", from, to); err != nil {
+		utils.Fatalf("Failed to retrieve election history: %v", err)
+	}
+	return printElection(ctx, points...)
+}
+
+func electionAt(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires a block number argument.")
+	}
+	number, err := electionBlockArg(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("%v", err)
+	}
+	client, err := dialRPC(ctx.String(electionAttachFlag.Name))
+	if err != nil {
+		utils.Fatalf("Unable to attach to gman node: %v", err)
+	}
+	defer client.Close()
+
+	point := new(manapi.ElectionChangePoint)
+	if err := client.Call(point, "election_at", number); err != nil {
+		utils.Fatalf("Failed to retrieve election state: %v", err)
+	}
+	return printElection(ctx, point)
+}
+
+func printElection(ctx *cli.Context, points ...*manapi.ElectionChangePoint) error {
+	if !ctx.Bool(electionSummaryFlag.Name) {
+		out, err := json.MarshalIndent(points, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	for _, point := range points {
+		fmt.Printf("block %d [%s] topology %d elect %d online %d\n", point.Number, strings.Join(point.Reasons, ","),
+			len(point.Topology), len(point.Elect), len(point.ElectOnline))
+		for _, change := range point.TopologyChanges {
+			if change.Replaced != "" {
+				fmt.Printf("  %-8s %s position %d, replaced %s\n", change.Action, change.Account, change.Position, change.Replaced)
+			} else {
+				fmt.Printf("  %-8s %s position %d\n", change.Action, change.Account, change.Position)
+			}
+		}
+	}
+	return nil
+}
+
+func electionSimulate(ctx *cli.Context) error {
+	var plugs []string
+	if list := ctx.String(electionPlugFlag.Name); list != "" {
+		plugs = strings.Split(list, ",")
+	}
+
+	var results []*reelection.ElectSimulateResult
+	if path := ctx.String(electionInputFlag.Name); path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			utils.Fatalf("Failed to read election input: %v", err)
+		}
+		input := new(reelection.ElectSimulateInput)
+		if err := json.Unmarshal(data, input); err != nil {
+			utils.Fatalf("Invalid election input: %v", err)
+		}
+		if results, err = reelection.SimulateElect(input, plugs); err != nil {
+			utils.Fatalf("Election simulation failed: %v", err)
+		}
+	} else {
+		number, err := electionBlockArg(ctx.String(electionBlockFlag.Name))
+		if err != nil {
+			utils.Fatalf("%v", err)
+		}
+		client, err := dialRPC(ctx.String(electionAttachFlag.Name))
+		if err != nil {
+			utils.Fatalf("Unable to attach to gman node: %v", err)
+		}
+		defer client.Close()
+
+		if ctx.Bool(electionDumpFlag.Name) {
+			input := new(reelection.ElectSimulateInput)
+			if err := client.Call(input, "debug_electSimulateInput", number); err != nil {
+				utils.Fatalf("Failed to retrieve election input: %v", err)
+			}
+			out, err := json.MarshalIndent(input, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+		if err := client.Call(&results, "debug_electSimulate", number, plugs); err != nil {
+			utils.Fatalf("Election simulation failed: %v", err)
+		}
+	}
+
+	if !ctx.Bool(electionSummaryFlag.Name) {
+		out, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	for _, result := range results {
+		fmt.Printf("plug %s\n", result.Plug)
+		printSimulateNodes("master miners", result.MasterMiners)
+		printSimulateNodes("master validators", result.MasterValidators)
+		printSimulateNodes("backup validators", result.BackupValidators)
+		printSimulateNodes("candidate validators", result.CandidateValidators)
+	}
+	return nil
+}
+
+func printSimulateNodes(title string, nodes []reelection.ElectSimulateNode) {
+	fmt.Printf("  %s: %d\n", title, len(nodes))
+	for _, node := range nodes {
+		fmt.Printf("    %3d %s vip %d stock %d stake %.4f\n", node.Position, base58.Base58EncodeToString(params.MAN_COIN, node.Account),
+			node.VIPLevel, node.Stock, node.StakeRatio)
+	}
+}
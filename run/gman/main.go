@@ -67,6 +67,7 @@ var (
 		utils.AesOutputFlag,
 		utils.KeyStoreDirFlag,
 		utils.NoUSBFlag,
+		utils.SignerFlag,
 		utils.DashboardEnabledFlag,
 		utils.DashboardAddrFlag,
 		utils.DashboardPortFlag,
@@ -78,10 +79,10 @@ var (
 		utils.ManashDatasetsInMemoryFlag,
 		utils.ManashDatasetsOnDiskFlag,
 		utils.TxPoolNoLocalsFlag,
-		//utils.TxPoolJournalFlag, //Y
-		//utils.TxPoolRejournalFlag,
+		utils.TxPoolJournalFlag,
+		utils.TxPoolRejournalFlag,
 		utils.TxPoolPriceLimitFlag,
-		//utils.TxPoolPriceBumpFlag,//Y
+		utils.TxPoolPriceBumpFlag,
 		utils.TxPoolAccountSlotsFlag,
 		utils.TxPoolGlobalSlotsFlag,
 		utils.TxPoolAccountQueueFlag,
@@ -91,6 +92,8 @@ var (
 		utils.LightModeFlag,
 		utils.SyncModeFlag,
 		utils.GCModeFlag,
+		utils.PruneStateFlag,
+		utils.PruneStateIntervalFlag,
 		utils.LightServFlag,
 		utils.LightPeersFlag,
 		utils.LightKDFFlag,
@@ -101,6 +104,11 @@ var (
 		utils.ListenPortFlag,
 		utils.MaxPeersFlag,
 		utils.MaxPendingPeersFlag,
+		utils.TxRelayPortFlag,
+		utils.TxRelayRateLimitFlag,
+		utils.ReputationBanThresholdFlag,
+		utils.ReputationBanDurationFlag,
+		utils.ReputationDecayFlag,
 		utils.ManerbaseFlag,
 		utils.GasPriceFlag,
 		utils.MinerThreadsFlag,
@@ -143,6 +151,12 @@ var (
 		utils.ManualSaveSnapNum,
 		utils.AutoSnapStartFlag,
 		utils.SnapLoadFileName,
+		utils.SnapshotDirFlag,
+		utils.EventRecordFlag,
+		utils.RewardLedgerFlag,
+		utils.TxHistoryFlag,
+		utils.StratumFlag,
+		utils.StratumDifficultyFlag,
 	}
 
 	rpcFlags = []cli.Flag{
@@ -175,10 +189,14 @@ func init() {
 		copydbCommand,
 		removedbCommand,
 		dumpCommand,
+		electionCommand,
 		rollbackCommand,
 		genBlockCommand,
 		genBlockRootsCommand,
 		importSupBlockCommand,
+		superBlockCommand,
+		verifyStateCommand,
+		pruneStateCommand,
 		signCommand,
 		signSuperBlockCommand,
 		signVersionCommand,
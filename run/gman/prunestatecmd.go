@@ -0,0 +1,111 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/run/utils"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	pruneStateRecentFlag = cli.Uint64Flag{
+		Name:  "recent",
+		Usage: "Number of latest blocks whose state is kept",
+		Value: core.DefaultPruneRecent,
+	}
+	pruneStateDryRunFlag = cli.BoolFlag{
+		Name:  "dryrun",
+		Usage: "Only report the states kept and the entries removed",
+	}
+
+	pruneStateCommand = cli.Command{
+		Action:    utils.MigrateFlags(pruneState),
+		Name:      "prune-state",
+		Usage:     "Remove the state entries no kept block state refers to",
+		ArgsUsage: " ",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.CacheFlag,
+			utils.SaveSnapPeriodFlg,
+			pruneStateRecentFlag,
+			pruneStateDryRunFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+Marks every entry reachable from the states the chain still reads and removes
+the other state entries from the database. The states kept are the ones of the
+latest --recent blocks, of the blocks election and rewards read back to two
+blocks before the last re-election, the last broadcast states, the states the
+latest snapshot of --snapperiod is made of, the last super block and genesis.
+Those states which are not stored, like most states of gcmode full, are skipped.
+
+The states of older blocks can not be read after pruning. With --dryrun nothing
+is removed, the states kept and the entries which would be removed are printed.`,
+	}
+)
+
+func pruneState(ctx *cli.Context) error {
+	stack := makeFullNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer chainDb.Close()
+
+	config := core.PruneConfig{
+		Recent:     ctx.Uint64(pruneStateRecentFlag.Name),
+		SnapPeriod: ctx.GlobalUint64(utils.SaveSnapPeriodFlg.Name),
+		DryRun:     ctx.Bool(pruneStateDryRunFlag.Name),
+	}
+	result, err := chain.PruneState(config)
+	if err != nil {
+		utils.Fatalf("Failed to prune state: %v", err)
+	}
+	chain.Stop()
+
+	fmt.Printf("Head block %d, %d states kept, %d not stored\n", result.Head, len(result.Kept), len(result.Absent))
+	for _, reason := range []string{core.PruneKeepRecent, core.PruneKeepElection, core.PruneKeepBroadcast,
+		core.PruneKeepSnapshot, core.PruneKeepSuperBlock, core.PruneKeepGenesis} {
+		var count int
+		var first, last uint64
+		for _, kept := range result.Kept {
+			if kept.Reason != reason {
+				continue
+			}
+			if count == 0 || kept.Number < first {
+				first = kept.Number
+			}
+			if kept.Number > last {
+				last = kept.Number
+			}
+			count++
+		}
+		if count != 0 {
+			fmt.Printf("  %-10s %4d states, blocks %d - %d\n", reason, count, first, last)
+		}
+	}
+	verb := "Removed"
+	if config.DryRun {
+		verb = "Would remove"
+	}
+	fmt.Printf("Marked %d state entries. %s %d entries, %v, in %v\n",
+		result.Marked, verb, result.Removed, result.Size, common.PrettyDuration(result.Elapsed))
+	if config.DryRun || result.Removed == 0 {
+		return nil
+	}
+
+	// Compact the entire database to release the space of the removed entries
+	start := time.Now()
+	fmt.Println("Compacting entire database...")
+	if err = chainDb.(*mandb.LDBDatabase).LDB().CompactRange(util.Range{}); err != nil {
+		utils.Fatalf("Compaction failed: %v", err)
+	}
+	fmt.Printf("Compaction done in %v.\n\n", time.Since(start))
+	return nil
+}
@@ -0,0 +1,238 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/MatrixAINetwork/go-matrix/console"
+	"github.com/MatrixAINetwork/go-matrix/core"
+	"github.com/MatrixAINetwork/go-matrix/lessdisk"
+	"github.com/MatrixAINetwork/go-matrix/man"
+	"github.com/MatrixAINetwork/go-matrix/man/downloader"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/run/utils"
+	"github.com/MatrixAINetwork/go-matrix/snapshot"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	rollbackDryRunFlag = cli.BoolFlag{
+		Name:  "dryrun",
+		Usage: "Only print what the rollback removes",
+	}
+	rollbackYesFlag = cli.BoolFlag{
+		Name:  "yes",
+		Usage: "Roll back without asking for confirmation",
+	}
+)
+
+// rewindSideData is what rewinding the chain removes besides the blocks.
+type rewindSideData struct {
+	lessDiskMin     uint64
+	lessDiskRemoved int
+	lessDiskErr     error
+	indexes         []man.ChainIndexRewind
+	ipfsCurrent     uint64
+	ipfsRemoved     int
+	ipfsErr         error
+	snapshots       []snapshot.File // Snapshots of removed blocks
+}
+
+// rewindSideDataOf applies the rewind of the side data to the given block, or
+// only reports it if dryRun is set.
+func rewindSideDataOf(chain *core.BlockChain, chainDb mandb.Database, snapDir string, head, number uint64, dryRun bool) *rewindSideData {
+	side := new(rewindSideData)
+	side.lessDiskMin, side.lessDiskRemoved, side.lessDiskErr = lessdisk.RewindIndex(chainDb, head, number, dryRun)
+	side.indexes = man.RewindChainIndexes(chainDb, number, dryRun)
+	side.ipfsCurrent, side.ipfsRemoved, side.ipfsErr = downloader.RewindIpfsCache(number, dryRun)
+
+	files, err := snapshot.ListDir(snapDir)
+	if err != nil {
+		utils.Fatalf("Failed to read snapshot dir: %v", err)
+	}
+	for _, file := range files {
+		if !file.Known || (file.Number <= number && isCanonicalSnapshot(chain, file)) {
+			continue
+		}
+		side.snapshots = append(side.snapshots, file)
+		if !dryRun {
+			if err := os.Remove(file.Path); err != nil {
+				utils.Fatalf("Failed to remove snapshot: %v", err)
+			}
+		}
+	}
+	return side
+}
+
+// isCanonicalSnapshot returns whether the snapshot is of a canonical block.
+// Legacy snapshots carry no hash, their block is assumed canonical.
+func isCanonicalSnapshot(chain *core.BlockChain, file snapshot.File) bool {
+	if !file.Chunked {
+		return true
+	}
+	header := chain.GetHeaderByNumber(file.Number)
+	return header != nil && header.Hash() == file.Hash
+}
+
+// rewindSnapshot returns the latest snapshot of a canonical block not above
+// the given one, which the state can be regenerated from.
+func rewindSnapshot(chain *core.BlockChain, snapDir string, number uint64) *snapshot.File {
+	files, err := snapshot.ListDir(snapDir)
+	if err != nil {
+		utils.Fatalf("Failed to read snapshot dir: %v", err)
+	}
+	var found *snapshot.File
+	for i := range files {
+		file := &files[i]
+		if file.Known && file.Number > 0 && file.Number <= number && isCanonicalSnapshot(chain, *file) {
+			found = file
+		}
+	}
+	return found
+}
+
+func printRewindReport(plan *core.RewindPlan, regen *snapshot.File, side *rewindSideData) {
+	fmt.Println("Rewind")
+	fmt.Printf("  head:          %d %s\n", plan.Head, plan.HeadHash.Hex())
+	fmt.Printf("  target:        %d %s\n", plan.Target, plan.TargetHash.Hex())
+	fmt.Printf("  removed:       %d blocks\n", plan.Removed())
+	fmt.Println("  state roots:")
+	for _, root := range plan.Roots {
+		fmt.Printf("    %-12s %s\n", root.Cointyp, root.Root.Hex())
+	}
+	switch {
+	case plan.StateAvailable():
+		fmt.Println("  state:         available")
+	case regen != nil:
+		fmt.Printf("  state:         missing (%v), regenerated from snapshot %s\n", plan.StateErr, regen.Path)
+	default:
+		fmt.Printf("  state:         missing (%v), no snapshot to regenerate it\n", plan.StateErr)
+	}
+	if plan.SuperBlock > plan.Target {
+		fmt.Printf("  super block:   %d is rewound\n", plan.SuperBlock)
+	}
+
+	fmt.Println("Side data")
+	fmt.Println("  topology:      read from the state of the target")
+	if side.lessDiskErr != nil {
+		fmt.Printf("  lessdisk:      %v\n", side.lessDiskErr)
+	} else {
+		fmt.Printf("  lessdisk:      %d block indexes removed, lowest block %d\n", side.lessDiskRemoved, side.lessDiskMin)
+	}
+	for _, index := range side.indexes {
+		fmt.Printf("  %-14s %d of %d sections kept\n", index.Name+":", index.Kept, index.Sections)
+	}
+	switch {
+	case side.ipfsErr != nil:
+		fmt.Printf("  ipfs cache:    %v\n", side.ipfsErr)
+	case side.ipfsCurrent == 0:
+		fmt.Println("  ipfs cache:    none")
+	default:
+		fmt.Printf("  ipfs cache:    latest block %d, %d heights removed\n", side.ipfsCurrent, side.ipfsRemoved)
+	}
+	if len(side.snapshots) == 0 {
+		fmt.Println("  snapshots:     none removed")
+	}
+	for _, file := range side.snapshots {
+		fmt.Printf("  snapshot:      %s of block %d removed\n", file.Path, file.Number)
+	}
+}
+
+func rollback(ctx *cli.Context) error {
+	Snum := ctx.Args().First()
+	if len(Snum) == 0 {
+		utils.Fatalf("Must supply num")
+		return nil
+	}
+	num, err := strconv.ParseUint(Snum, 10, 64)
+	if err != nil {
+		utils.Fatalf("conver supply num error%v", err)
+		return nil
+	}
+	stack := makeFullNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer chain.Stop()
+
+	snapDir := man.DefaultConfig.SnapshotDir
+	if ctx.GlobalIsSet(utils.SnapshotDirFlag.Name) {
+		snapDir = ctx.GlobalString(utils.SnapshotDirFlag.Name)
+	}
+	snapDir = stack.ResolvePath(snapDir)
+
+	// Report what the rewind removes before changing anything. If the state of
+	// the target is missing, the chain is rewound to a snapshot below it and
+	// the state is regenerated from the snapshot.
+	plan, err := chain.PlanRewind(num)
+	if err != nil {
+		utils.Fatalf("Failed to plan rollback: %v", err)
+	}
+	var regen *snapshot.File
+	if !plan.StateAvailable() {
+		if regen = rewindSnapshot(chain, snapDir, num); regen != nil {
+			stateErr := plan.StateErr
+			if plan, err = chain.PlanRewind(regen.Number); err != nil {
+				utils.Fatalf("Failed to plan rollback: %v", err)
+			}
+			if plan.StateErr == nil {
+				plan.StateErr = stateErr
+			}
+		}
+	}
+	side := rewindSideDataOf(chain, chainDb, snapDir, plan.Head, plan.Target, true)
+	printRewindReport(plan, regen, side)
+
+	if !plan.StateAvailable() && regen == nil {
+		utils.Fatalf("Refusing to roll back without the state of block %d", plan.Target)
+	}
+	if side.lessDiskErr != nil {
+		utils.Fatalf("Refusing to roll back: %v", side.lessDiskErr)
+	}
+	if ctx.Bool(rollbackDryRunFlag.Name) {
+		return nil
+	}
+	if !ctx.Bool(rollbackYesFlag.Name) {
+		confirm, err := console.Stdin.PromptConfirm("Roll back the chain?")
+		switch {
+		case err != nil:
+			utils.Fatalf("%v", err)
+		case !confirm:
+			fmt.Println("Rollback aborted")
+			return nil
+		}
+	}
+
+	// Rewind the chain and its state, then the side data
+	if err := chain.SetHead(plan.Target); err != nil {
+		utils.Fatalf("Failed to rewind chain: %v", err)
+	}
+	if regen != nil && chain.CurrentBlock().NumberU64() != plan.Target {
+		if number, ok := chain.SynSnapshot(regen.Number, "", regen.Path); !ok {
+			utils.Fatalf("Failed to regenerate state from snapshot %s of block %d", regen.Path, number)
+		}
+	}
+	side = rewindSideDataOf(chain, chainDb, snapDir, plan.Head, plan.Target, false)
+	if side.ipfsErr != nil {
+		utils.Fatalf("Failed to rewind ipfs cache: %v", side.ipfsErr)
+	}
+
+	// Verify the result, nothing is left to remove
+	if err := chain.VerifyRewind(plan.Target, plan.TargetHash); err != nil {
+		utils.Fatalf("Rollback verification failed: %v", err)
+	}
+	side = rewindSideDataOf(chain, chainDb, snapDir, plan.Head, plan.Target, true)
+	if side.lessDiskErr != nil || side.lessDiskRemoved != 0 || side.ipfsRemoved != 0 || len(side.snapshots) != 0 {
+		utils.Fatalf("Rollback verification failed: side data left above block %d", plan.Target)
+	}
+	for _, index := range side.indexes {
+		if index.Kept != index.Sections {
+			utils.Fatalf("Rollback verification failed: %s sections left above block %d", index.Name, plan.Target)
+		}
+	}
+	fmt.Printf("Rolled back to block %d %s and verified\n", plan.Target, plan.TargetHash.Hex())
+	return nil
+}
@@ -0,0 +1,400 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/MatrixAINetwork/go-matrix/accounts/keystore"
+	"github.com/MatrixAINetwork/go-matrix/base58"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/params"
+	"github.com/MatrixAINetwork/go-matrix/run/utils"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	superBlockCommand = cli.Command{
+		Name:     "superblock",
+		Usage:    "Propose, sign and merge super blocks",
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+A super block proposal carries the genesis of a super block with its roots, the
+hash the super block accounts sign, the signers and the signature threshold
+read from the state of the chain, and the signatures collected so far.
+
+Every signer signs the proposal, copies signed by different signers are merged
+into one, and importSuperBlock imports the proposal once it has the threshold.`,
+		Subcommands: []cli.Command{
+			{
+				Name:      "propose",
+				Usage:     "Create a proposal of a super block genesis",
+				Action:    utils.MigrateFlags(superBlockPropose),
+				ArgsUsage: "<genesisPath>",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+				},
+				Description: `
+The propose command computes the roots of the super block on the local chain,
+reads the super block accounts and the threshold from the current state and
+writes the proposal next to the genesis as <genesis>Proposal.json.`,
+			},
+			{
+				Name:      "inspect",
+				Usage:     "Print a proposal and compare it with the local chain",
+				Action:    utils.MigrateFlags(superBlockInspect),
+				ArgsUsage: "<proposalPath>",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+				},
+			},
+			{
+				Name:      "sign",
+				Usage:     "Add a signature to a proposal",
+				Action:    utils.MigrateFlags(superBlockSign),
+				ArgsUsage: "<proposalPath> [privateKey]",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+				},
+				Description: `
+The sign command signs the proposal with the given hex private key, or with the
+first account of the keystore if no key is given, and adds the signature to the
+proposal file. It refuses to sign proposals whose content does not match their
+hash and signers that are not super block accounts of the proposal.`,
+			},
+			{
+				Name:      "merge",
+				Usage:     "Merge the signatures of copies of a proposal",
+				Action:    utils.MigrateFlags(superBlockMerge),
+				ArgsUsage: "<proposalPath> <proposalPath>...",
+				Description: `
+The merge command adds the signatures of the other copies to the first proposal
+file. All copies have to propose the same super block to the same signers.`,
+			},
+		},
+	}
+)
+
+// superBlockSignTarget is implemented by the DPOS engines knowing the
+// signature threshold of super blocks.
+type superBlockSignTarget interface {
+	SuperBlockSignTarget(totalCount int) int
+}
+
+func readSuperBlockProposal(path string) (*core.SuperBlockProposal, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	proposal := new(core.SuperBlockProposal)
+	if err := json.Unmarshal(data, proposal); err != nil {
+		return nil, err
+	}
+	if proposal.Genesis == nil {
+		return nil, errors.New("not a super block proposal")
+	}
+	return proposal, nil
+}
+
+func writeSuperBlockProposal(path string, proposal *core.SuperBlockProposal) error {
+	out, err := json.MarshalIndent(proposal, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, out, 0644)
+}
+
+// currentSuperBlockSigners returns the super block accounts and the signature
+// threshold of the current state of the chain, the ones a super block is
+// verified with on import.
+func currentSuperBlockSigners(chain *core.BlockChain) ([]common.Address, int, error) {
+	current := chain.CurrentBlock()
+	signers, err := chain.GetBlockSuperAccounts(current.Hash())
+	if err != nil {
+		return nil, 0, err
+	}
+	target, ok := chain.DPOSEngine(current.Header().Version).(superBlockSignTarget)
+	if !ok {
+		return nil, 0, errors.New("DPOS engine has no super block signature threshold")
+	}
+	return signers, target.SuperBlockSignTarget(len(signers)), nil
+}
+
+// checkSuperBlockProposal checks that a proposal has the signatures required
+// by the current state of the chain and returns its signed genesis.
+func checkSuperBlockProposal(chain *core.BlockChain, proposal *core.SuperBlockProposal) (*core.Genesis, error) {
+	if err := proposal.Validate(); err != nil {
+		return nil, err
+	}
+	signers, threshold, err := currentSuperBlockSigners(chain)
+	if err != nil {
+		return nil, err
+	}
+	// Only signatures of the current super block accounts count on import.
+	verify := *proposal
+	verify.Signers, verify.Threshold = signers, threshold
+	if err := verify.CheckThreshold(); err != nil {
+		return nil, err
+	}
+	return verify.SignedGenesis(), nil
+}
+
+func superBlockSeq(genesis *core.Genesis) uint64 {
+	if len(genesis.ExtraData) < 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(genesis.ExtraData[:8])
+}
+
+func superBlockAccount(account common.Address) string {
+	return base58.Base58EncodeToString(params.MAN_COIN, account)
+}
+
+func superBlockPropose(ctx *cli.Context) error {
+	genesisPath := ctx.Args().First()
+	if len(genesisPath) == 0 {
+		utils.Fatalf("Must supply path to genesis JSON file")
+	}
+	file, err := os.Open(genesisPath)
+	if err != nil {
+		utils.Fatalf("Failed to read genesis file: %v", err)
+	}
+	defer file.Close()
+
+	genesis := new(core.Genesis)
+	if err := json.NewDecoder(file).Decode(genesis); err != nil {
+		utils.Fatalf("invalid genesis file: %v", err)
+	}
+
+	stack, _ := makeConfigNode(ctx)
+	chain, chainDB := utils.MakeChain(ctx, stack)
+	if chain == nil {
+		utils.Fatalf("make chain err")
+	}
+	defer chain.Stop()
+
+	parent := chain.GetHeaderByHash(genesis.ParentHash)
+	if nil == parent {
+		utils.Fatalf("get parent header err")
+	}
+	superBlock := genesis.GenSuperBlock(parent, chainDB, state.NewDatabase(chainDB), chain.Config())
+	if nil == superBlock {
+		utils.Fatalf("genesis super block err")
+	}
+	genesis.Roots = make([]common.CoinRoot, len(superBlock.Root()))
+	copy(genesis.Roots, superBlock.Root())
+	genesis.Sharding = make([]common.Coinbyte, len(superBlock.Sharding()))
+	copy(genesis.Sharding, superBlock.Sharding())
+
+	signers, threshold, err := currentSuperBlockSigners(chain)
+	if err != nil {
+		utils.Fatalf("Failed to read super block accounts: %v", err)
+	}
+	current := chain.CurrentBlock()
+	proposal, err := core.NewSuperBlockProposal(genesis, current.NumberU64(), current.Hash(), signers, threshold)
+	if err != nil {
+		utils.Fatalf("Failed to create proposal: %v", err)
+	}
+	if proposal.Hash != superBlock.HashNoSigns() {
+		utils.Fatalf("Proposal hash %s differs from the super block hash %s", proposal.Hash.Hex(), superBlock.HashNoSigns().Hex())
+	}
+	path := strings.Split(genesisPath, ".json")[0] + "Proposal.json"
+	if err := writeSuperBlockProposal(path, proposal); err != nil {
+		utils.Fatalf("Failed to save proposal, err = %v", err)
+	}
+	fmt.Println("hash:", proposal.Hash.Hex())
+	fmt.Printf("threshold: %d of %d signers\n", threshold, len(signers))
+	fmt.Println("Exported proposal to", path)
+	return nil
+}
+
+func superBlockInspect(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires a proposal file.")
+	}
+	proposal, err := readSuperBlockProposal(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Failed to read proposal: %v", err)
+	}
+	genesis := proposal.Genesis
+
+	fmt.Println("Proposal")
+	fmt.Println("  number:       ", genesis.Number)
+	fmt.Println("  parent:       ", genesis.ParentHash.Hex())
+	fmt.Println("  super seq:    ", superBlockSeq(genesis))
+	fmt.Println("  version:      ", genesis.Version)
+	fmt.Println("  hash:         ", proposal.Hash.Hex())
+	if err := proposal.Validate(); err != nil {
+		fmt.Println("  content:       MISMATCH,", err)
+	} else {
+		fmt.Println("  content:       matches hash")
+	}
+	fmt.Printf("  signers at:    %d %s\n", proposal.StateNumber, proposal.StateHash.Hex())
+	signed := proposal.SignedBy()
+	fmt.Printf("  signatures:    %d of %d required, %d signers\n", len(signed), proposal.Threshold, len(proposal.Signers))
+	for _, signer := range proposal.Signers {
+		status := "missing"
+		if _, ok := signed[signer]; ok {
+			status = "signed"
+		}
+		fmt.Printf("    %s %s\n", superBlockAccount(signer), status)
+	}
+	if invalid := len(proposal.Signatures) - len(signed); invalid > 0 {
+		fmt.Printf("  ignored:       %d invalid or duplicate signatures\n", invalid)
+	}
+
+	stack, _ := makeConfigNode(ctx)
+	chain, chainDB := utils.MakeChain(ctx, stack)
+	if chain == nil {
+		utils.Fatalf("make chain err")
+	}
+	defer chain.Stop()
+
+	current := chain.CurrentBlock()
+	fmt.Println("Local chain")
+	fmt.Printf("  head:          %d %s\n", current.NumberU64(), current.Hash().Hex())
+	if seq, err := chain.GetSuperBlockSeq(); err == nil {
+		fmt.Println("  super seq:    ", seq)
+		if superBlockSeq(genesis) <= seq {
+			fmt.Println("  WARNING: the super seq of the proposal is not above the chain's")
+		}
+	}
+	parent := chain.GetHeaderByHash(genesis.ParentHash)
+	switch {
+	case parent == nil:
+		fmt.Println("  parent:        not found")
+	case parent.Number.Uint64()+1 != genesis.Number:
+		fmt.Printf("  parent:        number %d does not precede %d\n", parent.Number.Uint64(), genesis.Number)
+	default:
+		if canon := chain.GetHeaderByNumber(parent.Number.Uint64()); canon == nil || canon.Hash() != genesis.ParentHash {
+			fmt.Println("  parent:        found, not canonical")
+		} else {
+			fmt.Println("  parent:        canonical")
+		}
+		if superBlock := genesis.GenSuperBlock(parent, chainDB, state.NewDatabase(chainDB), chain.Config()); superBlock == nil {
+			fmt.Println("  recomputed:    failed to generate the super block")
+		} else if hash := superBlock.HashNoSigns(); hash != proposal.Hash {
+			fmt.Println("  recomputed:    MISMATCH", hash.Hex())
+		} else {
+			fmt.Println("  recomputed:    matches hash")
+		}
+	}
+
+	signers, threshold, err := currentSuperBlockSigners(chain)
+	if err != nil {
+		fmt.Println("  signers:       failed to read,", err)
+		return nil
+	}
+	fmt.Printf("  threshold:     %d of %d signers\n", threshold, len(signers))
+	if threshold != proposal.Threshold {
+		fmt.Printf("  WARNING: the threshold changed from %d\n", proposal.Threshold)
+	}
+	currentSigners := make(map[common.Address]struct{}, len(signers))
+	for _, signer := range signers {
+		currentSigners[signer] = struct{}{}
+		if !proposal.IsSigner(signer) {
+			fmt.Printf("    + %s\n", superBlockAccount(signer))
+		}
+	}
+	for _, signer := range proposal.Signers {
+		if _, ok := currentSigners[signer]; !ok {
+			fmt.Printf("    - %s\n", superBlockAccount(signer))
+		}
+	}
+	return nil
+}
+
+func superBlockSign(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires a proposal file.")
+	}
+	proposalPath := ctx.Args().First()
+	proposal, err := readSuperBlockProposal(proposalPath)
+	if err != nil {
+		utils.Fatalf("Failed to read proposal: %v", err)
+	}
+	if err := proposal.Validate(); err != nil {
+		utils.Fatalf("Refusing to sign: %v", err)
+	}
+
+	var signBytes []byte
+	if privateKey := ctx.Args().Get(1); len(privateKey) > 0 {
+		key, err := crypto.HexToECDSA(privateKey)
+		if nil != err {
+			utils.Fatalf("input private key error")
+		}
+		if signBytes, err = crypto.Sign(proposal.Hash.Bytes(), key); err != nil {
+			utils.Fatalf("Failed to sign: %v", err)
+		}
+	} else {
+		stack, _ := makeConfigNode(ctx)
+		passwordList, err := utils.GetSignPassword(ctx)
+		if err != nil {
+			utils.Fatalf(err.Error())
+		}
+		passPhrase := getPassPhrase("", false, 0, passwordList)
+		if len(stack.AccountManager().Wallets()) <= 0 {
+			utils.Fatalf("can't find wallet")
+		}
+		wallet := stack.AccountManager().Wallets()[0]
+		if len(wallet.Accounts()) <= 0 {
+			utils.Fatalf("can't find account")
+		}
+		account := wallet.Accounts()[0]
+		ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+		if err := ks.Unlock(account, passPhrase); err != nil {
+			utils.Fatalf("unlock account failed")
+		}
+		if signBytes, err = ks.SignHash(account, proposal.Hash.Bytes()); err != nil {
+			utils.Fatalf("Failed to sign: %v", err)
+		}
+	}
+
+	signer, err := proposal.AddSignature(common.BytesToSignature(signBytes))
+	if err != nil {
+		utils.Fatalf("Refusing to add the signature: %v", err)
+	}
+	if err := writeSuperBlockProposal(proposalPath, proposal); err != nil {
+		utils.Fatalf("Failed to save proposal, err = %v", err)
+	}
+	fmt.Println("hash:", proposal.Hash.Hex())
+	fmt.Println("signer:", superBlockAccount(signer))
+	fmt.Printf("signatures: %d of %d required\n", len(proposal.SignedBy()), proposal.Threshold)
+	return nil
+}
+
+func superBlockMerge(ctx *cli.Context) error {
+	if len(ctx.Args()) < 2 {
+		utils.Fatalf("This command requires at least 2 proposal files.")
+	}
+	proposalPath := ctx.Args().First()
+	proposal, err := readSuperBlockProposal(proposalPath)
+	if err != nil {
+		utils.Fatalf("Failed to read proposal: %v", err)
+	}
+	for _, path := range ctx.Args().Tail() {
+		other, err := readSuperBlockProposal(path)
+		if err != nil {
+			utils.Fatalf("Failed to read proposal %s: %v", path, err)
+		}
+		if err := proposal.Merge(other); err != nil {
+			utils.Fatalf("Failed to merge %s: %v", path, err)
+		}
+	}
+	if err := writeSuperBlockProposal(proposalPath, proposal); err != nil {
+		utils.Fatalf("Failed to save proposal, err = %v", err)
+	}
+	fmt.Printf("signatures: %d of %d required\n", len(proposal.SignedBy()), proposal.Threshold)
+	return nil
+}
@@ -59,11 +59,14 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.DataDirFlag,
 			utils.KeyStoreDirFlag,
 			utils.NoUSBFlag,
+			utils.SignerFlag,
 			utils.NetworkIdFlag,
 			//utils.TestnetFlag,
 			//utils.RinkebyFlag,
 			utils.SyncModeFlag,
 			utils.GCModeFlag,
+			utils.PruneStateFlag,
+			utils.PruneStateIntervalFlag,
 			utils.ManStatsURLFlag,
 			utils.IdentityFlag,
 			utils.LightServFlag,
@@ -76,6 +79,12 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.SaveSnapStartFlg,
 			utils.SaveSnapPeriodFlg,
 			utils.SnapModeFlg,
+			utils.SnapshotDirFlag,
+			utils.EventRecordFlag,
+			utils.RewardLedgerFlag,
+			utils.TxHistoryFlag,
+			utils.StratumFlag,
+			utils.StratumDifficultyFlag,
 			utils.GetGenesisFlag,
 			utils.LessDiskEnabledFlag,
 			utils.DbTableSizeFlag,
@@ -112,10 +121,10 @@ var AppHelpFlagGroups = []flagGroup{
 		Name: "TRANSACTION POOL",
 		Flags: []cli.Flag{
 			utils.TxPoolNoLocalsFlag,
-			//utils.TxPoolJournalFlag,//Y
-			//utils.TxPoolRejournalFlag,
+			utils.TxPoolJournalFlag,
+			utils.TxPoolRejournalFlag,
 			utils.TxPoolPriceLimitFlag,
-			//utils.TxPoolPriceBumpFlag,//Y
+			utils.TxPoolPriceBumpFlag,
 			utils.TxPoolAccountSlotsFlag,
 			utils.TxPoolGlobalSlotsFlag,
 			utils.TxPoolAccountQueueFlag,
@@ -170,6 +179,11 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.ListenPortFlag,
 			utils.MaxPeersFlag,
 			utils.MaxPendingPeersFlag,
+			utils.TxRelayPortFlag,
+			utils.TxRelayRateLimitFlag,
+			utils.ReputationBanThresholdFlag,
+			utils.ReputationBanDurationFlag,
+			utils.ReputationDecayFlag,
 			utils.NATFlag,
 			utils.NoDiscoverFlag,
 			utils.DiscoveryV5Flag,
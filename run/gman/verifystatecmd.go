@@ -0,0 +1,154 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/matrixstate"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/run/utils"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	verifyStateSourceFlag = cli.StringFlag{
+		Name:  "source",
+		Usage: "Chaindata directory to re-fetch missing and corrupt state entries from",
+	}
+
+	verifyStateCommand = cli.Command{
+		Action:    utils.MigrateFlags(verifyState),
+		Name:      "verify-state",
+		Usage:     "Verify that the state of a block is complete on disk",
+		ArgsUsage: "<blockHash> | <blockNum>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.CacheFlag,
+			utils.LightModeFlag,
+			verifyStateSourceFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+Walks the state of the block for every currency: the shard roots of the
+currency, the trie of every shard with the storage tries and the code of its
+accounts, and the matrix state. Every entry has to be on disk and hash to its
+reference in the block header. Missing and corrupt entries are reported per
+currency and shard, the command fails if any is found.
+
+With --source the entries are re-fetched from the chaindata of another node,
+using the state sync scheduler, and the state is verified again.`,
+	}
+)
+
+func verifyState(ctx *cli.Context) error {
+	arg := ctx.Args().First()
+	if len(arg) == 0 {
+		utils.Fatalf("Must supply block hash or number")
+	}
+	stack := makeFullNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer chainDb.Close()
+
+	var block *types.Block
+	if hashish(arg) {
+		block = chain.GetBlockByHash(common.HexToHash(arg))
+	} else {
+		num, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			utils.Fatalf("Invalid block number %s: %v", arg, err)
+		}
+		block = chain.GetBlockByNumber(num)
+	}
+	if block == nil {
+		utils.Fatalf("Block %s not found", arg)
+	}
+	fmt.Printf("Verifying state of block %d %s\n", block.NumberU64(), block.Hash().Hex())
+
+	reports := state.VerifyState(chainDb, block.Root(), block.Sharding())
+	if source := ctx.String(verifyStateSourceFlag.Name); source != "" && stateFailed(reports) {
+		db, err := mandb.NewLDBDatabase(source, ctx.GlobalInt(utils.CacheFlag.Name), 256, 2)
+		if err != nil {
+			utils.Fatalf("Could not open source database: %v", err)
+		}
+		defer db.Close()
+
+		// Entries below a repaired root list or corrupt node are only found
+		// by verifying again, repeat while there is progress.
+		for stateFailed(reports) {
+			written, err := state.RepairState(chainDb, db, reports)
+			fmt.Printf("Re-fetched %d state entries from %s\n", written, source)
+			if err != nil {
+				printStateReports(reports)
+				utils.Fatalf("Failed to re-fetch state: %v", err)
+			}
+			if written == 0 {
+				break
+			}
+			reports = state.VerifyState(chainDb, block.Root(), block.Sharding())
+		}
+	}
+	printStateReports(reports)
+	if stateFailed(reports) {
+		utils.Fatalf("State of block %d is incomplete", block.NumberU64())
+	}
+
+	// The matrix state is only read once its trie is known to be complete.
+	st, err := chain.StateAt(block.Root())
+	if err != nil {
+		utils.Fatalf("Could not open state: %v", err)
+	}
+	failed, err := matrixstate.CheckKeys(st)
+	if err != nil {
+		utils.Fatalf("Could not read matrix state: %v", err)
+	}
+	for _, key := range matrixstate.GetManager(matrixstate.GetVersionInfo(st)).Keys() {
+		if err, ok := failed[key]; ok {
+			fmt.Printf("  matrix state %s: %v\n", key, err)
+		}
+	}
+	if len(failed) != 0 {
+		utils.Fatalf("Matrix state of block %d has %d undecodable keys", block.NumberU64(), len(failed))
+	}
+	fmt.Printf("State of block %d verified\n", block.NumberU64())
+	return nil
+}
+
+func stateFailed(reports []*state.CoinReport) bool {
+	for _, report := range reports {
+		if report.Failed() {
+			return true
+		}
+	}
+	return false
+}
+
+// printStateReports prints a summary line per currency and the failed
+// entries per shard.
+func printStateReports(reports []*state.CoinReport) {
+	for _, report := range reports {
+		var nodes, accounts, values, failed int
+		for _, shard := range report.Shards {
+			nodes += shard.Nodes
+			accounts += shard.Accounts
+			values += shard.Values
+			failed += len(shard.Errors)
+		}
+		fmt.Printf("%-12s root %s, %d shards, %d nodes, %d accounts, %d values, %d failed\n",
+			report.Coin, report.Root.Hex(), len(report.Shards), nodes, accounts, values, failed+len(report.Errors))
+		for _, err := range report.Errors {
+			fmt.Printf("  %v\n", err)
+		}
+		for _, shard := range report.Shards {
+			for _, err := range shard.Errors {
+				fmt.Printf("  shard %3d: %v\n", shard.Range, err)
+			}
+		}
+	}
+}
@@ -42,6 +42,7 @@ import (
 	"github.com/MatrixAINetwork/go-matrix/mandb"
 	"github.com/MatrixAINetwork/go-matrix/manstats"
 	"github.com/MatrixAINetwork/go-matrix/metrics"
+	"github.com/MatrixAINetwork/go-matrix/miner"
 	"github.com/MatrixAINetwork/go-matrix/p2p"
 	"github.com/MatrixAINetwork/go-matrix/p2p/discover"
 	"github.com/MatrixAINetwork/go-matrix/p2p/nat"
@@ -119,6 +120,10 @@ var (
 		Name:  "nousb",
 		Usage: "Disables monitoring for and managing USB hardware wallets",
 	}
+	SignerFlag = cli.StringFlag{
+		Name:  "signer",
+		Usage: "IPC path or HTTP URL of a remote signer for consensus signing (default = local keystore)",
+	}
 	NetworkIdFlag = cli.Uint64Flag{
 		Name:  "networkid",
 		Usage: "Network identifier (integer, 1=Frontier, 2=Morden (disused), 3=Ropsten, 4=Rinkeby)",
@@ -172,6 +177,15 @@ var (
 		Usage: `Blockchain garbage collection mode ("full", "archive")`,
 		Value: "archive",
 	}
+	PruneStateFlag = cli.Uint64Flag{
+		Name:  "prunestate.recent",
+		Usage: "Prune the state while running, keeping the states of this many latest blocks (0 = disabled, needs --gcmode full)",
+	}
+	PruneStateIntervalFlag = cli.Uint64Flag{
+		Name:  "prunestate.interval",
+		Usage: "Number of blocks between two online state prunes",
+		Value: man.DefaultConfig.PruneStateInterval,
+	}
 	DbTableSizeFlag = cli.IntFlag{
 		Name:  "dbsize",
 		Usage: "db store size ",
@@ -254,26 +268,26 @@ var (
 		Name:  "txpool.nolocals",
 		Usage: "Disables price exemptions for locally submitted transactions",
 	}
-	//TxPoolJournalFlag = cli.StringFlag{ //Y
-	//	Name:  "txpool.journal",
-	//	Usage: "Disk journal for local transaction to survive node restarts",
-	//	Value: core.DefaultTxPoolConfig.Journal,
-	//}
-	//TxPoolRejournalFlag = cli.DurationFlag{
-	//	Name:  "txpool.rejournal",
-	//	Usage: "Time interval to regenerate the local transaction journal",
-	//	Value: core.DefaultTxPoolConfig.Rejournal,
-	//}
+	TxPoolJournalFlag = cli.StringFlag{
+		Name:  "txpool.journal",
+		Usage: "Disk journal for local transaction to survive node restarts",
+		Value: man.DefaultConfig.TxPool.Journal,
+	}
+	TxPoolRejournalFlag = cli.DurationFlag{
+		Name:  "txpool.rejournal",
+		Usage: "Time interval to regenerate the local transaction journal",
+		Value: man.DefaultConfig.TxPool.Rejournal,
+	}
 	TxPoolPriceLimitFlag = cli.Uint64Flag{
 		Name:  "txpool.pricelimit",
 		Usage: "Minimum gas price limit to enforce for acceptance into the pool",
 		Value: man.DefaultConfig.TxPool.PriceLimit,
 	}
-	//TxPoolPriceBumpFlag = cli.Uint64Flag{ //Y
-	//	Name:  "txpool.pricebump",
-	//	Usage: "Price bump percentage to replace an already existing transaction",
-	//	Value: eth.DefaultConfig.TxPool.PriceBump,
-	//}
+	TxPoolPriceBumpFlag = cli.Uint64Flag{
+		Name:  "txpool.pricebump",
+		Usage: "Price bump percentage to replace an already existing transaction",
+		Value: man.DefaultConfig.TxPool.PriceBump,
+	}
 	TxPoolAccountSlotsFlag = cli.Uint64Flag{
 		Name:  "txpool.accountslots",
 		Usage: "Minimum number of executable transaction slots guaranteed per account",
@@ -406,7 +420,7 @@ var (
 	}
 	MetricsEnabledFlag = cli.BoolFlag{
 		Name:  metrics.MetricsEnabledFlag,
-		Usage: "Enable metrics collection and reporting",
+		Usage: "Enable metrics collection and reporting, served in Prometheus format on /metrics of the HTTP-RPC server",
 	}
 	FakePoWFlag = cli.BoolFlag{
 		Name:  "fakepow",
@@ -503,6 +517,31 @@ var (
 		Usage: "Network listening port",
 		Value: 50505,
 	}
+	TxRelayPortFlag = cli.IntFlag{
+		Name:  "txrelay.port",
+		Usage: "UDP port of the transaction relay to the validators, the same on all nodes of the network",
+		Value: p2p.DefaultTxRelayPort,
+	}
+	TxRelayRateLimitFlag = cli.IntFlag{
+		Name:  "txrelay.ratelimit",
+		Usage: "Transaction relay packets per second accepted from a source address or node",
+		Value: p2p.DefaultTxRelayRateLimit,
+	}
+	ReputationBanThresholdFlag = cli.IntFlag{
+		Name:  "reputation.banthreshold",
+		Usage: "Misbehaviour score at which a peer is banned",
+		Value: p2p.DefaultReputationConfig.BanThreshold,
+	}
+	ReputationBanDurationFlag = cli.DurationFlag{
+		Name:  "reputation.banduration",
+		Usage: "Duration of the bans of peers reaching the score threshold",
+		Value: p2p.DefaultReputationConfig.BanDuration,
+	}
+	ReputationDecayFlag = cli.IntFlag{
+		Name:  "reputation.decay",
+		Usage: "Misbehaviour score forgiven per hour (negative = never)",
+		Value: p2p.DefaultReputationConfig.DecayPerHour,
+	}
 	BootnodesFlag = cli.StringFlag{
 		Name:  "bootnodes",
 		Usage: "Comma separated enode URLs for P2P discovery bootstrap (set v4+v5 instead for light servers)",
@@ -605,6 +644,32 @@ var (
 		Usage: "load snap withName and start matrix with it",
 		Value: man.SnapLoadFile,
 	}
+	SnapshotDirFlag = DirectoryFlag{
+		Name:  "snapdir",
+		Usage: "Directory for saved and loaded snapshots (default = inside the datadir)",
+		Value: DirectoryString{man.DefaultConfig.SnapshotDir},
+	}
+	EventRecordFlag = cli.StringFlag{
+		Name:  "eventrecord",
+		Usage: "File to record the published consensus events to for offline replay (default = disabled)",
+	}
+	RewardLedgerFlag = cli.BoolFlag{
+		Name:  "rewardledger",
+		Usage: "Index the rewards and slashes of all accounts for the reward API (blocks imported without processing, as by fast sync, have no slashes)",
+	}
+	TxHistoryFlag = cli.BoolFlag{
+		Name:  "txhistory",
+		Usage: "Index the transactions and transfers of all accounts for the txhistory API (gas payers and scheduled transfers need --gcmode=archive)",
+	}
+	StratumFlag = cli.StringFlag{
+		Name:  "stratum",
+		Usage: "Stratum mining server listening address, e.g. :8008 (default = disabled)",
+	}
+	StratumDifficultyFlag = cli.Uint64Flag{
+		Name:  "stratum.difficulty",
+		Usage: "Initial and minimum x11 share difficulty of the stratum sessions",
+		Value: miner.DefaultStratumDifficulty,
+	}
 
 	BLockMemberName = cli.StringSliceFlag{
 		Name:  "blockmembername",
@@ -1045,6 +1110,21 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 	if ctx.GlobalIsSet(MaxPendingPeersFlag.Name) {
 		cfg.MaxPendingPeers = ctx.GlobalInt(MaxPendingPeersFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxRelayPortFlag.Name) {
+		cfg.TxRelayPort = ctx.GlobalInt(TxRelayPortFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxRelayRateLimitFlag.Name) {
+		cfg.TxRelayRateLimit = ctx.GlobalInt(TxRelayRateLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(ReputationBanThresholdFlag.Name) {
+		cfg.Reputation.BanThreshold = ctx.GlobalInt(ReputationBanThresholdFlag.Name)
+	}
+	if ctx.GlobalIsSet(ReputationBanDurationFlag.Name) {
+		cfg.Reputation.BanDuration = ctx.GlobalDuration(ReputationBanDurationFlag.Name)
+	}
+	if ctx.GlobalIsSet(ReputationDecayFlag.Name) {
+		cfg.Reputation.DecayPerHour = ctx.GlobalInt(ReputationDecayFlag.Name)
+	}
 	if ctx.GlobalIsSet(NetworkIdFlag.Name) {
 		cfg.NetWorkId = ctx.GlobalUint64(NetworkIdFlag.Name)
 	}
@@ -1113,6 +1193,9 @@ func SetNodeConfig(ctx *cli.Context, cfg *pod.Config) {
 	if ctx.GlobalIsSet(NoUSBFlag.Name) {
 		cfg.NoUSB = ctx.GlobalBool(NoUSBFlag.Name)
 	}
+	if ctx.GlobalIsSet(SignerFlag.Name) {
+		cfg.Signer = ctx.GlobalString(SignerFlag.Name)
+	}
 	if ctx.GlobalIsSet(LessDiskEnabledFlag.Name) {
 		cfg.LessDisk = ctx.GlobalBool(LessDiskEnabledFlag.Name)
 	} else {
@@ -1145,18 +1228,18 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	//if ctx.GlobalIsSet(TxPoolNoLocalsFlag.Name) { //Y
 	//	cfg.NoLocals = ctx.GlobalBool(TxPoolNoLocalsFlag.Name)
 	//}
-	//if ctx.GlobalIsSet(TxPoolJournalFlag.Name) {
-	//	cfg.Journal = ctx.GlobalString(TxPoolJournalFlag.Name)
-	//}
-	//if ctx.GlobalIsSet(TxPoolRejournalFlag.Name) {
-	//	cfg.Rejournal = ctx.GlobalDuration(TxPoolRejournalFlag.Name)
-	//}
+	if ctx.GlobalIsSet(TxPoolJournalFlag.Name) {
+		cfg.Journal = ctx.GlobalString(TxPoolJournalFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolRejournalFlag.Name) {
+		cfg.Rejournal = ctx.GlobalDuration(TxPoolRejournalFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolPriceLimitFlag.Name) {
 		cfg.PriceLimit = ctx.GlobalUint64(TxPoolPriceLimitFlag.Name)
 	}
-	//if ctx.GlobalIsSet(TxPoolPriceBumpFlag.Name) {//Y
-	//	cfg.PriceBump = ctx.GlobalUint64(TxPoolPriceBumpFlag.Name)
-	//}
+	if ctx.GlobalIsSet(TxPoolPriceBumpFlag.Name) {
+		cfg.PriceBump = ctx.GlobalUint64(TxPoolPriceBumpFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolAccountSlotsFlag.Name) {
 		cfg.AccountSlots = ctx.GlobalUint64(TxPoolAccountSlotsFlag.Name)
 	}
@@ -1277,6 +1360,15 @@ func SetManConfig(ctx *cli.Context, stack *pod.Node, cfg *man.Config) {
 		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
 	}
 	cfg.NoPruning = ctx.GlobalString(GCModeFlag.Name) == "archive"
+	if ctx.GlobalIsSet(PruneStateFlag.Name) {
+		if cfg.NoPruning {
+			Fatalf("--%s needs --%s full", PruneStateFlag.Name, GCModeFlag.Name)
+		}
+		cfg.PruneStateRecent = ctx.GlobalUint64(PruneStateFlag.Name)
+	}
+	if ctx.GlobalIsSet(PruneStateIntervalFlag.Name) {
+		cfg.PruneStateInterval = ctx.GlobalUint64(PruneStateIntervalFlag.Name)
+	}
 
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cfg.TrieCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
@@ -1287,6 +1379,24 @@ func SetManConfig(ctx *cli.Context, stack *pod.Node, cfg *man.Config) {
 	if ctx.GlobalIsSet(DocRootFlag.Name) {
 		cfg.DocRoot = ctx.GlobalString(DocRootFlag.Name)
 	}
+	if ctx.GlobalIsSet(SnapshotDirFlag.Name) {
+		cfg.SnapshotDir = ctx.GlobalString(SnapshotDirFlag.Name)
+	}
+	if ctx.GlobalIsSet(EventRecordFlag.Name) {
+		cfg.EventRecord = ctx.GlobalString(EventRecordFlag.Name)
+	}
+	if ctx.GlobalIsSet(RewardLedgerFlag.Name) {
+		cfg.RewardLedger = ctx.GlobalBool(RewardLedgerFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxHistoryFlag.Name) {
+		cfg.TxHistory = ctx.GlobalBool(TxHistoryFlag.Name)
+	}
+	if ctx.GlobalIsSet(StratumFlag.Name) {
+		cfg.StratumAddr = ctx.GlobalString(StratumFlag.Name)
+	}
+	if ctx.GlobalIsSet(StratumDifficultyFlag.Name) {
+		cfg.StratumDifficulty = ctx.GlobalUint64(StratumDifficultyFlag.Name)
+	}
 	if ctx.GlobalIsSet(ExtraDataFlag.Name) {
 		cfg.ExtraData = []byte(ctx.GlobalString(ExtraDataFlag.Name))
 	}
@@ -0,0 +1,53 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package simulation
+
+import (
+	"fmt"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+)
+
+// Height returns the number of the lowest head of the nodes.
+func Height(nodes ...*Node) uint64 {
+	var height uint64
+	for i, node := range nodes {
+		if number := node.Head().Number.Uint64(); i == 0 || number < height {
+			height = number
+		}
+	}
+	return height
+}
+
+// CheckAgreement verifies that the nodes reached the given height and hold
+// the same canonical block at it.
+func CheckAgreement(number uint64, nodes ...*Node) error {
+	var want common.Hash
+	for i, node := range nodes {
+		if head := node.Head().Number.Uint64(); head < number {
+			return fmt.Errorf("node %s is behind: have %d, want %d", node.Config.Name, head, number)
+		}
+		hash := node.chain.GetHeaderByNumber(number).Hash()
+		if i == 0 {
+			want = hash
+			continue
+		}
+		if hash != want {
+			return fmt.Errorf("node %s diverged at %d: have %s, want %s", node.Config.Name, number, hash.Hex(), want.Hex())
+		}
+	}
+	return nil
+}
+
+// Leaders returns the leaders of the blocks of the node's chain from the given
+// height up to its head.
+func (n *Node) Leaders(from uint64) []common.Address {
+	var leaders []common.Address
+	for number := from; number <= n.Head().Number.Uint64(); number++ {
+		if header := n.chain.GetHeaderByNumber(number); header != nil {
+			leaders = append(leaders, header.Leader)
+		}
+	}
+	return leaders
+}
@@ -0,0 +1,296 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package simulation
+
+import (
+	"bytes"
+	"container/heap"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common/mclock"
+	"github.com/MatrixAINetwork/go-matrix/log"
+)
+
+const (
+	settleRounds  = 3                      // Consecutive idle checks before the time advances
+	settlePause   = 200 * time.Microsecond // Real time between two idle checks
+	settleTimeout = 10 * time.Second       // Real time after which the time advances anyway
+)
+
+// Clock is the virtual time of a simulation, the services of the nodes take
+// their wall time and timers from it. Time only advances when the simulation
+// steps to the next scheduled action, and only once every goroutine of the
+// process is blocked, so the services handled everything due so far. A
+// scenario spanning minutes runs in seconds.
+type Clock struct {
+	mu    sync.Mutex
+	start time.Time
+	now   time.Duration
+	seq   uint64
+	queue actionQueue
+	busy  int
+	stack []byte
+}
+
+type action struct {
+	at    time.Duration
+	seq   uint64 // keeps the schedule order of actions due at the same time
+	index int    // position in the queue, -1 once run or cancelled
+	fn    func()
+}
+
+type actionQueue []*action
+
+func (q actionQueue) Len() int { return len(q) }
+func (q actionQueue) Less(i, j int) bool {
+	if q[i].at != q[j].at {
+		return q[i].at < q[j].at
+	}
+	return q[i].seq < q[j].seq
+}
+func (q actionQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+func (q *actionQueue) Push(x interface{}) {
+	item := x.(*action)
+	item.index = len(*q)
+	*q = append(*q, item)
+}
+func (q *actionQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	item.index = -1
+	*q = old[:n-1]
+	return item
+}
+
+// NewClock creates a clock whose wall time starts at start.
+func NewClock(start time.Time) *Clock {
+	return &Clock{start: start}
+}
+
+// Now returns the virtual wall time.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.start.Add(c.now)
+}
+
+// Elapsed returns the virtual time passed since the start.
+func (c *Clock) Elapsed() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Sleep blocks until the virtual time advanced by d.
+func (c *Clock) Sleep(d time.Duration) {
+	<-c.NewTimer(d).C()
+}
+
+// NewTimer creates a timer firing once the virtual time advanced by d.
+func (c *Clock) NewTimer(d time.Duration) mclock.Timer {
+	t := &timer{clock: c, c: make(chan time.Time, 1)}
+	c.mu.Lock()
+	t.act = c.schedule(d, t.fire)
+	c.mu.Unlock()
+	return t
+}
+
+// AfterFunc schedules fn to run once the virtual time advanced by d.
+func (c *Clock) AfterFunc(d time.Duration, fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.schedule(d, fn)
+}
+
+func (c *Clock) schedule(d time.Duration, fn func()) *action {
+	if d < 0 {
+		d = 0
+	}
+	c.seq++
+	act := &action{at: c.now + d, seq: c.seq, fn: fn}
+	heap.Push(&c.queue, act)
+	return act
+}
+
+func (c *Clock) cancel(act *action) bool {
+	if act == nil || act.index < 0 {
+		return false
+	}
+	heap.Remove(&c.queue, act.index)
+	return true
+}
+
+// Pending returns the number of scheduled actions.
+func (c *Clock) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.queue)
+}
+
+// Step waits for the process to settle, then advances the clock to the next
+// scheduled action and runs it. It returns false if nothing is scheduled.
+func (c *Clock) Step() bool {
+	c.settle()
+	c.mu.Lock()
+	if len(c.queue) == 0 {
+		c.mu.Unlock()
+		return false
+	}
+	next := heap.Pop(&c.queue).(*action)
+	c.now = next.at
+	c.mu.Unlock()
+
+	next.fn()
+	return true
+}
+
+// RunFor runs all actions due within d and leaves the clock at now+d.
+func (c *Clock) RunFor(d time.Duration) {
+	c.mu.Lock()
+	deadline := c.now + d
+	c.mu.Unlock()
+	c.RunUntil(func() bool { return false }, deadline)
+}
+
+// RunUntil runs the scheduled actions until done returns true or the elapsed
+// time reaches the deadline. It reports whether done returned true.
+func (c *Clock) RunUntil(done func() bool, deadline time.Duration) bool {
+	for {
+		c.settle()
+		if done() {
+			return true
+		}
+		c.mu.Lock()
+		if len(c.queue) == 0 || c.queue[0].at > deadline {
+			if c.now < deadline {
+				c.now = deadline
+			}
+			c.mu.Unlock()
+			return false
+		}
+		next := heap.Pop(&c.queue).(*action)
+		c.now = next.at
+		c.mu.Unlock()
+
+		next.fn()
+	}
+}
+
+// RunUntilIdle runs the scheduled actions, including the ones they schedule,
+// until nothing is left. It returns the number of actions run.
+func (c *Clock) RunUntilIdle() int {
+	count := 0
+	for c.Step() {
+		count++
+	}
+	return count
+}
+
+// hold keeps the time from advancing until release is called, for work which
+// waits on real time, like a chain sync.
+func (c *Clock) hold() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.busy++
+}
+
+func (c *Clock) release() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.busy--
+}
+
+// settle waits until nothing holds the clock and all goroutines but the
+// caller are blocked for a few checks in a row.
+func (c *Clock) settle() {
+	deadline := time.Now().Add(settleTimeout)
+	for idle := 0; idle < settleRounds; {
+		c.mu.Lock()
+		busy := c.busy > 0
+		c.mu.Unlock()
+		if busy || !c.quiescent() {
+			idle = 0
+		} else {
+			idle++
+		}
+		if time.Now().After(deadline) {
+			log.Warn("simulation", "process not settling, advancing the clock", c.Elapsed())
+			return
+		}
+		time.Sleep(settlePause)
+	}
+}
+
+// quiescent reports whether all goroutines except the caller are blocked.
+// Goroutines sleeping in real time or waiting in a system call, like the
+// keystore watcher, count as blocked, the services only sleep on the virtual
+// clock.
+func (c *Clock) quiescent() bool {
+	if c.stack == nil {
+		c.stack = make([]byte, 1<<20)
+	}
+	n := runtime.Stack(c.stack, true)
+	for n == len(c.stack) {
+		c.stack = make([]byte, 2*len(c.stack))
+		n = runtime.Stack(c.stack, true)
+	}
+	// The first goroutine of the dump is the caller
+	first := true
+	for _, line := range bytes.Split(c.stack[:n], []byte("\n")) {
+		if !bytes.HasPrefix(line, []byte("goroutine ")) {
+			continue
+		}
+		if first {
+			first = false
+			continue
+		}
+		start := bytes.IndexByte(line, '[')
+		end := bytes.IndexAny(line, ",]")
+		if start < 0 || end < start {
+			continue
+		}
+		switch string(line[start+1 : end]) {
+		case "running", "runnable":
+			return false
+		}
+	}
+	return true
+}
+
+// timer is a timer of the virtual clock.
+type timer struct {
+	clock *Clock
+	c     chan time.Time
+	act   *action
+}
+
+func (t *timer) fire() {
+	select {
+	case t.c <- t.clock.Now():
+	default:
+	}
+}
+
+func (t *timer) C() <-chan time.Time { return t.c }
+
+func (t *timer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.cancel(t.act)
+}
+
+func (t *timer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.clock.cancel(t.act)
+	t.act = t.clock.schedule(d, t.fire)
+	return active
+}
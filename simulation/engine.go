@@ -0,0 +1,56 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package simulation
+
+import (
+	"github.com/MatrixAINetwork/go-matrix/consensus"
+	"github.com/MatrixAINetwork/go-matrix/consensus/amhash"
+	"github.com/MatrixAINetwork/go-matrix/consensus/mtxdpos"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+)
+
+// engine is the AIMine consensus engine without the proof of work checks. The
+// simulated miners answer the mining requests without searching for seals,
+// every other rule of the headers is checked.
+type engine struct {
+	*amhash.Amhash
+}
+
+func (e engine) VerifyHeader(chain consensus.ChainReader, header *types.Header, seal bool, ai bool) error {
+	return e.Amhash.VerifyHeader(chain, header, false, false)
+}
+
+func (e engine) VerifyHeaders(chain consensus.ChainReader, headers []*types.Header, seals []bool, ais []bool) (chan<- struct{}, <-chan error) {
+	unchecked := make([]bool, len(headers))
+	return e.Amhash.VerifyHeaders(chain, headers, unchecked, unchecked)
+}
+
+func (e engine) VerifySeal(chain consensus.ChainReader, header *types.Header) error {
+	return nil
+}
+
+func (e engine) VerifyAISeal(chain consensus.ChainReader, header *types.Header) error {
+	return nil
+}
+
+func (e engine) VerifyBasePow(chain consensus.ChainReader, header *types.Header, basePower types.BasePowers) error {
+	return nil
+}
+
+// newEngines makes the engines of a node's chain, keyed by the versions like
+// man.CreateConsensusEngineMap does.
+func newEngines(simpleMode bool) (map[string]consensus.Engine, map[string]consensus.DPOSEngine) {
+	pow := amhash.New(amhash.Config{PowMode: amhash.ModeNormal})
+	pow.SetThreads(-1)
+	dpos := mtxdpos.NewMtxDPOS(simpleMode)
+
+	engines := make(map[string]consensus.Engine)
+	dposEngines := make(map[string]consensus.DPOSEngine)
+	for _, version := range manversion.VersionList {
+		engines[string(version)] = engine{pow}
+		dposEngines[string(version)] = dpos
+	}
+	return engines, dposEngines
+}
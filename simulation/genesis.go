@@ -0,0 +1,104 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package simulation
+
+import (
+	"crypto/ecdsa"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+)
+
+// depositContract is the address of the deposit contract, its storage holds the
+// deposits of the elected nodes.
+var depositContract = common.BytesToAddress([]byte{10})
+
+var (
+	validatorDeposit = new(big.Int).Mul(big.NewInt(100000), big.NewInt(1e18))
+	minerDeposit     = new(big.Int).Mul(big.NewInt(10000), big.NewInt(1e18))
+)
+
+// newGenesis makes the genesis of the simulated chain. It is the default
+// genesis at the AIMine version, the first one leaderelect2.0 and blkgenor2.0
+// run from, electing the validators and miners and deposited for them. The
+// super key signs the version and is the only super account of the chain.
+func newGenesis(validators, miners, broadcasts []common.Address, super *ecdsa.PrivateKey) (*core.Genesis, error) {
+	g, err := core.DefaultGenesis("")
+	if err != nil {
+		return nil, err
+	}
+	g.Version = manversion.VersionAIMine
+	sig, err := crypto.SignWithValidate(common.BytesToHash([]byte(g.Version)).Bytes(), true, super)
+	if err != nil {
+		return nil, err
+	}
+	g.VersionSignatures = []common.Signature{common.BytesToSignature(sig)}
+	if len(validators) > 0 {
+		g.Leader = validators[len(validators)-1]
+	}
+
+	superAccount := core.GenesisAddress(crypto.PubkeyToAddress(super.PublicKey))
+	g.MState.Foundation = &superAccount
+	for _, accounts := range []**[]core.GenesisAddress{
+		&g.MState.VersionSuperAccounts,
+		&g.MState.BlockSuperAccounts,
+		&g.MState.MultiCoinSuperAccounts,
+		&g.MState.SubChainSuperAccounts,
+	} {
+		*accounts = &[]core.GenesisAddress{superAccount}
+	}
+	genesisBroadcasts := make([]core.GenesisAddress, 0, len(broadcasts))
+	for _, addr := range broadcasts {
+		genesisBroadcasts = append(genesisBroadcasts, core.GenesisAddress(addr))
+	}
+	g.MState.Broadcasts = &genesisBroadcasts
+	g.MState.InnerMiners = &[]core.GenesisAddress{}
+	g.MState.EleInfoCfg.ValidatorNum = uint16(len(validators))
+	g.MState.EleInfoCfg.BackValidator = 0
+	g.MState.ElectMinerNumCfg.MinerNum = uint16(len(miners))
+
+	storage := make(map[common.Hash]common.Hash)
+	elect := make([]core.GenesisElect, 0, len(validators)+len(miners))
+	g.NetTopology = common.NetTopology{Type: common.NetTopoTypeAll}
+	groups := []struct {
+		nodes   []common.Address
+		role    common.ElectRoleType
+		deposit *big.Int
+		depRole int64
+	}{
+		{validators, common.ElectRoleValidator, validatorDeposit, int64(common.RoleValidator)},
+		{miners, common.ElectRoleMiner, minerDeposit, int64(common.RoleMiner)},
+	}
+	for _, group := range groups {
+		for i, addr := range group.nodes {
+			elect = append(elect, core.GenesisElect{Account: core.GenesisAddress(addr), Stock: 1, Type: group.role})
+			g.NetTopology.NetTopologyData = append(g.NetTopology.NetTopologyData, common.NetTopologyData{
+				Account:  addr,
+				Position: common.GeneratePosition(uint16(i), group.role),
+			})
+			setDeposit(storage, addr, group.deposit, group.depRole)
+		}
+	}
+	g.MState.CurElect = &elect
+	g.Alloc = core.GenesisAlloc{depositContract: {Balance: new(big.Int), Storage: storage}}
+	return g, nil
+}
+
+// setDeposit stores a deposit of the first version of the deposit contract,
+// the nodes sign with their deposit accounts.
+func setDeposit(storage map[common.Hash]common.Hash, addr common.Address, amount *big.Int, role int64) {
+	num := storage[common.BytesToHash(append(depositContract[:], 'D', 'N', 'U', 'M'))].Big()
+	index := make([]byte, 8)
+	binary.BigEndian.PutUint64(index, num.Uint64())
+	storage[common.BytesToHash(append(append(depositContract[:], 'D', 'I'), index...))] = addr.Hash()
+	storage[common.BytesToHash(append(depositContract[:], 'D', 'N', 'U', 'M'))] = common.BigToHash(num.Add(num, big.NewInt(1)))
+	storage[common.BytesToHash(append(addr[:], 'D'))] = common.BigToHash(amount)
+	storage[common.BytesToHash(append(addr[:], 'R'))] = common.BigToHash(big.NewInt(role))
+	storage[common.BytesToHash(append(addr[:], 'N', 'X'))] = addr.Hash()
+	storage[common.BytesToHash(append(addr[:], 'N', 'Y'))] = addr.Hash()
+}
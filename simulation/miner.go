@@ -0,0 +1,61 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package simulation
+
+import (
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/params"
+)
+
+// mine answers a mining request after the mining time of the simulation, with
+// the results the miner of a real node sends for it. The engines of the chains
+// don't check the seals, so the results carry no proof of work.
+func (n *Node) mine(req *mc.HD_V2_MiningReqMsg) {
+	header := req.Header
+	if header == nil || header.Difficulty == nil || header.Difficulty.Sign() == 0 {
+		return
+	}
+	// A request of a parent the miner doesn't have yet is answered on one of
+	// its resends, once the parent arrived
+	bcInterval, err := n.chain.GetBroadcastIntervalByHash(header.ParentHash)
+	if err != nil {
+		log.Trace("simulation", "mining request of unknown parent", header.Number, "node", n.Config.Name)
+		return
+	}
+	mineHash := header.HashNoSignsAndNonce()
+	n.mu.Lock()
+	mined := n.mined[mineHash]
+	n.mined[mineHash] = true
+	n.mu.Unlock()
+	if mined {
+		return
+	}
+	number := header.Number.Uint64()
+	aiNumber := params.GetNextAIBlockNumber(number, bcInterval.GetBroadcastInterval())
+
+	n.sim.Clock.AfterFunc(n.sim.config.MiningTime, func() {
+		n.Send(mc.HD_V2_PowMiningRsp, &mc.HD_V2_PowMiningRspMsg{
+			Number:     number + params.PowBlockPeriod - 1,
+			BlockHash:  mineHash,
+			Difficulty: header.Difficulty,
+			Nonce:      types.EncodeNonce(number),
+			Coinbase:   n.Address,
+			MixDigest:  mineHash,
+			Sm3Nonce:   types.EncodeNonce(number),
+		}, common.RoleValidator|common.RoleBroadcast, nil)
+		if bcInterval.IsReElectionNumber(aiNumber - 1) {
+			return
+		}
+		n.Send(mc.HD_V2_AIMiningRsp, &mc.HD_V2_AIMiningRspMsg{
+			Number:     aiNumber,
+			BlockHash:  mineHash,
+			AIHash:     crypto.Keccak256Hash(mineHash[:], n.Address[:]),
+			AICoinbase: n.Address,
+		}, common.RoleValidator|common.RoleBroadcast, nil)
+	})
+}
@@ -0,0 +1,222 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package simulation
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/msgsend"
+)
+
+var errUnknownNode = errors.New("unknown node")
+
+// NetworkConfig describes the behaviour of the simulated message transport.
+type NetworkConfig struct {
+	Latency time.Duration // Base delay of every message
+	Jitter  time.Duration // Random extra delay, up to this value
+	Loss    float64       // Probability a message is dropped, 0 to 1
+	Seed    int64         // Seed of the latency and loss randomness
+}
+
+// NetworkStats counts the messages carried by the network.
+type NetworkStats struct {
+	Sent        int // Messages handed to the network
+	Delivered   int // Messages decoded and published at the receiver
+	Lost        int // Messages dropped by the configured loss
+	Partitioned int // Messages dropped between partitions
+	Rejected    int // Messages the receiver failed to decode
+}
+
+type link struct {
+	from, to common.Address
+}
+
+// Network carries the algorithm messages and the block announcements between
+// the nodes of a simulation, delivering them on the virtual clock. The group
+// messages go to the nodes of the roles they were created with.
+type Network struct {
+	mu        sync.Mutex
+	config    NetworkConfig
+	clock     *Clock
+	rand      *rand.Rand
+	nodes     map[common.Address]*Node
+	order     []common.Address
+	partition map[common.Address]int
+	latency   map[link]time.Duration
+	stats     NetworkStats
+}
+
+// NewNetwork creates an empty network running on the given clock.
+func NewNetwork(clock *Clock, config NetworkConfig) *Network {
+	return &Network{
+		config:  config,
+		clock:   clock,
+		rand:    rand.New(rand.NewSource(config.Seed)),
+		nodes:   make(map[common.Address]*Node),
+		latency: make(map[link]time.Duration),
+	}
+}
+
+func (n *Network) join(node *Node) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.nodes[node.Address] = node
+	n.order = append(n.order, node.Address)
+}
+
+// Partition splits the network into the given groups. Messages between nodes
+// of different groups are dropped, nodes not listed form a group of their own.
+func (n *Network) Partition(groups ...[]common.Address) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.partition = make(map[common.Address]int)
+	for i, group := range groups {
+		for _, addr := range group {
+			n.partition[addr] = i + 1
+		}
+	}
+}
+
+// Heal removes all partitions.
+func (n *Network) Heal() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.partition = nil
+}
+
+// SetLinkLatency overrides the base latency of the messages sent from one node
+// to another.
+func (n *Network) SetLinkLatency(from, to common.Address, latency time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.latency[link{from, to}] = latency
+}
+
+// SetLoss changes the probability of a message to be dropped.
+func (n *Network) SetLoss(loss float64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.config.Loss = loss
+}
+
+// Stats returns the message counters of the network.
+func (n *Network) Stats() NetworkStats {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.stats
+}
+
+// route decides the fate of a message from one node to another. It returns
+// the delay of the delivery and false if the message is dropped, counting it
+// in the stats. The caller holds the lock.
+func (n *Network) route(from, to common.Address, code uint32) (time.Duration, bool) {
+	n.stats.Sent++
+	if n.partition != nil && n.partition[from] != n.partition[to] {
+		n.stats.Partitioned++
+		log.Trace("simulation", "partitioned message", code, "from", from.Hex(), "to", to.Hex())
+		return 0, false
+	}
+	if n.config.Loss > 0 && n.rand.Float64() < n.config.Loss {
+		n.stats.Lost++
+		log.Trace("simulation", "lost message", code, "from", from.Hex(), "to", to.Hex())
+		return 0, false
+	}
+	delay, ok := n.latency[link{from, to}]
+	if !ok {
+		delay = n.config.Latency
+	}
+	if n.config.Jitter > 0 {
+		delay += time.Duration(n.rand.Int63n(int64(n.config.Jitter)))
+	}
+	return delay, true
+}
+
+// send schedules the delivery of a message, if the network lets it through.
+func (n *Network) send(from, to common.Address, data msgsend.NetData) error {
+	n.mu.Lock()
+	receiver, ok := n.nodes[to]
+	if !ok {
+		n.mu.Unlock()
+		return errUnknownNode
+	}
+	delay, ok := n.route(from, to, data.SubCode)
+	n.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	msg := &msgsend.AlgorithmMsg{Account: from, Data: data}
+	n.clock.AfterFunc(delay, func() {
+		err := receiver.receive(msg)
+		n.mu.Lock()
+		if err != nil {
+			n.stats.Rejected++
+		} else {
+			n.stats.Delivered++
+		}
+		n.mu.Unlock()
+	})
+	return nil
+}
+
+// announce tells all other nodes about a new head of a node. The announcements
+// share the latency, loss and partitions of the messages but are not counted.
+func (n *Network) announce(from *Node, hash common.Hash, number uint64) {
+	type delivery struct {
+		to    *Node
+		delay time.Duration
+	}
+	n.mu.Lock()
+	stats := n.stats
+	deliveries := make([]delivery, 0, len(n.order))
+	for _, addr := range n.order {
+		if addr == from.Address {
+			continue
+		}
+		if delay, ok := n.route(from.Address, addr, 0); ok {
+			deliveries = append(deliveries, delivery{n.nodes[addr], delay})
+		}
+	}
+	n.stats = stats
+	n.mu.Unlock()
+
+	for _, d := range deliveries {
+		to := d.to
+		n.clock.AfterFunc(d.delay, func() { to.announced(from, hash, number) })
+	}
+}
+
+// sendToGroup sends a message to all other nodes holding one of the roles.
+func (n *Network) sendToGroup(from common.Address, roles common.RoleType, data msgsend.NetData) {
+	n.mu.Lock()
+	targets := make([]common.Address, 0, len(n.order))
+	for _, addr := range n.order {
+		if addr != from && n.nodes[addr].Role&roles != 0 {
+			targets = append(targets, addr)
+		}
+	}
+	n.mu.Unlock()
+	for _, to := range targets {
+		n.send(from, to, data)
+	}
+}
+
+// transport is the msgsend transport of one node.
+type transport struct {
+	network *Network
+	self    common.Address
+}
+
+func (t *transport) SendToGroup(roles common.RoleType, data msgsend.NetData) {
+	t.network.sendToGroup(t.self, roles, data)
+}
+
+func (t *transport) SendToSingle(addr common.Address, data msgsend.NetData) error {
+	return t.network.send(t.self, addr, data)
+}
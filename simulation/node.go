@@ -0,0 +1,303 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package simulation
+
+import (
+	"crypto/ecdsa"
+	"sync"
+
+	"github.com/MatrixAINetwork/go-matrix/accounts"
+	"github.com/MatrixAINetwork/go-matrix/accounts/signhelper"
+	"github.com/MatrixAINetwork/go-matrix/baseinterface"
+	"github.com/MatrixAINetwork/go-matrix/blkgenor2.0"
+	"github.com/MatrixAINetwork/go-matrix/blkverify"
+	"github.com/MatrixAINetwork/go-matrix/ca"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/common/mclock"
+	"github.com/MatrixAINetwork/go-matrix/consensus"
+	"github.com/MatrixAINetwork/go-matrix/consensus/blkmanage"
+	"github.com/MatrixAINetwork/go-matrix/core"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/core/vm"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/event"
+	"github.com/MatrixAINetwork/go-matrix/leaderelect2.0"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/man/downloader"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/msgsend"
+	"github.com/MatrixAINetwork/go-matrix/olconsensus"
+	"github.com/MatrixAINetwork/go-matrix/p2p/discover"
+	"github.com/MatrixAINetwork/go-matrix/p2p/simulations/adapters"
+	"github.com/MatrixAINetwork/go-matrix/params/manversion"
+	"github.com/MatrixAINetwork/go-matrix/reelection"
+)
+
+// Handler reacts to a message received by a node. Handlers run on the virtual
+// clock, so the messages they send are timed from the moment of reception.
+type Handler func(node *Node, msg interface{})
+
+// Node is one virtual validator, miner or broadcaster. It holds its own chain,
+// ca service, event center and message dispatcher and runs the consensus
+// services on them, assembled like man.New does for a real node. The node is
+// the backend of its services.
+type Node struct {
+	Address common.Address
+	Role    common.RoleType
+	Config  *adapters.NodeConfig
+
+	sim         *Simulation
+	db          mandb.Database
+	chain       *core.BlockChain
+	engines     map[string]consensus.Engine
+	dposEngines map[string]consensus.DPOSEngine
+	center      *mc.Center
+	hd          *msgsend.HD
+	ca          *ca.Service
+	signHelper  *signhelper.SignHelper
+	txPool      *core.TxPoolManager
+	eventMux    *event.TypeMux
+	random      *baseinterface.Random
+	reelection  *reelection.ReElection
+	olConsensus *olconsensus.TopNodeService
+	blkManage   *blkmanage.ManBlkManage
+	leader      *leaderelect2.LeaderIdentity
+	blockGen    *blkgenorV2.BlockGenor
+	blockVerify *blkverify.BlockVerify
+	downloader  *downloader.Downloader
+
+	headCh  chan core.ChainHeadEvent
+	headSub event.Subscription
+	syncMu  sync.Mutex
+	started bool
+
+	mu       sync.Mutex
+	handlers map[mc.EventCode][]Handler
+	received map[mc.EventCode][]interface{}
+	mined    map[common.Hash]bool
+}
+
+// newNode creates the chain of a node on the genesis and the message
+// dispatcher. The services are created by initServices, once all chains exist.
+func newNode(sim *Simulation, key *ecdsa.PrivateKey, name string, role common.RoleType) (*Node, error) {
+	node := &Node{
+		Address:  crypto.PubkeyToAddress(key.PublicKey),
+		Role:     role,
+		Config:   &adapters.NodeConfig{ID: discover.PubkeyID(&key.PublicKey), PrivateKey: key, Name: name},
+		sim:      sim,
+		db:       mandb.NewMemDatabase(),
+		center:   mc.NewOrderedCenter(),
+		eventMux: new(event.TypeMux),
+		handlers: make(map[mc.EventCode][]Handler),
+		received: make(map[mc.EventCode][]interface{}),
+		mined:    make(map[common.Hash]bool),
+	}
+	sim.genesis.MustCommit(node.db)
+	node.engines, node.dposEngines = newEngines(sim.genesis.Config.SimpleMode)
+
+	var err error
+	node.chain, err = core.NewBlockChain(node.db, &core.CacheConfig{TrieNodeLimit: 256}, sim.genesis.Config, vm.Config{}, node.engines, node.dposEngines)
+	if err != nil {
+		node.center.Stop()
+		return nil, err
+	}
+	node.ca = ca.NewService(node.Address, node.center)
+	node.chain.SetCA(node.ca)
+	node.chain.SetMsgCenter(node.center)
+
+	node.hd, err = msgsend.NewHDWithTransport(node.center, &transport{network: sim.Network, self: node.Address})
+	if err != nil {
+		node.chain.Stop()
+		node.center.Stop()
+		return nil, err
+	}
+	return node, nil
+}
+
+// initServices creates the services of the node. The ca service is started
+// last by start, so the services see the first role update.
+func (n *Node) initServices(am *accounts.Manager) (err error) {
+	n.signHelper = signhelper.NewSignHelper()
+	n.signHelper.SetCA(n.ca)
+	if err = n.signHelper.SetAccountManager(am); err != nil {
+		return err
+	}
+	n.signHelper.SetAuthReader(n.chain)
+	n.signHelper.SetSlashingProtection(signhelper.NewSlashingProtection(mandb.NewMemDatabase()))
+	n.ca.SetTopologyReader(n.chain.GetTopologyStore())
+
+	n.txPool = core.NewTxPoolManager(core.TxPoolConfig{}, n.chain.Config(), n.chain, "")
+
+	if n.random, err = baseinterface.NewRandom(n.chain); err != nil {
+		return err
+	}
+	for _, version := range []string{manversion.VersionAlpha, manversion.VersionBeta, manversion.VersionDelta, manversion.VersionAIMine, manversion.VersionZeta} {
+		n.chain.Processor([]byte(version)).SetRandom(n.random)
+	}
+	n.olConsensus = olconsensus.NewTopNodeService(n.chain)
+	topNodeInstance := olconsensus.NewTopNodeInstance(n.signHelper, n.hd, n.center, n.ca)
+	n.olConsensus.SetValidatorReader(n.chain)
+	n.olConsensus.SetStateReaderInterface(n.chain.GetTopologyStore())
+	n.olConsensus.SetTopNodeStateInterface(topNodeInstance)
+	n.olConsensus.SetValidatorAccountInterface(topNodeInstance)
+	n.olConsensus.SetMessageSendInterface(topNodeInstance)
+	n.olConsensus.SetMessageCenterInterface(topNodeInstance)
+	if err = n.olConsensus.Start(); err != nil {
+		return err
+	}
+	if n.reelection, err = reelection.New(n.chain, n.random, n.olConsensus); err != nil {
+		return err
+	}
+	n.reelection.SetCA(n.ca)
+	n.chain.RegisterMatrixStateDataProducer(mc.MSKeyElectGraph, n.reelection.ProduceElectGraphData)
+	n.chain.RegisterMatrixStateDataProducer(mc.MSKeyElectOnlineState, n.reelection.ProduceElectOnlineStateData)
+	n.chain.RegisterMatrixStateDataProducer(mc.MSKeyPreBroadcastRoot, n.reelection.ProducePreBroadcastStateData)
+	n.chain.RegisterMatrixStateDataProducer(mc.MSKeyMinHash, n.reelection.ProduceMinHashData)
+	n.chain.RegisterMatrixStateDataProducer(mc.MSKeyBroadcastTx, core.ProduceMatrixStateData)
+
+	if n.leader, err = leaderelect2.NewLeaderIdentityService(n, "leader服务V2"); err != nil {
+		return err
+	}
+	if n.blkManage, err = blkmanage.New(n); err != nil {
+		return err
+	}
+	if n.blockGen, err = blkgenorV2.New(n); err != nil {
+		return err
+	}
+	if n.blockVerify, err = blkverify.NewBlockVerify(n); err != nil {
+		return err
+	}
+	n.downloader = downloader.New(downloader.FullSync, n.db, n.eventMux, n.chain, nil, n.dropPeer, nil)
+
+	n.headCh = make(chan core.ChainHeadEvent, 16)
+	n.headSub = n.chain.SubscribeChainHeadEvent(n.headCh)
+	return nil
+}
+
+// start runs the ca service, which publishes the role of the node at the
+// current block and from then on follows the chain.
+func (n *Node) start() {
+	n.started = true
+	go n.ca.Start()
+	go n.announceLoop()
+}
+
+// Handle registers a scripted reaction to the messages of the given code, on
+// top of the services of the node.
+func (n *Node) Handle(code mc.EventCode, handler Handler) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.handlers[code] = append(n.handlers[code], handler)
+}
+
+// Send sends a message to the nodes, or to all nodes holding one of the roles
+// if nodes is nil.
+func (n *Node) Send(code mc.EventCode, msg interface{}, roles common.RoleType, nodes []common.Address) {
+	n.hd.SendNodeMsg(code, msg, roles, nodes)
+}
+
+// Received returns the decoded messages of the given code received so far.
+func (n *Node) Received(code mc.EventCode) []interface{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]interface{}(nil), n.received[code]...)
+}
+
+// receive hands a message arriving from the network to the services of the
+// node and the scripted handlers.
+func (n *Node) receive(msg *msgsend.AlgorithmMsg) error {
+	code, data, err := n.hd.Decode(msg)
+	if err != nil {
+		return err
+	}
+	n.mu.Lock()
+	n.received[code] = append(n.received[code], data)
+	handlers := append([]Handler(nil), n.handlers[code]...)
+	n.mu.Unlock()
+
+	if code == mc.HD_V2_MiningReq && n.Role == common.RoleMiner {
+		n.mine(data.(*mc.HD_V2_MiningReqMsg))
+	}
+	for _, handler := range handlers {
+		handler(n, data)
+	}
+	return n.center.PublishEvent(code, data)
+}
+
+// Head returns the head of the chain of the node.
+func (n *Node) Head() *types.Header {
+	return n.chain.CurrentHeader()
+}
+
+// Chain returns the chain of the node.
+func (n *Node) Chain() *core.BlockChain {
+	return n.chain
+}
+
+func (n *Node) stop() {
+	if n.headSub != nil {
+		n.headSub.Unsubscribe()
+	}
+	if n.downloader != nil {
+		n.downloader.Terminate()
+	}
+	if n.blockGen != nil {
+		n.blockGen.Close()
+	}
+	if n.blockVerify != nil {
+		n.blockVerify.Close()
+	}
+	if n.olConsensus != nil {
+		n.olConsensus.Close()
+	}
+	if n.random != nil {
+		n.random.Stop()
+	}
+	if n.txPool != nil {
+		n.txPool.Stop()
+	}
+	if n.started {
+		n.ca.Stop()
+	}
+	n.chain.Stop()
+	n.hd.Close()
+	n.center.Stop()
+}
+
+// The methods below make the node the backend of its services.
+
+func (n *Node) BlockChain() *core.BlockChain             { return n.chain }
+func (n *Node) TxPool() *core.TxPoolManager              { return n.txPool }
+func (n *Node) EventMux() *event.TypeMux                 { return n.eventMux }
+func (n *Node) SignHelper() *signhelper.SignHelper       { return n.signHelper }
+func (n *Node) HD() *msgsend.HD                          { return n.hd }
+func (n *Node) ReElection() *reelection.ReElection       { return n.reelection }
+func (n *Node) OLConsensus() *olconsensus.TopNodeService { return n.olConsensus }
+func (n *Node) Random() *baseinterface.Random            { return n.random }
+func (n *Node) ManBlkDeal() *blkmanage.ManBlkManage      { return n.blkManage }
+func (n *Node) MsgCenter() *mc.Center                    { return n.center }
+func (n *Node) CA() *ca.Service                          { return n.ca }
+func (n *Node) Clock() mclock.Clock                      { return n.sim.Clock }
+func (n *Node) ChainDb() mandb.Database                  { return n.db }
+
+func (n *Node) Engine(version string) consensus.Engine {
+	if engine, ok := n.engines[version]; ok {
+		return engine
+	}
+	return n.engines[manversion.VersionAlpha]
+}
+
+func (n *Node) DPOSEngine(version string) consensus.DPOSEngine {
+	if engine, ok := n.dposEngines[version]; ok {
+		return engine
+	}
+	return n.dposEngines[manversion.VersionAlpha]
+}
+
+// FetcherNotify only logs like the backend of a real node, the blocks reach
+// the nodes by the announcements of the chain heads.
+func (n *Node) FetcherNotify(hash common.Hash, number uint64, addr common.Address) {
+	log.Trace("simulation", "fetcher notify", number, "hash", hash.TerminalString(), "node", n.Config.Name, "from", addr.Hex())
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package simulation
+
+import (
+	"context"
+	"errors"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+	"github.com/MatrixAINetwork/go-matrix/rpc"
+)
+
+var errUnknownBlock = errors.New("block unknown to all nodes")
+
+// chainReaders serves the readers the services only take process wide, the
+// broadcast interval and the deposit backend. It reads from the chain of the
+// first node knowing the block, the nodes share one genesis and the blocks of
+// a hash are the same on all of them.
+type chainReaders struct {
+	sim *Simulation
+}
+
+func (r chainReaders) chains() []*core.BlockChain {
+	nodes := r.sim.Nodes()
+	chains := make([]*core.BlockChain, 0, len(nodes))
+	for _, node := range nodes {
+		chains = append(chains, node.chain)
+	}
+	return chains
+}
+
+func (r chainReaders) GetBroadcastInterval() (*mc.BCIntervalInfo, error) {
+	var best *core.BlockChain
+	for _, chain := range r.chains() {
+		if best == nil || chain.CurrentBlock().NumberU64() > best.CurrentBlock().NumberU64() {
+			best = chain
+		}
+	}
+	if best == nil {
+		return nil, errUnknownBlock
+	}
+	return best.GetBroadcastInterval()
+}
+
+func (r chainReaders) GetBroadcastIntervalByHash(hash common.Hash) (*mc.BCIntervalInfo, error) {
+	for _, chain := range r.chains() {
+		if chain.GetHeaderByHash(hash) != nil {
+			return chain.GetBroadcastIntervalByHash(hash)
+		}
+	}
+	return nil, errUnknownBlock
+}
+
+func (r chainReaders) GetBroadcastIntervalByNumber(number uint64) (*mc.BCIntervalInfo, error) {
+	for _, chain := range r.chains() {
+		if chain.GetHeaderByNumber(number) != nil {
+			return chain.GetBroadcastIntervalByNumber(number)
+		}
+	}
+	return nil, errUnknownBlock
+}
+
+func (r chainReaders) StateAndHeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDBManage, *types.Header, error) {
+	for _, chain := range r.chains() {
+		header := chain.CurrentHeader()
+		if blockNr != rpc.LatestBlockNumber && blockNr != rpc.PendingBlockNumber {
+			header = chain.GetHeaderByNumber(uint64(blockNr))
+		}
+		if header != nil {
+			st, err := chain.StateAt(header.Roots)
+			return st, header, err
+		}
+	}
+	return nil, nil, errUnknownBlock
+}
+
+func (r chainReaders) StateAndHeaderByHash(ctx context.Context, hash common.Hash) (*state.StateDBManage, *types.Header, error) {
+	for _, chain := range r.chains() {
+		if header := chain.GetHeaderByHash(hash); header != nil {
+			st, err := chain.StateAt(header.Roots)
+			return st, header, err
+		}
+	}
+	return nil, nil, errUnknownBlock
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package simulation
+
+import (
+	"fmt"
+	"time"
+)
+
+// Step is one scripted action of a scenario, run at a virtual time relative to
+// the start of the scenario.
+type Step struct {
+	At   time.Duration
+	Name string
+	Run  func(sim *Simulation) error
+}
+
+// Scenario is a script of steps run for a virtual duration, followed by a
+// check of the outcome.
+type Scenario struct {
+	Name     string
+	Steps    []Step
+	Duration time.Duration
+	Check    func(sim *Simulation) error
+}
+
+// Run schedules the steps of the scenario, runs the clock for the duration of
+// the scenario and checks the outcome. The first failing step aborts the
+// scenario.
+func (s *Simulation) Run(scenario Scenario) error {
+	var failed error
+	for _, step := range scenario.Steps {
+		step := step
+		s.Clock.AfterFunc(step.At, func() {
+			if failed != nil {
+				return
+			}
+			if err := step.Run(s); err != nil {
+				failed = fmt.Errorf("scenario %q step %q at %v: %v", scenario.Name, step.Name, s.Clock.Elapsed(), err)
+			}
+		})
+	}
+	s.Clock.RunUntil(func() bool { return failed != nil }, s.Clock.Elapsed()+scenario.Duration)
+	if failed != nil {
+		return failed
+	}
+	if scenario.Check != nil {
+		if err := scenario.Check(s); err != nil {
+			return fmt.Errorf("scenario %q check: %v", scenario.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+// Package simulation runs a network of virtual validators, miners and
+// broadcasters inside one process. Every node has its own chain, ca service,
+// event center and message dispatcher and runs the real leaderelect2.0,
+// blkverify and blkgenor2.0 services with the online consensus and the
+// reelection on them. The services exchange their messages through the
+// msgsend codecs over a simulated transport with latency, loss and
+// partitions, timed by a virtual clock, so a scenario spanning many blocks
+// runs in seconds and its outcome is read from the chains the nodes build.
+//
+// The nodes propagate their blocks by announcing their chain heads, a node
+// missing an announced block syncs it with the downloader from a fake peer
+// serving the chain of the announcer. The simulated miners answer the mining
+// requests without a proof of work, the engines of the chains don't check the
+// seals. The node identities are p2p/simulations adapter configs, the
+// adapters themselves can't run the nodes: every pod node starts the one p2p
+// server of the process.
+//
+// Some state is still process wide, which limits the scenarios:
+//   - the deposits and broadcast interval are read through the readers of
+//     the simulation, which serve the chain of any node;
+//   - the transaction pools follow the role updates of the default event
+//     center, so the nodes have no pools and the chains can't pass the first
+//     broadcast block;
+//   - the random services follow the default event center as well and never
+//     prepare, which rules out the reelection heights;
+//   - the p2p online states of the nodes are empty and the entrusted
+//     passwords are shared by all nodes.
+//
+// Only one simulation can run in a process at a time.
+package simulation
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/accounts"
+	"github.com/MatrixAINetwork/go-matrix/accounts/keystore"
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/depoistInfo"
+	"github.com/MatrixAINetwork/go-matrix/params/enstrust"
+	"github.com/MatrixAINetwork/go-matrix/params/manparams"
+
+	// The plugs of the random and election services, like gman registers them
+	_ "github.com/MatrixAINetwork/go-matrix/crypto/vrf"
+	_ "github.com/MatrixAINetwork/go-matrix/election/layered"
+	_ "github.com/MatrixAINetwork/go-matrix/election/layeredbss"
+	_ "github.com/MatrixAINetwork/go-matrix/election/layereddp"
+	_ "github.com/MatrixAINetwork/go-matrix/election/layereddp2.0"
+	_ "github.com/MatrixAINetwork/go-matrix/election/layeredmep"
+	_ "github.com/MatrixAINetwork/go-matrix/election/nochoice"
+	_ "github.com/MatrixAINetwork/go-matrix/election/stock"
+	_ "github.com/MatrixAINetwork/go-matrix/random/electionseed"
+	_ "github.com/MatrixAINetwork/go-matrix/random/ereryblockseed"
+	_ "github.com/MatrixAINetwork/go-matrix/random/everybroadcastseed"
+)
+
+// keyPassword protects the keys of the nodes in the keystore of the simulation.
+const keyPassword = "simulation"
+
+// Config describes the nodes of a simulation.
+type Config struct {
+	Validators int
+	Miners     int
+	Broadcasts int
+	Network    NetworkConfig
+	MiningTime time.Duration // Virtual time a miner takes to answer a request
+}
+
+// Simulation is a set of virtual nodes sharing one genesis, network and clock.
+type Simulation struct {
+	Clock   *Clock
+	Network *Network
+
+	Validators []*Node
+	Miners     []*Node
+	Broadcasts []*Node
+	nodes      []*Node
+
+	config   Config
+	genesis  *core.Genesis
+	keyDir   string
+	keyStore *keystore.KeyStore
+}
+
+// New creates the nodes of the simulation and starts their services. The keys
+// of the nodes are derived deterministically, so the same config always yields
+// the same network.
+func New(config Config) (*Simulation, error) {
+	if config.MiningTime == 0 {
+		config.MiningTime = time.Second
+	}
+	keys := make([]*ecdsa.PrivateKey, config.Broadcasts+config.Validators+config.Miners)
+	addrs := make([]common.Address, len(keys))
+	for i := range keys {
+		key, err := crypto.ToECDSA(crypto.Keccak256([]byte(fmt.Sprintf("simulation node %d", i))))
+		if err != nil {
+			return nil, err
+		}
+		keys[i], addrs[i] = key, crypto.PubkeyToAddress(key.PublicKey)
+	}
+	super, err := crypto.ToECDSA(crypto.Keccak256([]byte("simulation super account")))
+	if err != nil {
+		return nil, err
+	}
+	broadcasts := addrs[:config.Broadcasts]
+	validators := addrs[config.Broadcasts : config.Broadcasts+config.Validators]
+	miners := addrs[config.Broadcasts+config.Validators:]
+	genesis, err := newGenesis(validators, miners, broadcasts, super)
+	if err != nil {
+		return nil, err
+	}
+
+	clock := NewClock(time.Unix(int64(genesis.Timestamp), 0))
+	sim := &Simulation{
+		Clock:   clock,
+		Network: NewNetwork(clock, config.Network),
+		config:  config,
+		genesis: genesis,
+	}
+	if sim.keyDir, err = ioutil.TempDir("", "simulation-keystore"); err != nil {
+		return nil, err
+	}
+	sim.keyStore = keystore.NewKeyStore(sim.keyDir, keystore.LightScryptN, keystore.LightScryptP)
+
+	groups := []struct {
+		keys  []*ecdsa.PrivateKey
+		name  string
+		role  common.RoleType
+		nodes *[]*Node
+	}{
+		{keys[:config.Broadcasts], "broadcast", common.RoleBroadcast, &sim.Broadcasts},
+		{keys[config.Broadcasts : config.Broadcasts+config.Validators], "validator", common.RoleValidator, &sim.Validators},
+		{keys[config.Broadcasts+config.Validators:], "miner", common.RoleMiner, &sim.Miners},
+	}
+	for _, group := range groups {
+		for i, key := range group.keys {
+			node, err := sim.addNode(key, fmt.Sprintf("%s-%d", group.name, i), group.role)
+			if err != nil {
+				sim.Stop()
+				return nil, err
+			}
+			*group.nodes = append(*group.nodes, node)
+		}
+	}
+	if err := sim.startNodes(); err != nil {
+		sim.Stop()
+		return nil, err
+	}
+	return sim, nil
+}
+
+func (s *Simulation) addNode(key *ecdsa.PrivateKey, name string, role common.RoleType) (*Node, error) {
+	if _, err := s.keyStore.ImportECDSA(key, keyPassword); err != nil {
+		return nil, err
+	}
+	node, err := newNode(s, key, name, role)
+	if err != nil {
+		return nil, err
+	}
+	s.Network.join(node)
+	s.nodes = append(s.nodes, node)
+	return node, nil
+}
+
+// startNodes creates and starts the services of the nodes. Every chain set
+// itself as the reader of the broadcast interval when it was created, the
+// readers of the simulation replace it and serve the deposits too.
+func (s *Simulation) startNodes() error {
+	readers := chainReaders{s}
+	manparams.SetStateReader(readers)
+	depoistInfo.NewDepositInfo(readers)
+
+	am := accounts.NewManager(s.keyStore)
+	passwords := make(map[common.Address]string)
+	for _, node := range s.nodes {
+		if err := node.initServices(am); err != nil {
+			return err
+		}
+		passwords[node.Address] = keyPassword
+	}
+	if err := entrust.EntrustAccountValue.SetEntrustValue(passwords); err != nil {
+		return err
+	}
+	for _, node := range s.nodes {
+		node.start()
+	}
+	return nil
+}
+
+// Nodes returns all nodes of the simulation.
+func (s *Simulation) Nodes() []*Node {
+	return append([]*Node(nil), s.nodes...)
+}
+
+// Node returns the node with the given address, nil if there is none.
+func (s *Simulation) Node(addr common.Address) *Node {
+	for _, node := range s.nodes {
+		if node.Address == addr {
+			return node
+		}
+	}
+	return nil
+}
+
+// Addresses returns the addresses of the nodes.
+func Addresses(nodes []*Node) []common.Address {
+	addrs := make([]common.Address, 0, len(nodes))
+	for _, node := range nodes {
+		addrs = append(addrs, node.Address)
+	}
+	return addrs
+}
+
+// Stop stops the services of the nodes and removes the keystore.
+func (s *Simulation) Stop() {
+	for _, node := range s.nodes {
+		node.stop()
+	}
+	if s.keyDir != "" {
+		os.RemoveAll(s.keyDir)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package simulation
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/mc"
+)
+
+func newTestSimulation(t *testing.T, config Config) *Simulation {
+	sim, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to create simulation: %v", err)
+	}
+	return sim
+}
+
+// TestChainGrowth runs the services of the nodes on a healthy network and
+// checks that they build one chain, the validators leading in turn.
+func TestChainGrowth(t *testing.T) {
+	sim := newTestSimulation(t, Config{Validators: 4, Miners: 2, Broadcasts: 1, Network: NetworkConfig{Latency: 50 * time.Millisecond, Jitter: 20 * time.Millisecond}})
+	defer sim.Stop()
+
+	err := sim.Run(Scenario{
+		Name:     "chain growth",
+		Duration: 90 * time.Second,
+		Check: func(sim *Simulation) error {
+			if err := CheckAgreement(6, sim.Nodes()...); err != nil {
+				return err
+			}
+			leaders := sim.Validators[0].Leaders(1)
+			for i, leader := range leaders[:len(sim.Validators)] {
+				if sim.Node(leader) == nil || sim.Node(leader).Role != common.RoleValidator {
+					return fmt.Errorf("block %d led by %s, not a validator", i+1, leader.Hex())
+				}
+				for _, other := range leaders[:i] {
+					if other == leader {
+						return fmt.Errorf("block %d led by %s again before all validators led", i+1, leader.Hex())
+					}
+				}
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats := sim.Network.Stats(); stats.Rejected != 0 || stats.Lost != 0 {
+		t.Errorf("messages failed on a perfect network: %+v", stats)
+	}
+}
+
+// TestSilentValidator cuts a validator off the network from the start. The
+// others skip its turns by reelecting the leader and keep building the chain,
+// which the validator syncs once the network heals.
+func TestSilentValidator(t *testing.T) {
+	// Up to 7 validators all have to sign a block, 8 tolerate a silent one
+	sim := newTestSimulation(t, Config{Validators: 8, Miners: 1, Broadcasts: 1, Network: NetworkConfig{Latency: 50 * time.Millisecond}})
+	defer sim.Stop()
+
+	silent := sim.Validators[1]
+	var others []*Node
+	for _, node := range sim.Nodes() {
+		if node != silent {
+			others = append(others, node)
+		}
+	}
+	sim.Network.Partition(Addresses(others), []common.Address{silent.Address})
+	if !sim.Clock.RunUntil(func() bool { return Height(others...) >= 10 }, 10*time.Minute) {
+		t.Fatalf("chain stalled without the silent validator: height %d after %v", Height(others...), sim.Clock.Elapsed())
+	}
+	if err := CheckAgreement(10, others...); err != nil {
+		t.Fatal(err)
+	}
+	if head := silent.Head().Number.Uint64(); head != 0 {
+		t.Errorf("silent validator received blocks: head %d", head)
+	}
+	for i, leader := range others[0].Leaders(1) {
+		if leader == silent.Address {
+			t.Errorf("block %d led by the silent validator", i+1)
+		}
+	}
+	inquiries := 0
+	for _, node := range sim.Validators {
+		inquiries += len(node.Received(mc.HD_V2_LeaderReelectInquiryReq))
+	}
+	if inquiries == 0 {
+		t.Error("no leader reelection inquired")
+	}
+
+	sim.Network.Heal()
+	if !sim.Clock.RunUntil(func() bool { return Height(sim.Nodes()...) >= 12 }, sim.Clock.Elapsed()+5*time.Minute) {
+		t.Fatalf("silent validator didn't catch up: head %d", silent.Head().Number)
+	}
+	if err := CheckAgreement(12, sim.Nodes()...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClock(t *testing.T) {
+	start := time.Unix(1545000000, 0)
+	clock := NewClock(start)
+	var order []int
+	clock.AfterFunc(2*time.Second, func() { order = append(order, 2) })
+	clock.AfterFunc(time.Second, func() {
+		order = append(order, 1)
+		clock.AfterFunc(time.Second, func() { order = append(order, 3) })
+	})
+	timer := clock.NewTimer(1200 * time.Millisecond)
+	clock.RunFor(1500 * time.Millisecond)
+	if len(order) != 1 || clock.Elapsed() != 1500*time.Millisecond {
+		t.Fatalf("run for: have %v at %v", order, clock.Elapsed())
+	}
+	select {
+	case now := <-timer.C():
+		if !now.Equal(start.Add(1200 * time.Millisecond)) {
+			t.Errorf("timer fired at %v", now)
+		}
+	default:
+		t.Error("timer didn't fire")
+	}
+	stopped := clock.NewTimer(time.Second)
+	if !stopped.Stop() {
+		t.Error("pending timer not stopped")
+	}
+	clock.RunUntilIdle()
+	if len(order) != 3 || order[1] != 2 || order[2] != 3 || clock.Elapsed() != 2*time.Second {
+		t.Fatalf("run until idle: have %v at %v", order, clock.Elapsed())
+	}
+	if !clock.Now().Equal(start.Add(2 * time.Second)) {
+		t.Errorf("wall time mismatch: have %v", clock.Now())
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package simulation
+
+import (
+	"time"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core"
+	"github.com/MatrixAINetwork/go-matrix/log"
+	"github.com/MatrixAINetwork/go-matrix/man/downloader"
+)
+
+// announceLoop announces the new heads of the chain of the node to the other
+// nodes, like the protocol manager of a real node propagates its blocks.
+func (n *Node) announceLoop() {
+	for {
+		select {
+		case ev := <-n.headCh:
+			n.sim.Network.announce(n, ev.Block.Hash(), ev.Block.NumberU64())
+		case <-n.headSub.Err():
+			return
+		}
+	}
+}
+
+// announced handles the announcement of a head by a peer. A head the node
+// doesn't have is synced from the peer. The clock is held until the sync is
+// done, it runs on real time.
+func (n *Node) announced(peer *Node, hash common.Hash, number uint64) {
+	if n.chain.HasBlock(hash, number) {
+		return
+	}
+	n.sim.Clock.hold()
+	go func() {
+		defer n.sim.Clock.release()
+		n.syncFrom(peer)
+	}()
+}
+
+// syncFrom synchronises the chain of the node with the chain of the peer
+// through the downloader, serving it from the database of the peer with a
+// fake peer like the copydb command does.
+func (n *Node) syncFrom(peer *Node) {
+	n.syncMu.Lock()
+	defer n.syncMu.Unlock()
+
+	hc, err := core.NewHeaderChain(peer.db, peer.chain.Config(), func() bool { return false })
+	if err != nil {
+		log.Error("simulation", "open peer chain failed", err, "peer", peer.Config.Name)
+		return
+	}
+	head := hc.CurrentHeader()
+	if n.chain.HasBlock(head.Hash(), head.Number.Uint64()) {
+		return
+	}
+	td := hc.GetTd(head.Hash(), head.Number.Uint64())
+	local := n.chain.CurrentBlock()
+	if td.Cmp(n.chain.GetTd(local.Hash(), local.NumberU64())) <= 0 {
+		return
+	}
+	sbs, err := n.chain.GetSuperBlockNum()
+	if err != nil {
+		log.Error("simulation", "read super block number failed", err, "node", n.Config.Name)
+		return
+	}
+
+	id := peer.Config.Name
+	if err := n.downloader.RegisterPeer(id, 63, downloader.NewFakePeer(id, peer.db, hc, n.downloader)); err != nil {
+		log.Error("simulation", "register peer failed", err, "node", n.Config.Name, "peer", id)
+		return
+	}
+	defer n.downloader.UnregisterPeer(id, 0)
+	if err := n.downloader.Synchronise(id, head.Hash(), td, head.SuperBlockSeq(), sbs, downloader.FullSync); err != nil {
+		log.Warn("simulation", "sync failed", err, "node", n.Config.Name, "peer", id)
+		return
+	}
+	for n.downloader.Synchronising() {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// dropPeer is called by the downloader on a peer delivering an invalid chain.
+func (n *Node) dropPeer(id string, flg int) {
+	log.Warn("simulation", "dropping peer", id, "node", n.Config.Name)
+	n.downloader.UnregisterPeer(id, flg)
+}
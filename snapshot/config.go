@@ -6,18 +6,22 @@ package snapshot
 import (
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/MatrixAINetwork/go-matrix/common"
 )
 
-const SNAPDIR = "./snapdir"
+// DefaultDir is the default snapshot directory, relative to the node's
+// instance directory.
+const DefaultDir = "snapdir"
 
-func init() {
-	_, e := os.Stat(SNAPDIR)
-	if e != nil {
-		os.Mkdir(SNAPDIR, os.ModePerm)
-	}
+// filePrefix is the prefix of the file names of snapshots.
+const filePrefix = "TrieData"
+
+// FileName returns the file name of the snapshot taken at the given block.
+func FileName(number uint64) string {
+	return filePrefix + strconv.FormatUint(number, 10)
 }
 
 type Config struct {
@@ -0,0 +1,64 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package snapshot
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+)
+
+// File is a snapshot file of a snapshot directory.
+type File struct {
+	Path    string
+	Number  uint64      // Block the snapshot was taken at, if Known
+	Hash    common.Hash // Hash of the block, only for chunked snapshots
+	Chunked bool
+	Known   bool // Whether the block of the snapshot is known
+}
+
+// ListDir returns the snapshot files of a directory ordered by block number,
+// the ones of unknown blocks last. The block of a chunked snapshot is read
+// from its header, the one of a legacy snapshot from its FileName. Unfinished
+// snapshots are skipped.
+func ListDir(dir string) ([]File, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	files := make([]File, 0, len(infos))
+	for _, info := range infos {
+		if info.IsDir() || strings.HasSuffix(info.Name(), ".tmp") {
+			continue
+		}
+		file := File{Path: filepath.Join(dir, info.Name())}
+		if f, err := os.Open(file.Path); err == nil {
+			if sr, err := NewReader(f); err == nil {
+				file.Number, file.Hash, file.Chunked, file.Known = sr.Header.Number, sr.Header.Hash, true, true
+			}
+			f.Close()
+		}
+		if !file.Chunked && strings.HasPrefix(info.Name(), filePrefix) {
+			if number, err := strconv.ParseUint(strings.TrimPrefix(info.Name(), filePrefix), 10, 64); err == nil {
+				file.Number, file.Known = number, true
+			}
+		}
+		files = append(files, file)
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].Known != files[j].Known {
+			return files[i].Known
+		}
+		return files[i].Number < files[j].Number
+	})
+	return files, nil
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package snapshot
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+)
+
+func TestListDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapdir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name string, data []byte) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("manual", writeTestSnapshot(t))
+	write(FileName(20), []byte("legacy"))
+	write(FileName(5)+".tmp", []byte("unfinished"))
+	write("notes", []byte("other"))
+
+	files, err := ListDir(dir)
+	if err != nil {
+		t.Fatalf("failed to list dir: %v", err)
+	}
+	want := []File{
+		{Path: filepath.Join(dir, "manual"), Number: 10, Hash: common.HexToHash("0x01"), Chunked: true, Known: true},
+		{Path: filepath.Join(dir, FileName(20)), Number: 20, Known: true},
+		{Path: filepath.Join(dir, "notes")},
+	}
+	if len(files) != len(want) {
+		t.Fatalf("file count mismatch: have %d, want %d", len(files), len(want))
+	}
+	for i := range want {
+		if files[i] != want[i] {
+			t.Errorf("file %d mismatch: have %+v, want %+v", i, files[i], want[i])
+		}
+	}
+	if files, err := ListDir(filepath.Join(dir, "missing")); err != nil || len(files) != 0 {
+		t.Errorf("missing dir: have %v, %v", files, err)
+	}
+}
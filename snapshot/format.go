@@ -0,0 +1,385 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/MatrixAINetwork/go-matrix/core/types"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+	"github.com/MatrixAINetwork/go-matrix/rlp"
+	"github.com/pkg/errors"
+)
+
+// A chunked snapshot file is laid out as
+//
+//	magic | header record | chunk record ... | manifest record | manifest offset
+//
+// Every record is a one byte kind, a four byte big endian payload length and
+// the rlp encoded payload. The trailing eight bytes hold the file offset of the
+// manifest record, so a reader can load the manifest first and then verify
+// each chunk while streaming through the file.
+
+// FormatVersion is the version of the chunked snapshot format written by Writer.
+const FormatVersion uint32 = 3
+
+// DefaultChunkItems is the number of trie entries written into one state chunk.
+const DefaultChunkItems = 4096
+
+const (
+	maxRecordSize = 256 * 1024 * 1024
+	trailerSize   = 8
+)
+
+var magic = []byte("MANSNAP\x00")
+
+var (
+	ErrNotChunked       = errors.New("snapshot: not a chunked snapshot file")
+	ErrVersion          = errors.New("snapshot: unsupported format version")
+	ErrHeaderMismatch   = errors.New("snapshot: header hash does not match manifest")
+	ErrChunkMismatch    = errors.New("snapshot: chunk hash does not match manifest")
+	ErrChunkCount       = errors.New("snapshot: chunk count does not match manifest")
+	ErrRecordTooLarge   = errors.New("snapshot: record too large")
+	ErrUnexpectedRecord = errors.New("snapshot: unexpected record kind")
+	ErrWriterClosed     = errors.New("snapshot: writer closed")
+)
+
+// ChunkKind identifies the content of a chunk record.
+type ChunkKind uint8
+
+const (
+	ChunkBlock ChunkKind = iota
+	ChunkState
+
+	kindHeader   ChunkKind = 0xfe
+	kindManifest ChunkKind = 0xff
+)
+
+func (k ChunkKind) String() string {
+	switch k {
+	case ChunkBlock:
+		return "block"
+	case ChunkState:
+		return "state"
+	case kindHeader:
+		return "header"
+	case kindManifest:
+		return "manifest"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(k))
+	}
+}
+
+// Section describes one full state contained in the snapshot. Sections with
+// Block set belong to a block of the snapshot and are followed by its block
+// chunk, the others are auxiliary states (e.g. the pre-broadcast states).
+type Section struct {
+	Block  bool
+	Number uint64
+	Roots  []common.CoinRoot
+}
+
+// Header is the first record of a snapshot file.
+type Header struct {
+	Version  uint32
+	ChainID  *big.Int
+	Number   uint64
+	Hash     common.Hash
+	Roots    []common.CoinRoot
+	Sections []Section
+}
+
+// ChunkRef is a manifest entry of one chunk.
+type ChunkRef struct {
+	Kind    ChunkKind
+	Section uint32
+	Size    uint32
+	Hash    common.Hash
+}
+
+// Manifest lists the hash of the header and of every chunk in file order.
+type Manifest struct {
+	Header common.Hash
+	Chunks []ChunkRef
+}
+
+// Hash returns the hash identifying the whole snapshot.
+func (m *Manifest) Hash() common.Hash {
+	return types.RlpHash(m)
+}
+
+// BlockChunk carries a block of the snapshot with its total difficulty and
+// super block sequence.
+type BlockChunk struct {
+	Block *types.Block
+	Td    *big.Int
+	Seq   uint64
+}
+
+// StateChunk carries a bounded part of one shard trie of one coin. The
+// entries of a shard may be spread over several consecutive chunks.
+type StateChunk struct {
+	Coin  string
+	Shard uint32
+	Dump  state.DumpDB
+}
+
+// Writer streams a chunked snapshot into an io.Writer.
+type Writer struct {
+	w        *bufio.Writer
+	offset   uint64
+	manifest Manifest
+	closed   bool
+}
+
+// NewWriter writes the magic and the header and returns a writer for the chunks.
+func NewWriter(w io.Writer, header *Header) (*Writer, error) {
+	header.Version = FormatVersion
+	payload, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		return nil, err
+	}
+	sw := &Writer{
+		w:        bufio.NewWriter(w),
+		manifest: Manifest{Header: crypto.Keccak256Hash(payload)},
+	}
+	if _, err := sw.w.Write(magic); err != nil {
+		return nil, err
+	}
+	sw.offset = uint64(len(magic))
+	if err := sw.writeRecord(kindHeader, payload); err != nil {
+		return nil, err
+	}
+	return sw, nil
+}
+
+// WriteBlock appends a block chunk of the given section.
+func (w *Writer) WriteBlock(section int, chunk *BlockChunk) error {
+	return w.writeChunk(ChunkBlock, section, chunk)
+}
+
+// WriteState appends a state chunk of the given section.
+func (w *Writer) WriteState(section int, chunk *StateChunk) error {
+	return w.writeChunk(ChunkState, section, chunk)
+}
+
+func (w *Writer) writeChunk(kind ChunkKind, section int, val interface{}) error {
+	if w.closed {
+		return ErrWriterClosed
+	}
+	payload, err := rlp.EncodeToBytes(val)
+	if err != nil {
+		return err
+	}
+	if err := w.writeRecord(kind, payload); err != nil {
+		return err
+	}
+	w.manifest.Chunks = append(w.manifest.Chunks, ChunkRef{
+		Kind:    kind,
+		Section: uint32(section),
+		Size:    uint32(len(payload)),
+		Hash:    crypto.Keccak256Hash(payload),
+	})
+	return nil
+}
+
+func (w *Writer) writeRecord(kind ChunkKind, payload []byte) error {
+	if len(payload) > maxRecordSize {
+		return ErrRecordTooLarge
+	}
+	var prefix [5]byte
+	prefix[0] = byte(kind)
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(payload)))
+	if _, err := w.w.Write(prefix[:]); err != nil {
+		return err
+	}
+	if _, err := w.w.Write(payload); err != nil {
+		return err
+	}
+	w.offset += uint64(len(prefix) + len(payload))
+	return nil
+}
+
+// Manifest returns the manifest of the chunks written so far.
+func (w *Writer) Manifest() *Manifest {
+	return &w.manifest
+}
+
+// Close writes the manifest and the trailer and flushes the writer. It does
+// not close the underlying io.Writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return ErrWriterClosed
+	}
+	w.closed = true
+	payload, err := rlp.EncodeToBytes(&w.manifest)
+	if err != nil {
+		return err
+	}
+	manifestOffset := w.offset
+	if err := w.writeRecord(kindManifest, payload); err != nil {
+		return err
+	}
+	var trailer [trailerSize]byte
+	binary.BigEndian.PutUint64(trailer[:], manifestOffset)
+	if _, err := w.w.Write(trailer[:]); err != nil {
+		return err
+	}
+	return w.w.Flush()
+}
+
+// Reader gives verified access to a chunked snapshot.
+type Reader struct {
+	r           io.ReadSeeker
+	chunkOffset int64
+	Header      *Header
+	Manifest    *Manifest
+}
+
+// IsChunked reports whether the file at path is a chunked snapshot.
+func IsChunked(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	head := make([]byte, len(magic))
+	if _, err := io.ReadFull(f, head); err != nil {
+		return false
+	}
+	return bytes.Equal(head, magic)
+}
+
+// NewReader reads and checks the header and the manifest of a snapshot. The
+// chunks are only read and verified by Chunks.
+func NewReader(r io.ReadSeeker) (*Reader, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	head := make([]byte, len(magic))
+	if _, err := io.ReadFull(r, head); err != nil || !bytes.Equal(head, magic) {
+		return nil, ErrNotChunked
+	}
+	kind, payload, err := readRecord(r)
+	if err != nil {
+		return nil, err
+	}
+	if kind != kindHeader {
+		return nil, ErrUnexpectedRecord
+	}
+	header := new(Header)
+	if err := rlp.DecodeBytes(payload, header); err != nil {
+		return nil, err
+	}
+	if header.Version != FormatVersion {
+		return nil, ErrVersion
+	}
+	chunkOffset := int64(len(magic) + 5 + len(payload))
+	headerHash := crypto.Keccak256Hash(payload)
+
+	if _, err := r.Seek(-trailerSize, io.SeekEnd); err != nil {
+		return nil, err
+	}
+	var trailer [trailerSize]byte
+	if _, err := io.ReadFull(r, trailer[:]); err != nil {
+		return nil, err
+	}
+	if _, err := r.Seek(int64(binary.BigEndian.Uint64(trailer[:])), io.SeekStart); err != nil {
+		return nil, err
+	}
+	if kind, payload, err = readRecord(r); err != nil {
+		return nil, err
+	}
+	if kind != kindManifest {
+		return nil, ErrUnexpectedRecord
+	}
+	manifest := new(Manifest)
+	if err := rlp.DecodeBytes(payload, manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Header != headerHash {
+		return nil, ErrHeaderMismatch
+	}
+	for _, ref := range manifest.Chunks {
+		if int(ref.Section) >= len(header.Sections) {
+			return nil, fmt.Errorf("snapshot: chunk references unknown section %d", ref.Section)
+		}
+	}
+	return &Reader{r: r, chunkOffset: chunkOffset, Header: header, Manifest: manifest}, nil
+}
+
+// Chunks streams every chunk in file order, verifies it against the manifest
+// and hands it to fn. Iteration stops at the first error.
+func (sr *Reader) Chunks(fn func(ref ChunkRef, payload []byte) error) error {
+	if _, err := sr.r.Seek(sr.chunkOffset, io.SeekStart); err != nil {
+		return err
+	}
+	br := bufio.NewReader(sr.r)
+	for i, ref := range sr.Manifest.Chunks {
+		kind, payload, err := readRecord(br)
+		if err != nil {
+			return err
+		}
+		if kind == kindManifest {
+			return ErrChunkCount
+		}
+		if kind != ref.Kind || uint32(len(payload)) != ref.Size || crypto.Keccak256Hash(payload) != ref.Hash {
+			return errors.Wrapf(ErrChunkMismatch, "chunk %d", i)
+		}
+		if err := fn(ref, payload); err != nil {
+			return err
+		}
+	}
+	if kind, _, err := readRecord(br); err != nil || kind != kindManifest {
+		return ErrChunkCount
+	}
+	return nil
+}
+
+// Verify checks every chunk against the manifest without decoding them.
+func (sr *Reader) Verify() error {
+	return sr.Chunks(func(ChunkRef, []byte) error { return nil })
+}
+
+// DecodeBlockChunk decodes the payload of a ChunkBlock chunk.
+func DecodeBlockChunk(payload []byte) (*BlockChunk, error) {
+	chunk := new(BlockChunk)
+	if err := rlp.DecodeBytes(payload, chunk); err != nil {
+		return nil, err
+	}
+	return chunk, nil
+}
+
+// DecodeStateChunk decodes the payload of a ChunkState chunk.
+func DecodeStateChunk(payload []byte) (*StateChunk, error) {
+	chunk := new(StateChunk)
+	if err := rlp.DecodeBytes(payload, chunk); err != nil {
+		return nil, err
+	}
+	return chunk, nil
+}
+
+func readRecord(r io.Reader) (ChunkKind, []byte, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return 0, nil, err
+	}
+	size := binary.BigEndian.Uint32(prefix[1:])
+	if size > maxRecordSize {
+		return 0, nil, ErrRecordTooLarge
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return ChunkKind(prefix[0]), payload, nil
+}
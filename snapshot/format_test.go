@@ -0,0 +1,118 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+package snapshot
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/core/state"
+	"github.com/pkg/errors"
+)
+
+func writeTestSnapshot(t *testing.T) []byte {
+	header := &Header{
+		ChainID:  big.NewInt(1),
+		Number:   10,
+		Hash:     common.HexToHash("0x01"),
+		Roots:    []common.CoinRoot{{Cointyp: "MAN", Root: common.HexToHash("0x02")}},
+		Sections: []Section{{}, {Block: true, Number: 10}},
+	}
+	buf := new(bytes.Buffer)
+	w, err := NewWriter(buf, header)
+	if err != nil {
+		t.Fatalf("failed to create writer: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		chunk := &StateChunk{
+			Coin:  "MAN",
+			Shard: uint32(i),
+			Dump: state.DumpDB{
+				Root:    common.HexToHash("0x03"),
+				Account: []state.DumpValue{{Key: []byte{byte(i)}, GetKey: []byte{byte(i)}, Value: []byte{1, 2, 3}}},
+			},
+		}
+		if err := w.WriteState(i%2, chunk); err != nil {
+			t.Fatalf("failed to write chunk %d: %v", i, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close writer: %v", err)
+	}
+	if err := w.WriteState(0, &StateChunk{}); err != ErrWriterClosed {
+		t.Fatalf("write after close: have %v, want %v", err, ErrWriterClosed)
+	}
+	return buf.Bytes()
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	data := writeTestSnapshot(t)
+
+	sr, err := NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to open snapshot: %v", err)
+	}
+	if sr.Header.Version != FormatVersion || sr.Header.Number != 10 || sr.Header.ChainID.Cmp(big.NewInt(1)) != 0 {
+		t.Fatalf("header mismatch: %+v", sr.Header)
+	}
+	if len(sr.Manifest.Chunks) != 3 {
+		t.Fatalf("manifest chunk count mismatch: have %d, want 3", len(sr.Manifest.Chunks))
+	}
+	var shards []uint32
+	err = sr.Chunks(func(ref ChunkRef, payload []byte) error {
+		chunk, err := DecodeStateChunk(payload)
+		if err != nil {
+			return err
+		}
+		shards = append(shards, chunk.Shard)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to iterate chunks: %v", err)
+	}
+	if len(shards) != 3 || shards[0] != 0 || shards[2] != 2 {
+		t.Fatalf("chunk order mismatch: %v", shards)
+	}
+}
+
+func TestSnapshotCorruptChunk(t *testing.T) {
+	data := writeTestSnapshot(t)
+
+	sr, err := NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to open snapshot: %v", err)
+	}
+	// Flip the last payload byte of the first chunk
+	offset := sr.chunkOffset + 5 + int64(sr.Manifest.Chunks[0].Size) - 1
+	data[offset] ^= 0xff
+
+	sr, err = NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to open snapshot: %v", err)
+	}
+	if err := sr.Verify(); errors.Cause(err) != ErrChunkMismatch {
+		t.Fatalf("corrupt chunk: have %v, want %v", err, ErrChunkMismatch)
+	}
+}
+
+func TestSnapshotCorruptHeader(t *testing.T) {
+	data := writeTestSnapshot(t)
+	// The header number is encoded right after the chain id in the header record
+	idx := bytes.Index(data, []byte{0x0a, 0xa0})
+	if idx < 0 {
+		t.Fatalf("header number not found")
+	}
+	data[idx] = 0x0b
+	if _, err := NewReader(bytes.NewReader(data)); err != ErrHeaderMismatch {
+		t.Fatalf("corrupt header: have %v, want %v", err, ErrHeaderMismatch)
+	}
+}
+
+func TestSnapshotNotChunked(t *testing.T) {
+	if _, err := NewReader(bytes.NewReader([]byte("legacy rlp blob"))); err != ErrNotChunked {
+		t.Fatalf("legacy file: have %v, want %v", err, ErrNotChunked)
+	}
+}
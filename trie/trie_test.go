@@ -583,7 +583,7 @@ func tempDB() (string, *Database) {
 	if err != nil {
 		panic(fmt.Sprintf("can't create temporary directory: %v", err))
 	}
-	diskdb, err := mandb.NewLDBDatabase(dir, 256, 0)
+	diskdb, err := mandb.NewLDBDatabase(dir, 256, 0, 2)
 	if err != nil {
 		panic(fmt.Sprintf("can't create temporary database: %v", err))
 	}
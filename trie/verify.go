@@ -0,0 +1,146 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package trie
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/crypto"
+)
+
+// errNodeHashMismatch is returned for a node whose content does not hash to
+// the reference of its parent.
+var errNodeHashMismatch = errors.New("node content does not match its hash")
+
+// NodeError is a trie node that is missing from the database or whose content
+// is corrupt.
+type NodeError struct {
+	Hash    common.Hash // Hash the node is referenced by
+	Path    []byte      // Nibbles leading to the node
+	Missing bool        // Missing from the database, otherwise corrupt
+	Err     error
+}
+
+func (err *NodeError) Error() string {
+	if err.Missing {
+		return fmt.Sprintf("missing trie node %x (path %x)", err.Hash, err.Path)
+	}
+	return fmt.Sprintf("corrupt trie node %x (path %x): %v", err.Hash, err.Path, err.Err)
+}
+
+// VerifyTrie walks every node of the trie of root in the database and checks
+// that it is present and hashes to its reference. Unlike the node iterator it
+// carries on past a missing or corrupt node, only the subtrie below the node is
+// skipped. Every leaf value is passed to the callback, an error returned by
+// the callback is reported as corruption of the node holding the leaf.
+//
+// It returns the number of nodes read and the nodes that failed. A node shared
+// by several parents is reported once.
+func VerifyTrie(root common.Hash, database DatabaseReader, callback LeafCallback) (int, []*NodeError) {
+	if root == emptyRoot || root == (common.Hash{}) {
+		return 0, nil
+	}
+	v := &trieVerifier{database: database, callback: callback}
+	v.verify(root, nil)
+	return v.nodes, v.errs
+}
+
+// MarkTrie adds the hashes of the nodes of the trie of root to marked, walking
+// only the subtries whose root is not marked yet. Every leaf value walked is
+// passed to the callback. It fails if a node is missing or corrupt.
+func MarkTrie(root common.Hash, database DatabaseReader, marked map[common.Hash]struct{}, callback LeafCallback) error {
+	if root == emptyRoot || root == (common.Hash{}) {
+		return nil
+	}
+	v := &trieVerifier{database: database, callback: callback, marked: marked}
+	v.verify(root, nil)
+	if len(v.errs) != 0 {
+		return v.errs[0]
+	}
+	return nil
+}
+
+type trieVerifier struct {
+	database DatabaseReader
+	callback LeafCallback
+	marked   map[common.Hash]struct{} // Nodes not walked again, nil to walk all
+	nodes    int
+	errs     []*NodeError
+	failed   map[common.Hash]struct{} // Nodes already reported
+}
+
+// verify reads the node of hash and walks its children.
+func (v *trieVerifier) verify(hash common.Hash, path []byte) {
+	if v.marked != nil {
+		if _, ok := v.marked[hash]; ok {
+			return
+		}
+	}
+	if _, ok := v.failed[hash]; ok {
+		return
+	}
+	blob, err := v.database.Get(hash[:])
+	if err != nil || len(blob) == 0 {
+		v.fail(&NodeError{Hash: hash, Path: path, Missing: true, Err: err})
+		return
+	}
+	v.nodes++
+	if crypto.Keccak256Hash(blob) != hash {
+		v.fail(&NodeError{Hash: hash, Path: path, Err: errNodeHashMismatch})
+		return
+	}
+	n, err := decodeNode(hash[:], blob, 0)
+	if err != nil {
+		v.fail(&NodeError{Hash: hash, Path: path, Err: err})
+		return
+	}
+	if v.marked != nil {
+		v.marked[hash] = struct{}{}
+	}
+	v.walk(n, hash, path)
+}
+
+// walk visits a decoded node, parent is the hash of the stored node that
+// embeds it.
+func (v *trieVerifier) walk(n node, parent common.Hash, path []byte) {
+	switch n := n.(type) {
+	case *shortNode:
+		v.walk(n.Val, parent, appendPath(path, n.Key...))
+	case *fullNode:
+		for i, child := range n.Children {
+			if child != nil {
+				v.walk(child, parent, appendPath(path, byte(i)))
+			}
+		}
+	case hashNode:
+		v.verify(common.BytesToHash(n), path)
+	case valueNode:
+		if v.callback == nil {
+			return
+		}
+		if err := v.callback(n, parent); err != nil {
+			v.fail(&NodeError{Hash: parent, Path: path, Err: err})
+		}
+	}
+}
+
+// fail reports a node unless it was reported already.
+func (v *trieVerifier) fail(err *NodeError) {
+	if _, ok := v.failed[err.Hash]; ok {
+		return
+	}
+	if v.failed == nil {
+		v.failed = make(map[common.Hash]struct{})
+	}
+	v.failed[err.Hash] = struct{}{}
+	v.errs = append(v.errs, err)
+}
+
+func appendPath(path []byte, nibbles ...byte) []byte {
+	next := make([]byte, 0, len(path)+len(nibbles))
+	return append(append(next, path...), nibbles...)
+}
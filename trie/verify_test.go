@@ -0,0 +1,144 @@
+// Copyright (c) 2018 The MATRIX Authors
+// Distributed under the MIT software license, see the accompanying
+// file COPYING or http://www.opensource.org/licenses/mit-license.php
+
+package trie
+
+import (
+	"testing"
+
+	"github.com/MatrixAINetwork/go-matrix/common"
+	"github.com/MatrixAINetwork/go-matrix/mandb"
+)
+
+// makeVerifyTrie commits the test trie to a disk database.
+func makeVerifyTrie(t *testing.T) (*mandb.MemDatabase, common.Hash, int) {
+	triedb, trie, content := makeTestTrie()
+	root := trie.Hash()
+	if err := triedb.Commit(root, false); err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	return triedb.DiskDB().(*mandb.MemDatabase), root, len(content)
+}
+
+// Tests that a complete trie verifies and every leaf is reported.
+func TestVerifyTrieComplete(t *testing.T) {
+	diskdb, root, entries := makeVerifyTrie(t)
+
+	leaves := 0
+	nodes, errs := VerifyTrie(root, diskdb, func(leaf []byte, parent common.Hash) error {
+		leaves++
+		return nil
+	})
+	if len(errs) != 0 {
+		t.Fatalf("complete trie failed: %v", errs[0])
+	}
+	// Subtries shared by several parents are read once per reference
+	if nodes < diskdb.Len() {
+		t.Errorf("nodes read mismatch: have %d, want at least %d", nodes, diskdb.Len())
+	}
+	if leaves != entries {
+		t.Errorf("leaves mismatch: have %d, want %d", leaves, entries)
+	}
+	if nodes, errs := VerifyTrie(emptyRoot, diskdb, nil); nodes != 0 || len(errs) != 0 {
+		t.Errorf("empty trie: have %d nodes and %d errors", nodes, len(errs))
+	}
+}
+
+// Tests that missing and corrupt nodes are all reported once and the rest of
+// the trie is still walked.
+func TestVerifyTrieDamaged(t *testing.T) {
+	diskdb, root, _ := makeVerifyTrie(t)
+
+	// Damage two nodes neither of which is below the other
+	subtrie := func(hash common.Hash) map[common.Hash]struct{} {
+		nodes := make(map[common.Hash]struct{})
+		if err := MarkTrie(hash, diskdb, nodes, nil); err != nil {
+			t.Fatalf("failed to mark subtrie: %v", err)
+		}
+		return nodes
+	}
+	var hashes []common.Hash
+	for _, key := range diskdb.Keys() {
+		if hash := common.BytesToHash(key); hash != root {
+			hashes = append(hashes, hash)
+		}
+	}
+	var missing, corrupt common.Hash
+search:
+	for _, a := range hashes {
+		below := subtrie(a)
+		for _, b := range hashes {
+			if _, ok := below[b]; ok {
+				continue
+			}
+			if _, ok := subtrie(b)[a]; !ok {
+				missing, corrupt = a, b
+				break search
+			}
+		}
+	}
+	if corrupt == (common.Hash{}) {
+		t.Fatalf("no nodes to damage")
+	}
+	diskdb.Delete(missing[:])
+	blob, _ := diskdb.Get(corrupt[:])
+	blob = common.CopyBytes(blob)
+	blob[len(blob)-1] ^= 0xff
+	diskdb.Put(corrupt[:], blob)
+
+	nodes, errs := VerifyTrie(root, diskdb, nil)
+	if len(errs) != 2 {
+		t.Fatalf("errors mismatch: have %d, want 2: %v", len(errs), errs)
+	}
+	for _, err := range errs {
+		switch err.Hash {
+		case missing:
+			if !err.Missing {
+				t.Errorf("node %x: reported corrupt, want missing", err.Hash)
+			}
+		case corrupt:
+			if err.Missing || err.Err != errNodeHashMismatch {
+				t.Errorf("node %x: have %v, want hash mismatch", err.Hash, err)
+			}
+		default:
+			t.Errorf("unexpected failing node %x: %v", err.Hash, err)
+		}
+	}
+	if nodes < 2 {
+		t.Errorf("walk stopped early: %d nodes read", nodes)
+	}
+}
+
+// Tests that marking reaches every node of the trie once and skips subtries
+// already marked.
+func TestMarkTrie(t *testing.T) {
+	diskdb, root, _ := makeVerifyTrie(t)
+
+	marked := make(map[common.Hash]struct{})
+	if err := MarkTrie(root, diskdb, marked, nil); err != nil {
+		t.Fatalf("failed to mark trie: %v", err)
+	}
+	if len(marked) != diskdb.Len() {
+		t.Errorf("marked nodes mismatch: have %d, want %d", len(marked), diskdb.Len())
+	}
+	leaves := 0
+	if err := MarkTrie(root, diskdb, marked, func(leaf []byte, parent common.Hash) error {
+		leaves++
+		return nil
+	}); err != nil || leaves != 0 {
+		t.Errorf("marked trie walked again: %d leaves, err %v", leaves, err)
+	}
+
+	for _, key := range diskdb.Keys() {
+		if common.BytesToHash(key) != root {
+			diskdb.Delete(key)
+			break
+		}
+	}
+	if err := MarkTrie(root, diskdb, make(map[common.Hash]struct{}), nil); err == nil {
+		t.Errorf("trie with missing node marked")
+	} else if nodeErr, ok := err.(*NodeError); !ok || !nodeErr.Missing {
+		t.Errorf("error mismatch: have %v, want missing node", err)
+	}
+}